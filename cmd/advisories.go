@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var advisoriesCmd = &cobra.Command{
+	Use:   "advisories",
+	Short: "Check the server software and mods for known vulnerabilities",
+	Long: `Matches the server software and the versions the jars in mods/ and
+plugins/ declare against the built-in advisory database and the entries of
+.mcserver/advisories.json. The same check runs on every start and raises a
+critical event per finding.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runWorldAction("advisories", true)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(advisoriesCmd)
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"mcserver-manager/internal/audit"
+)
+
+var (
+	auditSince  time.Duration
+	auditSource string
+	auditActor  string
+	auditKind   string
+	auditLimit  int
+	auditJSON   bool
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show the audit log of console commands and administrative actions",
+	Args:  cobra.NoArgs,
+	Run:   runAudit,
+}
+
+func init() {
+	auditCmd.Flags().DurationVar(&auditSince, "since", 0, "Only show entries newer than this (e.g. 24h)")
+	auditCmd.Flags().StringVar(&auditSource, "source", "", "Filter by source: tui, api, discord, rules, cli, gitops, webhook, chat")
+	auditCmd.Flags().StringVar(&auditActor, "actor", "", "Filter by user or token name")
+	auditCmd.Flags().StringVar(&auditKind, "kind", "", "Filter by kind: command or action")
+	auditCmd.Flags().IntVar(&auditLimit, "limit", 50, "Show at most this many of the newest entries (0 for all)")
+	auditCmd.Flags().BoolVar(&auditJSON, "json", false, "Print entries as JSON lines")
+	rootCmd.AddCommand(auditCmd)
+}
+
+func runAudit(cmd *cobra.Command, args []string) {
+	absServerDir, err := filepath.Abs(serverDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving server directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	filter := audit.Filter{
+		Source: audit.Source(auditSource),
+		Actor:  auditActor,
+		Kind:   audit.Kind(auditKind),
+		Limit:  auditLimit,
+	}
+	if auditSince > 0 {
+		filter.Since = time.Now().Add(-auditSince)
+	}
+
+	entries, err := audit.Read(absServerDir, filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if auditJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range entries {
+			enc.Encode(e)
+		}
+		return
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No audit entries")
+		return
+	}
+	for _, e := range entries {
+		outcome := ""
+		switch {
+		case e.Denied:
+			outcome = "  ⛔ denied: " + e.Detail
+		case e.Error != "":
+			outcome = "  ❌ " + e.Error
+		case e.Detail != "":
+			outcome = "  (" + e.Detail + ")"
+		}
+		fmt.Printf("%s  %-7s %-16s %-7s %s%s\n",
+			e.Time.Format("2006-01-02 15:04:05"), e.Source, e.Actor, e.Kind, e.Action, outcome)
+	}
+}
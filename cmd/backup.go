@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "List backups, show what they hold and verify them",
+}
+
+var backupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List backups, newest first, with sizes and worlds",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runWorldAction("backup list", true)
+	},
+}
+
+var backupInfoCmd = &cobra.Command{
+	Use:   "info <name>",
+	Short: "Show a backup's manifest, worlds, file counts and sizes",
+	Long: `Reads a backup's zip directory and manifest without extracting anything:
+when it was taken, the modpack and server software it was taken from, and
+for each world its file count, compressed and uncompressed size, version
+and seed. The name is one from 'mcserver backup list', with or without .zip.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runWorldAction("backup info "+args[0], true)
+	},
+}
+
+var backupVerifyCmd = &cobra.Command{
+	Use:   "verify [name]",
+	Short: "Check backups for corruption before you need one",
+	Long: `Checks every backup, or the named one: its SHA-256 against the one written
+next to it when it was made, every file in the zip against its CRC-32, and
+that the worlds its manifest lists are in it. Exits with an error if any
+backup is damaged, so it can run from cron.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runWorldAction(strings.Join(append([]string{"backup verify"}, args...), " "), true)
+	},
+}
+
+func init() {
+	backupCmd.AddCommand(backupListCmd)
+	backupCmd.AddCommand(backupInfoCmd)
+	backupCmd.AddCommand(backupVerifyCmd)
+	rootCmd.AddCommand(backupCmd)
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"mcserver-manager/internal/server"
+	"mcserver-manager/internal/stats"
+)
+
+var (
+	benchLabel        string
+	benchLoadChunks   int
+	benchLoadDuration time.Duration
+	benchTimeout      time.Duration
+	benchHistory      int
+	benchConsole      bool
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Time a server start and compare it with previous runs",
+	Long: `Starts the server with the usual flags, measures the time until it is
+ready and its peak memory and CPU on the way, then stops it. With
+--load-chunks it first generates that many fresh chunks one million blocks
+out on the X axis and measures TPS, MSPT and memory while it does (use
+"world trim" to remove them afterwards). Results are kept in
+.mcserver/bench.jsonl and shown next to the previous runs, which helps
+when tuning JVM flags or pruning mods.`,
+	Args: cobra.NoArgs,
+	Run:  runBench,
+}
+
+func init() {
+	benchCmd.Flags().StringVar(&benchLabel, "label", "", "Name for this run, e.g. the JVM flags being tried")
+	benchCmd.Flags().IntVar(&benchLoadChunks, "load-chunks", 0, "Generate this many chunks after startup and measure the server meanwhile")
+	benchCmd.Flags().DurationVar(&benchLoadDuration, "load-duration", time.Minute, "How long to measure the chunk generation burst")
+	benchCmd.Flags().DurationVar(&benchTimeout, "timeout", 15*time.Minute, "Give up if the server has not started by then")
+	benchCmd.Flags().IntVar(&benchHistory, "history", 5, "Number of previous runs to compare with")
+	benchCmd.Flags().BoolVar(&benchConsole, "console", false, "Print the server console while benchmarking")
+	rootCmd.AddCommand(benchCmd)
+}
+
+func runBench(cmd *cobra.Command, args []string) {
+	config := buildConfig()
+	// Nothing should run besides the start being measured
+	config.AutoRestart, config.BackupEnabled = false, false
+
+	previous, err := server.BenchHistory(config.ServerDir, benchHistory)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading benchmark history: %v\n", err)
+		os.Exit(1)
+	}
+
+	srv := server.New(config)
+	go func() {
+		for line := range srv.OutputChan() {
+			if benchConsole {
+				fmt.Println(line)
+			}
+		}
+	}()
+
+	fmt.Println("Starting server...")
+	result, err := srv.Bench(server.BenchOptions{
+		Label:        benchLabel,
+		LoadChunks:   benchLoadChunks,
+		LoadDuration: benchLoadDuration,
+		Timeout:      benchTimeout,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Benchmark failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println()
+	printBenchRuns(append(previous, *result))
+	if len(previous) > 0 {
+		last := previous[len(previous)-1]
+		fmt.Printf("\nCompared with the previous run: boot %s, peak memory %s\n",
+			benchDelta(last.Boot.Seconds(), result.Boot.Seconds(), "s"),
+			benchDelta(float64(last.PeakMemory)/1024/1024, float64(result.PeakMemory)/1024/1024, " MB"))
+	}
+}
+
+func printBenchRuns(runs []server.BenchResult) {
+	fmt.Printf("%-16s %-20s %5s %8s %8s %9s %7s  %s\n", "TIME", "LABEL", "MODS", "PREPARE", "BOOT", "PEAK MEM", "CPU", "LOAD")
+	for _, r := range runs {
+		load := "-"
+		if r.Load != nil {
+			load = fmt.Sprintf("%d chunks: %.1f avg / %.1f min TPS, %.0f ms max, %s", r.Load.Chunks, r.Load.AvgTPS, r.Load.MinTPS, r.Load.MaxMSPT, stats.FormatBytes(r.Load.PeakMemory))
+		}
+		label := r.Label
+		if label == "" {
+			label = "-"
+		}
+		fmt.Printf("%-16s %-20s %5d %8s %8s %9s %6.0f%%  %s\n",
+			r.Time.Format("2006-01-02 15:04"), label, r.Mods,
+			fmt.Sprintf("%.1fs", r.Prepare.Seconds()), fmt.Sprintf("%.1fs", r.Boot.Seconds()),
+			stats.FormatBytes(r.PeakMemory), r.PeakCPU, load)
+	}
+}
+
+func benchDelta(before, after float64, unit string) string {
+	if before == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%+.1f%s (%+.0f%%)", after-before, unit, (after-before)/before*100)
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"mcserver-manager/internal/curseforge"
+	"mcserver-manager/internal/server"
+	"mcserver-manager/internal/stats"
+)
+
+var (
+	cachePruneDays    int
+	cachePruneMaxSize string
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the download cache shared by the servers on this host",
+	Long: `Modpacks and mods downloaded from CurseForge are kept in a cache, keyed by
+their CurseForge file ID, so reinstalling a pack or running several servers
+with the same mods downloads each file once. Cached files are checked
+against CurseForge's length and hash before each use.
+
+The cache is ~/.cache/mcserver on Linux (the user cache directory
+elsewhere); set ` + curseforge.CacheEnv + ` to move it, or to "off" to turn it off.`,
+}
+
+var cacheInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Show where the cache is and how much it holds",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := curseforge.CacheDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if dir == "" {
+			fmt.Printf("The download cache is off (%s=off)\n", curseforge.CacheEnv)
+			return
+		}
+		files, err := curseforge.CacheFiles()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		var total int64
+		for _, f := range files {
+			total += f.Size
+		}
+		fmt.Printf("Cache: %s\n", dir)
+		fmt.Printf("  %d file(s), %s\n", len(files), stats.FormatBytes(uint64(total)))
+		if len(files) > 0 {
+			fmt.Printf("  least recently used %s ago\n", stats.FormatDurationShort(time.Since(files[0].LastUsed)))
+		}
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove cached downloads not used recently",
+	Long: `Removes cached downloads no server has used for --older-than days, then
+with --max-size the least recently used ones until the cache fits.
+
+Examples:
+  mcserver cache prune
+  mcserver cache prune --older-than 0 --max-size 20G`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var maxSize uint64
+		if cachePruneMaxSize != "" {
+			var err error
+			if maxSize, err = server.ParseMemory(cachePruneMaxSize); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: --max-size: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		result, err := curseforge.PruneCache(time.Duration(cachePruneDays)*24*time.Hour, int64(maxSize))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Prune failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Removed %d file(s), freeing %s\n", result.Removed, stats.FormatBytes(uint64(result.Freed)))
+		fmt.Printf("  %d file(s) kept, %s\n", result.Kept, stats.FormatBytes(uint64(result.KeptBytes)))
+		if result.RemoveFails > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: %d file(s) could not be removed\n", result.RemoveFails)
+		}
+	},
+}
+
+func init() {
+	cachePruneCmd.Flags().IntVar(&cachePruneDays, "older-than", 90, "Remove downloads unused for this many days (0 keeps them)")
+	cachePruneCmd.Flags().StringVar(&cachePruneMaxSize, "max-size", "", "Then remove the least recently used until the cache fits, e.g. 20G")
+
+	cacheCmd.AddCommand(cacheInfoCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// Config files looked for in the working directory when --config is not given
+var configFileNames = []string{"mcserver.yaml", "mcserver.yml", "mcserver.toml"}
+
+var configFile string
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Read settings from this YAML or TOML file (default mcserver.yaml or mcserver.toml if present); flags given on the command line win")
+}
+
+// decodeFile reads a JSON, YAML or TOML file, by its extension
+func decodeFile(path string) (any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var doc yaml.Node
+		if err = yaml.Unmarshal(data, &doc); err == nil && len(doc.Content) > 0 {
+			value, err = yamlValue(doc.Content[0])
+		}
+	case ".toml":
+		var table map[string]any
+		err = toml.Unmarshal(data, &table)
+		value = tomlValue(table)
+	case ".json":
+		err = json.Unmarshal(data, &value)
+	default:
+		return nil, fmt.Errorf("%s: unknown format, use .yaml, .toml or .json", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return value, nil
+}
+
+// yamlValue converts a YAML node like yaml.Unmarshal would, except that
+// decimals keep their text: "mc-version: 1.20" is 1.20, not 1.2
+func yamlValue(node *yaml.Node) (any, error) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return yamlValue(node.Alias)
+	case yaml.MappingNode:
+		table := make(map[string]any, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value, err := yamlValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			table[node.Content[i].Value] = value
+		}
+		return table, nil
+	case yaml.SequenceNode:
+		list := make([]any, 0, len(node.Content))
+		for _, item := range node.Content {
+			value, err := yamlValue(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+	}
+	if node.Tag == "!!float" {
+		return json.Number(node.Value), nil
+	}
+	var value any
+	err := node.Decode(&value)
+	return value, err
+}
+
+// tomlValue turns arrays of tables, which the TOML decoder returns as
+// []map[string]any, into plain lists like the other formats have
+func tomlValue(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, v := range value {
+			value[key] = tomlValue(v)
+		}
+	case []map[string]any:
+		list := make([]any, len(value))
+		for i, table := range value {
+			list[i] = tomlValue(table)
+		}
+		return list
+	case []any:
+		for i, v := range value {
+			value[i] = tomlValue(v)
+		}
+	}
+	return value
+}
+
+// findConfigFile returns --config, or the first default config file in the
+// working directory, or ""
+func findConfigFile() string {
+	if configFile != "" {
+		return configFile
+	}
+	for _, name := range configFileNames {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// flattenSettings turns nested tables into flag names, so that
+// "backup: {enabled: true}" sets --backup-enabled. Underscores count as
+// dashes.
+func flattenSettings(prefix string, table map[string]any, out map[string]any) {
+	for key, value := range table {
+		name := strings.ReplaceAll(strings.ToLower(key), "_", "-")
+		if prefix != "" {
+			name = prefix + "-" + name
+		}
+		if nested, ok := value.(map[string]any); ok && name != "servers" {
+			flattenSettings(name, nested, out)
+			continue
+		}
+		out[name] = value
+	}
+}
+
+// applyConfigFile sets the flags of cmd that the command line left alone
+// from the config file. Settings for flags of other commands are skipped;
+// settings no command knows are an error.
+func applyConfigFile(cmd *cobra.Command) error {
+	path := findConfigFile()
+	if path == "" {
+		return nil
+	}
+	value, err := decodeFile(path)
+	if err != nil {
+		return err
+	}
+	table, ok := value.(map[string]any)
+	if !ok {
+		if value == nil {
+			return nil
+		}
+		return fmt.Errorf("%s: expected settings as key: value", path)
+	}
+	settings := map[string]any{}
+	flattenSettings("", table, settings)
+
+	// An inline list of servers stands in for a --servers file
+	if list, ok := settings["servers"].([]any); ok {
+		delete(settings, "servers")
+		if !cmd.Flags().Changed("servers") && len(list) > 0 {
+			serversFile = path
+		}
+	}
+
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			if rootCmd.Flags().Lookup(name) == nil && rootCmd.PersistentFlags().Lookup(name) == nil {
+				return fmt.Errorf("%s: unknown setting %q (see mcserver --help)", path, name)
+			}
+			continue
+		}
+		if flag.Changed || name == "config" {
+			continue
+		}
+		if err := setFlag(cmd.Flags(), flag, settings[name]); err != nil {
+			return fmt.Errorf("%s: %s: %w", path, name, err)
+		}
+	}
+	return nil
+}
+
+// setFlag sets a flag from a config value; a list sets a repeatable flag
+// once per item
+func setFlag(flags *pflag.FlagSet, flag *pflag.Flag, value any) error {
+	list, ok := value.([]any)
+	if !ok {
+		if _, isMap := value.(map[string]any); isMap {
+			return fmt.Errorf("expected a value, not a table")
+		}
+		return flags.Set(flag.Name, fmt.Sprint(value))
+	}
+	if _, repeatable := flag.Value.(pflag.SliceValue); !repeatable {
+		return fmt.Errorf("takes one value, not a list")
+	}
+	if len(list) == 0 {
+		return flag.Value.(pflag.SliceValue).Replace(nil)
+	}
+	for _, item := range list {
+		if err := flags.Set(flag.Name, fmt.Sprint(item)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"mcserver-manager/internal/configdrift"
+)
+
+var (
+	configHistoryFile  string
+	configHistoryLimit int
+	configHistoryJSON  bool
+)
+
+var configHistoryCmd = &cobra.Command{
+	Use:   "config-history",
+	Short: "Show recorded changes to server.properties, the whitelist, ops and ban lists",
+	Long: `The manager snapshots server.properties, whitelist.json, ops.json and the
+ban lists, and records a diff each time one changes: on start, while the
+server runs and when it changes them itself.`,
+	Args: cobra.NoArgs,
+	Run:  runConfigHistory,
+}
+
+func init() {
+	configHistoryCmd.Flags().StringVar(&configHistoryFile, "file", "", "Only show changes to this file (e.g. server.properties)")
+	configHistoryCmd.Flags().IntVar(&configHistoryLimit, "limit", 50, "Show at most this many of the newest changes (0 for all)")
+	configHistoryCmd.Flags().BoolVar(&configHistoryJSON, "json", false, "Print changes as JSON lines")
+	rootCmd.AddCommand(configHistoryCmd)
+}
+
+func runConfigHistory(cmd *cobra.Command, args []string) {
+	absServerDir, err := filepath.Abs(serverDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving server directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	changes, err := configdrift.History(absServerDir, configHistoryFile, configHistoryLimit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if configHistoryJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, c := range changes {
+			enc.Encode(c)
+		}
+		return
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("No config changes recorded")
+		return
+	}
+	for _, c := range changes {
+		fmt.Printf("%s  %-19s %s\n", c.Time.Format("2006-01-02 15:04:05"), c.File, c.When)
+		for _, line := range c.Diff {
+			fmt.Printf("    %s\n", line)
+		}
+	}
+}
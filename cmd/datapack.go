@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"mcserver-manager/internal/world"
+)
+
+var datapackCmd = &cobra.Command{
+	Use:   "datapack",
+	Short: "Install, enable, disable and remove datapacks in the active world",
+}
+
+var datapackListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List installed datapacks and whether they are enabled",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runWorldAction("datapack list", true)
+	},
+}
+
+var datapackInstallCmd = &cobra.Command{
+	Use:   "install <zip|url>",
+	Short: "Copy a datapack into world/datapacks",
+	Long: `Checks the zip has a pack.mcmeta and data/ folder and copies it into the
+active world's datapacks folder. Through --remote the running server reloads
+and the pack is verified as enabled; offline it is enabled on the next start.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		source := args[0]
+		if remoteAddr == "" && !world.IsURL(source) {
+			abs, err := filepath.Abs(source)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			source = abs
+		}
+		runWorldAction("datapack install "+source, false)
+	},
+}
+
+var datapackEnableCmd = &cobra.Command{
+	Use:   "enable <name>",
+	Short: "Enable a datapack on the running server (needs --remote)",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runWorldAction("datapack enable "+args[0], false)
+	},
+}
+
+var datapackDisableCmd = &cobra.Command{
+	Use:   "disable <name>",
+	Short: "Disable a datapack on the running server (needs --remote)",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runWorldAction("datapack disable "+args[0], false)
+	},
+}
+
+var datapackRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Disable and delete a datapack",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runWorldAction("datapack remove "+args[0], false)
+	},
+}
+
+func init() {
+	datapackCmd.AddCommand(datapackListCmd)
+	datapackCmd.AddCommand(datapackInstallCmd)
+	datapackCmd.AddCommand(datapackEnableCmd)
+	datapackCmd.AddCommand(datapackDisableCmd)
+	datapackCmd.AddCommand(datapackRemoveCmd)
+	rootCmd.AddCommand(datapackCmd)
+}
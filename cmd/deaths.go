@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var deathsCmd = &cobra.Command{
+	Use:   "deaths [player]",
+	Short: "List where players died",
+	Long: `Lists where each player last died, or the last deaths of one player. The
+manager asks the server for the location of every death it sees in the
+console (LastDeathLocation on 1.19+, the player's position before that)
+and keeps the last 10 per player in .mcserver/deaths.json.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runWorldAction(strings.Join(append([]string{"deaths"}, args...), " "), true)
+	},
+}
+
+var backCmd = &cobra.Command{
+	Use:   "back <player> [n]",
+	Short: "Teleport a player to where they died (needs --remote)",
+	Long: `Teleports a player to where they last died, or to their death n deaths
+ago as numbered by "mcserver deaths <player>". Use :back in the TUI for a
+local server.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if remoteAddr == "" {
+			fmt.Fprintln(os.Stderr, "Error: teleports go through the manager running the server; use --remote or :back in its TUI")
+			os.Exit(1)
+		}
+		runWorldAction("back "+strings.Join(args, " "), false)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(deathsCmd)
+	rootCmd.AddCommand(backCmd)
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"mcserver-manager/internal/i18n"
+)
+
+var initForce bool
+
+var initCmd = &cobra.Command{
+	Use:   "init [mcserver.yaml|mcserver.toml]",
+	Short: "Write a commented config file to start from",
+	Long: `Writes a config file listing the common settings with their defaults,
+commented out, and the ones given on the command line filled in:
+
+  mcserver init --modpack 123456 --ram-max 8G
+
+The format follows the extension, YAML by default. Any flag from
+'mcserver --help' is a valid key, and flags given when starting the
+server win over the file.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runInit,
+}
+
+// templateSection is a group of settings in the init template
+type templateSection struct {
+	title string
+	keys  []string
+}
+
+// templateSections are the settings the init template lists; the rest are
+// in --help. Keys that are always written uncommented are in templateActive.
+var templateSections = []templateSection{
+	{"Server", []string{"server-dir", "ram-min", "ram-max", "port", "java", "java-args", "accept-eula"}},
+	{"Modpack or server jar", []string{"modpack", "modpack-version", "mc-version", "server-type"}},
+	{"Players", []string{"op", "whitelist-url", "chat-commands", "hide-private-messages"}},
+	{"Restarts", []string{"auto-restart", "crash-limit", "start-timeout", "restart-policy", "restart-cron"}},
+	{"Backups", []string{"backup-enabled", "backup-interval", "backup-dir", "max-backups", "backup-exclude", "backup-targets", "backup-remote-keep"}},
+	{"Cross-play and proxies", []string{"bedrock-crossplay", "bedrock-port", "via-version", "velocity-dir", "proxy-ip"}},
+	{"Monitoring", []string{"health-interval", "tps-interval", "player-list-interval", "lag-threshold", "disk-alert", "cost-per-hour"}},
+	{"Remote control", []string{"agent-listen", "api-port", "web", "discord-guild", "discord-role"}},
+	{"Display", []string{"no-tui", "lang", "event-style", "event-panel"}},
+}
+
+var templateActive = map[string]bool{"server-dir": true, "ram-min": true, "ram-max": true, "port": true}
+
+func init() {
+	initCmd.Flags().BoolVar(&initForce, "force", false, "Overwrite an existing file")
+	rootCmd.AddCommand(initCmd)
+}
+
+func runInit(cmd *cobra.Command, args []string) {
+	path := configFileNames[0]
+	if len(args) > 0 {
+		path = args[0]
+	}
+	toml := false
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		toml = true
+	case ".yaml", ".yml":
+	default:
+		fmt.Fprintln(os.Stderr, "Error: the config file must end in .yaml, .yml or .toml")
+		os.Exit(1)
+	}
+	if _, err := os.Stat(path); err == nil && !initForce {
+		fmt.Fprintf(os.Stderr, "Error: %s already exists (use --force to overwrite it)\n", path)
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(path, []byte(renderTemplate(cmd.Flags(), toml)), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(i18n.T("Wrote %s; edit it and run mcserver from this directory", path))
+}
+
+// renderTemplate writes the template sections, with each setting's flag
+// help as a comment and its current value
+func renderTemplate(flags *pflag.FlagSet, toml bool) string {
+	sep := ": "
+	if toml {
+		sep = " = "
+	}
+	var b strings.Builder
+	b.WriteString("# mcserver config. Every flag from 'mcserver --help' can be set here\n")
+	b.WriteString("# by its name; flags on the command line win over this file.\n")
+	for _, section := range templateSections {
+		fmt.Fprintf(&b, "\n# --- %s ---\n", section.title)
+		for _, key := range section.keys {
+			flag := flags.Lookup(key)
+			if flag == nil {
+				continue
+			}
+			fmt.Fprintf(&b, "\n# %s\n", flag.Usage)
+			prefix := "# "
+			if templateActive[key] || flag.Changed {
+				prefix = ""
+			}
+			fmt.Fprintf(&b, "%s%s%s%s\n", prefix, key, sep, templateValue(flag))
+		}
+	}
+	b.WriteString(`
+# --- Several servers ---
+# Instead of one server, run several; see the README's Multiple servers.
+`)
+	if toml {
+		b.WriteString("# [[servers]]\n# name = \"survival\"\n# dir = \"survival\"\n# port = 25565\n")
+	} else {
+		b.WriteString("# servers:\n#   - name: survival\n#     dir: survival\n#     port: 25565\n")
+	}
+	return b.String()
+}
+
+// templateValue renders a flag's value as YAML and TOML both read it
+func templateValue(flag *pflag.Flag) string {
+	if slice, ok := flag.Value.(pflag.SliceValue); ok {
+		var items []string
+		for _, item := range slice.GetSlice() {
+			items = append(items, strconv.Quote(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	switch flag.Value.Type() {
+	case "bool", "int", "int64", "uint", "float64":
+		return flag.Value.String()
+	}
+	return strconv.Quote(flag.Value.String())
+}
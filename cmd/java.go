@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"mcserver-manager/internal/jdk"
+)
+
+var javaListJSON bool
+
+var javaCmd = &cobra.Command{
+	Use:   "java",
+	Short: "Find the Java installations on this machine",
+}
+
+var javaListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the Java installations found, newest first",
+	Long: `Looks for Java in JAVA_HOME, on PATH, in /usr/lib/jvm, /usr/java and
+/opt on Linux, /Library/Java/JavaVirtualMachines and Homebrew on macOS,
+the Program Files folders of Oracle, Adoptium, Microsoft, Zulu, Corretto
+and others on Windows, and in SDKMAN! and IntelliJ's ~/.jdks.
+
+Any of them can be picked by major version with --java 17. A server the
+configured Java cannot run gets the lowest version it supports from this
+list.`,
+	Args: cobra.NoArgs,
+	Run:  runJavaList,
+}
+
+func init() {
+	javaListCmd.Flags().BoolVar(&javaListJSON, "json", false, "Print the installations as JSON")
+	javaCmd.AddCommand(javaListCmd)
+	rootCmd.AddCommand(javaCmd)
+}
+
+func runJavaList(cmd *cobra.Command, args []string) {
+	installs := jdk.Discover()
+	if javaListJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(installs)
+		return
+	}
+	if len(installs) == 0 {
+		fmt.Println("No Java found; install one from https://adoptium.net")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "JAVA\tVERSION\tVENDOR\tPATH\tFOUND IN")
+	for _, install := range installs {
+		vendor := install.Vendor
+		if vendor == "" {
+			vendor = "-"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", install.Major, install.Version, vendor, install.Path, install.Source)
+	}
+	w.Flush()
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"mcserver-manager/internal/server"
+)
+
+var (
+	lagSince time.Duration
+	lagLimit int
+	lagLines bool
+	lagJSON  bool
+)
+
+var lagCmd = &cobra.Command{
+	Use:   "lag",
+	Short: "Show the logged lag spikes with the console lines around them",
+	Long: `While the server runs, the manager logs every stretch of TPS below
+--lag-threshold to .mcserver/lag-spikes.jsonl: when it started, how long it
+lasted, the lowest TPS and highest MSPT, how many players were online, and
+the console lines from shortly before and during it. Use :lag in the TUI for
+the current TPS percentiles.`,
+	Args: cobra.NoArgs,
+	Run:  runLag,
+}
+
+func init() {
+	lagCmd.Flags().DurationVar(&lagSince, "since", 24*time.Hour, "Only show spikes newer than this (0 for all)")
+	lagCmd.Flags().IntVar(&lagLimit, "limit", 20, "Show at most this many of the newest spikes (0 for all)")
+	lagCmd.Flags().BoolVar(&lagLines, "lines", false, "Print the console lines recorded with each spike")
+	lagCmd.Flags().BoolVar(&lagJSON, "json", false, "Print spikes as JSON lines")
+	rootCmd.AddCommand(lagCmd)
+}
+
+func runLag(cmd *cobra.Command, args []string) {
+	absServerDir, err := filepath.Abs(serverDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving server directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	var since time.Time
+	if lagSince > 0 {
+		since = time.Now().Add(-lagSince)
+	}
+	spikes, err := server.LagSpikes(absServerDir, since, lagLimit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if lagJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, spike := range spikes {
+			enc.Encode(spike)
+		}
+		return
+	}
+
+	if len(spikes) == 0 {
+		fmt.Println("No lag spikes logged")
+		return
+	}
+	for _, spike := range spikes {
+		fmt.Println(spike.String())
+		if lagLines {
+			for _, line := range spike.Lines {
+				fmt.Printf("    %s\n", line)
+			}
+		}
+	}
+}
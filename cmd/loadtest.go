@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"mcserver-manager/internal/loadbot"
+	"mcserver-manager/internal/server"
+	"mcserver-manager/internal/stats"
+)
+
+var (
+	loadtestBots         int
+	loadtestStep         int
+	loadtestStepDuration time.Duration
+	loadtestNamePrefix   string
+	loadtestMoveInterval time.Duration
+	loadtestRadius       float64
+	loadtestChatInterval time.Duration
+	loadtestTimeout      time.Duration
+	loadtestConsole      bool
+)
+
+var loadtestCmd = &cobra.Command{
+	Use:   "loadtest",
+	Short: "Measure how TPS and MSPT hold up as simulated players join",
+	Long: `Starts the server with the usual flags and has bots join it a step at a
+time. Bots are protocol-level clients: they log in, walk around where they
+spawned and chat now and then. TPS, MSPT and memory are measured on the
+empty server and after each step, so you can see how many players the
+server holds before opening it to the public.
+
+Bots have no Mojang account, so the server must run with
+online-mode=false, with the whitelist off and without proxy forwarding for
+the test. max-players must leave room for every bot. Bots do not fall, so
+on uneven ground allow-flight=true keeps them from being kicked for
+flying. Servers from ` + loadbot.SupportedVersions + ` are supported.`,
+	Args: cobra.NoArgs,
+	Run:  runLoadtest,
+}
+
+func init() {
+	loadtestCmd.Flags().IntVar(&loadtestBots, "bots", 20, "Number of bots to end with")
+	loadtestCmd.Flags().IntVar(&loadtestStep, "step", 5, "Bots added at each step")
+	loadtestCmd.Flags().DurationVar(&loadtestStepDuration, "step-duration", time.Minute, "How long to measure each step")
+	loadtestCmd.Flags().StringVar(&loadtestNamePrefix, "name-prefix", "LoadBot", "Bot names, followed by a number")
+	loadtestCmd.Flags().DurationVar(&loadtestMoveInterval, "move-interval", 250*time.Millisecond, "How often bots take a step (0 stands still)")
+	loadtestCmd.Flags().Float64Var(&loadtestRadius, "radius", 16, "How far bots wander from where they spawned, in blocks")
+	loadtestCmd.Flags().DurationVar(&loadtestChatInterval, "chat-interval", 30*time.Second, "How often each bot chats (0 never)")
+	loadtestCmd.Flags().DurationVar(&loadtestTimeout, "timeout", 15*time.Minute, "Give up if the server has not started by then")
+	loadtestCmd.Flags().BoolVar(&loadtestConsole, "console", false, "Print the server console while testing")
+	rootCmd.AddCommand(loadtestCmd)
+}
+
+func runLoadtest(cmd *cobra.Command, args []string) {
+	if len(loadtestNamePrefix)+3 > 16 {
+		fmt.Fprintln(os.Stderr, "Error: --name-prefix must be at most 13 characters")
+		os.Exit(1)
+	}
+	config := buildConfig()
+	// Nothing should run besides the test, and every bot comes from
+	// localhost
+	config.AutoRestart, config.BackupEnabled = false, false
+	config.ThrottleJoins, config.FloodJoins = 0, 0
+
+	srv := server.New(config)
+	go func() {
+		for line := range srv.OutputChan() {
+			if loadtestConsole {
+				fmt.Println(line)
+			}
+		}
+	}()
+
+	result, err := srv.LoadTest(server.LoadTestOptions{
+		Bots:         loadtestBots,
+		Step:         loadtestStep,
+		StepDuration: loadtestStepDuration,
+		NamePrefix:   loadtestNamePrefix,
+		Behavior: loadbot.Behavior{
+			MoveInterval: loadtestMoveInterval,
+			Radius:       loadtestRadius,
+			ChatInterval: loadtestChatInterval,
+		},
+		Timeout:  loadtestTimeout,
+		Progress: func(line string) { fmt.Println(line) },
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Load test failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n%s\n%5s %8s %8s %9s %9s %9s %7s\n", result.Version, "BOTS", "AVG TPS", "MIN TPS", "AVG MSPT", "MAX MSPT", "PEAK MEM", "FAILED")
+	for _, step := range result.Steps {
+		fmt.Printf("%5d %8.1f %8.1f %9.1f %9.1f %9s %7d\n", step.Bots, step.AvgTPS, step.MinTPS, step.AvgMSPT, step.MaxMSPT, stats.FormatBytes(step.PeakMemory), step.Failed)
+	}
+	if len(result.Failures) > 0 {
+		reasons := make([]string, 0, len(result.Failures))
+		for reason := range result.Failures {
+			reasons = append(reasons, reason)
+		}
+		sort.Slice(reasons, func(i, j int) bool { return result.Failures[reasons[i]] > result.Failures[reasons[j]] })
+		fmt.Println("\nBots that failed to join or were dropped:")
+		for _, reason := range reasons {
+			fmt.Printf("  %3dx %s\n", result.Failures[reason], reason)
+		}
+	}
+}
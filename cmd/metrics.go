@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"mcserver-manager/internal/metrics"
+	"mcserver-manager/internal/stats"
+)
+
+var (
+	metricsFrom   string
+	metricsTo     string
+	metricsFormat string
+)
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Work with the recorded metrics history",
+}
+
+var metricsExportCmd = &cobra.Command{
+	Use:   "export [out]",
+	Short: "Export metrics samples as CSV or Parquet for spreadsheets and notebooks",
+	Long: `Writes one row per sample of the history the manager records every
+--metrics-history seconds while the server runs: time, TPS, MSPT, memory
+used and max in bytes, CPU percent and players online. Without an output
+file the rows go to stdout. --from and --to take a date ("2026-10-01"), a
+date and time ("2026-10-01 18:00"), RFC 3339, or how long ago ("24h", "7d");
+a date as --to includes that day.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runMetricsExport,
+}
+
+func init() {
+	metricsExportCmd.Flags().StringVar(&metricsFrom, "from", "", "Start of the range (default: the oldest sample)")
+	metricsExportCmd.Flags().StringVar(&metricsTo, "to", "", "End of the range (default: now)")
+	metricsExportCmd.Flags().StringVar(&metricsFormat, "format", "", "csv or parquet (default: from the output file's extension, else csv)")
+	metricsCmd.AddCommand(metricsExportCmd)
+	rootCmd.AddCommand(metricsCmd)
+}
+
+func runMetricsExport(cmd *cobra.Command, args []string) {
+	absServerDir, err := filepath.Abs(serverDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving server directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	from, err := parseTimeFlag(metricsFrom, false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --from: %v\n", err)
+		os.Exit(1)
+	}
+	to, err := parseTimeFlag(metricsTo, true)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --to: %v\n", err)
+		os.Exit(1)
+	}
+
+	format := metricsFormat
+	if format == "" {
+		format = "csv"
+		if len(args) == 1 && strings.EqualFold(filepath.Ext(args[0]), ".parquet") {
+			format = "parquet"
+		}
+	}
+	write := map[string]func(io.Writer, []metrics.Sample) error{
+		"csv":     metrics.WriteCSV,
+		"parquet": metrics.WriteParquet,
+	}[format]
+	if write == nil {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q, want csv or parquet\n", format)
+		os.Exit(1)
+	}
+
+	samples, err := metrics.Read(absServerDir, from, to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	out := os.Stdout
+	if len(args) == 1 {
+		if out, err = os.Create(args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	w := bufio.NewWriter(out)
+	err = write(w, samples)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil && out != os.Stdout {
+		err = out.Close()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing metrics: %v\n", err)
+		os.Exit(1)
+	}
+	if out != os.Stdout {
+		fmt.Printf("Exported %d samples to %s\n", len(samples), args[0])
+	}
+}
+
+// parseTimeFlag reads an absolute time or how long ago; empty is the zero
+// time. A bare date is its midnight, or with end the next one, so the
+// range takes in the whole day.
+func parseTimeFlag(text string, end bool) (time.Time, error) {
+	if text == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, text); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", text, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", text, time.Local); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	d, err := stats.ParseDuration(text)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, want e.g. 2026-10-01, \"2026-10-01 18:00\" or 24h", text)
+	}
+	return time.Now().Add(-d), nil
+}
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"mcserver-manager/internal/curseforge"
+)
+
+var (
+	exportName      string
+	exportVersion   string
+	exportAuthor    string
+	exportMCVersion string
+	exportLoader    string
+	exportInclude   []string
+
+	migrateApply bool
+	migrateForce bool
+
+	modpackUpdateApply bool
+)
+
+var modpackCmd = &cobra.Command{
+	Use:   "modpack",
+	Short: "Modpack management commands",
+}
+
+var modpackExportCmd = &cobra.Command{
+	Use:   "export <output.zip>",
+	Short: "Export the current server as a CurseForge-style modpack zip",
+	Long: `Packages the server directory as a shareable CurseForge-style modpack.
+
+Mods that can be identified on CurseForge (by fingerprint) are referenced in
+manifest.json by project/file ID. Configs and unidentified jars are bundled
+under overrides/.
+
+Examples:
+  mcserver modpack export my-pack.zip
+  mcserver modpack export my-pack.zip --name "Da Bois Pack" --version 1.2.0
+  mcserver modpack export my-pack.zip --mc-version 1.20.1 --loader forge-47.2.0`,
+	Args: cobra.ExactArgs(1),
+	Run:  runModpackExport,
+}
+
+var modpackRollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Undo the last modpack install",
+	Long: `Restores the files replaced by the last modpack install and removes the
+files it added. Stop the server before rolling back.`,
+	Args: cobra.NoArgs,
+	Run:  runModpackRollback,
+}
+
+var modpackUpdateCmd = &cobra.Command{
+	Use:   "update <modpack> [version]",
+	Short: "Show or apply an in-place modpack update",
+	Long: `Downloads the modpack version and compares it with the install recorded in
+.mcserver/modpack-install.json: mods added, updated and dropped from the
+pack, and pack files changed. Worlds, server.properties, the player lists
+and configs edited since the last install are kept.
+
+With --apply the stopped server is updated in place; "mcserver modpack
+rollback" undoes it. Update --modpack/--modpack-version to match, or the
+next launch installs the old version again.
+
+Examples:
+  mcserver modpack update all-the-mods-9
+  mcserver modpack update all-the-mods-9 0.3.2 --apply`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		line := "modpack update " + strings.Join(args, " ")
+		if modpackUpdateApply {
+			line += " apply"
+		}
+		runWorldAction(line, !modpackUpdateApply)
+	},
+}
+
+var modpackUpgradeCmd = &cobra.Command{
+	Use:   "upgrade <modpack> [version] | upgrade rollback",
+	Short: "Blue/green upgrade a remote agent's modpack (needs --remote)",
+	Long: `Installs the modpack version into a directory beside the server, boots it
+once on a spare port with a throwaway world, then backs up, stops the live
+server, moves the worlds and server state across and starts the new version.
+The previous directory is kept; "upgrade rollback" swaps it back.
+
+Use :upgrade in the TUI for a local server.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if remoteAddr == "" {
+			fmt.Fprintln(os.Stderr, "Error: upgrades run in the manager serving the server; use --remote or :upgrade in its TUI")
+			os.Exit(1)
+		}
+		runWorldAction("upgrade "+strings.Join(args, " "), false)
+	},
+}
+
+var modpackMigrateCmd = &cobra.Command{
+	Use:   "migrate <forge|neoforge> [version]",
+	Short: "Plan or apply a move to another mod loader",
+	Long: `Identifies each installed mod on Modrinth (by hash) and CurseForge (by
+fingerprint) and finds its build for the other loader on the same
+Minecraft version, then lists the mapping and any mods left without one.
+
+With --apply the new loader, the configs and the mapped mods are set up
+beside the server and swapped in as a blue/green upgrade (needs --remote);
+"mcserver modpack upgrade rollback" restores the old loader. Mods with no
+counterpart block the migration unless --force drops them.
+
+Examples:
+  mcserver modpack migrate neoforge
+  mcserver modpack migrate neoforge 21.1.77 --apply --remote host:8080`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		line := "migrate " + strings.Join(args, " ")
+		if migrateApply {
+			if remoteAddr == "" {
+				fmt.Fprintln(os.Stderr, "Error: migrations run in the manager serving the server; use --remote or :migrate in its TUI")
+				os.Exit(1)
+			}
+			line += " apply"
+			if migrateForce {
+				line += " force"
+			}
+		}
+		runWorldAction(line, !migrateApply)
+	},
+}
+
+func init() {
+	modpackExportCmd.Flags().StringVar(&exportName, "name", "", "Modpack name (defaults to the installed pack's name)")
+	modpackExportCmd.Flags().StringVar(&exportVersion, "version", "", "Modpack version")
+	modpackExportCmd.Flags().StringVar(&exportAuthor, "author", "", "Modpack author")
+	modpackExportCmd.Flags().StringVar(&exportMCVersion, "mc-version", "", "Minecraft version (defaults to the installed pack's version)")
+	modpackExportCmd.Flags().StringVar(&exportLoader, "loader", "", "Mod loader ID (e.g., forge-47.2.0, fabric-0.15.3)")
+	modpackExportCmd.Flags().StringSliceVar(&exportInclude, "include", nil, "Extra files or folders to add to overrides")
+
+	modpackCmd.AddCommand(modpackExportCmd)
+	modpackCmd.AddCommand(modpackRollbackCmd)
+	modpackUpdateCmd.Flags().BoolVar(&modpackUpdateApply, "apply", false, "Update instead of only printing the changes")
+	modpackCmd.AddCommand(modpackUpdateCmd)
+	modpackMigrateCmd.Flags().BoolVar(&migrateApply, "apply", false, "Migrate instead of only printing the plan")
+	modpackMigrateCmd.Flags().BoolVar(&migrateForce, "force", false, "Migrate even if some mods have no counterpart")
+
+	modpackCmd.AddCommand(modpackUpgradeCmd)
+	modpackCmd.AddCommand(modpackMigrateCmd)
+	rootCmd.AddCommand(modpackCmd)
+}
+
+func runModpackExport(cmd *cobra.Command, args []string) {
+	absServerDir, err := filepath.Abs(serverDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving server directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	cf := curseforge.NewClient()
+	result, err := cf.ExportModpack(absServerDir, args[0], curseforge.ExportOptions{
+		Name:      exportName,
+		Version:   exportVersion,
+		Author:    exportAuthor,
+		MCVersion: exportMCVersion,
+		ModLoader: exportLoader,
+		Include:   exportInclude,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Export failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Exported modpack to %s\n", result.OutputPath)
+	fmt.Printf("  %d mod(s) referenced from CurseForge\n", result.Referenced)
+	fmt.Printf("  %d jar(s) bundled in overrides/mods\n", result.Bundled)
+	for _, dir := range result.OverrideDirs {
+		fmt.Printf("  overrides/%s\n", dir)
+	}
+}
+
+func runModpackRollback(cmd *cobra.Command, args []string) {
+	absServerDir, err := filepath.Abs(serverDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving server directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	info, err := curseforge.RollbackModpack(absServerDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Rollback failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Rolled back modpack install from %s\n", info.CreatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("  %d file(s) restored, %d file(s) removed\n", len(info.Replaced)+len(info.Removed), len(info.Added))
+}
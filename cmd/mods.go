@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var modsCmd = &cobra.Command{
+	Use:   "mods",
+	Short: "List installed mods and plugins, and update them",
+}
+
+var modsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the jars in mods/ and plugins/ with their ID and version",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runWorldAction("mods list", true)
+	},
+}
+
+var modsOutdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "Show mods with a newer build for the server's loader and Minecraft version",
+	Long: `Identifies each jar on Modrinth by its SHA-1 and on CurseForge by its
+fingerprint, and shows the ones whose project has a newer build for the
+server's loader and Minecraft version. CurseForge lookups need
+CURSEFORGE_API_KEY.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runWorldAction("mods outdated", true)
+	},
+}
+
+var modsUpdateCmd = &cobra.Command{
+	Use:   "update [mod...]",
+	Short: "Update the named mods, or every outdated one",
+	Long: `Zips the mods folder into the backup directory as mods_<time>.zip, then
+replaces each mod with its newest build. Mods are named by file, with or
+without .jar, or by mod ID. A new jar is downloaded before the old one is
+removed. Through --remote the running server loads them on its next
+restart.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runWorldAction(strings.Join(append([]string{"mods update"}, args...), " "), false)
+	},
+}
+
+func init() {
+	modsCmd.AddCommand(modsListCmd)
+	modsCmd.AddCommand(modsOutdatedCmd)
+	modsCmd.AddCommand(modsUpdateCmd)
+	rootCmd.AddCommand(modsCmd)
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"mcserver-manager/internal/server"
+	"mcserver-manager/internal/tui"
+)
+
+var (
+	monitorLog          string
+	monitorRCON         string
+	monitorRCONPassword string
+	monitorPID          int
+)
+
+var monitorCmd = &cobra.Command{
+	Use:   "monitor",
+	Short: "Watch a server launched by something else",
+	Long: `Follows the log of a server run by Pterodactyl, a systemd unit or any
+other supervisor and provides the TUI, stats, player tracking and alerts
+without owning the process. Commands, TPS requests and announcements go
+over RCON, taken from server.properties when enable-rcon is set there and
+--rcon is not given. Memory and CPU come from the java process running in
+the server directory, or from --pid. Starting, stopping and restarting are
+left to whatever runs the server.
+
+The server directory defaults to the one the log is in (logs/latest.log).`,
+	Args: cobra.NoArgs,
+	Run:  runMonitor,
+}
+
+func init() {
+	monitorCmd.Flags().StringVar(&monitorLog, "log", "", "Server log to follow, usually logs/latest.log")
+	monitorCmd.Flags().StringVar(&monitorRCON, "rcon", "", "RCON address (host:port) for sending commands")
+	monitorCmd.Flags().StringVar(&monitorRCONPassword, "rcon-password", os.Getenv("MCSERVER_RCON_PASSWORD"), "RCON password (or MCSERVER_RCON_PASSWORD)")
+	monitorCmd.Flags().IntVar(&monitorPID, "pid", 0, "PID of the server's java process, if it is not found automatically")
+	monitorCmd.MarkFlagRequired("log")
+	rootCmd.AddCommand(monitorCmd)
+}
+
+func runMonitor(cmd *cobra.Command, args []string) {
+	logPath, err := filepath.Abs(monitorLog)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving log path: %v\n", err)
+		os.Exit(1)
+	}
+	if !cmd.Flags().Changed("server-dir") {
+		serverDir = filepath.Dir(filepath.Dir(logPath))
+	}
+
+	config := buildConfig()
+	config.MonitorLog = logPath
+	config.RCONAddress, config.RCONPassword = monitorRCON, monitorRCONPassword
+	config.MonitorPID = monitorPID
+	loadExtensions()
+
+	srv := server.New(config)
+	if err := srv.Monitor(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if noTUI {
+		go func() {
+			for line := range srv.OutputChan() {
+				fmt.Println(line)
+			}
+		}()
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		return
+	}
+
+	if err := tui.RunMonitor(srv); err != nil {
+		fmt.Fprintf(os.Stderr, "TUI error: %v\n", err)
+		os.Exit(1)
+	}
+}
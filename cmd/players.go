@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var playersCmd = &cobra.Command{
+	Use:   "players",
+	Short: "Look up and manage players recorded in the player database",
+}
+
+var playersListCmd = &cobra.Command{
+	Use:   "list [seen|playtime|joins|first|name]",
+	Short: "List everyone who has played, with playtime and when they were last seen",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runWorldAction(strings.Join(append([]string{"players", "list"}, args...), " "), true)
+	},
+}
+
+var playersInfoCmd = &cobra.Command{
+	Use:   "info <player>",
+	Short: "Show a player's UUID, addresses, playtime and recent sessions",
+	Long: `Shows what .mcserver/players.json records about a player: their UUID,
+when they were first and last seen, total playtime, the addresses they
+joined from and who else joined from them, and their latest sessions.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runWorldAction("players info "+args[0], true)
+	},
+}
+
+var playersTempbanCmd = &cobra.Command{
+	Use:   "tempban <player> <duration> [reason]",
+	Short: "Ban a player for a while, e.g. 30m, 12h, 7d or 2w",
+	Long: `Bans the player and records the expiry in .mcserver/players.json; the
+manager pardons them once it runs out. Through --remote the running server
+bans them right away; offline the ban is written to banned-players.json with
+its expiry.`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runWorldAction("tempban "+strings.Join(args, " "), false)
+	},
+}
+
+var playersTempbansCmd = &cobra.Command{
+	Use:   "tempbans",
+	Short: "List temporary bans and when they run out",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runWorldAction("tempban list", true)
+	},
+}
+
+var playersUnbanCmd = &cobra.Command{
+	Use:   "unban <player>",
+	Short: "Lift a temporary ban early",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runWorldAction("tempban lift "+args[0], false)
+	},
+}
+
+func init() {
+	playersCmd.AddCommand(playersListCmd)
+	playersCmd.AddCommand(playersInfoCmd)
+	playersCmd.AddCommand(playersTempbanCmd)
+	playersCmd.AddCommand(playersTempbansCmd)
+	playersCmd.AddCommand(playersUnbanCmd)
+	rootCmd.AddCommand(playersCmd)
+}
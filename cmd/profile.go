@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"mcserver-manager/internal/server"
+	"mcserver-manager/internal/spark"
+)
+
+var (
+	profileHistory int
+	profileJSON    bool
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Rank mods by the server thread time spark sampled in them",
+	Long: `Runs spark's profiler on the running server and attributes the server
+thread's time to the mods and plugins whose code it was spent in, so you
+know which one to look at when TPS tanks. Spark has to be installed as a mod
+or plugin; Paper 1.21 and later has it built in.`,
+}
+
+var profileRunCmd = &cobra.Command{
+	Use:   "run [duration]",
+	Short: "Profile the running server with spark (default 60s)",
+	Long: `Starts spark's profiler, stops it after the duration with
+--save-to-file and records the per-mod report in .mcserver/profiles.jsonl.
+The server has to be running: use --remote, or :profile in the TUI.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		line := "profile"
+		if len(args) == 1 {
+			line += " " + args[0]
+		}
+		runWorldAction(line, false)
+	},
+}
+
+var profileReportCmd = &cobra.Command{
+	Use:   "report [file.sparkprofile]",
+	Short: "Show the recorded profile reports, or attribute a saved spark profile",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   runProfileReport,
+}
+
+func init() {
+	profileReportCmd.Flags().IntVar(&profileHistory, "history", 1, "Show this many of the newest recorded reports (0 for all)")
+	profileReportCmd.Flags().BoolVar(&profileJSON, "json", false, "Print reports as JSON lines")
+	profileCmd.AddCommand(profileRunCmd, profileReportCmd)
+	rootCmd.AddCommand(profileCmd)
+}
+
+func runProfileReport(cmd *cobra.Command, args []string) {
+	var reports []spark.Report
+	if len(args) == 1 {
+		report, err := spark.ReadFile(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		reports = append(reports, *report)
+	} else {
+		absServerDir, err := filepath.Abs(serverDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving server directory: %v\n", err)
+			os.Exit(1)
+		}
+		reports, err = server.ProfileHistory(absServerDir, profileHistory)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if profileJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, report := range reports {
+			enc.Encode(report)
+		}
+		return
+	}
+
+	if len(reports) == 0 {
+		fmt.Println("No profiles recorded; run 'mcserver profile run' while the server lags")
+		return
+	}
+	for i, report := range reports {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(report.String())
+	}
+}
@@ -0,0 +1,423 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"mcserver-manager/internal/api"
+	"mcserver-manager/internal/discord"
+	"mcserver-manager/internal/i18n"
+	"mcserver-manager/internal/rcon"
+	"mcserver-manager/internal/server"
+	"mcserver-manager/internal/tui"
+	"mcserver-manager/internal/web"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the status of a remote agent's server",
+	Args:  cobra.NoArgs,
+	Run:   runStatus,
+}
+
+var (
+	sendRCON         string
+	sendRCONPassword string
+)
+
+var sendCmd = &cobra.Command{
+	Use:   "send <command...>",
+	Short: "Send a console command to a remote agent's server, or over RCON",
+	Long: `With --remote the command goes to the agent's server. Otherwise it goes
+over RCON to the server in --server-dir, whoever launched it: --rcon, or the
+port enabled in its server.properties, and the reply is printed.`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runSend,
+}
+
+var reloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "Make a remote agent re-read its configuration without restarting",
+	Long: `Applies configuration changes that do not need a server restart and
+lists the ones that do. A local manager reloads on SIGHUP instead.`,
+	Args: cobra.NoArgs,
+	Run:  runReload,
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	sendCmd.Flags().StringVar(&sendRCON, "rcon", "", "RCON host:port (default: enable-rcon and rcon.port from server.properties)")
+	sendCmd.Flags().StringVar(&sendRCONPassword, "rcon-password", os.Getenv("MCSERVER_RCON_PASSWORD"), "RCON password (or MCSERVER_RCON_PASSWORD)")
+	rootCmd.AddCommand(sendCmd)
+	rootCmd.AddCommand(reloadCmd)
+}
+
+func tlsFiles() api.TLSFiles {
+	return api.TLSFiles{CertFile: tlsCert, KeyFile: tlsKey, CAFile: tlsCA}
+}
+
+// newRemoteClient connects to the agent named by --remote
+func newRemoteClient() *api.Client {
+	if remoteAddr == "" {
+		fmt.Fprintln(os.Stderr, "Error: --remote host:port is required")
+		os.Exit(1)
+	}
+
+	tlsConfig, err := api.ClientTLSConfig(tlsFiles())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	return api.NewClient(remoteAddr, tlsConfig, apiToken)
+}
+
+// runAgent runs the server headless and serves the control API over mTLS
+func runAgent(config *server.Config) {
+	tlsConfig, err := api.ServerTLSConfig(tlsFiles())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	srv := server.New(config)
+	srv.WatchReloadSignal()
+	agent, tokens := newAgent(srv, config.ServerDir)
+	if tokens.Empty() {
+		fmt.Println("⚠️  No API tokens configured; every client with a valid certificate is an admin (see 'mcserver token add')")
+	}
+	serveHTTP(agent, tokens, srv)
+	serveDiscord(agent, srv)
+
+	lines, _ := agent.Subscribe()
+	go func() {
+		for line := range lines {
+			fmt.Println(line)
+		}
+	}()
+
+	go func() {
+		fmt.Printf("🛰️  Agent listening on %s (mutual TLS)\n", agentListen)
+		if err := agent.ListenAndServe(agentListen, tlsConfig); err != nil {
+			fmt.Fprintf(os.Stderr, "Agent error: %v\n", err)
+			srv.Stop()
+			os.Exit(1)
+		}
+	}()
+
+	if grpcListen != "" {
+		go func() {
+			fmt.Printf("🛰️  gRPC control API listening on %s (mutual TLS)\n", grpcListen)
+			if err := agent.ServeGRPC(grpcListen, tlsConfig); err != nil {
+				fmt.Fprintf(os.Stderr, "gRPC error: %v\n", err)
+				srv.Stop()
+				os.Exit(1)
+			}
+		}()
+	}
+
+	go func() {
+		if err := srv.Start(); err != nil {
+			fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
+		}
+	}()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	<-sig
+
+	fmt.Println("Shutting down...")
+	srv.Stop()
+}
+
+// newAgent wraps srv in the control API, with the API tokens, command
+// policy and webhooks of its server directory
+func newAgent(srv *server.Server, dir string) (*api.Agent, *api.TokenStore) {
+	tokens, err := api.LoadTokens(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	policy, err := api.LoadCommandPolicy(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	webhooks, err := api.LoadWebhooks(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return api.NewAgent(srv, tokens, policy, webhooks), tokens
+}
+
+// serveHTTP serves the control API over plain HTTP on --api-port and the
+// web dashboard on --web, whichever are set. With no client certificates,
+// callers need an API token.
+func serveHTTP(agent *api.Agent, tokens *api.TokenStore, srv *server.Server) {
+	listeners := []struct {
+		enabled          bool
+		flag, addr, what string
+		serve            func(net.Listener) error
+	}{
+		{apiPort != 0, "--api-port", fmt.Sprintf(":%d", apiPort), "HTTP API", agent.Serve},
+		{webListen != "", "--web", webListen, "Web dashboard", func(ln net.Listener) error { return web.Serve(ln, agent) }},
+	}
+	for _, l := range listeners {
+		if !l.enabled {
+			continue
+		}
+		if tokens.Empty() {
+			fmt.Fprintf(os.Stderr, "Error: %s needs an API token; create one with 'mcserver token add'\n", l.flag)
+			os.Exit(1)
+		}
+		ln, err := net.Listen("tcp", l.addr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", l.flag, err)
+			os.Exit(1)
+		}
+		fmt.Printf("🌐 %s listening on http://%s\n", l.what, ln.Addr())
+		go func() {
+			if err := l.serve(ln); err != nil {
+				fmt.Fprintf(os.Stderr, "%s error: %v\n", l.what, err)
+				srv.Stop()
+				os.Exit(1)
+			}
+		}()
+	}
+}
+
+// serveDiscord runs the Discord bot when --discord-token is set. The bot
+// reaches the server through agent, with the role its --discord-role gives
+// each member.
+func serveDiscord(agent *api.Agent, srv *server.Server) {
+	if discordToken == "" {
+		return
+	}
+	if discordGuild == "" {
+		fmt.Fprintln(os.Stderr, "Error: --discord-token needs --discord-guild, the ID of the Discord server the bot serves")
+		os.Exit(1)
+	}
+	roles, err := discord.ParseRoles(discordRoles)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --discord-role: %v\n", err)
+		os.Exit(1)
+	}
+	bot := discord.New(agent, discord.Config{Token: discordToken, GuildID: discordGuild, Roles: roles})
+	go func() {
+		if err := bot.Run(context.Background()); err != nil {
+			srv.Notify(server.EventError, fmt.Sprintf("Discord bot stopped: %v", err))
+		}
+	}()
+}
+
+// runLocalWithAPI runs a local server whose console is shared with the
+// HTTP API, web dashboard and Discord bot, in the TUI or, with --no-tui, printed until interrupted
+func runLocalWithAPI(config *server.Config) {
+	srv := server.New(config)
+	srv.WatchReloadSignal()
+	agent, tokens := newAgent(srv, config.ServerDir)
+	serveHTTP(agent, tokens, srv)
+	serveDiscord(agent, srv)
+	lines, _ := agent.Subscribe()
+
+	if !noTUI {
+		if err := tui.RunServer(config, srv, lines); err != nil {
+			fmt.Fprintf(os.Stderr, "TUI error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if !config.AcceptEULA && !server.EULAAccepted(config.ServerDir) && promptEULA() {
+		if err := srv.AcceptEULA("console prompt"); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing eula.txt: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	go func() {
+		for line := range lines {
+			fmt.Println(line)
+		}
+	}()
+	go func() {
+		if err := srv.Start(); err != nil {
+			fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
+		}
+	}()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	<-sig
+
+	fmt.Println("Shutting down...")
+	srv.Stop()
+}
+
+// runRemote runs the TUI (or a plain console) against a remote agent
+func runRemote() {
+	client := newRemoteClient()
+	if _, err := client.FetchStats(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	client.Connect()
+	defer client.Close()
+
+	if noTUI {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		for {
+			select {
+			case line := <-client.OutputChan():
+				fmt.Println(line)
+			case <-sig:
+				return
+			}
+		}
+	}
+
+	if err := tui.RunRemote(client); err != nil {
+		fmt.Fprintf(os.Stderr, "TUI error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// field is a translated label of the status output, padded so the values
+// line up
+func field(name string) string {
+	return fmt.Sprintf("%-10s", i18n.T(name)+":")
+}
+
+func runStatus(cmd *cobra.Command, args []string) {
+	client := newRemoteClient()
+	stats, err := client.FetchStats()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s%s\n", field("Status"), stats.Status.Label())
+	if stats.Status == server.StatusRunning {
+		fmt.Printf("%s%s\n", field("Uptime"), stats.Uptime.Round(time.Second))
+	}
+	fmt.Printf("%s%d/%d\n", field("Players"), stats.PlayerCount, stats.MaxPlayers)
+	fmt.Printf("TPS:      %.1f", stats.TPS)
+	if stats.TPS1h.Samples > 0 {
+		fmt.Printf(" (p95/p99 5m %.1f/%.1f, 1h %.1f/%.1f)", stats.TPS5m.P95, stats.TPS5m.P99, stats.TPS1h.P95, stats.TPS1h.P99)
+	}
+	fmt.Println()
+	if stats.MSPT > 0 {
+		fmt.Printf("MSPT:     %.1f ms\n", stats.MSPT)
+	}
+	if stats.ViewDistance > 0 {
+		pending := ""
+		if stats.DistancePending {
+			pending = " (restart to apply)"
+		}
+		fmt.Printf("Distance: view %d, simulation %d%s\n", stats.ViewDistance, stats.SimulationDistance, pending)
+	}
+	if stats.RestartRequired != "" {
+		fmt.Printf("Restart:  required to apply %s\n", stats.RestartRequired)
+	}
+	fmt.Printf("%s%d MB / %d MB\n", field("Memory"), stats.MemoryUsed/1024/1024, stats.MemoryMax/1024/1024)
+	fmt.Printf("CPU:      %.1f%%\n", stats.CPUPercent)
+	fmt.Printf("Disk:     read %.1f MB/s, write %.1f MB/s", stats.DiskReadRate/1024/1024, stats.DiskWriteRate/1024/1024)
+	if stats.DiskUtil > 0 {
+		fmt.Printf(", disk %.0f%% busy", stats.DiskUtil)
+	}
+	fmt.Println()
+	if stats.DroppedLines > 0 {
+		fmt.Printf("Dropped:  %d console lines\n", stats.DroppedLines)
+	}
+	if l := stats.Lifetime; l.Starts > 0 {
+		fmt.Printf("Lifetime: %s up over %d starts since %s, %d restarts, peak %d players\n",
+			l.Uptime.Round(time.Minute), l.Starts, l.Since.Format("2006-01-02"), l.Restarts, l.PeakPlayers)
+		if l.Crashes > 0 {
+			fmt.Printf("Crashes:  %d, last %s ago (%s)\n", l.Crashes, time.Since(l.LastCrash).Round(time.Minute), l.LastCrashReason)
+		}
+		if l.Modpack != "" {
+			fmt.Printf("Modpack:  %s", l.Modpack)
+			if l.LastGoodModpack != "" && l.LastGoodModpack != l.Modpack {
+				fmt.Printf(" (last known good %s)", l.LastGoodModpack)
+			}
+			fmt.Println()
+		}
+	}
+	if len(stats.Players) > 0 {
+		names := make([]string, len(stats.Players))
+		for i, p := range stats.Players {
+			names[i] = p.Name
+		}
+		fmt.Printf("%s%s\n", field("Online"), strings.Join(names, ", "))
+	}
+	if w := stats.World; w != nil {
+		fmt.Printf("%s%s", field("World"), w.LevelName)
+		if w.Version != "" {
+			fmt.Printf(" (%s, data version %d)", w.Version, w.DataVersion)
+		}
+		fmt.Println()
+		if w.HasSeed {
+			fmt.Printf("%s%d\n", field("Seed"), w.Seed)
+		}
+		fmt.Printf("Spawn:    %d, %d, %d\n", w.SpawnX, w.SpawnY, w.SpawnZ)
+		fmt.Printf("Mode:     %s, hardcore %t\n", w.GameTypeName(), w.Hardcore)
+	}
+}
+
+func runSend(cmd *cobra.Command, args []string) {
+	command := strings.Join(args, " ")
+	if remoteAddr != "" {
+		if err := newRemoteClient().SendCommand(command); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	address, password := sendRCON, sendRCONPassword
+	if address == "" {
+		absServerDir, err := filepath.Abs(serverDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving server directory: %v\n", err)
+			os.Exit(1)
+		}
+		address, password = server.RCONFromProperties(absServerDir, password)
+		if address == "" {
+			fmt.Fprintf(os.Stderr, "Error: RCON is not enabled in %s; set enable-rcon, or use --remote or --rcon\n", filepath.Join(absServerDir, "server.properties"))
+			os.Exit(1)
+		}
+	}
+	client, err := rcon.Dial(address, password)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: RCON at %s: %v\n", address, err)
+		os.Exit(1)
+	}
+	defer client.Close()
+	reply, err := client.Command(command)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if reply != "" {
+		fmt.Println(reply)
+	}
+}
+
+func runReload(cmd *cobra.Command, args []string) {
+	output, err := newRemoteClient().RunAction("reload")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(output)
+}
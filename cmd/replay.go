@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"mcserver-manager/internal/recording"
+	"mcserver-manager/internal/tui"
+)
+
+var (
+	replaySpeed      float64
+	replayMaxIdle    time.Duration
+	replayTUI        bool
+	replayList       bool
+	replayTimestamps bool
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay [recording]",
+	Short: "Play back a console recording at its original pace or faster",
+	Long: `Replays console output saved with --record-console, with the pauses
+between lines as they happened, so a crash can be stepped through exactly as
+the console showed it. Without an argument the newest recording is played;
+recordings can be given by path or by name from --list.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runReplay,
+}
+
+func init() {
+	replayCmd.Flags().Float64Var(&replaySpeed, "speed", 1, "Playback speed multiplier (e.g. 10 plays ten times faster)")
+	replayCmd.Flags().DurationVar(&replayMaxIdle, "max-idle", 0, "Cut pauses longer than this (e.g. 2s); 0 keeps them")
+	replayCmd.Flags().BoolVar(&replayTUI, "tui", false, "Play back in the TUI console view")
+	replayCmd.Flags().BoolVar(&replayList, "list", false, "List the recordings of the server")
+	replayCmd.Flags().BoolVar(&replayTimestamps, "timestamps", false, "Prefix each line with the time it was printed")
+	rootCmd.AddCommand(replayCmd)
+}
+
+func runReplay(cmd *cobra.Command, args []string) {
+	absServerDir, err := filepath.Abs(serverDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving server directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	if replayList {
+		recordings, err := recording.List(absServerDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(recordings) == 0 {
+			fmt.Println("No console recordings (start the server with --record-console)")
+			return
+		}
+		for _, path := range recordings {
+			size := int64(0)
+			if info, err := os.Stat(path); err == nil {
+				size = info.Size()
+			}
+			fmt.Printf("%-24s %8d KB\n", filepath.Base(path), size/1024)
+		}
+		return
+	}
+
+	if replaySpeed <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: --speed must be positive")
+		os.Exit(1)
+	}
+
+	name := ""
+	if len(args) > 0 {
+		name = args[0]
+	}
+	path, err := recording.Resolve(absServerDir, name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if replayTUI {
+		if err := tui.RunReplay(path, replaySpeed, replayMaxIdle); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	player, err := recording.Open(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer player.Close()
+
+	started := player.Header.Started
+	fmt.Printf("Replaying %s, recorded %s, at %gx\n", filepath.Base(path), started.Format("2006-01-02 15:04:05"), replaySpeed)
+	player.Play(replaySpeed, replayMaxIdle, nil, func(frame recording.Frame) {
+		if replayTimestamps {
+			fmt.Printf("%s %s\n", started.Add(frame.Offset).Format("15:04:05.000"), frame.Line)
+		} else {
+			fmt.Println(frame.Line)
+		}
+	})
+}
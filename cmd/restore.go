@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var restoreTo string
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <name> [--to <dir>]",
+	Short: "Restore a backup over the server, or extract it elsewhere with --to",
+	Long: `Without --to the backup replaces the worlds in the server directory; a
+running server is stopped for it and started again (through --remote, or
+offline when no server runs).
+
+With --to the backup is extracted into that directory instead, which must
+be empty or not exist yet, and the live server is left alone. Use it to look
+through an old world or copy a player's data out of it. Through --remote a
+relative directory is taken from the remote server directory.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if restoreTo == "" {
+			runWorldAction("restore "+args[0], false)
+			return
+		}
+		dir := restoreTo
+		if remoteAddr == "" {
+			abs, err := filepath.Abs(dir)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			dir = abs
+		}
+		runWorldAction("restore "+args[0]+" "+dir, true)
+	},
+}
+
+func init() {
+	restoreCmd.Flags().StringVar(&restoreTo, "to", "", "Extract into this directory instead of the server directory")
+	rootCmd.AddCommand(restoreCmd)
+}
@@ -1,14 +1,28 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"mcserver-manager/internal/backup"
+	"mcserver-manager/internal/cron"
+	"mcserver-manager/internal/i18n"
+	"mcserver-manager/internal/jdk"
+	"mcserver-manager/internal/logparse"
+	"mcserver-manager/internal/privdrop"
 	"mcserver-manager/internal/server"
 	"mcserver-manager/internal/tui"
+	"mcserver-manager/internal/vanilla"
+	"mcserver-manager/internal/whitelist"
+	"mcserver-manager/pkg/extension"
 )
 
 var (
@@ -19,20 +33,168 @@ var (
 	serverDir string
 	javaPath  string
 	javaArgs  string
+	javaInsts []string
+	dryRun    bool
 
 	// Modpack flags
 	modpackID      string
 	modpackVersion string
 
+	// Vanilla server flags
+	mcVersion  string
+	snapshots  bool
+	serverType string
+
+	// EULA
+	acceptEULA bool
+
+	// Declared operators
+	ops      []string
+	opsPrune bool
+
+	// In-game commands
+	chatCommands bool
+	chatPrefix   string
+
+	// Remote whitelist
+	whitelistURL      string
+	whitelistInterval int
+
+	// Console recording
+	recordConsole bool
+	recordKeep    int
+
 	// Feature flags
-	autoRestart    bool
-	backupEnabled  bool
-	backupInterval int
-	backupDir      string
-	maxBackups     int
+	autoRestart      bool
+	crashLimit       int
+	restartPolicy    []string
+	restartCron      []string
+	startTimeout     int
+	backupEnabled    bool
+	backupInterval   int
+	backupDir        string
+	maxBackups       int
+	autosaveInterval int
+	autosaveOwn      bool
+	backupWorlds     []string
+	backupExclude    []string
+	backupTargets    []string
+	backupRemoteKeep int
+
+	// Bedrock cross-play flags
+	bedrockCrossplay bool
+	bedrockPort      int
+
+	// Protocol compatibility flags
+	viaVersion bool
+
+	// Proxy flags
+	velocityDir    string
+	proxyAddresses []string
+
+	// Connection throttling flags
+	throttleJoins    int
+	throttleWindow   int
+	throttleFirewall string
+	floodJoins       int
+
+	// Query protocol flags
+	queryEnabled bool
+	queryPort    int
+
+	// Health probe flags
+	healthInterval int
+	publicAddress  string
+	detectPublicIP bool
+
+	// Resource pack flags
+	resourcePack        string
+	resourcePackPort    int
+	resourcePackHost    string
+	requireResourcePack bool
+
+	// Adaptive view distance flags
+	adaptiveView        bool
+	viewDistanceMin     int
+	viewDistanceMax     int
+	simDistanceMin      int
+	simDistanceMax      int
+	viewDistanceCommand string
+
+	// Lag flags
+	lagThreshold float64
+
+	// Polling flags
+	statsInterval      int
+	tpsInterval        int
+	playerListInterval int
+
+	// Metrics history flags
+	metricsHistory int
+	metricsKeep    int
+	costPerHour    float64
+
+	// Disk flags
+	diskAlert int
+
+	// Hibernation flags
+	suspendWhenEmpty int
+
+	// Scheduling flags
+	cpuAffinity   string
+	niceLevel     int
+	priorityClass string
+
+	// cgroup flags
+	cgroupLimits bool
+	cgroupMemory string
+	cgroupCPU    int
+
+	// Sandbox flags
+	sandboxMode  string
+	sandboxPaths []string
+
+	// Output parsing flags
+	logProfile          string
+	playerNamePattern   string
+	hidePrivateMessages bool
+
+	// Scripting flags
+	scriptsEnabled bool
+
+	// Extension flags
+	extensionsDir string
+
+	// GitOps flags
+	gitopsRepo     string
+	gitopsBranch   string
+	gitopsPath     string
+	gitopsInterval int
+
+	// Privilege dropping flags
+	runAs string
+
+	// Remote agent flags
+	agentListen string
+	grpcListen  string
+	remoteAddr  string
+	tlsCert     string
+	tlsKey      string
+	tlsCA       string
+	apiToken    string
+	apiPort     int
+	webListen   string
+
+	// Discord bot flags
+	discordToken string
+	discordGuild string
+	discordRoles []string
 
 	// Display flags
-	noTUI bool
+	noTUI       bool
+	eventStyles []string
+	eventPanel  []string
+	lang        string
 )
 
 var rootCmd = &cobra.Command{
@@ -56,7 +218,7 @@ A powerful, feature-rich Minecraft server manager with:
   • CurseForge modpack auto-download and installation
   • Beautiful terminal UI with real-time statistics
   • Player tracking with join/leave events
-  • TPS, memory, CPU, and bandwidth monitoring
+  • TPS, memory, CPU, and disk I/O monitoring
   • Auto-restart on crash
   • Scheduled world backups
   • Graceful shutdown with save-all
@@ -71,30 +233,207 @@ Examples:
 
 func init() {
 	// Memory configuration
-	rootCmd.Flags().StringVarP(&ramMin, "ram-min", "m", "1G", "Minimum RAM allocation (e.g., 1G, 512M)")
-	rootCmd.Flags().StringVarP(&ramMax, "ram-max", "M", "4G", "Maximum RAM allocation (e.g., 4G, 8G)")
+	rootCmd.Flags().StringVarP(&ramMin, "ram-min", "m", "1G", "Minimum RAM allocation (e.g., 1G, 512M, 1.5G, or 25% of system RAM)")
+	rootCmd.Flags().StringVarP(&ramMax, "ram-max", "M", "4G", "Maximum RAM allocation (e.g., 4G, 8G, 6.5G, or 75% of system RAM)")
 
 	// Network configuration
 	rootCmd.Flags().IntVarP(&port, "port", "p", 25565, "Server port")
 
 	// Paths
-	rootCmd.Flags().StringVarP(&serverDir, "server-dir", "d", "./server", "Server directory path")
-	rootCmd.Flags().StringVar(&javaPath, "java", "java", "Path to Java executable")
+	rootCmd.PersistentFlags().StringVarP(&serverDir, "server-dir", "d", "./server", "Server directory path")
+	rootCmd.Flags().StringVar(&javaPath, "java", "java", "Path to Java executable, or a major version (17) to pick from 'mcserver java list'")
 	rootCmd.Flags().StringVar(&javaArgs, "java-args", "", "Additional Java arguments")
+	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the Java command the server would start with, after merging --java-args, and exit")
+	rootCmd.Flags().StringSliceVar(&javaInsts, "java-install", nil, "A Java by major version, as major=path (e.g. 8=/usr/lib/jvm/java-8/bin/java), used when --java cannot run the server; repeatable")
 
 	// Modpack configuration
-	rootCmd.Flags().StringVarP(&modpackID, "modpack", "k", "", "CurseForge modpack project ID or slug")
+	rootCmd.Flags().StringVarP(&modpackID, "modpack", "k", "", "CurseForge modpack project ID or slug, or <source>:<id> for an extension mod source")
 	rootCmd.Flags().StringVar(&modpackVersion, "modpack-version", "latest", "Modpack version (latest, specific version ID)")
 
+	// Vanilla server
+	rootCmd.Flags().StringVar(&mcVersion, "mc-version", "", "Download the vanilla server jar: a version such as 1.21.1, latest, or snapshot to follow the snapshot channel")
+	rootCmd.Flags().BoolVar(&snapshots, "snapshots", false, "Allow snapshot and pre-release versions for --mc-version, and Paper's experimental builds")
+	rootCmd.Flags().StringVar(&serverType, "server-type", "", "Server jar to download for --mc-version: vanilla (default), paper or purpur; alone it installs the latest release")
+
+	// EULA
+	rootCmd.Flags().BoolVar(&acceptEULA, "accept-eula", false, "Accept Mojang's EULA (https://aka.ms/MinecraftEULA) by writing eula.txt; otherwise you are asked before the first start")
+
+	// Declared operators
+	rootCmd.Flags().StringSliceVar(&ops, "op", nil, "Make a player operator on start, as name or name:level (level 1-4, default 4); repeatable")
+	rootCmd.Flags().BoolVar(&opsPrune, "ops-prune", false, "Remove operators not declared with --op from ops.json on start")
+
+	// In-game commands
+	rootCmd.Flags().BoolVar(&chatCommands, "chat-commands", false, "Let ops run manager actions from chat, e.g. !backup or !restart 10m (level 3, or 4 for admin actions)")
+	rootCmd.Flags().StringVar(&chatPrefix, "chat-prefix", "!", "What chat messages start with to be a manager action")
+
+	// Remote whitelist
+	rootCmd.Flags().StringVar(&whitelistURL, "whitelist-url", "", "Sync whitelist.json from this URL: a JSON or text list of names, a CSV, a Gist or a Google Sheet")
+	rootCmd.Flags().IntVar(&whitelistInterval, "whitelist-interval", 10, "Minutes between whitelist syncs while the server runs (0 syncs only on start)")
+
+	// Console recording
+	rootCmd.Flags().BoolVar(&recordConsole, "record-console", false, "Record console output with timestamps for mcserver replay")
+	rootCmd.Flags().IntVar(&recordKeep, "record-keep", 20, "Number of console recordings to keep (0 keeps all)")
+
 	// Features
 	rootCmd.Flags().BoolVarP(&autoRestart, "auto-restart", "r", true, "Auto-restart server on crash")
+	rootCmd.Flags().IntVar(&crashLimit, "crash-limit", 5, "Stop auto-restarting after this many crashes or failed starts in a row (0 never stops)")
+	rootCmd.Flags().StringSliceVar(&restartPolicy, "restart-policy", nil, "How a restart treats online players, as source:warning[:deadline] (sources manual, watchdog, scheduled; e.g. scheduled:5m:2h waits up to 2h for the server to empty, then counts down 5m); repeatable")
+	rootCmd.Flags().StringArrayVar(&restartCron, "restart-cron", nil, "Restart on a cron schedule in local time, e.g. \"0 4 * * *\" for 4:00 daily, counting down by the scheduled restart policy; repeatable")
+	rootCmd.Flags().IntVar(&startTimeout, "start-timeout", 15, "Minutes a start may take before it is failed with a thread dump (0 waits forever)")
 	rootCmd.Flags().BoolVar(&backupEnabled, "backup-enabled", false, "Enable scheduled backups")
 	rootCmd.Flags().IntVar(&backupInterval, "backup-interval", 60, "Backup interval in minutes")
-	rootCmd.Flags().StringVar(&backupDir, "backup-dir", "./backups", "Backup directory path")
-	rootCmd.Flags().IntVar(&maxBackups, "max-backups", 10, "Maximum number of backups to keep")
+	rootCmd.PersistentFlags().StringVar(&backupDir, "backup-dir", "./backups", "Backup directory path")
+	rootCmd.PersistentFlags().IntVar(&maxBackups, "max-backups", 10, "Maximum number of backups to keep")
+	rootCmd.Flags().IntVar(&autosaveInterval, "autosave-interval", 0, "Save the world every this many minutes, skipping saves a backup just did (0 leaves saving to the server)")
+	rootCmd.Flags().BoolVar(&autosaveOwn, "autosave-own", false, "Turn the server's own autosave off so only the manager saves, every --autosave-interval or 5 minutes")
+	rootCmd.Flags().StringSliceVar(&backupWorlds, "backup-worlds", nil, "World folders scheduled backups include, by name or pattern such as world*; default all")
+	rootCmd.Flags().StringSliceVar(&backupExclude, "backup-exclude", nil, "World folders scheduled backups leave out, by name or pattern, e.g. a mining world that is reset anyway")
+	rootCmd.Flags().StringSliceVar(&backupTargets, "backup-targets", nil, "Upload finished backups to s3://bucket/prefix, sftp://user@host/path or a directory such as a mounted NAS (rsync); repeatable")
+	rootCmd.Flags().IntVar(&backupRemoteKeep, "backup-remote-keep", 0, "Backups each --backup-targets target keeps (0 keeps --max-backups)")
+
+	// Bedrock cross-play
+	rootCmd.Flags().BoolVar(&bedrockCrossplay, "bedrock-crossplay", false, "Install Geyser + Floodgate so Bedrock players can join")
+	rootCmd.Flags().IntVar(&bedrockPort, "bedrock-port", 19132, "UDP port for Bedrock players (Geyser)")
+
+	// Protocol compatibility
+	rootCmd.Flags().BoolVar(&viaVersion, "via-version", false, "Install and keep ViaVersion/ViaBackwards updated (Paper)")
+
+	// Proxy
+	rootCmd.Flags().StringVar(&velocityDir, "velocity-dir", "", "Velocity proxy directory; configures modern forwarding")
+	rootCmd.Flags().StringSliceVar(&proxyAddresses, "proxy-ip", nil, "IP addresses of proxies in front of the server, never taken for a player's")
+
+	// Connection throttling
+	rootCmd.Flags().IntVar(&throttleJoins, "throttle-joins", 0, "Ban an IP (ban-ip) after more connections than this within --throttle-window (0 disables)")
+	rootCmd.Flags().IntVar(&throttleWindow, "throttle-window", 60, "Seconds over which connections are counted for --throttle-joins and --flood-joins")
+	rootCmd.Flags().StringVar(&throttleFirewall, "throttle-firewall", "", "Command that also blocks a throttled IP, with an {ip} placeholder (e.g. \"ufw insert 1 deny from {ip}\")")
+	rootCmd.Flags().IntVar(&floodJoins, "flood-joins", 0, "Raise a critical event on more connections than this from all IPs within --throttle-window (0 disables)")
+
+	// Query protocol
+	rootCmd.Flags().BoolVar(&queryEnabled, "query", false, "Enable the UDP query protocol and cross-check the player list")
+	rootCmd.Flags().IntVar(&queryPort, "query-port", 0, "UDP query port (defaults to the server port)")
+
+	// Health probe
+	rootCmd.Flags().IntVar(&healthInterval, "health-interval", 30, "Seconds between Server List Ping health checks (0 disables)")
+	rootCmd.Flags().StringVar(&publicAddress, "public-address", "", "Public host:port to verify external reachability")
+	rootCmd.Flags().BoolVar(&detectPublicIP, "detect-public-ip", false, "Ask an external service for the public IP to show a shareable connect address")
+
+	// Resource pack hosting
+	rootCmd.Flags().StringVar(&resourcePack, "resource-pack", "", "Resource pack zip to serve to players (sets resource-pack and resource-pack-sha1)")
+	rootCmd.Flags().IntVar(&resourcePackPort, "resource-pack-port", 8163, "HTTP port for resource pack downloads")
+	rootCmd.Flags().StringVar(&resourcePackHost, "resource-pack-host", "", "Host players download the pack from (defaults to the public IP)")
+	rootCmd.Flags().BoolVar(&requireResourcePack, "require-resource-pack", false, "Kick players who decline the resource pack")
+
+	// Adaptive view distance
+	rootCmd.Flags().BoolVar(&adaptiveView, "adaptive-view", false, "Tune view/simulation distance from sustained TPS, MSPT and player count")
+	rootCmd.Flags().IntVar(&viewDistanceMin, "view-distance-min", 4, "Lowest view distance the tuner may set")
+	rootCmd.Flags().IntVar(&viewDistanceMax, "view-distance-max", 12, "Highest view distance the tuner may set")
+	rootCmd.Flags().IntVar(&simDistanceMin, "sim-distance-min", 4, "Lowest simulation distance the tuner may set")
+	rootCmd.Flags().IntVar(&simDistanceMax, "sim-distance-max", 10, "Highest simulation distance the tuner may set")
+	rootCmd.Flags().StringVar(&viewDistanceCommand, "view-distance-command", "", "Console command that applies distances live, with {view} and {sim} placeholders")
+
+	// Polling
+	rootCmd.Flags().IntVar(&statsInterval, "stats-interval", 1, "Seconds between CPU, memory and disk readings")
+	rootCmd.Flags().IntVar(&tpsInterval, "tps-interval", 5, "Seconds between TPS requests to the server (0 disables)")
+	rootCmd.Flags().IntVar(&playerListInterval, "player-list-interval", 60, "Seconds between \"list\" checks that correct the tracked players (0 disables)")
+
+	// Lag spikes
+	rootCmd.Flags().Float64Var(&lagThreshold, "lag-threshold", 15, "Log a lag spike while TPS is below this (0 disables; see 'mcserver lag')")
+
+	// Metrics history
+	rootCmd.Flags().IntVar(&metricsHistory, "metrics-history", 60, "Seconds between the TPS, memory, CPU and player samples kept for 'mcserver metrics export' (0 disables)")
+	rootCmd.Flags().IntVar(&metricsKeep, "metrics-keep", 30, "Days of metrics history to keep (0 keeps all)")
+	rootCmd.Flags().Float64Var(&costPerHour, "cost-per-hour", 0, "Hosting cost per hour the server runs, for the estimates of 'mcserver usage' (0 leaves them out)")
+
+	// Disk
+	rootCmd.Flags().IntVar(&diskAlert, "disk-alert", 90, "Warn when the server's disk stays this many percent busy for 30 seconds (0 disables, Linux)")
+
+	// Hibernation
+	rootCmd.Flags().IntVar(&suspendWhenEmpty, "suspend-when-empty", 0, "Suspend the JVM after this many minutes without players, resuming on the next connection (0 disables)")
+
+	// Scheduling
+	rootCmd.Flags().StringVar(&cpuAffinity, "cpu-affinity", "", "Pin the server to these CPUs, e.g. 0-3,6")
+	rootCmd.Flags().IntVar(&niceLevel, "nice", 0, "Nice level for the server on Linux (-20 to 19)")
+	rootCmd.Flags().StringVar(&priorityClass, "priority-class", "", "Process priority class on Windows (idle, below-normal, normal, above-normal, high, realtime)")
+
+	// cgroup limits
+	rootCmd.Flags().BoolVar(&cgroupLimits, "cgroup-limits", false, "Run the server in a cgroup (or systemd scope) with memory/CPU limits (Linux)")
+	rootCmd.Flags().StringVar(&cgroupMemory, "cgroup-memory", "", "cgroup memory limit, e.g. 10G (default: --ram-max plus 25%, at least 1G extra)")
+	rootCmd.Flags().IntVar(&cgroupCPU, "cgroup-cpu", 0, "cgroup CPU limit in percent of one core, e.g. 200 (0 is unlimited)")
+
+	// Sandbox
+	rootCmd.Flags().StringVar(&sandboxMode, "sandbox", "off", "Harden the server process: minimal environment, no-new-privileges, private temp dir, filesystem allowlist (Linux). The server does not start when part of it fails, unless set to best-effort")
+	rootCmd.Flags().Lookup("sandbox").NoOptDefVal = "on"
+	rootCmd.Flags().StringSliceVar(&sandboxPaths, "sandbox-allow", nil, "Extra paths the sandboxed server may read and write")
+
+	// Output parsing
+	rootCmd.Flags().StringVar(&logProfile, "log-profile", "auto", "Console pattern profile: "+strings.Join(logparse.Names(), ", "))
+	rootCmd.Flags().StringVar(&playerNamePattern, "player-name-pattern", logparse.DefaultNamePattern, "Regex for one player name in console lines (no capturing groups, anchors or whitespace)")
+	rootCmd.Flags().BoolVar(&hidePrivateMessages, "hide-private-messages", false, "Keep /msg and /tell messages out of the events, console recordings and support bundles")
+
+	// Scripting
+	rootCmd.Flags().BoolVar(&scriptsEnabled, "scripts", false, "Run the Starlark automation scripts in server/.mcserver/scripts")
+
+	// Extensions
+	rootCmd.Flags().StringVar(&extensionsDir, "extensions", "", "Directory of Go plugin (.so) extensions to load at startup")
+
+	// GitOps
+	rootCmd.Flags().StringVar(&gitopsRepo, "gitops-repo", "", "Git repository to sync server.properties, whitelist/ops, config/ and a mods.json manifest from")
+	rootCmd.Flags().StringVar(&gitopsBranch, "gitops-branch", "main", "Branch of --gitops-repo to follow")
+	rootCmd.Flags().StringVar(&gitopsPath, "gitops-path", "", "Subdirectory of --gitops-repo holding the server files")
+	rootCmd.Flags().IntVar(&gitopsInterval, "gitops-interval", 5, "Minutes between GitOps syncs while the server runs (0 syncs only on start)")
+
+	// Privilege dropping
+	rootCmd.Flags().StringVar(&runAs, "run-as", "", "When started as root, create/use this user, chown the server files and run as it (e.g. minecraft)")
+
+	// Remote agent
+	rootCmd.Flags().StringVar(&agentListen, "agent-listen", "", "Run headless as an agent, serving the control API on this address (e.g. :7443)")
+	rootCmd.Flags().StringVar(&webListen, "web", "", "Serve the web dashboard, with live console and commands, on this address (e.g. :8080; needs an API token)")
+	rootCmd.Flags().IntVar(&apiPort, "api-port", 0, "Also serve the control API over plain HTTP on this port, for scripts and browsers (needs an API token)")
+	rootCmd.Flags().StringVar(&grpcListen, "grpc-listen", "", "Also serve the gRPC control API on this address in agent mode (e.g. :7444)")
+	rootCmd.PersistentFlags().StringVar(&remoteAddr, "remote", "", "Manage a remote agent at host:port instead of a local server")
+	rootCmd.PersistentFlags().StringVar(&tlsCert, "tls-cert", "", "Certificate for mutual TLS with the agent")
+	rootCmd.PersistentFlags().StringVar(&tlsKey, "tls-key", "", "Private key for --tls-cert")
+	rootCmd.PersistentFlags().StringVar(&tlsCA, "tls-ca", "", "CA certificate used to verify the other side")
+	rootCmd.PersistentFlags().StringVar(&apiToken, "api-token", os.Getenv("MCSERVER_API_TOKEN"), "API token for the remote agent (or MCSERVER_API_TOKEN)")
+
+	// Discord bot
+	rootCmd.Flags().StringVar(&discordToken, "discord-token", os.Getenv("MCSERVER_DISCORD_TOKEN"), "Run a Discord bot with /status, /players, /say and /restart using this bot token (or MCSERVER_DISCORD_TOKEN)")
+	rootCmd.Flags().StringVar(&discordGuild, "discord-guild", "", "Discord server (guild) ID the bot serves; required with --discord-token, members of other guilds are refused")
+	rootCmd.Flags().StringSliceVar(&discordRoles, "discord-role", nil, "Give members with a Discord role an API role, as <role-id>=<viewer|operator|admin>; repeatable")
 
 	// Display
 	rootCmd.Flags().BoolVar(&noTUI, "no-tui", false, "Disable TUI, use simple console output")
+	rootCmd.PersistentFlags().StringArrayVar(&eventStyles, "event-style", nil, "Color and icon of an event type in the TUI and dashboard, as type=color[,icon], e.g. chat=#00FFFF or backup=,💾; repeatable")
+	rootCmd.PersistentFlags().StringVar(&lang, "lang", "", "Language of the TUI and CLI output: "+strings.Join(i18n.Languages(), ", ")+" (default from LC_ALL, LC_MESSAGES or LANG)")
+	rootCmd.PersistentFlags().StringSliceVar(&eventPanel, "event-panel", nil, "Event types the TUI event panel lists, the rest only being logged (default warning,error,critical,join,leave,backup,restart,custom)")
+
+	// Settings from the config file fill in the flags left out, before the
+	// --server profile is picked
+	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		if cmd != initCmd {
+			if err := applyConfigFile(cmd); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if err := i18n.SetLanguage(lang); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --lang: %v\n", err)
+			os.Exit(1)
+		}
+		if err := server.ConfigureEventStyles(eventStyles, eventPanel); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		selectServer(cmd, args)
+	}
+
+	// bench starts the server like a normal run does, monitor takes the
+	// same monitoring and alerting flags, support-bundle reports them and
+	// init writes them to a config file
+	benchCmd.Flags().AddFlagSet(rootCmd.Flags())
+	initCmd.Flags().AddFlagSet(rootCmd.Flags())
+	monitorCmd.Flags().AddFlagSet(rootCmd.Flags())
+	supportBundleCmd.Flags().AddFlagSet(rootCmd.Flags())
 }
 
 func Execute() {
@@ -105,6 +444,195 @@ func Execute() {
 }
 
 func runServer(cmd *cobra.Command, args []string) {
+	if remoteAddr != "" {
+		runRemote()
+		return
+	}
+
+	// A --servers file runs all its servers, in the TUI starting on
+	// --server; the other modes run the one --server picks
+	if serversFile != "" && (serverName == "" || !noTUI && !dryRun && agentListen == "" && apiPort == 0 && webListen == "" && discordToken == "") {
+		if dryRun || agentListen != "" || apiPort != 0 || webListen != "" || discordToken != "" {
+			fmt.Fprintln(os.Stderr, "Error: --dry-run, --agent-listen, --api-port, --web and --discord-token need --server to pick one of the --servers")
+			os.Exit(1)
+		}
+		runServers()
+		return
+	}
+
+	config := buildConfig()
+	if dryRun {
+		printLaunchCommand(config)
+		return
+	}
+	dropPrivileges(config)
+	loadExtensions()
+
+	if agentListen != "" {
+		runAgent(config)
+		return
+	}
+	if apiPort != 0 || webListen != "" || discordToken != "" {
+		runLocalWithAPI(config)
+		return
+	}
+
+	if noTUI {
+		// Run in simple console mode
+		srv := server.New(config)
+		if !config.AcceptEULA && !server.EULAAccepted(config.ServerDir) && promptEULA() {
+			if err := srv.AcceptEULA("console prompt"); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing eula.txt: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		srv.WatchReloadSignal()
+		if err := srv.RunConsole(); err != nil {
+			fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		// Run with beautiful TUI
+		if err := tui.Run(config); err != nil {
+			fmt.Fprintf(os.Stderr, "TUI error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// printLaunchCommand prints the Java command line and what the manager
+// noticed while building it
+func printLaunchCommand(config *server.Config) {
+	srv := server.New(config)
+	java, args, err := srv.LaunchCommand()
+	for _, e := range srv.GetStats().RecentEvents {
+		if e.Type == server.EventWarning || e.Type == server.EventError || e.Type == server.EventCritical {
+			fmt.Printf("⚠️  %s\n", e.Message)
+		} else {
+			fmt.Printf("   %s\n", e.Message)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("\n%s", java)
+	for _, arg := range args {
+		fmt.Printf(" \\\n  %s", arg)
+	}
+	fmt.Println()
+}
+
+// loadExtensions opens --extensions plugins and lists every registered
+// extension, whether loaded or compiled in
+func loadExtensions() {
+	if extensionsDir != "" {
+		if _, err := extension.LoadPlugins(extensionsDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	var names []string
+	for _, ext := range extension.All() {
+		names = append(names, ext.Name())
+	}
+	if len(names) > 0 {
+		fmt.Printf("🧩 Extensions: %s\n", strings.Join(names, ", "))
+	}
+}
+
+// dropPrivileges switches from root to the --run-as user after handing it
+// the server, backup and proxy directories of every server and, for
+// --cgroup-limits, a cgroup subtree
+func dropPrivileges(configs ...*server.Config) {
+	if !privdrop.IsRoot() {
+		return
+	}
+	if runAs == "" {
+		fmt.Fprintln(os.Stderr, "Warning: running the server as root; consider --run-as minecraft")
+		return
+	}
+
+	account, err := privdrop.EnsureUser(runAs, configs[0].ServerDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if account.Created {
+		fmt.Printf("Created system user %s\n", account.Name)
+	}
+
+	var dirs []string
+	for _, config := range configs {
+		dirs = append(dirs, config.ServerDir, config.BackupDir)
+		if config.VelocityDir != "" {
+			dirs = append(dirs, config.VelocityDir)
+		}
+	}
+	if err := account.Chown(dirs...); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	// Setting up the cgroups takes root, so hand the account a subtree of
+	// its own first
+	for _, config := range configs {
+		if config.CgroupLimits {
+			if err := server.DelegateCgroups(account.UID, account.GID); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: --cgroup-limits: could not delegate a cgroup to %s: %v\n", account.Name, err)
+			}
+			break
+		}
+	}
+	if err := account.Drop(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// parseSandboxMode reads --sandbox: on (also true, as a config file's
+// "sandbox: true" sets it), off or best-effort
+func parseSandboxMode(mode string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "on", "true", "strict":
+		return "on", nil
+	case "off", "false", "":
+		return "off", nil
+	case "best-effort":
+		return "best-effort", nil
+	}
+	return "", fmt.Errorf("invalid mode %q, want on, off or best-effort", mode)
+}
+
+// buildConfig turns the command line flags into a server configuration,
+// with the --server profile applied when one is picked
+func buildConfig() *server.Config {
+	config := buildFlagConfig()
+	var err error
+	if serverName != "" {
+		var p serverProfile
+		if p, err = findServer(serverName); err == nil {
+			config, err = p.apply(config)
+		}
+	}
+	if err == nil {
+		err = config.ResolveMemory()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return config
+}
+
+// buildFlagConfig turns the command line flags into a server configuration
+func buildFlagConfig() *server.Config {
+	mode, err := parseSandboxMode(sandboxMode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --sandbox: %v\n", err)
+		os.Exit(1)
+	}
+	sandboxMode = mode
+
 	// Create absolute paths
 	absServerDir, err := filepath.Abs(serverDir)
 	if err != nil {
@@ -120,33 +648,295 @@ func runServer(cmd *cobra.Command, args []string) {
 
 	// Build server configuration
 	config := &server.Config{
-		RamMin:         ramMin,
-		RamMax:         ramMax,
-		Port:           port,
-		ServerDir:      absServerDir,
-		JavaPath:       javaPath,
-		JavaArgs:       javaArgs,
-		ModpackID:      modpackID,
-		ModpackVersion: modpackVersion,
-		AutoRestart:    autoRestart,
-		BackupEnabled:  backupEnabled,
-		BackupInterval: backupInterval,
-		BackupDir:      absBackupDir,
-		MaxBackups:     maxBackups,
+		RamMin:           ramMin,
+		RamMax:           ramMax,
+		Port:             port,
+		ServerDir:        absServerDir,
+		JavaPath:         javaPath,
+		JavaArgs:         javaArgs,
+		ModpackID:        modpackID,
+		ModpackVersion:   modpackVersion,
+		MCVersion:        mcVersion,
+		Snapshots:        snapshots,
+		ServerType:       serverType,
+		AcceptEULA:       acceptEULA,
+		AutoRestart:      autoRestart,
+		CrashLimit:       crashLimit,
+		StartTimeout:     startTimeout,
+		BackupEnabled:    backupEnabled,
+		BackupInterval:   backupInterval,
+		BackupDir:        absBackupDir,
+		MaxBackups:       maxBackups,
+		AutosaveInterval: autosaveInterval,
+		AutosaveOwn:      autosaveOwn,
+		BackupWorlds:     backupWorlds,
+		BackupExclude:    backupExclude,
+		BackupTargets:    backupTargets,
+		BackupRemoteKeep: backupRemoteKeep,
+
+		BedrockCrossplay: bedrockCrossplay,
+		BedrockPort:      bedrockPort,
+
+		ViaVersion: viaVersion,
+
+		ProxyAddresses: proxyAddresses,
+
+		ThrottleJoins:    throttleJoins,
+		ThrottleWindow:   throttleWindow,
+		ThrottleFirewall: throttleFirewall,
+		FloodJoins:       floodJoins,
+
+		QueryEnabled: queryEnabled,
+		QueryPort:    queryPort,
+
+		HealthInterval: healthInterval,
+		PublicAddress:  publicAddress,
+		DetectPublicIP: detectPublicIP,
+
+		ResourcePackPort:    resourcePackPort,
+		ResourcePackHost:    resourcePackHost,
+		RequireResourcePack: requireResourcePack,
+
+		AdaptiveView:        adaptiveView,
+		ViewDistanceMin:     viewDistanceMin,
+		ViewDistanceMax:     viewDistanceMax,
+		SimDistanceMin:      simDistanceMin,
+		SimDistanceMax:      simDistanceMax,
+		ViewDistanceCommand: viewDistanceCommand,
+
+		StatsInterval:      statsInterval,
+		TPSInterval:        tpsInterval,
+		PlayerListInterval: playerListInterval,
+		LagThreshold:       lagThreshold,
+		MetricsHistory:     metricsHistory,
+		MetricsKeep:        metricsKeep,
+		CostPerHour:        costPerHour,
+		DiskAlert:          diskAlert,
+
+		SuspendWhenEmpty: suspendWhenEmpty,
+
+		CPUAffinity:   cpuAffinity,
+		Nice:          niceLevel,
+		PriorityClass: priorityClass,
+
+		CgroupLimits: cgroupLimits,
+		CgroupMemory: cgroupMemory,
+		CgroupCPU:    cgroupCPU,
+
+		Sandbox:           sandboxMode != "off",
+		SandboxBestEffort: sandboxMode == "best-effort",
+
+		LogProfile:          logProfile,
+		PlayerNamePattern:   playerNamePattern,
+		HidePrivateMessages: hidePrivateMessages,
+
+		Scripts: scriptsEnabled,
+
+		GitOpsRepo:     gitopsRepo,
+		GitOpsBranch:   gitopsBranch,
+		GitOpsPath:     gitopsPath,
+		GitOpsInterval: gitopsInterval,
 	}
 
-	if noTUI {
-		// Run in simple console mode
-		srv := server.New(config)
-		if err := srv.RunConsole(); err != nil {
-			fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
+	for _, p := range sandboxPaths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving sandbox path: %v\n", err)
 			os.Exit(1)
 		}
-	} else {
-		// Run with beautiful TUI
-		if err := tui.Run(config); err != nil {
-			fmt.Fprintf(os.Stderr, "TUI error: %v\n", err)
+		config.SandboxPaths = append(config.SandboxPaths, abs)
+	}
+
+	if cpuAffinity != "" {
+		if _, err := server.ParseCPUList(cpuAffinity); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --cpu-affinity: %v\n", err)
 			os.Exit(1)
 		}
 	}
+	if niceLevel < -20 || niceLevel > 19 {
+		fmt.Fprintln(os.Stderr, "Error: --nice must be between -20 and 19")
+		os.Exit(1)
+	}
+	if err := logparse.ValidateNamePattern(playerNamePattern); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --player-name-pattern: %v\n", err)
+		os.Exit(1)
+	}
+	if err := server.ValidateWorldPatterns(append(backupWorlds, backupExclude...)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --backup-worlds/--backup-exclude: %v\n", err)
+		os.Exit(1)
+	}
+	for _, spec := range backupTargets {
+		if _, err := backup.ParseTarget(spec); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --backup-targets: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if priorityClass != "" {
+		if err := server.ValidatePriorityClass(priorityClass); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --priority-class: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if (mcVersion != "" || serverType != "") && modpackID != "" {
+		fmt.Fprintln(os.Stderr, "Error: --mc-version and --modpack both install the server; use one")
+		os.Exit(1)
+	}
+	if err := vanilla.CheckFlavor(serverType); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --server-type: %v\n", err)
+		os.Exit(1)
+	}
+
+	if config.JavaPath, err = jdk.Resolve(javaPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --java: %v\n", err)
+		os.Exit(1)
+	}
+	javaPaths, err := parseJavaInstalls(javaInsts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --java-install: %v\n", err)
+		os.Exit(1)
+	}
+	config.JavaPaths = javaPaths
+
+	declaredOps, err := parseOps(ops)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --op: %v\n", err)
+		os.Exit(1)
+	}
+	config.Ops, config.OpsPrune = declaredOps, opsPrune
+	config.ChatCommands, config.ChatPrefix = chatCommands, chatPrefix
+
+	policies, err := parseRestartPolicies(restartPolicy)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --restart-policy: %v\n", err)
+		os.Exit(1)
+	}
+	config.RestartPolicies = policies
+	for _, expr := range restartCron {
+		if _, err := cron.Parse(expr); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --restart-cron: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	config.RestartCron = restartCron
+
+	if whitelistURL != "" {
+		if _, err := whitelist.NewSource(whitelistURL); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --whitelist-url: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	config.WhitelistURL, config.WhitelistInterval = whitelistURL, whitelistInterval
+	config.RecordConsole, config.RecordKeep = recordConsole, recordKeep
+
+	if adaptiveView && (viewDistanceMin > viewDistanceMax || simDistanceMin > simDistanceMax) {
+		fmt.Fprintln(os.Stderr, "Error: --view-distance-min/--sim-distance-min must not exceed the matching max")
+		os.Exit(1)
+	}
+
+	if resourcePack != "" {
+		absResourcePack, err := filepath.Abs(resourcePack)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving resource pack path: %v\n", err)
+			os.Exit(1)
+		}
+		config.ResourcePack = absResourcePack
+	}
+
+	if velocityDir != "" {
+		absVelocityDir, err := filepath.Abs(velocityDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving Velocity directory: %v\n", err)
+			os.Exit(1)
+		}
+		config.VelocityDir = absVelocityDir
+	}
+	for _, addr := range proxyAddresses {
+		if net.ParseIP(addr) == nil {
+			fmt.Fprintf(os.Stderr, "Error: --proxy-ip: %q is not an IP address\n", addr)
+			os.Exit(1)
+		}
+	}
+
+	return config
+}
+
+// promptEULA asks on the terminal whether the operator accepts the EULA.
+// Without a terminal it declines, and Start reports how to accept.
+func promptEULA() bool {
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	fmt.Print(i18n.T("The Minecraft server needs Mojang's EULA accepted: https://aka.ms/MinecraftEULA") + "\n" + i18n.T("Do you accept it? [y/N] "))
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	// "yes" in the language of the prompt and its first letter count too
+	yes := strings.ToLower(i18n.T("yes"))
+	return answer == "y" || answer == "yes" || answer == yes || answer == string([]rune(yes)[:1])
+}
+
+// parseJavaInstalls turns major=path entries into Java paths by version
+func parseJavaInstalls(entries []string) (map[int]string, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	paths := make(map[int]string, len(entries))
+	for _, entry := range entries {
+		majorText, path, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		major, err := strconv.Atoi(majorText)
+		if !ok || err != nil || major < 5 || path == "" {
+			return nil, fmt.Errorf("%q: expected major=path, e.g. 8=/usr/lib/jvm/java-8/bin/java", entry)
+		}
+		paths[major] = path
+	}
+	return paths, nil
+}
+
+// parseOps turns name[:level] entries into the declared ops
+func parseOps(entries []string) (map[string]int, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	declared := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		name, levelText, hasLevel := strings.Cut(strings.TrimSpace(entry), ":")
+		level := 4
+		if hasLevel {
+			n, err := strconv.Atoi(levelText)
+			if err != nil || n < 1 || n > 4 {
+				return nil, fmt.Errorf("%q: level must be 1-4", entry)
+			}
+			level = n
+		}
+		if name == "" {
+			return nil, fmt.Errorf("%q: missing player name", entry)
+		}
+		declared[name] = level
+	}
+	return declared, nil
+}
+
+// parseRestartPolicies reads --restart-policy entries of the form
+// source:warning[:deadline] on top of the default policies
+func parseRestartPolicies(entries []string) (map[server.RestartSource]server.RestartPolicy, error) {
+	policies := server.DefaultRestartPolicies()
+	for _, entry := range entries {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		source := server.RestartSource(parts[0])
+		if _, ok := policies[source]; !ok || len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("%q: want source:warning[:deadline] with source manual, watchdog or scheduled", entry)
+		}
+		var policy server.RestartPolicy
+		var err error
+		if policy.Warning, err = time.ParseDuration(parts[1]); err != nil || policy.Warning < 0 {
+			return nil, fmt.Errorf("%q: invalid warning %q", entry, parts[1])
+		}
+		if len(parts) == 3 {
+			if policy.Deadline, err = time.ParseDuration(parts[2]); err != nil || policy.Deadline < 0 {
+				return nil, fmt.Errorf("%q: invalid deadline %q", entry, parts[2])
+			}
+		}
+		policies[source] = policy
+	}
+	return policies, nil
 }
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"mcserver-manager/internal/sandbox"
+)
+
+var (
+	sandboxRW         []string
+	sandboxRO         []string
+	sandboxBestEffort bool
+)
+
+// sandboxExecCmd is started by the server in place of java when --sandbox
+// is on. It locks itself down and then execs the real command.
+var sandboxExecCmd = &cobra.Command{
+	Use:    sandbox.HelperCommand + " --rw <path> --ro <path> -- <command> [args...]",
+	Short:  "Run a command under the server sandbox (internal)",
+	Hidden: true,
+	Args:   cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		policy := &sandbox.Policy{ReadWrite: sandboxRW, ReadOnly: sandboxRO, BestEffort: sandboxBestEffort}
+		err := sandbox.Exec(policy, args, os.Environ())
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	},
+}
+
+func init() {
+	sandboxExecCmd.Flags().StringArrayVar(&sandboxRW, "rw", nil, "Path the command may read and write")
+	sandboxExecCmd.Flags().StringArrayVar(&sandboxRO, "ro", nil, "Path the command may read and execute")
+	sandboxExecCmd.Flags().BoolVar(&sandboxBestEffort, "best-effort", false, "Run even when the filesystem allowlist can't be enforced")
+	rootCmd.AddCommand(sandboxExecCmd)
+}
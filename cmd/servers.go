@@ -0,0 +1,388 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"mcserver-manager/internal/cron"
+	"mcserver-manager/internal/jdk"
+	"mcserver-manager/internal/server"
+	"mcserver-manager/internal/tui"
+	"mcserver-manager/internal/vanilla"
+)
+
+var (
+	serversFile       string
+	serverName        string
+	serversCache      []serverProfile
+	backupConcurrency int
+
+	// --backup-dir before --server pointed it at the profile's backups
+	sharedBackupDir string
+)
+
+// serverProfile is one entry of the --servers file. Anything left out is
+// taken from the command line flags.
+type serverProfile struct {
+	Name           string      `json:"name"`
+	Dir            string      `json:"dir"`
+	Port           int         `json:"port,omitempty"`
+	RamMin         string      `json:"ram_min,omitempty"`
+	RamMax         string      `json:"ram_max,omitempty"`
+	Java           looseString `json:"java,omitempty"`
+	JavaArgs       string      `json:"java_args,omitempty"`
+	ModpackID      looseString `json:"modpack,omitempty"`
+	ModpackVersion looseString `json:"modpack_version,omitempty"`
+	MCVersion      looseString `json:"mc_version,omitempty"`
+	ServerType     string      `json:"server_type,omitempty"`
+	BackupEnabled  *bool       `json:"backup_enabled,omitempty"`
+	BackupInterval int         `json:"backup_interval,omitempty"`
+	BackupDir      string      `json:"backup_dir,omitempty"`
+	MaxBackups     int         `json:"max_backups,omitempty"`
+	BackupWorlds   []string    `json:"backup_worlds,omitempty"`
+	BackupExclude  []string    `json:"backup_exclude,omitempty"`
+	AutoRestart    *bool       `json:"auto_restart,omitempty"`
+	RestartCron    []string    `json:"restart_cron,omitempty"`
+}
+
+// looseString is a string that may be written as a number, such as
+// "java: 17" or "modpack: 123456"
+type looseString string
+
+func (l *looseString) UnmarshalJSON(data []byte) error {
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err == nil {
+		*l = looseString(number)
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	*l = looseString(text)
+	return nil
+}
+
+var serversCmd = &cobra.Command{
+	Use:   "servers",
+	Short: "List the servers defined in the --servers file",
+	Args:  cobra.NoArgs,
+	Run:   runServersList,
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&serversFile, "servers", "", "JSON, YAML or TOML file defining several named servers to manage together")
+	rootCmd.PersistentFlags().StringVar(&serverName, "server", "", "Only manage this server from the --servers file")
+	rootCmd.Flags().IntVar(&backupConcurrency, "backup-concurrency", 1, "With --servers, how many servers may archive a backup at once (0 no limit)")
+	rootCmd.AddCommand(serversCmd)
+}
+
+// loadServers reads and checks the --servers file once: a list of
+// servers, or a config file with a servers list. Relative paths in it are
+// relative to the file.
+func loadServers() ([]serverProfile, error) {
+	if serversCache != nil {
+		return serversCache, nil
+	}
+	value, err := decodeFile(serversFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read servers file: %w", err)
+	}
+	if table, ok := value.(map[string]any); ok {
+		value = table["servers"]
+	}
+	list, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: expected a list of servers", serversFile)
+	}
+	// Keys may use dashes like the flags do
+	for _, entry := range list {
+		if table, ok := entry.(map[string]any); ok {
+			for key, v := range table {
+				if name := strings.ReplaceAll(key, "-", "_"); name != key {
+					delete(table, key)
+					table[name] = v
+				}
+			}
+		}
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", serversFile, err)
+	}
+	var profiles []serverProfile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", serversFile, err)
+	}
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("%s defines no servers", serversFile)
+	}
+
+	base, err := filepath.Abs(filepath.Dir(serversFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve servers file: %w", err)
+	}
+	resolve := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(base, path)
+	}
+	seen := map[string]bool{}
+	for i := range profiles {
+		p := &profiles[i]
+		if p.Name == "" || strings.ContainsAny(p.Name, `/\ `) {
+			return nil, fmt.Errorf("server %d in %s needs a name without spaces or slashes", i+1, serversFile)
+		}
+		if seen[p.Name] {
+			return nil, fmt.Errorf("server %q is defined twice in %s", p.Name, serversFile)
+		}
+		seen[p.Name] = true
+		if p.Dir == "" {
+			return nil, fmt.Errorf("server %q in %s has no dir", p.Name, serversFile)
+		}
+		p.Dir = resolve(p.Dir)
+		p.BackupDir = resolve(p.BackupDir)
+	}
+	serversCache = profiles
+	return profiles, nil
+}
+
+// findServer returns the profile called name
+func findServer(name string) (serverProfile, error) {
+	profiles, err := loadServers()
+	if err != nil {
+		return serverProfile{}, err
+	}
+	var names []string
+	for _, p := range profiles {
+		if p.Name == name {
+			return p, nil
+		}
+		names = append(names, p.Name)
+	}
+	return serverProfile{}, fmt.Errorf("no server %q in %s (have %s)", name, serversFile, strings.Join(names, ", "))
+}
+
+// selectServer points the directory flags at the --server profile, so
+// subcommands that only need the server or backup directory use it too
+func selectServer(cmd *cobra.Command, args []string) {
+	if serverName == "" {
+		return
+	}
+	if serversFile == "" {
+		fmt.Fprintln(os.Stderr, "Error: --server needs --servers")
+		os.Exit(1)
+	}
+	p, err := findServer(serverName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	sharedBackupDir = backupDir
+	serverDir = p.Dir
+	backupDir = p.backupDir(backupDir)
+}
+
+// backupDir returns where the profile's backups go: its own backup_dir,
+// or a folder named after it in the shared one
+func (p serverProfile) backupDir(shared string) string {
+	if p.BackupDir != "" {
+		return p.BackupDir
+	}
+	return filepath.Join(shared, p.Name)
+}
+
+// apply returns a copy of base with the profile's settings
+func (p serverProfile) apply(base *server.Config) (*server.Config, error) {
+	config := *base
+	config.Name = p.Name
+	config.ServerDir = p.Dir
+	if p.Port != 0 {
+		config.Port = p.Port
+	}
+	if p.RamMin != "" {
+		config.RamMin = p.RamMin
+	}
+	if p.RamMax != "" {
+		config.RamMax = p.RamMax
+	}
+	if p.Java != "" {
+		java, err := jdk.Resolve(string(p.Java))
+		if err != nil {
+			return nil, fmt.Errorf("server %q: %w", p.Name, err)
+		}
+		config.JavaPath = java
+	}
+	if p.JavaArgs != "" {
+		config.JavaArgs = p.JavaArgs
+	}
+	if p.ModpackID != "" {
+		config.ModpackID = string(p.ModpackID)
+		config.ModpackVersion = string(p.ModpackVersion)
+	}
+	if p.MCVersion != "" {
+		config.MCVersion = string(p.MCVersion)
+	}
+	if p.ServerType != "" {
+		if err := vanilla.CheckFlavor(p.ServerType); err != nil {
+			return nil, fmt.Errorf("server %q: %w", p.Name, err)
+		}
+		config.ServerType = p.ServerType
+	}
+	if p.BackupEnabled != nil {
+		config.BackupEnabled = *p.BackupEnabled
+	}
+	if p.BackupInterval != 0 {
+		config.BackupInterval = p.BackupInterval
+	}
+	if p.MaxBackups != 0 {
+		config.MaxBackups = p.MaxBackups
+	}
+	if p.BackupWorlds != nil || p.BackupExclude != nil {
+		if err := server.ValidateWorldPatterns(append(p.BackupWorlds, p.BackupExclude...)); err != nil {
+			return nil, fmt.Errorf("server %q: %w", p.Name, err)
+		}
+		config.BackupWorlds, config.BackupExclude = p.BackupWorlds, p.BackupExclude
+	}
+	if p.AutoRestart != nil {
+		config.AutoRestart = *p.AutoRestart
+	}
+	if p.RestartCron != nil {
+		for _, expr := range p.RestartCron {
+			if _, err := cron.Parse(expr); err != nil {
+				return nil, fmt.Errorf("server %q: %w", p.Name, err)
+			}
+		}
+		config.RestartCron = p.RestartCron
+	}
+	shared := base.BackupDir
+	if sharedBackupDir != "" {
+		abs, err := filepath.Abs(sharedBackupDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve backup directory: %w", err)
+		}
+		shared = abs
+	}
+	config.BackupDir = p.backupDir(shared)
+	return &config, nil
+}
+
+// buildRegistry creates every server in the --servers file from the
+// command line flags, after dropping root
+func buildRegistry() *server.Registry {
+	profiles, err := loadServers()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	base := buildFlagConfig()
+	configs := make([]*server.Config, len(profiles))
+	for i, p := range profiles {
+		configs[i], err = p.apply(base)
+		if err == nil {
+			if err = configs[i].ResolveMemory(); err != nil {
+				err = fmt.Errorf("server %q: %w", p.Name, err)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	dropPrivileges(configs...)
+	loadExtensions()
+
+	registry := server.NewRegistry()
+	registry.SetBackupLimit(backupConcurrency)
+	for i, p := range profiles {
+		if _, err := registry.Add(p.Name, configs[i]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	return registry
+}
+
+// runServers runs every server in the --servers file, in the TUI with a
+// server selector (starting on --server) or as one console with each line
+// tagged by server
+func runServers() {
+	registry := buildRegistry()
+	for _, inst := range registry.Instances() {
+		inst.Server.WatchReloadSignal()
+	}
+
+	if !noTUI {
+		if err := tui.RunMulti(registry, serverName); err != nil {
+			fmt.Fprintf(os.Stderr, "TUI error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	var pending []*server.Instance
+	for _, inst := range registry.Instances() {
+		if !inst.Config.AcceptEULA && !server.EULAAccepted(inst.Config.ServerDir) {
+			pending = append(pending, inst)
+		}
+	}
+	if len(pending) > 0 && promptEULA() {
+		for _, inst := range pending {
+			if err := inst.Server.AcceptEULA("console prompt"); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing eula.txt for %s: %v\n", inst.Name, err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	var printMutex sync.Mutex
+	for _, inst := range registry.Instances() {
+		go func() {
+			for line := range inst.Server.OutputChan() {
+				printMutex.Lock()
+				fmt.Printf("[%s] %s\n", inst.Name, line)
+				printMutex.Unlock()
+			}
+		}()
+		go func() {
+			if err := inst.Server.Start(); err != nil {
+				fmt.Fprintf(os.Stderr, "[%s] Server error: %v\n", inst.Name, err)
+			}
+		}()
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	<-sig
+
+	fmt.Println("Shutting down...")
+	registry.StopAll()
+}
+
+func runServersList(cmd *cobra.Command, args []string) {
+	if serversFile == "" {
+		fmt.Fprintln(os.Stderr, "Error: --servers is required")
+		os.Exit(1)
+	}
+	profiles, err := loadServers()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	for _, p := range profiles {
+		portText := "default port"
+		if p.Port != 0 {
+			portText = fmt.Sprintf("port %d", p.Port)
+		}
+		fmt.Printf("%-16s %-12s %s\n", p.Name, portText, p.Dir)
+	}
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"mcserver-manager/internal/server"
+)
+
+var supportBundleCmd = &cobra.Command{
+	Use:   "support-bundle [out.zip]",
+	Short: "Collect logs, crash reports and system info into a zip for bug reports",
+	Long: `Writes a zip for attaching to modpack bug reports and hosting support
+tickets: logs/latest.log, the newest crash reports, JVM error logs and
+thread dumps, the manager's events, its config and server.properties with
+passwords, tokens and secrets redacted, the mods and plugins with their
+versions, and OS, CPU, memory, disk and Java info.
+
+Manager events only live in the manager running the server, so run
+":support-bundle" in its TUI to include them, or use --remote, which writes
+the bundle on the agent's host. Logs contain player names and IPs; look
+through the bundle before posting it publicly.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runSupportBundle,
+}
+
+func init() {
+	rootCmd.AddCommand(supportBundleCmd)
+}
+
+func runSupportBundle(cmd *cobra.Command, args []string) {
+	if remoteAddr != "" {
+		output, err := newRemoteClient().RunAction(strings.Join(append([]string{"support-bundle"}, args...), " "))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(output)
+		return
+	}
+
+	path := server.SupportBundleName()
+	if len(args) == 1 {
+		path = args[0]
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating support bundle: %v\n", err)
+		os.Exit(1)
+	}
+	files, err := server.New(buildConfig()).WriteSupportBundle(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote %s (%d files); check it before sharing, logs include player names and IPs\n", path, files)
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"mcserver-manager/internal/api"
+)
+
+var (
+	tokenRole  string
+	tokenAllow []string
+	tokenDeny  []string
+)
+
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage API tokens and roles for the remote agent",
+}
+
+var tokenAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Create an API token (viewer, operator or admin)",
+	Args:  cobra.ExactArgs(1),
+	Run:   runTokenAdd,
+}
+
+var tokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List API tokens",
+	Args:  cobra.NoArgs,
+	Run:   runTokenList,
+}
+
+var tokenCommandsCmd = &cobra.Command{
+	Use:   "commands <name>",
+	Short: "Set which console commands a token may send",
+	Args:  cobra.ExactArgs(1),
+	Run:   runTokenCommands,
+}
+
+var tokenRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Revoke an API token",
+	Args:  cobra.ExactArgs(1),
+	Run:   runTokenRemove,
+}
+
+func init() {
+	tokenAddCmd.Flags().StringVar(&tokenRole, "role", "viewer", "Role: viewer, operator or admin")
+	for _, c := range []*cobra.Command{tokenAddCmd, tokenCommandsCmd} {
+		c.Flags().StringSliceVar(&tokenAllow, "allow", nil, "Only allow these commands (e.g. kick,ban,\"whitelist add\")")
+		c.Flags().StringSliceVar(&tokenDeny, "deny", nil, "Never allow these commands (e.g. op,stop)")
+	}
+
+	tokenCmd.AddCommand(tokenAddCmd)
+	tokenCmd.AddCommand(tokenListCmd)
+	tokenCmd.AddCommand(tokenCommandsCmd)
+	tokenCmd.AddCommand(tokenRemoveCmd)
+	rootCmd.AddCommand(tokenCmd)
+}
+
+func loadTokenStore() *api.TokenStore {
+	absServerDir, err := filepath.Abs(serverDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving server directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	store, err := api.LoadTokens(absServerDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return store
+}
+
+func runTokenAdd(cmd *cobra.Command, args []string) {
+	role, err := api.ParseRole(tokenRole)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	secret, err := loadTokenStore().Create(args[0], role, tokenAllow, tokenDeny)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Created %s token %q\n", role, args[0])
+	fmt.Printf("   %s\n", secret)
+	fmt.Println("   Store it now, it cannot be shown again.")
+}
+
+func runTokenList(cmd *cobra.Command, args []string) {
+	tokens := loadTokenStore().List()
+	if len(tokens) == 0 {
+		fmt.Println("No API tokens configured")
+		return
+	}
+	for _, tok := range tokens {
+		fmt.Printf("%-20s %-9s", tok.Name, tok.Role)
+		if len(tok.Allow) > 0 {
+			fmt.Printf(" allow: %s", strings.Join(tok.Allow, ", "))
+		}
+		if len(tok.Deny) > 0 {
+			fmt.Printf(" deny: %s", strings.Join(tok.Deny, ", "))
+		}
+		fmt.Println()
+	}
+}
+
+func runTokenCommands(cmd *cobra.Command, args []string) {
+	if err := loadTokenStore().SetCommands(args[0], tokenAllow, tokenDeny); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Updated command rules for %q\n", args[0])
+}
+
+func runTokenRemove(cmd *cobra.Command, args []string) {
+	if err := loadTokenStore().Remove(args[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("🗑️  Revoked token %q\n", args[0])
+}
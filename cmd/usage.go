@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"mcserver-manager/internal/server"
+)
+
+var usageDays int
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show the server's running time, CPU time and memory-hours per day, with an estimated cost",
+	Long: `Shows, per day, how long the server process ran, how much of that time
+nobody was online or it was suspended, the CPU time it used, its
+memory-hours (GiB of resident memory over time) and player-hours. With
+--cost-per-hour it also estimates the hosting cost per day and week, and
+what the hours with nobody online cost, to weigh stopping the server when
+it is empty.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if remoteAddr != "" {
+			runWorldAction(fmt.Sprintf("usage %d", usageDays), true)
+			return
+		}
+		absServerDir, err := filepath.Abs(serverDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving server directory: %v\n", err)
+			os.Exit(1)
+		}
+		report, err := server.UsageReport(absServerDir, usageDays, costPerHour)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(report)
+	},
+}
+
+func init() {
+	usageCmd.Flags().IntVar(&usageDays, "days", 7, "Number of days to show")
+	// Same setting as the server's, so the config file's applies here too
+	usageCmd.Flags().Float64Var(&costPerHour, "cost-per-hour", 0, "Hosting cost per hour the server runs (0 leaves the estimates out)")
+	rootCmd.AddCommand(usageCmd)
+}
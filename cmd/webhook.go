@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"mcserver-manager/internal/api"
+)
+
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Manage signed webhooks that run manager actions over the HTTP API",
+}
+
+var webhookAddCmd = &cobra.Command{
+	Use:   "add <name> <action...>",
+	Short: "Create a webhook that runs an action, e.g. 'backup now' or 'upgrade mypack latest'",
+	Args:  cobra.MinimumNArgs(2),
+	Run:   runWebhookAdd,
+}
+
+var webhookListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List webhooks",
+	Args:  cobra.NoArgs,
+	Run:   runWebhookList,
+}
+
+var webhookRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Delete a webhook",
+	Args:  cobra.ExactArgs(1),
+	Run:   runWebhookRemove,
+}
+
+func init() {
+	webhookCmd.AddCommand(webhookAddCmd)
+	webhookCmd.AddCommand(webhookListCmd)
+	webhookCmd.AddCommand(webhookRemoveCmd)
+	rootCmd.AddCommand(webhookCmd)
+}
+
+func loadWebhookStore() *api.WebhookStore {
+	absServerDir, err := filepath.Abs(serverDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving server directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	store, err := api.LoadWebhooks(absServerDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return store
+}
+
+func runWebhookAdd(cmd *cobra.Command, args []string) {
+	action := strings.Join(args[1:], " ")
+	secret, err := loadWebhookStore().Create(args[0], action)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Created webhook %q running :%s\n", args[0], action)
+	fmt.Printf("   POST /v1/hooks/%s with %s: <unix time>, %s: <unique id without \".\"> and\n", args[0], api.TimestampHeader, api.DeliveryHeader)
+	fmt.Printf("   %s: sha256=<HMAC-SHA256 of \"<time>.<id>.<body>\">\n", api.SignatureHeader)
+	fmt.Printf("   Secret: %s\n", secret)
+}
+
+func runWebhookList(cmd *cobra.Command, args []string) {
+	hooks := loadWebhookStore().List()
+	if len(hooks) == 0 {
+		fmt.Println("No webhooks configured")
+		return
+	}
+	for _, hook := range hooks {
+		fmt.Printf("%-20s /v1/hooks/%-20s :%s\n", hook.Name, hook.Name, hook.Action)
+	}
+}
+
+func runWebhookRemove(cmd *cobra.Command, args []string) {
+	if err := loadWebhookStore().Remove(args[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("🗑️  Removed webhook %q\n", args[0])
+}
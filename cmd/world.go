@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"mcserver-manager/internal/audit"
+	"mcserver-manager/internal/server"
+	"mcserver-manager/internal/world"
+)
+
+var (
+	worldSeed string
+	worldType string
+
+	worldExportName  string
+	worldExportScrub bool
+
+	trimWorld  string
+	trimRadius int
+	trimCenter string
+	trimBorder bool
+	trimApply  bool
+)
+
+var worldCmd = &cobra.Command{
+	Use:   "world",
+	Short: "List, switch, create and archive worlds",
+}
+
+var worldListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List world folders with sizes and versions",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runWorldAction("world list", true)
+	},
+}
+
+var worldUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Make a world the active level-name",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runWorldAction("world use "+args[0], false)
+	},
+}
+
+var worldCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Switch to a new world generated with a seed and level type",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		line := "world create " + args[0]
+		if worldSeed != "" {
+			line += " seed=" + worldSeed
+		}
+		if worldType != "" {
+			line += " type=" + worldType
+		}
+		runWorldAction(line, false)
+	},
+}
+
+var worldArchiveCmd = &cobra.Command{
+	Use:   "archive <name>",
+	Short: "Zip a retired world into the backup directory and remove it",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runWorldAction("world archive "+args[0], false)
+	},
+}
+
+var worldImportCmd = &cobra.Command{
+	Use:   "import <zip|url> [name]",
+	Short: "Replace the active world (or add name) from a zip file or URL",
+	Long: `Validates that the archive contains level.dat, backs up the existing
+worlds, extracts the new one (fixing nested folders) and makes it active.
+Use :world import in the TUI to do this while the server is running; it is
+stopped and restarted around the import.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		source := args[0]
+		if remoteAddr == "" && !world.IsURL(source) {
+			abs, err := filepath.Abs(source)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			source = abs
+		}
+		line := "world import " + source
+		if len(args) == 2 {
+			line += " " + args[1]
+		}
+		runWorldAction(line, false)
+	},
+}
+
+var worldExportCmd = &cobra.Command{
+	Use:   "export <out.zip>",
+	Short: "Export a world as a clean zip for sharing or single-player",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		out := args[0]
+		if remoteAddr == "" {
+			abs, err := filepath.Abs(out)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			out = abs
+		}
+		line := "world export " + out
+		if worldExportName != "" {
+			line += " world=" + worldExportName
+		}
+		if worldExportScrub {
+			line += " scrub-players"
+		}
+		runWorldAction(line, false)
+	},
+}
+
+var worldTrimCmd = &cobra.Command{
+	Use:   "trim",
+	Short: "Delete region files outside a radius or the world border (dry run by default)",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		line := "world trim"
+		if trimWorld != "" {
+			line += " world=" + trimWorld
+		}
+		if trimRadius > 0 {
+			line += fmt.Sprintf(" radius=%d", trimRadius)
+		}
+		if trimCenter != "" {
+			line += " center=" + trimCenter
+		}
+		if trimBorder {
+			line += " border"
+		}
+		if trimApply {
+			line += " apply"
+		}
+		runWorldAction(line, !trimApply)
+	},
+}
+
+func init() {
+	worldCreateCmd.Flags().StringVar(&worldSeed, "seed", "", "World seed (random if empty)")
+	worldCreateCmd.Flags().StringVar(&worldType, "type", "", "Level type, e.g. minecraft:flat, minecraft:large_biomes")
+
+	worldExportCmd.Flags().StringVar(&worldExportName, "world", "", "World to export (defaults to the active world)")
+	worldExportCmd.Flags().BoolVar(&worldExportScrub, "scrub-players", false, "Leave out player inventories, stats and advancements")
+
+	worldTrimCmd.Flags().StringVar(&trimWorld, "world", "", "World to trim (defaults to the active world)")
+	worldTrimCmd.Flags().IntVar(&trimRadius, "radius", 0, "Keep regions within this many blocks of the center")
+	worldTrimCmd.Flags().StringVar(&trimCenter, "center", "", "Center as X,Z (default 0,0)")
+	worldTrimCmd.Flags().BoolVar(&trimBorder, "border", false, "Use the world border for center and radius")
+	worldTrimCmd.Flags().BoolVar(&trimApply, "apply", false, "Actually delete the files (otherwise only report)")
+
+	worldCmd.AddCommand(worldListCmd)
+	worldCmd.AddCommand(worldUseCmd)
+	worldCmd.AddCommand(worldCreateCmd)
+	worldCmd.AddCommand(worldArchiveCmd)
+	worldCmd.AddCommand(worldImportCmd)
+	worldCmd.AddCommand(worldExportCmd)
+	worldCmd.AddCommand(worldTrimCmd)
+	rootCmd.AddCommand(worldCmd)
+}
+
+// runWorldAction runs a world action on the remote agent, or directly on
+// the server directory when no server is running there
+func runWorldAction(line string, readOnly bool) {
+	output, err := runOfflineOrRemote(line, readOnly)
+	// Output can come with an error, like the damaged backups verify found
+	if output != "" {
+		fmt.Println(output)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runOfflineOrRemote runs a manager action line through --remote if set,
+// otherwise against the local server directory, which must not have a
+// running server unless the action is read-only (use ":" actions in its
+// TUI instead)
+func runOfflineOrRemote(line string, readOnly bool) (string, error) {
+	if remoteAddr != "" {
+		return newRemoteClient().RunAction(line)
+	}
+
+	absServerDir, err := filepath.Abs(serverDir)
+	if err != nil {
+		return "", fmt.Errorf("resolving server directory: %w", err)
+	}
+	if pid, running := server.RunningPID(absServerDir); running && !readOnly {
+		return "", fmt.Errorf("the server in %s is running (pid %d); use :%s in its TUI or --remote", absServerDir, pid, line)
+	}
+
+	absBackupDir, err := filepath.Abs(backupDir)
+	if err != nil {
+		return "", fmt.Errorf("resolving backup directory: %w", err)
+	}
+
+	actor := "local"
+	if u, err := user.Current(); err == nil {
+		actor = u.Username
+	}
+
+	srv := server.New(&server.Config{ServerDir: absServerDir, BackupDir: absBackupDir, MaxBackups: maxBackups})
+	return srv.RunAction(audit.SourceCLI, actor, line)
+}
@@ -0,0 +1,162 @@
+package addons
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const geyserDownloadBase = "https://download.geysermc.org/v2/projects"
+
+// Platform is the plugin/mod platform an add-on is built for
+type Platform string
+
+const (
+	PlatformSpigot   Platform = "spigot"
+	PlatformFabric   Platform = "fabric"
+	PlatformNeoForge Platform = "neoforge"
+)
+
+// GeyserResult describes what InstallGeyser did
+type GeyserResult struct {
+	Platform   Platform
+	Installed  []string // jars downloaded this run
+	ConfigPath string
+	Warnings   []string
+}
+
+// DetectPlatform works out which add-on platform a server directory runs
+func DetectPlatform(serverDir string) (Platform, error) {
+	if _, err := os.Stat(filepath.Join(serverDir, "libraries/net/neoforged")); err == nil {
+		return PlatformNeoForge, nil
+	}
+	if _, err := os.Stat(filepath.Join(serverDir, "libraries/net/minecraftforge/forge")); err == nil {
+		return "", fmt.Errorf("Geyser does not support Forge servers (use NeoForge, Fabric, or Paper)")
+	}
+	if _, err := os.Stat(filepath.Join(serverDir, ".fabric")); err == nil {
+		return PlatformFabric, nil
+	}
+
+	entries, err := os.ReadDir(serverDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read server directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := strings.ToLower(entry.Name())
+		if !strings.HasSuffix(name, ".jar") {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(name, "fabric-server"):
+			return PlatformFabric, nil
+		case strings.HasPrefix(name, "paper"), strings.HasPrefix(name, "purpur"), strings.HasPrefix(name, "spigot"):
+			return PlatformSpigot, nil
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(serverDir, "plugins")); err == nil {
+		return PlatformSpigot, nil
+	}
+
+	return "", fmt.Errorf("could not detect a Geyser-compatible platform (Paper/Spigot, Fabric, NeoForge)")
+}
+
+// InstallGeyser downloads Geyser and Floodgate for the detected platform if
+// they are missing and points Geyser's Bedrock listener at bedrockPort
+func InstallGeyser(serverDir string, bedrockPort, javaPort int) (*GeyserResult, error) {
+	platform, err := DetectPlatform(serverDir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GeyserResult{Platform: platform}
+
+	var targetDir, configDir string
+	switch platform {
+	case PlatformSpigot:
+		targetDir = filepath.Join(serverDir, "plugins")
+		configDir = filepath.Join(targetDir, "Geyser-Spigot")
+	case PlatformFabric:
+		targetDir = filepath.Join(serverDir, "mods")
+		configDir = filepath.Join(serverDir, "config", "Geyser-Fabric")
+		result.Warnings = append(result.Warnings, "Geyser-Fabric requires Fabric API in the mods folder")
+	case PlatformNeoForge:
+		targetDir = filepath.Join(serverDir, "mods")
+		configDir = filepath.Join(serverDir, "config", "Geyser-NeoForge")
+	}
+
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", targetDir, err)
+	}
+
+	for _, project := range []string{"geyser", "floodgate"} {
+		dest := filepath.Join(targetDir, fmt.Sprintf("%s-%s.jar", project, platform))
+		if _, err := os.Stat(dest); err == nil {
+			continue
+		}
+
+		url := fmt.Sprintf("%s/%s/versions/latest/builds/latest/downloads/%s", geyserDownloadBase, project, platform)
+		if err := downloadFile(url, dest); err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", project, err)
+		}
+		result.Installed = append(result.Installed, filepath.Base(dest))
+	}
+
+	result.ConfigPath = filepath.Join(configDir, "config.yml")
+	if err := configureGeyser(result.ConfigPath, bedrockPort, javaPort); err != nil {
+		return nil, fmt.Errorf("failed to configure Geyser: %w", err)
+	}
+
+	return result, nil
+}
+
+// configureGeyser writes a minimal Geyser config, or updates the Bedrock port
+// and auth type in an existing one
+func configureGeyser(configPath string, bedrockPort, javaPort int) error {
+	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+			return err
+		}
+		config := fmt.Sprintf(`# Generated by MCServer Manager. Geyser fills in the remaining defaults.
+bedrock:
+  address: 0.0.0.0
+  port: %d
+remote:
+  address: auto
+  port: %d
+  auth-type: floodgate
+`, bedrockPort, javaPort)
+		return os.WriteFile(configPath, []byte(config), 0644)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Patch keys in place so the rest of the user's config is preserved
+	lines := strings.Split(string(data), "\n")
+	section := ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") && strings.HasSuffix(trimmed, ":") {
+			section = strings.TrimSuffix(trimmed, ":")
+			continue
+		}
+
+		indent := line[:len(line)-len(strings.TrimLeft(line, " "))]
+		switch {
+		case section == "bedrock" && strings.HasPrefix(trimmed, "port:"):
+			lines[i] = indent + "port: " + strconv.Itoa(bedrockPort)
+		case section == "remote" && strings.HasPrefix(trimmed, "auth-type:"):
+			lines[i] = indent + "auth-type: floodgate"
+		}
+	}
+
+	return os.WriteFile(configPath, []byte(strings.Join(lines, "\n")), 0644)
+}
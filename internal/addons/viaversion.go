@@ -0,0 +1,249 @@
+package addons
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const hangarAPIBase = "https://hangar.papermc.io/api/v1"
+
+// viaProjects are the Hangar projects kept installed by InstallViaVersion
+var viaProjects = []string{"ViaVersion", "ViaBackwards"}
+
+// addonsStateFile records installed add-on versions, relative to the server dir
+const addonsStateFile = ".mcserver/addons.json"
+
+var paperJarRegex = regexp.MustCompile(`^(?:paper|purpur)-(\d+\.\d+(?:\.\d+)?)-.*\.jar$`)
+
+// ViaResult describes what InstallViaVersion did
+type ViaResult struct {
+	MCVersion string
+	Updated   map[string]string // project -> new version
+	Current   map[string]string // project -> installed version
+}
+
+type hangarVersion struct {
+	Name                 string              `json:"name"`
+	PlatformDependencies map[string][]string `json:"platformDependencies"`
+	Downloads            map[string]struct {
+		DownloadURL string `json:"downloadUrl"`
+		ExternalURL string `json:"externalUrl"`
+		FileInfo    struct {
+			Name string `json:"name"`
+		} `json:"fileInfo"`
+	} `json:"downloads"`
+}
+
+// DetectPaperVersion returns the Minecraft version of a Paper (or Purpur)
+// server from its jar name
+func DetectPaperVersion(serverDir string) (string, error) {
+	entries, err := os.ReadDir(serverDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read server directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if matches := paperJarRegex.FindStringSubmatch(strings.ToLower(entry.Name())); matches != nil {
+			return matches[1], nil
+		}
+	}
+
+	return "", fmt.Errorf("no Paper server jar found in %s", serverDir)
+}
+
+// InstallViaVersion installs ViaVersion and ViaBackwards into plugins/,
+// upgrading them whenever a newer build compatible with the server's
+// Minecraft version is published
+func InstallViaVersion(serverDir string) (*ViaResult, error) {
+	return installVia(serverDir, false)
+}
+
+// StageViaVersion is InstallViaVersion for a running server: new builds go
+// to Paper's plugins/update folder, which the server swaps in on its next
+// start, instead of over jars the server has open
+func StageViaVersion(serverDir string) (*ViaResult, error) {
+	return installVia(serverDir, true)
+}
+
+func installVia(serverDir string, staged bool) (*ViaResult, error) {
+	mcVersion, err := DetectPaperVersion(serverDir)
+	if err != nil {
+		return nil, err
+	}
+
+	pluginsDir := filepath.Join(serverDir, "plugins")
+	destDir := pluginsDir
+	if staged {
+		destDir = filepath.Join(pluginsDir, "update")
+	}
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create plugins directory: %w", err)
+	}
+
+	state := loadAddonsState(serverDir)
+	result := &ViaResult{
+		MCVersion: mcVersion,
+		Updated:   map[string]string{},
+		Current:   map[string]string{},
+	}
+
+	for _, project := range viaProjects {
+		version, err := latestHangarVersion(project, mcVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find %s for %s: %w", project, mcVersion, err)
+		}
+
+		installed := state[project]
+		jarPath := filepath.Join(pluginsDir, project+".jar")
+		if _, statErr := os.Stat(jarPath); statErr == nil && installed.Version == version.Name {
+			result.Current[project] = installed.Version
+			continue
+		}
+
+		download := version.Downloads["PAPER"]
+		link := download.DownloadURL
+		if link == "" {
+			link = download.ExternalURL
+		}
+		if link == "" {
+			return nil, fmt.Errorf("%s %s has no Paper download", project, version.Name)
+		}
+
+		if err := downloadFile(link, filepath.Join(destDir, filepath.Base(jarPath))); err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", project, err)
+		}
+
+		state[project] = addonState{Version: version.Name, File: filepath.Base(jarPath)}
+		result.Updated[project] = version.Name
+		result.Current[project] = version.Name
+	}
+
+	if err := saveAddonsState(serverDir, state); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// latestHangarVersion returns the newest release of a Hangar project whose
+// Paper platform range includes mcVersion
+func latestHangarVersion(project, mcVersion string) (*hangarVersion, error) {
+	query := url.Values{}
+	query.Set("limit", "25")
+	query.Set("channel", "Release")
+	query.Set("platform", "PAPER")
+
+	resp, err := http.Get(fmt.Sprintf("%s/projects/%s/versions?%s", hangarAPIBase, project, query.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Hangar API returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Result []hangarVersion `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	// Hangar lists newest first
+	for i := range result.Result {
+		if supportsVersion(result.Result[i].PlatformDependencies["PAPER"], mcVersion) {
+			return &result.Result[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("no compatible release")
+}
+
+// supportsVersion reports whether a Hangar platform list ("1.20.4",
+// "1.8-1.20.4", "1.21.x", ...) includes mcVersion. "1.21.x" stands for
+// every 1.21 release, 1.21 itself included.
+func supportsVersion(ranges []string, mcVersion string) bool {
+	for _, r := range ranges {
+		if lo, hi, ok := strings.Cut(r, "-"); ok {
+			if compareBound(mcVersion, lo) >= 0 && compareBound(mcVersion, hi) <= 0 {
+				return true
+			}
+			continue
+		}
+		if compareBound(mcVersion, r) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// compareBound compares a version with a platform bound, where a bound
+// ending in ".x" covers its whole major.minor line
+func compareBound(version, bound string) int {
+	if line, ok := strings.CutSuffix(bound, ".x"); ok {
+		return compareVersions(majorMinor(version), line)
+	}
+	return compareVersions(version, bound)
+}
+
+// majorMinor cuts a version to its first two parts, "1.21.4" to "1.21"
+func majorMinor(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	return strings.Join(parts[:min(len(parts), 2)], ".")
+}
+
+// compareVersions compares dotted version strings numerically
+func compareVersions(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+type addonState struct {
+	Version string `json:"version"`
+	File    string `json:"file"`
+}
+
+func loadAddonsState(serverDir string) map[string]addonState {
+	state := map[string]addonState{}
+	if data, err := os.ReadFile(filepath.Join(serverDir, addonsStateFile)); err == nil {
+		json.Unmarshal(data, &state)
+	}
+	return state
+}
+
+func saveAddonsState(serverDir string, state map[string]addonState) error {
+	path := filepath.Join(serverDir, addonsStateFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,167 @@
+// Package advisory matches a server's software and mods against a small
+// database of known vulnerabilities: the one built in, plus the entries of
+// .mcserver/advisories.json in the server directory.
+package advisory
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"mcserver-manager/internal/modinfo"
+	"mcserver-manager/internal/servertype"
+)
+
+// File holds a server's own advisories, relative to the server dir
+const File = ".mcserver/advisories.json"
+
+//go:embed advisories.json
+var builtin []byte
+
+// Advisory is one known vulnerability. It applies to the server software
+// of type Software, or to the mod or plugin with ID Mod, on Minecraft MC
+// when set, in versions below Fixed (all versions when empty).
+type Advisory struct {
+	ID       string `json:"id"`
+	Software string `json:"software,omitempty"`
+	Mod      string `json:"mod,omitempty"`
+	MC       string `json:"mc,omitempty"`
+	Fixed    string `json:"fixed,omitempty"`
+	CVE      string `json:"cve,omitempty"`
+	Summary  string `json:"summary"`
+	URL      string `json:"url,omitempty"`
+}
+
+// Finding is an advisory that applies to this server
+type Finding struct {
+	Advisory Advisory
+	Subject  string // the software, or the jar of the mod
+	Version  string
+}
+
+func (f Finding) String() string {
+	text := fmt.Sprintf("%s %s: %s", f.Subject, f.Version, f.Advisory.Summary)
+	if f.Advisory.CVE != "" {
+		text += " (" + f.Advisory.CVE + ")"
+	}
+	if f.Advisory.Fixed != "" {
+		text += "; fixed in " + f.Advisory.Fixed
+	}
+	if f.Advisory.URL != "" {
+		text += ", see " + f.Advisory.URL
+	}
+	return text
+}
+
+// Load returns the built-in advisories and the server's own. An entry of
+// the server's with the ID of a built-in one replaces it.
+func Load(serverDir string) ([]Advisory, error) {
+	var advisories []Advisory
+	if err := json.Unmarshal(builtin, &advisories); err != nil {
+		return nil, fmt.Errorf("built-in advisories: %w", err)
+	}
+	data, err := os.ReadFile(filepath.Join(serverDir, File))
+	if os.IsNotExist(err) {
+		return advisories, nil
+	} else if err != nil {
+		return advisories, err
+	}
+	var own []Advisory
+	if err := json.Unmarshal(data, &own); err != nil {
+		return advisories, fmt.Errorf("failed to parse %s: %w", File, err)
+	}
+	for _, a := range own {
+		replaced := false
+		for i := range advisories {
+			if advisories[i].ID == a.ID {
+				advisories[i], replaced = a, true
+			}
+		}
+		if !replaced {
+			advisories = append(advisories, a)
+		}
+	}
+	return advisories, nil
+}
+
+// Check returns the advisories that apply to the server software and the
+// jars in mods/ and plugins/
+func Check(serverDir string, info *servertype.Info) ([]Finding, error) {
+	advisories, err := Load(serverDir)
+	var findings []Finding
+	var mods []modinfo.Mod
+	for _, a := range advisories {
+		if a.MC != "" && (info == nil || a.MC != info.MCVersion) {
+			continue
+		}
+		switch {
+		case a.Software != "":
+			if info != nil && info.Type == a.Software && info.LoaderVersion != "" && affected(info.LoaderVersion, a.Fixed, "") {
+				findings = append(findings, Finding{Advisory: a, Subject: info.Type, Version: info.LoaderVersion})
+			}
+		case a.Mod != "":
+			if mods == nil {
+				mods = modinfo.List(serverDir)
+			}
+			mc := ""
+			if info != nil {
+				mc = info.MCVersion
+			}
+			for _, m := range mods {
+				if strings.EqualFold(m.ID, a.Mod) && affected(m.Version, a.Fixed, mc) {
+					findings = append(findings, Finding{Advisory: a, Subject: m.File, Version: m.Version})
+				}
+			}
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Subject < findings[j].Subject })
+	return findings, err
+}
+
+// affected reports whether version is below fixed. Mod versions often
+// start with the Minecraft version ("1.12.2-0.5.77"), which is dropped.
+func affected(version, fixed, mc string) bool {
+	if fixed == "" {
+		return true
+	}
+	if mc != "" {
+		version = strings.TrimPrefix(strings.TrimPrefix(version, "mc"), mc+"-")
+	}
+	return compare(version, fixed) < 0
+}
+
+// compare orders versions by their runs of digits: 0.10.0.71 after 0.9.4,
+// 1.2.3-beta.4 as 1.2.3.4
+func compare(a, b string) int {
+	pa, pb := numbers(a), numbers(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func numbers(v string) []int {
+	var out []int
+	for _, field := range strings.FieldsFunc(v, func(r rune) bool { return r < '0' || r > '9' }) {
+		n, _ := strconv.Atoi(field)
+		out = append(out, n)
+	}
+	return out
+}
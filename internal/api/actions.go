@@ -0,0 +1,71 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+
+	"mcserver-manager/internal/audit"
+	"mcserver-manager/internal/server"
+)
+
+// errForbidden marks requests rejected for the caller's role
+var errForbidden = errors.New("forbidden")
+
+// SendCommand checks that the caller may send command, sends it and records
+// it in the audit log. Rejected commands are recorded too.
+func (a *Agent) SendCommand(id Identity, command string) error {
+	if err := a.policy.Check(id, command); err != nil {
+		a.srv.RecordDenied(id.source(), id.Name, audit.KindCommand, command, err.Error())
+		return fmt.Errorf("%w: %v", errForbidden, err)
+	}
+	return a.srv.SendCommandAs(id.source(), id.Name, command)
+}
+
+// runAction runs an administrative action and records the outcome
+func (a *Agent) runAction(id Identity, action, detail string, fn func() error) error {
+	err := fn()
+	a.srv.RecordAction(id.source(), id.Name, action, detail, err)
+	return err
+}
+
+// denyAction records an action rejected for the caller's role
+func (a *Agent) denyAction(id Identity, action string, required Role) {
+	a.srv.RecordDenied(id.source(), id.Name, audit.KindAction, action, fmt.Sprintf("%s role required", required))
+}
+
+// runManagerAction runs a manager action line, checking that admin-only
+// actions come from an admin
+func (a *Agent) runManagerAction(id Identity, line string) (string, error) {
+	action, _, err := server.LookupAction(line)
+	if err != nil {
+		return "", err
+	}
+	if action.Admin && !id.Role.Allows(RoleAdmin) {
+		a.denyAction(id, line, RoleAdmin)
+		return "", fmt.Errorf("%w: %s role required", errForbidden, RoleAdmin)
+	}
+	return a.srv.RunAction(id.source(), id.Name, line)
+}
+
+// Restart restarts the server in the background with the manual restart
+// policy, if the caller is an admin
+func (a *Agent) Restart(id Identity) error {
+	if !id.Role.Allows(RoleAdmin) {
+		a.denyAction(id, "restart", RoleAdmin)
+		return fmt.Errorf("%w: %s role required", errForbidden, RoleAdmin)
+	}
+	go a.runAction(id, "restart", "", a.srv.Restart)
+	return nil
+}
+
+// DenyAction records an action a front end on top of the agent turned
+// down for the caller's role
+func (a *Agent) DenyAction(id Identity, action string, required Role) {
+	a.denyAction(id, action, required)
+}
+
+// Notify adds an event to the server's event log, for front ends on top
+// of the agent
+func (a *Agent) Notify(eventType server.EventType, message string) {
+	a.srv.Notify(eventType, message)
+}
@@ -0,0 +1,381 @@
+package api
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"mcserver-manager/internal/server"
+	"mcserver-manager/pkg/eventbus"
+)
+
+// How many recent console lines a new subscriber receives
+const consoleBacklog = 500
+
+// How long one batch of console lines may take to reach a client
+const consoleWriteTimeout = 10 * time.Second
+
+// Agent exposes a local server's control API so a remote client can drive
+// the TUI and CLI subcommands against it
+type Agent struct {
+	srv      *server.Server
+	tokens   *TokenStore
+	policy   *CommandPolicy
+	webhooks *WebhookStore
+
+	console *eventbus.Bus[string]
+}
+
+// NewAgent creates an agent for srv. The agent takes ownership of the
+// server's output channel and fans it out to subscribers; events come
+// straight from the server's event bus.
+// With no tokens configured every mTLS client is treated as an admin.
+// webhooks may be nil.
+func NewAgent(srv *server.Server, tokens *TokenStore, policy *CommandPolicy, webhooks *WebhookStore) *Agent {
+	a := &Agent{
+		srv:      srv,
+		tokens:   tokens,
+		policy:   policy,
+		webhooks: webhooks,
+		console:  eventbus.New[string](consoleBacklog),
+	}
+	go a.pump()
+	return a
+}
+
+// ListenAndServe serves the control API on addr using tlsConfig
+func (a *Agent) ListenAndServe(addr string, tlsConfig *tls.Config) error {
+	httpServer := &http.Server{
+		Addr:      addr,
+		Handler:   a.Handler(),
+		TLSConfig: tlsConfig,
+	}
+	return httpServer.ListenAndServeTLS("", "")
+}
+
+// Serve serves the control API over plain HTTP on ln, for scripts and
+// browsers. Without client certificates only API tokens authenticate, so
+// it refuses to run when none are configured.
+func (a *Agent) Serve(ln net.Listener) error {
+	if a.tokens == nil || a.tokens.Empty() {
+		return fmt.Errorf("the HTTP API needs an API token (see 'mcserver token add')")
+	}
+	httpServer := &http.Server{
+		Handler:           a.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return httpServer.Serve(ln)
+}
+
+// Handler returns the HTTP handler for the control API
+func (a *Agent) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/status", a.route(http.MethodGet, RoleViewer, a.handleStatus))
+	mux.HandleFunc("/v1/stats", a.route(http.MethodGet, RoleViewer, a.handleStats))
+	mux.HandleFunc("/v1/players", a.route(http.MethodGet, RoleViewer, a.handlePlayers))
+	mux.HandleFunc("/v1/console", a.route(http.MethodGet, RoleViewer, a.handleConsole))
+	mux.HandleFunc("/v1/command", a.route(http.MethodPost, RoleOperator, a.handleCommand))
+	mux.HandleFunc("/v1/action", a.route(http.MethodPost, RoleOperator, a.handleAction))
+	mux.HandleFunc("/v1/backups", a.handleBackups)
+	mux.HandleFunc("/v1/restore", a.route(http.MethodPost, RoleAdmin, a.handleRestore))
+	mux.HandleFunc("/v1/start", a.route(http.MethodPost, RoleAdmin, a.handleLifecycle("start", a.srv.Start)))
+	mux.HandleFunc("/v1/stop", a.route(http.MethodPost, RoleAdmin, a.handleLifecycle("stop", a.srv.Stop)))
+	mux.HandleFunc("/v1/restart", a.route(http.MethodPost, RoleAdmin, a.handleLifecycle("restart", a.srv.Restart)))
+	mux.HandleFunc("/v1/hooks/", a.handleWebhook)
+	return mux
+}
+
+// route rejects requests that don't use the given HTTP method or whose
+// token lacks the required role
+func (a *Agent) route(method string, required Role, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s required", method))
+			return
+		}
+
+		// Only an explicit bearer token counts: a browser attaches basic
+		// auth and cookies to requests other sites' pages make, so
+		// accepting those would let any page drive the API
+		id, ok := a.authenticate(r.Header.Get("Authorization"), r.TLS != nil)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or invalid API token, send Authorization: Bearer <token>"))
+			return
+		}
+		if !id.Role.Allows(required) {
+			if required != RoleViewer {
+				a.denyAction(id, r.URL.Path, required)
+			}
+			writeError(w, http.StatusForbidden, fmt.Errorf("%s role required", required))
+			return
+		}
+
+		h(w, r.WithContext(withIdentity(r.Context(), id)))
+	}
+}
+
+// Authenticate resolves a token sent some other way than the
+// Authorization header, such as the dashboard's first WebSocket message.
+// mtls tells whether the client passed mutual TLS.
+func (a *Agent) Authenticate(token string, mtls bool) (Identity, bool) {
+	return a.authenticate("Bearer "+token, mtls)
+}
+
+// authenticate resolves an "Authorization: Bearer <token>" value. With no
+// tokens configured, only a client that passed mutual TLS gets in.
+func (a *Agent) authenticate(header string, mtls bool) (Identity, bool) {
+	if a.tokens == nil || a.tokens.Empty() {
+		return Identity{Name: "mtls", Role: RoleAdmin}, mtls
+	}
+	secret, found := strings.CutPrefix(header, "Bearer ")
+	if !found {
+		return Identity{}, false
+	}
+	return a.tokens.Authenticate(strings.TrimSpace(secret))
+}
+
+// Subscribe returns a channel of console lines, starting with the recent
+// backlog, and a function that cancels the subscription
+func (a *Agent) Subscribe() (<-chan string, func()) {
+	sub := a.console.Subscribe(eventbus.WithBacklog[string](), eventbus.WithBuffer[string](consoleBacklog+100))
+	return sub.C(), sub.Close
+}
+
+// Stats returns the server's statistics
+func (a *Agent) Stats() server.ServerStats {
+	return a.srv.GetStats()
+}
+
+func (a *Agent) pump() {
+	for line := range a.srv.OutputChan() {
+		a.console.Publish(line)
+	}
+}
+
+func (a *Agent) handleStats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, a.srv.GetStats())
+}
+
+func (a *Agent) handleStatus(w http.ResponseWriter, r *http.Request) {
+	stats := a.srv.GetStats()
+	resp := statusResponse{
+		Status:     stats.Status.String(),
+		Uptime:     int64(stats.Uptime.Seconds()),
+		Players:    stats.PlayerCount,
+		MaxPlayers: stats.MaxPlayers,
+		TPS:        stats.TPS,
+		MSPT:       stats.MSPT,
+		MemoryUsed: stats.MemoryUsed,
+		MemoryMax:  stats.MemoryMax,
+		CPUPercent: stats.CPUPercent,
+		Address:    stats.ShareAddress,
+	}
+	if stats.Software != nil {
+		resp.Software = stats.Software.String()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (a *Agent) handlePlayers(w http.ResponseWriter, r *http.Request) {
+	players := []playerResponse{}
+	for _, p := range a.srv.GetStats().Players {
+		players = append(players, playerResponse{Name: p.Name, UUID: p.UUID, JoinedAt: p.JoinedAt, Bedrock: p.Bedrock})
+	}
+	writeJSON(w, http.StatusOK, players)
+}
+
+func (a *Agent) handleCommand(w http.ResponseWriter, r *http.Request) {
+	var req commandRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Command == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("request body must be {\"command\": \"...\"}"))
+		return
+	}
+
+	if err := a.SendCommand(IdentityFrom(r.Context()), req.Command); err != nil {
+		status := http.StatusConflict
+		if errors.Is(err, errForbidden) {
+			status = http.StatusForbidden
+		}
+		writeError(w, status, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *Agent) handleAction(w http.ResponseWriter, r *http.Request) {
+	var req actionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Action == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("request body must be {\"action\": \"...\"}"))
+		return
+	}
+
+	output, err := a.runManagerAction(IdentityFrom(r.Context()), req.Action)
+	if err != nil {
+		status := http.StatusUnprocessableEntity
+		if errors.Is(err, errForbidden) {
+			status = http.StatusForbidden
+		}
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, actionResponse{Output: output})
+}
+
+func (a *Agent) handleBackups(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		a.route(http.MethodPost, RoleOperator, func(w http.ResponseWriter, r *http.Request) {
+			if err := a.runAction(IdentityFrom(r.Context()), "backup", "", a.srv.Backup); err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})(w, r)
+		return
+	}
+
+	a.route(http.MethodGet, RoleViewer, func(w http.ResponseWriter, r *http.Request) {
+		backups, err := a.srv.ListBackups()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, backups)
+	})(w, r)
+}
+
+func (a *Agent) handleRestore(w http.ResponseWriter, r *http.Request) {
+	var req restoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("request body must be {\"name\": \"backup_....zip\"}"))
+		return
+	}
+
+	err := a.runAction(IdentityFrom(r.Context()), "restore", req.Name, func() error {
+		return a.srv.RestoreBackup(req.Name)
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleLifecycle runs a start/stop/restart in the background, the same way
+// the TUI does, since they can take minutes to finish
+func (a *Agent) handleLifecycle(name string, action func() error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		go a.runAction(IdentityFrom(r.Context()), name, "", action)
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// handleConsole streams console lines as plain text until the client
+// disconnects
+func (a *Agent) handleConsole(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming not supported"))
+		return
+	}
+
+	lines, cancel := a.Subscribe()
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// A client that stops reading would block a write forever and keep the
+	// subscription alive, so every batch gets a deadline
+	rc := http.NewResponseController(w)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			rc.SetWriteDeadline(time.Now().Add(consoleWriteTimeout))
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return
+			}
+			// Batch whatever else is already queued before flushing
+			for drained := false; !drained; {
+				select {
+				case more, ok := <-lines:
+					if !ok {
+						return
+					}
+					if _, err := fmt.Fprintln(w, more); err != nil {
+						return
+					}
+				case <-r.Context().Done():
+					return
+				default:
+					drained = true
+				}
+			}
+			if rc.Flush() != nil {
+				return
+			}
+		}
+	}
+}
+
+type statusResponse struct {
+	Status     string  `json:"status"`
+	Uptime     int64   `json:"uptime_seconds"`
+	Players    int     `json:"players"`
+	MaxPlayers int     `json:"max_players"`
+	TPS        float64 `json:"tps"`
+	MSPT       float64 `json:"mspt,omitempty"`
+	MemoryUsed uint64  `json:"memory_used"`
+	MemoryMax  uint64  `json:"memory_max"`
+	CPUPercent float64 `json:"cpu_percent"`
+	Software   string  `json:"software,omitempty"`
+	Address    string  `json:"address,omitempty"`
+}
+
+type playerResponse struct {
+	Name     string    `json:"name"`
+	UUID     string    `json:"uuid,omitempty"`
+	JoinedAt time.Time `json:"joined_at"`
+	Bedrock  bool      `json:"bedrock,omitempty"`
+}
+
+type commandRequest struct {
+	Command string `json:"command"`
+}
+
+type actionRequest struct {
+	Action string `json:"action"`
+}
+
+type actionResponse struct {
+	Output string `json:"output"`
+}
+
+type restoreRequest struct {
+	Name string `json:"name"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
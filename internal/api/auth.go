@@ -0,0 +1,211 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"mcserver-manager/internal/audit"
+)
+
+// TokensFile holds API tokens, relative to the server directory
+const TokensFile = ".mcserver/tokens.json"
+
+// Role decides what an API token may do
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"   // stats, console, events
+	RoleOperator Role = "operator" // + allowed commands, backups
+	RoleAdmin    Role = "admin"    // + any command, start/stop/restart, restore
+)
+
+var roleRank = map[Role]int{RoleViewer: 1, RoleOperator: 2, RoleAdmin: 3}
+
+// ParseRole validates a role name
+func ParseRole(name string) (Role, error) {
+	role := Role(strings.ToLower(name))
+	if _, ok := roleRank[role]; !ok {
+		return "", fmt.Errorf("unknown role %q (viewer, operator, admin)", name)
+	}
+	return role, nil
+}
+
+// Allows reports whether r includes the permissions of required
+func (r Role) Allows(required Role) bool {
+	return roleRank[r] >= roleRank[required]
+}
+
+// Token is an API credential. Only the SHA-256 of the secret is stored.
+type Token struct {
+	Name string `json:"name"`
+	Hash string `json:"hash"`
+	Role Role   `json:"role"`
+
+	// Optional per-token command rules, see CommandPolicy
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+// Identity is the caller of an API request
+type Identity struct {
+	Name  string
+	Role  Role
+	Allow []string
+	Deny  []string
+
+	// Where the caller came in, for the audit log; the API when empty
+	Source audit.Source
+}
+
+func (id Identity) source() audit.Source {
+	if id.Source == "" {
+		return audit.SourceAPI
+	}
+	return id.Source
+}
+
+type identityKey struct{}
+
+// IdentityFrom returns the caller stored in ctx by the auth middleware
+func IdentityFrom(ctx context.Context) Identity {
+	if id, ok := ctx.Value(identityKey{}).(Identity); ok {
+		return id
+	}
+	return Identity{}
+}
+
+func withIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// TokenStore loads and saves API tokens
+type TokenStore struct {
+	path string
+
+	mu     sync.RWMutex
+	tokens []Token
+}
+
+// LoadTokens reads the token store for a server directory. A missing file
+// yields an empty store.
+func LoadTokens(serverDir string) (*TokenStore, error) {
+	store := &TokenStore{path: filepath.Join(serverDir, TokensFile)}
+
+	data, err := os.ReadFile(store.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("failed to read tokens: %w", err)
+	}
+	if err := json.Unmarshal(data, &store.tokens); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", store.path, err)
+	}
+	return store, nil
+}
+
+// Empty reports whether no tokens are configured
+func (t *TokenStore) Empty() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.tokens) == 0
+}
+
+// List returns the configured tokens
+func (t *TokenStore) List() []Token {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return append([]Token(nil), t.tokens...)
+}
+
+// Create adds a token and returns its secret, which is not stored
+func (t *TokenStore) Create(name string, role Role, allow, deny []string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, tok := range t.tokens {
+		if tok.Name == name {
+			return "", fmt.Errorf("token %q already exists", name)
+		}
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	secret := hex.EncodeToString(buf)
+
+	t.tokens = append(t.tokens, Token{Name: name, Hash: hashToken(secret), Role: role, Allow: allow, Deny: deny})
+	return secret, t.save()
+}
+
+// SetCommands replaces a token's command allow and deny lists
+func (t *TokenStore) SetCommands(name string, allow, deny []string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i := range t.tokens {
+		if t.tokens[i].Name == name {
+			t.tokens[i].Allow = allow
+			t.tokens[i].Deny = deny
+			return t.save()
+		}
+	}
+	return fmt.Errorf("token %q not found", name)
+}
+
+// Remove deletes a token by name
+func (t *TokenStore) Remove(name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, tok := range t.tokens {
+		if tok.Name == name {
+			t.tokens = append(t.tokens[:i], t.tokens[i+1:]...)
+			return t.save()
+		}
+	}
+	return fmt.Errorf("token %q not found", name)
+}
+
+// Authenticate returns the identity for a bearer secret
+func (t *TokenStore) Authenticate(secret string) (Identity, bool) {
+	if secret == "" {
+		return Identity{}, false
+	}
+	hash := hashToken(secret)
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, tok := range t.tokens {
+		if subtle.ConstantTimeCompare([]byte(tok.Hash), []byte(hash)) == 1 {
+			return Identity{Name: tok.Name, Role: tok.Role, Allow: tok.Allow, Deny: tok.Deny}, true
+		}
+	}
+	return Identity{}, false
+}
+
+func (t *TokenStore) save() error {
+	if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(t.tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(t.path, data, 0600)
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,243 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"mcserver-manager/internal/backup"
+	"mcserver-manager/internal/server"
+)
+
+// Client talks to a remote agent's control API. It satisfies the same
+// methods the TUI uses on a local server.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+	stream  *http.Client
+
+	statsMutex sync.RWMutex
+	stats      server.ServerStats
+
+	outputChan chan string
+	ctx        context.Context
+	cancel     context.CancelFunc
+}
+
+// NewClient creates a client for the agent at address (host:port). token
+// may be empty when the agent has no API tokens configured.
+func NewClient(address string, tlsConfig *tls.Config, token string) *Client {
+	transport := &http.Transport{TLSClientConfig: tlsConfig}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Client{
+		baseURL:    "https://" + address,
+		token:      token,
+		http:       &http.Client{Transport: transport, Timeout: 15 * time.Second},
+		stream:     &http.Client{Transport: transport},
+		outputChan: make(chan string, 1000),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Connect starts polling stats and streaming console output in the
+// background, reconnecting if the agent drops
+func (c *Client) Connect() {
+	go c.pollStats()
+	go c.streamConsole()
+}
+
+// Close stops background polling and streaming
+func (c *Client) Close() {
+	c.cancel()
+}
+
+// FetchStats requests the current stats from the agent
+func (c *Client) FetchStats() (server.ServerStats, error) {
+	var stats server.ServerStats
+	err := c.do(http.MethodGet, "/v1/stats", nil, &stats)
+	return stats, err
+}
+
+// GetStats returns the most recently polled stats
+func (c *Client) GetStats() server.ServerStats {
+	c.statsMutex.RLock()
+	defer c.statsMutex.RUnlock()
+	return c.stats
+}
+
+// OutputChan returns the channel of remote console lines
+func (c *Client) OutputChan() <-chan string {
+	return c.outputChan
+}
+
+// SendCommand sends a console command to the remote server
+func (c *Client) SendCommand(command string) error {
+	return c.do(http.MethodPost, "/v1/command", commandRequest{Command: command}, nil)
+}
+
+// RunAction runs a manager action (":world list" without the colon) on
+// the agent
+func (c *Client) RunAction(line string) (string, error) {
+	// Actions such as restore stop and start the server, which takes
+	// longer than the usual request timeout
+	var resp actionResponse
+	err := c.send(c.stream, http.MethodPost, "/v1/action", actionRequest{Action: line}, &resp)
+	return resp.Output, err
+}
+
+// ListBackups lists the backups on the agent
+func (c *Client) ListBackups() ([]backup.BackupInfo, error) {
+	var backups []backup.BackupInfo
+	err := c.do(http.MethodGet, "/v1/backups", nil, &backups)
+	return backups, err
+}
+
+// Start asks the agent to start the server
+func (c *Client) Start() error {
+	return c.do(http.MethodPost, "/v1/start", nil, nil)
+}
+
+// Stop asks the agent to stop the server
+func (c *Client) Stop() error {
+	return c.do(http.MethodPost, "/v1/stop", nil, nil)
+}
+
+// Restart asks the agent to restart the server
+func (c *Client) Restart() error {
+	return c.do(http.MethodPost, "/v1/restart", nil, nil)
+}
+
+func (c *Client) pollStats() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		if stats, err := c.FetchStats(); err == nil {
+			c.statsMutex.Lock()
+			c.stats = stats
+			c.statsMutex.Unlock()
+		}
+
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Client) streamConsole() {
+	for {
+		err := c.readConsole()
+		if c.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			c.emit(fmt.Sprintf("[agent] Console stream lost: %v, reconnecting...", err))
+		}
+
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-time.After(3 * time.Second):
+		}
+	}
+}
+
+func (c *Client) readConsole() error {
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, c.baseURL+"/v1/console", nil)
+	if err != nil {
+		return err
+	}
+
+	c.authorize(req)
+
+	resp, err := c.stream.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("agent returned status %d", resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		c.emit(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.ErrUnexpectedEOF
+}
+
+func (c *Client) authorize(req *http.Request) {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+}
+
+func (c *Client) emit(line string) {
+	select {
+	case c.outputChan <- line:
+	default:
+	}
+}
+
+// do sends a JSON request and decodes a JSON response into out (if non-nil)
+func (c *Client) do(method, path string, body, out interface{}) error {
+	return c.send(c.http, method, path, body, out)
+}
+
+// send is do with the given HTTP client
+func (c *Client) send(client *http.Client, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(c.ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.authorize(req)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach agent: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr errorResponse
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("agent: %s", apiErr.Error)
+		}
+		return fmt.Errorf("agent returned status %d", resp.StatusCode)
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+	return nil
+}
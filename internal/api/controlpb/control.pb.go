@@ -0,0 +1,1575 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: mcserver/v1/control.proto
+
+package controlpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Values match server.ServerStatus
+type ServerStatus int32
+
+const (
+	ServerStatus_SERVER_STATUS_STOPPED     ServerStatus = 0
+	ServerStatus_SERVER_STATUS_STARTING    ServerStatus = 1
+	ServerStatus_SERVER_STATUS_RUNNING     ServerStatus = 2
+	ServerStatus_SERVER_STATUS_STOPPING    ServerStatus = 3
+	ServerStatus_SERVER_STATUS_CRASHED     ServerStatus = 4
+	ServerStatus_SERVER_STATUS_RESTARTING  ServerStatus = 5
+	ServerStatus_SERVER_STATUS_DOWNLOADING ServerStatus = 6
+	ServerStatus_SERVER_STATUS_INSTALLING  ServerStatus = 7
+	ServerStatus_SERVER_STATUS_SUSPENDED   ServerStatus = 8
+)
+
+// Enum value maps for ServerStatus.
+var (
+	ServerStatus_name = map[int32]string{
+		0: "SERVER_STATUS_STOPPED",
+		1: "SERVER_STATUS_STARTING",
+		2: "SERVER_STATUS_RUNNING",
+		3: "SERVER_STATUS_STOPPING",
+		4: "SERVER_STATUS_CRASHED",
+		5: "SERVER_STATUS_RESTARTING",
+		6: "SERVER_STATUS_DOWNLOADING",
+		7: "SERVER_STATUS_INSTALLING",
+		8: "SERVER_STATUS_SUSPENDED",
+	}
+	ServerStatus_value = map[string]int32{
+		"SERVER_STATUS_STOPPED":     0,
+		"SERVER_STATUS_STARTING":    1,
+		"SERVER_STATUS_RUNNING":     2,
+		"SERVER_STATUS_STOPPING":    3,
+		"SERVER_STATUS_CRASHED":     4,
+		"SERVER_STATUS_RESTARTING":  5,
+		"SERVER_STATUS_DOWNLOADING": 6,
+		"SERVER_STATUS_INSTALLING":  7,
+		"SERVER_STATUS_SUSPENDED":   8,
+	}
+)
+
+func (x ServerStatus) Enum() *ServerStatus {
+	p := new(ServerStatus)
+	*p = x
+	return p
+}
+
+func (x ServerStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ServerStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_mcserver_v1_control_proto_enumTypes[0].Descriptor()
+}
+
+func (ServerStatus) Type() protoreflect.EnumType {
+	return &file_mcserver_v1_control_proto_enumTypes[0]
+}
+
+func (x ServerStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ServerStatus.Descriptor instead.
+func (ServerStatus) EnumDescriptor() ([]byte, []int) {
+	return file_mcserver_v1_control_proto_rawDescGZIP(), []int{0}
+}
+
+// Values match server.EventType
+type EventType int32
+
+const (
+	EventType_EVENT_TYPE_INFO         EventType = 0
+	EventType_EVENT_TYPE_WARNING      EventType = 1
+	EventType_EVENT_TYPE_ERROR        EventType = 2
+	EventType_EVENT_TYPE_PLAYER_JOIN  EventType = 3
+	EventType_EVENT_TYPE_PLAYER_LEAVE EventType = 4
+	EventType_EVENT_TYPE_CHAT         EventType = 5
+	EventType_EVENT_TYPE_COMMAND      EventType = 6
+	EventType_EVENT_TYPE_BACKUP       EventType = 7
+	EventType_EVENT_TYPE_RESTART      EventType = 8
+	EventType_EVENT_TYPE_CUSTOM       EventType = 9
+	EventType_EVENT_TYPE_CRITICAL     EventType = 10
+	EventType_EVENT_TYPE_WHISPER      EventType = 11
+	EventType_EVENT_TYPE_EMOTE        EventType = 12
+)
+
+// Enum value maps for EventType.
+var (
+	EventType_name = map[int32]string{
+		0:  "EVENT_TYPE_INFO",
+		1:  "EVENT_TYPE_WARNING",
+		2:  "EVENT_TYPE_ERROR",
+		3:  "EVENT_TYPE_PLAYER_JOIN",
+		4:  "EVENT_TYPE_PLAYER_LEAVE",
+		5:  "EVENT_TYPE_CHAT",
+		6:  "EVENT_TYPE_COMMAND",
+		7:  "EVENT_TYPE_BACKUP",
+		8:  "EVENT_TYPE_RESTART",
+		9:  "EVENT_TYPE_CUSTOM",
+		10: "EVENT_TYPE_CRITICAL",
+		11: "EVENT_TYPE_WHISPER",
+		12: "EVENT_TYPE_EMOTE",
+	}
+	EventType_value = map[string]int32{
+		"EVENT_TYPE_INFO":         0,
+		"EVENT_TYPE_WARNING":      1,
+		"EVENT_TYPE_ERROR":        2,
+		"EVENT_TYPE_PLAYER_JOIN":  3,
+		"EVENT_TYPE_PLAYER_LEAVE": 4,
+		"EVENT_TYPE_CHAT":         5,
+		"EVENT_TYPE_COMMAND":      6,
+		"EVENT_TYPE_BACKUP":       7,
+		"EVENT_TYPE_RESTART":      8,
+		"EVENT_TYPE_CUSTOM":       9,
+		"EVENT_TYPE_CRITICAL":     10,
+		"EVENT_TYPE_WHISPER":      11,
+		"EVENT_TYPE_EMOTE":        12,
+	}
+)
+
+func (x EventType) Enum() *EventType {
+	p := new(EventType)
+	*p = x
+	return p
+}
+
+func (x EventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_mcserver_v1_control_proto_enumTypes[1].Descriptor()
+}
+
+func (EventType) Type() protoreflect.EnumType {
+	return &file_mcserver_v1_control_proto_enumTypes[1]
+}
+
+func (x EventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventType.Descriptor instead.
+func (EventType) EnumDescriptor() ([]byte, []int) {
+	return file_mcserver_v1_control_proto_rawDescGZIP(), []int{1}
+}
+
+type GetStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatusRequest) Reset() {
+	*x = GetStatusRequest{}
+	mi := &file_mcserver_v1_control_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatusRequest) ProtoMessage() {}
+
+func (x *GetStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mcserver_v1_control_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetStatusRequest) Descriptor() ([]byte, []int) {
+	return file_mcserver_v1_control_proto_rawDescGZIP(), []int{0}
+}
+
+type Player struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Uuid          string                 `protobuf:"bytes,2,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	JoinTime      *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=join_time,json=joinTime,proto3" json:"join_time,omitempty"`
+	Bedrock       bool                   `protobuf:"varint,4,opt,name=bedrock,proto3" json:"bedrock,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Player) Reset() {
+	*x = Player{}
+	mi := &file_mcserver_v1_control_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Player) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Player) ProtoMessage() {}
+
+func (x *Player) ProtoReflect() protoreflect.Message {
+	mi := &file_mcserver_v1_control_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Player.ProtoReflect.Descriptor instead.
+func (*Player) Descriptor() ([]byte, []int) {
+	return file_mcserver_v1_control_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Player) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Player) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *Player) GetJoinTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.JoinTime
+	}
+	return nil
+}
+
+func (x *Player) GetBedrock() bool {
+	if x != nil {
+		return x.Bedrock
+	}
+	return false
+}
+
+type Status struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        ServerStatus           `protobuf:"varint,1,opt,name=status,proto3,enum=mcserver.v1.ServerStatus" json:"status,omitempty"`
+	StartTime     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	UptimeSeconds int64                  `protobuf:"varint,3,opt,name=uptime_seconds,json=uptimeSeconds,proto3" json:"uptime_seconds,omitempty"`
+	Restarts      int32                  `protobuf:"varint,4,opt,name=restarts,proto3" json:"restarts,omitempty"`
+	Tps           float64                `protobuf:"fixed64,5,opt,name=tps,proto3" json:"tps,omitempty"`
+	MemoryUsed    uint64                 `protobuf:"varint,6,opt,name=memory_used,json=memoryUsed,proto3" json:"memory_used,omitempty"`
+	MemoryMax     uint64                 `protobuf:"varint,7,opt,name=memory_max,json=memoryMax,proto3" json:"memory_max,omitempty"`
+	CpuPercent    float64                `protobuf:"fixed64,8,opt,name=cpu_percent,json=cpuPercent,proto3" json:"cpu_percent,omitempty"`
+	PlayerCount   int32                  `protobuf:"varint,9,opt,name=player_count,json=playerCount,proto3" json:"player_count,omitempty"`
+	MaxPlayers    int32                  `protobuf:"varint,10,opt,name=max_players,json=maxPlayers,proto3" json:"max_players,omitempty"`
+	Players       []*Player              `protobuf:"bytes,11,rep,name=players,proto3" json:"players,omitempty"`
+	// Always the disk rates; use disk_read_rate and disk_write_rate
+	//
+	// Deprecated: Marked as deprecated in mcserver/v1/control.proto.
+	BandwidthIn float64 `protobuf:"fixed64,12,opt,name=bandwidth_in,json=bandwidthIn,proto3" json:"bandwidth_in,omitempty"`
+	// Deprecated: Marked as deprecated in mcserver/v1/control.proto.
+	BandwidthOut float64 `protobuf:"fixed64,13,opt,name=bandwidth_out,json=bandwidthOut,proto3" json:"bandwidth_out,omitempty"`
+	MapName      string  `protobuf:"bytes,14,opt,name=map_name,json=mapName,proto3" json:"map_name,omitempty"`
+	Motd         string  `protobuf:"bytes,15,opt,name=motd,proto3" json:"motd,omitempty"`
+	Reachable    bool    `protobuf:"varint,16,opt,name=reachable,proto3" json:"reachable,omitempty"`
+	LatencyMs    int64   `protobuf:"varint,17,opt,name=latency_ms,json=latencyMs,proto3" json:"latency_ms,omitempty"`
+	ShareAddress string  `protobuf:"bytes,18,opt,name=share_address,json=shareAddress,proto3" json:"share_address,omitempty"`
+	// Console lines the manager had to drop
+	DroppedLines uint64 `protobuf:"varint,19,opt,name=dropped_lines,json=droppedLines,proto3" json:"dropped_lines,omitempty"`
+	// Set when mods or configs changed since the server started
+	RestartRequired string `protobuf:"bytes,20,opt,name=restart_required,json=restartRequired,proto3" json:"restart_required,omitempty"`
+	// Set when start was refused until the Minecraft EULA is accepted
+	EulaRequired bool `protobuf:"varint,21,opt,name=eula_required,json=eulaRequired,proto3" json:"eula_required,omitempty"`
+	// Detected server software (e.g. "paper"), its Minecraft version and the
+	// major version of the Java running it
+	ServerType  string `protobuf:"bytes,22,opt,name=server_type,json=serverType,proto3" json:"server_type,omitempty"`
+	McVersion   string `protobuf:"bytes,23,opt,name=mc_version,json=mcVersion,proto3" json:"mc_version,omitempty"`
+	JavaVersion int32  `protobuf:"varint,24,opt,name=java_version,json=javaVersion,proto3" json:"java_version,omitempty"`
+	// Counters kept across manager restarts
+	LifetimeUptimeSeconds int64                  `protobuf:"varint,25,opt,name=lifetime_uptime_seconds,json=lifetimeUptimeSeconds,proto3" json:"lifetime_uptime_seconds,omitempty"`
+	Starts                int32                  `protobuf:"varint,26,opt,name=starts,proto3" json:"starts,omitempty"`
+	Crashes               int32                  `protobuf:"varint,27,opt,name=crashes,proto3" json:"crashes,omitempty"`
+	LastCrash             *timestamppb.Timestamp `protobuf:"bytes,28,opt,name=last_crash,json=lastCrash,proto3" json:"last_crash,omitempty"`
+	LastCrashReason       string                 `protobuf:"bytes,29,opt,name=last_crash_reason,json=lastCrashReason,proto3" json:"last_crash_reason,omitempty"`
+	LastGoodModpack       string                 `protobuf:"bytes,30,opt,name=last_good_modpack,json=lastGoodModpack,proto3" json:"last_good_modpack,omitempty"`
+	// Disk I/O of the server process in bytes per second, and how busy the
+	// disk holding the server directory is in percent
+	DiskReadRate  float64 `protobuf:"fixed64,31,opt,name=disk_read_rate,json=diskReadRate,proto3" json:"disk_read_rate,omitempty"`
+	DiskWriteRate float64 `protobuf:"fixed64,32,opt,name=disk_write_rate,json=diskWriteRate,proto3" json:"disk_write_rate,omitempty"`
+	DiskUtil      float64 `protobuf:"fixed64,33,opt,name=disk_util,json=diskUtil,proto3" json:"disk_util,omitempty"`
+	// TPS that 95% and 99% of the samples of the last 5 minutes and hour
+	// were at or above
+	FiveMinuteTpsP95 float64 `protobuf:"fixed64,34,opt,name=five_minute_tps_p95,json=fiveMinuteTpsP95,proto3" json:"five_minute_tps_p95,omitempty"`
+	FiveMinuteTpsP99 float64 `protobuf:"fixed64,35,opt,name=five_minute_tps_p99,json=fiveMinuteTpsP99,proto3" json:"five_minute_tps_p99,omitempty"`
+	HourTpsP95       float64 `protobuf:"fixed64,36,opt,name=hour_tps_p95,json=hourTpsP95,proto3" json:"hour_tps_p95,omitempty"`
+	HourTpsP99       float64 `protobuf:"fixed64,37,opt,name=hour_tps_p99,json=hourTpsP99,proto3" json:"hour_tps_p99,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Status) Reset() {
+	*x = Status{}
+	mi := &file_mcserver_v1_control_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Status) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Status) ProtoMessage() {}
+
+func (x *Status) ProtoReflect() protoreflect.Message {
+	mi := &file_mcserver_v1_control_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Status.ProtoReflect.Descriptor instead.
+func (*Status) Descriptor() ([]byte, []int) {
+	return file_mcserver_v1_control_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Status) GetStatus() ServerStatus {
+	if x != nil {
+		return x.Status
+	}
+	return ServerStatus_SERVER_STATUS_STOPPED
+}
+
+func (x *Status) GetStartTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartTime
+	}
+	return nil
+}
+
+func (x *Status) GetUptimeSeconds() int64 {
+	if x != nil {
+		return x.UptimeSeconds
+	}
+	return 0
+}
+
+func (x *Status) GetRestarts() int32 {
+	if x != nil {
+		return x.Restarts
+	}
+	return 0
+}
+
+func (x *Status) GetTps() float64 {
+	if x != nil {
+		return x.Tps
+	}
+	return 0
+}
+
+func (x *Status) GetMemoryUsed() uint64 {
+	if x != nil {
+		return x.MemoryUsed
+	}
+	return 0
+}
+
+func (x *Status) GetMemoryMax() uint64 {
+	if x != nil {
+		return x.MemoryMax
+	}
+	return 0
+}
+
+func (x *Status) GetCpuPercent() float64 {
+	if x != nil {
+		return x.CpuPercent
+	}
+	return 0
+}
+
+func (x *Status) GetPlayerCount() int32 {
+	if x != nil {
+		return x.PlayerCount
+	}
+	return 0
+}
+
+func (x *Status) GetMaxPlayers() int32 {
+	if x != nil {
+		return x.MaxPlayers
+	}
+	return 0
+}
+
+func (x *Status) GetPlayers() []*Player {
+	if x != nil {
+		return x.Players
+	}
+	return nil
+}
+
+// Deprecated: Marked as deprecated in mcserver/v1/control.proto.
+func (x *Status) GetBandwidthIn() float64 {
+	if x != nil {
+		return x.BandwidthIn
+	}
+	return 0
+}
+
+// Deprecated: Marked as deprecated in mcserver/v1/control.proto.
+func (x *Status) GetBandwidthOut() float64 {
+	if x != nil {
+		return x.BandwidthOut
+	}
+	return 0
+}
+
+func (x *Status) GetMapName() string {
+	if x != nil {
+		return x.MapName
+	}
+	return ""
+}
+
+func (x *Status) GetMotd() string {
+	if x != nil {
+		return x.Motd
+	}
+	return ""
+}
+
+func (x *Status) GetReachable() bool {
+	if x != nil {
+		return x.Reachable
+	}
+	return false
+}
+
+func (x *Status) GetLatencyMs() int64 {
+	if x != nil {
+		return x.LatencyMs
+	}
+	return 0
+}
+
+func (x *Status) GetShareAddress() string {
+	if x != nil {
+		return x.ShareAddress
+	}
+	return ""
+}
+
+func (x *Status) GetDroppedLines() uint64 {
+	if x != nil {
+		return x.DroppedLines
+	}
+	return 0
+}
+
+func (x *Status) GetRestartRequired() string {
+	if x != nil {
+		return x.RestartRequired
+	}
+	return ""
+}
+
+func (x *Status) GetEulaRequired() bool {
+	if x != nil {
+		return x.EulaRequired
+	}
+	return false
+}
+
+func (x *Status) GetServerType() string {
+	if x != nil {
+		return x.ServerType
+	}
+	return ""
+}
+
+func (x *Status) GetMcVersion() string {
+	if x != nil {
+		return x.McVersion
+	}
+	return ""
+}
+
+func (x *Status) GetJavaVersion() int32 {
+	if x != nil {
+		return x.JavaVersion
+	}
+	return 0
+}
+
+func (x *Status) GetLifetimeUptimeSeconds() int64 {
+	if x != nil {
+		return x.LifetimeUptimeSeconds
+	}
+	return 0
+}
+
+func (x *Status) GetStarts() int32 {
+	if x != nil {
+		return x.Starts
+	}
+	return 0
+}
+
+func (x *Status) GetCrashes() int32 {
+	if x != nil {
+		return x.Crashes
+	}
+	return 0
+}
+
+func (x *Status) GetLastCrash() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastCrash
+	}
+	return nil
+}
+
+func (x *Status) GetLastCrashReason() string {
+	if x != nil {
+		return x.LastCrashReason
+	}
+	return ""
+}
+
+func (x *Status) GetLastGoodModpack() string {
+	if x != nil {
+		return x.LastGoodModpack
+	}
+	return ""
+}
+
+func (x *Status) GetDiskReadRate() float64 {
+	if x != nil {
+		return x.DiskReadRate
+	}
+	return 0
+}
+
+func (x *Status) GetDiskWriteRate() float64 {
+	if x != nil {
+		return x.DiskWriteRate
+	}
+	return 0
+}
+
+func (x *Status) GetDiskUtil() float64 {
+	if x != nil {
+		return x.DiskUtil
+	}
+	return 0
+}
+
+func (x *Status) GetFiveMinuteTpsP95() float64 {
+	if x != nil {
+		return x.FiveMinuteTpsP95
+	}
+	return 0
+}
+
+func (x *Status) GetFiveMinuteTpsP99() float64 {
+	if x != nil {
+		return x.FiveMinuteTpsP99
+	}
+	return 0
+}
+
+func (x *Status) GetHourTpsP95() float64 {
+	if x != nil {
+		return x.HourTpsP95
+	}
+	return 0
+}
+
+func (x *Status) GetHourTpsP99() float64 {
+	if x != nil {
+		return x.HourTpsP99
+	}
+	return 0
+}
+
+type SendCommandRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Command       string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendCommandRequest) Reset() {
+	*x = SendCommandRequest{}
+	mi := &file_mcserver_v1_control_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendCommandRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendCommandRequest) ProtoMessage() {}
+
+func (x *SendCommandRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mcserver_v1_control_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendCommandRequest.ProtoReflect.Descriptor instead.
+func (*SendCommandRequest) Descriptor() ([]byte, []int) {
+	return file_mcserver_v1_control_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SendCommandRequest) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+type SendCommandResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendCommandResponse) Reset() {
+	*x = SendCommandResponse{}
+	mi := &file_mcserver_v1_control_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendCommandResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendCommandResponse) ProtoMessage() {}
+
+func (x *SendCommandResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mcserver_v1_control_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendCommandResponse.ProtoReflect.Descriptor instead.
+func (*SendCommandResponse) Descriptor() ([]byte, []int) {
+	return file_mcserver_v1_control_proto_rawDescGZIP(), []int{4}
+}
+
+// A manager action line as typed after ":" in the TUI, e.g. "world list"
+type RunActionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Action        string                 `protobuf:"bytes,1,opt,name=action,proto3" json:"action,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunActionRequest) Reset() {
+	*x = RunActionRequest{}
+	mi := &file_mcserver_v1_control_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunActionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunActionRequest) ProtoMessage() {}
+
+func (x *RunActionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mcserver_v1_control_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunActionRequest.ProtoReflect.Descriptor instead.
+func (*RunActionRequest) Descriptor() ([]byte, []int) {
+	return file_mcserver_v1_control_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RunActionRequest) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+type RunActionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Output        string                 `protobuf:"bytes,1,opt,name=output,proto3" json:"output,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunActionResponse) Reset() {
+	*x = RunActionResponse{}
+	mi := &file_mcserver_v1_control_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunActionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunActionResponse) ProtoMessage() {}
+
+func (x *RunActionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mcserver_v1_control_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunActionResponse.ProtoReflect.Descriptor instead.
+func (*RunActionResponse) Descriptor() ([]byte, []int) {
+	return file_mcserver_v1_control_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RunActionResponse) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+type StreamConsoleRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Skip the recent backlog and only stream new lines
+	SkipBacklog   bool `protobuf:"varint,1,opt,name=skip_backlog,json=skipBacklog,proto3" json:"skip_backlog,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamConsoleRequest) Reset() {
+	*x = StreamConsoleRequest{}
+	mi := &file_mcserver_v1_control_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamConsoleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamConsoleRequest) ProtoMessage() {}
+
+func (x *StreamConsoleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mcserver_v1_control_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamConsoleRequest.ProtoReflect.Descriptor instead.
+func (*StreamConsoleRequest) Descriptor() ([]byte, []int) {
+	return file_mcserver_v1_control_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *StreamConsoleRequest) GetSkipBacklog() bool {
+	if x != nil {
+		return x.SkipBacklog
+	}
+	return false
+}
+
+type ConsoleLine struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConsoleLine) Reset() {
+	*x = ConsoleLine{}
+	mi := &file_mcserver_v1_control_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConsoleLine) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConsoleLine) ProtoMessage() {}
+
+func (x *ConsoleLine) ProtoReflect() protoreflect.Message {
+	mi := &file_mcserver_v1_control_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConsoleLine.ProtoReflect.Descriptor instead.
+func (*ConsoleLine) Descriptor() ([]byte, []int) {
+	return file_mcserver_v1_control_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ConsoleLine) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+type StreamEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamEventsRequest) Reset() {
+	*x = StreamEventsRequest{}
+	mi := &file_mcserver_v1_control_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamEventsRequest) ProtoMessage() {}
+
+func (x *StreamEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mcserver_v1_control_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamEventsRequest.ProtoReflect.Descriptor instead.
+func (*StreamEventsRequest) Descriptor() ([]byte, []int) {
+	return file_mcserver_v1_control_proto_rawDescGZIP(), []int{9}
+}
+
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	Type          EventType              `protobuf:"varint,2,opt,name=type,proto3,enum=mcserver.v1.EventType" json:"type,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_mcserver_v1_control_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_mcserver_v1_control_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_mcserver_v1_control_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Event) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Event) GetType() EventType {
+	if x != nil {
+		return x.Type
+	}
+	return EventType_EVENT_TYPE_INFO
+}
+
+func (x *Event) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type LifecycleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LifecycleRequest) Reset() {
+	*x = LifecycleRequest{}
+	mi := &file_mcserver_v1_control_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LifecycleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LifecycleRequest) ProtoMessage() {}
+
+func (x *LifecycleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mcserver_v1_control_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LifecycleRequest.ProtoReflect.Descriptor instead.
+func (*LifecycleRequest) Descriptor() ([]byte, []int) {
+	return file_mcserver_v1_control_proto_rawDescGZIP(), []int{11}
+}
+
+type LifecycleResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LifecycleResponse) Reset() {
+	*x = LifecycleResponse{}
+	mi := &file_mcserver_v1_control_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LifecycleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LifecycleResponse) ProtoMessage() {}
+
+func (x *LifecycleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mcserver_v1_control_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LifecycleResponse.ProtoReflect.Descriptor instead.
+func (*LifecycleResponse) Descriptor() ([]byte, []int) {
+	return file_mcserver_v1_control_proto_rawDescGZIP(), []int{12}
+}
+
+type CreateBackupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateBackupRequest) Reset() {
+	*x = CreateBackupRequest{}
+	mi := &file_mcserver_v1_control_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateBackupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateBackupRequest) ProtoMessage() {}
+
+func (x *CreateBackupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mcserver_v1_control_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateBackupRequest.ProtoReflect.Descriptor instead.
+func (*CreateBackupRequest) Descriptor() ([]byte, []int) {
+	return file_mcserver_v1_control_proto_rawDescGZIP(), []int{13}
+}
+
+type CreateBackupResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateBackupResponse) Reset() {
+	*x = CreateBackupResponse{}
+	mi := &file_mcserver_v1_control_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateBackupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateBackupResponse) ProtoMessage() {}
+
+func (x *CreateBackupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mcserver_v1_control_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateBackupResponse.ProtoReflect.Descriptor instead.
+func (*CreateBackupResponse) Descriptor() ([]byte, []int) {
+	return file_mcserver_v1_control_proto_rawDescGZIP(), []int{14}
+}
+
+type Backup struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Size          int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Backup) Reset() {
+	*x = Backup{}
+	mi := &file_mcserver_v1_control_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Backup) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Backup) ProtoMessage() {}
+
+func (x *Backup) ProtoReflect() protoreflect.Message {
+	mi := &file_mcserver_v1_control_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Backup.ProtoReflect.Descriptor instead.
+func (*Backup) Descriptor() ([]byte, []int) {
+	return file_mcserver_v1_control_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Backup) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Backup) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Backup) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type ListBackupsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBackupsRequest) Reset() {
+	*x = ListBackupsRequest{}
+	mi := &file_mcserver_v1_control_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBackupsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBackupsRequest) ProtoMessage() {}
+
+func (x *ListBackupsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mcserver_v1_control_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBackupsRequest.ProtoReflect.Descriptor instead.
+func (*ListBackupsRequest) Descriptor() ([]byte, []int) {
+	return file_mcserver_v1_control_proto_rawDescGZIP(), []int{16}
+}
+
+type ListBackupsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Backups       []*Backup              `protobuf:"bytes,1,rep,name=backups,proto3" json:"backups,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBackupsResponse) Reset() {
+	*x = ListBackupsResponse{}
+	mi := &file_mcserver_v1_control_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBackupsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBackupsResponse) ProtoMessage() {}
+
+func (x *ListBackupsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mcserver_v1_control_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBackupsResponse.ProtoReflect.Descriptor instead.
+func (*ListBackupsResponse) Descriptor() ([]byte, []int) {
+	return file_mcserver_v1_control_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ListBackupsResponse) GetBackups() []*Backup {
+	if x != nil {
+		return x.Backups
+	}
+	return nil
+}
+
+type RestoreBackupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreBackupRequest) Reset() {
+	*x = RestoreBackupRequest{}
+	mi := &file_mcserver_v1_control_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreBackupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreBackupRequest) ProtoMessage() {}
+
+func (x *RestoreBackupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mcserver_v1_control_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreBackupRequest.ProtoReflect.Descriptor instead.
+func (*RestoreBackupRequest) Descriptor() ([]byte, []int) {
+	return file_mcserver_v1_control_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *RestoreBackupRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type RestoreBackupResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreBackupResponse) Reset() {
+	*x = RestoreBackupResponse{}
+	mi := &file_mcserver_v1_control_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreBackupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreBackupResponse) ProtoMessage() {}
+
+func (x *RestoreBackupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mcserver_v1_control_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreBackupResponse.ProtoReflect.Descriptor instead.
+func (*RestoreBackupResponse) Descriptor() ([]byte, []int) {
+	return file_mcserver_v1_control_proto_rawDescGZIP(), []int{19}
+}
+
+var File_mcserver_v1_control_proto protoreflect.FileDescriptor
+
+const file_mcserver_v1_control_proto_rawDesc = "" +
+	"\n" +
+	"\x19mcserver/v1/control.proto\x12\vmcserver.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x12\n" +
+	"\x10GetStatusRequest\"\x83\x01\n" +
+	"\x06Player\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04uuid\x18\x02 \x01(\tR\x04uuid\x127\n" +
+	"\tjoin_time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\bjoinTime\x12\x18\n" +
+	"\abedrock\x18\x04 \x01(\bR\abedrock\"\xe2\n" +
+	"\n" +
+	"\x06Status\x121\n" +
+	"\x06status\x18\x01 \x01(\x0e2\x19.mcserver.v1.ServerStatusR\x06status\x129\n" +
+	"\n" +
+	"start_time\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\tstartTime\x12%\n" +
+	"\x0euptime_seconds\x18\x03 \x01(\x03R\ruptimeSeconds\x12\x1a\n" +
+	"\brestarts\x18\x04 \x01(\x05R\brestarts\x12\x10\n" +
+	"\x03tps\x18\x05 \x01(\x01R\x03tps\x12\x1f\n" +
+	"\vmemory_used\x18\x06 \x01(\x04R\n" +
+	"memoryUsed\x12\x1d\n" +
+	"\n" +
+	"memory_max\x18\a \x01(\x04R\tmemoryMax\x12\x1f\n" +
+	"\vcpu_percent\x18\b \x01(\x01R\n" +
+	"cpuPercent\x12!\n" +
+	"\fplayer_count\x18\t \x01(\x05R\vplayerCount\x12\x1f\n" +
+	"\vmax_players\x18\n" +
+	" \x01(\x05R\n" +
+	"maxPlayers\x12-\n" +
+	"\aplayers\x18\v \x03(\v2\x13.mcserver.v1.PlayerR\aplayers\x12%\n" +
+	"\fbandwidth_in\x18\f \x01(\x01B\x02\x18\x01R\vbandwidthIn\x12'\n" +
+	"\rbandwidth_out\x18\r \x01(\x01B\x02\x18\x01R\fbandwidthOut\x12\x19\n" +
+	"\bmap_name\x18\x0e \x01(\tR\amapName\x12\x12\n" +
+	"\x04motd\x18\x0f \x01(\tR\x04motd\x12\x1c\n" +
+	"\treachable\x18\x10 \x01(\bR\treachable\x12\x1d\n" +
+	"\n" +
+	"latency_ms\x18\x11 \x01(\x03R\tlatencyMs\x12#\n" +
+	"\rshare_address\x18\x12 \x01(\tR\fshareAddress\x12#\n" +
+	"\rdropped_lines\x18\x13 \x01(\x04R\fdroppedLines\x12)\n" +
+	"\x10restart_required\x18\x14 \x01(\tR\x0frestartRequired\x12#\n" +
+	"\reula_required\x18\x15 \x01(\bR\feulaRequired\x12\x1f\n" +
+	"\vserver_type\x18\x16 \x01(\tR\n" +
+	"serverType\x12\x1d\n" +
+	"\n" +
+	"mc_version\x18\x17 \x01(\tR\tmcVersion\x12!\n" +
+	"\fjava_version\x18\x18 \x01(\x05R\vjavaVersion\x126\n" +
+	"\x17lifetime_uptime_seconds\x18\x19 \x01(\x03R\x15lifetimeUptimeSeconds\x12\x16\n" +
+	"\x06starts\x18\x1a \x01(\x05R\x06starts\x12\x18\n" +
+	"\acrashes\x18\x1b \x01(\x05R\acrashes\x129\n" +
+	"\n" +
+	"last_crash\x18\x1c \x01(\v2\x1a.google.protobuf.TimestampR\tlastCrash\x12*\n" +
+	"\x11last_crash_reason\x18\x1d \x01(\tR\x0flastCrashReason\x12*\n" +
+	"\x11last_good_modpack\x18\x1e \x01(\tR\x0flastGoodModpack\x12$\n" +
+	"\x0edisk_read_rate\x18\x1f \x01(\x01R\fdiskReadRate\x12&\n" +
+	"\x0fdisk_write_rate\x18  \x01(\x01R\rdiskWriteRate\x12\x1b\n" +
+	"\tdisk_util\x18! \x01(\x01R\bdiskUtil\x12-\n" +
+	"\x13five_minute_tps_p95\x18\" \x01(\x01R\x10fiveMinuteTpsP95\x12-\n" +
+	"\x13five_minute_tps_p99\x18# \x01(\x01R\x10fiveMinuteTpsP99\x12 \n" +
+	"\fhour_tps_p95\x18$ \x01(\x01R\n" +
+	"hourTpsP95\x12 \n" +
+	"\fhour_tps_p99\x18% \x01(\x01R\n" +
+	"hourTpsP99\".\n" +
+	"\x12SendCommandRequest\x12\x18\n" +
+	"\acommand\x18\x01 \x01(\tR\acommand\"\x15\n" +
+	"\x13SendCommandResponse\"*\n" +
+	"\x10RunActionRequest\x12\x16\n" +
+	"\x06action\x18\x01 \x01(\tR\x06action\"+\n" +
+	"\x11RunActionResponse\x12\x16\n" +
+	"\x06output\x18\x01 \x01(\tR\x06output\"9\n" +
+	"\x14StreamConsoleRequest\x12!\n" +
+	"\fskip_backlog\x18\x01 \x01(\bR\vskipBacklog\"!\n" +
+	"\vConsoleLine\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\"\x15\n" +
+	"\x13StreamEventsRequest\"}\n" +
+	"\x05Event\x12.\n" +
+	"\x04time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12*\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x16.mcserver.v1.EventTypeR\x04type\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"\x12\n" +
+	"\x10LifecycleRequest\"\x13\n" +
+	"\x11LifecycleResponse\"\x15\n" +
+	"\x13CreateBackupRequest\"\x16\n" +
+	"\x14CreateBackupResponse\"k\n" +
+	"\x06Backup\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x129\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\x14\n" +
+	"\x12ListBackupsRequest\"D\n" +
+	"\x13ListBackupsResponse\x12-\n" +
+	"\abackups\x18\x01 \x03(\v2\x13.mcserver.v1.BackupR\abackups\"*\n" +
+	"\x14RestoreBackupRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\x17\n" +
+	"\x15RestoreBackupResponse*\x8f\x02\n" +
+	"\fServerStatus\x12\x19\n" +
+	"\x15SERVER_STATUS_STOPPED\x10\x00\x12\x1a\n" +
+	"\x16SERVER_STATUS_STARTING\x10\x01\x12\x19\n" +
+	"\x15SERVER_STATUS_RUNNING\x10\x02\x12\x1a\n" +
+	"\x16SERVER_STATUS_STOPPING\x10\x03\x12\x19\n" +
+	"\x15SERVER_STATUS_CRASHED\x10\x04\x12\x1c\n" +
+	"\x18SERVER_STATUS_RESTARTING\x10\x05\x12\x1d\n" +
+	"\x19SERVER_STATUS_DOWNLOADING\x10\x06\x12\x1c\n" +
+	"\x18SERVER_STATUS_INSTALLING\x10\a\x12\x1b\n" +
+	"\x17SERVER_STATUS_SUSPENDED\x10\b*\xc1\x02\n" +
+	"\tEventType\x12\x13\n" +
+	"\x0fEVENT_TYPE_INFO\x10\x00\x12\x16\n" +
+	"\x12EVENT_TYPE_WARNING\x10\x01\x12\x14\n" +
+	"\x10EVENT_TYPE_ERROR\x10\x02\x12\x1a\n" +
+	"\x16EVENT_TYPE_PLAYER_JOIN\x10\x03\x12\x1b\n" +
+	"\x17EVENT_TYPE_PLAYER_LEAVE\x10\x04\x12\x13\n" +
+	"\x0fEVENT_TYPE_CHAT\x10\x05\x12\x16\n" +
+	"\x12EVENT_TYPE_COMMAND\x10\x06\x12\x15\n" +
+	"\x11EVENT_TYPE_BACKUP\x10\a\x12\x16\n" +
+	"\x12EVENT_TYPE_RESTART\x10\b\x12\x15\n" +
+	"\x11EVENT_TYPE_CUSTOM\x10\t\x12\x17\n" +
+	"\x13EVENT_TYPE_CRITICAL\x10\n" +
+	"\x12\x16\n" +
+	"\x12EVENT_TYPE_WHISPER\x10\v\x12\x14\n" +
+	"\x10EVENT_TYPE_EMOTE\x10\f2\xd8\x06\n" +
+	"\aControl\x12?\n" +
+	"\tGetStatus\x12\x1d.mcserver.v1.GetStatusRequest\x1a\x13.mcserver.v1.Status\x12P\n" +
+	"\vSendCommand\x12\x1f.mcserver.v1.SendCommandRequest\x1a .mcserver.v1.SendCommandResponse\x12J\n" +
+	"\tRunAction\x12\x1d.mcserver.v1.RunActionRequest\x1a\x1e.mcserver.v1.RunActionResponse\x12N\n" +
+	"\rStreamConsole\x12!.mcserver.v1.StreamConsoleRequest\x1a\x18.mcserver.v1.ConsoleLine0\x01\x12F\n" +
+	"\fStreamEvents\x12 .mcserver.v1.StreamEventsRequest\x1a\x12.mcserver.v1.Event0\x01\x12F\n" +
+	"\x05Start\x12\x1d.mcserver.v1.LifecycleRequest\x1a\x1e.mcserver.v1.LifecycleResponse\x12E\n" +
+	"\x04Stop\x12\x1d.mcserver.v1.LifecycleRequest\x1a\x1e.mcserver.v1.LifecycleResponse\x12H\n" +
+	"\aRestart\x12\x1d.mcserver.v1.LifecycleRequest\x1a\x1e.mcserver.v1.LifecycleResponse\x12S\n" +
+	"\fCreateBackup\x12 .mcserver.v1.CreateBackupRequest\x1a!.mcserver.v1.CreateBackupResponse\x12P\n" +
+	"\vListBackups\x12\x1f.mcserver.v1.ListBackupsRequest\x1a .mcserver.v1.ListBackupsResponse\x12V\n" +
+	"\rRestoreBackup\x12!.mcserver.v1.RestoreBackupRequest\x1a\".mcserver.v1.RestoreBackupResponseB)Z'mcserver-manager/internal/api/controlpbb\x06proto3"
+
+var (
+	file_mcserver_v1_control_proto_rawDescOnce sync.Once
+	file_mcserver_v1_control_proto_rawDescData []byte
+)
+
+func file_mcserver_v1_control_proto_rawDescGZIP() []byte {
+	file_mcserver_v1_control_proto_rawDescOnce.Do(func() {
+		file_mcserver_v1_control_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_mcserver_v1_control_proto_rawDesc), len(file_mcserver_v1_control_proto_rawDesc)))
+	})
+	return file_mcserver_v1_control_proto_rawDescData
+}
+
+var file_mcserver_v1_control_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_mcserver_v1_control_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
+var file_mcserver_v1_control_proto_goTypes = []any{
+	(ServerStatus)(0),             // 0: mcserver.v1.ServerStatus
+	(EventType)(0),                // 1: mcserver.v1.EventType
+	(*GetStatusRequest)(nil),      // 2: mcserver.v1.GetStatusRequest
+	(*Player)(nil),                // 3: mcserver.v1.Player
+	(*Status)(nil),                // 4: mcserver.v1.Status
+	(*SendCommandRequest)(nil),    // 5: mcserver.v1.SendCommandRequest
+	(*SendCommandResponse)(nil),   // 6: mcserver.v1.SendCommandResponse
+	(*RunActionRequest)(nil),      // 7: mcserver.v1.RunActionRequest
+	(*RunActionResponse)(nil),     // 8: mcserver.v1.RunActionResponse
+	(*StreamConsoleRequest)(nil),  // 9: mcserver.v1.StreamConsoleRequest
+	(*ConsoleLine)(nil),           // 10: mcserver.v1.ConsoleLine
+	(*StreamEventsRequest)(nil),   // 11: mcserver.v1.StreamEventsRequest
+	(*Event)(nil),                 // 12: mcserver.v1.Event
+	(*LifecycleRequest)(nil),      // 13: mcserver.v1.LifecycleRequest
+	(*LifecycleResponse)(nil),     // 14: mcserver.v1.LifecycleResponse
+	(*CreateBackupRequest)(nil),   // 15: mcserver.v1.CreateBackupRequest
+	(*CreateBackupResponse)(nil),  // 16: mcserver.v1.CreateBackupResponse
+	(*Backup)(nil),                // 17: mcserver.v1.Backup
+	(*ListBackupsRequest)(nil),    // 18: mcserver.v1.ListBackupsRequest
+	(*ListBackupsResponse)(nil),   // 19: mcserver.v1.ListBackupsResponse
+	(*RestoreBackupRequest)(nil),  // 20: mcserver.v1.RestoreBackupRequest
+	(*RestoreBackupResponse)(nil), // 21: mcserver.v1.RestoreBackupResponse
+	(*timestamppb.Timestamp)(nil), // 22: google.protobuf.Timestamp
+}
+var file_mcserver_v1_control_proto_depIdxs = []int32{
+	22, // 0: mcserver.v1.Player.join_time:type_name -> google.protobuf.Timestamp
+	0,  // 1: mcserver.v1.Status.status:type_name -> mcserver.v1.ServerStatus
+	22, // 2: mcserver.v1.Status.start_time:type_name -> google.protobuf.Timestamp
+	3,  // 3: mcserver.v1.Status.players:type_name -> mcserver.v1.Player
+	22, // 4: mcserver.v1.Status.last_crash:type_name -> google.protobuf.Timestamp
+	22, // 5: mcserver.v1.Event.time:type_name -> google.protobuf.Timestamp
+	1,  // 6: mcserver.v1.Event.type:type_name -> mcserver.v1.EventType
+	22, // 7: mcserver.v1.Backup.created_at:type_name -> google.protobuf.Timestamp
+	17, // 8: mcserver.v1.ListBackupsResponse.backups:type_name -> mcserver.v1.Backup
+	2,  // 9: mcserver.v1.Control.GetStatus:input_type -> mcserver.v1.GetStatusRequest
+	5,  // 10: mcserver.v1.Control.SendCommand:input_type -> mcserver.v1.SendCommandRequest
+	7,  // 11: mcserver.v1.Control.RunAction:input_type -> mcserver.v1.RunActionRequest
+	9,  // 12: mcserver.v1.Control.StreamConsole:input_type -> mcserver.v1.StreamConsoleRequest
+	11, // 13: mcserver.v1.Control.StreamEvents:input_type -> mcserver.v1.StreamEventsRequest
+	13, // 14: mcserver.v1.Control.Start:input_type -> mcserver.v1.LifecycleRequest
+	13, // 15: mcserver.v1.Control.Stop:input_type -> mcserver.v1.LifecycleRequest
+	13, // 16: mcserver.v1.Control.Restart:input_type -> mcserver.v1.LifecycleRequest
+	15, // 17: mcserver.v1.Control.CreateBackup:input_type -> mcserver.v1.CreateBackupRequest
+	18, // 18: mcserver.v1.Control.ListBackups:input_type -> mcserver.v1.ListBackupsRequest
+	20, // 19: mcserver.v1.Control.RestoreBackup:input_type -> mcserver.v1.RestoreBackupRequest
+	4,  // 20: mcserver.v1.Control.GetStatus:output_type -> mcserver.v1.Status
+	6,  // 21: mcserver.v1.Control.SendCommand:output_type -> mcserver.v1.SendCommandResponse
+	8,  // 22: mcserver.v1.Control.RunAction:output_type -> mcserver.v1.RunActionResponse
+	10, // 23: mcserver.v1.Control.StreamConsole:output_type -> mcserver.v1.ConsoleLine
+	12, // 24: mcserver.v1.Control.StreamEvents:output_type -> mcserver.v1.Event
+	14, // 25: mcserver.v1.Control.Start:output_type -> mcserver.v1.LifecycleResponse
+	14, // 26: mcserver.v1.Control.Stop:output_type -> mcserver.v1.LifecycleResponse
+	14, // 27: mcserver.v1.Control.Restart:output_type -> mcserver.v1.LifecycleResponse
+	16, // 28: mcserver.v1.Control.CreateBackup:output_type -> mcserver.v1.CreateBackupResponse
+	19, // 29: mcserver.v1.Control.ListBackups:output_type -> mcserver.v1.ListBackupsResponse
+	21, // 30: mcserver.v1.Control.RestoreBackup:output_type -> mcserver.v1.RestoreBackupResponse
+	20, // [20:31] is the sub-list for method output_type
+	9,  // [9:20] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_mcserver_v1_control_proto_init() }
+func file_mcserver_v1_control_proto_init() {
+	if File_mcserver_v1_control_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_mcserver_v1_control_proto_rawDesc), len(file_mcserver_v1_control_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   20,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_mcserver_v1_control_proto_goTypes,
+		DependencyIndexes: file_mcserver_v1_control_proto_depIdxs,
+		EnumInfos:         file_mcserver_v1_control_proto_enumTypes,
+		MessageInfos:      file_mcserver_v1_control_proto_msgTypes,
+	}.Build()
+	File_mcserver_v1_control_proto = out.File
+	file_mcserver_v1_control_proto_goTypes = nil
+	file_mcserver_v1_control_proto_depIdxs = nil
+}
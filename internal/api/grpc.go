@@ -0,0 +1,290 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"mcserver-manager/internal/api/controlpb"
+	"mcserver-manager/internal/server"
+	"mcserver-manager/pkg/eventbus"
+	"mcserver-manager/pkg/extension"
+)
+
+// grpcService implements controlpb.ControlServer on top of an Agent
+type grpcService struct {
+	controlpb.UnimplementedControlServer
+	agent *Agent
+}
+
+// ServeGRPC serves the gRPC control API on addr using tlsConfig
+func (a *Agent) ServeGRPC(addr string, tlsConfig *tls.Config) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	grpcServer := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(tlsConfig)),
+		grpc.UnaryInterceptor(a.unaryAuth),
+		grpc.StreamInterceptor(a.streamAuth),
+	)
+	controlpb.RegisterControlServer(grpcServer, &grpcService{agent: a})
+	return grpcServer.Serve(listener)
+}
+
+// grpcRoles is the role each RPC requires
+var grpcRoles = map[string]Role{
+	controlpb.Control_GetStatus_FullMethodName:     RoleViewer,
+	controlpb.Control_StreamConsole_FullMethodName: RoleViewer,
+	controlpb.Control_StreamEvents_FullMethodName:  RoleViewer,
+	controlpb.Control_ListBackups_FullMethodName:   RoleViewer,
+	controlpb.Control_SendCommand_FullMethodName:   RoleOperator,
+	controlpb.Control_RunAction_FullMethodName:     RoleOperator,
+	controlpb.Control_CreateBackup_FullMethodName:  RoleOperator,
+	controlpb.Control_Start_FullMethodName:         RoleAdmin,
+	controlpb.Control_Stop_FullMethodName:          RoleAdmin,
+	controlpb.Control_Restart_FullMethodName:       RoleAdmin,
+	controlpb.Control_RestoreBackup_FullMethodName: RoleAdmin,
+}
+
+// authorizeGRPC authenticates the call's token and checks its role
+func (a *Agent) authorizeGRPC(ctx context.Context, fullMethod string) (context.Context, error) {
+	var header string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			header = values[0]
+		}
+	}
+
+	id, ok := a.authenticate(header, true)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing or invalid API token")
+	}
+
+	required, known := grpcRoles[fullMethod]
+	if !known {
+		required = RoleAdmin
+	}
+	if !id.Role.Allows(required) {
+		if required != RoleViewer {
+			a.denyAction(id, fullMethod, required)
+		}
+		return nil, status.Errorf(codes.PermissionDenied, "%s role required", required)
+	}
+
+	return withIdentity(ctx, id), nil
+}
+
+func (a *Agent) unaryAuth(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authorizeGRPC(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *Agent) streamAuth(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if _, err := a.authorizeGRPC(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+func (g *grpcService) GetStatus(ctx context.Context, req *controlpb.GetStatusRequest) (*controlpb.Status, error) {
+	stats := g.agent.srv.GetStats()
+
+	players := make([]*controlpb.Player, len(stats.Players))
+	for i, p := range stats.Players {
+		players[i] = &controlpb.Player{
+			Name:     p.Name,
+			Uuid:     p.UUID,
+			JoinTime: timestamppb.New(p.JoinedAt),
+			Bedrock:  p.Bedrock,
+		}
+	}
+
+	var serverType, mcVersion string
+	if sw := stats.Software; sw != nil {
+		serverType, mcVersion = sw.Type, sw.MCVersion
+	}
+	var lastCrash *timestamppb.Timestamp
+	if !stats.Lifetime.LastCrash.IsZero() {
+		lastCrash = timestamppb.New(stats.Lifetime.LastCrash)
+	}
+
+	return &controlpb.Status{
+		Status:          controlpb.ServerStatus(stats.Status),
+		StartTime:       timestamppb.New(stats.StartTime),
+		UptimeSeconds:   int64(stats.Uptime.Seconds()),
+		Restarts:        int32(stats.Restarts),
+		Tps:             stats.TPS,
+		MemoryUsed:      stats.MemoryUsed,
+		MemoryMax:       stats.MemoryMax,
+		CpuPercent:      stats.CPUPercent,
+		PlayerCount:     int32(stats.PlayerCount),
+		MaxPlayers:      int32(stats.MaxPlayers),
+		Players:         players,
+		BandwidthIn:     stats.DiskReadRate,
+		BandwidthOut:    stats.DiskWriteRate,
+		MapName:         stats.MapName,
+		Motd:            stats.MOTD,
+		Reachable:       stats.Reachable,
+		LatencyMs:       stats.Latency.Milliseconds(),
+		ShareAddress:    stats.ShareAddress,
+		DroppedLines:    stats.DroppedLines,
+		RestartRequired: stats.RestartRequired,
+		EulaRequired:    stats.EULARequired,
+		ServerType:      serverType,
+		McVersion:       mcVersion,
+		JavaVersion:     int32(stats.JavaVersion),
+
+		LifetimeUptimeSeconds: int64(stats.Lifetime.Uptime.Seconds()),
+		Starts:                int32(stats.Lifetime.Starts),
+		Crashes:               int32(stats.Lifetime.Crashes),
+		LastCrash:             lastCrash,
+		LastCrashReason:       stats.Lifetime.LastCrashReason,
+		LastGoodModpack:       stats.Lifetime.LastGoodModpack,
+
+		DiskReadRate:  stats.DiskReadRate,
+		DiskWriteRate: stats.DiskWriteRate,
+		DiskUtil:      stats.DiskUtil,
+
+		FiveMinuteTpsP95: stats.TPS5m.P95,
+		FiveMinuteTpsP99: stats.TPS5m.P99,
+		HourTpsP95:       stats.TPS1h.P95,
+		HourTpsP99:       stats.TPS1h.P99,
+	}, nil
+}
+
+func (g *grpcService) SendCommand(ctx context.Context, req *controlpb.SendCommandRequest) (*controlpb.SendCommandResponse, error) {
+	if req.GetCommand() == "" {
+		return nil, status.Error(codes.InvalidArgument, "command is required")
+	}
+	if err := g.agent.SendCommand(IdentityFrom(ctx), req.GetCommand()); err != nil {
+		if errors.Is(err, errForbidden) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return &controlpb.SendCommandResponse{}, nil
+}
+
+func (g *grpcService) RunAction(ctx context.Context, req *controlpb.RunActionRequest) (*controlpb.RunActionResponse, error) {
+	output, err := g.agent.runManagerAction(IdentityFrom(ctx), req.GetAction())
+	if err != nil {
+		if errors.Is(err, errForbidden) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return &controlpb.RunActionResponse{Output: output}, nil
+}
+
+func (g *grpcService) StreamConsole(req *controlpb.StreamConsoleRequest, stream grpc.ServerStreamingServer[controlpb.ConsoleLine]) error {
+	opts := []eventbus.Option[string]{eventbus.WithBuffer[string](consoleBacklog + 100)}
+	if !req.GetSkipBacklog() {
+		opts = append(opts, eventbus.WithBacklog[string]())
+	}
+	sub := g.agent.console.Subscribe(opts...)
+	defer sub.Close()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case line, ok := <-sub.C():
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(&controlpb.ConsoleLine{Text: line}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (g *grpcService) StreamEvents(req *controlpb.StreamEventsRequest, stream grpc.ServerStreamingServer[controlpb.Event]) error {
+	sub := g.agent.srv.Events().Subscribe()
+	defer sub.Close()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-sub.C():
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(eventToProto(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (g *grpcService) Start(ctx context.Context, req *controlpb.LifecycleRequest) (*controlpb.LifecycleResponse, error) {
+	go g.agent.runAction(IdentityFrom(ctx), "start", "", g.agent.srv.Start)
+	return &controlpb.LifecycleResponse{}, nil
+}
+
+func (g *grpcService) Stop(ctx context.Context, req *controlpb.LifecycleRequest) (*controlpb.LifecycleResponse, error) {
+	go g.agent.runAction(IdentityFrom(ctx), "stop", "", g.agent.srv.Stop)
+	return &controlpb.LifecycleResponse{}, nil
+}
+
+func (g *grpcService) Restart(ctx context.Context, req *controlpb.LifecycleRequest) (*controlpb.LifecycleResponse, error) {
+	go g.agent.runAction(IdentityFrom(ctx), "restart", "", g.agent.srv.Restart)
+	return &controlpb.LifecycleResponse{}, nil
+}
+
+func (g *grpcService) CreateBackup(ctx context.Context, req *controlpb.CreateBackupRequest) (*controlpb.CreateBackupResponse, error) {
+	if err := g.agent.runAction(IdentityFrom(ctx), "backup", "", g.agent.srv.Backup); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &controlpb.CreateBackupResponse{}, nil
+}
+
+func (g *grpcService) ListBackups(ctx context.Context, req *controlpb.ListBackupsRequest) (*controlpb.ListBackupsResponse, error) {
+	backups, err := g.agent.srv.ListBackups()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &controlpb.ListBackupsResponse{}
+	for _, b := range backups {
+		resp.Backups = append(resp.Backups, &controlpb.Backup{
+			Name:      b.Name,
+			Size:      b.Size,
+			CreatedAt: timestamppb.New(b.CreatedAt),
+		})
+	}
+	return resp, nil
+}
+
+func (g *grpcService) RestoreBackup(ctx context.Context, req *controlpb.RestoreBackupRequest) (*controlpb.RestoreBackupResponse, error) {
+	err := g.agent.runAction(IdentityFrom(ctx), "restore", req.GetName(), func() error {
+		return g.agent.srv.RestoreBackup(req.GetName())
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &controlpb.RestoreBackupResponse{}, nil
+}
+
+func eventToProto(event extension.Event) *controlpb.Event {
+	t, _ := server.ParseEventType(event.Type)
+	return &controlpb.Event{
+		Time:    timestamppb.New(event.Time),
+		Type:    controlpb.EventType(t),
+		Message: event.Message,
+	}
+}
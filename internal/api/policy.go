@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PolicyFile holds per-role command rules, relative to the server directory
+const PolicyFile = ".mcserver/command-policy.json"
+
+// RoleRules are the console commands a role may or may not send. Entries
+// are command names ("kick") or name prefixes ("whitelist add"); "*"
+// matches everything. Deny wins over allow.
+type RoleRules struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+// CommandPolicy decides which console commands each caller may send
+type CommandPolicy struct {
+	Roles map[Role]RoleRules `json:"roles"`
+}
+
+// DefaultCommandPolicy lets operators moderate and admins do anything.
+// Viewers may not send commands.
+func DefaultCommandPolicy() *CommandPolicy {
+	return &CommandPolicy{Roles: map[Role]RoleRules{
+		RoleOperator: {Allow: []string{
+			"list", "say", "tell", "msg", "w", "me",
+			"kick", "ban", "ban-ip", "pardon", "pardon-ip", "banlist",
+			"whitelist", "tp", "teleport", "time", "weather", "save-all",
+		}},
+		RoleAdmin: {Allow: []string{"*"}},
+	}}
+}
+
+// LoadCommandPolicy reads the policy for a server directory, falling back
+// to DefaultCommandPolicy for roles the file doesn't mention
+func LoadCommandPolicy(serverDir string) (*CommandPolicy, error) {
+	policy := DefaultCommandPolicy()
+
+	data, err := os.ReadFile(filepath.Join(serverDir, PolicyFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return policy, nil
+		}
+		return nil, fmt.Errorf("failed to read command policy: %w", err)
+	}
+
+	var custom CommandPolicy
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", PolicyFile, err)
+	}
+	for role, rules := range custom.Roles {
+		if _, err := ParseRole(string(role)); err != nil {
+			return nil, fmt.Errorf("%s: %w", PolicyFile, err)
+		}
+		policy.Roles[role] = rules
+	}
+
+	return policy, nil
+}
+
+// Check returns an error describing why id may not send command, or nil.
+// The token's own lists narrow the role's: a command must pass both.
+func (p *CommandPolicy) Check(id Identity, command string) error {
+	// The console reads one command per line, so anything after a line
+	// break would run without being checked
+	if strings.ContainsAny(command, "\r\n") {
+		return fmt.Errorf("command must be a single line")
+	}
+	words := commandWords(command)
+	if len(words) == 0 {
+		return fmt.Errorf("empty command")
+	}
+
+	rules := p.Roles[id.Role]
+	if matchAny(rules.Deny, words) || matchAny(id.Deny, words) {
+		return fmt.Errorf("%q is denied", words[0])
+	}
+	if !matchAny(rules.Allow, words) {
+		return fmt.Errorf("%q is not allowed for the %s role", words[0], id.Role)
+	}
+	if len(id.Allow) > 0 && !matchAny(id.Allow, words) {
+		return fmt.Errorf("%q is not in this token's allowlist", words[0])
+	}
+	return nil
+}
+
+// commandWords lowercases a command and strips a leading slash and
+// namespace ("/minecraft:kick Bob" -> ["kick", "bob"])
+func commandWords(command string) []string {
+	words := strings.Fields(strings.ToLower(strings.TrimPrefix(strings.TrimSpace(command), "/")))
+	if len(words) > 0 {
+		if _, after, found := strings.Cut(words[0], ":"); found {
+			words[0] = after
+		}
+	}
+	return words
+}
+
+func matchAny(patterns, words []string) bool {
+	for _, pattern := range patterns {
+		if pattern == "*" {
+			return true
+		}
+		prefix := strings.Fields(strings.ToLower(pattern))
+		if len(prefix) == 0 || len(prefix) > len(words) {
+			continue
+		}
+		match := true
+		for i := range prefix {
+			if prefix[i] != words[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
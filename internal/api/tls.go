@@ -0,0 +1,74 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLSFiles are the PEM files used for mutual TLS
+type TLSFiles struct {
+	CertFile string
+	KeyFile  string
+	CAFile   string // CA that signs the peer's certificate
+}
+
+// Complete reports whether all three files are set
+func (f TLSFiles) Complete() bool {
+	return f.CertFile != "" && f.KeyFile != "" && f.CAFile != ""
+}
+
+// ServerTLSConfig builds a TLS config that requires clients to present a
+// certificate signed by the CA
+func ServerTLSConfig(files TLSFiles) (*tls.Config, error) {
+	cert, pool, err := loadTLSFiles(files)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// ClientTLSConfig builds a TLS config that presents a client certificate
+// and verifies the agent against the CA
+func ClientTLSConfig(files TLSFiles) (*tls.Config, error) {
+	cert, pool, err := loadTLSFiles(files)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+func loadTLSFiles(files TLSFiles) (tls.Certificate, *x509.CertPool, error) {
+	if !files.Complete() {
+		return tls.Certificate{}, nil, fmt.Errorf("mutual TLS requires --tls-cert, --tls-key and --tls-ca")
+	}
+
+	cert, err := tls.LoadX509KeyPair(files.CertFile, files.KeyFile)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	caPEM, err := os.ReadFile(files.CAFile)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("failed to read CA: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return tls.Certificate{}, nil, fmt.Errorf("no certificates found in %s", files.CAFile)
+	}
+
+	return cert, pool, nil
+}
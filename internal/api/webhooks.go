@@ -0,0 +1,263 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mcserver-manager/internal/audit"
+	"mcserver-manager/internal/server"
+)
+
+// WebhooksFile holds inbound webhooks, relative to the server directory
+const WebhooksFile = ".mcserver/webhooks.json"
+
+// SignatureHeader carries a webhook's HMAC-SHA256, "sha256=<hex>", of
+// "<timestamp>.<delivery>.<body>" (see Webhook.Verify)
+const SignatureHeader = "X-Hub-Signature-256"
+
+// TimestampHeader and DeliveryHeader carry when a webhook was sent, in
+// Unix seconds, and an ID unique to the delivery, which may not contain
+// "." so the signed string splits only one way. Both are signed with the
+// body, so an old request can't be sent again.
+const (
+	TimestampHeader = "X-Mcserver-Timestamp"
+	DeliveryHeader  = "X-Mcserver-Delivery"
+)
+
+// How far a webhook's timestamp may be from the manager's clock; delivery
+// IDs are remembered this long
+const webhookWindow = 5 * time.Minute
+
+// Largest webhook body read; senders like CI put a JSON payload there
+const maxWebhookBody = 1 << 20
+
+// Webhook lets an external system run one predefined manager action by
+// POSTing to /v1/hooks/<name>. The timestamp, delivery ID and body must be
+// signed with HMAC-SHA256 under the secret, which is stored as-is since
+// the signature needs it.
+type Webhook struct {
+	Name   string `json:"name"`
+	Secret string `json:"secret"`
+	Action string `json:"action"`
+}
+
+// WebhookStore loads and saves webhooks
+type WebhookStore struct {
+	path string
+
+	mu    sync.RWMutex
+	hooks []Webhook
+
+	// Delivery IDs seen within webhookWindow, by hook name and ID
+	seenMu sync.Mutex
+	seen   map[string]time.Time
+}
+
+// LoadWebhooks reads the webhooks for a server directory. A missing file
+// yields an empty store.
+func LoadWebhooks(serverDir string) (*WebhookStore, error) {
+	store := &WebhookStore{path: filepath.Join(serverDir, WebhooksFile)}
+
+	data, err := os.ReadFile(store.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("failed to read webhooks: %w", err)
+	}
+	if err := json.Unmarshal(data, &store.hooks); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", store.path, err)
+	}
+	return store, nil
+}
+
+// List returns the configured webhooks
+func (w *WebhookStore) List() []Webhook {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return append([]Webhook(nil), w.hooks...)
+}
+
+// Create adds a webhook running action and returns its secret
+func (w *WebhookStore) Create(name, action string) (string, error) {
+	if _, _, err := server.LookupAction(action); err != nil {
+		return "", err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, hook := range w.hooks {
+		if hook.Name == name {
+			return "", fmt.Errorf("webhook %q already exists", name)
+		}
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	secret := hex.EncodeToString(buf)
+
+	w.hooks = append(w.hooks, Webhook{Name: name, Secret: secret, Action: strings.TrimPrefix(strings.TrimSpace(action), ":")})
+	return secret, w.save()
+}
+
+// Remove deletes a webhook by name
+func (w *WebhookStore) Remove(name string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i, hook := range w.hooks {
+		if hook.Name == name {
+			w.hooks = append(w.hooks[:i], w.hooks[i+1:]...)
+			return w.save()
+		}
+	}
+	return fmt.Errorf("webhook %q not found", name)
+}
+
+// Lookup returns the webhook called name
+func (w *WebhookStore) Lookup(name string) (Webhook, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for _, hook := range w.hooks {
+		if hook.Name == name {
+			return hook, true
+		}
+	}
+	return Webhook{}, false
+}
+
+// Verify reports whether signature, "sha256=<hex>", is the hook's HMAC of
+// "<timestamp>.<delivery>.<body>"
+func (h Webhook) Verify(timestamp, delivery string, body []byte, signature string) bool {
+	sum, found := strings.CutPrefix(signature, "sha256=")
+	if !found {
+		return false
+	}
+	got, err := hex.DecodeString(sum)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(h.Secret))
+	mac.Write([]byte(timestamp + "." + delivery + "."))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// checkTimestamp reports whether a TimestampHeader value is within
+// webhookWindow of now
+func checkTimestamp(value string, now time.Time) error {
+	secs, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("missing or invalid %s", TimestampHeader)
+	}
+	if sent := time.Unix(secs, 0); sent.Before(now.Add(-webhookWindow)) || sent.After(now.Add(webhookWindow)) {
+		return fmt.Errorf("%s is more than %s off", TimestampHeader, webhookWindow)
+	}
+	return nil
+}
+
+// firstDelivery records a hook's delivery ID and reports whether it is new,
+// forgetting the ones older than webhookWindow, whose timestamps no longer
+// pass anyway
+func (w *WebhookStore) firstDelivery(name, id string, now time.Time) bool {
+	w.seenMu.Lock()
+	defer w.seenMu.Unlock()
+
+	for key, at := range w.seen {
+		if now.Sub(at) > webhookWindow {
+			delete(w.seen, key)
+		}
+	}
+	key := name + "/" + id
+	if _, dup := w.seen[key]; dup {
+		return false
+	}
+	if w.seen == nil {
+		w.seen = map[string]time.Time{}
+	}
+	w.seen[key] = now
+	return true
+}
+
+func (w *WebhookStore) save() error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(w.hooks, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(w.path, data, 0600)
+}
+
+// handleWebhook runs the action of the webhook named in the path when the
+// signature checks out and the delivery is recent and not seen before. The
+// signature stands in for a token, and the action runs in the background
+// since backups and upgrades take longer than senders wait for an answer;
+// its outcome goes to the audit log.
+func (a *Agent) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s required", http.MethodPost))
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/v1/hooks/")
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Unknown hooks and bad signatures look the same to the sender
+	var hook Webhook
+	ok := false
+	if a.webhooks != nil {
+		hook, ok = a.webhooks.Lookup(name)
+	}
+	timestamp, delivery := r.Header.Get(TimestampHeader), r.Header.Get(DeliveryHeader)
+	if strings.Contains(delivery, ".") {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%s may not contain \".\"", DeliveryHeader))
+		return
+	}
+	if !ok || !hook.Verify(timestamp, delivery, body, r.Header.Get(SignatureHeader)) {
+		if ok {
+			a.srv.RecordDenied(audit.SourceWebhook, name, audit.KindAction, hook.Action, "bad signature")
+		}
+		writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or invalid %s", SignatureHeader))
+		return
+	}
+	now := time.Now()
+	if err := checkTimestamp(timestamp, now); err != nil {
+		a.srv.RecordDenied(audit.SourceWebhook, name, audit.KindAction, hook.Action, err.Error())
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+	if delivery == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("missing %s", DeliveryHeader))
+		return
+	}
+	if !a.webhooks.firstDelivery(name, delivery, now) {
+		a.srv.RecordDenied(audit.SourceWebhook, name, audit.KindAction, hook.Action, "replayed delivery "+delivery)
+		writeError(w, http.StatusConflict, fmt.Errorf("delivery %s was already received", delivery))
+		return
+	}
+
+	go a.srv.RunAction(audit.SourceWebhook, name, hook.Action)
+	writeJSON(w, http.StatusAccepted, actionResponse{Output: "Running " + hook.Action})
+}
@@ -0,0 +1,144 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// auditFile is the append-only log, relative to the server directory
+const auditFile = ".mcserver/audit.jsonl"
+
+// Source is where a command or action came from
+type Source string
+
+const (
+	SourceTUI     Source = "tui"
+	SourceAPI     Source = "api"
+	SourceDiscord Source = "discord"
+	SourceRules   Source = "rules"
+	SourceCLI     Source = "cli"
+	SourceGitOps  Source = "gitops"
+	SourceWebhook Source = "webhook"
+	SourceChat    Source = "chat"
+)
+
+// Kind separates console commands from administrative actions
+type Kind string
+
+const (
+	KindCommand Kind = "command"
+	KindAction  Kind = "action"
+)
+
+// Entry is one audit record
+type Entry struct {
+	Time   time.Time `json:"time"`
+	Source Source    `json:"source"`
+	Actor  string    `json:"actor,omitempty"`
+	Kind   Kind      `json:"kind"`
+	Action string    `json:"action"` // the command line, or e.g. "restart"
+	Detail string    `json:"detail,omitempty"`
+	Denied bool      `json:"denied,omitempty"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// Log appends entries to a server's audit file
+type Log struct {
+	path string
+	mu   sync.Mutex
+}
+
+// Open returns the audit log for a server directory
+func Open(serverDir string) *Log {
+	return &Log{path: filepath.Join(serverDir, auditFile)}
+}
+
+// Record appends an entry, filling in the time if unset
+func (l *Log) Record(entry Entry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Filter selects entries when reading the log. Zero fields match everything.
+type Filter struct {
+	Since  time.Time
+	Source Source
+	Actor  string
+	Kind   Kind
+	Limit  int // most recent N after filtering
+}
+
+func (f Filter) matches(e Entry) bool {
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if f.Source != "" && e.Source != f.Source {
+		return false
+	}
+	if f.Actor != "" && e.Actor != f.Actor {
+		return false
+	}
+	if f.Kind != "" && e.Kind != f.Kind {
+		return false
+	}
+	return true
+}
+
+// Read returns the entries matching filter, oldest first
+func Read(serverDir string, filter Filter) ([]Entry, error) {
+	f, err := os.Open(filepath.Join(serverDir, auditFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		if filter.matches(e) {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+	return entries, nil
+}
@@ -0,0 +1,80 @@
+package backup
+
+import (
+	"archive/zip"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Contents describes a backup from its zip directory, without extracting
+// anything
+type Contents struct {
+	// Nil for backups made before manifests existed
+	Manifest *Manifest
+
+	Worlds       []WorldContents
+	Files        int
+	Compressed   uint64
+	Uncompressed uint64
+}
+
+// WorldContents is one top-level folder of a backup
+type WorldContents struct {
+	Name         string
+	Files        int
+	Compressed   uint64
+	Uncompressed uint64
+}
+
+// Inspect reads what the backup at path holds
+func Inspect(path string) (*Contents, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer r.Close()
+
+	contents := &Contents{}
+	byName := map[string]*WorldContents{}
+	for _, f := range r.File {
+		if f.Name == ManifestFile || f.FileInfo().IsDir() {
+			continue
+		}
+		top, _, _ := strings.Cut(f.Name, "/")
+		w := byName[top]
+		if w == nil {
+			w = &WorldContents{Name: top}
+			byName[top] = w
+		}
+		w.Files++
+		w.Compressed += f.CompressedSize64
+		w.Uncompressed += f.UncompressedSize64
+		contents.Files++
+		contents.Compressed += f.CompressedSize64
+		contents.Uncompressed += f.UncompressedSize64
+	}
+	for _, w := range byName {
+		contents.Worlds = append(contents.Worlds, *w)
+	}
+	sort.Slice(contents.Worlds, func(i, j int) bool { return contents.Worlds[i].Name < contents.Worlds[j].Name })
+
+	if manifest, err := ReadManifest(path); err == nil {
+		contents.Manifest = manifest
+	}
+	return contents, nil
+}
+
+// Find returns the backup called name
+func (m *Manager) Find(name string) (*BackupInfo, error) {
+	backups, err := m.ListBackups()
+	if err != nil {
+		return nil, err
+	}
+	for i := range backups {
+		if backups[i].Name == name || strings.TrimSuffix(backups[i].Name, ".zip") == name {
+			return &backups[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no backup named %q in %s", name, m.backupDir)
+}
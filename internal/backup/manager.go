@@ -2,6 +2,7 @@ package backup
 
 import (
 	"archive/zip"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -9,13 +10,34 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"mcserver-manager/internal/world"
 )
 
+// ManifestFile is the entry at the root of every backup that describes it
+const ManifestFile = "mcserver-manifest.json"
+
+// Manifest records when a backup was taken and what its worlds were
+type Manifest struct {
+	CreatedAt time.Time              `json:"createdAt"`
+	Worlds    map[string]*world.Info `json:"worlds"`
+
+	// World folders in the backup, and those a selection left out
+	Included []string `json:"included,omitempty"`
+	Skipped  []string `json:"skipped,omitempty"`
+
+	// The modpack file and server software the server ran at the time
+	Modpack  string `json:"modpack,omitempty"`
+	Software string `json:"software,omitempty"`
+}
+
 // Manager handles world backups
 type Manager struct {
 	serverDir  string
 	backupDir  string
 	maxBackups int
+
+	modpack, software string
 }
 
 // BackupInfo holds information about a backup
@@ -35,11 +57,28 @@ func NewManager(serverDir, backupDir string, maxBackups int) *Manager {
 	}
 }
 
-// CreateBackup creates a backup of the world folders
-func (m *Manager) CreateBackup() error {
+// SetMaxBackups changes how many backups are kept from the next backup on
+func (m *Manager) SetMaxBackups(n int) {
+	m.maxBackups = n
+}
+
+// SetOrigin records the modpack and server software in the manifests of
+// the following backups
+func (m *Manager) SetOrigin(modpack, software string) {
+	m.modpack, m.software = modpack, software
+}
+
+// CreateBackup creates a backup of the world folders and returns its path
+func (m *Manager) CreateBackup() (string, error) {
+	return m.CreateBackupOf(nil)
+}
+
+// CreateBackupOf backs up the world folders include accepts by name, all
+// of them if include is nil, and returns the backup's path
+func (m *Manager) CreateBackupOf(include func(name string) bool) (string, error) {
 	// Ensure backup directory exists
 	if err := os.MkdirAll(m.backupDir, 0755); err != nil {
-		return fmt.Errorf("failed to create backup directory: %w", err)
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
 	}
 
 	// Generate backup filename with timestamp
@@ -50,33 +89,58 @@ func (m *Manager) CreateBackup() error {
 	// Find world directories to backup
 	worldDirs, err := m.findWorldDirs()
 	if err != nil {
-		return fmt.Errorf("failed to find world directories: %w", err)
+		return "", fmt.Errorf("failed to find world directories: %w", err)
 	}
 
 	if len(worldDirs) == 0 {
-		return fmt.Errorf("no world directories found to backup")
+		return "", fmt.Errorf("no world directories found to backup")
+	}
+	var skipped []string
+	if include != nil {
+		var selected []string
+		for _, dir := range worldDirs {
+			if include(filepath.Base(dir)) {
+				selected = append(selected, dir)
+			} else {
+				skipped = append(skipped, filepath.Base(dir))
+			}
+		}
+		if len(selected) == 0 {
+			return "", fmt.Errorf("the world selection leaves out every world folder (%s)", strings.Join(skipped, ", "))
+		}
+		worldDirs = selected
 	}
 
 	// Create the backup zip file
 	zipFile, err := os.Create(backupPath)
 	if err != nil {
-		return fmt.Errorf("failed to create backup file: %w", err)
+		return "", fmt.Errorf("failed to create backup file: %w", err)
 	}
 	defer zipFile.Close()
 
 	zipWriter := zip.NewWriter(zipFile)
 	defer zipWriter.Close()
 
+	if err := m.writeManifest(zipWriter, worldDirs, skipped); err != nil {
+		return "", fmt.Errorf("failed to write backup manifest: %w", err)
+	}
+
 	// Add each world directory to the backup
 	for _, worldDir := range worldDirs {
 		if err := m.addDirToZip(zipWriter, worldDir, filepath.Base(worldDir)); err != nil {
-			return fmt.Errorf("failed to add %s to backup: %w", worldDir, err)
+			return "", fmt.Errorf("failed to add %s to backup: %w", worldDir, err)
 		}
 	}
 
 	// Close the zip writer to finalize
 	if err := zipWriter.Close(); err != nil {
-		return fmt.Errorf("failed to finalize backup: %w", err)
+		return "", fmt.Errorf("failed to finalize backup: %w", err)
+	}
+	if err := zipFile.Close(); err != nil {
+		return "", fmt.Errorf("failed to finalize backup: %w", err)
+	}
+	if err := writeChecksum(backupPath); err != nil {
+		return "", fmt.Errorf("failed to write backup checksum: %w", err)
 	}
 
 	// Cleanup old backups
@@ -85,7 +149,58 @@ func (m *Manager) CreateBackup() error {
 		fmt.Printf("Warning: failed to cleanup old backups: %v\n", err)
 	}
 
-	return nil
+	return backupPath, nil
+}
+
+// writeManifest adds the manifest entry, reading level.dat of every world
+// folder that has one
+func (m *Manager) writeManifest(zipWriter *zip.Writer, worldDirs, skipped []string) error {
+	manifest := Manifest{
+		CreatedAt: time.Now(),
+		Worlds:    map[string]*world.Info{},
+		Skipped:   skipped,
+		Modpack:   m.modpack,
+		Software:  m.software,
+	}
+	for _, dir := range worldDirs {
+		manifest.Included = append(manifest.Included, filepath.Base(dir))
+		if info, err := world.ReadInfo(dir); err == nil {
+			manifest.Worlds[filepath.Base(dir)] = info
+		}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	w, err := zipWriter.Create(ManifestFile)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// ReadManifest returns the manifest stored in a backup. Backups made before
+// manifests existed return an error.
+func ReadManifest(backupPath string) (*Manifest, error) {
+	r, err := zip.OpenReader(backupPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer r.Close()
+
+	f, err := r.Open(ManifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("backup has no manifest")
+	}
+	defer f.Close()
+
+	var manifest Manifest
+	if err := json.NewDecoder(f).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse backup manifest: %w", err)
+	}
+	return &manifest, nil
 }
 
 // findWorldDirs finds all world directories in the server folder
@@ -200,7 +315,9 @@ func (m *Manager) cleanupOldBackups() error {
 	for i := m.maxBackups; i < len(backups); i++ {
 		if err := os.Remove(backups[i].Path); err != nil {
 			fmt.Printf("Warning: failed to remove old backup %s: %v\n", backups[i].Name, err)
+			continue
 		}
+		os.Remove(backups[i].Path + ChecksumSuffix)
 	}
 
 	return nil
@@ -245,6 +362,11 @@ func (m *Manager) ListBackups() ([]BackupInfo, error) {
 
 // RestoreBackup restores a backup to the server directory
 func (m *Manager) RestoreBackup(backupPath string) error {
+	return RestoreTo(backupPath, m.serverDir)
+}
+
+// RestoreTo extracts a backup into dir, which is created if needed
+func RestoreTo(backupPath, dir string) error {
 	// Open the backup zip file
 	r, err := zip.OpenReader(backupPath)
 	if err != nil {
@@ -254,7 +376,13 @@ func (m *Manager) RestoreBackup(backupPath string) error {
 
 	// Extract all files
 	for _, f := range r.File {
-		destPath := filepath.Join(m.serverDir, f.Name)
+		if f.Name == ManifestFile {
+			continue
+		}
+		if !filepath.IsLocal(f.Name) {
+			return fmt.Errorf("backup entry %q points outside the directory", f.Name)
+		}
+		destPath := filepath.Join(dir, f.Name)
 
 		if f.FileInfo().IsDir() {
 			os.MkdirAll(destPath, 0755)
@@ -0,0 +1,82 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// rsyncTarget copies backups into a directory, typically a mounted NAS or
+// second disk, with rsync when it is installed
+type rsyncTarget struct {
+	dir string
+}
+
+func (t *rsyncTarget) String() string {
+	return t.dir
+}
+
+func (t *rsyncTarget) Upload(ctx context.Context, path string) error {
+	if err := os.MkdirAll(t.dir, 0755); err != nil {
+		return err
+	}
+	if rsync, err := exec.LookPath("rsync"); err == nil {
+		out, err := exec.CommandContext(ctx, rsync, "--times", path, t.dir+string(filepath.Separator)).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("rsync: %v: %s", err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+	return copyFile(path, filepath.Join(t.dir, filepath.Base(path)))
+}
+
+func (t *rsyncTarget) List(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(t.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+func (t *rsyncTarget) Delete(ctx context.Context, name string) error {
+	return os.Remove(filepath.Join(t.dir, name))
+}
+
+// copyFile copies src to dst through a temporary file, so an interrupted
+// copy never looks like a complete backup
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dst + ".part"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
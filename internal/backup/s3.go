@@ -0,0 +1,266 @@
+package backup
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// s3Target uploads backups to an S3-compatible bucket (AWS, MinIO,
+// Backblaze B2, Cloudflare R2, ...) with path-style requests signed with
+// Signature Version 4
+type s3Target struct {
+	endpoint string // scheme://host
+	region   string
+	bucket   string
+	prefix   string // "" or ending in "/"
+
+	accessKey, secretKey, sessionToken string
+
+	http *http.Client
+}
+
+func newS3Target(u *url.URL) (*s3Target, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("s3 target %q needs a bucket", u.Redacted())
+	}
+	q := u.Query()
+	t := &s3Target{
+		region:       q.Get("region"),
+		bucket:       u.Host,
+		prefix:       strings.Trim(u.Path, "/"),
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		http:         &http.Client{},
+	}
+	if t.prefix != "" {
+		t.prefix += "/"
+	}
+	if t.region == "" {
+		t.region = "us-east-1"
+	}
+	t.endpoint = strings.TrimRight(q.Get("endpoint"), "/")
+	if t.endpoint == "" {
+		t.endpoint = "https://s3." + t.region + ".amazonaws.com"
+	}
+	if e, err := url.Parse(t.endpoint); err != nil || e.Host == "" || (e.Scheme != "https" && e.Scheme != "http") {
+		return nil, fmt.Errorf("s3 target: invalid endpoint %q", t.endpoint)
+	}
+	if t.accessKey == "" || t.secretKey == "" {
+		return nil, fmt.Errorf("s3 target %s needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY", t)
+	}
+	return t, nil
+}
+
+func (t *s3Target) String() string {
+	return "s3://" + t.bucket + "/" + t.prefix
+}
+
+// Single PUTs are limited to 5 GB, which world backups stay well under
+func (t *s3Target) Upload(ctx context.Context, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	req, err := t.request(ctx, http.MethodPut, t.prefix+filepath.Base(path), nil, f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", "application/zip")
+	resp, err := t.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (t *s3Target) List(ctx context.Context) ([]string, error) {
+	var names []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {t.prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		req, err := t.request(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := t.do(req)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Contents []struct {
+				Key string `xml:"Key"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse bucket listing: %w", err)
+		}
+		for _, c := range result.Contents {
+			// Only this prefix's own files, not those of folders below it
+			if name := strings.TrimPrefix(c.Key, t.prefix); !strings.Contains(name, "/") {
+				names = append(names, name)
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return names, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (t *s3Target) Delete(ctx context.Context, name string) error {
+	req, err := t.request(ctx, http.MethodDelete, t.prefix+name, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := t.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// request builds a signed request for key in the bucket ("" for the
+// bucket itself). The payload is not hashed, which S3 allows; TLS
+// protects it in transit.
+func (t *s3Target) request(ctx context.Context, method, key string, query url.Values, body io.Reader) (*http.Request, error) {
+	uri := "/" + awsEscape(t.bucket, false)
+	if key != "" {
+		uri += "/" + awsEscape(key, true)
+	}
+	canonicalQuery := canonicalAWSQuery(query)
+	target := t.endpoint + uri
+	if canonicalQuery != "" {
+		target += "?" + canonicalQuery
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	const payloadHash = "UNSIGNED-PAYLOAD"
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	if t.sessionToken != "" {
+		headers["x-amz-security-token"] = t.sessionToken
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+		if name != "host" {
+			req.Header.Set(name, headers[name])
+		}
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{method, uri, canonicalQuery, canonicalHeaders.String(), signedHeaders, payloadHash}, "\n")
+	scope := day + "/" + t.region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	signingKey := hmacSHA256([]byte("AWS4"+t.secretKey), day)
+	for _, part := range []string{t.region, "s3", "aws4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", t.accessKey, scope, signedHeaders, signature))
+	return req, nil
+}
+
+// do sends req and turns S3 error responses into errors
+func (t *s3Target) do(req *http.Request) (*http.Response, error) {
+	resp, err := t.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s: %w", t.endpoint, err)
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var s3Err struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		}
+		if xml.NewDecoder(resp.Body).Decode(&s3Err) == nil && s3Err.Code != "" {
+			return nil, fmt.Errorf("%s: %s (%s)", t, s3Err.Message, s3Err.Code)
+		}
+		return nil, fmt.Errorf("%s: status %d", t, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalAWSQuery encodes query sorted by name, the way SigV4 signs it
+func canonicalAWSQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		for _, value := range query[name] {
+			parts = append(parts, awsEscape(name, false)+"="+awsEscape(value, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// awsEscape percent-encodes everything but the unreserved characters,
+// keeping slashes in object keys
+func awsEscape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,108 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// sftpTarget uploads backups with OpenSSH's sftp in batch mode, so the
+// user's SSH keys, agent and known_hosts apply as they do on the command
+// line
+type sftpTarget struct {
+	host string // user@host
+	port string
+	dir  string
+}
+
+func newSFTPTarget(u *url.URL) (*sftpTarget, error) {
+	if u.Host == "" || u.Hostname() == "" {
+		return nil, fmt.Errorf("sftp target %q needs a host", u.Redacted())
+	}
+	if _, hasPassword := u.User.Password(); hasPassword {
+		return nil, fmt.Errorf("sftp target %q: use an SSH key instead of a password", u.Redacted())
+	}
+	host := u.Hostname()
+	if name := u.User.Username(); name != "" {
+		host = name + "@" + host
+	}
+	dir := u.Path
+	if dir == "" {
+		dir = "."
+	}
+	return &sftpTarget{host: host, port: u.Port(), dir: dir}, nil
+}
+
+func (t *sftpTarget) String() string {
+	s := "sftp://" + t.host
+	if t.port != "" {
+		s += ":" + t.port
+	}
+	return s + t.dir
+}
+
+// run runs sftp batch commands and returns what they printed
+func (t *sftpTarget) run(ctx context.Context, commands ...string) (string, error) {
+	sftp, err := exec.LookPath("sftp")
+	if err != nil {
+		return "", fmt.Errorf("sftp targets need OpenSSH's sftp installed")
+	}
+	args := []string{"-b", "-", "-o", "BatchMode=yes"}
+	if t.port != "" {
+		args = append(args, "-P", t.port)
+	}
+	cmd := exec.CommandContext(ctx, sftp, append(args, t.host)...)
+	cmd.Stdin = strings.NewReader(strings.Join(commands, "\n") + "\n")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("sftp: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+func (t *sftpTarget) Upload(ctx context.Context, local string) error {
+	// Upload under a temporary name so an interrupted transfer never
+	// looks like a complete backup; "-mkdir" may fail when it exists, and
+	// makes the parent first for a per-server folder in a new directory
+	name := path.Base(strings.ReplaceAll(local, "\\", "/"))
+	remote := path.Join(t.dir, name)
+	_, err := t.run(ctx,
+		"-mkdir "+quoteSFTP(path.Dir(t.dir)),
+		"-mkdir "+quoteSFTP(t.dir),
+		"put "+quoteSFTP(local)+" "+quoteSFTP(remote+".part"),
+		"rename "+quoteSFTP(remote+".part")+" "+quoteSFTP(remote),
+	)
+	return err
+}
+
+func (t *sftpTarget) List(ctx context.Context) ([]string, error) {
+	out, err := t.run(ctx, "ls -1 "+quoteSFTP(t.dir))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		// Batch mode echoes each command with the prompt
+		if line == "" || strings.HasPrefix(line, "sftp>") {
+			continue
+		}
+		names = append(names, path.Base(line))
+	}
+	return names, nil
+}
+
+func (t *sftpTarget) Delete(ctx context.Context, name string) error {
+	_, err := t.run(ctx, "rm "+quoteSFTP(path.Join(t.dir, name)))
+	return err
+}
+
+// quoteSFTP quotes an argument of an sftp batch command
+func quoteSFTP(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,104 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Target is somewhere off the server's disk that completed backups are
+// copied to
+type Target interface {
+	// String identifies the target in events, without credentials
+	String() string
+	// Upload copies the backup zip at path to the target under its name
+	Upload(ctx context.Context, path string) error
+	// List returns the names of the backups on the target
+	List(ctx context.Context) ([]string, error)
+	// Delete removes a backup from the target by name
+	Delete(ctx context.Context, name string) error
+}
+
+// ParseTarget reads a target:
+//
+//	s3://bucket/prefix[?endpoint=https://...&region=...]
+//	sftp://user@host[:port]/path
+//	/mnt/nas/backups (or rsync:/mnt/nas/backups)
+//
+// S3 credentials come from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY,
+// SFTP logs in with the user's SSH keys.
+func ParseTarget(spec string) (Target, error) {
+	if path, ok := strings.CutPrefix(spec, "rsync:"); ok {
+		spec = path
+	}
+	if filepath.IsAbs(spec) {
+		return &rsyncTarget{dir: filepath.Clean(spec)}, nil
+	}
+
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup target %q: %w", spec, err)
+	}
+	switch u.Scheme {
+	case "s3":
+		return newS3Target(u)
+	case "sftp":
+		return newSFTPTarget(u)
+	}
+	return nil, fmt.Errorf("invalid backup target %q, want s3://bucket/prefix, sftp://user@host/path or an absolute path", spec)
+}
+
+// ServerTarget returns spec with a folder named after server added to its
+// path, so servers sharing a target keep their backups, and their
+// retention, apart
+func ServerTarget(spec, server string) string {
+	if dir, ok := strings.CutPrefix(spec, "rsync:"); ok {
+		return "rsync:" + filepath.Join(dir, server)
+	}
+	if filepath.IsAbs(spec) {
+		return filepath.Join(spec, server)
+	}
+	u, err := url.Parse(spec)
+	if err != nil {
+		return spec
+	}
+	u.Path = path.Join("/", u.Path, server)
+	u.RawPath = ""
+	return u.String()
+}
+
+// isBackupName reports whether name is one of the manager's backup zips
+func isBackupName(name string) bool {
+	return strings.HasPrefix(name, "backup_") && strings.HasSuffix(name, ".zip")
+}
+
+// Prune deletes the oldest backups on t so that keep remain. Backup names
+// carry their timestamp, so they sort oldest first.
+func Prune(ctx context.Context, t Target, keep int) ([]string, error) {
+	names, err := t.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var backups []string
+	for _, name := range names {
+		if isBackupName(name) {
+			backups = append(backups, name)
+		}
+	}
+	if keep <= 0 || len(backups) <= keep {
+		return nil, nil
+	}
+	sort.Strings(backups)
+
+	removed := backups[:len(backups)-keep]
+	for _, name := range removed {
+		if err := t.Delete(ctx, name); err != nil {
+			return nil, fmt.Errorf("failed to delete %s: %w", name, err)
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,121 @@
+package backup
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ChecksumSuffix names the file next to each backup holding its SHA-256,
+// in sha256sum's format so `sha256sum -c` can check it too
+const ChecksumSuffix = ".sha256"
+
+// Verification is what VerifyBackup checked
+type Verification struct {
+	Name  string
+	Files int
+	// Checksum is false for backups made before checksums were written,
+	// which are only checked entry by entry
+	Checksum bool
+}
+
+// writeChecksum stores the SHA-256 of the backup at path next to it
+func writeChecksum(path string) error {
+	sum, err := fileSHA256(path)
+	if err != nil {
+		return err
+	}
+	line := sum + "  " + filepath.Base(path) + "\n"
+	return os.WriteFile(path+ChecksumSuffix, []byte(line), 0644)
+}
+
+// VerifyBackup checks the named backup: its SHA-256 against the one stored
+// when it was made, every entry against the CRC-32 in the zip, and that
+// every world in its manifest is in the archive
+func (m *Manager) VerifyBackup(name string) (*Verification, error) {
+	info, err := m.Find(name)
+	if err != nil {
+		return nil, err
+	}
+	v := &Verification{Name: info.Name}
+
+	if data, err := os.ReadFile(info.Path + ChecksumSuffix); err == nil {
+		want, _, _ := strings.Cut(strings.TrimSpace(string(data)), " ")
+		got, err := fileSHA256(info.Path)
+		if err != nil {
+			return v, err
+		}
+		if !strings.EqualFold(want, got) {
+			return v, fmt.Errorf("SHA-256 is %s, but was %s when the backup was made", got, want)
+		}
+		v.Checksum = true
+	} else if !os.IsNotExist(err) {
+		return v, err
+	}
+
+	r, err := zip.OpenReader(info.Path)
+	if err != nil {
+		return v, fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer r.Close()
+
+	tops := map[string]bool{}
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if err := readEntry(f); err != nil {
+			return v, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		top, _, _ := strings.Cut(f.Name, "/")
+		tops[top] = true
+		v.Files++
+	}
+
+	// Backups made before manifests existed have none to check against
+	manifest, err := ReadManifest(info.Path)
+	if err != nil {
+		return v, nil
+	}
+	for _, world := range manifest.Included {
+		if !tops[world] {
+			return v, fmt.Errorf("manifest lists world %s, but the archive has none of its files", world)
+		}
+	}
+	return v, nil
+}
+
+// readEntry reads a zip entry to the end, which checks its CRC-32
+func readEntry(f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if _, err := io.Copy(io.Discard, rc); err != nil {
+		if errors.Is(err, zip.ErrChecksum) {
+			return fmt.Errorf("contents do not match their CRC-32")
+		}
+		return err
+	}
+	return nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
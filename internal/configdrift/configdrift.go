@@ -0,0 +1,257 @@
+// Package configdrift snapshots the server's key config files and records a
+// diff whenever they change, so edits on a shared server leave a trail.
+package configdrift
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mcserver-manager/internal/props"
+)
+
+const (
+	snapshotDir = ".mcserver/config-snapshots"
+	historyFile = ".mcserver/config-history.jsonl"
+)
+
+// Tracked are the files, relative to the server dir, whose changes are
+// recorded
+var Tracked = []string{
+	"server.properties",
+	"whitelist.json",
+	"ops.json",
+	"banned-players.json",
+	"banned-ips.json",
+}
+
+// checkMutex keeps concurrent checks from recording the same change twice
+var checkMutex sync.Mutex
+
+// Change is one recorded edit of a tracked file
+type Change struct {
+	Time time.Time `json:"time"`
+	File string    `json:"file"`
+	When string    `json:"when"` // what was going on when it was seen
+	Diff []string  `json:"diff"`
+}
+
+func (c *Change) String() string {
+	return fmt.Sprintf("%s %s: %s", c.File, c.When, strings.Join(c.Diff, ", "))
+}
+
+// Check compares the tracked files with their snapshots, records a Change
+// for each one that differs and takes new snapshots. A file seen for the
+// first time is only snapshotted.
+func Check(serverDir, when string) ([]Change, error) {
+	checkMutex.Lock()
+	defer checkMutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Join(serverDir, snapshotDir), 0755); err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for _, file := range Tracked {
+		live := filepath.Join(serverDir, file)
+		snapshot := filepath.Join(serverDir, snapshotDir, file)
+
+		current, err := os.ReadFile(live)
+		if err != nil && !os.IsNotExist(err) {
+			return changes, err
+		}
+		previous, err := os.ReadFile(snapshot)
+		if os.IsNotExist(err) {
+			if current != nil {
+				if err := os.WriteFile(snapshot, current, 0644); err != nil {
+					return changes, err
+				}
+			}
+			continue
+		}
+		if err != nil {
+			return changes, err
+		}
+		if string(current) == string(previous) {
+			continue
+		}
+
+		diff, err := diffFile(file, snapshot, live)
+		if err != nil {
+			return changes, fmt.Errorf("failed to diff %s: %w", file, err)
+		}
+		if current == nil {
+			err = os.Remove(snapshot)
+		} else {
+			err = os.WriteFile(snapshot, current, 0644)
+		}
+		if err != nil {
+			return changes, err
+		}
+		// Whitespace or ordering only
+		if len(diff) == 0 {
+			continue
+		}
+		changes = append(changes, Change{Time: time.Now(), File: file, When: when, Diff: diff})
+	}
+
+	if len(changes) > 0 {
+		if err := appendHistory(serverDir, changes); err != nil {
+			return changes, err
+		}
+	}
+	return changes, nil
+}
+
+// History returns the recorded changes, oldest first, optionally of one
+// file only and limited to the newest limit entries
+func History(serverDir, file string, limit int) ([]Change, error) {
+	f, err := os.Open(filepath.Join(serverDir, historyFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var changes []Change
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var c Change
+		if json.Unmarshal(scanner.Bytes(), &c) != nil {
+			continue
+		}
+		if file == "" || c.File == file {
+			changes = append(changes, c)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(changes) > limit {
+		changes = changes[len(changes)-limit:]
+	}
+	return changes, nil
+}
+
+func appendHistory(serverDir string, changes []Change) error {
+	f, err := os.OpenFile(filepath.Join(serverDir, historyFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for i := range changes {
+		if err := enc.Encode(&changes[i]); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// diffFile describes how file changed from the snapshot to the live copy:
+// per key for server.properties, per entry for the JSON player lists
+func diffFile(file, before, after string) ([]string, error) {
+	if file == "server.properties" {
+		return diffProperties(before, after)
+	}
+
+	// A snapshot of a broken file counts as empty
+	old, _ := listEntries(before)
+	current, err := listEntries(after)
+	if err != nil {
+		return []string{"now invalid JSON"}, nil
+	}
+	var diff []string
+	for _, entry := range current {
+		if !contains(old, entry) {
+			diff = append(diff, "+"+entry)
+		}
+	}
+	for _, entry := range old {
+		if !contains(current, entry) {
+			diff = append(diff, "-"+entry)
+		}
+	}
+	return diff, nil
+}
+
+func diffProperties(before, after string) ([]string, error) {
+	old, err := props.Load(before)
+	if err != nil {
+		return nil, err
+	}
+	current, err := props.Load(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var diff []string
+	for _, key := range current.Keys() {
+		value, _ := current.Get(key)
+		was, ok := old.Get(key)
+		switch {
+		case !ok:
+			diff = append(diff, fmt.Sprintf("+%s=%s", key, value))
+		case was != value:
+			diff = append(diff, fmt.Sprintf("%s: %q -> %q", key, was, value))
+		}
+	}
+	for _, key := range old.Keys() {
+		if _, ok := current.Get(key); !ok {
+			diff = append(diff, "-"+key)
+		}
+	}
+	return diff, nil
+}
+
+// listEntries reads a player list as sorted "name" or "ip" entries, with
+// the level for ops
+func listEntries(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var list []struct {
+		Name  string `json:"name"`
+		IP    string `json:"ip"`
+		Level int    `json:"level"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	var entries []string
+	for _, e := range list {
+		entry := e.Name
+		if e.IP != "" {
+			entry = e.IP
+		}
+		if e.Level > 0 {
+			entry += fmt.Sprintf(" (level %d)", e.Level)
+		}
+		entries = append(entries, entry)
+	}
+	sort.Strings(entries)
+	return entries, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+// Package cron reads the five-field schedules of crontab(5), such as
+// "0 4 * * *", and works out when they next fire.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression. Times are in the local time zone.
+type Schedule struct {
+	expr   string
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	// A * day of month or day of week; when neither is, a day matching
+	// either fires, as in crontab
+	domAny, dowAny bool
+}
+
+// field is the range of one of the five fields
+type field struct {
+	name     string
+	min, max int
+	names    []string // names for min, min+1, ...
+}
+
+var fields = []field{
+	{"minute", 0, 59, nil},
+	{"hour", 0, 23, nil},
+	{"day of month", 1, 31, nil},
+	{"month", 1, 12, []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{"day of week", 0, 7, []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+var macros = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+}
+
+// Parse reads "minute hour day-of-month month day-of-week", each a *, a
+// number, a range (1-5), a list (1,15) or a step (*/15, 0-30/10); months
+// and weekdays may be named (jan, mon). @daily, @weekly and the other
+// crontab shorthands are accepted too.
+func Parse(expr string) (*Schedule, error) {
+	text := strings.TrimSpace(expr)
+	if macro, ok := macros[strings.ToLower(text)]; ok {
+		text = macro
+	}
+	parts := strings.Fields(text)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("invalid schedule %q: want 5 fields (minute hour day month weekday), e.g. \"0 4 * * *\"", expr)
+	}
+
+	s := &Schedule{expr: expr}
+	sets := []*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow}
+	for i, part := range parts {
+		bits, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", expr, err)
+		}
+		*sets[i] = bits
+	}
+	// Sunday is 0 and 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = strings.HasPrefix(parts[2], "*")
+	s.dowAny = strings.HasPrefix(parts[4], "*")
+	if s.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("invalid schedule %q: it never fires", expr)
+	}
+	return s, nil
+}
+
+func parseField(text string, f field) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(text, ",") {
+		span, stepText, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s: invalid step %q", f.name, stepText)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		if span != "*" {
+			first, last, isRange := strings.Cut(span, "-")
+			var err error
+			if lo, err = f.value(first); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = f.value(last); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				// "5/15" counts from 5 to the end, as in cronie
+				hi = f.max
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("%s: range %q runs backwards", f.name, span)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// value reads one number or name of a field
+func (f field) value(text string) (int, error) {
+	name := strings.ToLower(text)
+	for i, n := range f.names {
+		if name == n {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(text)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s: %q is not between %d and %d", f.name, text, f.min, f.max)
+	}
+	return v, nil
+}
+
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Next returns the first time after t the schedule fires, or the zero time
+// if it never does (such as "0 0 31 2 *")
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,181 @@
+package curseforge
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CacheEnv overrides the download cache directory; "off" turns it off
+const CacheEnv = "MCSERVER_CACHE_DIR"
+
+// A lock older than this was left by a manager that died mid-download
+const cacheLockStale = time.Hour
+
+const lockSuffix = ".lock"
+
+// CacheDir is where CurseForge downloads are kept for every server on the
+// host to reuse: $MCSERVER_CACHE_DIR, or mcserver in the user's cache
+// directory (~/.cache/mcserver on Linux). It returns "" when the cache is
+// off.
+func CacheDir() (string, error) {
+	dir := os.Getenv(CacheEnv)
+	switch dir {
+	case "off":
+		return "", nil
+	case "":
+		base, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("no cache directory (set %s): %w", CacheEnv, err)
+		}
+		dir = filepath.Join(base, "mcserver")
+	}
+	return filepath.Abs(dir)
+}
+
+// cacheEntry locks the cache path of a file, keyed by its CurseForge file
+// ID, which never changes content. ok is false when there is no cache or
+// another manager is downloading the same file.
+func cacheEntry(file *ModpackFile) (path string, unlock func(), ok bool) {
+	dir, err := CacheDir()
+	if err != nil || dir == "" || file.ID == 0 || file.FileName == "" {
+		return "", nil, false
+	}
+	path = filepath.Join(dir, "curseforge", strconv.Itoa(file.ID), filepath.Base(file.FileName))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", nil, false
+	}
+
+	lock := path + lockSuffix
+	for try := 0; try < 2; try++ {
+		f, err := os.OpenFile(lock, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return path, func() { os.Remove(lock) }, true
+		}
+		info, statErr := os.Stat(lock)
+		if !os.IsExist(err) || statErr != nil || time.Since(info.ModTime()) < cacheLockStale {
+			return "", nil, false
+		}
+		os.Remove(lock)
+	}
+	return "", nil, false
+}
+
+// placeFile puts a cached file at dest, as a hard link when the cache is on
+// the same filesystem and as a copy otherwise
+func placeFile(cached, dest string) error {
+	os.Remove(dest)
+	if err := os.Link(cached, dest); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(cached)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp := dest + partialSuffix
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
+
+// CacheFile is one file in the download cache
+type CacheFile struct {
+	Path     string
+	Size     int64
+	LastUsed time.Time
+}
+
+// CacheFiles lists the downloads in the cache, least recently used first,
+// with unfinished ones among them
+func CacheFiles() ([]CacheFile, error) {
+	dir, err := CacheDir()
+	if err != nil || dir == "" {
+		return nil, err
+	}
+	var files []CacheFile
+	root := filepath.Join(dir, "curseforge")
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) && path == root {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || strings.HasSuffix(path, lockSuffix) {
+			return nil
+		}
+		files = append(files, CacheFile{Path: path, Size: info.Size(), LastUsed: info.ModTime()})
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool { return files[i].LastUsed.Before(files[j].LastUsed) })
+	return files, err
+}
+
+// PruneResult is what PruneCache removed and kept
+type PruneResult struct {
+	Removed     int
+	Freed       int64
+	Kept        int
+	KeptBytes   int64
+	RemoveFails int
+}
+
+// PruneCache removes downloads not used for maxAge (0 keeps them all), then
+// the least recently used ones until the cache holds at most maxSize bytes
+// (0 for no limit). Files being downloaded are left alone.
+func PruneCache(maxAge time.Duration, maxSize int64) (*PruneResult, error) {
+	files, err := CacheFiles()
+	if err != nil {
+		return nil, err
+	}
+	var total int64
+	for _, f := range files {
+		total += f.Size
+	}
+
+	result := &PruneResult{}
+	for _, f := range files {
+		old := maxAge > 0 && time.Since(f.LastUsed) > maxAge
+		over := maxSize > 0 && total > maxSize
+		busy := false
+		if _, err := os.Stat(strings.TrimSuffix(f.Path, partialSuffix) + lockSuffix); err == nil {
+			busy = true
+		}
+		if (!old && !over) || busy {
+			result.Kept++
+			result.KeptBytes += f.Size
+			continue
+		}
+		if err := os.Remove(f.Path); err != nil {
+			result.RemoveFails++
+			result.Kept++
+			result.KeptBytes += f.Size
+			continue
+		}
+		os.Remove(filepath.Dir(f.Path))
+		total -= f.Size
+		result.Removed++
+		result.Freed += f.Size
+	}
+	return result, nil
+}
@@ -1,7 +1,6 @@
 package curseforge
 
 import (
-	"archive/zip"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -28,6 +27,10 @@ const (
 type Client struct {
 	httpClient *http.Client
 	apiKey     string
+
+	// Progress, if set, is told how the mod downloads of ApplyUpgrade are
+	// going, from any goroutine
+	Progress func(DownloadProgress)
 }
 
 // Modpack represents a CurseForge modpack
@@ -42,40 +45,56 @@ type Modpack struct {
 
 // ModpackFile represents a specific version of a modpack
 type ModpackFile struct {
-	ID           int    `json:"id"`
-	DisplayName  string `json:"displayName"`
-	FileName     string `json:"fileName"`
-	DownloadURL  string `json:"downloadUrl"`
-	FileLength   int64  `json:"fileLength"`
-	ServerPackID int    `json:"serverPackFileId"`
+	ID           int        `json:"id"`
+	DisplayName  string     `json:"displayName"`
+	FileName     string     `json:"fileName"`
+	DownloadURL  string     `json:"downloadUrl"`
+	FileLength   int64      `json:"fileLength"`
+	ServerPackID int        `json:"serverPackFileId"`
+	Hashes       []FileHash `json:"hashes"`
+}
+
+// FileHash is one of the hashes CurseForge lists for a file
+type FileHash struct {
+	Value string `json:"value"`
+	Algo  int    `json:"algo"` // 1 SHA-1, 2 MD5
 }
 
 // ModpackManifest is the manifest.json inside a modpack
 type ModpackManifest struct {
-	Minecraft struct {
-		Version    string `json:"version"`
-		ModLoaders []struct {
-			ID      string `json:"id"`
-			Primary bool   `json:"primary"`
-		} `json:"modLoaders"`
-	} `json:"minecraft"`
-	ManifestType    string `json:"manifestType"`
-	ManifestVersion int    `json:"manifestVersion"`
-	Name            string `json:"name"`
-	Version         string `json:"version"`
-	Author          string `json:"author"`
-	Files           []struct {
-		ProjectID int  `json:"projectID"`
-		FileID    int  `json:"fileID"`
-		Required  bool `json:"required"`
-	} `json:"files"`
-	Overrides string `json:"overrides"`
+	Minecraft       ManifestMinecraft `json:"minecraft"`
+	ManifestType    string            `json:"manifestType"`
+	ManifestVersion int               `json:"manifestVersion"`
+	Name            string            `json:"name"`
+	Version         string            `json:"version"`
+	Author          string            `json:"author"`
+	Files           []ManifestFile    `json:"files"`
+	Overrides       string            `json:"overrides"`
+}
+
+// ManifestMinecraft holds the game version and mod loaders of a manifest
+type ManifestMinecraft struct {
+	Version    string              `json:"version"`
+	ModLoaders []ManifestModLoader `json:"modLoaders"`
+}
+
+// ManifestModLoader is a mod loader entry in a manifest
+type ManifestModLoader struct {
+	ID      string `json:"id"`
+	Primary bool   `json:"primary"`
+}
+
+// ManifestFile is a mod reference (project and file ID) in a manifest
+type ManifestFile struct {
+	ProjectID int  `json:"projectID"`
+	FileID    int  `json:"fileID"`
+	Required  bool `json:"required"`
 }
 
 // NewClient creates a new CurseForge client
 func NewClient() *Client {
 	return &Client{
-		httpClient: &http.Client{},
+		httpClient: newAPIHTTPClient(),
 		apiKey:     os.Getenv("CURSEFORGE_API_KEY"),
 	}
 }
@@ -83,7 +102,7 @@ func NewClient() *Client {
 // NewClientWithKey creates a new CurseForge client with an API key
 func NewClientWithKey(apiKey string) *Client {
 	return &Client{
-		httpClient: &http.Client{},
+		httpClient: newAPIHTTPClient(),
 		apiKey:     apiKey,
 	}
 }
@@ -204,6 +223,57 @@ func (c *Client) GetModpackFile(projectID, fileID int) (*ModpackFile, error) {
 	return &result.Data, nil
 }
 
+// modLoaderTypes are CurseForge's modLoaderType filter values
+var modLoaderTypes = map[string]int{
+	"forge":    1,
+	"fabric":   4,
+	"quilt":    5,
+	"neoforge": 6,
+}
+
+// LatestModFile returns the newest file of a mod for a loader and Minecraft
+// version
+func (c *Client) LatestModFile(projectID int, loader, mcVersion string) (*ModpackFile, error) {
+	loaderType, ok := modLoaderTypes[loader]
+	if !ok {
+		return nil, fmt.Errorf("unsupported mod loader: %s", loader)
+	}
+	url := fmt.Sprintf("%s/v1/mods/%d/files?gameVersion=%s&modLoaderType=%d&pageSize=1", cfAPIBase, projectID, mcVersion, loaderType)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.apiKey != "" {
+		req.Header.Set("x-api-key", c.apiKey)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mod files: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CurseForge API returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Data []ModpackFile `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if len(result.Data) == 0 {
+		return nil, fmt.Errorf("no %s file of mod %d for Minecraft %s", loader, projectID, mcVersion)
+	}
+	return &result.Data[0], nil
+}
+
 // GetLatestServerPack gets the latest server pack for a modpack
 func (c *Client) GetLatestServerPack(projectID int) (*ModpackFile, error) {
 	url := fmt.Sprintf("%s/v1/mods/%d/files?gameVersionTypeId=0", cfAPIBase, projectID)
@@ -274,188 +344,40 @@ func (c *Client) DownloadModpack(modpackQuery, version, destDir string) (string,
 		return "", fmt.Errorf("failed to get modpack file: %w", err)
 	}
 
-	// Determine download URL
-	downloadURL := file.DownloadURL
-	if downloadURL == "" {
-		// Construct CDN URL from file ID
-		idStr := strconv.Itoa(file.ID)
-		part1 := idStr[:4]
-		part2 := strings.TrimLeft(idStr[4:], "0")
-		if part2 == "" {
-			part2 = "0"
-		}
-		downloadURL = fmt.Sprintf("%s/%s/%s/%s", cfCDNBase, part1, part2, file.FileName)
-	}
-
 	// Create destination directory
 	if err := os.MkdirAll(destDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create destination directory: %w", err)
 	}
 
-	// Download the file
-	destPath := filepath.Join(destDir, file.FileName)
-
-	resp, err := http.Get(downloadURL)
-	if err != nil {
+	// Download the file, resuming an earlier partial download
+	if err := c.downloadFile(file, destDir, nil); err != nil {
 		return "", fmt.Errorf("failed to download modpack: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("download returned status %d", resp.StatusCode)
-	}
-
-	out, err := os.Create(destPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to create file: %w", err)
-	}
-	defer out.Close()
-
-	_, err = io.Copy(out, resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to write file: %w", err)
-	}
-
-	return destPath, nil
+	return filepath.Join(destDir, file.FileName), nil
 }
 
-// InstallModpack extracts and installs a modpack
+// InstallModpack extracts and installs a modpack.
+//
+// Everything is extracted and downloaded into a staging directory first and
+// only merged into destDir once complete, so a failure leaves the live server
+// untouched. Files replaced by the merge are kept for RollbackModpack. An
+// install over an earlier one is an upgrade, see PlanUpgrade.
 func (c *Client) InstallModpack(modpackPath, destDir string) error {
-	// Open the zip file
-	r, err := zip.OpenReader(modpackPath)
+	plan, err := c.PlanUpgrade(modpackPath, destDir)
 	if err != nil {
-		return fmt.Errorf("failed to open modpack: %w", err)
-	}
-	defer r.Close()
-
-	var manifest *ModpackManifest
-
-	// First pass: find and parse manifest
-	for _, f := range r.File {
-		if f.Name == "manifest.json" {
-			rc, err := f.Open()
-			if err != nil {
-				return fmt.Errorf("failed to open manifest: %w", err)
-			}
-
-			manifest = &ModpackManifest{}
-			err = json.NewDecoder(rc).Decode(manifest)
-			rc.Close()
-
-			if err != nil {
-				return fmt.Errorf("failed to parse manifest: %w", err)
-			}
-			break
-		}
-	}
-
-	// Extract all files
-	for _, f := range r.File {
-		destPath := filepath.Join(destDir, f.Name)
-
-		// Handle overrides directory specially
-		if manifest != nil && manifest.Overrides != "" {
-			if strings.HasPrefix(f.Name, manifest.Overrides+"/") {
-				destPath = filepath.Join(destDir, strings.TrimPrefix(f.Name, manifest.Overrides+"/"))
-			}
-		}
-
-		if f.FileInfo().IsDir() {
-			os.MkdirAll(destPath, 0755)
-			continue
-		}
-
-		// Create parent directories
-		if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
-			return fmt.Errorf("failed to create directory: %w", err)
-		}
-
-		// Extract file
-		rc, err := f.Open()
-		if err != nil {
-			return fmt.Errorf("failed to open file in archive: %w", err)
-		}
-
-		outFile, err := os.Create(destPath)
-		if err != nil {
-			rc.Close()
-			return fmt.Errorf("failed to create file: %w", err)
-		}
-
-		_, err = io.Copy(outFile, rc)
-		outFile.Close()
-		rc.Close()
-
-		if err != nil {
-			return fmt.Errorf("failed to extract file: %w", err)
-		}
-	}
-
-	// Download mods if manifest exists
-	if manifest != nil {
-		modsDir := filepath.Join(destDir, "mods")
-		os.MkdirAll(modsDir, 0755)
-
-		for _, mod := range manifest.Files {
-			if err := c.downloadMod(mod.ProjectID, mod.FileID, modsDir); err != nil {
-				// Log error but continue
-				fmt.Printf("Warning: failed to download mod %d: %v\n", mod.ProjectID, err)
-			}
-		}
-
-		// Install mod loader if specified
-		if len(manifest.Minecraft.ModLoaders) > 0 {
-			for _, loader := range manifest.Minecraft.ModLoaders {
-				if loader.Primary {
-					if err := c.installModLoader(loader.ID, manifest.Minecraft.Version, destDir); err != nil {
-						fmt.Printf("Warning: failed to install mod loader %s: %v\n", loader.ID, err)
-					}
-					break
-				}
-			}
-		}
+		return err
 	}
-
-	return nil
+	return c.ApplyUpgrade(plan)
 }
 
-// downloadMod downloads a specific mod
-func (c *Client) downloadMod(projectID, fileID int, destDir string) error {
+// DownloadMod downloads a specific mod file into destDir
+func (c *Client) DownloadMod(projectID, fileID int, destDir string) error {
 	file, err := c.GetModpackFile(projectID, fileID)
 	if err != nil {
 		return err
 	}
-
-	downloadURL := file.DownloadURL
-	if downloadURL == "" {
-		idStr := strconv.Itoa(file.ID)
-		part1 := idStr[:4]
-		part2 := strings.TrimLeft(idStr[4:], "0")
-		if part2 == "" {
-			part2 = "0"
-		}
-		downloadURL = fmt.Sprintf("%s/%s/%s/%s", cfCDNBase, part1, part2, file.FileName)
-	}
-
-	resp, err := http.Get(downloadURL)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("download returned status %d", resp.StatusCode)
-	}
-
-	destPath := filepath.Join(destDir, file.FileName)
-	out, err := os.Create(destPath)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	_, err = io.Copy(out, resp.Body)
-	return err
+	return c.downloadFile(file, destDir, nil)
 }
 
 // installModLoader installs Forge or Fabric
@@ -514,8 +436,8 @@ func (c *Client) installForge(mcVersion, forgeVersion, destDir string) error {
 
 	// Note: Running the installer requires Java, which would need to be done separately
 	// For now, we just download the installer
-	fmt.Printf("Forge installer downloaded to: %s\n", installerPath)
-	fmt.Printf("Run: java -jar %s --installServer\n", installerPath)
+	fmt.Printf("Forge installer downloaded: %s\n", filepath.Base(installerPath))
+	fmt.Printf("Run in the server directory: java -jar %s --installServer\n", filepath.Base(installerPath))
 
 	return nil
 }
@@ -582,8 +504,8 @@ func (c *Client) installNeoForge(mcVersion, neoVersion, destDir string) error {
 		return err
 	}
 
-	fmt.Printf("NeoForge installer downloaded to: %s\n", installerPath)
-	fmt.Printf("Run: java -jar %s --installServer\n", installerPath)
+	fmt.Printf("NeoForge installer downloaded: %s\n", filepath.Base(installerPath))
+	fmt.Printf("Run in the server directory: java -jar %s --installServer\n", filepath.Base(installerPath))
 
 	return nil
 }
@@ -0,0 +1,425 @@
+package curseforge
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// Mods downloaded at once
+	downloadWorkers = 6
+
+	// Tries per file, and the wait before the first retry, doubled after
+	// each one
+	downloadAttempts = 4
+	downloadBackoff  = time.Second
+
+	// How often Progress hears about bytes between finished files
+	progressInterval = 250 * time.Millisecond
+
+	// Suffix of a download until it is verified
+	partialSuffix = ".partial"
+
+	// How long a download may wait for the response headers, and for the
+	// next bytes of the body, before the try counts as failed
+	downloadHeaderTimeout = 30 * time.Second
+	downloadStallTimeout  = time.Minute
+)
+
+// downloadClient fetches mod files. Files can be large, so it has no
+// overall timeout; fetchFile gives up on stalled bodies instead.
+var downloadClient = &http.Client{Transport: downloadTransport()}
+
+func downloadTransport() http.RoundTripper {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.ResponseHeaderTimeout = downloadHeaderTimeout
+	return t
+}
+
+// DownloadProgress is how far the mod downloads of an install are
+type DownloadProgress struct {
+	Done   int // files finished, including failed ones
+	Failed int
+	Total  int
+
+	Bytes      int64
+	TotalBytes int64 // from CurseForge's file sizes, 0 if it has none
+
+	// Time left at the rate so far, 0 until there is a rate
+	ETA time.Duration
+}
+
+// Percent is how much of the download is done, by bytes when the sizes are
+// known
+func (p DownloadProgress) Percent() float64 {
+	if p.TotalBytes > 0 {
+		return min(float64(p.Bytes)/float64(p.TotalBytes)*100, 100)
+	}
+	if p.Total > 0 {
+		return float64(p.Done) / float64(p.Total) * 100
+	}
+	return 100
+}
+
+// retryableError is a download failure worth trying again
+type retryableError struct{ err error }
+
+func (e retryableError) Error() string { return e.err.Error() }
+func (e retryableError) Unwrap() error { return e.err }
+
+// fileDownloadURL is where a file can be downloaded, built from its ID on
+// the CDN when the API leaves the URL out (mods that opted out of
+// third-party downloads)
+func fileDownloadURL(file *ModpackFile) string {
+	if file.DownloadURL != "" {
+		return file.DownloadURL
+	}
+	idStr := strconv.Itoa(file.ID)
+	part1 := idStr[:4]
+	part2 := strings.TrimLeft(idStr[4:], "0")
+	if part2 == "" {
+		part2 = "0"
+	}
+	return fmt.Sprintf("%s/%s/%s/%s", cfCDNBase, part1, part2, file.FileName)
+}
+
+// downloadFile puts a file looked up with GetModpackFile in destDir, from
+// the download cache if it has a copy that still verifies, otherwise
+// downloading it into the cache first. onBytes, if set, is told the bytes
+// as they arrive, or all at once for a cached file.
+func (c *Client) downloadFile(file *ModpackFile, destDir string, onBytes func(int64)) error {
+	destPath := filepath.Join(destDir, file.FileName)
+	cached, unlock, ok := cacheEntry(file)
+	if !ok {
+		return fetchVerified(file, destPath, onBytes)
+	}
+	defer unlock()
+
+	if info, err := os.Stat(cached); err == nil && verifyFile(file, cached) == nil {
+		now := time.Now()
+		os.Chtimes(cached, now, now)
+		if onBytes != nil {
+			onBytes(info.Size())
+		}
+		return placeFile(cached, destPath)
+	}
+	if err := fetchVerified(file, cached, onBytes); err != nil {
+		return err
+	}
+	return placeFile(cached, destPath)
+}
+
+// fetchVerified downloads a file to destPath, retrying network errors and
+// server errors with backoff. The file is written as <name>.partial,
+// resumed from where a failed try stopped, and only renamed into place
+// once its length and hash match what CurseForge lists. onBytes, if set,
+// is told each chunk written, and told to take back bytes a try had to
+// throw away.
+func fetchVerified(file *ModpackFile, destPath string, onBytes func(int64)) error {
+	partial := destPath + partialSuffix
+
+	// Bytes of this file onBytes has been told about, kept equal to the
+	// size of the partial file between tries
+	var counted int64
+	recount := func() {
+		if onBytes == nil {
+			return
+		}
+		var size int64
+		if info, err := os.Stat(partial); err == nil {
+			size = info.Size()
+		}
+		if size != counted {
+			onBytes(size - counted)
+			counted = size
+		}
+	}
+	count := func(n int64) {
+		counted += n
+		if onBytes != nil {
+			onBytes(n)
+		}
+	}
+
+	wait := downloadBackoff
+	var err error
+	for attempt := 1; attempt <= downloadAttempts; attempt++ {
+		recount()
+		if err = fetchFile(fileDownloadURL(file), partial, count); err == nil {
+			err = verifyFile(file, partial)
+		}
+		if err == nil {
+			return os.Rename(partial, destPath)
+		}
+		if _, ok := err.(retryableError); !ok {
+			os.Remove(partial)
+			recount()
+			return err
+		}
+		if attempt < downloadAttempts {
+			time.Sleep(wait)
+			wait *= 2
+		}
+	}
+	// What is left of the partial file is kept for the next download to
+	// resume
+	recount()
+	return err
+}
+
+// fetchFile downloads url into partial, continuing the file with a Range
+// request if it already has some of it. A connection that goes quiet for
+// downloadStallTimeout is cut, which leaves the partial file to resume.
+func fetchFile(url, partial string, count func(int64)) error {
+	var offset int64
+	if info, err := os.Stat(partial); err == nil {
+		offset = info.Size()
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	stalled := fmt.Errorf("download stalled for %s", downloadStallTimeout)
+	timer := time.AfterFunc(downloadStallTimeout, func() { cancel(stalled) })
+	defer timer.Stop()
+	stallErr := func(err error) error {
+		if context.Cause(ctx) == stalled {
+			return stalled
+		}
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return retryableError{stallErr(err)}
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)):
+		flags = os.O_WRONLY | os.O_APPEND
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// Already complete, or longer than the file; verifying tells
+		return nil
+	case resp.StatusCode == http.StatusOK:
+		// The server ignored the range and sent the whole file
+		if offset > 0 {
+			count(-offset)
+		}
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return retryableError{fmt.Errorf("download returned status %d", resp.StatusCode)}
+	default:
+		return fmt.Errorf("download returned status %d", resp.StatusCode)
+	}
+
+	out, err := os.OpenFile(partial, flags, 0644)
+	if err != nil {
+		return err
+	}
+	progress := func(n int64) {
+		timer.Reset(downloadStallTimeout)
+		count(n)
+	}
+	_, err = io.Copy(out, &countingReader{r: resp.Body, add: progress})
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return retryableError{stallErr(err)}
+	}
+	return nil
+}
+
+// verifyFile checks a download against the length and hash CurseForge
+// lists for it. A mismatch throws the file away, so the next try starts
+// over.
+func verifyFile(file *ModpackFile, partial string) error {
+	mismatch := func(format string, args ...any) error {
+		os.Remove(partial)
+		return retryableError{fmt.Errorf("%s: "+format, append([]any{file.FileName}, args...)...)}
+	}
+
+	info, err := os.Stat(partial)
+	if err != nil {
+		return err
+	}
+	if file.FileLength > 0 && info.Size() != file.FileLength {
+		return mismatch("downloaded %d bytes, expected %d", info.Size(), file.FileLength)
+	}
+
+	algo, want := file.hash()
+	if want == "" {
+		return nil
+	}
+	got, err := hashFile(partial, algo)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(got, want) {
+		return mismatch("%s is %s, expected %s", hashNames[algo], got, want)
+	}
+	return nil
+}
+
+// CurseForge's hash algorithms
+const (
+	hashSHA1 = 1
+	hashMD5  = 2
+)
+
+var hashNames = map[int]string{hashSHA1: "SHA-1", hashMD5: "MD5"}
+
+// hash picks the strongest hash CurseForge lists for the file, returning
+// an empty value if it lists none
+func (f *ModpackFile) hash() (int, string) {
+	for _, algo := range []int{hashSHA1, hashMD5} {
+		for _, h := range f.Hashes {
+			if h.Algo == algo && h.Value != "" {
+				return algo, h.Value
+			}
+		}
+	}
+	return 0, ""
+}
+
+func hashFile(path string, algo int) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	var h hash.Hash = sha1.New()
+	if algo == hashMD5 {
+		h = md5.New()
+	}
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// countingReader reports the bytes read through it
+type countingReader struct {
+	r   io.Reader
+	add func(int64)
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if n > 0 {
+		c.add(int64(n))
+	}
+	return n, err
+}
+
+// downloadMods downloads files into destDir downloadWorkers at a time,
+// telling c.Progress how it goes. With lenient, failures are printed as
+// warnings and the rest carry on; otherwise the first failure stops the
+// downloads not yet started and is returned.
+func (c *Client) downloadMods(files []*ModpackFile, destDir string, lenient bool) error {
+	if len(files) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return err
+	}
+
+	var (
+		mu       sync.Mutex
+		progress = DownloadProgress{Total: len(files)}
+		bytes    atomic.Int64
+		started  = time.Now()
+		reported time.Time
+		firstErr error
+		failed   atomic.Bool
+	)
+	for _, file := range files {
+		progress.TotalBytes += file.FileLength
+	}
+
+	// report tells Progress the current state; between finished files, at
+	// most every progressInterval
+	report := func(force bool) {
+		if c.Progress == nil {
+			return
+		}
+		mu.Lock()
+		if !force && time.Since(reported) < progressInterval {
+			mu.Unlock()
+			return
+		}
+		reported = time.Now()
+		p := progress
+		mu.Unlock()
+
+		p.Bytes = bytes.Load()
+		elapsed := time.Since(started)
+		switch {
+		case p.TotalBytes > 0 && p.Bytes > 0:
+			p.ETA = time.Duration(float64(elapsed) / float64(p.Bytes) * float64(max(p.TotalBytes-p.Bytes, 0)))
+		case p.TotalBytes == 0 && p.Done > 0:
+			p.ETA = elapsed / time.Duration(p.Done) * time.Duration(p.Total-p.Done)
+		}
+		c.Progress(p)
+	}
+	onBytes := func(n int64) {
+		bytes.Add(n)
+		report(false)
+	}
+
+	jobs := make(chan *ModpackFile)
+	var wg sync.WaitGroup
+	for i := 0; i < min(downloadWorkers, len(files)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range jobs {
+				err := c.downloadFile(file, destDir, onBytes)
+				mu.Lock()
+				progress.Done++
+				if err != nil {
+					progress.Failed++
+					if lenient {
+						fmt.Printf("Warning: failed to download mod %s: %v\n", file.FileName, err)
+					} else if firstErr == nil {
+						firstErr = fmt.Errorf("failed to download %s: %w", file.FileName, err)
+						failed.Store(true)
+					}
+				}
+				mu.Unlock()
+				report(true)
+			}
+		}()
+	}
+
+	report(true)
+	for _, file := range files {
+		if failed.Load() {
+			break
+		}
+		jobs <- file
+	}
+	close(jobs)
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,387 @@
+package curseforge
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ExportOptions controls how a server directory is exported as a modpack
+type ExportOptions struct {
+	Name      string
+	Version   string
+	Author    string
+	MCVersion string
+	ModLoader string // e.g. "forge-47.2.0"
+
+	// Extra paths (relative to the server directory) to add to overrides
+	Include []string
+}
+
+// ExportResult summarizes an export
+type ExportResult struct {
+	OutputPath   string
+	Referenced   int // mods referenced by CurseForge project/file ID
+	Bundled      int // jars copied into overrides/mods
+	OverrideDirs []string
+}
+
+// overrideDirs are the configuration folders copied into overrides/
+var overrideDirs = []string{
+	"config",
+	"defaultconfigs",
+	"kubejs",
+	"scripts",
+	"resourcepacks",
+}
+
+// FingerprintMatch is a CurseForge file identified by its fingerprint
+type FingerprintMatch struct {
+	ProjectID int
+	FileID    int
+	FileName  string
+}
+
+// Fingerprint computes the CurseForge fingerprint of a file: a 32-bit
+// MurmurHash2 (seed 1) of the contents with whitespace bytes removed
+func Fingerprint(path string) (uint32, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	normalized := make([]byte, 0, len(data))
+	for _, b := range data {
+		if b == 9 || b == 10 || b == 13 || b == 32 {
+			continue
+		}
+		normalized = append(normalized, b)
+	}
+
+	return murmur2(normalized, 1), nil
+}
+
+func murmur2(data []byte, seed uint32) uint32 {
+	const m = 0x5bd1e995
+	const r = 24
+
+	h := seed ^ uint32(len(data))
+
+	i := 0
+	for ; len(data)-i >= 4; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+
+	switch len(data) - i {
+	case 3:
+		h ^= uint32(data[i+2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(data[i+1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(data[i])
+		h *= m
+	}
+
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+
+	return h
+}
+
+// MatchFingerprints looks up files on CurseForge by fingerprint
+func (c *Client) MatchFingerprints(fingerprints []uint32) (map[uint32]FingerprintMatch, error) {
+	body, err := json.Marshal(map[string][]uint32{"fingerprints": fingerprints})
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/v1/fingerprints/%d", cfAPIBase, minecraftGameID)
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if c.apiKey != "" {
+		req.Header.Set("x-api-key", c.apiKey)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match fingerprints: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CurseForge API returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Data struct {
+			ExactMatches []struct {
+				ID   int `json:"id"`
+				File struct {
+					ID          int    `json:"id"`
+					ModID       int    `json:"modId"`
+					FileName    string `json:"fileName"`
+					Fingerprint uint32 `json:"fileFingerprint"`
+				} `json:"file"`
+			} `json:"exactMatches"`
+		} `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	matches := make(map[uint32]FingerprintMatch)
+	for _, m := range result.Data.ExactMatches {
+		matches[m.File.Fingerprint] = FingerprintMatch{
+			ProjectID: m.File.ModID,
+			FileID:    m.File.ID,
+			FileName:  m.File.FileName,
+		}
+	}
+
+	return matches, nil
+}
+
+// ExportModpack packages a server directory as a CurseForge-style modpack zip.
+// Mods that can be identified on CurseForge are referenced in manifest.json;
+// everything else is bundled under overrides/.
+func (c *Client) ExportModpack(serverDir, outPath string, opts ExportOptions) (*ExportResult, error) {
+	manifest := &ModpackManifest{
+		ManifestType:    "minecraftModpack",
+		ManifestVersion: 1,
+		Name:            opts.Name,
+		Version:         opts.Version,
+		Author:          opts.Author,
+		Files:           []ManifestFile{},
+		Overrides:       "overrides",
+	}
+
+	// Start from the manifest of the installed pack, if there is one
+	if existing, err := readManifest(filepath.Join(serverDir, "manifest.json")); err == nil {
+		manifest.Minecraft = existing.Minecraft
+		if manifest.Name == "" {
+			manifest.Name = existing.Name
+		}
+		if manifest.Version == "" {
+			manifest.Version = existing.Version
+		}
+		if manifest.Author == "" {
+			manifest.Author = existing.Author
+		}
+	}
+
+	if opts.MCVersion != "" {
+		manifest.Minecraft.Version = opts.MCVersion
+	}
+	if opts.ModLoader != "" {
+		manifest.Minecraft.ModLoaders = []ManifestModLoader{{ID: opts.ModLoader, Primary: true}}
+	}
+	if manifest.Minecraft.Version == "" {
+		return nil, fmt.Errorf("minecraft version unknown: no manifest.json in %s, specify it explicitly", serverDir)
+	}
+	if manifest.Name == "" {
+		manifest.Name = filepath.Base(serverDir)
+	}
+	if manifest.Version == "" {
+		manifest.Version = "1.0.0"
+	}
+
+	// Fingerprint installed mods
+	modsDir := filepath.Join(serverDir, "mods")
+	jars, err := filepath.Glob(filepath.Join(modsDir, "*.jar"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mods: %w", err)
+	}
+	sort.Strings(jars)
+
+	fingerprints := make(map[string]uint32, len(jars))
+	var fpList []uint32
+	for _, jar := range jars {
+		fp, err := Fingerprint(jar)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fingerprint %s: %w", filepath.Base(jar), err)
+		}
+		fingerprints[jar] = fp
+		fpList = append(fpList, fp)
+	}
+
+	matches := map[uint32]FingerprintMatch{}
+	if len(fpList) > 0 {
+		if matches, err = c.MatchFingerprints(fpList); err != nil {
+			// Not fatal: unidentified jars are bundled instead
+			fmt.Printf("Warning: could not identify mods on CurseForge: %v\n", err)
+			matches = map[uint32]FingerprintMatch{}
+		}
+	}
+
+	// Create the output archive
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	// Written next to outPath and renamed into place once complete, so a
+	// failed export never leaves a truncated pack or clobbers an old one
+	tmpPath := outPath + partialSuffix
+	outFile, err := os.Create(tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export file: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			outFile.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	// Closed once, below: its error decides whether the pack is renamed
+	// into place, and on failure the partial file is removed unfinished
+	zipWriter := zip.NewWriter(outFile)
+
+	result := &ExportResult{OutputPath: outPath}
+
+	for _, jar := range jars {
+		if match, ok := matches[fingerprints[jar]]; ok {
+			manifest.Files = append(manifest.Files, ManifestFile{
+				ProjectID: match.ProjectID,
+				FileID:    match.FileID,
+				Required:  true,
+			})
+			result.Referenced++
+			continue
+		}
+
+		if err := addFileToZip(zipWriter, jar, "overrides/mods/"+filepath.Base(jar)); err != nil {
+			return nil, fmt.Errorf("failed to add %s: %w", filepath.Base(jar), err)
+		}
+		result.Bundled++
+	}
+
+	// Copy config folders and extra includes into overrides
+	includes := append(append([]string{}, overrideDirs...), opts.Include...)
+	for _, rel := range includes {
+		src := filepath.Join(serverDir, rel)
+		info, err := os.Stat(src)
+		if err != nil {
+			continue
+		}
+
+		if info.IsDir() {
+			err = addTreeToZip(zipWriter, src, "overrides/"+filepath.ToSlash(rel))
+		} else {
+			err = addFileToZip(zipWriter, src, "overrides/"+filepath.ToSlash(rel))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to add %s: %w", rel, err)
+		}
+		result.OverrideDirs = append(result.OverrideDirs, rel)
+	}
+
+	// Write the manifest last so it reflects identified mods
+	w, err := zipWriter.Create("manifest.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize export: %w", err)
+	}
+	if err := outFile.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize export: %w", err)
+	}
+	if err := os.Rename(tmpPath, outPath); err != nil {
+		return nil, fmt.Errorf("failed to finalize export: %w", err)
+	}
+	committed = true
+
+	return result, nil
+}
+
+// readManifest parses a manifest.json file
+func readManifest(path string) (*ModpackManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &ModpackManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// addTreeToZip adds a directory tree to a zip archive under prefix
+func addTreeToZip(zipWriter *zip.Writer, source, prefix string) error {
+	return filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+
+		return addFileToZip(zipWriter, path, prefix+"/"+filepath.ToSlash(relPath))
+	})
+}
+
+// addFileToZip adds a single file to a zip archive
+func addFileToZip(zipWriter *zip.Writer, path, name string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = strings.TrimPrefix(name, "/")
+	header.Method = zip.Deflate
+
+	writer, err := zipWriter.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(writer, file)
+	return err
+}
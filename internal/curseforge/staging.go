@@ -0,0 +1,241 @@
+package curseforge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// managerDir holds manager metadata inside the server directory
+	managerDir = ".mcserver"
+
+	stagingDirName  = "staging"
+	rollbackDirName = "rollback"
+	rollbackFile    = "rollback.json"
+)
+
+// RollbackInfo records what an install changed so it can be undone
+type RollbackInfo struct {
+	CreatedAt time.Time `json:"createdAt"`
+	Added     []string  `json:"added"`
+	Replaced  []string  `json:"replaced"`
+	Removed   []string  `json:"removed,omitempty"`
+}
+
+// safeJoin joins an archive entry name onto base, rejecting entries that
+// would escape it (absolute paths, "..", drive letters)
+func safeJoin(base, name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if name == "" || strings.HasPrefix(name, "/") || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("unsafe path in archive: %q", name)
+	}
+
+	joined := filepath.Join(base, filepath.FromSlash(name))
+	rel, err := filepath.Rel(base, joined)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		return "", fmt.Errorf("unsafe path in archive: %q", name)
+	}
+
+	return joined, nil
+}
+
+// prepareStaging creates an empty staging directory inside destDir. Staging
+// on the same filesystem keeps the final merge a series of renames.
+func prepareStaging(destDir string) (string, error) {
+	stagingDir := filepath.Join(destDir, managerDir, stagingDirName)
+
+	if err := os.RemoveAll(stagingDir); err != nil {
+		return "", fmt.Errorf("failed to clear staging directory: %w", err)
+	}
+	if err := os.MkdirAll(stagingDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	return stagingDir, nil
+}
+
+// commitStaging moves every staged file into destDir and removes the files
+// in remove, relative to destDir. Files it replaces or removes are moved
+// aside into the rollback directory. If any move fails, the changes made
+// so far are undone.
+func commitStaging(stagingDir, destDir string, remove []string) error {
+	var staged []string
+	err := filepath.Walk(stagingDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(stagingDir, path)
+		if err != nil {
+			return err
+		}
+		staged = append(staged, rel)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan staging directory: %w", err)
+	}
+
+	rollbackDir := filepath.Join(destDir, managerDir, rollbackDirName)
+	if err := os.RemoveAll(rollbackDir); err != nil {
+		return fmt.Errorf("failed to clear previous rollback state: %w", err)
+	}
+	savedDir := filepath.Join(rollbackDir, "files")
+
+	info := &RollbackInfo{CreatedAt: time.Now()}
+
+	for _, rel := range staged {
+		src := filepath.Join(stagingDir, rel)
+		dst := filepath.Join(destDir, rel)
+
+		if existing, err := os.Stat(dst); err == nil {
+			if existing.IsDir() {
+				undoCommit(destDir, savedDir, info)
+				return fmt.Errorf("%s is a directory in the server but a file in the modpack", rel)
+			}
+
+			saved := filepath.Join(savedDir, rel)
+			if err := os.MkdirAll(filepath.Dir(saved), 0755); err != nil {
+				undoCommit(destDir, savedDir, info)
+				return err
+			}
+			if err := os.Rename(dst, saved); err != nil {
+				undoCommit(destDir, savedDir, info)
+				return fmt.Errorf("failed to set aside %s: %w", rel, err)
+			}
+			info.Replaced = append(info.Replaced, rel)
+		} else {
+			info.Added = append(info.Added, rel)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			undoCommit(destDir, savedDir, info)
+			return err
+		}
+		if err := os.Rename(src, dst); err != nil {
+			undoCommit(destDir, savedDir, info)
+			return fmt.Errorf("failed to install %s: %w", rel, err)
+		}
+	}
+
+	installed := make(map[string]bool, len(staged))
+	for _, rel := range staged {
+		installed[rel] = true
+	}
+	for _, rel := range remove {
+		dst := filepath.Join(destDir, rel)
+		if installed[rel] {
+			continue
+		}
+		if _, err := os.Stat(dst); err != nil {
+			continue
+		}
+		saved := filepath.Join(savedDir, rel)
+		if err := os.MkdirAll(filepath.Dir(saved), 0755); err != nil {
+			undoCommit(destDir, savedDir, info)
+			return err
+		}
+		if err := os.Rename(dst, saved); err != nil {
+			undoCommit(destDir, savedDir, info)
+			return fmt.Errorf("failed to remove %s: %w", rel, err)
+		}
+		info.Removed = append(info.Removed, rel)
+	}
+
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(rollbackDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(rollbackDir, rollbackFile), data, 0644)
+}
+
+// undoCommit reverts a partially applied commit
+func undoCommit(destDir, savedDir string, info *RollbackInfo) {
+	for _, rel := range info.Added {
+		os.Remove(filepath.Join(destDir, rel))
+	}
+	for _, rel := range info.Replaced {
+		dst := filepath.Join(destDir, rel)
+		os.Remove(dst)
+		os.Rename(filepath.Join(savedDir, rel), dst)
+	}
+	for _, rel := range info.Removed {
+		os.Rename(filepath.Join(savedDir, rel), filepath.Join(destDir, rel))
+	}
+	os.RemoveAll(filepath.Dir(savedDir))
+}
+
+// GetRollbackInfo returns the rollback state of the last install, or nil if
+// there is nothing to roll back
+func GetRollbackInfo(serverDir string) (*RollbackInfo, error) {
+	data, err := os.ReadFile(filepath.Join(serverDir, managerDir, rollbackDirName, rollbackFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	info := &RollbackInfo{}
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, fmt.Errorf("failed to parse rollback state: %w", err)
+	}
+	return info, nil
+}
+
+// RollbackModpack restores the server directory to its state before the
+// last modpack install
+func RollbackModpack(serverDir string) (*RollbackInfo, error) {
+	info, err := GetRollbackInfo(serverDir)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, fmt.Errorf("no modpack install to roll back")
+	}
+
+	rollbackDir := filepath.Join(serverDir, managerDir, rollbackDirName)
+	savedDir := filepath.Join(rollbackDir, "files")
+
+	for _, rel := range info.Added {
+		if err := os.Remove(filepath.Join(serverDir, rel)); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove %s: %w", rel, err)
+		}
+	}
+
+	for _, rel := range info.Replaced {
+		dst := filepath.Join(serverDir, rel)
+		os.Remove(dst)
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(filepath.Join(savedDir, rel), dst); err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", rel, err)
+		}
+	}
+
+	for _, rel := range info.Removed {
+		dst := filepath.Join(serverDir, rel)
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(filepath.Join(savedDir, rel), dst); err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", rel, err)
+		}
+	}
+
+	if err := os.RemoveAll(rollbackDir); err != nil {
+		return nil, fmt.Errorf("failed to clear rollback state: %w", err)
+	}
+
+	return info, nil
+}
@@ -0,0 +1,189 @@
+package curseforge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// TimeoutEnv overrides how long one API request may take, as seconds or a
+// duration like "1m"
+const TimeoutEnv = "CURSEFORGE_TIMEOUT"
+
+const (
+	defaultAPITimeout = 30 * time.Second
+
+	// Time between API requests, shared by every client in the process, so
+	// resolving a pack of hundreds of mods stays under CurseForge's limit
+	apiInterval = 100 * time.Millisecond
+
+	// Tries per request, and the wait before the first retry, doubled after
+	// each one up to apiMaxBackoff
+	apiAttempts   = 5
+	apiBackoff    = time.Second
+	apiMaxBackoff = 30 * time.Second
+)
+
+// apiLimiter spaces out the requests of all clients
+var apiLimiter = &rateLimiter{interval: apiInterval}
+
+// rateLimiter hands out request slots interval apart
+type rateLimiter struct {
+	mu       sync.Mutex
+	next     time.Time
+	interval time.Duration
+}
+
+// wait blocks until the caller's slot comes up
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	slot := l.next
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pause holds back every request until d from now, when CurseForge asked
+// for a break
+func (l *rateLimiter) pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.next) {
+		l.next = until
+	}
+}
+
+// apiTransport rate limits API requests, gives each try its own timeout,
+// and retries network errors, 429 and 5xx responses with backoff
+type apiTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+// newAPIHTTPClient is the HTTP client the API calls go through
+func newAPIHTTPClient() *http.Client {
+	return &http.Client{Transport: &apiTransport{base: http.DefaultTransport, timeout: apiTimeout()}}
+}
+
+// apiTimeout reads TimeoutEnv once, falling back to defaultAPITimeout
+var apiTimeout = sync.OnceValue(func() time.Duration {
+	value := os.Getenv(TimeoutEnv)
+	if value == "" {
+		return defaultAPITimeout
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d
+	}
+	fmt.Printf("Warning: ignoring %s=%q, want seconds or a duration like 1m\n", TimeoutEnv, value)
+	return defaultAPITimeout
+})
+
+func (t *apiTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	wait := apiBackoff
+	for attempt := 1; ; attempt++ {
+		if err := apiLimiter.wait(req.Context()); err != nil {
+			return nil, err
+		}
+
+		try := req
+		if attempt > 1 && req.Body != nil {
+			if req.GetBody == nil {
+				return nil, fmt.Errorf("cannot retry %s %s: the request body cannot be rewound", req.Method, req.URL)
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			try = req.Clone(req.Context())
+			try.Body = body
+		}
+		ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+		resp, err := t.base.RoundTrip(try.WithContext(ctx))
+
+		delay := wait
+		if err != nil {
+			cancel()
+			// The caller giving up is not worth a retry
+			if req.Context().Err() != nil {
+				return nil, err
+			}
+			if attempt == apiAttempts {
+				return nil, fmt.Errorf("%w (after %d tries)", err, attempt)
+			}
+		} else if (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500) || attempt == apiAttempts {
+			// The timeout has to last until the body is read
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		} else {
+			if after, ok := retryAfter(resp); ok {
+				delay = min(after, apiMaxBackoff)
+			}
+			if resp.StatusCode == http.StatusTooManyRequests {
+				apiLimiter.pause(delay)
+			}
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+			cancel()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+		wait = min(wait*2, apiMaxBackoff)
+	}
+}
+
+// retryAfter reads a Retry-After header, in seconds or as a date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// cancelOnClose releases a try's timeout once its body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
@@ -0,0 +1,560 @@
+package curseforge
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"mcserver-manager/internal/world"
+)
+
+// installRecordFile remembers what the last install put in the server, so
+// the next one can tell the pack's files from the owner's edits
+const installRecordFile = "modpack-install.json"
+
+// preservedFiles belong to the server rather than the pack. A pack only
+// provides them for a server that has none yet.
+var preservedFiles = []string{
+	managerDir,
+	"server.properties",
+	"eula.txt",
+	"whitelist.json",
+	"ops.json",
+	"banned-players.json",
+	"banned-ips.json",
+	"usercache.json",
+}
+
+// installRecord is what an install put in the server directory
+type installRecord struct {
+	InstalledAt time.Time        `json:"installedAt"`
+	Pack        string           `json:"pack"`
+	Manifest    *ModpackManifest `json:"manifest,omitempty"`
+	// Jar in mods/ each manifest mod was saved as, by project ID
+	Mods map[int]string `json:"mods,omitempty"`
+	// SHA-256 of each file the pack's archive holds, by slash path, as
+	// the pack shipped it
+	Files map[string]string `json:"files"`
+}
+
+// UpgradePlan is what installing a modpack over the server would change
+type UpgradePlan struct {
+	From string
+	To   string
+	// "old -> new" when the mod loader or Minecraft version changes
+	Loader string
+
+	ModsAdded   []string
+	ModsUpdated []string
+	ModsRemoved []string
+
+	FilesAdded   []string
+	FilesUpdated []string
+	FilesRemoved []string
+	// Edited since the last install, so left as they are even though the
+	// pack has a different version
+	FilesKept []string
+	// World and server files the pack ships that the server already has
+	Preserved []string
+
+	// No record of the last install: config files that differ from the
+	// pack are kept, and only mods dropped from the old manifest are
+	// removed
+	NoRecord bool
+
+	packPath  string
+	serverDir string
+	manifest  *ModpackManifest
+	// Archive entries to write, by server path
+	write map[string]string
+	// Manifest mods to download
+	download []*ModpackFile
+	// Server paths to remove
+	remove        []string
+	installLoader bool
+	record        *installRecord
+	// A first install only warns about mods it cannot get, as installs
+	// always have; an upgrade that cannot get one changes nothing
+	fresh bool
+}
+
+// FirstInstall reports whether the server had no modpack before
+func (p *UpgradePlan) FirstInstall() bool {
+	return p.fresh
+}
+
+// Empty reports whether the upgrade changes nothing
+func (p *UpgradePlan) Empty() bool {
+	return len(p.write) == 0 && len(p.download) == 0 && len(p.remove) == 0 && !p.installLoader
+}
+
+// Summary describes the plan, one line per change
+func (p *UpgradePlan) Summary() []string {
+	var lines []string
+	if p.From != "" && p.From != p.To {
+		lines = append(lines, fmt.Sprintf("Modpack: %s -> %s", p.From, p.To))
+	}
+	if p.Loader != "" {
+		lines = append(lines, "Loader: "+p.Loader)
+	}
+	for _, group := range []struct {
+		label string
+		names []string
+	}{
+		{"Mods added", p.ModsAdded},
+		{"Mods updated", p.ModsUpdated},
+		{"Mods removed", p.ModsRemoved},
+		{"Files added", p.FilesAdded},
+		{"Files updated", p.FilesUpdated},
+		{"Files removed", p.FilesRemoved},
+		{"Edited files kept", p.FilesKept},
+		{"Server files preserved", p.Preserved},
+	} {
+		if len(group.names) > 0 {
+			lines = append(lines, fmt.Sprintf("%s (%d): %s", group.label, len(group.names), strings.Join(group.names, ", ")))
+		}
+	}
+	if p.NoRecord && len(p.FilesKept) > 0 {
+		lines = append(lines, "No record of the installed pack, so every file that differs from the pack was kept")
+	}
+	return lines
+}
+
+// PlanUpgrade compares the modpack at modpackPath with what the last
+// install put in serverDir. Files the owner edited since, the worlds and
+// the server's own files are left alone, and mods dropped from the pack
+// are removed.
+func (c *Client) PlanUpgrade(modpackPath, serverDir string) (*UpgradePlan, error) {
+	r, err := zip.OpenReader(modpackPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open modpack: %w", err)
+	}
+	defer r.Close()
+
+	plan := &UpgradePlan{packPath: modpackPath, serverDir: serverDir, write: map[string]string{}}
+	entries := map[string]*zip.File{}
+	for _, f := range r.File {
+		if f.Name == "manifest.json" {
+			manifest, err := decodeManifest(f)
+			if err != nil {
+				return nil, err
+			}
+			plan.manifest = manifest
+		}
+	}
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		name := f.Name
+		if plan.manifest != nil && plan.manifest.Overrides != "" {
+			name = strings.TrimPrefix(name, plan.manifest.Overrides+"/")
+		}
+		if _, err := safeJoin(serverDir, name); err != nil {
+			return nil, err
+		}
+		entries[strings.ReplaceAll(name, "\\", "/")] = f
+	}
+
+	old, err := readInstallRecord(serverDir)
+	if err != nil {
+		return nil, err
+	}
+	if old == nil {
+		plan.NoRecord = true
+		old = &installRecord{Files: map[string]string{}}
+		// Installs before records still left the manifest behind
+		old.Manifest, _ = readManifest(filepath.Join(serverDir, "manifest.json"))
+		plan.fresh = old.Manifest == nil
+	}
+	plan.record = &installRecord{Pack: filepath.Base(modpackPath), Manifest: plan.manifest, Mods: map[int]string{}, Files: map[string]string{}}
+	plan.From, plan.To = packLabel(old.Manifest, old.Pack), packLabel(plan.manifest, filepath.Base(modpackPath))
+
+	if err := plan.planFiles(entries, old); err != nil {
+		return nil, err
+	}
+	if err := c.planMods(plan, old); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// planFiles sorts the pack's files into added, updated, kept and
+// preserved, and the old pack's files it no longer has into removed
+func (p *UpgradePlan) planFiles(entries map[string]*zip.File, old *installRecord) error {
+	worlds := map[string]bool{}
+	level := world.ActiveName(p.serverDir)
+	for _, name := range []string{level, level + "_nether", level + "_the_end"} {
+		worlds[name] = true
+	}
+	preserved := func(rel string) bool {
+		top, _, _ := strings.Cut(rel, "/")
+		for _, name := range preservedFiles {
+			if top == name {
+				return true
+			}
+		}
+		return worlds[top]
+	}
+	exists := func(rel string) bool {
+		_, err := os.Stat(filepath.Join(p.serverDir, filepath.FromSlash(rel)))
+		return err == nil
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, rel := range names {
+		sum, err := entrySHA256(entries[rel])
+		if err != nil {
+			return fmt.Errorf("failed to read %s from the modpack: %w", rel, err)
+		}
+		p.record.Files[rel] = sum
+
+		top, _, _ := strings.Cut(rel, "/")
+		if preserved(rel) && exists(top) {
+			p.Preserved = append(p.Preserved, rel)
+			continue
+		}
+		live, err := fileSHA256(filepath.Join(p.serverDir, filepath.FromSlash(rel)))
+		switch {
+		case os.IsNotExist(err):
+			p.write[rel] = entries[rel].Name
+			p.added(rel)
+		case err != nil:
+			return err
+		case live == sum:
+		case old.Files[rel] == live:
+			p.write[rel] = entries[rel].Name
+			p.updated(rel)
+		default:
+			p.FilesKept = append(p.FilesKept, rel)
+		}
+	}
+
+	var gone []string
+	for rel, sum := range old.Files {
+		if _, ok := entries[rel]; ok || preserved(rel) {
+			continue
+		}
+		live, err := fileSHA256(filepath.Join(p.serverDir, filepath.FromSlash(rel)))
+		if err != nil {
+			continue
+		}
+		if live != sum {
+			p.FilesKept = append(p.FilesKept, rel)
+			continue
+		}
+		gone = append(gone, rel)
+	}
+	sort.Strings(gone)
+	for _, rel := range gone {
+		p.remove = append(p.remove, rel)
+		if isModJar(rel) {
+			p.ModsRemoved = append(p.ModsRemoved, path.Base(rel))
+		} else {
+			p.FilesRemoved = append(p.FilesRemoved, rel)
+		}
+	}
+	sort.Strings(p.FilesKept)
+	return nil
+}
+
+func (p *UpgradePlan) added(rel string) {
+	if isModJar(rel) {
+		p.ModsAdded = append(p.ModsAdded, path.Base(rel))
+	} else {
+		p.FilesAdded = append(p.FilesAdded, rel)
+	}
+}
+
+func (p *UpgradePlan) updated(rel string) {
+	if isModJar(rel) {
+		p.ModsUpdated = append(p.ModsUpdated, path.Base(rel))
+	} else {
+		p.FilesUpdated = append(p.FilesUpdated, rel)
+	}
+}
+
+// planMods diffs the mods the old and new manifests reference by project
+func (c *Client) planMods(p *UpgradePlan, old *installRecord) error {
+	oldFiles := map[int]int{}
+	if old.Manifest != nil {
+		for _, f := range old.Manifest.Files {
+			oldFiles[f.ProjectID] = f.FileID
+		}
+	}
+	// The jar an old manifest mod was saved as, from the record or else
+	// from CurseForge
+	oldJar := func(projectID int) (string, error) {
+		if name, ok := old.Mods[projectID]; ok {
+			return name, nil
+		}
+		file, err := c.GetModpackFile(projectID, oldFiles[projectID])
+		if err != nil {
+			return "", fmt.Errorf("failed to look up installed mod %d: %w", projectID, err)
+		}
+		return file.FileName, nil
+	}
+
+	if p.manifest != nil {
+		for _, mod := range p.manifest.Files {
+			oldID, had := oldFiles[mod.ProjectID]
+			unchanged := had && oldID == mod.FileID
+			if unchanged {
+				name, known := old.Mods[mod.ProjectID]
+				if !known {
+					// Installed before records existed; looked up if it
+					// ever changes
+					continue
+				}
+				if _, err := os.Stat(filepath.Join(p.serverDir, "mods", name)); err == nil {
+					p.record.Mods[mod.ProjectID] = name
+					continue
+				}
+			}
+
+			file, err := c.GetModpackFile(mod.ProjectID, mod.FileID)
+			if err != nil && p.fresh {
+				fmt.Printf("Warning: failed to download mod %d: %v\n", mod.ProjectID, err)
+				continue
+			} else if err != nil {
+				return fmt.Errorf("failed to look up mod %d: %w", mod.ProjectID, err)
+			}
+			p.download = append(p.download, file)
+			p.record.Mods[mod.ProjectID] = file.FileName
+			if !had || unchanged {
+				// New, or deleted since the last install
+				p.ModsAdded = append(p.ModsAdded, file.FileName)
+				continue
+			}
+			p.ModsUpdated = append(p.ModsUpdated, file.FileName)
+			jar, err := oldJar(mod.ProjectID)
+			if err != nil {
+				return err
+			}
+			if jar != file.FileName {
+				p.remove = append(p.remove, "mods/"+jar)
+			}
+		}
+	}
+
+	newProjects := map[int]bool{}
+	if p.manifest != nil {
+		for _, mod := range p.manifest.Files {
+			newProjects[mod.ProjectID] = true
+		}
+	}
+	var removed []int
+	for projectID := range oldFiles {
+		if !newProjects[projectID] {
+			removed = append(removed, projectID)
+		}
+	}
+	sort.Ints(removed)
+	for _, projectID := range removed {
+		jar, err := oldJar(projectID)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(filepath.Join(p.serverDir, "mods", jar)); err != nil {
+			continue
+		}
+		p.remove = append(p.remove, "mods/"+jar)
+		p.ModsRemoved = append(p.ModsRemoved, jar)
+	}
+
+	oldLoader, newLoader := loaderLabel(old.Manifest), loaderLabel(p.manifest)
+	switch {
+	case newLoader == "":
+	case p.fresh:
+		p.installLoader = true
+	case oldLoader != newLoader:
+		p.installLoader = true
+		p.Loader = oldLoader + " -> " + newLoader
+		if oldLoader == "" {
+			p.Loader = newLoader
+		}
+	}
+	return nil
+}
+
+// ApplyUpgrade carries out a plan. Everything is staged first, and files
+// replaced or removed are kept for RollbackModpack.
+func (c *Client) ApplyUpgrade(plan *UpgradePlan) error {
+	r, err := zip.OpenReader(plan.packPath)
+	if err != nil {
+		return fmt.Errorf("failed to open modpack: %w", err)
+	}
+	defer r.Close()
+	byName := map[string]*zip.File{}
+	for _, f := range r.File {
+		byName[f.Name] = f
+	}
+
+	stagingDir, err := prepareStaging(plan.serverDir)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stagingDir)
+
+	for rel, entry := range plan.write {
+		f := byName[entry]
+		if f == nil {
+			return fmt.Errorf("%s is missing from the modpack", entry)
+		}
+		dest, err := safeJoin(stagingDir, rel)
+		if err != nil {
+			return err
+		}
+		if err := extractEntry(f, dest); err != nil {
+			return fmt.Errorf("failed to extract %s: %w", rel, err)
+		}
+	}
+
+	if err := c.downloadMods(plan.download, filepath.Join(stagingDir, "mods"), plan.fresh); err != nil {
+		return fmt.Errorf("%w, nothing changed", err)
+	}
+
+	if plan.installLoader && plan.manifest != nil {
+		for _, loader := range plan.manifest.Minecraft.ModLoaders {
+			if loader.Primary {
+				if err := c.installModLoader(loader.ID, plan.manifest.Minecraft.Version, stagingDir); err != nil {
+					fmt.Printf("Warning: failed to install mod loader %s: %v\n", loader.ID, err)
+				}
+				break
+			}
+		}
+	}
+
+	remove := make([]string, len(plan.remove))
+	for i, rel := range plan.remove {
+		remove[i] = filepath.FromSlash(rel)
+	}
+	if err := commitStaging(stagingDir, plan.serverDir, remove); err != nil {
+		return fmt.Errorf("failed to apply modpack: %w", err)
+	}
+
+	plan.record.InstalledAt = time.Now()
+	return writeInstallRecord(plan.serverDir, plan.record)
+}
+
+func extractEntry(f *zip.File, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func decodeManifest(f *zip.File) (*ModpackManifest, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %w", err)
+	}
+	defer rc.Close()
+	manifest := &ModpackManifest{}
+	if err := json.NewDecoder(rc).Decode(manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+func readInstallRecord(serverDir string) (*installRecord, error) {
+	data, err := os.ReadFile(filepath.Join(serverDir, managerDir, installRecordFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	record := &installRecord{}
+	if err := json.Unmarshal(data, record); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", installRecordFile, err)
+	}
+	if record.Files == nil {
+		record.Files = map[string]string{}
+	}
+	return record, nil
+}
+
+func writeInstallRecord(serverDir string, record *installRecord) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(serverDir, managerDir, installRecordFile), data, 0644)
+}
+
+// packLabel names an installed pack by its manifest, else its file name
+func packLabel(manifest *ModpackManifest, file string) string {
+	if manifest != nil && manifest.Name != "" {
+		return strings.TrimSpace(manifest.Name + " " + manifest.Version)
+	}
+	return strings.TrimSuffix(file, filepath.Ext(file))
+}
+
+// loaderLabel is the primary loader and Minecraft version of a manifest
+func loaderLabel(manifest *ModpackManifest) string {
+	if manifest == nil {
+		return ""
+	}
+	for _, loader := range manifest.Minecraft.ModLoaders {
+		if loader.Primary {
+			return loader.ID + " on " + manifest.Minecraft.Version
+		}
+	}
+	return ""
+}
+
+func isModJar(rel string) bool {
+	return strings.HasPrefix(rel, "mods/") && strings.HasSuffix(strings.ToLower(rel), ".jar")
+}
+
+func entrySHA256(f *zip.File) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, rc); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
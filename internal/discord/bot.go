@@ -0,0 +1,358 @@
+// Package discord runs a Discord bot with slash commands (/status,
+// /players, /say, /restart) on top of the control API agent. It connects
+// out to Discord's gateway, so the server needs no public address, and
+// maps the Discord roles of whoever runs a command to an API role.
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mcserver-manager/internal/api"
+	"mcserver-manager/internal/audit"
+	"mcserver-manager/internal/server"
+	"mcserver-manager/internal/stats"
+)
+
+const apiBase = "https://discord.com/api/v10"
+
+// Discord caps a message at this many characters
+const maxMessage = 2000
+
+// The Administrator bit of a member's permissions
+const permAdministrator = 1 << 3
+
+// Message flag that shows a reply only to the member who ran the command
+const flagEphemeral = 1 << 6
+
+// errAuth is returned when Discord rejects the bot token; reconnecting
+// would not help
+var errAuth = errors.New("Discord rejected the bot token")
+
+// Config is how the bot connects and who may do what
+type Config struct {
+	Token string
+
+	// The guild the bot serves. Commands are registered there only, and
+	// roles and permissions are only read from its members, since an
+	// Administrator in any other guild the bot was added to is nobody here.
+	GuildID string
+
+	// API role for members with a Discord role ID. Members with several
+	// get the highest, members with Discord's Administrator permission are
+	// admins and everyone else is a viewer.
+	Roles map[string]api.Role
+}
+
+// ParseRoles reads "<discord-role-id>=<role>" entries
+func ParseRoles(entries []string) (map[string]api.Role, error) {
+	roles := map[string]api.Role{}
+	for _, entry := range entries {
+		id, name, found := strings.Cut(entry, "=")
+		if !found || id == "" {
+			return nil, fmt.Errorf("invalid Discord role %q, want <role-id>=<viewer|operator|admin>", entry)
+		}
+		role, err := api.ParseRole(name)
+		if err != nil {
+			return nil, err
+		}
+		roles[strings.TrimSpace(id)] = role
+	}
+	return roles, nil
+}
+
+// Bot answers slash commands through an agent
+type Bot struct {
+	agent  *api.Agent
+	config Config
+	http   *http.Client
+}
+
+// New creates a bot for agent
+func New(agent *api.Agent, config Config) *Bot {
+	return &Bot{
+		agent:  agent,
+		config: config,
+		http:   &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// commands are the slash commands the bot registers, with the API role
+// each needs
+var commands = []struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Options     []commandOption `json:"options,omitempty"`
+	role        api.Role
+}{
+	{Name: "status", Description: "Server status, players, TPS and memory", role: api.RoleViewer},
+	{Name: "players", Description: "Who is online", role: api.RoleViewer},
+	{Name: "say", Description: "Broadcast a message in the server chat", role: api.RoleOperator, Options: []commandOption{
+		{Type: optionString, Name: "message", Description: "What to say", Required: true},
+	}},
+	{Name: "restart", Description: "Restart the server, warning players first", role: api.RoleAdmin},
+}
+
+const optionString = 3
+
+type commandOption struct {
+	Type        int    `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// Run registers the slash commands and answers them until ctx ends,
+// reconnecting to the gateway with a growing delay when the connection
+// drops
+func (b *Bot) Run(ctx context.Context) error {
+	if b.config.GuildID == "" {
+		return fmt.Errorf("no Discord guild configured")
+	}
+	var app struct {
+		ID string `json:"id"`
+	}
+	if err := b.rest(ctx, http.MethodGet, "/oauth2/applications/@me", nil, &app); err != nil {
+		return err
+	}
+	path := "/applications/" + app.ID + "/guilds/" + b.config.GuildID + "/commands"
+	if err := b.rest(ctx, http.MethodPut, path, commands, nil); err != nil {
+		return fmt.Errorf("failed to register slash commands: %w", err)
+	}
+
+	delay := time.Second
+	for {
+		connected := time.Now()
+		err := session(ctx, b.config.Token, func(event string, data json.RawMessage) {
+			switch event {
+			case "READY":
+				b.agent.Notify(server.EventInfo, "Discord bot connected")
+			case "INTERACTION_CREATE":
+				go b.handleInteraction(ctx, data)
+			}
+		})
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		// A session that lasted a while was fine; start the delay over
+		if time.Since(connected) > time.Minute {
+			delay = time.Second
+		}
+		b.agent.Notify(server.EventWarning, fmt.Sprintf("Discord bot disconnected, retrying in %s: %v", delay, err))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, 5*time.Minute)
+	}
+}
+
+type interaction struct {
+	ID      string `json:"id"`
+	Token   string `json:"token"`
+	Type    int    `json:"type"`
+	GuildID string `json:"guild_id"`
+	Data    struct {
+		Name    string `json:"name"`
+		Options []struct {
+			Name  string          `json:"name"`
+			Value json.RawMessage `json:"value"`
+		} `json:"options"`
+	} `json:"data"`
+	Member *struct {
+		User        discordUser `json:"user"`
+		Roles       []string    `json:"roles"`
+		Permissions string      `json:"permissions"`
+	} `json:"member"`
+	User *discordUser `json:"user"`
+}
+
+type discordUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+// Interaction type of a slash command
+const interactionCommand = 2
+
+func (b *Bot) handleInteraction(ctx context.Context, data json.RawMessage) {
+	var in interaction
+	if err := json.Unmarshal(data, &in); err != nil || in.Type != interactionCommand {
+		return
+	}
+	// Only members of the configured guild have roles that mean anything;
+	// direct messages come without a member
+	if in.Member == nil || in.GuildID != b.config.GuildID {
+		b.reply(ctx, in, "Use this command in the server's Discord guild", true)
+		return
+	}
+
+	id := b.identity(in)
+	for _, c := range commands {
+		if c.Name != in.Data.Name {
+			continue
+		}
+		if !id.Role.Allows(c.role) {
+			b.agent.DenyAction(id, "/"+c.Name, c.role)
+			b.reply(ctx, in, fmt.Sprintf("You need the %s role for /%s", c.role, c.Name), true)
+			return
+		}
+		text, err := b.run(id, in)
+		if err != nil {
+			b.reply(ctx, in, "❌ "+err.Error(), true)
+			return
+		}
+		b.reply(ctx, in, text, false)
+		return
+	}
+}
+
+// identity is the API caller behind an interaction
+func (b *Bot) identity(in interaction) api.Identity {
+	id := api.Identity{
+		Name:   "discord:" + in.Member.User.Username,
+		Role:   api.RoleViewer,
+		Source: audit.SourceDiscord,
+	}
+	if perms, err := strconv.ParseUint(in.Member.Permissions, 10, 64); err == nil && perms&permAdministrator != 0 {
+		id.Role = api.RoleAdmin
+	}
+	for _, roleID := range in.Member.Roles {
+		if role, ok := b.config.Roles[roleID]; ok && role.Allows(id.Role) {
+			id.Role = role
+		}
+	}
+	return id
+}
+
+// run carries out the command of an interaction the caller may run
+func (b *Bot) run(id api.Identity, in interaction) (string, error) {
+	s := b.agent.Stats()
+	switch in.Data.Name {
+	case "status":
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "**%s**", s.Status)
+		if s.Status == server.StatusRunning {
+			fmt.Fprintf(&sb, " for %s", stats.FormatDuration(s.Uptime))
+			fmt.Fprintf(&sb, "\nPlayers: %d/%d · TPS: %s · Memory: %s/%s · CPU: %s",
+				s.PlayerCount, s.MaxPlayers, stats.FormatTPS(s.TPS),
+				stats.FormatBytes(s.MemoryUsed), stats.FormatBytes(s.MemoryMax), stats.FormatPercent(s.CPUPercent))
+		}
+		if s.ShareAddress != "" {
+			fmt.Fprintf(&sb, "\nAddress: `%s`", s.ShareAddress)
+		}
+		return sb.String(), nil
+
+	case "players":
+		if len(s.Players) == 0 {
+			return "Nobody is online", nil
+		}
+		names := make([]string, 0, len(s.Players))
+		for _, p := range s.Players {
+			names = append(names, p.Name)
+		}
+		sort.Strings(names)
+		return fmt.Sprintf("**%d/%d online:** %s", len(names), s.MaxPlayers, strings.Join(names, ", ")), nil
+
+	case "say":
+		var message string
+		for _, opt := range in.Data.Options {
+			if opt.Name == "message" {
+				json.Unmarshal(opt.Value, &message)
+			}
+		}
+		if strings.TrimSpace(message) == "" {
+			return "", fmt.Errorf("nothing to say")
+		}
+		// Newlines would end the console command
+		message = strings.Join(strings.Fields(message), " ")
+		if err := b.agent.SendCommand(id, "say "+message); err != nil {
+			return "", err
+		}
+		return "Said: " + message, nil
+
+	case "restart":
+		if s.Status != server.StatusRunning {
+			return "", fmt.Errorf("the server is not running")
+		}
+		if err := b.agent.Restart(id); err != nil {
+			return "", err
+		}
+		return "Restarting; players get the usual countdown", nil
+	}
+	return "", fmt.Errorf("unknown command /%s", in.Data.Name)
+}
+
+// reply answers an interaction with a message, only visible to the caller
+// when ephemeral
+func (b *Bot) reply(ctx context.Context, in interaction, text string, ephemeral bool) {
+	if runes := []rune(text); len(runes) > maxMessage {
+		text = string(runes[:maxMessage-1]) + "…"
+	}
+	data := map[string]any{"content": text, "allowed_mentions": map[string]any{"parse": []string{}}}
+	if ephemeral {
+		data["flags"] = flagEphemeral
+	}
+	body := map[string]any{"type": 4, "data": data}
+	if err := b.rest(ctx, http.MethodPost, "/interactions/"+in.ID+"/"+in.Token+"/callback", body, nil); err != nil {
+		b.agent.Notify(server.EventWarning, fmt.Sprintf("Discord reply to /%s failed: %v", in.Data.Name, err))
+	}
+}
+
+// rest calls Discord's HTTP API as the bot
+func (b *Bot) rest(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, apiBase+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bot "+b.config.Token)
+	req.Header.Set("User-Agent", "DiscordBot (https://github.com/LunarSamurai/Minecraft-Ez-PZ-Server-Auto-Ingestor, 1)")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := b.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Discord: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return errAuth
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("Discord: %s (status %d)", apiErr.Message, resp.StatusCode)
+		}
+		return fmt.Errorf("Discord returned status %d", resp.StatusCode)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to parse Discord response: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// gatewayURL is Discord's gateway, speaking JSON; the bot asks for no
+// intents since interactions arrive without them
+const gatewayURL = "wss://gateway.discord.gg/?v=10&encoding=json"
+
+// Gateway opcodes
+const (
+	opDispatch       = 0
+	opHeartbeat      = 1
+	opIdentify       = 2
+	opReconnect      = 7
+	opInvalidSession = 9
+	opHello          = 10
+)
+
+type payload struct {
+	Op int             `json:"op"`
+	D  json.RawMessage `json:"d"`
+	S  *int64          `json:"s"`
+	T  string          `json:"t"`
+}
+
+// gateway is one connection to the Discord gateway
+type gateway struct {
+	conn *websocket.Conn
+
+	sendMu sync.Mutex
+	seqMu  sync.Mutex
+	seq    *int64
+}
+
+// session connects, identifies with token and hands each dispatch event
+// to handle until the connection drops or ctx ends
+func session(ctx context.Context, token string, handle func(event string, data json.RawMessage)) error {
+	conn, err := websocket.Dial(gatewayURL, "", "https://discord.com")
+	if err != nil {
+		return fmt.Errorf("failed to reach the Discord gateway: %w", err)
+	}
+	g := &gateway{conn: conn}
+	defer conn.Close()
+
+	// Closing the connection is the only way to interrupt a receive
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	var hello struct {
+		HeartbeatInterval int64 `json:"heartbeat_interval"`
+	}
+	first, err := g.receive()
+	if err != nil {
+		return err
+	}
+	if first.Op != opHello || json.Unmarshal(first.D, &hello) != nil || hello.HeartbeatInterval <= 0 {
+		return fmt.Errorf("unexpected first gateway message (op %d)", first.Op)
+	}
+
+	heartbeatDone := make(chan struct{})
+	defer close(heartbeatDone)
+	go g.heartbeat(time.Duration(hello.HeartbeatInterval)*time.Millisecond, heartbeatDone)
+
+	err = g.send(opIdentify, map[string]any{
+		"token":   token,
+		"intents": 0,
+		"properties": map[string]string{
+			"os":      "linux",
+			"browser": "mcserver",
+			"device":  "mcserver",
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		p, err := g.receive()
+		if err != nil {
+			return err
+		}
+		if p.S != nil {
+			g.seqMu.Lock()
+			g.seq = p.S
+			g.seqMu.Unlock()
+		}
+
+		switch p.Op {
+		case opDispatch:
+			handle(p.T, p.D)
+		case opHeartbeat:
+			if err := g.sendHeartbeat(); err != nil {
+				return err
+			}
+		case opReconnect:
+			return fmt.Errorf("gateway asked to reconnect")
+		case opInvalidSession:
+			return fmt.Errorf("gateway session invalidated")
+		}
+	}
+}
+
+// receive reads one gateway message
+func (g *gateway) receive() (payload, error) {
+	var p payload
+	err := websocket.JSON.Receive(g.conn, &p)
+	if err == nil {
+		return p, nil
+	}
+	if errors.Is(err, io.EOF) {
+		return p, fmt.Errorf("gateway closed the connection")
+	}
+	return p, err
+}
+
+func (g *gateway) send(op int, data any) error {
+	g.sendMu.Lock()
+	defer g.sendMu.Unlock()
+	g.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	return websocket.JSON.Send(g.conn, map[string]any{"op": op, "d": data})
+}
+
+func (g *gateway) sendHeartbeat() error {
+	g.seqMu.Lock()
+	seq := g.seq
+	g.seqMu.Unlock()
+	return g.send(opHeartbeat, seq)
+}
+
+// heartbeat keeps the connection alive every interval until done
+func (g *gateway) heartbeat(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if g.sendHeartbeat() != nil {
+				g.conn.Close()
+				return
+			}
+		}
+	}
+}
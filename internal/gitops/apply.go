@@ -0,0 +1,365 @@
+package gitops
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"mcserver-manager/internal/props"
+)
+
+// Files and folders a repository may manage, relative to its Path and the
+// server dir. Anything else in the repository is ignored.
+var (
+	managedFiles = []string{"server.properties", "whitelist.json", "ops.json", "banned-players.json", "banned-ips.json"}
+	managedDirs  = []string{"config", "defaultconfigs"}
+)
+
+// ModsManifest lists the mod jars the server should have:
+//
+//	[{"file": "jei-1.20.1.jar", "url": "https://...", "sha1": "..."}]
+const ModsManifest = "mods.json"
+
+type modEntry struct {
+	File string `json:"file"`
+	URL  string `json:"url"`
+	SHA1 string `json:"sha1"`
+}
+
+var httpClient = &http.Client{Timeout: 5 * time.Minute}
+
+func (r *Repo) apply(source string, st *state, result *Result) error {
+	desired, err := desiredFiles(source)
+	if err != nil {
+		return err
+	}
+	keep := filepath.Join(r.serverDir, Dir, "previous", shortCommit(result.Commit))
+
+	for _, rel := range sortedKeys(desired) {
+		src := desired[rel]
+		if rel == ModsManifest {
+			continue
+		}
+		if strings.HasSuffix(rel, ".properties") {
+			if err := r.applyProperties(rel, src, keep, st, result); err != nil {
+				return err
+			}
+			continue
+		}
+
+		dst := filepath.Join(r.serverDir, filepath.FromSlash(rel))
+		want, local := fileHash(src), fileHash(dst)
+		if local == want {
+			st.Files[rel] = want
+			continue
+		}
+		if applied, known := st.Files[rel]; known && local != applied {
+			result.Drifted = append(result.Drifted, rel)
+			continue
+		}
+		if err := validate(src); err != nil {
+			result.Invalid = append(result.Invalid, fmt.Sprintf("%s (%v)", rel, err))
+			continue
+		}
+		if err := preserve(dst, filepath.Join(keep, filepath.FromSlash(rel))); err != nil {
+			return err
+		}
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(dst, data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", rel, err)
+		}
+		st.Files[rel] = want
+		result.Changed = append(result.Changed, rel)
+	}
+
+	// Files the repository no longer has are removed, unless edited here
+	for _, rel := range sortedKeys(st.Files) {
+		if _, ok := desired[rel]; ok {
+			continue
+		}
+		dst := filepath.Join(r.serverDir, filepath.FromSlash(rel))
+		switch fileHash(dst) {
+		case st.Files[rel]:
+			if err := preserve(dst, filepath.Join(keep, filepath.FromSlash(rel))); err != nil {
+				return err
+			}
+			os.Remove(dst)
+			result.Removed = append(result.Removed, rel)
+		case "":
+		default:
+			result.Drifted = append(result.Drifted, rel)
+		}
+		delete(st.Files, rel)
+	}
+	for rel := range st.Properties {
+		if _, ok := desired[rel]; !ok {
+			delete(st.Properties, rel)
+		}
+	}
+
+	if src, ok := desired[ModsManifest]; ok {
+		return r.applyMods(src, st, result)
+	}
+	return nil
+}
+
+// applyProperties merges a repository .properties file key by key, since
+// the manager and the server both write server.properties themselves
+func (r *Repo) applyProperties(rel, src, keep string, st *state, result *Result) error {
+	want, err := loadProperties(src)
+	if err != nil {
+		result.Invalid = append(result.Invalid, fmt.Sprintf("%s (%v)", rel, err))
+		return nil
+	}
+	dst := filepath.Join(r.serverDir, filepath.FromSlash(rel))
+	local, err := props.Load(dst)
+	if err != nil {
+		return err
+	}
+
+	applied := st.Properties[rel]
+	next := map[string]string{}
+	var changed []string
+	for _, key := range sortedKeys(want) {
+		value := want[key]
+		current, _ := local.Get(key)
+		if current == value {
+			next[key] = value
+			continue
+		}
+		if prev, known := applied[key]; known && current != prev {
+			result.Drifted = append(result.Drifted, rel+":"+key)
+			next[key] = prev
+			continue
+		}
+		local.Set(key, value)
+		next[key] = value
+		changed = append(changed, key)
+	}
+	st.Properties[rel] = next
+
+	if len(changed) == 0 {
+		return nil
+	}
+	if err := preserve(dst, filepath.Join(keep, filepath.FromSlash(rel))); err != nil {
+		return err
+	}
+	if err := local.Save(dst); err != nil {
+		return fmt.Errorf("failed to write %s: %w", rel, err)
+	}
+	result.Changed = append(result.Changed, fmt.Sprintf("%s (%s)", rel, strings.Join(changed, ", ")))
+	return nil
+}
+
+// applyMods downloads the jars in the manifest that are missing or differ,
+// and removes jars an earlier manifest installed that it no longer lists
+func (r *Repo) applyMods(src string, st *state, result *Result) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	var entries []modEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		result.Invalid = append(result.Invalid, fmt.Sprintf("%s (%v)", ModsManifest, err))
+		return nil
+	}
+
+	modsDir := filepath.Join(r.serverDir, "mods")
+	if err := os.MkdirAll(modsDir, 0755); err != nil {
+		return err
+	}
+
+	wanted := map[string]bool{}
+	for _, e := range entries {
+		if e.File == "" || filepath.Base(e.File) != e.File || !strings.HasSuffix(e.File, ".jar") || e.URL == "" || e.SHA1 == "" {
+			result.Invalid = append(result.Invalid, fmt.Sprintf("%s entry %q (needs file, url and sha1)", ModsManifest, e.File))
+			continue
+		}
+		wanted[e.File] = true
+		dst := filepath.Join(modsDir, e.File)
+		if strings.EqualFold(fileHash(dst), e.SHA1) {
+			st.Mods[e.File] = strings.ToLower(e.SHA1)
+			continue
+		}
+		if err := download(e.URL, dst, e.SHA1); err != nil {
+			result.Invalid = append(result.Invalid, fmt.Sprintf("%s (%v)", e.File, err))
+			continue
+		}
+		st.Mods[e.File] = strings.ToLower(e.SHA1)
+		result.ModsAdded = append(result.ModsAdded, e.File)
+	}
+
+	for _, name := range sortedKeys(st.Mods) {
+		if wanted[name] {
+			continue
+		}
+		dst := filepath.Join(modsDir, name)
+		if fileHash(dst) == st.Mods[name] {
+			os.Remove(dst)
+			result.ModsRemoved = append(result.ModsRemoved, name)
+		}
+		delete(st.Mods, name)
+	}
+	return nil
+}
+
+// desiredFiles returns the managed files present in the repository,
+// keyed by slash-separated path. Symlinks are ignored so a repository
+// cannot make the manager copy files from elsewhere on the host.
+func desiredFiles(source string) (map[string]string, error) {
+	if _, err := os.Stat(source); err != nil {
+		return nil, fmt.Errorf("repository path not found: %w", err)
+	}
+
+	files := map[string]string{}
+	for _, name := range append(managedFiles, ModsManifest) {
+		path := filepath.Join(source, name)
+		if info, err := os.Lstat(path); err == nil && info.Mode().IsRegular() {
+			files[name] = path
+		}
+	}
+	for _, dir := range managedDirs {
+		root := filepath.Join(source, dir)
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return nil
+			}
+			rel, err := filepath.Rel(source, path)
+			if err == nil {
+				files[filepath.ToSlash(rel)] = path
+			}
+			return nil
+		})
+	}
+	return files, nil
+}
+
+// validate catches files the server would fail to read
+func validate(path string) error {
+	if !strings.HasSuffix(path, ".json") {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !json.Valid(data) {
+		return fmt.Errorf("not valid JSON")
+	}
+	return nil
+}
+
+func loadProperties(path string) (map[string]string, error) {
+	p, err := props.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	for _, key := range p.Keys() {
+		values[key], _ = p.Get(key)
+	}
+	return values, nil
+}
+
+// preserve copies a file about to be replaced into the keep folder so a
+// bad commit can be undone by hand
+func preserve(path, dest string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(dest, data, 0644)
+}
+
+func download(url, dest, sha string) error {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download returned status %d", resp.StatusCode)
+	}
+
+	tmp := dest + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	hash := sha1.New()
+	_, err = io.Copy(io.MultiWriter(out, hash), resp.Body)
+	out.Close()
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if got := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(got, sha) {
+		os.Remove(tmp)
+		return fmt.Errorf("sha1 mismatch: got %s", got)
+	}
+	return os.Rename(tmp, dest)
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// fileHash returns the SHA-1 of a file, or "" if it does not exist
+func fileHash(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	hash := sha1.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortStrings(s []string) {
+	sort.Strings(s)
+}
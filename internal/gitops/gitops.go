@@ -0,0 +1,236 @@
+package gitops
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Dir holds the checkout, the sync state and copies of replaced files,
+// relative to the server dir
+const Dir = ".mcserver/gitops"
+
+// Repo syncs a server's configuration from a git repository. Files the
+// repository manages are applied only while nobody has edited them on the
+// server since the last sync; edited files are reported as drift and left
+// alone until the repository and the server agree again.
+type Repo struct {
+	URL    string
+	Branch string
+	Path   string // subdirectory of the repository holding the server files
+
+	serverDir string
+	mu        sync.Mutex // one sync at a time
+}
+
+// Result describes one sync
+type Result struct {
+	Commit      string
+	Changed     []string // files written from the repository
+	Removed     []string // files the repository stopped managing
+	Drifted     []string // edited on the server, left alone
+	Invalid     []string // failed validation, not applied
+	ModsAdded   []string
+	ModsRemoved []string
+}
+
+// Empty reports whether the sync changed nothing
+func (r *Result) Empty() bool {
+	return len(r.Changed)+len(r.Removed)+len(r.ModsAdded)+len(r.ModsRemoved) == 0
+}
+
+func (r *Result) String() string {
+	short := r.Commit
+	if len(short) > 7 {
+		short = short[:7]
+	}
+	parts := []string{"commit " + short}
+	add := func(label string, items []string) {
+		if len(items) > 0 {
+			parts = append(parts, fmt.Sprintf("%s %s", label, strings.Join(items, ", ")))
+		}
+	}
+	add("changed", r.Changed)
+	add("removed", r.Removed)
+	add("added mods", r.ModsAdded)
+	add("removed mods", r.ModsRemoved)
+	add("drifted (kept local)", r.Drifted)
+	add("invalid (skipped)", r.Invalid)
+	if len(parts) == 1 {
+		parts = append(parts, "up to date")
+	}
+	return strings.Join(parts, "; ")
+}
+
+// state records what the last sync applied, to tell repository changes
+// from local edits
+type state struct {
+	Commit     string                       `json:"commit"`
+	Files      map[string]string            `json:"files"`      // path -> sha1
+	Properties map[string]map[string]string `json:"properties"` // path -> key -> value
+	Mods       map[string]string            `json:"mods"`       // jar name -> sha1
+}
+
+// New prepares syncing repoURL's branch into serverDir
+func New(serverDir, repoURL, branch, path string) *Repo {
+	if branch == "" {
+		branch = "main"
+	}
+	return &Repo{URL: repoURL, Branch: branch, Path: path, serverDir: serverDir}
+}
+
+// Source is the repository URL without any credentials in it, for display
+func (r *Repo) Source() string {
+	u, err := url.Parse(r.URL)
+	if err != nil || u.User == nil {
+		return r.URL
+	}
+	u.User = nil
+	return u.String()
+}
+
+func (r *Repo) checkout() string {
+	return filepath.Join(r.serverDir, Dir, "repo")
+}
+
+// Fetch updates the checkout to the tip of the branch and returns its commit
+func (r *Repo) Fetch() (string, error) {
+	dir := r.checkout()
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return "", err
+		}
+		if _, err := git("", "clone", "--depth", "1", "--branch", r.Branch, r.URL, dir); err != nil {
+			return "", err
+		}
+	} else {
+		if _, err := git(dir, "fetch", "--depth", "1", "origin", r.Branch); err != nil {
+			return "", err
+		}
+		if _, err := git(dir, "reset", "--hard", "FETCH_HEAD"); err != nil {
+			return "", err
+		}
+	}
+	return git(dir, "rev-parse", "HEAD")
+}
+
+// Sync fetches the branch and applies it
+func (r *Repo) Sync() (*Result, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	commit, err := r.Fetch()
+	if err != nil {
+		return nil, err
+	}
+	st, err := r.loadState()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Commit: commit}
+	source := filepath.Join(r.checkout(), filepath.FromSlash(r.Path))
+	if err := r.apply(source, st, result); err != nil {
+		return result, err
+	}
+
+	st.Commit = commit
+	if err := r.saveState(st); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// Drift lists managed files that were edited on the server since the last
+// sync, without fetching
+func (r *Repo) Drift() ([]string, error) {
+	st, err := r.loadState()
+	if err != nil {
+		return nil, err
+	}
+
+	var drifted []string
+	for rel, applied := range st.Files {
+		if fileHash(filepath.Join(r.serverDir, filepath.FromSlash(rel))) != applied {
+			drifted = append(drifted, rel)
+		}
+	}
+	for rel, keys := range st.Properties {
+		local, err := loadProperties(filepath.Join(r.serverDir, filepath.FromSlash(rel)))
+		if err != nil {
+			continue
+		}
+		for key, applied := range keys {
+			if local[key] != applied {
+				drifted = append(drifted, rel+":"+key)
+			}
+		}
+	}
+	sortStrings(drifted)
+	return drifted, nil
+}
+
+// Commit returns the last applied commit, if any
+func (r *Repo) Commit() string {
+	st, err := r.loadState()
+	if err != nil {
+		return ""
+	}
+	return st.Commit
+}
+
+func (r *Repo) statePath() string {
+	return filepath.Join(r.serverDir, Dir, "state.json")
+}
+
+func (r *Repo) loadState() (*state, error) {
+	st := &state{
+		Files:      map[string]string{},
+		Properties: map[string]map[string]string{},
+		Mods:       map[string]string{},
+	}
+	data, err := os.ReadFile(r.statePath())
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gitops state: %w", err)
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("failed to parse gitops state: %w", err)
+	}
+	return st, nil
+}
+
+func (r *Repo) saveState(st *state) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(r.statePath(), data)
+}
+
+// git runs a git command and returns its trimmed output
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	// Never wait on a credential prompt nobody can answer
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s failed: %s", args[0], msg)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,101 @@
+// Package i18n translates the TUI and CLI output. Messages are looked up by
+// their English text, so an untranslated message, or a language without a
+// catalog, shows the English that is in the code.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// English is the language of the messages in the code
+const English = "en"
+
+// catalog maps English messages, fmt verbs and all, to the current
+// language; nil for English
+var catalog map[string]string
+
+// Languages returns the codes of the built-in languages, English first
+func Languages() []string {
+	langs := []string{English}
+	entries, _ := locales.ReadDir("locales")
+	var others []string
+	for _, e := range entries {
+		others = append(others, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	sort.Strings(others)
+	return append(langs, others...)
+}
+
+// SetLanguage switches the output to a language, given as a code like
+// "de" or a locale like "de_DE.UTF-8". An empty lang takes the locale from
+// LC_ALL, LC_MESSAGES or LANG, where an unknown one falls back to English.
+func SetLanguage(lang string) error {
+	fromEnv := lang == ""
+	if fromEnv {
+		lang = envLocale()
+	}
+	code := normalize(lang)
+	if code == "" || code == English {
+		catalog = nil
+		return nil
+	}
+
+	data, err := locales.ReadFile(path.Join("locales", code+".json"))
+	if err != nil {
+		catalog = nil
+		if fromEnv {
+			return nil
+		}
+		return fmt.Errorf("no translation for %q (available: %s)", lang, strings.Join(Languages(), ", "))
+	}
+	var messages map[string]string
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return fmt.Errorf("catalog %s: %w", code, err)
+	}
+	catalog = messages
+	return nil
+}
+
+// envLocale is the locale the environment asks messages in
+func envLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// normalize turns "de_DE.UTF-8", "de-DE" or "DE" into "de"; the C and
+// POSIX locales are English
+func normalize(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "_-.@"); i >= 0 {
+		lang = lang[:i]
+	}
+	if lang == "c" || lang == "posix" {
+		return English
+	}
+	return lang
+}
+
+// T translates an English message and formats it with args like
+// fmt.Sprintf; without args the message is returned as is
+func T(msg string, args ...any) string {
+	if translated, ok := catalog[msg]; ok && translated != "" {
+		msg = translated
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
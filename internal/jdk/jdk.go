@@ -0,0 +1,270 @@
+// Package jdk finds the Java installations on this machine: JAVA_HOME,
+// the java on PATH, and the usual install locations of package managers,
+// vendor installers and SDKMAN!.
+package jdk
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Install is one Java runtime
+type Install struct {
+	Path    string `json:"path"`             // the java executable
+	Major   int    `json:"major"`            // 8, 17, 21, ...
+	Version string `json:"version"`          // 1.8.0_402, 17.0.10, ...
+	Vendor  string `json:"vendor,omitempty"` // from the release file
+	Source  string `json:"source"`           // JAVA_HOME, PATH or the dir it was found in
+}
+
+// versionLine is the first line of "java -version": version "21.0.2" on
+// modern releases, version "1.8.0_402" on Java 8
+var versionLine = regexp.MustCompile(`version "([^"]+)"`)
+
+// MajorOf returns the major version of a Java version string
+func MajorOf(version string) int {
+	parts := strings.FieldsFunc(version, func(r rune) bool { return r < '0' || r > '9' })
+	if len(parts) == 0 {
+		return 0
+	}
+	major, _ := strconv.Atoi(parts[0])
+	if major == 1 && len(parts) > 1 {
+		major, _ = strconv.Atoi(parts[1])
+	}
+	return major
+}
+
+// Probe reads the version of the java at path (a file path or a name on
+// PATH), from the release file of its home when there is one, or else by
+// running "java -version"
+func Probe(path string) (Install, error) {
+	install := Install{Path: path}
+	if resolved, err := exec.LookPath(path); err == nil {
+		if real, err := filepath.EvalSymlinks(resolved); err == nil {
+			resolved = real
+		}
+		home := filepath.Dir(filepath.Dir(resolved))
+		install.Version, install.Vendor = readRelease(filepath.Join(home, "release"))
+		// A JRE inside a Java 8 JDK has its release file a level up
+		if install.Version == "" && filepath.Base(home) == "jre" {
+			install.Version, install.Vendor = readRelease(filepath.Join(filepath.Dir(home), "release"))
+		}
+	}
+
+	if install.Version == "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, path, "-version").CombinedOutput()
+		if err != nil {
+			return install, err
+		}
+		m := versionLine.FindSubmatch(out)
+		if m == nil {
+			return install, fmt.Errorf("unrecognized version output")
+		}
+		install.Version = string(m[1])
+	}
+	install.Major = MajorOf(install.Version)
+	if install.Major == 0 {
+		return install, fmt.Errorf("unrecognized version %q", install.Version)
+	}
+	return install, nil
+}
+
+// readRelease returns JAVA_VERSION and IMPLEMENTOR from a JDK's release
+// file
+func readRelease(path string) (string, string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", ""
+	}
+	defer f.Close()
+	var version, vendor string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(value, `"`)
+		switch key {
+		case "JAVA_VERSION":
+			version = value
+		case "IMPLEMENTOR":
+			vendor = value
+		}
+	}
+	return version, vendor
+}
+
+// candidate is a java executable to probe and where it was found
+type candidate struct {
+	path, source string
+}
+
+// candidates lists the java executables in the usual places
+func candidates() []candidate {
+	exe := "java"
+	if runtime.GOOS == "windows" {
+		exe = "java.exe"
+	}
+	var found []candidate
+	if home := os.Getenv("JAVA_HOME"); home != "" {
+		found = append(found, candidate{filepath.Join(home, "bin", exe), "JAVA_HOME"})
+	}
+	if path, err := exec.LookPath("java"); err == nil {
+		found = append(found, candidate{path, "PATH"})
+	}
+
+	// Dirs holding one JDK per subdir, with the suffix leading to bin/
+	var roots []string
+	userHome, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "windows":
+		for _, env := range []string{"ProgramFiles", "ProgramFiles(x86)", "ProgramW6432"} {
+			base := os.Getenv(env)
+			if base == "" {
+				continue
+			}
+			for _, vendor := range []string{"Java", "Eclipse Adoptium", "Eclipse Foundation", "AdoptOpenJDK", "Microsoft", "Zulu", "BellSoft", "Amazon Corretto", "Semeru"} {
+				roots = append(roots, filepath.Join(base, vendor))
+			}
+		}
+	case "darwin":
+		roots = append(roots, "/Library/Java/JavaVirtualMachines/*/Contents/Home")
+		if userHome != "" {
+			roots = append(roots, filepath.Join(userHome, "Library/Java/JavaVirtualMachines/*/Contents/Home"))
+		}
+		roots = append(roots, "/opt/homebrew/opt/openjdk*/libexec/openjdk.jdk/Contents/Home")
+	default:
+		roots = append(roots, "/usr/lib/jvm", "/usr/lib64/jvm", "/usr/java", "/opt/java", "/opt/jdk", "/opt")
+	}
+	if userHome != "" {
+		roots = append(roots, filepath.Join(userHome, ".sdkman/candidates/java"), filepath.Join(userHome, ".jdks"))
+	}
+
+	for _, root := range roots {
+		pattern := filepath.Join(root, "*", "bin", exe)
+		if strings.HasSuffix(root, "Contents/Home") {
+			pattern = filepath.Join(root, "bin", exe)
+		}
+		matches, _ := filepath.Glob(pattern)
+		for _, m := range matches {
+			found = append(found, candidate{m, filepath.Dir(filepath.Dir(filepath.Dir(m)))})
+		}
+	}
+	return found
+}
+
+// Discover probes every java it can find. The same runtime reached by
+// several paths (symlinks, JAVA_HOME) is listed once, under the first.
+// The newest versions come first.
+func Discover() []Install {
+	found := candidates()
+	installs := make([]*Install, len(found))
+	var wg sync.WaitGroup
+	for i, c := range found {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if install, err := Probe(c.path); err == nil {
+				install.Source = c.source
+				installs[i] = &install
+			}
+		}()
+	}
+	wg.Wait()
+
+	seen := map[string]bool{}
+	list := []Install{}
+	for _, install := range installs {
+		if install == nil {
+			continue
+		}
+		key := install.Path
+		if resolved, err := exec.LookPath(key); err == nil {
+			key = resolved
+		}
+		if real, err := filepath.EvalSymlinks(key); err == nil {
+			key = real
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		list = append(list, *install)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Major != list[j].Major {
+			return list[i].Major > list[j].Major
+		}
+		return compare(list[i].Version, list[j].Version) > 0
+	})
+	return list
+}
+
+// Find returns the newest install of a major version
+func Find(major int) (Install, bool) {
+	for _, install := range Discover() {
+		if install.Major == major {
+			return install, true
+		}
+	}
+	return Install{}, false
+}
+
+// Resolve turns a --java value into an executable: a bare major version
+// ("17") names a discovered install, anything else is a path or command
+func Resolve(java string) (string, error) {
+	major, err := strconv.Atoi(java)
+	if err != nil {
+		return java, nil
+	}
+	list := Discover()
+	var have []string
+	for _, install := range list {
+		if install.Major == major {
+			return install.Path, nil
+		}
+		if v := strconv.Itoa(install.Major); len(have) == 0 || have[len(have)-1] != v {
+			have = append(have, v)
+		}
+	}
+	if len(have) == 0 {
+		return "", fmt.Errorf("no Java %d found, and no other Java either", major)
+	}
+	return "", fmt.Errorf("no Java %d found (have %s; see 'mcserver java list')", major, strings.Join(have, ", "))
+}
+
+// compare orders versions by their runs of digits
+func compare(a, b string) int {
+	pa := strings.FieldsFunc(a, func(r rune) bool { return r < '0' || r > '9' })
+	pb := strings.FieldsFunc(b, func(r rune) bool { return r < '0' || r > '9' })
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			y, _ = strconv.Atoi(pb[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,422 @@
+// Package loadbot simulates players at the protocol level for load tests:
+// bots join an offline-mode server, walk around their spawn point and chat,
+// answering just enough of the protocol to stay connected.
+package loadbot
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mcserver-manager/internal/nbt"
+	"mcserver-manager/internal/slp"
+)
+
+// Bot is one simulated player connected to a server
+type Bot struct {
+	Name string
+
+	conn      net.Conn
+	r         *bufio.Reader
+	protocol  int32
+	ids       *packetIDs
+	threshold int
+
+	writeMu sync.Mutex
+
+	mu         sync.Mutex
+	x, y, z    float64
+	homeX      float64
+	homeZ      float64
+	positioned bool
+}
+
+// Behavior is what a bot does once it is in the world
+type Behavior struct {
+	// How often the bot takes a step, 0 to stand still
+	MoveInterval time.Duration
+	// How far from where it spawned the bot wanders, in blocks
+	Radius float64
+	// How often, give or take a third, the bot says something; 0 never
+	ChatInterval time.Duration
+}
+
+// Keep-alives come every 15 seconds; a server this quiet is gone
+const readTimeout = 45 * time.Second
+
+// Join connects to address as name, logs in without authentication and
+// finishes configuration, leaving the bot in the world. The server must run
+// with online-mode=false and speak protocol.
+func Join(address, name string, protocol int32, timeout time.Duration) (*Bot, error) {
+	ids := protocols[protocol]
+	if ids == nil {
+		return nil, fmt.Errorf("protocol %d is not supported (bots speak %s)", protocol, SupportedVersions)
+	}
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q: %w", address, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q", portStr)
+	}
+
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	b := &Bot{Name: name, conn: conn, r: bufio.NewReader(conn), protocol: protocol, ids: ids, threshold: -1}
+	conn.SetDeadline(time.Now().Add(timeout))
+	if err := b.login(host, port); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if protocol >= protocolConfiguration {
+		if err := b.configure(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	conn.SetDeadline(time.Time{})
+	return b, nil
+}
+
+// Close disconnects the bot
+func (b *Bot) Close() error {
+	return b.conn.Close()
+}
+
+func (b *Bot) send(p *packet) error {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	_, err := b.conn.Write(p.encode(b.threshold))
+	return err
+}
+
+func (b *Bot) read(want func(id int32) bool) (int32, *bytes.Reader, error) {
+	return readPacket(b.r, b.threshold, want)
+}
+
+func all(int32) bool { return true }
+
+func (b *Bot) login(host string, port int) error {
+	if err := b.send(newPacket(0x00).varInt(b.protocol).str(host).u16(uint16(port)).varInt(2)); err != nil {
+		return err
+	}
+	start := newPacket(0x00).str(b.Name)
+	if b.protocol < protocolConfiguration {
+		start.boolean(true)
+	}
+	if err := b.send(start.uuid(offlineUUID(b.Name))); err != nil {
+		return err
+	}
+
+	for {
+		id, body, err := b.read(all)
+		if err != nil {
+			return fmt.Errorf("login failed: %w", err)
+		}
+		switch id {
+		case 0x00:
+			reason, _ := readString(body)
+			return fmt.Errorf("refused: %s", slp.FlattenChat(json.RawMessage(reason)))
+		case 0x01:
+			return fmt.Errorf("the server is in online mode, and bots have no Mojang account")
+		case 0x02:
+			if b.protocol >= protocolConfiguration {
+				return b.send(newPacket(0x03))
+			}
+			return nil
+		case 0x03:
+			threshold, err := readVarInt(body)
+			if err != nil {
+				return err
+			}
+			b.threshold = int(threshold)
+		case 0x04:
+			// Login plugin request: a bot knows no channels
+			msgID, err := readVarInt(body)
+			if err != nil {
+				return err
+			}
+			if err := b.send(newPacket(0x02).varInt(msgID).boolean(false)); err != nil {
+				return err
+			}
+		case 0x05:
+			key, _ := readString(body)
+			if err := b.send(newPacket(0x04).str(key).boolean(false)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// configure answers the configuration phase until the server moves the
+// bot into the world
+func (b *Bot) configure() error {
+	// Configuration packet IDs, clientbound then serverbound
+	disconnect, finish, keepAlive, ping, knownPacks, cookie := int32(0x01), int32(0x02), int32(0x03), int32(0x04), int32(-1), int32(-1)
+	ack, keepAliveReply, pong, knownPacksReply, cookieReply := int32(0x02), int32(0x03), int32(0x04), int32(-1), int32(-1)
+	if b.protocol >= protocolKnownPacks {
+		disconnect, finish, keepAlive, ping, knownPacks, cookie = 0x02, 0x03, 0x04, 0x05, 0x0E, 0x00
+		ack, keepAliveReply, pong, knownPacksReply, cookieReply = 0x03, 0x04, 0x05, 0x07, 0x01
+	}
+
+	want := func(id int32) bool {
+		return id == disconnect || id == keepAlive || id == ping || id == cookie
+	}
+	for {
+		id, body, err := b.read(want)
+		if err != nil {
+			return fmt.Errorf("configuration failed: %w", err)
+		}
+		switch id {
+		case disconnect:
+			return fmt.Errorf("refused: %s", b.reason(body))
+		case finish:
+			return b.send(newPacket(ack))
+		case keepAlive:
+			err = b.echo(keepAliveReply, body)
+		case ping:
+			err = b.echo(pong, body)
+		case knownPacks:
+			// Knowing none makes the server send every registry in full
+			err = b.send(newPacket(knownPacksReply).varInt(0))
+		case cookie:
+			key, _ := readString(body)
+			err = b.send(newPacket(cookieReply).str(key).boolean(false))
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// echo sends body back under id, for keep-alives and pings
+func (b *Bot) echo(id int32, body *bytes.Reader) error {
+	p := newPacket(id)
+	io.Copy(p, body)
+	return b.send(p)
+}
+
+// Run plays until the server disconnects the bot or stop is closed, and
+// returns why the bot left; nil when stopped
+func (b *Bot) Run(behavior Behavior, stop <-chan struct{}) error {
+	errc := make(chan error, 1)
+	go func() { errc <- b.readLoop() }()
+
+	var move <-chan time.Time
+	if behavior.MoveInterval > 0 {
+		ticker := time.NewTicker(behavior.MoveInterval)
+		defer ticker.Stop()
+		move = ticker.C
+	}
+	var chat <-chan time.Time
+	if behavior.ChatInterval > 0 {
+		timer := time.NewTimer(jitter(behavior.ChatInterval))
+		defer timer.Stop()
+		chat = timer.C
+	}
+
+	messages := 0
+	for {
+		var err error
+		select {
+		case <-stop:
+			b.conn.Close()
+			<-errc
+			return nil
+		case err := <-errc:
+			b.conn.Close()
+			return err
+		case <-move:
+			err = b.step(behavior.Radius)
+		case <-chat:
+			messages++
+			err = b.chat(fmt.Sprintf("Load test message %d from %s", messages, b.Name))
+			chat = time.After(jitter(behavior.ChatInterval))
+		}
+		if err != nil {
+			b.conn.Close()
+			<-errc
+			return fmt.Errorf("connection lost: %w", err)
+		}
+	}
+}
+
+func (b *Bot) readLoop() error {
+	ids := b.ids
+	want := func(id int32) bool {
+		return id == ids.disconnect || id == ids.keepAlive || id == ids.syncPosition
+	}
+	for {
+		b.conn.SetReadDeadline(time.Now().Add(readTimeout))
+		id, body, err := b.read(want)
+		if err != nil {
+			return fmt.Errorf("connection lost: %w", err)
+		}
+		switch id {
+		case ids.disconnect:
+			return fmt.Errorf("kicked: %s", b.reason(body))
+		case ids.keepAlive:
+			err = b.echo(ids.keepAliveReply, body)
+		case ids.syncPosition:
+			err = b.teleported(body)
+		case ids.chunkBatchFinished:
+			// Ask for chunks as fast as a vanilla client on a fast machine
+			err = b.send(newPacket(ids.chunkBatchReceived).f32(20))
+		}
+		if err != nil {
+			return fmt.Errorf("connection lost: %w", err)
+		}
+	}
+}
+
+// teleported confirms a position the server put the bot at, which is
+// also how it learns where it spawned
+func (b *Bot) teleported(body *bytes.Reader) error {
+	var teleportID int32
+	var err error
+	if b.protocol >= protocolTeleportFirst {
+		if teleportID, err = readVarInt(body); err != nil {
+			return err
+		}
+	}
+	var pos [3]float64
+	for i := range pos {
+		if pos[i], err = readF64(body); err != nil {
+			return err
+		}
+	}
+	var flags uint32
+	if b.protocol >= protocolTeleportFirst {
+		// Velocity, then yaw and pitch
+		body.Seek(3*8+2*4, io.SeekCurrent)
+		var raw [4]byte
+		if _, err := io.ReadFull(body, raw[:]); err != nil {
+			return err
+		}
+		flags = uint32(raw[0])<<24 | uint32(raw[1])<<16 | uint32(raw[2])<<8 | uint32(raw[3])
+	} else {
+		body.Seek(2*4, io.SeekCurrent)
+		flag, err := body.ReadByte()
+		if err != nil {
+			return err
+		}
+		flags = uint32(flag)
+		if teleportID, err = readVarInt(body); err != nil {
+			return err
+		}
+	}
+
+	b.mu.Lock()
+	current := [3]float64{b.x, b.y, b.z}
+	for i := range pos {
+		if flags&(1<<i) != 0 {
+			pos[i] += current[i]
+		}
+	}
+	b.x, b.y, b.z = pos[0], pos[1], pos[2]
+	if !b.positioned {
+		b.homeX, b.homeZ, b.positioned = b.x, b.z, true
+	}
+	b.mu.Unlock()
+
+	if err := b.send(newPacket(b.ids.confirmTeleport).varInt(teleportID)); err != nil {
+		return err
+	}
+	return b.sendPosition(pos[0], pos[1], pos[2])
+}
+
+// step walks up to a block in a random direction, back towards the spawn
+// point once the bot is radius away from it. Bots have no physics, so they
+// keep their height.
+func (b *Bot) step(radius float64) error {
+	b.mu.Lock()
+	if !b.positioned {
+		b.mu.Unlock()
+		return nil
+	}
+	angle := rand.Float64() * 2 * math.Pi
+	dx, dz := math.Cos(angle)*0.8, math.Sin(angle)*0.8
+	if math.Hypot(b.x+dx-b.homeX, b.z+dz-b.homeZ) > radius {
+		dx, dz = -dx, -dz
+	}
+	b.x, b.z = b.x+dx, b.z+dz
+	x, y, z := b.x, b.y, b.z
+	b.mu.Unlock()
+	return b.sendPosition(x, y, z)
+}
+
+func (b *Bot) sendPosition(x, y, z float64) error {
+	// On ground; since 1.21.2 the same byte is a set of flags with on
+	// ground as its lowest bit
+	return b.send(newPacket(b.ids.setPosition).f64(x).f64(y).f64(z).boolean(true))
+}
+
+// chat sends an unsigned chat message, which offline-mode servers accept
+func (b *Bot) chat(message string) error {
+	p := newPacket(b.ids.chat).str(message).i64(time.Now().UnixMilli()).i64(rand.Int63()).boolean(false)
+	// No messages acknowledged: an empty 20-bit set
+	p.varInt(0).Write([]byte{0, 0, 0})
+	return b.send(p)
+}
+
+// reason reads a disconnect reason, JSON text before 1.20.3 and an NBT
+// component since
+func (b *Bot) reason(body *bytes.Reader) string {
+	if b.protocol < protocolNBTChat {
+		reason, _ := readString(body)
+		return slp.FlattenChat(json.RawMessage(reason))
+	}
+	tag, err := nbt.ReadNetwork(body)
+	if err != nil {
+		return "no reason given"
+	}
+	return nbtText(tag)
+}
+
+// nbtText flattens an NBT text component; translated messages show their
+// key, like multiplayer.disconnect.server_full
+func nbtText(tag interface{}) string {
+	switch v := tag.(type) {
+	case string:
+		return v
+	case nbt.Compound:
+		var parts []string
+		for _, key := range []string{"text", "translate"} {
+			if s := v.String(key); s != "" {
+				parts = append(parts, s)
+			}
+		}
+		if extra, ok := v.Get("extra").([]interface{}); ok {
+			for _, e := range extra {
+				parts = append(parts, nbtText(e))
+			}
+		}
+		return strings.Join(parts, "")
+	}
+	return ""
+}
+
+// offlineUUID is the UUID an offline-mode server gives a name
+func offlineUUID(name string) [16]byte {
+	sum := md5.Sum([]byte("OfflinePlayer:" + name))
+	sum[6] = sum[6]&0x0f | 0x30
+	sum[8] = sum[8]&0x3f | 0x80
+	return sum
+}
+
+func jitter(d time.Duration) time.Duration {
+	return d*2/3 + time.Duration(rand.Int63n(int64(d)*2/3+1))
+}
@@ -0,0 +1,224 @@
+package loadbot
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Protocol versions where the login flow changed
+const (
+	// Login Start carries the player's UUID
+	protocolLoginUUID = 761 // 1.19.3
+	// Login is followed by a configuration phase
+	protocolConfiguration = 764 // 1.20.2
+	// Disconnect reasons in configuration and play are NBT, not JSON
+	protocolNBTChat = 765 // 1.20.3
+	// Cookies and known packs
+	protocolKnownPacks = 766 // 1.20.5
+	// Sync Player Position starts with the teleport ID and carries velocity
+	protocolTeleportFirst = 768 // 1.21.2
+)
+
+// packetIDs are the play packets a bot reads or sends, which move between
+// versions
+type packetIDs struct {
+	// Clientbound
+	disconnect, keepAlive, syncPosition, chunkBatchFinished int32
+	// Serverbound
+	confirmTeleport, chat, keepAliveReply, setPosition, chunkBatchReceived int32
+}
+
+// protocols lists the versions bots can play, by protocol number. Versions
+// before 1.20.2 have no chunk batches (-1).
+var protocols = map[int32]*packetIDs{
+	762: {0x1A, 0x23, 0x3C, -1, 0x00, 0x05, 0x12, 0x14, -1},     // 1.19.4
+	763: {0x1A, 0x23, 0x3C, -1, 0x00, 0x05, 0x12, 0x14, -1},     // 1.20, 1.20.1
+	764: {0x1B, 0x24, 0x3E, 0x0C, 0x00, 0x05, 0x14, 0x16, 0x07}, // 1.20.2
+	765: {0x1B, 0x24, 0x3E, 0x0C, 0x00, 0x05, 0x15, 0x17, 0x07}, // 1.20.3, 1.20.4
+	766: {0x1D, 0x26, 0x40, 0x0C, 0x00, 0x06, 0x18, 0x1A, 0x08}, // 1.20.5, 1.20.6
+	767: {0x1D, 0x26, 0x40, 0x0C, 0x00, 0x06, 0x18, 0x1A, 0x08}, // 1.21, 1.21.1
+	768: {0x1D, 0x27, 0x42, 0x0C, 0x00, 0x07, 0x1A, 0x1C, 0x09}, // 1.21.2, 1.21.3
+	769: {0x1D, 0x27, 0x42, 0x0C, 0x00, 0x07, 0x1A, 0x1C, 0x09}, // 1.21.4
+}
+
+// SupportedVersions describes the versions bots can join, for messages
+const SupportedVersions = "1.19.4 to 1.21.4"
+
+// Supported reports whether bots can join a server speaking protocol
+func Supported(protocol int32) bool {
+	return protocols[protocol] != nil
+}
+
+// Packets are at most this long, a bit above what vanilla accepts
+const maxPacket = 1 << 23
+
+// packet builds the body of an outgoing packet
+type packet struct {
+	bytes.Buffer
+}
+
+func newPacket(id int32) *packet {
+	p := &packet{}
+	p.varInt(id)
+	return p
+}
+
+func (p *packet) varInt(value int32) *packet {
+	v := uint32(value)
+	for v&^0x7F != 0 {
+		p.WriteByte(byte(v&0x7F | 0x80))
+		v >>= 7
+	}
+	p.WriteByte(byte(v))
+	return p
+}
+
+func (p *packet) str(s string) *packet {
+	p.varInt(int32(len(s)))
+	p.WriteString(s)
+	return p
+}
+
+func (p *packet) u16(v uint16) *packet {
+	binary.Write(p, binary.BigEndian, v)
+	return p
+}
+
+func (p *packet) i64(v int64) *packet {
+	binary.Write(p, binary.BigEndian, v)
+	return p
+}
+
+func (p *packet) f32(v float32) *packet {
+	binary.Write(p, binary.BigEndian, math.Float32bits(v))
+	return p
+}
+
+func (p *packet) f64(v float64) *packet {
+	binary.Write(p, binary.BigEndian, math.Float64bits(v))
+	return p
+}
+
+func (p *packet) boolean(v bool) *packet {
+	if v {
+		p.WriteByte(1)
+	} else {
+		p.WriteByte(0)
+	}
+	return p
+}
+
+func (p *packet) uuid(id [16]byte) *packet {
+	p.Write(id[:])
+	return p
+}
+
+// encode frames the packet, compressing it when compression is on and the
+// packet reaches the threshold (-1 when off)
+func (p *packet) encode(threshold int) []byte {
+	var body bytes.Buffer
+	switch {
+	case threshold < 0:
+		body.Write(p.Bytes())
+	case p.Len() < threshold:
+		body.WriteByte(0)
+		body.Write(p.Bytes())
+	default:
+		size := &packet{}
+		size.varInt(int32(p.Len()))
+		body.Write(size.Bytes())
+		zw := zlib.NewWriter(&body)
+		zw.Write(p.Bytes())
+		zw.Close()
+	}
+	frame := &packet{}
+	frame.varInt(int32(body.Len()))
+	frame.Write(body.Bytes())
+	return frame.Bytes()
+}
+
+// readPacket reads the next packet and returns its ID. The body is only
+// read, and decompressed, when want says the packet matters, so the chunks
+// and entities a bot has no use for cost little.
+func readPacket(r *bufio.Reader, threshold int, want func(id int32) bool) (int32, *bytes.Reader, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if length < 0 || length > maxPacket {
+		return 0, nil, fmt.Errorf("invalid packet length %d", length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, nil, err
+	}
+
+	body := bytes.NewReader(data)
+	if threshold >= 0 {
+		size, err := readVarInt(body)
+		if err != nil {
+			return 0, nil, err
+		}
+		if size != 0 {
+			zr, err := zlib.NewReader(body)
+			if err != nil {
+				return 0, nil, err
+			}
+			defer zr.Close()
+			br := bufio.NewReaderSize(zr, 16)
+			id, err := readVarInt(br)
+			if err != nil || !want(id) {
+				return id, nil, err
+			}
+			rest, err := io.ReadAll(br)
+			return id, bytes.NewReader(rest), err
+		}
+	}
+	id, err := readVarInt(body)
+	return id, body, err
+}
+
+func readVarInt(r io.ByteReader) (int32, error) {
+	var result uint32
+	for i := 0; i < 5; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		result |= uint32(b&0x7F) << (7 * i)
+		if b&0x80 == 0 {
+			return int32(result), nil
+		}
+	}
+	return 0, fmt.Errorf("varint too long")
+}
+
+func readString(r *bytes.Reader) (string, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return "", err
+	}
+	if length < 0 || int(length) > r.Len() {
+		return "", fmt.Errorf("invalid string length %d", length)
+	}
+	data := make([]byte, length)
+	_, err = io.ReadFull(r, data)
+	return string(data), err
+}
+
+func readI64(r *bytes.Reader) (int64, error) {
+	var v int64
+	err := binary.Read(r, binary.BigEndian, &v)
+	return v, err
+}
+
+func readF64(r *bytes.Reader) (float64, error) {
+	var v uint64
+	err := binary.Read(r, binary.BigEndian, &v)
+	return math.Float64frombits(v), err
+}
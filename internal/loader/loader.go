@@ -0,0 +1,242 @@
+// Package loader detects and installs the Forge and NeoForge mod loaders,
+// and plans migrations of a modded server from one to the other.
+package loader
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Loaders this package handles
+const (
+	Forge    = "forge"
+	NeoForge = "neoforge"
+)
+
+const (
+	forgeMaven      = "https://maven.minecraftforge.net/net/minecraftforge/forge"
+	forgePromotions = "https://files.minecraftforge.net/net/minecraftforge/forge/promotions_slim.json"
+	neoForgeMaven   = "https://maven.neoforged.net/releases/net/neoforged/neoforge"
+)
+
+// Library dirs each installer leaves in the server dir
+var libDirs = map[string]string{
+	Forge:    "libraries/net/minecraftforge/forge",
+	NeoForge: "libraries/net/neoforged/neoforge",
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Minute}
+
+// Info is an installed loader
+type Info struct {
+	Name      string
+	Version   string
+	MCVersion string
+}
+
+func (i *Info) String() string {
+	return fmt.Sprintf("%s %s (Minecraft %s)", i.Name, i.Version, i.MCVersion)
+}
+
+// Detect returns the loader installed in serverDir. Forge names its
+// library dirs "<mc>-<forge>", NeoForge only by its own version, which
+// encodes the Minecraft one.
+func Detect(serverDir string) (*Info, error) {
+	for _, name := range []string{NeoForge, Forge} {
+		entries, err := os.ReadDir(filepath.Join(serverDir, libDirs[name]))
+		if err != nil {
+			continue
+		}
+		for i := len(entries) - 1; i >= 0; i-- {
+			if !entries[i].IsDir() {
+				continue
+			}
+			version := entries[i].Name()
+			if name == Forge {
+				mc, forge, ok := strings.Cut(version, "-")
+				if ok {
+					return &Info{Name: Forge, Version: forge, MCVersion: mc}, nil
+				}
+				continue
+			}
+			if mc := neoForgeMC(version); mc != "" {
+				return &Info{Name: NeoForge, Version: version, MCVersion: mc}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no Forge or NeoForge install found in %s", serverDir)
+}
+
+// neoForgeMC maps a NeoForge version to its Minecraft version: 21.1.77 is
+// for 1.21.1, 21.0.167 for 1.21
+func neoForgeMC(version string) string {
+	parts := strings.Split(version, ".")
+	if len(parts) < 3 {
+		return ""
+	}
+	if parts[1] == "0" {
+		return "1." + parts[0]
+	}
+	return "1." + parts[0] + "." + parts[1]
+}
+
+// neoForgePrefix is the inverse of neoForgeMC, as a version prefix
+func neoForgePrefix(mcVersion string) (string, error) {
+	parts := strings.Split(mcVersion, ".")
+	if len(parts) < 2 || parts[0] != "1" {
+		return "", fmt.Errorf("unsupported Minecraft version %q", mcVersion)
+	}
+	minor := "0"
+	if len(parts) > 2 {
+		minor = parts[2]
+	}
+	return parts[1] + "." + minor + ".", nil
+}
+
+// LatestVersion returns the loader build to install for a Minecraft
+// version: Forge's recommended build, or else its latest, and NeoForge's
+// newest stable build, or else its newest beta.
+func LatestVersion(name, mcVersion string) (string, error) {
+	switch name {
+	case Forge:
+		var promos struct {
+			Promos map[string]string `json:"promos"`
+		}
+		if err := getJSON(forgePromotions, &promos); err != nil {
+			return "", fmt.Errorf("failed to fetch Forge promotions: %w", err)
+		}
+		for _, kind := range []string{"-recommended", "-latest"} {
+			if v, ok := promos.Promos[mcVersion+kind]; ok {
+				return v, nil
+			}
+		}
+		return "", fmt.Errorf("no Forge build for Minecraft %s", mcVersion)
+
+	case NeoForge:
+		prefix, err := neoForgePrefix(mcVersion)
+		if err != nil {
+			return "", err
+		}
+		versions, err := neoForgeVersions()
+		if err != nil {
+			return "", err
+		}
+		var stable, beta []string
+		for _, v := range versions {
+			switch {
+			case !strings.HasPrefix(v, prefix):
+			case strings.Contains(v, "-"):
+				beta = append(beta, v)
+			default:
+				stable = append(stable, v)
+			}
+		}
+		for _, list := range [][]string{stable, beta} {
+			if len(list) > 0 {
+				sort.Slice(list, func(i, j int) bool { return buildNumber(list[i]) < buildNumber(list[j]) })
+				return list[len(list)-1], nil
+			}
+		}
+		return "", fmt.Errorf("no NeoForge build for Minecraft %s", mcVersion)
+	}
+	return "", fmt.Errorf("unsupported mod loader: %s", name)
+}
+
+func neoForgeVersions() ([]string, error) {
+	resp, err := httpClient.Get(neoForgeMaven + "/maven-metadata.xml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch NeoForge versions: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("NeoForge maven returned status %d", resp.StatusCode)
+	}
+
+	var metadata struct {
+		Versions []string `xml:"versioning>versions>version"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse NeoForge versions: %w", err)
+	}
+	return metadata.Versions, nil
+}
+
+// buildNumber is the last numeric part of a NeoForge version
+func buildNumber(version string) int {
+	version, _, _ = strings.Cut(version, "-")
+	n, _ := strconv.Atoi(version[strings.LastIndex(version, ".")+1:])
+	return n
+}
+
+// Install downloads the loader's installer and runs it against dir, which
+// leaves the libraries and run scripts the server launches with
+func Install(info *Info, dir, javaPath string) error {
+	var url string
+	switch info.Name {
+	case Forge:
+		v := info.MCVersion + "-" + info.Version
+		url = fmt.Sprintf("%s/%s/forge-%s-installer.jar", forgeMaven, v, v)
+	case NeoForge:
+		url = fmt.Sprintf("%s/%s/neoforge-%s-installer.jar", neoForgeMaven, info.Version, info.Version)
+	default:
+		return fmt.Errorf("unsupported mod loader: %s", info.Name)
+	}
+
+	installer := filepath.Join(dir, info.Name+"-installer.jar")
+	if err := download(url, installer); err != nil {
+		return fmt.Errorf("failed to download the %s installer: %w", info.Name, err)
+	}
+	defer os.Remove(installer)
+
+	cmd := exec.Command(javaPath, "-jar", filepath.Base(installer), "--installServer")
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+		return fmt.Errorf("%s installer failed: %w: %s", info.Name, err, lines[len(lines)-1])
+	}
+	os.Remove(installer + ".log")
+	return nil
+}
+
+func getJSON(url string, v any) error {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request returned status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func download(url, dest string) error {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download returned status %d", resp.StatusCode)
+	}
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, resp.Body)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,250 @@
+package loader
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"mcserver-manager/internal/curseforge"
+	"mcserver-manager/internal/modrinth"
+)
+
+// configDirs are copied from the old server to the migrated one. Worlds
+// and state files are moved by the directory swap instead.
+var configDirs = []string{
+	"config",
+	"defaultconfigs",
+	"kubejs",
+	"scripts",
+}
+
+// Sources a replacement mod is found on
+const (
+	SourceModrinth   = "modrinth"
+	SourceCurseForge = "curseforge"
+)
+
+// Mod is an installed mod jar and its counterpart for the target loader
+type Mod struct {
+	File        string // jar in mods/
+	Replacement string // counterpart's file name, "" if there is none
+	Source      string
+
+	modrinth          *modrinth.File
+	projectID, fileID int
+}
+
+// Plan is a loader migration worked out against a server dir
+type Plan struct {
+	From Info
+	To   Info
+	Mods []Mod
+}
+
+// Missing returns the mods with no counterpart for the target loader
+func (p *Plan) Missing() []string {
+	var missing []string
+	for _, mod := range p.Mods {
+		if mod.Replacement == "" {
+			missing = append(missing, mod.File)
+		}
+	}
+	return missing
+}
+
+// NewPlan looks up, for every jar in serverDir's mods folder, the same
+// project's newest build for target on the installed Minecraft version.
+// Jars are identified on Modrinth by hash first and on CurseForge by
+// fingerprint for the rest. An empty version picks the loader's latest.
+func NewPlan(serverDir, target, version string) (*Plan, error) {
+	if _, ok := libDirs[target]; !ok {
+		return nil, fmt.Errorf("unsupported mod loader: %s", target)
+	}
+	from, err := Detect(serverDir)
+	if err != nil {
+		return nil, err
+	}
+	if from.Name == target {
+		return nil, fmt.Errorf("the server already runs %s", from)
+	}
+	if version == "" {
+		if version, err = LatestVersion(target, from.MCVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	plan := &Plan{From: *from, To: Info{Name: target, Version: version, MCVersion: from.MCVersion}}
+	jars, err := filepath.Glob(filepath.Join(serverDir, "mods", "*.jar"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(jars)
+	if len(jars) == 0 {
+		return plan, nil
+	}
+
+	hashes := make(map[string]string, len(jars))
+	var hashList []string
+	for _, jar := range jars {
+		hash, err := sha1File(jar)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %s: %w", filepath.Base(jar), err)
+		}
+		hashes[jar] = hash
+		hashList = append(hashList, hash)
+	}
+	updates, err := modrinth.NewClient().UpdatesByHash(hashList, target, from.MCVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up mods on Modrinth: %w", err)
+	}
+
+	var unmatched []string
+	for _, jar := range jars {
+		mod := Mod{File: filepath.Base(jar)}
+		if update, ok := updates[hashes[jar]]; ok {
+			if file := update.PrimaryFile(); file != nil {
+				mod.Replacement, mod.Source, mod.modrinth = file.Filename, SourceModrinth, file
+			}
+		}
+		if mod.Replacement == "" {
+			unmatched = append(unmatched, jar)
+		}
+		plan.Mods = append(plan.Mods, mod)
+	}
+	if len(unmatched) > 0 {
+		if err := plan.matchCurseForge(unmatched); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// matchCurseForge fills in counterparts for jars Modrinth did not know
+func (p *Plan) matchCurseForge(jars []string) error {
+	cf := curseforge.NewClient()
+	fingerprints := make(map[string]uint32, len(jars))
+	var fpList []uint32
+	for _, jar := range jars {
+		fp, err := curseforge.Fingerprint(jar)
+		if err != nil {
+			return fmt.Errorf("failed to fingerprint %s: %w", filepath.Base(jar), err)
+		}
+		fingerprints[filepath.Base(jar)] = fp
+		fpList = append(fpList, fp)
+	}
+	matches, err := cf.MatchFingerprints(fpList)
+	if err != nil {
+		return fmt.Errorf("failed to look up mods on CurseForge: %w", err)
+	}
+
+	for i := range p.Mods {
+		mod := &p.Mods[i]
+		fp, ok := fingerprints[mod.File]
+		if !ok {
+			continue
+		}
+		match, ok := matches[fp]
+		if !ok {
+			continue
+		}
+		file, err := cf.LatestModFile(match.ProjectID, p.To.Name, p.To.MCVersion)
+		if err != nil {
+			continue
+		}
+		mod.Replacement, mod.Source = file.FileName, SourceCurseForge
+		mod.projectID, mod.fileID = match.ProjectID, file.ID
+	}
+	return nil
+}
+
+// Build sets up dir as the migrated server: the target loader, serverDir's
+// config folders and the counterpart of every mod that has one
+func (p *Plan) Build(serverDir, dir, javaPath string) error {
+	if err := Install(&p.To, dir, javaPath); err != nil {
+		return err
+	}
+
+	for _, rel := range configDirs {
+		src := filepath.Join(serverDir, rel)
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		if err := copyTree(src, filepath.Join(dir, rel)); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", rel, err)
+		}
+	}
+
+	modsDir := filepath.Join(dir, "mods")
+	if err := os.MkdirAll(modsDir, 0755); err != nil {
+		return err
+	}
+	mr, cf := modrinth.NewClient(), curseforge.NewClient()
+	for _, mod := range p.Mods {
+		var err error
+		switch mod.Source {
+		case SourceModrinth:
+			err = mr.Download(mod.modrinth, filepath.Join(modsDir, mod.Replacement))
+		case SourceCurseForge:
+			err = cf.DownloadMod(mod.projectID, mod.fileID, modsDir)
+		default:
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to download %s: %w", mod.Replacement, err)
+		}
+	}
+	return nil
+}
+
+func sha1File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha1.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// copyTree copies the regular files under src to dst
+func copyTree(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(out, in)
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	})
+}
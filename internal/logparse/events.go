@@ -0,0 +1,73 @@
+package logparse
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// EventRulesFile holds user-defined event patterns, relative to the server dir
+const EventRulesFile = ".mcserver/event-patterns.json"
+
+// EventRule turns matching console lines into events of a given type
+type EventRule struct {
+	Pattern *regexp.Regexp
+	Type    string // info, warning, error, join, leave, chat, command, backup, restart or custom
+	Label   string // shown before custom events, e.g. "BOSS"
+	Message string // template with $1 / ${name} groups; the whole line if empty
+}
+
+type eventRuleFile struct {
+	Pattern string `json:"pattern"`
+	Type    string `json:"type"`
+	Label   string `json:"label"`
+	Message string `json:"message"`
+}
+
+// LoadEventRules reads EventRulesFile. A missing file means no rules.
+func LoadEventRules(serverDir string) ([]EventRule, error) {
+	data, err := os.ReadFile(filepath.Join(serverDir, EventRulesFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read event patterns: %w", err)
+	}
+
+	var entries []eventRuleFile
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", EventRulesFile, err)
+	}
+
+	rules := make([]EventRule, 0, len(entries))
+	for i, e := range entries {
+		re, err := regexp.Compile(e.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s entry %d: invalid pattern: %w", EventRulesFile, i+1, err)
+		}
+		if e.Type == "" {
+			e.Type = "custom"
+		}
+		rules = append(rules, EventRule{Pattern: re, Type: e.Type, Label: e.Label, Message: e.Message})
+	}
+	return rules, nil
+}
+
+// Match returns the event message for line, or false if the rule does not
+// apply
+func (r *EventRule) Match(line string) (string, bool) {
+	match := r.Pattern.FindStringSubmatchIndex(line)
+	if match == nil {
+		return "", false
+	}
+	message := line
+	if r.Message != "" {
+		message = string(r.Pattern.ExpandString(nil, r.Message, line, match))
+	}
+	if r.Label != "" {
+		message = "[" + r.Label + "] " + message
+	}
+	return message, true
+}
@@ -0,0 +1,367 @@
+package logparse
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"sort"
+
+	"mcserver-manager/internal/servertype"
+)
+
+// CustomProfileFile holds user-provided patterns, relative to the server dir
+const CustomProfileFile = ".mcserver/log-profile.json"
+
+// Profile is the set of patterns used to read one server type's console.
+// Only the line prefix really differs between loaders; the message text
+// comes from Minecraft itself.
+type Profile struct {
+	Name string
+
+	// PlayerName matches exactly one player name, for checking names
+	// given to the manager rather than read from the console
+	PlayerName *regexp.Regexp
+
+	Done       *regexp.Regexp
+	Join       *regexp.Regexp // group 1: player
+	Leave      *regexp.Regexp // group 1: player
+	Chat       *regexp.Regexp // group 1: player, group 2: message
+	PlayerList *regexp.Regexp // group 1: online, group 2: max
+	UUID       *regexp.Regexp // group 1: player, group 2: uuid
+	IP         *regexp.Regexp // group 1: player, group 2: address
+	// A client that got as far as logging in: "Steve[/1.2.3.4:5555]
+	// logged in" when accepted, "Steve (/1.2.3.4:5555) lost connection"
+	// (a GameProfile dump before 1.20.2) when refused; group 1: address
+	Connection *regexp.Regexp
+	// Geyser reports Bedrock players itself; group 1 is the Bedrock
+	// gamertag, group 2 on joins the Java-side name
+	GeyserJoin  *regexp.Regexp
+	GeyserLeave *regexp.Regexp
+	TPS         *regexp.Regexp // group 1: ticks per second
+	MSPT        *regexp.Regexp // group 1: milliseconds per tick
+	Warn        *regexp.Regexp
+	Error       *regexp.Regexp
+
+	// Private messages, as servers that log player commands (Paper,
+	// Spigot) show them; group 1: sender, group 2: recipient, group 3:
+	// message
+	Whisper *regexp.Regexp
+	// "/me" emotes, broadcast like chat; group 1: player, group 2: action
+	Emote *regexp.Regexp
+	// Vanilla death messages; group 1: player, group 2: the rest, e.g.
+	// "was slain by Zombie". Only lines about online players are deaths.
+	Death *regexp.Regexp
+
+	// Console commands that make the server print TPS/MSPT
+	TPSCommands []string
+	// TPS is derived from MSPT (vanilla "tick query" reports only MSPT)
+	TPSFromMSPT bool
+}
+
+// deathPattern matches what follows the player's name in vanilla death
+// messages
+const deathPattern = `((?:was|were) (?:slain|shot|killed|blown up|pricked|squashed|squished|impaled|fireballed|struck by lightning|poked|stung|obliterated|skewered|roasted|frozen|doomed|pummeled|burnt|burned|knocked|pierced)\b.*` +
+	`|(?:drowned|died|blew up|hit the ground too hard|fell|went up in flames|went off with a bang|burned to death|walked into|tried to swim in lava|suffocated|starved to death|froze to death|experienced kinetic energy|discovered the floor was lava|withered away|left the confines of this world|didn't want to live)\b.*)`
+
+// DefaultNamePattern matches one player name: up to 16 letters, digits
+// and underscores, as Java accounts have, optionally after Floodgate's
+// "." prefix for Bedrock players. Offline-mode or modded servers that
+// allow other names need --player-name-pattern.
+const DefaultNamePattern = `\.?[A-Za-z0-9_]{1,16}`
+
+// patterns are the per-loader parts of a profile; the message patterns are
+// shared and filled in by build
+type patterns struct {
+	// head matches a line from its start up to the logger's ": ", e.g.
+	// "[12:00:00] [Server thread/INFO]". It must not be able to reach past
+	// that first "]: ", or a player could write a line of their own into
+	// the chat message that follows.
+	head string
+	tps  string
+	mspt string
+
+	// Console commands that make the server print TPS/MSPT
+	tpsCommands []string
+	tpsFromMSPT bool
+}
+
+// mojangHead is the "[12:00:00] [Server thread/INFO]" head of Mojang's
+// log4j layout. Chat and logins are logged from other threads too, so any
+// thread at INFO level is accepted.
+const mojangHead = `\[[^\]]+\] \[[^\]]+/INFO\]`
+
+var profiles = map[string]patterns{
+	// [12:00:00] [Server thread/INFO]: Steve joined the game
+	"vanilla": {
+		head:        mojangHead,
+		mspt:        `Average time per tick: ([\d.]+) ?ms`,
+		tpsCommands: []string{"tick query"},
+		tpsFromMSPT: true,
+	},
+
+	// Same layout as vanilla, Fabric keeps Mojang's log4j config
+	"fabric": {
+		head:        mojangHead,
+		mspt:        `Average time per tick: ([\d.]+) ?ms`,
+		tpsCommands: []string{"tick query"},
+		tpsFromMSPT: true,
+	},
+
+	// [12:00:00] [Server thread/INFO] [minecraft/MinecraftServer]: Steve joined the game
+	"forge": {
+		head:        mojangHead + `(?: \[[^\]]+\])?`,
+		tps:         `Mean TPS: ([\d.]+)`,
+		mspt:        `Mean tick time: ([\d.]+) ms`,
+		tpsCommands: []string{"forge tps"},
+	},
+
+	// [12:00:00 INFO]: Steve joined the game on the console, Mojang's
+	// layout in logs/latest.log
+	"paper": {
+		head:        `(?:\[[^\]]+ INFO\]|` + mojangHead + `)`,
+		tps:         `TPS from last 1m, 5m, 15m: \*?([\d.]+)`,
+		mspt:        `◴ ([\d.]+)/[\d.]+/[\d.]+`,
+		tpsCommands: []string{"tps", "mspt"},
+	},
+}
+
+// ValidateNamePattern checks a player-name pattern can be embedded in the
+// profile regexes: it must compile, must not add capturing groups, which
+// would shift the groups the parser reads, and must not contain anchors or
+// match whitespace, which would let a name run into the text around it
+func ValidateNamePattern(names string) error {
+	re, err := regexp.Compile(names)
+	if err != nil {
+		return fmt.Errorf("invalid player name pattern: %w", err)
+	}
+	if re.NumSubexp() > 0 {
+		return fmt.Errorf("player name pattern must not contain capturing groups, use (?:...) instead")
+	}
+	if re.MatchString("") {
+		return fmt.Errorf("player name pattern must not match an empty name")
+	}
+	parsed, err := syntax.Parse(names, syntax.Perl)
+	if err != nil {
+		return fmt.Errorf("invalid player name pattern: %w", err)
+	}
+	return checkNameSyntax(parsed)
+}
+
+// checkNameSyntax rejects anchors and anything that can match whitespace,
+// such as \s, a space, "." or a negated class like [^<>] that lets spaces
+// through
+func checkNameSyntax(re *syntax.Regexp) error {
+	switch re.Op {
+	case syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText,
+		syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return fmt.Errorf("player name pattern must not contain anchors (^, $, \\A, \\z, \\b)")
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return fmt.Errorf("player name pattern must not contain \".\", which matches spaces; list the allowed characters instead")
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			if slices.Contains(whitespace, r) {
+				return fmt.Errorf("player name pattern must not match whitespace")
+			}
+		}
+	case syntax.OpCharClass:
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			for _, r := range whitespace {
+				if r >= re.Rune[i] && r <= re.Rune[i+1] {
+					return fmt.Errorf("player name pattern must not match whitespace")
+				}
+			}
+		}
+	}
+	for _, sub := range re.Sub {
+		if err := checkNameSyntax(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// whitespace is what \s matches, the spaces a console line separates words
+// with
+var whitespace = []rune{'\t', '\n', '\f', '\r', ' '}
+
+func build(name string, p patterns, names string) (*Profile, error) {
+	if names == "" {
+		names = DefaultNamePattern
+	}
+	if err := ValidateNamePattern(names); err != nil {
+		return nil, err
+	}
+
+	player := `((?:` + names + `))`
+	// Bedrock display names may contain spaces, which Floodgate turns into
+	// underscores for the Java name
+	gamertag := `((?:` + names + `)(?: (?:` + names + `))*)`
+	// Every pattern starts right after the logger, so text a player wrote
+	// can never be read as a line of its own
+	info := `^` + p.head + `: `
+	// Plugins and mods such as Geyser log under their own "[Geyser-Spigot]"
+	// tag
+	plugin := `^` + p.head + `(?: \[[^\]]+\])?: `
+	// IPv4, or bracketed IPv6 as proxies forward it
+	address := `(\d+\.\d+\.\d+\.\d+|\[[0-9a-fA-F:.]+\])`
+	profile := &Profile{
+		Name:        name,
+		PlayerName:  regexp.MustCompile(`^(?:` + names + `)$`),
+		Done:        regexp.MustCompile(info + `Done \([\d.]+s\)! For help, type "help"`),
+		Join:        regexp.MustCompile(info + player + ` joined the game$`),
+		Leave:       regexp.MustCompile(info + player + ` left the game$`),
+		Chat:        regexp.MustCompile(info + `(?:\[Not Secure\] )?<` + player + `> (.+)$`),
+		Whisper:     regexp.MustCompile(info + player + ` issued server command: /(?:minecraft:)?(?:msg|tell|w|whisper) (\S+) (.+)$`),
+		Emote:       regexp.MustCompile(info + `(?:\[Not Secure\] )?\* ` + player + ` (.+)$`),
+		Death:       regexp.MustCompile(info + player + ` ` + deathPattern + `$`),
+		PlayerList:  regexp.MustCompile(info + `There are (\d+) of a max of (\d+) players online`),
+		UUID:        regexp.MustCompile(info + `UUID of player ` + player + ` is ([a-f0-9-]+)$`),
+		IP:          regexp.MustCompile(info + player + `\[/` + address + `:\d+\] logged in`),
+		Connection:  regexp.MustCompile(info + `[^ <*[]\S*(?:\[| \()/` + address + `:\d+[\])] (?:logged in|lost connection)`),
+		GeyserJoin:  regexp.MustCompile(plugin + gamertag + ` \(logged in as: ` + player + `\) has connected to the Java server$`),
+		GeyserLeave: regexp.MustCompile(plugin + gamertag + ` has disconnected from the Java server$`),
+		Warn:        regexp.MustCompile(`WARN\]`),
+		Error:       regexp.MustCompile(`ERROR\]`),
+		TPSCommands: p.tpsCommands,
+		TPSFromMSPT: p.tpsFromMSPT,
+	}
+	if p.tps != "" {
+		profile.TPS = regexp.MustCompile(p.tps)
+	}
+	if p.mspt != "" {
+		profile.MSPT = regexp.MustCompile(p.mspt)
+	}
+	return profile, nil
+}
+
+// Names returns the built-in profile names, plus "auto" and "custom"
+func Names() []string {
+	names := []string{"auto", "custom"}
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names[2:])
+	return names
+}
+
+// Select resolves a profile name for a server directory: "auto" detects
+// the server type, "custom" loads CustomProfileFile. names is the
+// player-name pattern, DefaultNamePattern if empty.
+func Select(name, serverDir, names string) (*Profile, error) {
+	switch name {
+	case "", "auto":
+		return ForServer(servertype.Detect(serverDir), names)
+	case "custom":
+		return LoadCustom(serverDir, names)
+	}
+	if p, ok := profiles[name]; ok {
+		return build(name, p, names)
+	}
+	return nil, fmt.Errorf("unknown log profile %q", name)
+}
+
+// Detect guesses the profile from the files in the server directory
+func Detect(serverDir string) string {
+	return servertype.Detect(serverDir).Family()
+}
+
+// ForServer builds the profile for detected server software, with the TPS
+// commands its version has
+func ForServer(info *servertype.Info, names string) (*Profile, error) {
+	family := info.Family()
+	p := profiles[family]
+	switch {
+	case info.Type == servertype.NeoForge && servertype.Compare(info.MCVersion, "1.20.2") >= 0:
+		// NeoForge renamed its commands, and the summary reads
+		// "Overall: 20.000 TPS (1.234 ms/tick)"
+		p.tpsCommands = []string{"neoforge tps"}
+		p.tps = `(?:Mean TPS: |Overall: )([\d.]+)`
+		p.mspt = `(?:Mean tick time: |Overall: [\d.]+ TPS \()([\d.]+) ms`
+	case info.Type == servertype.Spigot:
+		// mspt is a Paper command
+		p.tpsCommands = []string{"tps"}
+	case (family == "vanilla" || family == "fabric") && info.MCVersion != "" && servertype.Compare(info.MCVersion, "1.20.3") < 0:
+		// tick query arrived in 1.20.3; older servers only answer it with
+		// an error
+		p.tpsCommands = nil
+	}
+	return build(family, p, names)
+}
+
+// customFile is the JSON layout of CustomProfileFile. Empty fields keep
+// the base profile's pattern.
+type customFile struct {
+	Base        string   `json:"base"`
+	Join        string   `json:"join"`
+	Leave       string   `json:"leave"`
+	Chat        string   `json:"chat"`
+	Whisper     string   `json:"whisper"`
+	Emote       string   `json:"emote"`
+	Death       string   `json:"death"`
+	Done        string   `json:"done"`
+	TPS         string   `json:"tps"`
+	MSPT        string   `json:"mspt"`
+	Warn        string   `json:"warn"`
+	Error       string   `json:"error"`
+	TPSCommands []string `json:"tpsCommands"`
+}
+
+// LoadCustom reads CustomProfileFile on top of its base profile
+func LoadCustom(serverDir, names string) (*Profile, error) {
+	data, err := os.ReadFile(filepath.Join(serverDir, CustomProfileFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read custom log profile: %w", err)
+	}
+	var file customFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", CustomProfileFile, err)
+	}
+
+	baseName := file.Base
+	if baseName == "" || baseName == "auto" {
+		baseName = Detect(serverDir)
+	}
+	base, ok := profiles[baseName]
+	if !ok {
+		return nil, fmt.Errorf("unknown base profile %q in %s", file.Base, CustomProfileFile)
+	}
+	profile, err := build("custom", base, names)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := []struct {
+		pattern string
+		target  **regexp.Regexp
+	}{
+		{file.Join, &profile.Join},
+		{file.Leave, &profile.Leave},
+		{file.Chat, &profile.Chat},
+		{file.Whisper, &profile.Whisper},
+		{file.Emote, &profile.Emote},
+		{file.Death, &profile.Death},
+		{file.Done, &profile.Done},
+		{file.TPS, &profile.TPS},
+		{file.MSPT, &profile.MSPT},
+		{file.Warn, &profile.Warn},
+		{file.Error, &profile.Error},
+	}
+	for _, o := range overrides {
+		if o.pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(o.pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q in %s: %w", o.pattern, CustomProfileFile, err)
+		}
+		*o.target = re
+	}
+	if file.TPSCommands != nil {
+		profile.TPSCommands = file.TPSCommands
+	}
+	return profile, nil
+}
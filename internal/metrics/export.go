@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Columns are the exported fields, in order
+var Columns = []string{"time", "tps", "mspt", "memory_used", "memory_max", "cpu_percent", "players"}
+
+// WriteCSV writes the samples as CSV with a header row, times in RFC 3339
+// and memory in bytes
+func WriteCSV(w io.Writer, samples []Sample) error {
+	cw := csv.NewWriter(w)
+	cw.Write(Columns)
+	for _, s := range samples {
+		cw.Write([]string{
+			s.Time.Format(time.RFC3339),
+			strconv.FormatFloat(s.TPS, 'f', 2, 64),
+			strconv.FormatFloat(s.MSPT, 'f', 2, 64),
+			strconv.FormatUint(s.MemoryUsed, 10),
+			strconv.FormatUint(s.MemoryMax, 10),
+			strconv.FormatFloat(s.CPUPercent, 'f', 1, 64),
+			strconv.Itoa(s.Players),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteParquet writes the samples as a Parquet file with one row group,
+// times as UTC millisecond timestamps
+func WriteParquet(w io.Writer, samples []Sample) error {
+	columns := []parquetColumn{
+		{name: "time", kind: parquetTimestamp},
+		{name: "tps", kind: parquetDouble},
+		{name: "mspt", kind: parquetDouble},
+		{name: "memory_used", kind: parquetInt64},
+		{name: "memory_max", kind: parquetInt64},
+		{name: "cpu_percent", kind: parquetDouble},
+		{name: "players", kind: parquetInt32},
+	}
+	for _, s := range samples {
+		columns[0].add(uint64(s.Time.UnixMilli()))
+		columns[1].addFloat(s.TPS)
+		columns[2].addFloat(s.MSPT)
+		columns[3].add(s.MemoryUsed)
+		columns[4].add(s.MemoryMax)
+		columns[5].addFloat(s.CPUPercent)
+		columns[6].add(uint64(s.Players))
+	}
+	if err := writeParquet(w, columns, len(samples)); err != nil {
+		return fmt.Errorf("failed to write parquet: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Dir holds the metrics history, one JSON lines file per day, relative to
+// the server dir
+const Dir = ".mcserver/metrics"
+
+const dayFormat = "2006-01-02"
+
+// Sample is one row of the metrics history
+type Sample struct {
+	Time       time.Time `json:"time"`
+	TPS        float64   `json:"tps"`
+	MSPT       float64   `json:"mspt"`
+	MemoryUsed uint64    `json:"memory_used"`
+	MemoryMax  uint64    `json:"memory_max"`
+	CPUPercent float64   `json:"cpu_percent"`
+	Players    int       `json:"players"`
+}
+
+// Append adds a sample to the file of its day
+func Append(serverDir string, sample Sample) error {
+	dir := filepath.Join(serverDir, Dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to record metrics: %w", err)
+	}
+	path := filepath.Join(dir, sample.Time.Format(dayFormat)+".jsonl")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to record metrics: %w", err)
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(sample)
+}
+
+// Read returns the samples from from to to, oldest first. A zero from
+// starts at the oldest sample, a zero to ends at the newest.
+func Read(serverDir string, from, to time.Time) ([]Sample, error) {
+	days, err := dayFiles(serverDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []Sample
+	for _, day := range days {
+		// A day's file holds samples from its midnight to the next, give or
+		// take a time zone change
+		if (!from.IsZero() && day.date.AddDate(0, 0, 2).Before(from)) || (!to.IsZero() && day.date.AddDate(0, 0, -1).After(to)) {
+			continue
+		}
+		f, err := os.Open(day.path)
+		if err != nil {
+			return nil, err
+		}
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			var sample Sample
+			if json.Unmarshal(sc.Bytes(), &sample) != nil {
+				continue
+			}
+			if (from.IsZero() || !sample.Time.Before(from)) && (to.IsZero() || !sample.Time.After(to)) {
+				samples = append(samples, sample)
+			}
+		}
+		f.Close()
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].Time.Before(samples[j].Time) })
+	return samples, nil
+}
+
+// Prune removes the files of days more than keepDays ago
+func Prune(serverDir string, keepDays int) error {
+	days, err := dayFiles(serverDir)
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().AddDate(0, 0, -keepDays)
+	for _, day := range days {
+		if day.date.AddDate(0, 0, 1).Before(cutoff) {
+			if err := os.Remove(day.path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type dayFile struct {
+	date time.Time
+	path string
+}
+
+// dayFiles lists the history files, oldest first
+func dayFiles(serverDir string) ([]dayFile, error) {
+	dir := filepath.Join(serverDir, Dir)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metrics history: %w", err)
+	}
+	var days []dayFile
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".jsonl")
+		if !ok {
+			continue
+		}
+		date, err := time.ParseInLocation(dayFormat, name, time.Local)
+		if err != nil {
+			continue
+		}
+		days = append(days, dayFile{date: date, path: filepath.Join(dir, e.Name())})
+	}
+	// ReadDir sorts by name, which for these names is by date
+	return days, nil
+}
@@ -0,0 +1,264 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// A minimal Parquet writer: flat required columns, one row group, one
+// uncompressed PLAIN data page per column. See
+// https://github.com/apache/parquet-format for the layout and the Thrift
+// definitions of the metadata.
+
+const parquetMagic = "PAR1"
+
+type parquetKind int
+
+const (
+	parquetInt32 parquetKind = iota
+	parquetInt64
+	parquetDouble
+	parquetTimestamp // INT64 milliseconds since the epoch, UTC
+)
+
+// Parquet physical types, converted types and enums used below
+const (
+	typeInt32  = 1
+	typeInt64  = 2
+	typeDouble = 5
+
+	convertedTimestampMillis = 9
+
+	repetitionRequired = 0
+	encodingPlain      = 0
+	encodingRLE        = 3
+	pageData           = 0
+	codecUncompressed  = 0
+)
+
+type parquetColumn struct {
+	name string
+	kind parquetKind
+	data bytes.Buffer
+}
+
+// add appends an integer value, or a float's bits
+func (c *parquetColumn) add(v uint64) {
+	if c.kind == parquetInt32 {
+		c.data.Write(binary.LittleEndian.AppendUint32(nil, uint32(v)))
+		return
+	}
+	c.data.Write(binary.LittleEndian.AppendUint64(nil, v))
+}
+
+func (c *parquetColumn) addFloat(v float64) {
+	c.add(math.Float64bits(v))
+}
+
+func (c *parquetColumn) physicalType() int32 {
+	switch c.kind {
+	case parquetInt32:
+		return typeInt32
+	case parquetDouble:
+		return typeDouble
+	}
+	return typeInt64
+}
+
+// writeParquet writes the columns, each holding rows values, as a file
+func writeParquet(w io.Writer, columns []parquetColumn, rows int) error {
+	var file bytes.Buffer
+	file.WriteString(parquetMagic)
+
+	chunks := make([][]byte, len(columns))
+	var total int64
+	for i := range columns {
+		c := &columns[i]
+		offset := int64(file.Len())
+
+		var header thriftWriter
+		header.i32(1, pageData)
+		header.i32(2, int32(c.data.Len()))
+		header.i32(3, int32(c.data.Len()))
+		header.beginStruct(5)
+		header.i32(1, int32(rows))
+		header.i32(2, encodingPlain)
+		header.i32(3, encodingRLE)
+		header.i32(4, encodingRLE)
+		header.endStruct()
+		header.stop()
+		file.Write(header.buf.Bytes())
+		file.Write(c.data.Bytes())
+		size := int64(file.Len()) - offset
+		total += size
+
+		var chunk thriftWriter
+		chunk.i64(2, offset)
+		chunk.beginStruct(3)
+		chunk.i32(1, c.physicalType())
+		chunk.listI32(2, encodingPlain)
+		chunk.listString(3, c.name)
+		chunk.i32(4, codecUncompressed)
+		chunk.i64(5, int64(rows))
+		chunk.i64(6, size)
+		chunk.i64(7, size)
+		chunk.i64(9, offset)
+		chunk.endStruct()
+		chunk.stop()
+		chunks[i] = chunk.buf.Bytes()
+	}
+
+	var meta thriftWriter
+	meta.i32(1, 1)
+	meta.beginList(2, len(columns)+1)
+	meta.beginElement()
+	meta.string(4, "schema")
+	meta.i32(5, int32(len(columns)))
+	meta.endElement()
+	for i := range columns {
+		c := &columns[i]
+		meta.beginElement()
+		meta.i32(1, c.physicalType())
+		meta.i32(3, repetitionRequired)
+		meta.string(4, c.name)
+		if c.kind == parquetTimestamp {
+			meta.i32(6, convertedTimestampMillis)
+		}
+		meta.endElement()
+	}
+	meta.i64(3, int64(rows))
+	meta.beginList(4, 1)
+	meta.beginElement()
+	meta.beginList(1, len(columns))
+	for _, chunk := range chunks {
+		meta.raw(chunk)
+	}
+	meta.i64(2, total)
+	meta.i64(3, int64(rows))
+	meta.endElement()
+	meta.string(6, "mcserver")
+	meta.stop()
+
+	file.Write(meta.buf.Bytes())
+	file.Write(binary.LittleEndian.AppendUint32(nil, uint32(meta.buf.Len())))
+	file.WriteString(parquetMagic)
+	_, err := w.Write(file.Bytes())
+	return err
+}
+
+// thriftWriter encodes with Thrift's compact protocol, which Parquet uses
+// for its metadata
+type thriftWriter struct {
+	buf  bytes.Buffer
+	last []int16 // previous field id of each open struct
+}
+
+// Compact protocol type ids
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	if len(t.last) == 0 {
+		t.last = append(t.last, 0)
+	}
+	last := &t.last[len(t.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(uint64(zigzag(int64(id))))
+	}
+	*last = id
+}
+
+func (t *thriftWriter) varint(v uint64) {
+	t.buf.Write(binary.AppendUvarint(nil, v))
+}
+
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(zigzag(int64(v)))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(zigzag(v))
+}
+
+func (t *thriftWriter) string(id int16, v string) {
+	t.field(id, thriftBinary)
+	t.varint(uint64(len(v)))
+	t.buf.WriteString(v)
+}
+
+func (t *thriftWriter) beginStruct(id int16) {
+	t.field(id, thriftStruct)
+	t.last = append(t.last, 0)
+}
+
+func (t *thriftWriter) endStruct() {
+	t.stop()
+	t.last = t.last[:len(t.last)-1]
+}
+
+// stop ends the outermost struct
+func (t *thriftWriter) stop() {
+	t.buf.WriteByte(0)
+}
+
+func (t *thriftWriter) listHeader(size int, elem byte) {
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elem)
+		return
+	}
+	t.buf.WriteByte(0xf0 | elem)
+	t.varint(uint64(size))
+}
+
+// beginList starts a list of structs, each written between beginElement
+// and endElement
+func (t *thriftWriter) beginList(id int16, size int) {
+	t.field(id, thriftList)
+	t.listHeader(size, thriftStruct)
+}
+
+func (t *thriftWriter) beginElement() {
+	t.last = append(t.last, 0)
+}
+
+func (t *thriftWriter) endElement() {
+	t.endStruct()
+}
+
+func (t *thriftWriter) listI32(id int16, values ...int32) {
+	t.field(id, thriftList)
+	t.listHeader(len(values), thriftI32)
+	for _, v := range values {
+		t.varint(zigzag(int64(v)))
+	}
+}
+
+func (t *thriftWriter) listString(id int16, values ...string) {
+	t.field(id, thriftList)
+	t.listHeader(len(values), thriftBinary)
+	for _, v := range values {
+		t.varint(uint64(len(v)))
+		t.buf.WriteString(v)
+	}
+}
+
+// raw appends a struct encoded by another writer, as a list element
+func (t *thriftWriter) raw(encoded []byte) {
+	t.buf.Write(encoded)
+}
@@ -0,0 +1,183 @@
+// Package modinfo reads the id, name and version mods and plugins declare
+// in their jars: fabric.mod.json, quilt.mod.json, the Forge and NeoForge
+// mods.toml and plugin.yml.
+package modinfo
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Dirs are the folders of a server directory that hold mod and plugin jars
+var Dirs = []string{"mods", "plugins"}
+
+// Mod is what a jar says about itself. Fields it does not declare are
+// empty; Loader is "" for a jar without known metadata.
+type Mod struct {
+	File    string // relative to the server directory
+	Loader  string // fabric, quilt, forge, neoforge or bukkit
+	ID      string
+	Name    string
+	Version string
+}
+
+// List reads every jar in Dirs, sorted by path
+func List(serverDir string) []Mod {
+	var mods []Mod
+	for _, dir := range Dirs {
+		entries, err := os.ReadDir(filepath.Join(serverDir, dir))
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() || !strings.EqualFold(filepath.Ext(e.Name()), ".jar") {
+				continue
+			}
+			rel := filepath.Join(dir, e.Name())
+			mod, _ := Read(filepath.Join(serverDir, rel))
+			mod.File = filepath.ToSlash(rel)
+			mods = append(mods, mod)
+		}
+	}
+	sort.Slice(mods, func(i, j int) bool { return mods[i].File < mods[j].File })
+	return mods
+}
+
+// Read returns the metadata of one jar
+func Read(jar string) (Mod, error) {
+	mod := Mod{File: jar}
+	r, err := zip.OpenReader(jar)
+	if err != nil {
+		return mod, err
+	}
+	defer r.Close()
+
+	if data := readEntry(r, "fabric.mod.json"); data != nil {
+		var meta struct {
+			ID      string `json:"id"`
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		}
+		// Some mods leave control characters in their strings, which
+		// Fabric's lenient parser accepts; take what decodes
+		json.Unmarshal(data, &meta)
+		mod.Loader, mod.ID, mod.Name, mod.Version = "fabric", meta.ID, meta.Name, meta.Version
+		return mod, nil
+	}
+	if data := readEntry(r, "quilt.mod.json"); data != nil {
+		var meta struct {
+			Loader struct {
+				ID       string `json:"id"`
+				Version  string `json:"version"`
+				Metadata struct {
+					Name string `json:"name"`
+				} `json:"metadata"`
+			} `json:"quilt_loader"`
+		}
+		json.Unmarshal(data, &meta)
+		mod.Loader, mod.ID, mod.Name, mod.Version = "quilt", meta.Loader.ID, meta.Loader.Metadata.Name, meta.Loader.Version
+		return mod, nil
+	}
+	for _, toml := range []struct{ entry, loader string }{
+		{"META-INF/neoforge.mods.toml", "neoforge"},
+		{"META-INF/mods.toml", "forge"},
+	} {
+		if data := readEntry(r, toml.entry); data != nil {
+			fields := firstTOMLTable(string(data), "[[mods]]")
+			mod.Loader, mod.ID, mod.Name, mod.Version = toml.loader, fields["modId"], fields["displayName"], fields["version"]
+			if strings.HasPrefix(mod.Version, "${") {
+				// ${file.jarVersion} comes from the manifest
+				mod.Version = manifestVersion(r)
+			}
+			return mod, nil
+		}
+	}
+	for _, entry := range []string{"paper-plugin.yml", "plugin.yml"} {
+		if data := readEntry(r, entry); data != nil {
+			fields := yamlScalars(string(data))
+			mod.Loader, mod.ID, mod.Name, mod.Version = "bukkit", fields["name"], fields["name"], fields["version"]
+			return mod, nil
+		}
+	}
+	mod.Version = manifestVersion(r)
+	return mod, nil
+}
+
+func readEntry(r *zip.ReadCloser, name string) []byte {
+	f, err := r.Open(name)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, 1<<20))
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// manifestVersion returns Implementation-Version from META-INF/MANIFEST.MF
+func manifestVersion(r *zip.ReadCloser) string {
+	data := readEntry(r, "META-INF/MANIFEST.MF")
+	sc := bufio.NewScanner(strings.NewReader(string(data)))
+	for sc.Scan() {
+		if value, ok := strings.CutPrefix(sc.Text(), "Implementation-Version:"); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// firstTOMLTable returns the key = "value" pairs of the first table named
+// header, which is as much of mods.toml as identifies a mod
+func firstTOMLTable(text, header string) map[string]string {
+	fields := map[string]string{}
+	in := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			if in {
+				break
+			}
+			in = line == header
+			continue
+		}
+		if !in {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if quote := value[:min(len(value), 1)]; quote == `"` || quote == "'" {
+			if end := strings.Index(value[1:], quote); end >= 0 {
+				value = value[1 : end+1]
+			}
+		}
+		fields[strings.TrimSpace(key)] = value
+	}
+	return fields
+}
+
+// yamlScalars returns the top-level scalar keys of plugin.yml
+func yamlScalars(text string) map[string]string {
+	fields := map[string]string{}
+	for _, line := range strings.Split(text, "\n") {
+		if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '#' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields[key] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	return fields
+}
@@ -0,0 +1,166 @@
+package modrinth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+const apiBase = "https://api.modrinth.com/v2"
+
+// Client handles Modrinth API interactions
+type Client struct {
+	httpClient *http.Client
+}
+
+// Version is a published version of a Modrinth project
+type Version struct {
+	ID            string   `json:"id"`
+	ProjectID     string   `json:"project_id"`
+	Name          string   `json:"name"`
+	VersionNumber string   `json:"version_number"`
+	GameVersions  []string `json:"game_versions"`
+	Loaders       []string `json:"loaders"`
+	Files         []File   `json:"files"`
+}
+
+// File is a downloadable file of a version
+type File struct {
+	URL      string            `json:"url"`
+	Filename string            `json:"filename"`
+	Primary  bool              `json:"primary"`
+	Size     int64             `json:"size"`
+	Hashes   map[string]string `json:"hashes"`
+}
+
+// NewClient creates a new Modrinth client
+func NewClient() *Client {
+	return &Client{httpClient: &http.Client{}}
+}
+
+// PrimaryFile returns the primary file of a version
+func (v *Version) PrimaryFile() *File {
+	for i := range v.Files {
+		if v.Files[i].Primary {
+			return &v.Files[i]
+		}
+	}
+	if len(v.Files) > 0 {
+		return &v.Files[0]
+	}
+	return nil
+}
+
+// LatestVersion returns the newest version of a project for a loader and
+// Minecraft version
+func (c *Client) LatestVersion(project, loader, mcVersion string) (*Version, error) {
+	loaders, _ := json.Marshal([]string{loader})
+	gameVersions, _ := json.Marshal([]string{mcVersion})
+
+	query := url.Values{}
+	query.Set("loaders", string(loaders))
+	query.Set("game_versions", string(gameVersions))
+
+	var versions []Version
+	if err := c.get(fmt.Sprintf("/project/%s/version?%s", project, query.Encode()), &versions); err != nil {
+		return nil, err
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no %s version of %s for Minecraft %s", loader, project, mcVersion)
+	}
+
+	return &versions[0], nil
+}
+
+// UpdatesByHash identifies installed files by SHA-1 and returns, per hash,
+// the newest version of the same project for a loader and Minecraft
+// version. Hashes Modrinth does not know, or projects without such a
+// version, are missing from the result.
+func (c *Client) UpdatesByHash(hashes []string, loader, mcVersion string) (map[string]Version, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"hashes":        hashes,
+		"algorithm":     "sha1",
+		"loaders":       []string{loader},
+		"game_versions": []string{mcVersion},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]Version{}
+	if err := c.do("POST", "/version_files/update", bytes.NewReader(body), &updates); err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
+func (c *Client) get(path string, out interface{}) error {
+	return c.do("GET", path, nil, out)
+}
+
+func (c *Client) do(method, path string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequest(method, apiBase+path, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("User-Agent", "mcserver-manager")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("Modrinth request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Modrinth API returned status %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return nil
+}
+
+// Download saves a version file to dest
+func (c *Client) Download(file *File, dest string) error {
+	req, err := http.NewRequest("GET", file.URL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "mcserver-manager")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download returned status %d", resp.StatusCode)
+	}
+
+	tmp := dest + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, resp.Body)
+	out.Close()
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, dest)
+}
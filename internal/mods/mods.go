@@ -0,0 +1,322 @@
+// Package mods inventories the mod and plugin jars of a server, finds them
+// on Modrinth and CurseForge, and updates them to the newest build for the
+// server's loader and Minecraft version.
+package mods
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"mcserver-manager/internal/curseforge"
+	"mcserver-manager/internal/modinfo"
+	"mcserver-manager/internal/modrinth"
+	"mcserver-manager/internal/servertype"
+)
+
+// Where an installed jar was found
+const (
+	SourceModrinth   = "modrinth"
+	SourceCurseForge = "curseforge"
+)
+
+// Mod is an installed jar and what the mod sites know about it
+type Mod struct {
+	modinfo.Mod
+
+	// "" when neither site knows the jar
+	Source string
+	// Newest build for the server, nil when the jar is it or is unknown
+	Update *Update
+
+	sha1        string
+	fingerprint uint32
+	cfProject   int
+}
+
+// Update is a newer build of an installed mod
+type Update struct {
+	Version  string
+	FileName string
+
+	modrinth *modrinth.File
+	cfFile   int
+}
+
+// Inventory is the jars of a server directory
+type Inventory struct {
+	ServerDir string
+	// What builds are looked up for, in Modrinth's loader names
+	Loader    string
+	MCVersion string
+	Mods      []Mod
+	// Lookups that failed without failing the whole check, such as
+	// CurseForge without an API key
+	Warnings []string
+}
+
+// List reads the jars of serverDir without looking anything up
+func List(serverDir string) *Inventory {
+	info := servertype.Detect(serverDir)
+	inv := &Inventory{ServerDir: serverDir, Loader: lookupLoader(info), MCVersion: info.MCVersion}
+	for _, mod := range modinfo.List(serverDir) {
+		inv.Mods = append(inv.Mods, Mod{Mod: mod})
+	}
+	return inv
+}
+
+// lookupLoader names the server's loader the way Modrinth does; plugins
+// for Paper forks are published for paper
+func lookupLoader(info *servertype.Info) string {
+	switch info.Type {
+	case servertype.Paper, servertype.Purpur:
+		return "paper"
+	}
+	return info.Type
+}
+
+// Resolve identifies every jar, on Modrinth by SHA-1 and on CurseForge by
+// fingerprint for the rest, and fills in the newer builds
+func (inv *Inventory) Resolve() error {
+	if inv.MCVersion == "" {
+		return fmt.Errorf("could not tell the server's Minecraft version")
+	}
+	if len(inv.Mods) == 0 {
+		return nil
+	}
+
+	hashes := make([]string, 0, len(inv.Mods))
+	for i := range inv.Mods {
+		mod := &inv.Mods[i]
+		hash, err := sha1File(filepath.Join(inv.ServerDir, mod.File))
+		if err != nil {
+			return fmt.Errorf("failed to hash %s: %w", mod.File, err)
+		}
+		mod.sha1 = hash
+		hashes = append(hashes, hash)
+	}
+	updates, err := modrinth.NewClient().UpdatesByHash(hashes, inv.Loader, inv.MCVersion)
+	if err != nil {
+		return fmt.Errorf("failed to look up mods on Modrinth: %w", err)
+	}
+
+	var unknown []*Mod
+	for i := range inv.Mods {
+		mod := &inv.Mods[i]
+		newest, ok := updates[mod.sha1]
+		if !ok {
+			unknown = append(unknown, mod)
+			continue
+		}
+		mod.Source = SourceModrinth
+		if file := newest.PrimaryFile(); file != nil && file.Hashes["sha1"] != mod.sha1 {
+			mod.Update = &Update{Version: newest.VersionNumber, FileName: file.Filename, modrinth: file}
+		}
+	}
+	if len(unknown) > 0 {
+		if err := inv.resolveCurseForge(unknown); err != nil {
+			inv.Warnings = append(inv.Warnings, err.Error())
+		}
+	}
+	return nil
+}
+
+// resolveCurseForge looks up the jars Modrinth did not know. CurseForge
+// has no plugin loaders, so plugin servers skip it.
+func (inv *Inventory) resolveCurseForge(mods []*Mod) error {
+	if inv.Loader == "paper" || inv.Loader == servertype.Spigot || inv.Loader == servertype.Vanilla {
+		return nil
+	}
+	cf := curseforge.NewClient()
+	fingerprints := make([]uint32, 0, len(mods))
+	for _, mod := range mods {
+		fp, err := curseforge.Fingerprint(filepath.Join(inv.ServerDir, mod.File))
+		if err != nil {
+			return fmt.Errorf("failed to fingerprint %s: %w", mod.File, err)
+		}
+		mod.fingerprint = fp
+		fingerprints = append(fingerprints, fp)
+	}
+	matches, err := cf.MatchFingerprints(fingerprints)
+	if err != nil {
+		return fmt.Errorf("failed to look up mods on CurseForge: %w", err)
+	}
+
+	for _, mod := range mods {
+		match, ok := matches[mod.fingerprint]
+		if !ok {
+			continue
+		}
+		mod.Source, mod.cfProject = SourceCurseForge, match.ProjectID
+		newest, err := cf.LatestModFile(match.ProjectID, inv.Loader, inv.MCVersion)
+		if err != nil || newest.ID == match.FileID {
+			continue
+		}
+		mod.Update = &Update{Version: newest.DisplayName, FileName: newest.FileName, cfFile: newest.ID}
+	}
+	return nil
+}
+
+// Outdated returns the mods with a newer build
+func (inv *Inventory) Outdated() []Mod {
+	var outdated []Mod
+	for _, mod := range inv.Mods {
+		if mod.Update != nil {
+			outdated = append(outdated, mod)
+		}
+	}
+	return outdated
+}
+
+// Find returns the installed mod called name: its file name, with or
+// without .jar, or its mod ID
+func (inv *Inventory) Find(name string) (*Mod, error) {
+	for i := range inv.Mods {
+		mod := &inv.Mods[i]
+		base := filepath.Base(mod.File)
+		if strings.EqualFold(base, name) || strings.EqualFold(strings.TrimSuffix(base, filepath.Ext(base)), name) ||
+			(mod.ID != "" && strings.EqualFold(mod.ID, name)) {
+			return mod, nil
+		}
+	}
+	return nil, fmt.Errorf("no mod %s installed", name)
+}
+
+// Apply replaces each of mods with its update. The folders they are in are
+// zipped into backupDir first, and the path of that zip is returned. A
+// new jar is downloaded before the old one is removed, so a failed
+// download leaves the mod as it was.
+func (inv *Inventory) Apply(mods []Mod, backupDir string) (string, error) {
+	dirs := map[string]bool{}
+	for _, mod := range mods {
+		if mod.Update == nil {
+			return "", fmt.Errorf("%s is up to date", mod.File)
+		}
+		dirs[filepath.Dir(filepath.FromSlash(mod.File))] = true
+	}
+	if len(mods) == 0 {
+		return "", nil
+	}
+
+	backup, err := inv.backupDirs(dirs, backupDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to back up mods, nothing changed: %w", err)
+	}
+
+	mr, cf := modrinth.NewClient(), curseforge.NewClient()
+	for _, mod := range mods {
+		old := filepath.Join(inv.ServerDir, filepath.FromSlash(mod.File))
+		dir := filepath.Dir(old)
+		switch mod.Source {
+		case SourceModrinth:
+			err = mr.Download(mod.Update.modrinth, filepath.Join(dir, mod.Update.FileName))
+			if err == nil {
+				err = checkSHA1(filepath.Join(dir, mod.Update.FileName), mod.Update.modrinth.Hashes["sha1"])
+			}
+		case SourceCurseForge:
+			err = cf.DownloadMod(mod.cfProject, mod.Update.cfFile, dir)
+		}
+		if err != nil {
+			return backup, fmt.Errorf("failed to download %s: %w", mod.Update.FileName, err)
+		}
+		if filepath.Base(old) != mod.Update.FileName {
+			if err := os.Remove(old); err != nil {
+				return backup, fmt.Errorf("updated %s, but failed to remove the old jar: %w", mod.File, err)
+			}
+		}
+	}
+	return backup, nil
+}
+
+// backupDirs zips the given folders of the server directory into
+// backupDir as mods_<time>.zip
+func (inv *Inventory) backupDirs(dirs map[string]bool, backupDir string) (string, error) {
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(backupDir, "mods_"+time.Now().Format("2006-01-02_15-04-05")+".zip")
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	zw := zip.NewWriter(f)
+
+	names := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		names = append(names, dir)
+	}
+	sort.Strings(names)
+	for _, dir := range names {
+		entries, err := os.ReadDir(filepath.Join(inv.ServerDir, dir))
+		if err != nil {
+			zw.Close()
+			f.Close()
+			return "", err
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			if err := addFile(zw, filepath.Join(inv.ServerDir, dir, e.Name()), filepath.ToSlash(filepath.Join(dir, e.Name()))); err != nil {
+				zw.Close()
+				f.Close()
+				return "", err
+			}
+		}
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
+
+func addFile(zw *zip.Writer, path, name string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	// Jars are zips already
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, src)
+	return err
+}
+
+func checkSHA1(path, want string) error {
+	if want == "" {
+		return nil
+	}
+	got, err := sha1File(path)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		os.Remove(path)
+		return fmt.Errorf("SHA-1 is %s, Modrinth says %s", got, want)
+	}
+	return nil
+}
+
+func sha1File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,324 @@
+package nbt
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// Tag types
+const (
+	tagEnd byte = iota
+	tagByte
+	tagShort
+	tagInt
+	tagLong
+	tagFloat
+	tagDouble
+	tagByteArray
+	tagString
+	tagList
+	tagCompound
+	tagIntArray
+	tagLongArray
+)
+
+// Limits so a corrupt file can't exhaust the stack or memory
+const (
+	maxDepth  = 512
+	maxLength = 64 << 20
+)
+
+// Compound is an NBT compound tag. Values are int8, int16, int32, int64,
+// float32, float64, []byte, string, []interface{}, Compound, []int32 or
+// []int64.
+type Compound map[string]interface{}
+
+// Get walks nested compounds by key and returns the value, or nil
+func (c Compound) Get(path ...string) interface{} {
+	var value interface{} = c
+	for _, key := range path {
+		compound, ok := value.(Compound)
+		if !ok {
+			return nil
+		}
+		value = compound[key]
+	}
+	return value
+}
+
+// Compound returns the nested compound at path, or nil
+func (c Compound) Compound(path ...string) Compound {
+	v, _ := c.Get(path...).(Compound)
+	return v
+}
+
+// String returns the string at path, or ""
+func (c Compound) String(path ...string) string {
+	v, _ := c.Get(path...).(string)
+	return v
+}
+
+// Int returns the integer at path widened to int64, and whether it exists
+func (c Compound) Int(path ...string) (int64, bool) {
+	switch v := c.Get(path...).(type) {
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	}
+	return 0, false
+}
+
+// Float returns the number at path as float64, and whether it exists
+func (c Compound) Float(path ...string) (float64, bool) {
+	switch v := c.Get(path...).(type) {
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	if i, ok := c.Int(path...); ok {
+		return float64(i), true
+	}
+	return 0, false
+}
+
+// ReadFile reads an NBT file, detecting gzip or zlib compression
+func ReadFile(path string) (Compound, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	root, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return root, nil
+}
+
+// Read decodes a (possibly compressed) NBT stream and returns its root
+// compound
+func Read(r io.Reader) (Compound, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+
+	var src io.Reader = br
+	switch {
+	case magic[0] == 0x1f && magic[1] == 0x8b:
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		src = gz
+	case magic[0] == 0x78:
+		zr, err := zlib.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		src = zr
+	}
+
+	d := &decoder{r: bufio.NewReader(src)}
+	tag, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+	if tag != tagCompound {
+		return nil, fmt.Errorf("root tag is %d, expected compound", tag)
+	}
+	if _, err := d.string(); err != nil {
+		return nil, err
+	}
+
+	value, err := d.payload(tagCompound, 0)
+	if err != nil {
+		return nil, err
+	}
+	return value.(Compound), nil
+}
+
+// ReadNetwork decodes one uncompressed tag as sent in network packets since
+// 1.20.2: a tag type and its payload, without a name
+func ReadNetwork(r io.Reader) (interface{}, error) {
+	d := &decoder{r: bufio.NewReader(r)}
+	tag, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+	return d.payload(tag, 0)
+}
+
+type decoder struct {
+	r   *bufio.Reader
+	buf [8]byte
+}
+
+func (d *decoder) payload(tag byte, depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("nesting too deep")
+	}
+
+	switch tag {
+	case tagByte:
+		b, err := d.byte()
+		return int8(b), err
+	case tagShort:
+		v, err := d.uint(2)
+		return int16(v), err
+	case tagInt:
+		v, err := d.uint(4)
+		return int32(v), err
+	case tagLong:
+		v, err := d.uint(8)
+		return int64(v), err
+	case tagFloat:
+		v, err := d.uint(4)
+		return math.Float32frombits(uint32(v)), err
+	case tagDouble:
+		v, err := d.uint(8)
+		return math.Float64frombits(v), err
+	case tagString:
+		return d.string()
+
+	case tagByteArray:
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, n)
+		_, err = io.ReadFull(d.r, data)
+		return data, err
+
+	case tagIntArray:
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		values := make([]int32, n)
+		for i := range values {
+			v, err := d.uint(4)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = int32(v)
+		}
+		return values, nil
+
+	case tagLongArray:
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		values := make([]int64, n)
+		for i := range values {
+			v, err := d.uint(8)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = int64(v)
+		}
+		return values, nil
+
+	case tagList:
+		elemTag, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, 0, min(n, 1024))
+		for i := 0; i < n; i++ {
+			v, err := d.payload(elemTag, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+
+	case tagCompound:
+		compound := Compound{}
+		for {
+			childTag, err := d.byte()
+			if err != nil {
+				return nil, err
+			}
+			if childTag == tagEnd {
+				return compound, nil
+			}
+			name, err := d.string()
+			if err != nil {
+				return nil, err
+			}
+			v, err := d.payload(childTag, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			compound[name] = v
+		}
+	}
+
+	return nil, fmt.Errorf("unknown tag type %d", tag)
+}
+
+func (d *decoder) byte() (byte, error) {
+	return d.r.ReadByte()
+}
+
+func (d *decoder) uint(size int) (uint64, error) {
+	if _, err := io.ReadFull(d.r, d.buf[:size]); err != nil {
+		return 0, err
+	}
+	switch size {
+	case 2:
+		return uint64(binary.BigEndian.Uint16(d.buf[:2])), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(d.buf[:4])), nil
+	default:
+		return binary.BigEndian.Uint64(d.buf[:8]), nil
+	}
+}
+
+func (d *decoder) length() (int, error) {
+	v, err := d.uint(4)
+	if err != nil {
+		return 0, err
+	}
+	if int32(v) < 0 || v > maxLength {
+		return 0, fmt.Errorf("invalid length %d", int32(v))
+	}
+	return int(int32(v)), nil
+}
+
+func (d *decoder) string() (string, error) {
+	n, err := d.uint(2)
+	if err != nil {
+		return "", err
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(d.r, data); err != nil {
+		return "", err
+	}
+	// Java's modified UTF-8 only differs for NUL and supplementary
+	// characters, which world data doesn't use in practice
+	return string(data), nil
+}
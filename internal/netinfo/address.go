@@ -0,0 +1,85 @@
+package netinfo
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// publicIPServices return the caller's public IP as plain text
+var publicIPServices = []string{
+	"https://api.ipify.org",
+	"https://ifconfig.me/ip",
+	"https://icanhazip.com",
+}
+
+// PublicIP asks an external echo service for this host's public IPv4 address
+func PublicIP() (string, error) {
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	var lastErr error
+	for _, service := range publicIPServices {
+		resp, err := client.Get(service)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 64))
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			lastErr = fmt.Errorf("%s returned status %d", service, resp.StatusCode)
+			continue
+		}
+
+		ip := net.ParseIP(strings.TrimSpace(string(body)))
+		if ip == nil || ip.To4() == nil {
+			lastErr = fmt.Errorf("%s returned an invalid address", service)
+			continue
+		}
+		return ip.String(), nil
+	}
+
+	return "", fmt.Errorf("failed to detect public IP: %w", lastErr)
+}
+
+// LANAddresses returns this host's private IPv4 addresses
+func LANAddresses() []string {
+	var addrs []string
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return addrs
+	}
+
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+
+		ifaceAddrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		for _, addr := range ifaceAddrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			ip := ipNet.IP.To4()
+			if ip != nil && ip.IsPrivate() {
+				addrs = append(addrs, ip.String())
+			}
+		}
+	}
+
+	return addrs
+}
@@ -0,0 +1,393 @@
+package playerdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// File is the player database, relative to the server dir
+const File = ".mcserver/players.json"
+
+// Player is what the manager knows about someone who has played
+type Player struct {
+	Name      string    `json:"name"`
+	UUID      string    `json:"uuid,omitempty"`
+	FirstSeen time.Time `json:"firstSeen,omitzero"`
+	LastSeen  time.Time `json:"lastSeen,omitzero"`
+	Joins     int       `json:"joins"`
+	// Addresses the player joined from, most recent last
+	IPs []string `json:"ips,omitempty"`
+	// Time online over the sessions that ended
+	Playtime time.Duration `json:"playtime,omitempty"`
+	// The latest sessions, oldest first
+	Sessions []Session `json:"sessions,omitempty"`
+
+	TempBan *TempBan `json:"tempBan,omitempty"`
+}
+
+// Session is one stay on the server
+type Session struct {
+	Joined time.Time `json:"joined"`
+	Left   time.Time `json:"left,omitzero"` // zero while online
+	IP     string    `json:"ip,omitempty"`
+}
+
+// Limits on what is kept per player
+const (
+	maxIPs      = 10
+	maxSessions = 100
+)
+
+// Online reports whether the player's latest session is still open
+func (p *Player) Online() bool {
+	return len(p.Sessions) > 0 && p.Sessions[len(p.Sessions)-1].Left.IsZero()
+}
+
+// TotalPlaytime returns the player's playtime including the open session,
+// counted up to now
+func (p *Player) TotalPlaytime(now time.Time) time.Duration {
+	total := p.Playtime
+	if p.Online() {
+		total += now.Sub(p.Sessions[len(p.Sessions)-1].Joined)
+	}
+	return total
+}
+
+// LastIP returns the address the player last joined from
+func (p *Player) LastIP() string {
+	if len(p.IPs) == 0 {
+		return ""
+	}
+	return p.IPs[len(p.IPs)-1]
+}
+
+// closeSession ends the player's open session at the given time
+func (p *Player) closeSession(at time.Time) bool {
+	if !p.Online() {
+		return false
+	}
+	session := &p.Sessions[len(p.Sessions)-1]
+	if at.Before(session.Joined) {
+		at = session.Joined
+	}
+	session.Left = at
+	p.Playtime += at.Sub(session.Joined)
+	return true
+}
+
+// addIP notes an address the player joined from
+func (p *Player) addIP(ip string) {
+	p.IPs = slices.DeleteFunc(p.IPs, func(known string) bool { return known == ip })
+	p.IPs = append(p.IPs, ip)
+	if len(p.IPs) > maxIPs {
+		p.IPs = p.IPs[len(p.IPs)-maxIPs:]
+	}
+}
+
+// TempBan is a ban the manager lifts again once it runs out
+type TempBan struct {
+	Since  time.Time `json:"since"`
+	Until  time.Time `json:"until"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// copy returns p with its own TempBan
+func (p *Player) copy() Player {
+	c := *p
+	c.IPs = slices.Clone(p.IPs)
+	c.Sessions = slices.Clone(p.Sessions)
+	if p.TempBan != nil {
+		ban := *p.TempBan
+		c.TempBan = &ban
+	}
+	return c
+}
+
+// DB is the set of players seen on a server, saved on every change. It is
+// safe for concurrent use.
+type DB struct {
+	path string
+
+	mu      sync.Mutex
+	players map[string]*Player // by lower-case name
+	// UUIDs and addresses logged before the join they belong to
+	pendingUUIDs map[string]string
+	pendingIPs   map[string]string
+}
+
+// Open loads the player database of serverDir. A new one is seeded from
+// the server's usercache.json, so players from before it existed are not
+// taken for newcomers. Sessions left open when the manager last stopped
+// are closed at the time the player was last seen.
+func Open(serverDir string) (*DB, error) {
+	db := &DB{
+		path:         filepath.Join(serverDir, File),
+		players:      make(map[string]*Player),
+		pendingUUIDs: make(map[string]string),
+		pendingIPs:   make(map[string]string),
+	}
+
+	data, err := os.ReadFile(db.path)
+	if os.IsNotExist(err) {
+		db.seed(filepath.Join(serverDir, "usercache.json"))
+		return db, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read player database: %w", err)
+	}
+
+	var players []*Player
+	if err := json.Unmarshal(data, &players); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", File, err)
+	}
+	for _, p := range players {
+		p.closeSession(p.LastSeen)
+		db.players[strings.ToLower(p.Name)] = p
+	}
+	return db, nil
+}
+
+// seed adds the players of a usercache.json, without dates
+func (db *DB) seed(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var cache []struct {
+		Name string `json:"name"`
+		UUID string `json:"uuid"`
+	}
+	if json.Unmarshal(data, &cache) != nil {
+		return
+	}
+	for _, entry := range cache {
+		db.players[strings.ToLower(entry.Name)] = &Player{Name: entry.Name, UUID: entry.UUID}
+	}
+}
+
+// Join records a player joining at the given time and opens a session for
+// them. It returns their record; first reports that the player had never
+// been seen before.
+func (db *DB) Join(name string, at time.Time) (p Player, first bool, err error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	key := strings.ToLower(name)
+	player, ok := db.players[key]
+	if !ok {
+		player = &Player{Name: name, FirstSeen: at}
+		db.players[key] = player
+	}
+	if uuid, ok := db.pendingUUIDs[key]; ok {
+		player.UUID = uuid
+		delete(db.pendingUUIDs, key)
+	}
+	ip, hasIP := db.pendingIPs[key]
+	if hasIP {
+		player.addIP(ip)
+		delete(db.pendingIPs, key)
+	}
+	player.Name = name
+	player.LastSeen = at
+	player.Joins++
+	// A Bedrock player is reported by both Geyser and the server
+	if !player.Online() {
+		player.Sessions = append(player.Sessions, Session{Joined: at, IP: ip})
+		if len(player.Sessions) > maxSessions {
+			player.Sessions = player.Sessions[len(player.Sessions)-maxSessions:]
+		}
+	} else if hasIP {
+		player.Sessions[len(player.Sessions)-1].IP = ip
+	}
+	return player.copy(), !ok, db.save()
+}
+
+// Leave records a player leaving at the given time, ending their session
+func (db *DB) Leave(name string, at time.Time) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	player, ok := db.players[strings.ToLower(name)]
+	if !ok {
+		return nil
+	}
+	player.LastSeen = at
+	player.closeSession(at)
+	return db.save()
+}
+
+// CloseSessions ends the session of everyone still online at the given
+// time, for when the server stops without logging their leaves
+func (db *DB) CloseSessions(at time.Time) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	closed := false
+	for _, player := range db.players {
+		if player.closeSession(at) {
+			player.LastSeen = at
+			closed = true
+		}
+	}
+	if !closed {
+		return nil
+	}
+	return db.save()
+}
+
+// SetIP records the address a player joins from. The server logs it just
+// before the join, so unless the player is online already it is kept for
+// Join.
+func (db *DB) SetIP(name, ip string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	key := strings.ToLower(name)
+	player, ok := db.players[key]
+	if !ok || !player.Online() || player.Sessions[len(player.Sessions)-1].IP != "" {
+		db.pendingIPs[key] = ip
+		return nil
+	}
+	player.addIP(ip)
+	player.Sessions[len(player.Sessions)-1].IP = ip
+	return db.save()
+}
+
+// SharingIP returns the other players who have joined from one of the
+// addresses of name
+func (db *DB) SharingIP(name string) []Player {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	player, ok := db.players[strings.ToLower(name)]
+	if !ok || len(player.IPs) == 0 {
+		return nil
+	}
+	var sharing []Player
+	for _, p := range db.sorted() {
+		if strings.EqualFold(p.Name, player.Name) {
+			continue
+		}
+		for _, ip := range p.IPs {
+			if slices.Contains(player.IPs, ip) {
+				sharing = append(sharing, p)
+				break
+			}
+		}
+	}
+	return sharing
+}
+
+// SetUUID records a player's UUID. The server logs it just before the
+// join, so for a player not seen yet it is kept for Join.
+func (db *DB) SetUUID(name, uuid string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	key := strings.ToLower(name)
+	player, ok := db.players[key]
+	if !ok {
+		db.pendingUUIDs[key] = uuid
+		return nil
+	}
+	if player.UUID == uuid {
+		return nil
+	}
+	player.UUID = uuid
+	return db.save()
+}
+
+// Get returns the record of a player by name, ignoring case
+func (db *DB) Get(name string) (Player, bool) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	player, ok := db.players[strings.ToLower(name)]
+	if !ok {
+		return Player{}, false
+	}
+	return player.copy(), true
+}
+
+// SetTempBan records a temporary ban of a player, who need not have
+// joined before, or clears it when ban is nil
+func (db *DB) SetTempBan(name string, ban *TempBan) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	key := strings.ToLower(name)
+	player, ok := db.players[key]
+	if !ok {
+		if ban == nil {
+			return nil
+		}
+		player = &Player{Name: name}
+		db.players[key] = player
+	}
+	player.TempBan = ban
+	return db.save()
+}
+
+// TempBans returns the temporarily banned players, soonest expiry first
+func (db *DB) TempBans() []Player {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var banned []Player
+	for _, p := range db.players {
+		if p.TempBan != nil {
+			banned = append(banned, p.copy())
+		}
+	}
+	sort.Slice(banned, func(i, j int) bool {
+		return banned[i].TempBan.Until.Before(banned[j].TempBan.Until)
+	})
+	return banned
+}
+
+// All returns every player, most recently seen first
+func (db *DB) All() []Player {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.sorted()
+}
+
+func (db *DB) sorted() []Player {
+	players := make([]Player, 0, len(db.players))
+	for _, p := range db.players {
+		players = append(players, p.copy())
+	}
+	sort.Slice(players, func(i, j int) bool {
+		if !players[i].LastSeen.Equal(players[j].LastSeen) {
+			return players[i].LastSeen.After(players[j].LastSeen)
+		}
+		return strings.ToLower(players[i].Name) < strings.ToLower(players[j].Name)
+	})
+	return players
+}
+
+// save writes the database; db.mu must be held
+func (db *DB) save() error {
+	data, err := json.MarshalIndent(db.sorted(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(db.path), 0755); err != nil {
+		return fmt.Errorf("failed to save player database: %w", err)
+	}
+	tmp := db.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to save player database: %w", err)
+	}
+	if err := os.Rename(tmp, db.path); err != nil {
+		return fmt.Errorf("failed to save player database: %w", err)
+	}
+	return nil
+}
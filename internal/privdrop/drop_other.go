@@ -0,0 +1,10 @@
+//go:build !unix
+
+package privdrop
+
+import "fmt"
+
+// Drop is only supported on Unix systems
+func (a *Account) Drop() error {
+	return fmt.Errorf("dropping privileges is not supported on this platform")
+}
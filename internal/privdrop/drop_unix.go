@@ -0,0 +1,33 @@
+//go:build unix
+
+package privdrop
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// Drop permanently switches the whole process to the account. Everything
+// the manager does afterwards, including starting the JVM, runs as that
+// user.
+func (a *Account) Drop() error {
+	if err := syscall.Setgroups([]int{a.GID}); err != nil {
+		return fmt.Errorf("failed to set groups: %w", err)
+	}
+	if err := syscall.Setgid(a.GID); err != nil {
+		return fmt.Errorf("failed to set gid: %w", err)
+	}
+	if err := syscall.Setuid(a.UID); err != nil {
+		return fmt.Errorf("failed to set uid: %w", err)
+	}
+	if os.Geteuid() == 0 {
+		return fmt.Errorf("still root after setuid")
+	}
+
+	// Java keeps preferences and caches under $HOME
+	os.Setenv("HOME", a.HomeDir)
+	os.Setenv("USER", a.Name)
+	os.Setenv("LOGNAME", a.Name)
+	return nil
+}
@@ -0,0 +1,80 @@
+package privdrop
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+)
+
+// Account is the unprivileged user the manager switches to
+type Account struct {
+	Name    string
+	UID     int
+	GID     int
+	HomeDir string
+	Created bool // EnsureUser had to create it
+}
+
+// IsRoot reports whether the manager is running with root privileges
+func IsRoot() bool {
+	return os.Geteuid() == 0
+}
+
+// EnsureUser looks up name, creating it as a system account with home as
+// its home directory if it does not exist yet
+func EnsureUser(name, home string) (*Account, error) {
+	u, err := user.Lookup(name)
+	created := false
+	if _, missing := err.(user.UnknownUserError); missing {
+		useradd, lookErr := exec.LookPath("useradd")
+		if lookErr != nil {
+			return nil, fmt.Errorf("user %s does not exist and useradd is not available", name)
+		}
+		out, runErr := exec.Command(useradd, "--system", "--user-group", "--home-dir", home,
+			"--no-create-home", "--shell", "/usr/sbin/nologin", name).CombinedOutput()
+		if runErr != nil {
+			return nil, fmt.Errorf("failed to create user %s: %v: %s", name, runErr, out)
+		}
+		u, err = user.Lookup(name)
+		created = true
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user %s: %w", name, err)
+	}
+
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return nil, fmt.Errorf("user %s has no numeric uid", name)
+	}
+	gid, err := strconv.Atoi(u.Gid)
+	if err != nil {
+		return nil, fmt.Errorf("user %s has no numeric gid", name)
+	}
+	if uid == 0 {
+		return nil, fmt.Errorf("user %s is root", name)
+	}
+	return &Account{Name: name, UID: uid, GID: gid, HomeDir: u.HomeDir, Created: created}, nil
+}
+
+// Chown gives the account ownership of everything under each path,
+// creating missing directories first
+func (a *Account) Chown(paths ...string) error {
+	for _, root := range paths {
+		if err := os.MkdirAll(root, 0755); err != nil {
+			return err
+		}
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			return os.Lchown(path, a.UID, a.GID)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to chown %s: %w", root, err)
+		}
+	}
+	return nil
+}
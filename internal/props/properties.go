@@ -0,0 +1,94 @@
+package props
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Properties is a server.properties file that keeps line order and
+// comments when edited
+type Properties struct {
+	lines []string
+	index map[string]int // key -> line
+}
+
+// Load reads a properties file. A missing file yields empty properties.
+func Load(path string) (*Properties, error) {
+	p := &Properties{index: make(map[string]int)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return p, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if key, _, ok := parseLine(line); ok {
+			p.index[key] = len(p.lines)
+		}
+		p.lines = append(p.lines, line)
+	}
+
+	return p, nil
+}
+
+// Get returns a property value and whether it is set
+func (p *Properties) Get(key string) (string, bool) {
+	i, ok := p.index[key]
+	if !ok {
+		return "", false
+	}
+	_, value, _ := parseLine(p.lines[i])
+	return value, true
+}
+
+// GetDefault returns a property value, or def if it is unset
+func (p *Properties) GetDefault(key, def string) string {
+	if value, ok := p.Get(key); ok {
+		return value
+	}
+	return def
+}
+
+// Set updates a property in place, appending it if new
+func (p *Properties) Set(key, value string) {
+	line := key + "=" + value
+	if i, ok := p.index[key]; ok {
+		p.lines[i] = line
+		return
+	}
+	p.index[key] = len(p.lines)
+	p.lines = append(p.lines, line)
+}
+
+// Keys returns the property keys in file order
+func (p *Properties) Keys() []string {
+	var keys []string
+	for _, line := range p.lines {
+		if key, _, ok := parseLine(line); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Save writes the properties back to path
+func (p *Properties) Save(path string) error {
+	return os.WriteFile(path, []byte(strings.Join(p.lines, "\n")+"\n"), 0644)
+}
+
+func parseLine(line string) (key, value string, ok bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "!") {
+		return "", "", false
+	}
+	key, value, ok = strings.Cut(trimmed, "=")
+	if !ok {
+		return "", "", false
+	}
+	return strings.TrimSpace(key), strings.TrimSpace(value), true
+}
@@ -0,0 +1,220 @@
+package proxy
+
+import (
+	"strings"
+)
+
+// setYAMLKeys sets scalar keys inside a (possibly nested) YAML section,
+// editing lines in place so comments and unrelated settings survive. Missing
+// sections and keys are inserted with two-space indentation.
+func setYAMLKeys(content string, section []string, values map[string]string, order []string) string {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+
+	// Find the deepest existing header along the section path
+	type header struct {
+		line   int
+		indent int
+	}
+	var found []header
+	depth := 0
+	searchFrom, searchIndent := 0, -1
+	for depth < len(section) {
+		match := -1
+		for i := searchFrom; i < len(lines); i++ {
+			ind, key, _, ok := yamlLine(lines[i])
+			if !ok {
+				continue
+			}
+			if ind <= searchIndent {
+				break
+			}
+			if key == section[depth] && (depth == 0 && ind == 0 || depth > 0) {
+				match = i
+				found = append(found, header{line: i, indent: ind})
+				searchFrom, searchIndent = i+1, ind
+				break
+			}
+		}
+		if match < 0 {
+			break
+		}
+		depth++
+	}
+
+	// Create any missing headers
+	insertAt := len(lines)
+	indent := 0
+	if len(found) > 0 {
+		last := found[len(found)-1]
+		insertAt = last.line + 1
+		indent = last.indent + 2
+	}
+	var inserted []string
+	for i := depth; i < len(section); i++ {
+		inserted = append(inserted, strings.Repeat(" ", indent)+section[i]+":")
+		indent += 2
+	}
+	if len(inserted) > 0 {
+		lines = append(lines[:insertAt], append(inserted, lines[insertAt:]...)...)
+		insertAt += len(inserted)
+		for _, key := range order {
+			lines = append(lines[:insertAt], append([]string{strings.Repeat(" ", indent) + key + ": " + values[key]}, lines[insertAt:]...)...)
+			insertAt++
+		}
+		return strings.Join(lines, "\n") + "\n"
+	}
+
+	// Section exists: replace direct children, then add missing keys
+	headerIndent := found[len(found)-1].indent
+	childIndent := -1
+	done := map[string]bool{}
+	for i := insertAt; i < len(lines); i++ {
+		ind, key, _, ok := yamlLine(lines[i])
+		if !ok {
+			continue
+		}
+		if ind <= headerIndent {
+			break
+		}
+		if childIndent < 0 {
+			childIndent = ind
+		}
+		if ind != childIndent {
+			continue
+		}
+		if value, want := values[key]; want {
+			lines[i] = strings.Repeat(" ", ind) + key + ": " + value
+			done[key] = true
+		}
+	}
+	if childIndent < 0 {
+		childIndent = headerIndent + 2
+	}
+
+	for _, key := range order {
+		if done[key] {
+			continue
+		}
+		lines = append(lines[:insertAt], append([]string{strings.Repeat(" ", childIndent) + key + ": " + values[key]}, lines[insertAt:]...)...)
+		insertAt++
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// getYAMLKey reads a scalar from a nested YAML section
+func getYAMLKey(content string, section []string, key string) string {
+	path := append(append([]string{}, section...), key)
+
+	var stack []struct {
+		indent int
+		key    string
+	}
+	for _, line := range strings.Split(content, "\n") {
+		ind, k, value, ok := yamlLine(line)
+		if !ok {
+			continue
+		}
+		for len(stack) > 0 && stack[len(stack)-1].indent >= ind {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, struct {
+			indent int
+			key    string
+		}{ind, k})
+
+		if len(stack) != len(path) {
+			continue
+		}
+		match := true
+		for i := range path {
+			if stack[i].key != path[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return strings.Trim(value, `'"`)
+		}
+	}
+	return ""
+}
+
+// yamlLine splits a "key: value" line into indentation, key and value
+func yamlLine(line string) (indent int, key, value string, ok bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "-") {
+		return 0, "", "", false
+	}
+	k, v, found := strings.Cut(trimmed, ":")
+	if !found {
+		return 0, "", "", false
+	}
+	indent = len(line) - len(strings.TrimLeft(line, " "))
+	return indent, strings.TrimSpace(k), strings.TrimSpace(v), true
+}
+
+// setTOMLKey sets key = value inside a TOML table ("" for the top level)
+func setTOMLKey(content, table, key, value string) string {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+
+	current := ""
+	tableEnd := -1
+	tableFound := table == ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			if current == table && tableFound && tableEnd < 0 {
+				tableEnd = i
+			}
+			current = strings.Trim(trimmed, "[]")
+			if current == table {
+				tableFound = true
+			}
+			continue
+		}
+		if current != table {
+			continue
+		}
+		if k, _, ok := strings.Cut(trimmed, "="); ok && strings.TrimSpace(k) == key {
+			lines[i] = key + " = " + value
+			return strings.Join(lines, "\n") + "\n"
+		}
+	}
+
+	entry := key + " = " + value
+	switch {
+	case !tableFound:
+		lines = append(lines, "", "["+table+"]", entry)
+	case tableEnd >= 0:
+		lines = append(lines[:tableEnd], append([]string{entry}, lines[tableEnd:]...)...)
+	default:
+		lines = append(lines, entry)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// getTOMLKey reads a value from a TOML table ("" for the top level)
+func getTOMLKey(content, table, key string) string {
+	current := ""
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			current = strings.Trim(trimmed, "[]")
+			continue
+		}
+		if current != table {
+			continue
+		}
+		if k, v, ok := strings.Cut(trimmed, "="); ok && strings.TrimSpace(k) == key {
+			return strings.Trim(strings.TrimSpace(v), `'"`)
+		}
+	}
+	return ""
+}
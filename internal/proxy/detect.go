@@ -0,0 +1,50 @@
+package proxy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Forwarding is how a proxy in front of the server passes on the players'
+// own address and UUID
+type Forwarding string
+
+const (
+	ForwardingNone   Forwarding = ""
+	ForwardingModern Forwarding = "modern" // Velocity modern forwarding
+	ForwardingLegacy Forwarding = "legacy" // BungeeCord IP forwarding
+)
+
+// DetectForwarding reads the server's configs for proxy forwarding: Paper's
+// velocity section, the Forge and Fabric forwarding mods, or spigot.yml's
+// bungeecord setting
+func DetectForwarding(serverDir string) Forwarding {
+	read := func(parts ...string) string {
+		data, _ := os.ReadFile(filepath.Join(append([]string{serverDir}, parts...)...))
+		return string(data)
+	}
+
+	if isTrue(getYAMLKey(read("config", "paper-global.yml"), []string{"proxies", "velocity"}, "enabled")) ||
+		isTrue(getYAMLKey(read("paper.yml"), []string{"settings", "velocity-support"}, "enabled")) ||
+		getTOMLKey(read("config", "pcf-common.toml"), "modernForwarding", "forwardingSecret") != "" ||
+		getTOMLKey(read("config", "FabricProxy-Lite.toml"), "", "secret") != "" {
+		return ForwardingModern
+	}
+	if isTrue(getYAMLKey(read("spigot.yml"), []string{"settings"}, "bungeecord")) {
+		return ForwardingLegacy
+	}
+	return ForwardingNone
+}
+
+// OfflineUUID reports whether uuid is a name-derived offline-mode UUID
+// (version 3), which a backend behind a proxy only sees when the proxy
+// does not forward the player's real one
+func OfflineUUID(uuid string) bool {
+	parts := strings.Split(uuid, "-")
+	return len(parts) == 5 && strings.HasPrefix(parts[2], "3")
+}
+
+func isTrue(value string) bool {
+	return strings.EqualFold(value, "true")
+}
@@ -0,0 +1,287 @@
+package proxy
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"mcserver-manager/internal/modrinth"
+)
+
+const secretFileName = "forwarding.secret"
+
+// Backend is the kind of server behind the proxy, which decides where the
+// forwarding secret is configured
+type Backend string
+
+const (
+	BackendPaper       Backend = "paper"
+	BackendPaperLegacy Backend = "paper-legacy" // paper.yml, before 1.19
+	BackendForge       Backend = "forge"
+	BackendFabric      Backend = "fabric"
+)
+
+// Forwarding mods installed for modded backends (Modrinth project slugs)
+const (
+	forgeForwardingMod  = "proxy-compatible-forge"
+	fabricForwardingMod = "fabricproxy-lite"
+)
+
+// ForwardingResult describes what ConfigureModernForwarding changed
+type ForwardingResult struct {
+	Backend        Backend
+	SecretCreated  bool
+	BackendConfig  string
+	InstalledMod   string
+	ProxyConfigSet bool
+}
+
+// ConfigureModernForwarding sets up Velocity modern forwarding between a
+// Velocity proxy directory and a backend server directory. An existing
+// forwarding.secret is reused; otherwise a new one is generated.
+func ConfigureModernForwarding(proxyDir, serverDir string) (*ForwardingResult, error) {
+	backend, mcVersion, err := detectBackend(serverDir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ForwardingResult{Backend: backend}
+
+	secret, created, err := ensureSecret(proxyDir)
+	if err != nil {
+		return nil, err
+	}
+	result.SecretCreated = created
+
+	// Proxy side: velocity.toml
+	velocityToml := filepath.Join(proxyDir, "velocity.toml")
+	if data, err := os.ReadFile(velocityToml); err == nil {
+		content := setTOMLKey(string(data), "", "player-info-forwarding-mode", `"modern"`)
+		content = setTOMLKey(content, "", "forwarding-secret-file", `"`+secretFileName+`"`)
+		if err := os.WriteFile(velocityToml, []byte(content), 0644); err != nil {
+			return nil, fmt.Errorf("failed to update velocity.toml: %w", err)
+		}
+		result.ProxyConfigSet = true
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read velocity.toml: %w", err)
+	}
+
+	// Backend side
+	switch backend {
+	case BackendPaper:
+		result.BackendConfig = filepath.Join(serverDir, "config", "paper-global.yml")
+		err = updateFile(result.BackendConfig, func(content string) string {
+			return setYAMLKeys(content, []string{"proxies", "velocity"}, map[string]string{
+				"enabled":     "true",
+				"online-mode": "true",
+				"secret":      "'" + secret + "'",
+			}, []string{"enabled", "online-mode", "secret"})
+		})
+
+	case BackendPaperLegacy:
+		result.BackendConfig = filepath.Join(serverDir, "paper.yml")
+		err = updateFile(result.BackendConfig, func(content string) string {
+			return setYAMLKeys(content, []string{"settings", "velocity-support"}, map[string]string{
+				"enabled":     "true",
+				"online-mode": "true",
+				"secret":      "'" + secret + "'",
+			}, []string{"enabled", "online-mode", "secret"})
+		})
+
+	case BackendForge:
+		result.InstalledMod, err = installForwardingMod(serverDir, forgeForwardingMod, "forge", mcVersion)
+		if err != nil {
+			return nil, err
+		}
+		result.BackendConfig = filepath.Join(serverDir, "config", "pcf-common.toml")
+		err = updateFile(result.BackendConfig, func(content string) string {
+			return setTOMLKey(content, "modernForwarding", "forwardingSecret", `"`+secret+`"`)
+		})
+
+	case BackendFabric:
+		result.InstalledMod, err = installForwardingMod(serverDir, fabricForwardingMod, "fabric", mcVersion)
+		if err != nil {
+			return nil, err
+		}
+		result.BackendConfig = filepath.Join(serverDir, "config", "FabricProxy-Lite.toml")
+		err = updateFile(result.BackendConfig, func(content string) string {
+			return setTOMLKey(content, "", "secret", `"`+secret+`"`)
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure backend: %w", err)
+	}
+
+	return result, nil
+}
+
+// VerifyForwarding checks that the proxy and backend agree on the secret
+func VerifyForwarding(proxyDir, serverDir string) error {
+	proxySecret, err := readSecret(proxyDir)
+	if err != nil {
+		return err
+	}
+
+	backend, _, err := detectBackend(serverDir)
+	if err != nil {
+		return err
+	}
+
+	var backendSecret string
+	switch backend {
+	case BackendPaper:
+		data, _ := os.ReadFile(filepath.Join(serverDir, "config", "paper-global.yml"))
+		backendSecret = getYAMLKey(string(data), []string{"proxies", "velocity"}, "secret")
+	case BackendPaperLegacy:
+		data, _ := os.ReadFile(filepath.Join(serverDir, "paper.yml"))
+		backendSecret = getYAMLKey(string(data), []string{"settings", "velocity-support"}, "secret")
+	case BackendForge:
+		data, _ := os.ReadFile(filepath.Join(serverDir, "config", "pcf-common.toml"))
+		backendSecret = getTOMLKey(string(data), "modernForwarding", "forwardingSecret")
+	case BackendFabric:
+		data, _ := os.ReadFile(filepath.Join(serverDir, "config", "FabricProxy-Lite.toml"))
+		backendSecret = getTOMLKey(string(data), "", "secret")
+	}
+
+	if backendSecret == "" {
+		return fmt.Errorf("backend has no forwarding secret configured")
+	}
+	if backendSecret != proxySecret {
+		return fmt.Errorf("backend forwarding secret does not match %s", filepath.Join(proxyDir, secretFileName))
+	}
+
+	if data, err := os.ReadFile(filepath.Join(proxyDir, "velocity.toml")); err == nil {
+		if mode := getTOMLKey(string(data), "", "player-info-forwarding-mode"); mode != "modern" {
+			return fmt.Errorf("velocity.toml forwarding mode is %q, expected \"modern\"", mode)
+		}
+	}
+
+	return nil
+}
+
+// detectBackend determines the backend type and, for modded servers, the
+// Minecraft version needed to pick a forwarding mod build
+func detectBackend(serverDir string) (Backend, string, error) {
+	forgeDirs, _ := filepath.Glob(filepath.Join(serverDir, "libraries/net/minecraftforge/forge/*"))
+	if len(forgeDirs) > 0 {
+		// Directory names are "<mc>-<forge>", e.g. "1.20.1-47.2.0"
+		mcVersion, _, _ := strings.Cut(filepath.Base(forgeDirs[0]), "-")
+		return BackendForge, mcVersion, nil
+	}
+
+	if _, err := os.Stat(filepath.Join(serverDir, "libraries/net/neoforged")); err == nil {
+		return "", "", fmt.Errorf("modern forwarding is not supported for NeoForge servers yet")
+	}
+
+	if _, err := os.Stat(filepath.Join(serverDir, "config", "paper-global.yml")); err == nil {
+		return BackendPaper, "", nil
+	}
+	if _, err := os.Stat(filepath.Join(serverDir, "paper.yml")); err == nil {
+		return BackendPaperLegacy, "", nil
+	}
+
+	entries, err := os.ReadDir(serverDir)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read server directory: %w", err)
+	}
+	for _, entry := range entries {
+		name := strings.ToLower(entry.Name())
+		if !strings.HasSuffix(name, ".jar") {
+			continue
+		}
+		if strings.HasPrefix(name, "paper") || strings.HasPrefix(name, "purpur") {
+			return BackendPaper, "", nil
+		}
+	}
+
+	versions, _ := filepath.Glob(filepath.Join(serverDir, ".fabric/server/*"))
+	for _, v := range versions {
+		// Fabric caches "<mc>-server.jar" here
+		if strings.HasSuffix(v, "-server.jar") {
+			return BackendFabric, strings.TrimSuffix(filepath.Base(v), "-server.jar"), nil
+		}
+	}
+
+	return "", "", fmt.Errorf("could not detect a backend that supports modern forwarding (Paper, Forge, Fabric)")
+}
+
+// installForwardingMod downloads the forwarding mod for a modded backend
+func installForwardingMod(serverDir, project, loader, mcVersion string) (string, error) {
+	if mcVersion == "" {
+		return "", fmt.Errorf("could not determine Minecraft version for %s", project)
+	}
+
+	modsDir := filepath.Join(serverDir, "mods")
+	if matches, _ := filepath.Glob(filepath.Join(modsDir, "*"+project+"*.jar")); len(matches) > 0 {
+		return "", nil
+	}
+
+	mr := modrinth.NewClient()
+	version, err := mr.LatestVersion(project, loader, mcVersion)
+	if err != nil {
+		return "", fmt.Errorf("failed to find forwarding mod: %w", err)
+	}
+
+	file := version.PrimaryFile()
+	if file == nil {
+		return "", fmt.Errorf("%s %s has no files", project, version.VersionNumber)
+	}
+
+	if err := os.MkdirAll(modsDir, 0755); err != nil {
+		return "", err
+	}
+	if err := mr.Download(file, filepath.Join(modsDir, file.Filename)); err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", project, err)
+	}
+
+	return file.Filename, nil
+}
+
+// ensureSecret returns the proxy's forwarding secret, generating one if needed
+func ensureSecret(proxyDir string) (string, bool, error) {
+	if secret, err := readSecret(proxyDir); err == nil {
+		return secret, false, nil
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", false, fmt.Errorf("failed to generate secret: %w", err)
+	}
+	secret := hex.EncodeToString(buf)
+
+	if err := os.MkdirAll(proxyDir, 0755); err != nil {
+		return "", false, err
+	}
+	if err := os.WriteFile(filepath.Join(proxyDir, secretFileName), []byte(secret), 0600); err != nil {
+		return "", false, fmt.Errorf("failed to write forwarding secret: %w", err)
+	}
+
+	return secret, true, nil
+}
+
+func readSecret(proxyDir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(proxyDir, secretFileName))
+	if err != nil {
+		return "", fmt.Errorf("failed to read forwarding secret: %w", err)
+	}
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", fmt.Errorf("forwarding secret is empty")
+	}
+	return secret, nil
+}
+
+// updateFile applies edit to a file's contents, creating it if missing
+func updateFile(path string, edit func(string) string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(edit(string(data))), 0644)
+}
@@ -0,0 +1,193 @@
+package query
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Packet types of the GameSpy4 (UT3) query protocol used by Minecraft
+const (
+	typeHandshake byte = 0x09
+	typeStat      byte = 0x00
+)
+
+var magic = []byte{0xFE, 0xFD}
+
+// FullStat is the response to a full stat query
+type FullStat struct {
+	MOTD       string
+	GameType   string
+	Version    string
+	Software   string   // e.g. "Paper on 1.20.4"
+	Plugins    []string // "Name Version" entries
+	Map        string
+	NumPlayers int
+	MaxPlayers int
+	HostPort   int
+	HostIP     string
+	Players    []string
+}
+
+// Client queries a server's UDP query port
+type Client struct {
+	Address string
+	Timeout time.Duration
+}
+
+// NewClient creates a query client for host:port
+func NewClient(address string) *Client {
+	return &Client{
+		Address: address,
+		Timeout: 3 * time.Second,
+	}
+}
+
+// FullStat performs a handshake and a full stat request
+func (c *Client) FullStat() (*FullStat, error) {
+	conn, err := net.DialTimeout("udp", c.Address, c.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close()
+
+	sessionID := rand.Int31() & 0x0F0F0F0F
+
+	token, err := c.handshake(conn, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var req bytes.Buffer
+	req.Write(magic)
+	req.WriteByte(typeStat)
+	binary.Write(&req, binary.BigEndian, sessionID)
+	binary.Write(&req, binary.BigEndian, token)
+	req.Write([]byte{0, 0, 0, 0}) // padding requests the full stat
+
+	resp, err := c.roundTrip(conn, req.Bytes(), typeStat, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseFullStat(resp)
+}
+
+func (c *Client) handshake(conn net.Conn, sessionID int32) (int32, error) {
+	var req bytes.Buffer
+	req.Write(magic)
+	req.WriteByte(typeHandshake)
+	binary.Write(&req, binary.BigEndian, sessionID)
+
+	resp, err := c.roundTrip(conn, req.Bytes(), typeHandshake, sessionID)
+	if err != nil {
+		return 0, fmt.Errorf("handshake failed: %w", err)
+	}
+
+	token, err := strconv.ParseInt(string(bytes.TrimRight(resp, "\x00")), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid challenge token: %w", err)
+	}
+	return int32(token), nil
+}
+
+// roundTrip sends a request and returns the response payload after the
+// type and session ID header
+func (c *Client) roundTrip(conn net.Conn, req []byte, wantType byte, sessionID int32) ([]byte, error) {
+	conn.SetDeadline(time.Now().Add(c.Timeout))
+
+	if _, err := conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 65535)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	if n < 5 || buf[0] != wantType || int32(binary.BigEndian.Uint32(buf[1:5])) != sessionID {
+		return nil, fmt.Errorf("unexpected response")
+	}
+
+	return buf[5:n], nil
+}
+
+// parseFullStat decodes the key/value section and player list
+func parseFullStat(data []byte) (*FullStat, error) {
+	// 11 bytes of constant padding ("splitnum\x00\x80\x00")
+	if len(data) < 11 {
+		return nil, fmt.Errorf("response too short")
+	}
+	data = data[11:]
+
+	fields := map[string]string{}
+	for {
+		key, rest, ok := readString(data)
+		if !ok {
+			return nil, fmt.Errorf("truncated key/value section")
+		}
+		data = rest
+		if key == "" {
+			break
+		}
+		value, rest, ok := readString(data)
+		if !ok {
+			return nil, fmt.Errorf("truncated key/value section")
+		}
+		data = rest
+		fields[key] = value
+	}
+
+	// 10 bytes of constant padding ("\x01player_\x00\x00")
+	stat := &FullStat{
+		MOTD:     fields["hostname"],
+		GameType: fields["gametype"],
+		Version:  fields["version"],
+		Map:      fields["map"],
+		HostIP:   fields["hostip"],
+	}
+	stat.NumPlayers, _ = strconv.Atoi(fields["numplayers"])
+	stat.MaxPlayers, _ = strconv.Atoi(fields["maxplayers"])
+	stat.HostPort, _ = strconv.Atoi(fields["hostport"])
+
+	// "Software: Plugin1 1.0; Plugin2 2.0"
+	if plugins := fields["plugins"]; plugins != "" {
+		software, list, found := strings.Cut(plugins, ":")
+		stat.Software = strings.TrimSpace(software)
+		if found {
+			for _, p := range strings.Split(list, ";") {
+				if p = strings.TrimSpace(p); p != "" {
+					stat.Plugins = append(stat.Plugins, p)
+				}
+			}
+		}
+	}
+
+	if len(data) >= 10 {
+		data = data[10:]
+		for {
+			name, rest, ok := readString(data)
+			if !ok || name == "" {
+				break
+			}
+			data = rest
+			stat.Players = append(stat.Players, name)
+		}
+	}
+
+	return stat, nil
+}
+
+// readString reads a null-terminated string
+func readString(data []byte) (string, []byte, bool) {
+	idx := bytes.IndexByte(data, 0)
+	if idx < 0 {
+		return "", nil, false
+	}
+	return string(data[:idx]), data[idx+1:], true
+}
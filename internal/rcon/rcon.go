@@ -0,0 +1,167 @@
+package rcon
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Packet types of the Source RCON protocol used by Minecraft
+const (
+	typeResponse int32 = 0
+	typeCommand  int32 = 2
+	typeAuth     int32 = 3
+)
+
+// Minecraft refuses requests larger than this
+const maxPayload = 1446
+
+// ErrAuth is returned when the server rejects the password
+var ErrAuth = errors.New("rcon password rejected")
+
+// Client is an authenticated RCON connection. It is safe for concurrent
+// use; commands are sent one at a time.
+type Client struct {
+	Address string
+	Timeout time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	nextID int32
+}
+
+// Dial connects to host:port and logs in with password
+func Dial(address, password string) (*Client, error) {
+	c := &Client{Address: address, Timeout: 10 * time.Second, nextID: 1}
+
+	conn, err := net.DialTimeout("tcp", address, c.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	c.conn, c.reader = conn, bufio.NewReader(conn)
+
+	c.conn.SetDeadline(time.Now().Add(c.Timeout))
+	id := c.id()
+	if err := c.write(id, typeAuth, password); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// The server answers the login with an auth response, id -1 on a bad
+	// password; some send an empty response packet first
+	for {
+		gotID, typ, _, err := c.read()
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("login failed: %w", err)
+		}
+		if gotID == -1 {
+			conn.Close()
+			return nil, ErrAuth
+		}
+		if typ == typeCommand && gotID == id {
+			break
+		}
+	}
+	return c, nil
+}
+
+// Command runs a console command and returns what the server replied
+func (c *Client) Command(command string) (string, error) {
+	if len(command) > maxPayload {
+		return "", fmt.Errorf("command too long for rcon (%d bytes)", len(command))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return "", net.ErrClosed
+	}
+	c.conn.SetDeadline(time.Now().Add(c.Timeout))
+
+	// Long replies are split over several packets with no end marker, so a
+	// request of an unknown type follows, which the server answers only
+	// once the reply is complete
+	id, end := c.id(), c.id()
+	if err := c.write(id, typeCommand, command); err != nil {
+		return "", err
+	}
+	if err := c.write(end, typeResponse, ""); err != nil {
+		return "", err
+	}
+
+	var reply strings.Builder
+	for {
+		gotID, _, body, err := c.read()
+		if err != nil {
+			return "", err
+		}
+		switch gotID {
+		case id:
+			reply.WriteString(body)
+		case end:
+			return reply.String(), nil
+		}
+	}
+}
+
+// Close ends the connection
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+func (c *Client) id() int32 {
+	id := c.nextID
+	c.nextID++
+	if c.nextID <= 0 {
+		c.nextID = 1
+	}
+	return id
+}
+
+// write sends one packet: length, id, type, then the body and two NULs
+func (c *Client) write(id, typ int32, body string) error {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, int32(len(body)+10))
+	binary.Write(&buf, binary.LittleEndian, id)
+	binary.Write(&buf, binary.LittleEndian, typ)
+	buf.WriteString(body)
+	buf.Write([]byte{0, 0})
+	if _, err := c.conn.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to send: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) read() (id, typ int32, body string, err error) {
+	var length int32
+	if err = binary.Read(c.reader, binary.LittleEndian, &length); err != nil {
+		return
+	}
+	if length < 10 || length > 1<<20 {
+		err = fmt.Errorf("invalid packet length %d", length)
+		return
+	}
+	data := make([]byte, length)
+	if _, err = io.ReadFull(c.reader, data); err != nil {
+		return
+	}
+	id = int32(binary.LittleEndian.Uint32(data[0:4]))
+	typ = int32(binary.LittleEndian.Uint32(data[4:8]))
+	body = string(bytes.TrimRight(data[8:], "\x00"))
+	return
+}
@@ -0,0 +1,223 @@
+// Package recording saves console output with timestamps and plays it back
+// at its original pace, or faster, for investigating what led to a crash.
+//
+// A recording is JSON lines: a header object, then one [seconds, "line"]
+// array per console line, seconds counted from the start.
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dir holds the recordings, relative to the server dir
+const Dir = ".mcserver/recordings"
+
+const ext = ".jsonl"
+
+// Header starts every recording
+type Header struct {
+	Version int       `json:"version"`
+	Started time.Time `json:"started"`
+	Server  string    `json:"server"`
+}
+
+// Frame is one recorded console line
+type Frame struct {
+	Offset time.Duration
+	Line   string
+}
+
+// Recorder writes a recording. It is safe for concurrent use, since stdout
+// and stderr are read separately.
+type Recorder struct {
+	mu      sync.Mutex
+	file    *os.File
+	w       *bufio.Writer
+	started time.Time
+	Path    string
+}
+
+// Create starts a new recording in serverDir and removes the oldest ones
+// beyond keep
+func Create(serverDir string, keep int) (*Recorder, error) {
+	dir := filepath.Join(serverDir, Dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	path := filepath.Join(dir, started.Format("2006-01-02_15-04-05")+ext)
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %w", err)
+	}
+	r := &Recorder{file: file, w: bufio.NewWriter(file), started: started, Path: path}
+
+	header, _ := json.Marshal(Header{Version: 1, Started: started, Server: filepath.Base(serverDir)})
+	r.w.Write(append(header, '\n'))
+	r.w.Flush()
+
+	prune(dir, keep)
+	return r, nil
+}
+
+// Write records a console line
+func (r *Recorder) Write(line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return
+	}
+	frame, _ := json.Marshal([]any{roundSeconds(time.Since(r.started)), line})
+	r.w.Write(append(frame, '\n'))
+	// Flush per line so a crash of the manager itself loses nothing
+	r.w.Flush()
+}
+
+// Close finishes the recording
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	r.w.Flush()
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+func roundSeconds(d time.Duration) float64 {
+	return float64(d.Milliseconds()) / 1000
+}
+
+// List returns the recordings in serverDir, newest first
+func List(serverDir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(serverDir, Dir, "*"+ext))
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(matches)))
+	return matches, nil
+}
+
+func prune(dir string, keep int) {
+	if keep <= 0 {
+		return
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "*"+ext))
+	sort.Strings(matches)
+	for len(matches) > keep {
+		os.Remove(matches[0])
+		matches = matches[1:]
+	}
+}
+
+// Player reads a recording back
+type Player struct {
+	Header Header
+	file   *os.File
+	sc     *bufio.Scanner
+}
+
+// Open opens a recording for playback
+func Open(path string) (*Player, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	sc := bufio.NewScanner(file)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	p := &Player{file: file, sc: sc}
+	if !sc.Scan() || json.Unmarshal(sc.Bytes(), &p.Header) != nil || p.Header.Version == 0 {
+		file.Close()
+		return nil, fmt.Errorf("%s is not a console recording", filepath.Base(path))
+	}
+	return p, nil
+}
+
+// Next returns the next frame, or false at the end
+func (p *Player) Next() (Frame, bool) {
+	for p.sc.Scan() {
+		var raw []json.RawMessage
+		if json.Unmarshal(p.sc.Bytes(), &raw) != nil || len(raw) != 2 {
+			continue
+		}
+		var seconds float64
+		var line string
+		if json.Unmarshal(raw[0], &seconds) != nil || json.Unmarshal(raw[1], &line) != nil {
+			continue
+		}
+		return Frame{Offset: time.Duration(seconds * float64(time.Second)), Line: line}, true
+	}
+	return Frame{}, false
+}
+
+// Play sends every frame to out, paced like the original divided by speed.
+// Pauses longer than maxIdle are cut to it, so quiet stretches don't stall
+// a replay; 0 keeps them. Closing stop ends playback early.
+func (p *Player) Play(speed float64, maxIdle time.Duration, stop <-chan struct{}, out func(Frame)) {
+	if speed <= 0 {
+		speed = 1
+	}
+	var last time.Duration
+	for {
+		frame, ok := p.Next()
+		if !ok {
+			return
+		}
+		wait := time.Duration(float64(frame.Offset-last) / speed)
+		if maxIdle > 0 && wait > maxIdle {
+			wait = maxIdle
+		}
+		last = frame.Offset
+		if wait > 0 {
+			select {
+			case <-stop:
+				return
+			case <-time.After(wait):
+			}
+		}
+		out(frame)
+	}
+}
+
+// Close closes the recording
+func (p *Player) Close() error {
+	return p.file.Close()
+}
+
+// Resolve finds a recording by path, or by name in serverDir's recordings;
+// an empty name is the newest one
+func Resolve(serverDir, name string) (string, error) {
+	if name == "" {
+		recordings, err := List(serverDir)
+		if err != nil {
+			return "", err
+		}
+		if len(recordings) == 0 {
+			return "", fmt.Errorf("no recordings in %s", filepath.Join(serverDir, Dir))
+		}
+		return recordings[0], nil
+	}
+	if _, err := os.Stat(name); err == nil {
+		return name, nil
+	}
+	path := filepath.Join(serverDir, Dir, name)
+	if !strings.HasSuffix(path, ext) {
+		path += ext
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("no recording %q", name)
+	}
+	return path, nil
+}
@@ -0,0 +1,115 @@
+package respack
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// Host serves a single resource pack zip over HTTP so clients can download
+// it from the URL in server.properties
+type Host struct {
+	path string
+	port int
+
+	mu   sync.RWMutex
+	sha1 string
+
+	listener net.Listener
+}
+
+// NewHost hashes the pack at path and prepares to serve it on port
+func NewHost(path string, port int) (*Host, error) {
+	h := &Host{path: path, port: port}
+	if err := h.Refresh(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Refresh recomputes the SHA-1 after the pack file changed
+func (h *Host) Refresh() error {
+	sum, err := fileSHA1(h.path)
+	if err != nil {
+		return fmt.Errorf("failed to hash resource pack: %w", err)
+	}
+	h.mu.Lock()
+	h.sha1 = sum
+	h.mu.Unlock()
+	return nil
+}
+
+// SHA1 returns the hex digest for resource-pack-sha1
+func (h *Host) SHA1() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.sha1
+}
+
+// URL returns the download address for players connecting via host. The
+// hash is part of the path so clients never reuse a stale cached copy, and
+// the file name is escaped so spaces or "#" in it survive.
+func (h *Host) URL(host string) string {
+	return fmt.Sprintf("http://%s/%s/%s", net.JoinHostPort(host, strconv.Itoa(h.port)), h.SHA1(), url.PathEscape(filepath.Base(h.path)))
+}
+
+// Start listens on the configured port; it is a no-op if already serving
+func (h *Host) Start() error {
+	if h.listener != nil {
+		return nil
+	}
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", h.port))
+	if err != nil {
+		return fmt.Errorf("failed to listen for resource pack downloads: %w", err)
+	}
+	h.listener = ln
+	go http.Serve(ln, h)
+	return nil
+}
+
+// Close stops serving
+func (h *Host) Close() error {
+	if h.listener == nil {
+		return nil
+	}
+	err := h.listener.Close()
+	h.listener = nil
+	return err
+}
+
+// ServeHTTP answers GET /<sha1>/<file>; any other path is a 404 so the
+// port does not expose anything but the pack
+func (h *Host) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.URL.Path != "/"+h.SHA1()+"/"+filepath.Base(h.path) {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	http.ServeFile(w, r, h.path)
+}
+
+func fileSHA1(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha1.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,101 @@
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// HelperCommand is the hidden mcserver subcommand that applies a policy to
+// itself and then execs the real server, so the JVM keeps the helper's pid
+const HelperCommand = "sandbox-exec"
+
+// Policy lists the paths the sandboxed process may touch. Anything not
+// beneath one of them is off limits once the policy is enforced.
+type Policy struct {
+	ReadWrite []string
+	ReadOnly  []string // read and execute
+
+	// Run anyway, with a warning, when the kernel can't enforce the
+	// filesystem allowlist
+	BestEffort bool
+}
+
+// systemReadOnly is what a JVM needs from the host: libraries, certs,
+// timezone data, DNS config and /proc, /sys for its own introspection
+var systemReadOnly = []string{"/usr", "/lib", "/lib64", "/bin", "/etc", "/proc", "/sys", "/opt"}
+
+// ForServer builds the policy for a server directory and the java binary,
+// plus any extra read-write paths the user allowed
+func ForServer(serverDir, javaPath string, extra []string) (*Policy, error) {
+	javaBin, err := exec.LookPath(javaPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find java: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(javaBin); err == nil {
+		javaBin = resolved
+	}
+	// .../jdk/bin/java -> .../jdk
+	javaHome := filepath.Dir(filepath.Dir(javaBin))
+
+	policy := &Policy{
+		ReadWrite: append([]string{serverDir, "/dev"}, extra...),
+		ReadOnly:  append([]string{javaHome}, systemReadOnly...),
+	}
+	return policy, nil
+}
+
+// Env returns the restricted environment for the server: only what the
+// JVM needs, with HOME and TMPDIR inside the server directory
+func Env(serverDir string) []string {
+	env := []string{
+		"HOME=" + serverDir,
+		"TMPDIR=" + TmpDir(serverDir),
+	}
+	for _, key := range []string{"PATH", "LANG", "LC_ALL", "TZ", "JAVA_HOME", "TERM"} {
+		if value, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+value)
+		}
+	}
+	return env
+}
+
+// TmpDir is the server's private temporary directory
+func TmpDir(serverDir string) string {
+	return filepath.Join(serverDir, ".mcserver", "tmp")
+}
+
+// Wrap returns the helper invocation that runs name with args under policy
+func Wrap(policy *Policy, name string, args []string) (string, []string, error) {
+	if err := supported(); err != nil {
+		return "", nil, err
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to locate mcserver binary: %w", err)
+	}
+
+	helperArgs := []string{HelperCommand}
+	if policy.BestEffort {
+		helperArgs = append(helperArgs, "--best-effort")
+	}
+	for _, p := range policy.ReadWrite {
+		helperArgs = append(helperArgs, "--rw", p)
+	}
+	for _, p := range policy.ReadOnly {
+		helperArgs = append(helperArgs, "--ro", p)
+	}
+	helperArgs = append(helperArgs, "--", name)
+	return self, append(helperArgs, args...), nil
+}
+
+// Describe summarises a policy for the event log
+func (p *Policy) Describe() string {
+	desc := fmt.Sprintf("read-write %s", strings.Join(p.ReadWrite, ", "))
+	if p.BestEffort {
+		desc += " (best effort)"
+	}
+	return desc
+}
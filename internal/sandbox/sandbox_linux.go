@@ -0,0 +1,112 @@
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Filesystem rights handled by each Landlock ABI version
+var landlockABIAccess = map[int]uint64{
+	1: 1<<13 - 1,
+	2: 1<<14 - 1, // + REFER
+	3: 1<<15 - 1, // + TRUNCATE
+}
+
+const readOnlyAccess = unix.LANDLOCK_ACCESS_FS_EXECUTE | unix.LANDLOCK_ACCESS_FS_READ_FILE | unix.LANDLOCK_ACCESS_FS_READ_DIR
+
+func supported() error {
+	return nil
+}
+
+// Exec applies no-new-privileges and the filesystem policy, then replaces
+// this process with argv. It only returns on failure, which includes a
+// kernel without Landlock unless the policy is BestEffort; then it runs
+// with a warning.
+func Exec(policy *Policy, argv []string, env []string) error {
+	// Both settings are per thread and survive exec only from this one
+	runtime.LockOSThread()
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to set no-new-privileges: %w", err)
+	}
+
+	if err := restrictFilesystem(policy); err != nil {
+		if !policy.BestEffort {
+			return fmt.Errorf("filesystem allowlist not enforced: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "[mcserver] Warning: filesystem allowlist not enforced: %v\n", err)
+	}
+
+	path, err := exec.LookPath(argv[0])
+	if err != nil {
+		return err
+	}
+	return unix.Exec(path, argv, env)
+}
+
+func restrictFilesystem(policy *Policy) error {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return fmt.Errorf("Landlock is not available on this kernel")
+	}
+	version := int(abi)
+	if version > 3 {
+		version = 3
+	}
+	handled := landlockABIAccess[version]
+
+	attr := unix.LandlockRulesetAttr{Access_fs: handled}
+	fd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("failed to create Landlock ruleset: %w", errno)
+	}
+	defer unix.Close(int(fd))
+
+	for _, p := range policy.ReadWrite {
+		if err := addPathRule(int(fd), p, handled); err != nil {
+			return err
+		}
+	}
+	for _, p := range policy.ReadOnly {
+		if err := addPathRule(int(fd), p, readOnlyAccess&handled); err != nil {
+			return err
+		}
+	}
+
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, fd, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to enforce Landlock ruleset: %w", errno)
+	}
+	return nil
+}
+
+func addPathRule(rulesetFD int, path string, access uint64) error {
+	pathFD, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		// Missing system paths (no /lib64 on some distros) are fine
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer unix.Close(pathFD)
+
+	// Directories take every right; files cannot hold directory rights
+	var st unix.Stat_t
+	if err := unix.Fstat(pathFD, &st); err == nil && st.Mode&unix.S_IFMT != unix.S_IFDIR {
+		access &= unix.LANDLOCK_ACCESS_FS_EXECUTE | unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+			unix.LANDLOCK_ACCESS_FS_READ_FILE | unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+
+	rule := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(pathFD)}
+	_, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(rulesetFD), unix.LANDLOCK_RULE_PATH_BENEATH,
+		uintptr(unsafe.Pointer(&rule)), 0, 0, 0)
+	if errno != 0 {
+		return fmt.Errorf("failed to allow %s: %w", path, errno)
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package sandbox
+
+import "fmt"
+
+func supported() error {
+	return fmt.Errorf("sandboxing is only supported on Linux")
+}
+
+// Exec is only implemented on Linux
+func Exec(policy *Policy, argv []string, env []string) error {
+	return supported()
+}
@@ -0,0 +1,329 @@
+package scripting
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+// Dir holds user scripts, relative to the server dir
+const Dir = ".mcserver/scripts"
+
+const (
+	// Work one hook call may do before it is aborted, so a runaway loop
+	// cannot wedge the other scripts
+	maxSteps    = 5_000_000
+	callTimeout = 5 * time.Second
+
+	queueSize   = 256
+	minInterval = time.Second
+)
+
+// Host is everything a script can reach. The server implements it; scripts
+// never see the process, the filesystem or the network directly.
+type Host interface {
+	SendCommand(command string) error
+	Players() []string
+	// Notify raises a notification on behalf of a script
+	Notify(script, message string)
+	// Print shows script output and errors to the operator
+	Print(script, message string)
+}
+
+// Runtime runs the scripts in Dir. Hooks are queued and run one at a time
+// on a single goroutine, so scripts never race each other or the caller.
+type Runtime struct {
+	host    Host
+	scripts []*script
+	queue   chan func()
+
+	mu     sync.Mutex
+	timers map[*time.Timer]struct{}
+	closed bool
+	done   chan struct{}
+}
+
+type script struct {
+	name    string
+	globals starlark.StringDict
+}
+
+// Load runs every *.star file in the server's script dir. Scripts that fail
+// to load are skipped and reported in the returned error; the runtime is
+// nil only when there are no scripts at all.
+func Load(serverDir string, host Host) (*Runtime, error) {
+	files, err := filepath.Glob(filepath.Join(serverDir, Dir, "*.star"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+	sort.Strings(files)
+
+	rt := &Runtime{
+		host:   host,
+		queue:  make(chan func(), queueSize),
+		timers: make(map[*time.Timer]struct{}),
+		done:   make(chan struct{}),
+	}
+
+	var errs []error
+	for _, file := range files {
+		sc, err := rt.load(file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		rt.scripts = append(rt.scripts, sc)
+	}
+
+	go rt.run()
+	return rt, errors.Join(errs...)
+}
+
+func (rt *Runtime) load(file string) (*script, error) {
+	name := strings.TrimSuffix(filepath.Base(file), ".star")
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read script %s: %w", name, err)
+	}
+
+	sc := &script{name: name}
+	predeclared := starlark.StringDict{
+		"server": rt.module(sc),
+		// Predeclared values are not frozen with the globals, so this is
+		// where a script keeps state between hook calls
+		"state": starlark.NewDict(0),
+	}
+	thread := rt.thread(sc)
+	opts := &syntax.FileOptions{Set: true, While: true, TopLevelControl: true, GlobalReassign: true}
+	globals, err := starlark.ExecFileOptions(opts, thread, file, src, predeclared)
+	if err != nil {
+		return nil, fmt.Errorf("script %s: %s", name, describe(err))
+	}
+	sc.globals = globals
+	return sc, nil
+}
+
+// Fire calls hook(args...) in every script that defines it. It does not
+// wait for the scripts to run.
+func (rt *Runtime) Fire(hook string, args ...any) {
+	if rt == nil {
+		return
+	}
+	values := make(starlark.Tuple, len(args))
+	for i, a := range args {
+		values[i] = toValue(a)
+	}
+	rt.enqueue(func() {
+		for _, sc := range rt.scripts {
+			if fn, ok := sc.globals[hook].(starlark.Callable); ok {
+				rt.call(sc, fn, values)
+			}
+		}
+	})
+}
+
+// Close cancels pending timers and stops the runtime; queued hooks that
+// have not started are discarded
+func (rt *Runtime) Close() {
+	if rt == nil {
+		return
+	}
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if rt.closed {
+		return
+	}
+	rt.closed = true
+	for t := range rt.timers {
+		t.Stop()
+	}
+	close(rt.done)
+}
+
+// Names returns the loaded scripts
+func (rt *Runtime) Names() []string {
+	if rt == nil {
+		return nil
+	}
+	names := make([]string, len(rt.scripts))
+	for i, sc := range rt.scripts {
+		names[i] = sc.name
+	}
+	return names
+}
+
+func (rt *Runtime) run() {
+	for {
+		select {
+		case <-rt.done:
+			return
+		case job := <-rt.queue:
+			job()
+		}
+	}
+}
+
+func (rt *Runtime) enqueue(job func()) {
+	rt.mu.Lock()
+	closed := rt.closed
+	rt.mu.Unlock()
+	if closed {
+		return
+	}
+	select {
+	case rt.queue <- job:
+	default:
+		rt.host.Print("scripts", "hook queue full, dropping a call")
+	}
+}
+
+func (rt *Runtime) call(sc *script, fn starlark.Callable, args starlark.Tuple) {
+	thread := rt.thread(sc)
+	timer := time.AfterFunc(callTimeout, func() { thread.Cancel("timed out") })
+	defer timer.Stop()
+
+	if _, err := starlark.Call(thread, fn, args, nil); err != nil {
+		rt.host.Print(sc.name, fmt.Sprintf("%s: %s", fn.Name(), describe(err)))
+	}
+}
+
+func (rt *Runtime) thread(sc *script) *starlark.Thread {
+	thread := &starlark.Thread{
+		Name:  sc.name,
+		Print: func(_ *starlark.Thread, msg string) { rt.host.Print(sc.name, msg) },
+		Load: func(*starlark.Thread, string) (starlark.StringDict, error) {
+			return nil, fmt.Errorf("load is not supported")
+		},
+	}
+	thread.SetMaxExecutionSteps(maxSteps)
+	return thread
+}
+
+// schedule runs fn after interval, and keeps re-arming if repeat is set
+func (rt *Runtime) schedule(sc *script, fn starlark.Callable, interval time.Duration, repeat bool) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if rt.closed {
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(interval, func() {
+		rt.mu.Lock()
+		delete(rt.timers, timer)
+		rt.mu.Unlock()
+
+		rt.enqueue(func() {
+			rt.call(sc, fn, nil)
+			if repeat {
+				rt.schedule(sc, fn, interval, true)
+			}
+		})
+	})
+	rt.timers[timer] = struct{}{}
+}
+
+// module builds the "server" object a script calls into
+func (rt *Runtime) module(sc *script) *starlarkstruct.Module {
+	return &starlarkstruct.Module{
+		Name: "server",
+		Members: starlark.StringDict{
+			"command": starlark.NewBuiltin("command", func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+				var command string
+				if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &command); err != nil {
+					return nil, err
+				}
+				if err := rt.host.SendCommand(command); err != nil {
+					return nil, err
+				}
+				return starlark.None, nil
+			}),
+
+			"players": starlark.NewBuiltin("players", func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+				if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+					return nil, err
+				}
+				var names []starlark.Value
+				for _, p := range rt.host.Players() {
+					names = append(names, starlark.String(p))
+				}
+				return starlark.NewList(names), nil
+			}),
+
+			"notify": starlark.NewBuiltin("notify", func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+				var message string
+				if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &message); err != nil {
+					return nil, err
+				}
+				rt.host.Notify(sc.name, message)
+				return starlark.None, nil
+			}),
+
+			"after": rt.scheduleBuiltin(sc, "after", false),
+			"every": rt.scheduleBuiltin(sc, "every", true),
+		},
+	}
+}
+
+func (rt *Runtime) scheduleBuiltin(sc *script, name string, repeat bool) *starlark.Builtin {
+	return starlark.NewBuiltin(name, func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var seconds starlark.Value
+		var fn starlark.Callable
+		if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 2, &seconds, &fn); err != nil {
+			return nil, err
+		}
+		secs, ok := starlark.AsFloat(seconds)
+		if !ok {
+			return nil, fmt.Errorf("%s: seconds must be a number, got %s", b.Name(), seconds.Type())
+		}
+		interval := time.Duration(secs * float64(time.Second))
+		if interval < minInterval {
+			return nil, fmt.Errorf("%s: interval must be at least %s", b.Name(), minInterval)
+		}
+		rt.schedule(sc, fn, interval, repeat)
+		return starlark.None, nil
+	})
+}
+
+func toValue(v any) starlark.Value {
+	switch v := v.(type) {
+	case string:
+		return starlark.String(v)
+	case int:
+		return starlark.MakeInt(v)
+	case float64:
+		return starlark.Float(v)
+	case bool:
+		return starlark.Bool(v)
+	case []string:
+		list := make([]starlark.Value, len(v))
+		for i, s := range v {
+			list[i] = starlark.String(s)
+		}
+		return starlark.NewList(list)
+	case nil:
+		return starlark.None
+	}
+	return starlark.String(fmt.Sprint(v))
+}
+
+// describe includes the Starlark backtrace where there is one
+func describe(err error) string {
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return evalErr.Backtrace()
+	}
+	return err.Error()
+}
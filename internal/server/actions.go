@@ -0,0 +1,97 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"mcserver-manager/internal/audit"
+)
+
+// Action is a manager command, run from the TUI as ":name args" or through
+// the API's action endpoint
+type Action struct {
+	Name  string
+	Usage string
+	Help  string
+	Admin bool // requires the admin role over the API; otherwise operator
+	Run   func(s *Server, args []string) (string, error)
+}
+
+var actions = map[string]*Action{}
+
+func registerAction(a *Action) {
+	actions[a.Name] = a
+}
+
+// Actions returns the registered manager actions sorted by name
+func Actions() []*Action {
+	list := make([]*Action, 0, len(actions))
+	for _, a := range actions {
+		list = append(list, a)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// LookupAction returns the action a command line refers to
+func LookupAction(line string) (*Action, []string, error) {
+	fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(line), ":"))
+	if len(fields) == 0 {
+		return nil, nil, fmt.Errorf("empty action")
+	}
+	a, ok := actions[strings.ToLower(fields[0])]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown action %q (try :help)", fields[0])
+	}
+	return a, fields[1:], nil
+}
+
+// RunAction runs a manager action line ("world use creative") on behalf
+// of a user and records it in the audit log
+func (s *Server) RunAction(source audit.Source, actor, line string) (string, error) {
+	a, args, err := LookupAction(line)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := a.Run(s, args)
+	s.RecordAction(source, actor, strings.TrimPrefix(strings.TrimSpace(line), ":"), "", err)
+	return output, err
+}
+
+// withRestart backs up and stops the server if it is running, runs fn and
+// then starts the server again
+func (s *Server) withRestart(fn func() error) error {
+	s.wake()
+	wasRunning := s.stats.Status == StatusRunning
+	if wasRunning {
+		if err := s.Backup(); err != nil {
+			return fmt.Errorf("backup failed, nothing changed: %w", err)
+		}
+		s.Stop()
+	}
+
+	err := fn()
+	if wasRunning {
+		if startErr := s.Start(); startErr != nil && err == nil {
+			err = startErr
+		}
+	}
+	return err
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "help",
+		Usage: "help",
+		Help:  "List manager actions",
+		Run: func(s *Server, args []string) (string, error) {
+			var b strings.Builder
+			for _, a := range Actions() {
+				fmt.Fprintf(&b, ":%-32s %s\n", a.Usage, a.Help)
+			}
+			return strings.TrimRight(b.String(), "\n"), nil
+		},
+	})
+}
@@ -0,0 +1,225 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"mcserver-manager/internal/stats"
+)
+
+// announcementsFile holds the broadcast rotation, relative to the server dir
+const announcementsFile = ".mcserver/announcements.json"
+
+// announcement is one broadcast message: plain text sent with say, or a
+// tellraw JSON text component. Either may use the {tps}, {uptime},
+// {players}, {max_players} and {next_restart} placeholders.
+type announcement struct {
+	Message string          `json:"message"`
+	Tellraw json.RawMessage `json:"tellraw"`
+
+	// Daily "HH:MM" times to send it at; without any it is part of the
+	// interval rotation
+	At []string `json:"at"`
+}
+
+type announcementConfig struct {
+	Interval int            `json:"interval"` // minutes between rotated messages
+	Messages []announcement `json:"messages"`
+}
+
+// loadAnnouncements reads announcementsFile. A missing file means no
+// announcements.
+func (s *Server) loadAnnouncements() {
+	s.announcements = nil
+	data, err := os.ReadFile(filepath.Join(s.config.ServerDir, announcementsFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			s.addEvent(EventWarning, fmt.Sprintf("Failed to read announcements: %v", err))
+		}
+		return
+	}
+
+	var cfg announcementConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Failed to parse %s: %v", announcementsFile, err))
+		return
+	}
+	for i := range cfg.Messages {
+		a := &cfg.Messages[i]
+		if (a.Message == "") == (len(a.Tellraw) == 0) {
+			s.addEvent(EventWarning, fmt.Sprintf("%s message %d: set exactly one of message and tellraw", announcementsFile, i+1))
+			return
+		}
+		// Console commands are one line
+		if len(a.Tellraw) > 0 {
+			var compact bytes.Buffer
+			json.Compact(&compact, a.Tellraw)
+			a.Tellraw = compact.Bytes()
+		}
+		for _, at := range a.At {
+			if _, err := time.Parse("15:04", at); err != nil {
+				s.addEvent(EventWarning, fmt.Sprintf("%s message %d: invalid time %q, want HH:MM", announcementsFile, i+1, at))
+				return
+			}
+		}
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 15
+	}
+	s.announcements = &cfg
+	if len(cfg.Messages) > 0 {
+		s.addEvent(EventInfo, fmt.Sprintf("Loaded %d announcements", len(cfg.Messages)))
+	}
+}
+
+// announceLoop broadcasts the rotation every interval, and the scheduled
+// messages at their times, while one server process runs and players are
+// online to read them
+func (s *Server) announceLoop() {
+	proc := s.cmd
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	lastRotation := time.Now()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case now := <-ticker.C:
+			if s.cmd != proc {
+				return
+			}
+			cfg := s.announcements
+			if cfg == nil || s.stats.Status != StatusRunning || s.GetStats().PlayerCount == 0 {
+				continue
+			}
+
+			clock := now.Format("15:04")
+			for i := range cfg.Messages {
+				for _, at := range cfg.Messages[i].At {
+					if at == clock {
+						s.announce(&cfg.Messages[i])
+					}
+				}
+			}
+
+			if now.Sub(lastRotation) >= time.Duration(cfg.Interval)*time.Minute {
+				if a := cfg.rotation(&s.announceNext); a != nil {
+					s.announce(a)
+				}
+				lastRotation = now
+			}
+		}
+	}
+}
+
+// rotation returns the next message of the interval rotation, advancing
+// *next, or nil if every message is scheduled
+func (c *announcementConfig) rotation(next *int) *announcement {
+	for range c.Messages {
+		a := &c.Messages[*next%len(c.Messages)]
+		*next = (*next + 1) % len(c.Messages)
+		if len(a.At) == 0 {
+			return a
+		}
+	}
+	return nil
+}
+
+// announce sends a message to every player
+func (s *Server) announce(a *announcement) error {
+	if a.Message != "" {
+		return s.SendCommand("say " + s.expandPlaceholders(a.Message, false))
+	}
+	return s.SendCommand("tellraw @a " + s.expandPlaceholders(string(a.Tellraw), true))
+}
+
+// expandPlaceholders fills in live stats. Inside tellraw JSON the values
+// are escaped as string content.
+func (s *Server) expandPlaceholders(text string, inJSON bool) string {
+	st := s.GetStats()
+	nextRestart := "not scheduled"
+	if at, ok := s.nextRestart(); ok {
+		nextRestart = at.Format("15:04")
+	}
+
+	values := []string{
+		"{tps}", fmt.Sprintf("%.1f", st.TPS),
+		"{uptime}", stats.FormatDurationShort(st.Uptime),
+		"{players}", strconv.Itoa(st.PlayerCount),
+		"{max_players}", strconv.Itoa(st.MaxPlayers),
+		"{next_restart}", nextRestart,
+	}
+	if inJSON {
+		for i := 1; i < len(values); i += 2 {
+			quoted, _ := json.Marshal(values[i])
+			values[i] = string(quoted[1 : len(quoted)-1])
+		}
+	}
+	return strings.NewReplacer(values...).Replace(text)
+}
+
+// nextRestart returns when the pending restart happens at the latest, or
+// else when the next scheduled one is due
+func (s *Server) nextRestart() (time.Time, bool) {
+	if pending := s.PendingRestart(); pending != nil {
+		return pending.At, true
+	}
+	if at, _ := s.nextScheduledRestart(time.Now()); !at.IsZero() {
+		return at, true
+	}
+	return time.Time{}, false
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "announce",
+		Usage: "announce [list|next|<n>]",
+		Help:  "List the announcements, or broadcast the next one in the rotation or number n now",
+		Run: func(s *Server, args []string) (string, error) {
+			cfg := s.announcements
+			if cfg == nil || len(cfg.Messages) == 0 {
+				return "", fmt.Errorf("no announcements configured in %s", announcementsFile)
+			}
+			sub := "list"
+			if len(args) > 0 {
+				sub = args[0]
+			}
+
+			switch sub {
+			case "list":
+				lines := []string{fmt.Sprintf("Every %d minutes while players are online:", cfg.Interval)}
+				for i, a := range cfg.Messages {
+					text := a.Message
+					if text == "" {
+						text = "tellraw " + string(a.Tellraw)
+					}
+					if len(a.At) > 0 {
+						text += " (at " + strings.Join(a.At, ", ") + ")"
+					}
+					lines = append(lines, fmt.Sprintf("  %d. %s", i+1, text))
+				}
+				return strings.Join(lines, "\n"), nil
+
+			case "next":
+				a := cfg.rotation(&s.announceNext)
+				if a == nil {
+					return "", fmt.Errorf("every announcement is scheduled; give its number")
+				}
+				return "Announced", s.announce(a)
+			}
+
+			n, err := strconv.Atoi(sub)
+			if err != nil || n < 1 || n > len(cfg.Messages) {
+				return "", fmt.Errorf("usage: announce [list|next|<1-%d>]", len(cfg.Messages))
+			}
+			return "Announced", s.announce(&cfg.Messages[n-1])
+		},
+	})
+}
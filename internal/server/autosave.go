@@ -0,0 +1,153 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// How often the manager saves when it owns saving and no interval is set:
+// vanilla's own autosave period
+const defaultOwnedAutosave = 5
+
+// How often autosaveLoop looks again while the server is still starting
+const autosaveRecheck = 10 * time.Second
+
+// autosaveInterval returns the time between the manager's saves, 0 when the
+// server saves on its own
+func (s *Server) autosaveInterval() time.Duration {
+	minutes := s.config.AutosaveInterval
+	if s.config.AutosaveOwn && minutes <= 0 {
+		minutes = defaultOwnedAutosave
+	}
+	return time.Duration(max(minutes, 0)) * time.Minute
+}
+
+// markSaved records that the world was just flushed
+func (s *Server) markSaved() {
+	s.lastSave.Store(time.Now().UnixNano())
+}
+
+// pauseSaving turns autosave off and flushes the world for a backup or
+// export, and returns the function that turns it back on, to be deferred
+// so that it also runs on a panic. Manager autosaves wait until then, and
+// count from the flush. The pause is recorded in the state file, so a run
+// that dies in between is undone on the next start.
+func (s *Server) pauseSaving() func() {
+	s.saveMutex.Lock()
+	s.updateState(func(l *Lifetime) { l.SavesOff = time.Now() })
+	s.SendCommand("save-off")
+	s.SendCommand("save-all flush")
+	time.Sleep(2 * time.Second)
+	s.markSaved()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			// When the manager owns saving the server's autosave stays off
+			if !s.config.AutosaveOwn {
+				if err := s.SendCommand("save-on"); err != nil {
+					s.addEvent(EventError, fmt.Sprintf("Could not turn autosave back on: %v", err))
+				}
+			}
+			s.updateState(func(l *Lifetime) { l.SavesOff = time.Time{} })
+			s.saveMutex.Unlock()
+		})
+	}
+}
+
+// resumeLeftoverPause turns autosave back on if a backup or export of an
+// earlier run turned it off and never got to turn it on again. It reports
+// false while a backup of this run holds the save lock.
+func (s *Server) resumeLeftoverPause() bool {
+	if !s.saveMutex.TryLock() {
+		return false
+	}
+	defer s.saveMutex.Unlock()
+	since := s.GetStats().Lifetime.SavesOff
+	if since.IsZero() {
+		return true
+	}
+	if !s.config.AutosaveOwn {
+		if err := s.SendCommand("save-on"); err != nil {
+			s.addEvent(EventError, fmt.Sprintf("Could not turn autosave back on: %v", err))
+			return true
+		}
+		s.addEvent(EventWarning, fmt.Sprintf("Autosave was left off by a backup interrupted at %s; turned it back on", since.Format("2006-01-02 15:04")))
+	}
+	s.updateState(func(l *Lifetime) { l.SavesOff = time.Time{} })
+	return true
+}
+
+// autosave saves the world, unless a backup is already doing so
+func (s *Server) autosave() {
+	if !s.saveMutex.TryLock() {
+		return
+	}
+	defer s.saveMutex.Unlock()
+	if err := s.SendCommand("save-all"); err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Autosave failed: %v", err))
+		return
+	}
+	s.markSaved()
+}
+
+// autosaveLoop saves every autosave interval, counted from the last save
+// so that a backup's flush postpones the next one. Once the server has
+// started it undoes a pause an earlier run left behind, and with
+// AutosaveOwn turns the server's autosave off.
+func (s *Server) autosaveLoop() {
+	proc := s.cmd
+	owned := false
+	resumed := false
+	s.markSaved()
+	for {
+		running := s.cmd == proc && s.GetStats().Status == StatusRunning
+		if running && !resumed {
+			resumed = s.resumeLeftoverPause()
+		}
+		// Re-read each round so a reload can hand saving back and forth
+		if running && owned != s.config.AutosaveOwn && s.saveMutex.TryLock() {
+			owned = s.config.AutosaveOwn
+			if owned {
+				s.SendCommand("save-off")
+				s.addEvent(EventInfo, fmt.Sprintf("Autosave: the manager saves every %s", s.autosaveInterval()))
+			} else {
+				s.SendCommand("save-on")
+			}
+			s.saveMutex.Unlock()
+		}
+
+		var wait time.Duration
+		interval := s.autosaveInterval()
+		switch {
+		case !resumed || !running && interval > 0:
+			wait = autosaveRecheck
+		case interval > 0:
+			wait = max(time.Until(time.Unix(0, s.lastSave.Load()).Add(interval)), time.Second)
+		}
+		var due <-chan time.Time
+		var timer *time.Timer
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			due = timer.C
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.reloadSignal():
+		case <-due:
+			if running && time.Since(time.Unix(0, s.lastSave.Load())) >= s.autosaveInterval() {
+				s.autosave()
+			}
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if s.cmd != proc {
+			// Restarted; the new process has its own loop
+			return
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"mcserver-manager/internal/backup"
+	"mcserver-manager/internal/stats"
+	"mcserver-manager/internal/world"
+)
+
+// BackupContents describes the named backup without extracting it
+func (s *Server) BackupContents(name string) (*backup.BackupInfo, *backup.Contents, error) {
+	info, err := s.backupMgr.Find(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	contents, err := backup.Inspect(info.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return info, contents, nil
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "backup",
+		Usage: "backup now|list|info <name>|verify [name]",
+		Help:  "Back up now, list backups, show what one holds without extracting it, or check backups for corruption",
+		Admin: true,
+		Run:   runBackupAction,
+	})
+}
+
+func runBackupAction(s *Server, args []string) (string, error) {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+
+	switch args[0] {
+	case "now":
+		if err := s.Backup(); err != nil {
+			return "", err
+		}
+		return "Backup completed", nil
+
+	case "list":
+		backups, err := s.ListBackups()
+		if err != nil {
+			return "", err
+		}
+		if len(backups) == 0 {
+			return "No backups in " + s.config.BackupDir, nil
+		}
+		sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+		var lines []string
+		for _, b := range backups {
+			worlds := "no manifest"
+			if manifest, err := backup.ReadManifest(b.Path); err == nil {
+				worlds = strings.Join(manifestWorlds(manifest), ", ")
+			}
+			lines = append(lines, fmt.Sprintf("%-32s %10s  %s", b.Name, stats.FormatBytes(uint64(b.Size)), worlds))
+		}
+		return strings.Join(lines, "\n"), nil
+
+	case "info":
+		if len(args) != 2 {
+			return "", fmt.Errorf("usage: backup info <name>")
+		}
+		info, contents, err := s.BackupContents(args[1])
+		if err != nil {
+			return "", err
+		}
+		return formatBackupContents(info, contents), nil
+
+	case "verify":
+		if len(args) > 2 {
+			return "", fmt.Errorf("usage: backup verify [name]")
+		}
+		return s.verifyBackups(args[1:])
+	}
+	return "", fmt.Errorf("usage: backup now|list|info <name>|verify [name]")
+}
+
+// verifyBackups checks the named backups, or all of them, reporting each
+// on a line and failing if any is damaged
+func (s *Server) verifyBackups(names []string) (string, error) {
+	if len(names) == 0 {
+		backups, err := s.ListBackups()
+		if err != nil {
+			return "", err
+		}
+		if len(backups) == 0 {
+			return "No backups in " + s.config.BackupDir, nil
+		}
+		for _, b := range backups {
+			names = append(names, b.Name)
+		}
+	}
+
+	var lines []string
+	failed := 0
+	for _, name := range names {
+		v, err := s.backupMgr.VerifyBackup(name)
+		if err != nil {
+			failed++
+			if v == nil {
+				return "", err
+			}
+			lines = append(lines, fmt.Sprintf("FAIL  %-32s %v", v.Name, err))
+			continue
+		}
+		detail := "SHA-256 matches"
+		if !v.Checksum {
+			detail = "no checksum (made before backups had one)"
+		}
+		lines = append(lines, fmt.Sprintf("OK    %-32s %s, %s", v.Name, plural(v.Files, "file"), detail))
+	}
+	output := strings.Join(lines, "\n")
+	if failed > 0 {
+		return output, fmt.Errorf("%d of %d backups failed verification", failed, len(names))
+	}
+	return output, nil
+}
+
+// manifestWorlds returns the world folders a manifest lists, sorted
+func manifestWorlds(m *backup.Manifest) []string {
+	names := append([]string(nil), m.Included...)
+	if len(names) == 0 {
+		// Manifests before Included only list worlds with a level.dat
+		for name := range m.Worlds {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func formatBackupContents(info *backup.BackupInfo, c *backup.Contents) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", info.Name)
+	created := info.CreatedAt
+	if c.Manifest != nil {
+		created = c.Manifest.CreatedAt
+	}
+	fmt.Fprintf(&b, "  Created   %s\n", created.Local().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "  Size      %s compressed, %s uncompressed, %s\n",
+		stats.FormatBytes(c.Compressed), stats.FormatBytes(c.Uncompressed), plural(c.Files, "file"))
+
+	if c.Manifest == nil {
+		b.WriteString("  Manifest  none (made before backups carried one)\n")
+	} else {
+		m := c.Manifest
+		if m.Modpack != "" {
+			fmt.Fprintf(&b, "  Modpack   %s\n", m.Modpack)
+		}
+		if m.Software != "" {
+			fmt.Fprintf(&b, "  Software  %s\n", m.Software)
+		}
+		if len(m.Skipped) > 0 {
+			fmt.Fprintf(&b, "  Skipped   %s\n", strings.Join(m.Skipped, ", "))
+		}
+	}
+
+	b.WriteString("\n  Worlds:\n")
+	for _, w := range c.Worlds {
+		line := fmt.Sprintf("  %-20s %12s %10s / %-10s", w.Name, plural(w.Files, "file"), stats.FormatBytes(w.Compressed), stats.FormatBytes(w.Uncompressed))
+		if c.Manifest != nil {
+			if level := c.Manifest.Worlds[w.Name]; level != nil {
+				line += "  " + describeLevel(level)
+			}
+		}
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// describeLevel summarizes a world's level.dat on one line
+func describeLevel(w *world.Info) string {
+	var parts []string
+	if w.Version != "" {
+		parts = append(parts, "Minecraft "+w.Version)
+	}
+	if w.HasSeed {
+		parts = append(parts, fmt.Sprintf("seed %d", w.Seed))
+	}
+	mode := w.GameTypeName()
+	if w.Hardcore {
+		mode = "hardcore"
+	}
+	parts = append(parts, mode, fmt.Sprintf("spawn %d, %d, %d", w.SpawnX, w.SpawnY, w.SpawnZ))
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// ValidateWorldPatterns checks --backup-worlds and --backup-exclude
+// patterns, world folder names that may contain * and ?
+func ValidateWorldPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid world pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// backupSelection returns which world folders scheduled backups include,
+// or nil when they include all of them
+func (s *Server) backupSelection() func(name string) bool {
+	include, exclude := s.config.BackupWorlds, s.config.BackupExclude
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil
+	}
+	return func(name string) bool {
+		return (len(include) == 0 || matchWorld(include, name)) && !matchWorld(exclude, name)
+	}
+}
+
+func matchWorld(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// benchFile holds the results of past benchmark runs, relative to the
+// server dir
+const benchFile = ".mcserver/bench.jsonl"
+
+// Chunks are loaded for the load burst this far out on the X axis, away
+// from spawn, so they have to be generated
+const benchLoadOffset = 1_000_000
+
+// BenchOptions configures a startup benchmark
+type BenchOptions struct {
+	Label string
+
+	// Chunks to generate after startup (0 skips the load burst), and how
+	// long to measure the server while it generates them
+	LoadChunks   int
+	LoadDuration time.Duration
+
+	// Give up on a start that takes longer
+	Timeout time.Duration
+}
+
+// BenchResult is one benchmark run
+type BenchResult struct {
+	Time     time.Time `json:"time"`
+	Label    string    `json:"label,omitempty"`
+	RamMax   string    `json:"ram_max"`
+	JavaArgs string    `json:"java_args,omitempty"`
+	Mods     int       `json:"mods"`
+
+	// Setup before the JVM runs (installs, syncs), and from the JVM start
+	// to the server reporting Done
+	Prepare time.Duration `json:"prepare"`
+	Boot    time.Duration `json:"boot"`
+
+	PeakMemory uint64  `json:"peak_memory"`
+	PeakCPU    float64 `json:"peak_cpu"`
+
+	Load *BenchLoad `json:"load,omitempty"`
+}
+
+// BenchLoad is what the server did during the chunk generation burst
+type BenchLoad struct {
+	Chunks     int           `json:"chunks"`
+	Duration   time.Duration `json:"duration"`
+	AvgTPS     float64       `json:"avg_tps"`
+	MinTPS     float64       `json:"min_tps"`
+	MaxMSPT    float64       `json:"max_mspt"`
+	PeakMemory uint64        `json:"peak_memory"`
+}
+
+// Bench starts the server, measures how long it takes to be ready and what
+// it uses on the way, optionally runs a chunk generation burst, and stops
+// it again. The result is appended to the benchmark history.
+func (s *Server) Bench(opts BenchOptions) (*BenchResult, error) {
+	if s.stats.Status != StatusStopped && s.stats.Status != StatusCrashed {
+		return nil, fmt.Errorf("server is already running")
+	}
+
+	mods, _ := filepath.Glob(filepath.Join(s.config.ServerDir, "mods", "*.jar"))
+	result := &BenchResult{
+		Time:     time.Now(),
+		Label:    opts.Label,
+		RamMax:   s.config.RamMax,
+		JavaArgs: s.config.JavaArgs,
+		Mods:     len(mods),
+	}
+
+	begin := time.Now()
+	if err := s.Start(); err != nil {
+		return nil, err
+	}
+	defer s.Stop()
+
+	deadline := time.After(opts.Timeout)
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	for done := false; !done; {
+		select {
+		case <-deadline:
+			return nil, fmt.Errorf("server did not finish starting within %s", opts.Timeout)
+		case <-ticker.C:
+		}
+		st := s.GetStats()
+		result.PeakMemory = max(result.PeakMemory, st.MemoryUsed)
+		result.PeakCPU = max(result.PeakCPU, st.CPUPercent)
+
+		switch st.Status {
+		case StatusRunning:
+			result.Prepare = st.StartTime.Sub(begin)
+			result.Boot = time.Since(st.StartTime)
+			done = true
+		case StatusCrashed, StatusStopped:
+			return nil, fmt.Errorf("server exited during startup")
+		}
+	}
+
+	if opts.LoadChunks > 0 {
+		load, err := s.benchLoad(opts.LoadChunks, opts.LoadDuration)
+		if err != nil {
+			return nil, err
+		}
+		result.Load = load
+	}
+
+	if err := saveBenchResult(s.config.ServerDir, result); err != nil {
+		return result, fmt.Errorf("failed to save benchmark result: %w", err)
+	}
+	return result, nil
+}
+
+// benchLoad force-loads fresh chunks far from spawn, in the largest squares
+// forceload accepts, and samples TPS, MSPT and memory while the server
+// generates them
+func (s *Server) benchLoad(chunks int, duration time.Duration) (*BenchLoad, error) {
+	load := &BenchLoad{Chunks: chunks, Duration: duration}
+
+	for x := benchLoadOffset; chunks > 0; x += 16 * 16 {
+		cols := min(chunks, 16)
+		rows := min(chunks/cols, 16)
+		if err := s.SendCommand(fmt.Sprintf("forceload add %d 0 %d %d", x, x+cols*16-1, rows*16-1)); err != nil {
+			return nil, err
+		}
+		chunks -= cols * rows
+	}
+	defer s.SendCommand("forceload remove all")
+
+	ticks, err := s.measureTicks(duration)
+	if err != nil {
+		return nil, fmt.Errorf("%w during the load burst", err)
+	}
+	load.AvgTPS, load.MinTPS, load.MaxMSPT, load.PeakMemory = ticks.AvgTPS, ticks.MinTPS, ticks.MaxMSPT, ticks.PeakMemory
+	return load, nil
+}
+
+// tickStats is how the server kept up over a measuring window
+type tickStats struct {
+	AvgTPS     float64
+	MinTPS     float64
+	AvgMSPT    float64
+	MaxMSPT    float64
+	PeakMemory uint64
+}
+
+// measureTicks samples TPS, MSPT and memory for duration
+func (s *Server) measureTicks(duration time.Duration) (*tickStats, error) {
+	ticks := &tickStats{MinTPS: 20}
+
+	// TPS is reported every few seconds, so sample each report once
+	var samples, msptSamples int
+	var total, totalMSPT float64
+	lastTPS := s.GetStats().TPS
+	end := time.After(duration)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-end:
+			if samples > 0 {
+				ticks.AvgTPS = total / float64(samples)
+			} else {
+				ticks.AvgTPS, ticks.MinTPS = lastTPS, lastTPS
+			}
+			if msptSamples > 0 {
+				ticks.AvgMSPT = totalMSPT / float64(msptSamples)
+			}
+			return ticks, nil
+		case <-ticker.C:
+		}
+		st := s.GetStats()
+		if st.Status != StatusRunning {
+			return nil, fmt.Errorf("server stopped")
+		}
+		ticks.PeakMemory = max(ticks.PeakMemory, st.MemoryUsed)
+		ticks.MaxMSPT = max(ticks.MaxMSPT, st.MSPT)
+		if st.TPS != lastTPS {
+			lastTPS = st.TPS
+			samples++
+			total += st.TPS
+			ticks.MinTPS = min(ticks.MinTPS, st.TPS)
+			if st.MSPT > 0 {
+				msptSamples++
+				totalMSPT += st.MSPT
+			}
+		}
+	}
+}
+
+func saveBenchResult(serverDir string, result *BenchResult) error {
+	path := filepath.Join(serverDir, benchFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(result)
+}
+
+// BenchHistory returns past benchmark runs, oldest first, at most limit of
+// the newest (0 for all)
+func BenchHistory(serverDir string, limit int) ([]BenchResult, error) {
+	f, err := os.Open(filepath.Join(serverDir, benchFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var results []BenchResult
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var r BenchResult
+		if json.Unmarshal(sc.Bytes(), &r) == nil {
+			results = append(results, r)
+		}
+	}
+	if limit > 0 && len(results) > limit {
+		results = results[len(results)-limit:]
+	}
+	return results, sc.Err()
+}
@@ -0,0 +1,392 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"mcserver-manager/internal/props"
+	"mcserver-manager/internal/world"
+)
+
+const (
+	// Suffixes of the sibling directories an upgrade is built in and the
+	// previous version is kept in
+	greenSuffix = ".green"
+	blueSuffix  = ".blue"
+
+	// World the smoke boot generates, so the real one is never opened by
+	// two servers or by an untested version
+	smokeWorld   = "bluegreen-smoke"
+	smokeTimeout = 15 * time.Minute
+
+	upgradeFile = ".mcserver/upgrade.json"
+)
+
+// stateFiles move with the worlds when directories are swapped: everything
+// that belongs to this server rather than to the installed version
+var stateFiles = []string{
+	".mcserver",
+	"server.properties",
+	"eula.txt",
+	"whitelist.json",
+	"ops.json",
+	"banned-players.json",
+	"banned-ips.json",
+	"usercache.json",
+}
+
+// upgradeRecord remembers what the last swap replaced, for rollback
+type upgradeRecord struct {
+	SwappedAt      time.Time `json:"swappedAt"`
+	Previous       string    `json:"previous"`
+	ModpackID      string    `json:"modpackId"`
+	ModpackVersion string    `json:"modpackVersion"`
+}
+
+// Upgrade installs a modpack version into a parallel directory, boots it
+// once on a spare port with a throwaway world, and swaps it in for the
+// current server directory. The live server keeps running until the swap,
+// and the previous directory is kept for Rollback.
+func (s *Server) Upgrade(modpackID, version string) error {
+	err := s.blueGreen(modpackID+" "+version, modpackID, version, nil)
+	if err == nil {
+		s.addEvent(EventInfo, "Update --modpack/--modpack-version to match, or the next launch installs the old version")
+	}
+	return err
+}
+
+// blueGreen builds a server in the green directory, with the modpack if
+// modpackID is set and populate filling in anything else, smoke boots it
+// and swaps it in. The config's modpack becomes modpackID.
+func (s *Server) blueGreen(label, modpackID, version string, populate func(dir string) error) error {
+	if !s.upgradeMutex.TryLock() {
+		return fmt.Errorf("an upgrade is already in progress")
+	}
+	defer s.upgradeMutex.Unlock()
+
+	green := s.config.ServerDir + greenSuffix
+	s.addEvent(EventInfo, fmt.Sprintf("Upgrade: building %s in %s", label, filepath.Base(green)))
+	if err := s.buildGreen(green, modpackID, version, populate); err != nil {
+		s.addEvent(EventError, fmt.Sprintf("Upgrade failed, nothing changed (see %s): %v", filepath.Base(green), err))
+		return err
+	}
+
+	record := upgradeRecord{
+		SwappedAt:      time.Now(),
+		Previous:       s.config.ServerDir + blueSuffix,
+		ModpackID:      s.config.ModpackID,
+		ModpackVersion: s.config.ModpackVersion,
+	}
+	err := s.withSwap(func() error {
+		if err := os.RemoveAll(record.Previous); err != nil {
+			return fmt.Errorf("failed to remove the old %s: %w", filepath.Base(record.Previous), err)
+		}
+		if err := swapDirs(s.config.ServerDir, green, record.Previous, s.carried()); err != nil {
+			return err
+		}
+		s.config.ModpackID, s.config.ModpackVersion = modpackID, version
+		return writeUpgradeRecord(s.config.ServerDir, &record)
+	})
+	if err != nil {
+		s.addEvent(EventError, fmt.Sprintf("Upgrade swap failed: %v", err))
+		return err
+	}
+
+	s.addEvent(EventInfo, fmt.Sprintf("Upgraded to %s; the previous version is in %s (:upgrade rollback)", label, filepath.Base(record.Previous)))
+	return nil
+}
+
+// Rollback swaps the directory replaced by the last Upgrade back in. The
+// worlds come along as they are now, so a world the new version already
+// converted needs the backup taken before the swap instead.
+func (s *Server) Rollback() error {
+	if !s.upgradeMutex.TryLock() {
+		return fmt.Errorf("an upgrade is already in progress")
+	}
+	defer s.upgradeMutex.Unlock()
+
+	record, err := readUpgradeRecord(s.config.ServerDir)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(record.Previous); err != nil {
+		return fmt.Errorf("previous server directory is gone: %w", err)
+	}
+
+	err = s.withSwap(func() error {
+		os.Remove(filepath.Join(s.config.ServerDir, upgradeFile))
+		green := s.config.ServerDir + greenSuffix
+		if err := os.RemoveAll(green); err != nil {
+			return err
+		}
+		if err := swapDirs(s.config.ServerDir, record.Previous, green, s.carried()); err != nil {
+			return err
+		}
+		s.config.ModpackID, s.config.ModpackVersion = record.ModpackID, record.ModpackVersion
+		return nil
+	})
+	if err != nil {
+		s.addEvent(EventError, fmt.Sprintf("Rollback failed: %v", err))
+		return err
+	}
+
+	s.addEvent(EventInfo, fmt.Sprintf("Rolled back to the server from %s", record.SwappedAt.Format("2006-01-02 15:04")))
+	return nil
+}
+
+// buildGreen installs the new version into dir and smoke boots it
+func (s *Server) buildGreen(dir, modpackID, version string, populate func(dir string) error) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// Same settings as the live server, minus anything that would bind
+	// its ports or touch shared state
+	port, err := freePort()
+	if err != nil {
+		return err
+	}
+	for _, file := range []string{"server.properties", "eula.txt"} {
+		if err := copyFile(filepath.Join(s.config.ServerDir, file), filepath.Join(dir, file)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	properties, err := props.Load(filepath.Join(dir, "server.properties"))
+	if err != nil {
+		return err
+	}
+	properties.Set("level-name", smokeWorld)
+	properties.Set("enable-rcon", "false")
+	properties.Set("enable-query", "false")
+	if err := properties.Save(filepath.Join(dir, "server.properties")); err != nil {
+		return err
+	}
+	if populate != nil {
+		if err := populate(dir); err != nil {
+			return err
+		}
+	}
+
+	// Only what it takes to boot the new version the way the live server
+	// runs; everything else, such as restarts, backups, autosaves, syncs
+	// and anything added to Config later, stays off for the smoke boot
+	live := s.config
+	cfg := Config{
+		RamMin:            live.RamMin,
+		RamMax:            live.RamMax,
+		Port:              port,
+		ServerDir:         dir,
+		JavaPath:          live.JavaPath,
+		JavaArgs:          live.JavaArgs,
+		JavaPaths:         live.JavaPaths,
+		ModpackID:         modpackID,
+		ModpackVersion:    version,
+		Snapshots:         live.Snapshots,
+		ServerType:        live.ServerType,
+		AcceptEULA:        live.AcceptEULA,
+		StatsInterval:     live.StatsInterval,
+		CPUAffinity:       live.CPUAffinity,
+		Nice:              live.Nice,
+		PriorityClass:     live.PriorityClass,
+		Sandbox:           live.Sandbox,
+		SandboxBestEffort: live.SandboxBestEffort,
+		SandboxPaths:      live.SandboxPaths,
+		LogProfile:        live.LogProfile,
+		PlayerNamePattern: live.PlayerNamePattern,
+	}
+
+	green := newServer(&cfg)
+	defer green.close()
+	go s.relaySmokeOutput(green)
+
+	if err := green.Start(); err != nil {
+		return err
+	}
+	status := green.waitBooted(smokeTimeout)
+	green.Stop()
+	if status != StatusRunning {
+		return fmt.Errorf("smoke boot ended %s", strings.ToLower(status.String()))
+	}
+	s.addEvent(EventInfo, "Upgrade: smoke boot passed")
+
+	for _, folder := range []string{smokeWorld, smokeWorld + "_nether", smokeWorld + "_the_end"} {
+		os.RemoveAll(filepath.Join(dir, folder))
+	}
+	return nil
+}
+
+// waitBooted waits for a started server to finish loading, crash or exit
+func (s *Server) waitBooted(timeout time.Duration) ServerStatus {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		switch status := s.GetStats().Status; status {
+		case StatusRunning, StatusCrashed, StatusStopped:
+			return status
+		}
+		time.Sleep(time.Second)
+	}
+	return StatusStarting
+}
+
+// relaySmokeOutput shows the smoke-booted server's console in ours
+func (s *Server) relaySmokeOutput(green *Server) {
+	for {
+		select {
+		case <-green.ctx.Done():
+			return
+		case line := <-green.outputChan:
+			s.spool.push("[green] " + line)
+		}
+	}
+}
+
+// withSwap backs up and stops the server, runs swap, and starts the server
+// again if it was running. The backup is taken even while stopped, since
+// a swap hands the worlds to a different version.
+func (s *Server) withSwap(swap func() error) error {
+	s.wake()
+	wasRunning := s.stats.Status == StatusRunning
+	if worlds, _ := s.ListWorlds(); len(worlds) > 0 {
+		if err := s.Backup(); err != nil {
+			return fmt.Errorf("backup failed, nothing changed: %w", err)
+		}
+	}
+	if wasRunning {
+		s.Stop()
+	}
+
+	err := swap()
+	if wasRunning {
+		if startErr := s.Start(); startErr != nil && err == nil {
+			err = startErr
+		}
+	}
+	return err
+}
+
+// carried lists what a swap moves besides the worlds: the state files, and
+// the backup folder when it lives inside the server directory
+func (s *Server) carried() []string {
+	names := append([]string{}, stateFiles...)
+	if rel, err := filepath.Rel(s.config.ServerDir, s.config.BackupDir); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+		names = append(names, strings.Split(filepath.ToSlash(rel), "/")[0])
+	}
+	return names
+}
+
+// swapDirs moves the worlds and the carried files from live into next,
+// then renames live to aside and next to live
+func swapDirs(live, next, aside string, carried []string) error {
+	moves := append([]string{}, carried...)
+	worlds, err := world.List(live)
+	if err != nil {
+		return err
+	}
+	for _, w := range worlds {
+		moves = append(moves, w.Name)
+		moves = append(moves, w.Dimensions...)
+	}
+
+	var moved []string
+	undo := func() {
+		for _, name := range moved {
+			os.Rename(filepath.Join(next, name), filepath.Join(live, name))
+		}
+	}
+	for _, name := range moves {
+		src := filepath.Join(live, name)
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		dst := filepath.Join(next, name)
+		if err := os.RemoveAll(dst); err != nil {
+			undo()
+			return err
+		}
+		if err := os.Rename(src, dst); err != nil {
+			undo()
+			return fmt.Errorf("failed to move %s: %w", name, err)
+		}
+		moved = append(moved, name)
+	}
+
+	if err := os.Rename(live, aside); err != nil {
+		undo()
+		return fmt.Errorf("failed to move the server directory aside: %w", err)
+	}
+	if err := os.Rename(next, live); err != nil {
+		os.Rename(aside, live)
+		undo()
+		return fmt.Errorf("failed to swap in %s: %w", filepath.Base(next), err)
+	}
+	return nil
+}
+
+func writeUpgradeRecord(serverDir string, record *upgradeRecord) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(serverDir, upgradeFile), data, 0644)
+}
+
+func readUpgradeRecord(serverDir string) (*upgradeRecord, error) {
+	data, err := os.ReadFile(filepath.Join(serverDir, upgradeFile))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no upgrade to roll back")
+	}
+	if err != nil {
+		return nil, err
+	}
+	record := &upgradeRecord{}
+	if err := json.Unmarshal(data, record); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", upgradeFile, err)
+	}
+	return record, nil
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return 0, fmt.Errorf("no free port for the smoke boot: %w", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "upgrade",
+		Usage: "upgrade <modpack> [version]|rollback",
+		Help:  "Build a modpack version beside the server, smoke boot it and swap it in (or swap the previous one back)",
+		Admin: true,
+		Run: func(s *Server, args []string) (string, error) {
+			if len(args) == 1 && args[0] == "rollback" {
+				return "Rolled back", s.Rollback()
+			}
+			if len(args) < 1 || len(args) > 2 {
+				return "", fmt.Errorf("usage: upgrade <modpack> [version] | upgrade rollback")
+			}
+			version := "latest"
+			if len(args) == 2 {
+				version = args[1]
+			}
+
+			// A smoke boot takes minutes; while the server runs there is a
+			// manager process to finish it, so don't hold the caller
+			if s.stats.Status == StatusRunning {
+				go s.Upgrade(args[0], version)
+				return fmt.Sprintf("Upgrading to %s %s in the background; progress is in the event log", args[0], version), nil
+			}
+			return "Upgraded to " + args[0] + " " + version, s.Upgrade(args[0], version)
+		},
+	})
+}
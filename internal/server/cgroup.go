@@ -0,0 +1,31 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// cgroupMemoryLimit returns the memory ceiling in bytes: the configured
+// value, or the heap plus a quarter (at least 1 GiB) for metaspace, thread
+// stacks and native buffers
+func (s *Server) cgroupMemoryLimit() uint64 {
+	if s.config.CgroupMemory != "" {
+		return parseMemoryString(s.config.CgroupMemory)
+	}
+	heap := parseMemoryString(s.config.RamMax)
+	return heap + max(heap/4, 1024*1024*1024)
+}
+
+// cgroupName is unique per server port, so several servers on one host get
+// separate groups
+func (s *Server) cgroupName() string {
+	return "mcserver-" + strconv.Itoa(s.config.Port)
+}
+
+func (s *Server) describeCgroupLimits() string {
+	desc := fmt.Sprintf("memory %d MB", s.cgroupMemoryLimit()/1024/1024)
+	if s.config.CgroupCPU > 0 {
+		desc += fmt.Sprintf(", CPU %d%%", s.config.CgroupCPU)
+	}
+	return desc
+}
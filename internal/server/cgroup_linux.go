@@ -0,0 +1,164 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+// cgroupBase is where the servers' groups are created: the root, or the
+// subtree DelegateCgroups handed to the --run-as user
+var cgroupBase = cgroupRoot
+
+// DelegateCgroups, called as root before dropping to the --run-as user,
+// creates a "mcserver" subtree owned by uid and gid and moves the manager
+// into it. The kernel only lets a process move another between groups
+// when it may write cgroup.procs of their common ancestor, so without this
+// the unprivileged manager could neither create the servers' groups nor
+// move the JVMs into them.
+func DelegateCgroups(uid, gid int) error {
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err != nil {
+		return fmt.Errorf("cgroup v2 is not mounted")
+	}
+	if err := enableControllers(cgroupRoot); err != nil {
+		return err
+	}
+
+	base := filepath.Join(cgroupRoot, "mcserver")
+	// A group with controllers for its children can't hold processes, so
+	// the manager lives in a leaf next to the servers
+	manager := filepath.Join(base, "manager")
+	if err := os.MkdirAll(manager, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(manager, "cgroup.procs"), []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		return fmt.Errorf("failed to move the manager into %s: %w", manager, err)
+	}
+	if err := enableControllers(base); err != nil {
+		return err
+	}
+
+	owned := []string{
+		base,
+		filepath.Join(base, "cgroup.procs"),
+		filepath.Join(base, "cgroup.threads"),
+		filepath.Join(base, "cgroup.subtree_control"),
+		manager,
+		filepath.Join(manager, "cgroup.procs"),
+		filepath.Join(manager, "cgroup.threads"),
+	}
+	for _, path := range owned {
+		if err := os.Chown(path, uid, gid); err != nil {
+			return fmt.Errorf("failed to delegate %s: %w", path, err)
+		}
+	}
+	cgroupBase = base
+	return nil
+}
+
+// enableControllers lets the children of a group have memory and, where
+// the kernel has it, CPU limits
+func enableControllers(dir string) error {
+	file := filepath.Join(dir, "cgroup.subtree_control")
+	if os.WriteFile(file, []byte("+memory +cpu"), 0644) == nil {
+		return nil
+	}
+	return os.WriteFile(file, []byte("+memory"), 0644)
+}
+
+// prepareCgroup decides how to confine the JVM. With a writable cgroup v2
+// hierarchy it creates the group itself (the process joins it once
+// started); otherwise it wraps the command in a transient systemd scope.
+// It returns the command to run, which may be unchanged.
+func (s *Server) prepareCgroup(name string, args []string) (string, []string, error) {
+	if dir, err := s.createCgroup(); err == nil {
+		s.cgroupDir = dir
+		return name, args, nil
+	}
+	s.cgroupDir = ""
+
+	systemdRun, err := exec.LookPath("systemd-run")
+	if err != nil {
+		return "", nil, fmt.Errorf("no writable cgroup v2 hierarchy and systemd-run not found")
+	}
+	wrapped := []string{"--scope", "--quiet", "--unit", s.cgroupName(),
+		"-p", "MemoryMax=" + strconv.FormatUint(s.cgroupMemoryLimit(), 10),
+		"-p", "OOMPolicy=kill",
+	}
+	if os.Geteuid() != 0 {
+		wrapped = append([]string{"--user"}, wrapped...)
+	}
+	if s.config.CgroupCPU > 0 {
+		wrapped = append(wrapped, "-p", fmt.Sprintf("CPUQuota=%d%%", s.config.CgroupCPU))
+	}
+	wrapped = append(wrapped, "--", name)
+	return systemdRun, append(wrapped, args...), nil
+}
+
+// createCgroup makes (or reuses) a cgroup v2 group with the limits
+func (s *Server) createCgroup() (string, error) {
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err != nil {
+		return "", fmt.Errorf("cgroup v2 is not mounted")
+	}
+
+	// Limits can only be set on a child once the parent delegates the
+	// controllers to its children
+	controllers := "+memory"
+	if s.config.CgroupCPU > 0 {
+		controllers += " +cpu"
+	}
+	if err := os.WriteFile(filepath.Join(cgroupBase, "cgroup.subtree_control"), []byte(controllers), 0644); err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(cgroupBase, s.cgroupName())
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	limits := map[string]string{
+		"memory.max":       strconv.FormatUint(s.cgroupMemoryLimit(), 10),
+		"memory.oom.group": "1",
+	}
+	if s.config.CgroupCPU > 0 {
+		// quota and period in microseconds; 100% is one full CPU
+		limits["cpu.max"] = fmt.Sprintf("%d 100000", s.config.CgroupCPU*1000)
+	}
+	for file, value := range limits {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(value), 0644); err != nil {
+			return "", fmt.Errorf("failed to set %s: %w", file, err)
+		}
+	}
+	return dir, nil
+}
+
+// joinCgroup moves the started JVM into the group created by prepareCgroup
+func (s *Server) joinCgroup(pid int) error {
+	if s.cgroupDir == "" {
+		return nil
+	}
+	return os.WriteFile(filepath.Join(s.cgroupDir, "cgroup.procs"), []byte(strconv.Itoa(pid)), 0644)
+}
+
+// cgroupOOMKills returns how many times the group's OOM killer fired
+func (s *Server) cgroupOOMKills() int {
+	if s.cgroupDir == "" {
+		return 0
+	}
+	data, err := os.ReadFile(filepath.Join(s.cgroupDir, "memory.events"))
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if count, ok := strings.CutPrefix(line, "oom_kill "); ok {
+			n, _ := strconv.Atoi(count)
+			return n
+		}
+	}
+	return 0
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+
+package server
+
+import "fmt"
+
+// prepareCgroup is only implemented on Linux
+func (s *Server) prepareCgroup(name string, args []string) (string, []string, error) {
+	return "", nil, fmt.Errorf("cgroup limits are only supported on Linux")
+}
+
+// DelegateCgroups is only implemented on Linux
+func DelegateCgroups(uid, gid int) error {
+	return fmt.Errorf("cgroup limits are only supported on Linux")
+}
+
+func (s *Server) joinCgroup(pid int) error {
+	return nil
+}
+
+func (s *Server) cgroupOOMKills() int {
+	return 0
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"mcserver-manager/internal/audit"
+)
+
+// Op levels ops.json must give a player for chat commands: operator
+// actions need the level that may kick and ban, admin-only ones the level
+// that may stop the server
+const (
+	chatOperatorLevel = 3
+	chatAdminLevel    = 4
+)
+
+// Most reply lines sent back to chat; the rest are counted
+const chatReplyLines = 15
+
+// chatShorthands are what players mean by an action typed bare in chat
+var chatShorthands = map[string]string{
+	"backup": "backup now",
+}
+
+// chatCommand runs a manager action typed in chat after the chat prefix,
+// like "!tps history", for ops with a high enough level in ops.json, and
+// tells the player the result with tellraw. Actions run in the background
+// so the console keeps being read. line is the whole console line the
+// chat was read from.
+func (s *Server) chatCommand(player, message, line string) {
+	prefix := s.config.ChatPrefix
+	if !s.config.ChatCommands || prefix == "" {
+		return
+	}
+	// The name is only scraped from the console, so it must come from a
+	// real chat line of a player who is online before it counts for ops
+	if !chatLineFrom(line, player) || !s.playerOnline(player) {
+		return
+	}
+	command, found := strings.CutPrefix(strings.TrimSpace(message), prefix)
+	command = strings.TrimSpace(command)
+	if !found || command == "" {
+		return
+	}
+	if expanded, ok := chatShorthands[strings.ToLower(command)]; ok {
+		command = expanded
+	}
+
+	action, _, err := LookupAction(command)
+	if err != nil {
+		s.tellPlayer(player, err.Error(), true)
+		return
+	}
+	need := chatOperatorLevel
+	if action.Admin {
+		need = chatAdminLevel
+	}
+	if level := s.opLevel(player); level < need {
+		s.RecordDenied(audit.SourceChat, player, audit.KindAction, command, fmt.Sprintf("op level %d required", need))
+		s.tellPlayer(player, fmt.Sprintf("%s%s needs op level %d", prefix, action.Name, need), true)
+		return
+	}
+
+	go func() {
+		output, err := s.RunAction(audit.SourceChat, player, command)
+		if err != nil {
+			s.tellPlayer(player, err.Error(), true)
+			return
+		}
+		if output == "" {
+			output = "Done"
+		}
+		s.tellPlayer(player, output, false)
+	}()
+}
+
+// chatLineFrom reports whether a console line is chat from player and
+// nothing else: the "<player> " comes right after the logger's first "]: "
+// (or Minecraft's "[Not Secure] " tag), and the line is not a command a
+// player issued, whose arguments Paper and Spigot log verbatim
+func chatLineFrom(line, player string) bool {
+	if strings.Contains(line, "issued server command") {
+		return false
+	}
+	_, rest, found := strings.Cut(line, "]: ")
+	if !found {
+		return false
+	}
+	rest = strings.TrimPrefix(rest, "[Not Secure] ")
+	return strings.HasPrefix(rest, "<"+player+"> ")
+}
+
+// opLevel returns a player's level in ops.json, 0 for players who are
+// not ops
+func (s *Server) opLevel(name string) int {
+	data, err := os.ReadFile(filepath.Join(s.config.ServerDir, "ops.json"))
+	if err != nil {
+		return 0
+	}
+	var ops []opEntry
+	if json.Unmarshal(data, &ops) != nil {
+		return 0
+	}
+	for _, op := range ops {
+		if strings.EqualFold(op.Name, name) {
+			return op.Level
+		}
+	}
+	return 0
+}
+
+// tellPlayer sends text to one player with tellraw, in red for errors
+func (s *Server) tellPlayer(player, text string, isError bool) {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if len(lines) > chatReplyLines {
+		more := len(lines) - chatReplyLines
+		lines = append(lines[:chatReplyLines], fmt.Sprintf("... %d more lines", more))
+	}
+	color := "gray"
+	if isError {
+		color = "red"
+	}
+	component, _ := json.Marshal([]map[string]string{
+		{"text": "[mcserver] ", "color": "dark_aqua"},
+		{"text": strings.Join(lines, "\n"), "color": color},
+	})
+	s.SendCommand("tellraw " + player + " " + string(component))
+}
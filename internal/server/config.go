@@ -1,11 +1,20 @@
 package server
 
 import (
+	"fmt"
 	"time"
+
+	"mcserver-manager/internal/curseforge"
+	"mcserver-manager/internal/i18n"
+	"mcserver-manager/internal/servertype"
+	"mcserver-manager/internal/world"
 )
 
 // Config holds all server configuration
 type Config struct {
+	// Instance name when one manager runs several servers
+	Name string
+
 	// Memory settings
 	RamMin string
 	RamMax string
@@ -18,16 +27,190 @@ type Config struct {
 	JavaPath  string
 	JavaArgs  string
 
+	// Other Javas by major version, for servers JavaPath cannot run: old
+	// modpacks on Java 8, new releases on 21
+	JavaPaths map[int]string
+
 	// Modpack settings
 	ModpackID      string
 	ModpackVersion string
 
+	// Vanilla server: a version ID, "latest" or "snapshot"; empty leaves
+	// the server jar alone. Snapshots must be opted into. ServerType picks
+	// Mojang's jar or a Paper or Purpur build of the version.
+	MCVersion  string
+	Snapshots  bool
+	ServerType string
+
+	// Agree to Mojang's EULA in eula.txt on the operator's behalf;
+	// otherwise it has to be accepted explicitly before the first start
+	AcceptEULA bool
+
+	// Operators by name and level (1-4), written to ops.json on start.
+	// OpsPrune also removes ops that are not declared.
+	Ops      map[string]int
+	OpsPrune bool
+
+	// Let ops run manager actions from chat as ChatPrefix and the action,
+	// e.g. "!tps history"
+	ChatCommands bool
+	ChatPrefix   string
+
+	// Remote member list (raw URL, Gist or Google Sheet) whitelist.json is
+	// replaced with, and the minutes between syncs while running
+	WhitelistURL      string
+	WhitelistInterval int
+
+	// Save console output with timestamps for replaying, keeping the
+	// newest RecordKeep sessions
+	RecordConsole bool
+	RecordKeep    int
+
+	// A server launched by something else (mcserver monitor): its log is
+	// followed and commands go over RCON; MonitorPID picks the process for
+	// resource stats instead of looking for a java process in ServerDir
+	MonitorLog   string
+	RCONAddress  string
+	RCONPassword string
+	MonitorPID   int
+
 	// Feature flags
-	AutoRestart    bool
-	BackupEnabled  bool
-	BackupInterval int
-	BackupDir      string
-	MaxBackups     int
+	AutoRestart bool
+	CrashLimit  int // crashes in a row before auto-restart gives up, 0 never
+	// How restarts warn and wait for online players, by what asked for them;
+	// sources without an entry use DefaultRestartPolicies
+	RestartPolicies  map[RestartSource]RestartPolicy
+	RestartCron      []string // cron expressions for scheduled restarts, local time
+	StartTimeout     int      // minutes to reach Done before a start counts as failed, 0 waits forever
+	BackupEnabled    bool
+	BackupInterval   int
+	BackupDir        string
+	MaxBackups       int
+	AutosaveInterval int  // minutes between the manager's save-all, 0 leaves it to the server
+	AutosaveOwn      bool // save-off after start, so only the manager saves
+
+	// World folders scheduled backups include and leave out, by name or
+	// pattern; empty includes every world. Other backups take them all.
+	BackupWorlds  []string
+	BackupExclude []string
+
+	// Where finished backups are uploaded (see backup.ParseTarget), and
+	// how many each keeps; 0 keeps MaxBackups like the local directory
+	BackupTargets    []string
+	BackupRemoteKeep int
+
+	// Bedrock cross-play (Geyser + Floodgate)
+	BedrockCrossplay bool
+	BedrockPort      int
+
+	// Keep ViaVersion/ViaBackwards installed (Paper)
+	ViaVersion bool
+
+	// Velocity proxy directory; enables modern forwarding when set
+	VelocityDir string
+	// Addresses of proxies in front of the server. Their address in the
+	// log is the proxy's, not a player's, so it is not recorded or
+	// throttled.
+	ProxyAddresses []string
+
+	// Connection throttling: more than ThrottleJoins connections from one
+	// IP within ThrottleWindow seconds get it banned with ban-ip, and blocked
+	// by ThrottleFirewall ("{ip}" filled in) when set. FloodJoins from all
+	// IPs within the window raise a critical event. 0 disables either.
+	ThrottleJoins    int
+	ThrottleWindow   int
+	ThrottleFirewall string
+	FloodJoins       int
+
+	// UDP query protocol (enable-query) for cross-checking players
+	QueryEnabled bool
+	QueryPort    int
+
+	// Server List Ping health probe
+	HealthInterval int    // seconds between probes, 0 disables
+	PublicAddress  string // optional host:port to probe externally
+
+	// Detect the public IP for the share line (and probe it if no
+	// PublicAddress is configured)
+	DetectPublicIP bool
+
+	// Resource pack zip served over HTTP and set in server.properties
+	ResourcePack        string
+	ResourcePackPort    int
+	ResourcePackHost    string // host players download from, defaults to the public IP
+	RequireResourcePack bool
+
+	// Adaptive view/simulation distance, tuned from TPS, MSPT and players
+	AdaptiveView        bool
+	ViewDistanceMin     int
+	ViewDistanceMax     int
+	SimDistanceMin      int
+	SimDistanceMax      int
+	ViewDistanceCommand string // console command template, e.g. "vd {view} {sim}"
+
+	// Seconds between CPU, memory and disk readings, and between TPS
+	// requests (0 disables TPS polling)
+	StatsInterval int
+	TPSInterval   int
+
+	// Seconds between "list" checks that correct the log-tracked players,
+	// 0 disables
+	PlayerListInterval int
+
+	// TPS below which the manager logs a lag spike, 0 disables
+	LagThreshold float64
+
+	// Seconds between the samples of .mcserver/metrics (0 disables), and
+	// the days of them kept (0 keeps all)
+	MetricsHistory int
+	MetricsKeep    int
+
+	// Hosting cost per hour the server runs, for the usage report's
+	// estimates; 0 leaves them out
+	CostPerHour float64
+
+	// Percent busy the server's disk may stay at for 30 seconds before a
+	// warning, 0 disables (Linux only)
+	DiskAlert int
+
+	// Minutes empty before the JVM is suspended (SIGSTOP), 0 disables
+	SuspendWhenEmpty int
+
+	// Scheduling of the JVM on shared hosts
+	CPUAffinity   string // CPU list such as "0-3,6"
+	Nice          int    // Linux nice level, -20..19
+	PriorityClass string // Windows priority class, e.g. "below-normal"
+
+	// Linux cgroup confinement of the JVM
+	CgroupLimits bool
+	CgroupMemory string // e.g. "10G"; defaults to RamMax plus overhead
+	CgroupCPU    int    // percent of one CPU, e.g. 200 for two cores; 0 is unlimited
+
+	// Opt-in hardening of the JVM: restricted environment, no-new-privileges,
+	// private temp dir and a filesystem allowlist (Landlock). The server
+	// does not start when any of it can't be applied, unless
+	// SandboxBestEffort lets it run with what could be.
+	Sandbox           bool
+	SandboxBestEffort bool
+	SandboxPaths      []string // extra read-write paths
+
+	// Console pattern profile: auto, vanilla, forge, fabric, paper or custom
+	LogProfile string
+	// Regex for one player name in console lines; empty uses the default
+	PlayerNamePattern string
+	// Keep private messages out of the events, console recordings and
+	// support bundles
+	HidePrivateMessages bool
+
+	// Run the Starlark scripts in .mcserver/scripts
+	Scripts bool
+
+	// Sync config files from a git repository before each start and every
+	// GitOpsInterval minutes (0 syncs only on start)
+	GitOpsRepo     string
+	GitOpsBranch   string
+	GitOpsPath     string // subdirectory of the repository with the files
+	GitOpsInterval int
 }
 
 // Player represents a connected player
@@ -36,6 +219,9 @@ type Player struct {
 	UUID      string
 	JoinedAt  time.Time
 	IPAddress string
+	Bedrock   bool // joined through Geyser
+	// Playtime before this session, from the player database
+	Playtime time.Duration
 }
 
 // ServerStats holds real-time server statistics
@@ -46,25 +232,82 @@ type ServerStats struct {
 	Uptime    time.Duration
 	Restarts  int
 
+	// Counters kept across manager restarts, from .mcserver/state.json
+	Lifetime Lifetime
+
 	// Performance
 	TPS        float64
+	MSPT       float64 // mean milliseconds per tick, when the server reports it
 	MemoryUsed uint64
 	MemoryMax  uint64
 	CPUPercent float64
 
-	// Network
-	BytesIn      uint64
-	BytesOut     uint64
-	BandwidthIn  float64 // bytes per second
-	BandwidthOut float64
+	// TPS percentiles of the last 5 minutes and hour
+	TPS5m TPSPercentiles
+	TPS1h TPSPercentiles
+
+	// Disk I/O of the server process, and how busy the disk holding the
+	// server directory is (percent of time, Linux only)
+	DiskRead      uint64 // bytes since the process started
+	DiskWrite     uint64
+	DiskReadRate  float64 // bytes per second
+	DiskWriteRate float64
+	DiskUtil      float64
 
 	// Players
 	Players     []Player
 	PlayerCount int
 	MaxPlayers  int
 
+	// Query protocol (authoritative server-reported info)
+	MapName        string
+	Plugins        []string
+	PlayerDesync   bool // log-tracked players differ from the query list
+	LastQueryError string
+
+	// Reachability (Server List Ping)
+	Reachable       bool
+	PublicReachable bool
+	Latency         time.Duration
+	MOTD            string
+	Favicon         string // data URI
+	LastPing        time.Time
+
+	// Share info
+	ShareAddress  string   // "X.Y.Z.W:25565"
+	ShareVerified bool     // ShareAddress answered an external ping
+	LANAddresses  []string // "192.168.1.5:25565"
+
+	// Adaptive view distance
+	ViewDistance       int
+	SimulationDistance int
+	DistancePending    bool // written to server.properties, applies on restart
+
+	// Why a restart is needed to apply file changes, e.g. "3 changed mods"
+	RestartRequired string
+	// Restart counting down or waiting for players to leave
+	PendingRestart *PendingRestart
+
+	// Start was refused until the EULA is accepted
+	EULARequired bool
+
+	// Mod downloads of a modpack install, while they run
+	Download *curseforge.DownloadProgress
+
+	// Server software and Minecraft version, and the major version and
+	// path of the Java running it, detected on start
+	Software    *servertype.Info
+	JavaVersion int
+	JavaPath    string
+
+	// Active world, from level.dat (nil until the world exists)
+	World *world.Info
+
 	// Events
 	RecentEvents []ServerEvent
+
+	// Console lines lost because the spill file was full or unwritable
+	DroppedLines uint64
 }
 
 // ServerStatus represents the current server state
@@ -79,6 +322,7 @@ const (
 	StatusRestarting
 	StatusDownloading
 	StatusInstalling
+	StatusSuspended
 )
 
 func (s ServerStatus) String() string {
@@ -99,11 +343,19 @@ func (s ServerStatus) String() string {
 		return "Downloading Modpack"
 	case StatusInstalling:
 		return "Installing Modpack"
+	case StatusSuspended:
+		return "Suspended"
 	default:
 		return "Unknown"
 	}
 }
 
+// Label is the status in the language of the output, for people; String
+// stays English for the API and extensions
+func (s ServerStatus) Label() string {
+	return i18n.T(s.String())
+}
+
 func (s ServerStatus) Color() string {
 	switch s {
 	case StatusStopped:
@@ -118,6 +370,8 @@ func (s ServerStatus) Color() string {
 		return "#FF0000"
 	case StatusDownloading, StatusInstalling:
 		return "#00AAFF"
+	case StatusSuspended:
+		return "#5555FF"
 	default:
 		return "#FFFFFF"
 	}
@@ -143,8 +397,48 @@ const (
 	EventCommand
 	EventBackup
 	EventRestart
+	EventCustom
+	EventCritical
+	EventWhisper
+	EventEmote
 )
 
+// eventTypeNames maps the names used in event-patterns.json to types
+var eventTypeNames = map[string]EventType{
+	"info":     EventInfo,
+	"warning":  EventWarning,
+	"error":    EventError,
+	"join":     EventPlayerJoin,
+	"leave":    EventPlayerLeave,
+	"chat":     EventChat,
+	"command":  EventCommand,
+	"backup":   EventBackup,
+	"restart":  EventRestart,
+	"custom":   EventCustom,
+	"critical": EventCritical,
+	"whisper":  EventWhisper,
+	"emote":    EventEmote,
+}
+
+// ParseEventType looks up an event type by its config name
+func ParseEventType(name string) (EventType, error) {
+	if t, ok := eventTypeNames[name]; ok {
+		return t, nil
+	}
+	return 0, fmt.Errorf("unknown event type %q", name)
+}
+
+// Name returns the config name of an event type, as used in
+// event-patterns.json and script hooks
+func (e EventType) Name() string {
+	for name, t := range eventTypeNames {
+		if t == e {
+			return name
+		}
+	}
+	return "unknown"
+}
+
 func (e EventType) String() string {
 	switch e {
 	case EventInfo:
@@ -165,32 +459,15 @@ func (e EventType) String() string {
 		return "BACKUP"
 	case EventRestart:
 		return "RESTART"
+	case EventCustom:
+		return "CUSTOM"
+	case EventCritical:
+		return "CRIT"
+	case EventWhisper:
+		return "MSG"
+	case EventEmote:
+		return "ME"
 	default:
 		return "UNKNOWN"
 	}
 }
-
-func (e EventType) Color() string {
-	switch e {
-	case EventInfo:
-		return "#AAAAAA"
-	case EventWarning:
-		return "#FFAA00"
-	case EventError:
-		return "#FF5555"
-	case EventPlayerJoin:
-		return "#55FF55"
-	case EventPlayerLeave:
-		return "#FF5555"
-	case EventChat:
-		return "#55FFFF"
-	case EventCommand:
-		return "#AA55FF"
-	case EventBackup:
-		return "#5555FF"
-	case EventRestart:
-		return "#FFFF55"
-	default:
-		return "#FFFFFF"
-	}
-}
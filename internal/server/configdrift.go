@@ -0,0 +1,104 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"mcserver-manager/internal/configdrift"
+)
+
+// How often the tracked config files are compared with their snapshots
+// while the server runs
+const configDriftInterval = 30 * time.Second
+
+// What was happening when a config change was seen
+const (
+	driftWhileStopped = "edited while stopped"
+	driftByManager    = "changed by the manager on start"
+	driftWhileRunning = "edited while running"
+
+	driftByAdaptiveView  = "changed by adaptive view distance"
+	driftByWhitelistSync = "changed by the whitelist sync"
+)
+
+// checkConfigDrift records changes to the tracked config files since the
+// last check and reports each one as an event
+func (s *Server) checkConfigDrift(when string) {
+	changes, err := configdrift.Check(s.config.ServerDir, when)
+	for i := range changes {
+		s.addEvent(EventWarning, "Config "+summarizeChange(&changes[i]))
+	}
+	if err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Config drift check failed: %v", err))
+	}
+}
+
+// summarizeChange shortens a change to a few diff lines for the event log
+func summarizeChange(c *configdrift.Change) string {
+	const shown = 3
+	diff := c.Diff
+	more := ""
+	if len(diff) > shown {
+		more = fmt.Sprintf(" and %d more", len(diff)-shown)
+		diff = diff[:shown]
+	}
+	return fmt.Sprintf("%s %s: %s%s", c.File, c.When, strings.Join(diff, ", "), more)
+}
+
+// configDriftLoop checks the tracked config files while one server process
+// runs, which catches both the server's own writes (whitelist add, op) and
+// edits by hand
+func (s *Server) configDriftLoop() {
+	proc := s.cmd
+	ticker := time.NewTicker(configDriftInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if s.cmd != proc {
+				return
+			}
+			s.checkConfigDrift(driftWhileRunning)
+		}
+	}
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "confighistory",
+		Usage: "confighistory [file] [count]",
+		Help:  "Show recorded changes to server.properties, the whitelist, ops and ban lists",
+		Run: func(s *Server, args []string) (string, error) {
+			file, limit := "", 20
+			for _, arg := range args {
+				if n, err := strconv.Atoi(arg); err == nil {
+					limit = n
+				} else {
+					file = arg
+				}
+			}
+
+			// Pick up anything changed since the last tick
+			if s.stats.Status == StatusRunning {
+				s.checkConfigDrift(driftWhileRunning)
+			}
+			changes, err := configdrift.History(s.config.ServerDir, file, limit)
+			if err != nil {
+				return "", err
+			}
+			if len(changes) == 0 {
+				return "No config changes recorded", nil
+			}
+			var lines []string
+			for i := range changes {
+				lines = append(lines, changes[i].Time.Format("2006-01-02 15:04")+"  "+changes[i].String())
+			}
+			return strings.Join(lines, "\n"), nil
+		},
+	})
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// outputWaiter receives the first console line matching pattern
+type outputWaiter struct {
+	pattern *regexp.Regexp
+	line    chan string
+}
+
+// CommandOutput sends a console command and returns the first output line
+// matching pattern, for commands whose reply needs checking
+func (s *Server) CommandOutput(command string, pattern *regexp.Regexp, timeout time.Duration) (string, error) {
+	w := &outputWaiter{pattern: pattern, line: make(chan string, 1)}
+
+	s.waitersMutex.Lock()
+	s.waiters = append(s.waiters, w)
+	s.waitersMutex.Unlock()
+	defer s.removeWaiter(w)
+
+	if err := s.SendCommand(command); err != nil {
+		return "", err
+	}
+
+	select {
+	case line := <-w.line:
+		return line, nil
+	case <-time.After(timeout):
+		return "", fmt.Errorf("no reply to %q within %s", command, timeout)
+	}
+}
+
+// notifyWaiters hands a console line to any CommandOutput call expecting it
+func (s *Server) notifyWaiters(line string) {
+	s.waitersMutex.Lock()
+	defer s.waitersMutex.Unlock()
+
+	for _, w := range s.waiters {
+		if w.pattern.MatchString(line) {
+			select {
+			case w.line <- line:
+			default:
+			}
+		}
+	}
+}
+
+func (s *Server) removeWaiter(w *outputWaiter) {
+	s.waitersMutex.Lock()
+	defer s.waitersMutex.Unlock()
+
+	for i, other := range s.waiters {
+		if other == w {
+			s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+			return
+		}
+	}
+}
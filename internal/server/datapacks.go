@@ -0,0 +1,229 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"mcserver-manager/internal/stats"
+	"mcserver-manager/internal/world"
+)
+
+var (
+	// "There are 2 data pack(s) enabled: [vanilla (built-in)], [file/x.zip (world)]"
+	// or "There are no data packs enabled"
+	datapackListRegex = regexp.MustCompile(`There are (?:no|\d+) data packs?(?:\(s\))? enabled(?:: (.*))?`)
+	datapackItemRegex = regexp.MustCompile(`\[([^\[\]]+)\]`)
+)
+
+// ListDatapacks returns the active world's datapacks. While the server is
+// running, Enabled comes from /datapack list rather than level.dat.
+func (s *Server) ListDatapacks() ([]world.Datapack, error) {
+	packs, err := world.Datapacks(s.activeWorldPath())
+	if err != nil {
+		return nil, err
+	}
+	if s.stats.Status != StatusRunning {
+		return packs, nil
+	}
+
+	enabled, err := s.enabledDatapacks()
+	if err != nil {
+		return nil, err
+	}
+	for i := range packs {
+		packs[i].Enabled = enabled[packs[i].ID()]
+	}
+	return packs, nil
+}
+
+// InstallDatapack adds a datapack zip or URL to the active world. A running
+// server reloads to pick it up; otherwise it is enabled on the next start.
+func (s *Server) InstallDatapack(source string) (*world.Datapack, error) {
+	pack, err := world.InstallDatapack(s.config.ServerDir, s.activeWorldPath(), source)
+	if err != nil {
+		return nil, err
+	}
+	s.addEvent(EventInfo, fmt.Sprintf("Installed datapack %s", pack.Name))
+
+	if s.stats.Status != StatusRunning {
+		return pack, nil
+	}
+
+	// /reload enables packs it has not seen before
+	if err := s.SendCommand("reload"); err != nil {
+		return pack, err
+	}
+	time.Sleep(2 * time.Second)
+	enabled, err := s.enabledDatapacks()
+	if err != nil {
+		return pack, err
+	}
+	if !enabled[pack.ID()] {
+		return pack, s.setDatapack(pack, true)
+	}
+	pack.Enabled = true
+	return pack, nil
+}
+
+// EnableDatapack turns on an installed pack on the running server
+func (s *Server) EnableDatapack(name string) error {
+	return s.toggleDatapack(name, true)
+}
+
+// DisableDatapack turns off an installed pack on the running server
+func (s *Server) DisableDatapack(name string) error {
+	return s.toggleDatapack(name, false)
+}
+
+func (s *Server) toggleDatapack(name string, enable bool) error {
+	if s.stats.Status != StatusRunning {
+		// Enabled packs live in level.dat, which only the server writes
+		return fmt.Errorf("the server must be running to enable or disable datapacks")
+	}
+	pack, err := world.FindDatapack(s.activeWorldPath(), name)
+	if err != nil {
+		return err
+	}
+	return s.setDatapack(pack, enable)
+}
+
+// RemoveDatapack disables a pack if the server is running, then deletes it
+func (s *Server) RemoveDatapack(name string) error {
+	pack, err := world.FindDatapack(s.activeWorldPath(), name)
+	if err != nil {
+		return err
+	}
+
+	if s.stats.Status == StatusRunning {
+		enabled, err := s.enabledDatapacks()
+		if err != nil {
+			return err
+		}
+		if enabled[pack.ID()] {
+			if err := s.setDatapack(pack, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := world.RemoveDatapack(s.activeWorldPath(), pack.Name); err != nil {
+		return err
+	}
+	s.addEvent(EventInfo, fmt.Sprintf("Removed datapack %s", pack.Name))
+	return nil
+}
+
+// setDatapack issues /datapack enable|disable and checks the enabled list
+// afterwards, since the command only reports failures as chat text
+func (s *Server) setDatapack(pack *world.Datapack, enable bool) error {
+	verb := "disable"
+	if enable {
+		verb = "enable"
+	}
+	if err := s.SendCommand(fmt.Sprintf(`datapack %s "%s"`, verb, pack.ID())); err != nil {
+		return err
+	}
+	time.Sleep(time.Second)
+
+	enabled, err := s.enabledDatapacks()
+	if err != nil {
+		return err
+	}
+	if enabled[pack.ID()] != enable {
+		return fmt.Errorf("failed to %s %s: the server still lists it as %s", verb, pack.Name, enabledWord(!enable))
+	}
+	pack.Enabled = enable
+	s.addEvent(EventInfo, fmt.Sprintf("Datapack %s %sd", pack.Name, verb))
+	return nil
+}
+
+// enabledDatapacks asks the running server which packs are enabled
+func (s *Server) enabledDatapacks() (map[string]bool, error) {
+	line, err := s.CommandOutput("datapack list enabled", datapackListRegex, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled := map[string]bool{}
+	list := datapackListRegex.FindStringSubmatch(line)[1]
+	for _, m := range datapackItemRegex.FindAllStringSubmatch(list, -1) {
+		// Newer versions append the source: "file/x.zip (world)"
+		id, _, _ := strings.Cut(m[1], " (")
+		enabled[strings.TrimSpace(id)] = true
+	}
+	return enabled, nil
+}
+
+func enabledWord(enabled bool) string {
+	if enabled {
+		return "enabled"
+	}
+	return "disabled"
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "datapack",
+		Usage: "datapack list|install|enable|disable|remove ...",
+		Help:  "Manage the active world's datapacks (install <zip|url>)",
+		Admin: true,
+		Run:   runDatapackAction,
+	})
+}
+
+func runDatapackAction(s *Server, args []string) (string, error) {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+
+	switch args[0] {
+	case "list":
+		packs, err := s.ListDatapacks()
+		if err != nil {
+			return "", err
+		}
+		if len(packs) == 0 {
+			return "No datapacks installed", nil
+		}
+		var lines []string
+		for _, p := range packs {
+			lines = append(lines, fmt.Sprintf("%-8s %-32s %10s", enabledWord(p.Enabled), p.Name, stats.FormatBytes(uint64(p.Size))))
+		}
+		return strings.Join(lines, "\n"), nil
+
+	case "install":
+		if len(args) != 2 {
+			return "", fmt.Errorf("usage: datapack install <zip|url>")
+		}
+		pack, err := s.InstallDatapack(args[1])
+		if err != nil {
+			return "", err
+		}
+		if !pack.Enabled {
+			return fmt.Sprintf("Installed %s; it is enabled when the server next starts", pack.Name), nil
+		}
+		return fmt.Sprintf("Installed and enabled %s", pack.Name), nil
+
+	case "enable":
+		if len(args) != 2 {
+			return "", fmt.Errorf("usage: datapack enable <name>")
+		}
+		return "Enabled " + args[1], s.EnableDatapack(args[1])
+
+	case "disable":
+		if len(args) != 2 {
+			return "", fmt.Errorf("usage: datapack disable <name>")
+		}
+		return "Disabled " + args[1], s.DisableDatapack(args[1])
+
+	case "remove":
+		if len(args) != 2 {
+			return "", fmt.Errorf("usage: datapack remove <name>")
+		}
+		return "Removed " + args[1], s.RemoveDatapack(args[1])
+	}
+
+	return "", fmt.Errorf("unknown datapack action %q", args[0])
+}
@@ -0,0 +1,312 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// deathsFile holds the last deaths of each player, relative to the server
+// dir
+const deathsFile = ".mcserver/deaths.json"
+
+// Deaths kept per player
+const deathsKeep = 10
+
+// How long the server has to answer a location query
+const deathQueryTimeout = 5 * time.Second
+
+var (
+	// 1.19+: "Steve has the following entity data: {dimension:
+	// "minecraft:overworld", pos: [I; 12, 64, -3]}"
+	lastDeathRegex = regexp.MustCompile(`has the following entity data: \{dimension: "([^"]+)", pos: \[I; (-?\d+), (-?\d+), (-?\d+)\]\}`)
+	// "Steve has the following entity data: [12.5d, 64.0d, -3.2d]"
+	entityPosRegex = regexp.MustCompile(`has the following entity data: \[(-?[\d.E]+)d, (-?[\d.E]+)d, (-?[\d.E]+)d\]`)
+	// "Steve has the following entity data: "minecraft:the_nether"", or
+	// 0/-1/1 before 1.16
+	entityDimensionRegex = regexp.MustCompile(`has the following entity data: "?([\w:.-]+)"?$`)
+	// What the server says when it has no such data
+	entityDataErrorRegex = regexp.MustCompile(`No entity was found|Found no elements matching|Unknown or incomplete command|Unknown command`)
+)
+
+// Death is where and how a player died
+type Death struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+	// Empty when the server could not say where
+	Dimension string  `json:"dimension,omitempty"`
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Z         float64 `json:"z"`
+}
+
+// Located reports whether the death location is known
+func (d Death) Located() bool {
+	return d.Dimension != ""
+}
+
+// Place describes the location, like "12 64 -3 in the overworld"
+func (d Death) Place() string {
+	if !d.Located() {
+		return "an unknown location"
+	}
+	return fmt.Sprintf("%.0f %.0f %.0f in the %s", d.X, d.Y, d.Z, strings.ReplaceAll(strings.TrimPrefix(d.Dimension, "minecraft:"), "_", " "))
+}
+
+// playerDied records a death message about an online player and asks the
+// server where it happened; it runs apart from the output reader, which
+// has to deliver the replies
+func (s *Server) playerDied(name, message string) {
+	death := Death{Time: time.Now(), Message: name + " " + message}
+	if err := s.locateDeath(name, &death); err != nil {
+		s.addEvent(EventInfo, fmt.Sprintf("%s (location unknown: %v)", death.Message, err))
+	} else {
+		s.addEvent(EventInfo, fmt.Sprintf("%s at %s", death.Message, death.Place()))
+	}
+
+	s.deathsMutex.Lock()
+	defer s.deathsMutex.Unlock()
+	deaths, err := ReadDeaths(s.config.ServerDir)
+	if err == nil {
+		deaths[name] = append(deaths[name], death)
+		if len(deaths[name]) > deathsKeep {
+			deaths[name] = deaths[name][len(deaths[name])-deathsKeep:]
+		}
+		err = writeDeaths(s.config.ServerDir, deaths)
+	}
+	if err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Could not save %s: %v", deathsFile, err))
+	}
+}
+
+// locateDeath asks for the player's LastDeathLocation, and on servers
+// before 1.19 for where the dead player still is
+func (s *Server) locateDeath(name string, death *Death) error {
+	reply := func(path string, want *regexp.Regexp) ([]string, error) {
+		pattern := regexp.MustCompile(regexp.QuoteMeta(name) + " " + want.String() + "|" + entityDataErrorRegex.String())
+		line, err := s.CommandOutput("data get entity "+name+" "+path, pattern, deathQueryTimeout)
+		if err != nil {
+			return nil, err
+		}
+		if m := want.FindStringSubmatch(line); m != nil {
+			return m, nil
+		}
+		return nil, fmt.Errorf("the server has no %s for %s", path, name)
+	}
+
+	if m, err := reply("LastDeathLocation", lastDeathRegex); err == nil {
+		death.X, _ = strconv.ParseFloat(m[2], 64)
+		death.Y, _ = strconv.ParseFloat(m[3], 64)
+		death.Z, _ = strconv.ParseFloat(m[4], 64)
+		// Block coordinates; the middle of the block is safer to land on
+		death.X, death.Z = death.X+0.5, death.Z+0.5
+		death.Dimension = m[1]
+		return nil
+	}
+
+	pos, err := reply("Pos", entityPosRegex)
+	if err != nil {
+		return err
+	}
+	dim, err := reply("Dimension", entityDimensionRegex)
+	if err != nil {
+		return err
+	}
+	death.X, _ = strconv.ParseFloat(pos[1], 64)
+	death.Y, _ = strconv.ParseFloat(pos[2], 64)
+	death.Z, _ = strconv.ParseFloat(pos[3], 64)
+	death.Dimension = legacyDimension(dim[1])
+	return nil
+}
+
+// legacyDimension names the numeric dimensions of servers before 1.16
+func legacyDimension(dim string) string {
+	switch dim {
+	case "0":
+		return "minecraft:overworld"
+	case "-1":
+		return "minecraft:the_nether"
+	case "1":
+		return "minecraft:the_end"
+	}
+	return dim
+}
+
+// ReadDeaths returns the recorded deaths by player, oldest first
+func ReadDeaths(serverDir string) (map[string][]Death, error) {
+	deaths := map[string][]Death{}
+	data, err := os.ReadFile(filepath.Join(serverDir, deathsFile))
+	if os.IsNotExist(err) {
+		return deaths, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &deaths); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", deathsFile, err)
+	}
+	return deaths, nil
+}
+
+func writeDeaths(serverDir string, deaths map[string][]Death) error {
+	data, err := json.MarshalIndent(deaths, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(serverDir, deathsFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// playerDeaths finds a player's deaths, ignoring the case of the name
+func playerDeaths(deaths map[string][]Death, player string) (string, []Death) {
+	for name, list := range deaths {
+		if strings.EqualFold(name, player) {
+			return name, list
+		}
+	}
+	return player, nil
+}
+
+// TeleportBack sends a player to where they died, n deaths ago (1 is the
+// last)
+func (s *Server) TeleportBack(player string, n int) (string, error) {
+	if s.GetStats().Status != StatusRunning {
+		return "", fmt.Errorf("the server is not running")
+	}
+	deaths, err := ReadDeaths(s.config.ServerDir)
+	if err != nil {
+		return "", err
+	}
+	name, list := playerDeaths(deaths, player)
+	if len(list) == 0 {
+		return "", fmt.Errorf("no deaths recorded for %s", player)
+	}
+	if n < 1 || n > len(list) {
+		return "", fmt.Errorf("%s has %s recorded", name, plural(len(list), "death"))
+	}
+	death := list[len(list)-n]
+	if !death.Located() {
+		return "", fmt.Errorf("the server did not say where %s died at %s", name, deathTime(death.Time))
+	}
+
+	command := fmt.Sprintf("execute in %s run tp %s %s %s %s", death.Dimension, name, formatCoord(death.X), formatCoord(death.Y), formatCoord(death.Z))
+	if err := s.SendCommand(command); err != nil {
+		return "", err
+	}
+	s.addEvent(EventInfo, fmt.Sprintf("Teleported %s back to %s", name, death.Place()))
+	return fmt.Sprintf("Teleported %s to %s (%s, %s)", name, death.Place(), death.Message, deathTime(death.Time)), nil
+}
+
+func formatCoord(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// deathTime is the time of a death, with the date unless it was today
+func deathTime(t time.Time) string {
+	if t.Format("2006-01-02") == time.Now().Format("2006-01-02") {
+		return t.Format("15:04")
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+// playerOnline reports whether a player is tracked as online
+func (s *Server) playerOnline(name string) bool {
+	s.statsMutex.RLock()
+	defer s.statsMutex.RUnlock()
+	for _, p := range s.stats.Players {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// describeDeaths lists the recorded deaths of one player, or the last one
+// of every player
+func describeDeaths(serverDir, player string) (string, error) {
+	deaths, err := ReadDeaths(serverDir)
+	if err != nil {
+		return "", err
+	}
+	var lines []string
+	if player != "" {
+		name, list := playerDeaths(deaths, player)
+		if len(list) == 0 {
+			return fmt.Sprintf("No deaths recorded for %s", player), nil
+		}
+		for i := len(list) - 1; i >= 0; i-- {
+			d := list[i]
+			lines = append(lines, fmt.Sprintf("%2d  %s  %s, at %s", len(list)-i, d.Time.Format("2006-01-02 15:04"), d.Message, d.Place()))
+		}
+		lines = append(lines, fmt.Sprintf("\"back %s [n]\" teleports them to one", name))
+		return strings.Join(lines, "\n"), nil
+	}
+
+	if len(deaths) == 0 {
+		return "No deaths recorded", nil
+	}
+	names := make([]string, 0, len(deaths))
+	for name := range deaths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		list := deaths[name]
+		if len(list) == 0 {
+			continue
+		}
+		d := list[len(list)-1]
+		lines = append(lines, fmt.Sprintf("%-16s %s  %s, at %s", name, d.Time.Format("2006-01-02 15:04"), d.Message, d.Place()))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "deaths",
+		Usage: "deaths [player]",
+		Help:  "List where players last died, or all recorded deaths of one player",
+		Run: func(s *Server, args []string) (string, error) {
+			if len(args) > 1 {
+				return "", fmt.Errorf("usage: deaths [player]")
+			}
+			player := ""
+			if len(args) == 1 {
+				player = args[0]
+			}
+			return describeDeaths(s.config.ServerDir, player)
+		},
+	})
+
+	registerAction(&Action{
+		Name:  "back",
+		Usage: "back <player> [n]",
+		Help:  "Teleport a player to where they last died, or died n deaths ago",
+		Admin: true,
+		Run: func(s *Server, args []string) (string, error) {
+			if len(args) < 1 || len(args) > 2 {
+				return "", fmt.Errorf("usage: back <player> [n]")
+			}
+			n := 1
+			if len(args) == 2 {
+				var err error
+				if n, err = strconv.Atoi(args[1]); err != nil || n < 1 {
+					return "", fmt.Errorf("usage: back <player> [n]")
+				}
+			}
+			return s.TeleportBack(args[0], n)
+		},
+	})
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+
+	"mcserver-manager/internal/stats"
+)
+
+// How long the server's disk has to stay at DiskAlert before a warning
+const diskAlertAfter = 30 * time.Second
+
+// diskTracker follows the device holding the server directory. Its busy
+// time (Linux only) shows saturation, which the server's own I/O cannot:
+// a backup is written by the manager, not the JVM.
+type diskTracker struct {
+	device    string // name in disk.IOCounters, "" when unknown
+	lastBusy  uint64 // milliseconds the device spent on I/O
+	lastCheck time.Time
+	busySince time.Time
+	warned    bool
+}
+
+// findDiskDevice returns the IOCounters name of the block device the
+// server directory is on
+func findDiskDevice(dir string) string {
+	dir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return ""
+	}
+	partitions, err := disk.Partitions(false)
+	if err != nil {
+		return ""
+	}
+	device, mount := "", ""
+	for _, p := range partitions {
+		if len(p.Mountpoint) > len(mount) && (dir == p.Mountpoint || strings.HasPrefix(dir, strings.TrimSuffix(p.Mountpoint, "/")+"/")) {
+			device, mount = p.Device, p.Mountpoint
+		}
+	}
+	if !strings.HasPrefix(device, "/dev/") {
+		// overlay, tmpfs and the like
+		return ""
+	}
+	// /dev/mapper/vg-root is a link to /dev/dm-0
+	if resolved, err := filepath.EvalSymlinks(device); err == nil {
+		device = resolved
+	}
+	return filepath.Base(device)
+}
+
+// updateDiskUtil samples how busy the server's disk is; statsMutex must be
+// held
+func (s *Server) updateDiskUtil(now time.Time) {
+	d := &s.disk
+	if d.device == "" {
+		return
+	}
+	counters, err := disk.IOCounters(d.device)
+	if err != nil {
+		return
+	}
+	c, ok := counters[d.device]
+	if !ok {
+		return
+	}
+	if !d.lastCheck.IsZero() && c.IoTime >= d.lastBusy {
+		if elapsed := now.Sub(d.lastCheck).Milliseconds(); elapsed > 0 {
+			s.stats.DiskUtil = min(float64(c.IoTime-d.lastBusy)/float64(elapsed)*100, 100)
+		}
+	}
+	d.lastBusy = c.IoTime
+	d.lastCheck = now
+}
+
+// checkDiskSaturation warns once per episode of the server's disk staying
+// at DiskAlert percent busy for diskAlertAfter
+func (s *Server) checkDiskSaturation() {
+	if s.config.DiskAlert <= 0 {
+		return
+	}
+	st := s.GetStats()
+	d := &s.disk
+	if st.DiskUtil < float64(s.config.DiskAlert) {
+		d.busySince = time.Time{}
+		d.warned = false
+		return
+	}
+	if d.busySince.IsZero() {
+		d.busySince = time.Now()
+	}
+	if d.warned || time.Since(d.busySince) < diskAlertAfter {
+		return
+	}
+	d.warned = true
+
+	cause := "usually autosave or a backup competing for the disk"
+	if s.backingUp.Load() {
+		cause = "a backup is running"
+	}
+	s.addEvent(EventWarning, fmt.Sprintf("Disk %s %.0f%% busy for %s (server reading %s, writing %s); %s",
+		d.device, st.DiskUtil, diskAlertAfter, stats.FormatBytesPerSec(st.DiskReadRate), stats.FormatBytesPerSec(st.DiskWriteRate), cause))
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"mcserver-manager/internal/curseforge"
+	"mcserver-manager/internal/stats"
+)
+
+// How often mod download progress is logged as an event
+const downloadEventInterval = 10 * time.Second
+
+// trackModDownloads shows the mod downloads of a modpack install in the
+// stats, for the TUI's progress bar, and as an event every
+// downloadEventInterval. The status is Downloading until they finish.
+// The returned func clears the progress of downloads cut short.
+func (s *Server) trackModDownloads(cf *curseforge.Client) func() {
+	var mu sync.Mutex
+	var logged time.Time
+	cf.Progress = func(p curseforge.DownloadProgress) {
+		finished := p.Done == p.Total
+
+		s.statsMutex.Lock()
+		if finished {
+			s.stats.Download = nil
+			s.stats.Status = StatusInstalling
+		} else {
+			s.stats.Download = &p
+			s.stats.Status = StatusDownloading
+		}
+		s.statsMutex.Unlock()
+
+		mu.Lock()
+		defer mu.Unlock()
+		if !finished && time.Since(logged) < downloadEventInterval {
+			return
+		}
+		logged = time.Now()
+		s.addEvent(EventInfo, describeDownload(p))
+	}
+	return func() {
+		s.statsMutex.Lock()
+		s.stats.Download = nil
+		s.statsMutex.Unlock()
+	}
+}
+
+// describeDownload is a progress line like "Downloading mods: 12/140,
+// 48.2 MB of 310.0 MB, about 1m20s left"
+func describeDownload(p curseforge.DownloadProgress) string {
+	if p.Done == p.Total {
+		line := fmt.Sprintf("Downloaded %s (%s)", plural(p.Total-p.Failed, "mod"), stats.FormatBytes(uint64(max(p.Bytes, 0))))
+		if p.Failed > 0 {
+			line += fmt.Sprintf(", %d failed", p.Failed)
+		}
+		return line
+	}
+	line := fmt.Sprintf("Downloading mods: %d/%d", p.Done, p.Total)
+	if p.TotalBytes > 0 {
+		line += fmt.Sprintf(", %s of %s", stats.FormatBytes(uint64(max(p.Bytes, 0))), stats.FormatBytes(uint64(p.TotalBytes)))
+	} else if p.Bytes > 0 {
+		line += ", " + stats.FormatBytes(uint64(p.Bytes))
+	}
+	if p.ETA > 0 {
+		line += fmt.Sprintf(", about %s left", p.ETA.Round(time.Second))
+	}
+	return line
+}
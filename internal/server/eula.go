@@ -0,0 +1,83 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const eulaURL = "https://aka.ms/MinecraftEULA"
+
+// ErrEULANotAccepted is returned by Start while eula.txt does not agree to
+// Mojang's EULA and the config does not accept it on the operator's behalf
+var ErrEULANotAccepted = errors.New("the Minecraft EULA has not been accepted (" + eulaURL + ")")
+
+// EULAAccepted reports whether eula.txt in serverDir agrees to the EULA
+func EULAAccepted(serverDir string) bool {
+	data, err := os.ReadFile(filepath.Join(serverDir, "eula.txt"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if ok && strings.TrimSpace(key) == "eula" {
+			return strings.EqualFold(strings.TrimSpace(value), "true")
+		}
+	}
+	return false
+}
+
+// acceptEULA makes sure the EULA is accepted before launch. It is only
+// written on the operator's behalf with --accept-eula.
+func (s *Server) acceptEULA() error {
+	if EULAAccepted(s.config.ServerDir) {
+		return nil
+	}
+	if !s.config.AcceptEULA {
+		return ErrEULANotAccepted
+	}
+	return s.AcceptEULA("--accept-eula")
+}
+
+// AcceptEULA writes eula.txt agreeing to the EULA, noting how and when
+func (s *Server) AcceptEULA(how string) error {
+	content := fmt.Sprintf("#By changing the setting below to TRUE you are indicating your agreement to our EULA (%s).\n#Accepted %s via %s\neula=true\n",
+		eulaURL, time.Now().Format(time.RFC3339), how)
+	if err := os.MkdirAll(s.config.ServerDir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(s.config.ServerDir, "eula.txt"), []byte(content), 0644); err != nil {
+		return err
+	}
+	s.statsMutex.Lock()
+	s.stats.EULARequired = false
+	s.statsMutex.Unlock()
+	return nil
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "eula",
+		Usage: "eula [accept]",
+		Help:  "Show whether Mojang's EULA is accepted, or accept it",
+		Admin: true,
+		Run: func(s *Server, args []string) (string, error) {
+			if len(args) == 0 {
+				if EULAAccepted(s.config.ServerDir) {
+					return "EULA accepted", nil
+				}
+				return "EULA not accepted; read " + eulaURL + " and run :eula accept", nil
+			}
+			if args[0] != "accept" {
+				return "", fmt.Errorf("usage: eula [accept]")
+			}
+			if err := s.AcceptEULA(":eula accept"); err != nil {
+				return "", err
+			}
+			return "EULA accepted; the server can start now", nil
+		},
+	})
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// EventStyle is how the TUI and the web dashboard show an event type
+type EventStyle struct {
+	Color string // "#RRGGBB"
+	Icon  string
+
+	// Listed in the TUI event panel; otherwise the type is only in the
+	// event log, the dashboard and the support bundle
+	Panel bool
+}
+
+var eventStyles = map[EventType]EventStyle{
+	EventInfo:        {Color: "#AAAAAA", Icon: "•"},
+	EventWarning:     {Color: "#FFAA00", Icon: "!", Panel: true},
+	EventError:       {Color: "#FF5555", Icon: "✗", Panel: true},
+	EventPlayerJoin:  {Color: "#55FF55", Icon: "→", Panel: true},
+	EventPlayerLeave: {Color: "#FF5555", Icon: "←", Panel: true},
+	EventChat:        {Color: "#55FFFF", Icon: "»"},
+	EventCommand:     {Color: "#AA55FF", Icon: "/"},
+	EventBackup:      {Color: "#5555FF", Icon: "▣", Panel: true},
+	EventRestart:     {Color: "#FFFF55", Icon: "↻", Panel: true},
+	EventCustom:      {Color: "#FF55FF", Icon: "◆", Panel: true},
+	EventCritical:    {Color: "#FF0000", Icon: "‼", Panel: true},
+	EventWhisper:     {Color: "#FFAAFF", Icon: "✉"},
+	EventEmote:       {Color: "#55AAAA", Icon: "*"},
+}
+
+var colorRegex = regexp.MustCompile(`^#([0-9A-Fa-f]{3}){1,2}$`)
+
+// Style returns how an event type is shown
+func (e EventType) Style() EventStyle {
+	if style, ok := eventStyles[e]; ok {
+		return style
+	}
+	return EventStyle{Color: "#FFFFFF", Icon: "•"}
+}
+
+func (e EventType) Color() string { return e.Style().Color }
+func (e EventType) Icon() string  { return e.Style().Icon }
+
+// InPanel reports whether the TUI event panel lists the type
+func (e EventType) InPanel() bool { return e.Style().Panel }
+
+// ConfigureEventStyles overrides the colors and icons of event types, from
+// "type=color", "type=color,icon" or "type=,icon" (--event-style), and,
+// when panel is not empty, sets the types the TUI event panel lists
+// (--event-panel). It applies to the whole process, so to every server
+// it runs.
+func ConfigureEventStyles(styles, panel []string) error {
+	next := make(map[EventType]EventStyle, len(eventStyles))
+	for t, style := range eventStyles {
+		next[t] = style
+	}
+
+	for _, spec := range styles {
+		name, value, ok := strings.Cut(spec, "=")
+		if !ok {
+			return fmt.Errorf("event style %q: want type=color[,icon]", spec)
+		}
+		t, err := ParseEventType(strings.TrimSpace(name))
+		if err != nil {
+			return fmt.Errorf("event style %q: %w", spec, err)
+		}
+		style := next[t]
+		color, icon, hasIcon := strings.Cut(value, ",")
+		if color = strings.TrimSpace(color); color != "" {
+			if !colorRegex.MatchString(color) {
+				return fmt.Errorf("event style %q: color must be #RRGGBB or #RGB", spec)
+			}
+			style.Color = color
+		}
+		if hasIcon {
+			style.Icon = strings.TrimSpace(icon)
+		}
+		next[t] = style
+	}
+
+	if len(panel) > 0 {
+		shown := map[EventType]bool{}
+		for _, name := range panel {
+			t, err := ParseEventType(strings.TrimSpace(name))
+			if err != nil {
+				return fmt.Errorf("event panel: %w", err)
+			}
+			shown[t] = true
+		}
+		for t, style := range next {
+			style.Panel = shown[t]
+			next[t] = style
+		}
+	}
+
+	eventStyles = next
+	return nil
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"mcserver-manager/internal/backup"
+	"mcserver-manager/pkg/eventbus"
+	"mcserver-manager/pkg/extension"
+)
+
+const (
+	metricsInterval    = 15 * time.Second
+	backupStoreTimeout = 30 * time.Minute
+)
+
+// startExtensions hooks the registered extensions up to this server; it
+// runs once, from New
+func (s *Server) startExtensions() {
+	for _, h := range extension.EventHandlers() {
+		sub := s.events.Subscribe(eventbus.WithBuffer[extension.Event](1000))
+		go func() {
+			defer sub.Close()
+			for event := range sub.C() {
+				h.HandleEvent(event)
+			}
+		}()
+	}
+
+	if exporters := extension.MetricsExporters(); len(exporters) > 0 {
+		go s.exportMetricsLoop(exporters)
+	}
+}
+
+func (s *Server) exportMetricsLoop(exporters []extension.MetricsExporter) {
+	ticker := time.NewTicker(metricsInterval)
+	defer ticker.Stop()
+
+	// Report each exporter's failure once until it recovers
+	failing := map[string]bool{}
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		st := s.GetStats()
+		metrics := extension.Metrics{
+			Time:          time.Now(),
+			Status:        st.Status.String(),
+			Uptime:        st.Uptime,
+			TPS:           st.TPS,
+			MSPT:          st.MSPT,
+			MemoryUsed:    st.MemoryUsed,
+			MemoryMax:     st.MemoryMax,
+			CPUPercent:    st.CPUPercent,
+			Players:       st.PlayerCount,
+			MaxPlayers:    st.MaxPlayers,
+			DiskReadRate:  st.DiskReadRate,
+			DiskWriteRate: st.DiskWriteRate,
+			DiskUtil:      st.DiskUtil,
+			BandwidthIn:   st.DiskReadRate,
+			BandwidthOut:  st.DiskWriteRate,
+		}
+		for _, e := range exporters {
+			err := e.ExportMetrics(metrics)
+			if err != nil && !failing[e.Name()] {
+				s.addEvent(EventWarning, fmt.Sprintf("Metrics exporter %s failed: %v", e.Name(), err))
+			}
+			failing[e.Name()] = err != nil
+		}
+	}
+}
+
+// storeBackup uploads a finished backup to the configured backup targets,
+// pruning each to BackupRemoteKeep, and hands it to every registered
+// backup target extension. A named server uploads into its own folder on
+// each target, since every server of --servers shares the targets.
+func (s *Server) storeBackup(path string) {
+	for _, spec := range s.config.BackupTargets {
+		if s.config.Name != "" {
+			spec = backup.ServerTarget(spec, s.config.Name)
+		}
+		target, err := backup.ParseTarget(spec)
+		if err != nil {
+			// Checked when the flags were read
+			continue
+		}
+		ctx, cancel := context.WithTimeout(s.ctx, backupStoreTimeout)
+		err = target.Upload(ctx, path)
+		var removed []string
+		if err == nil {
+			removed, err = backup.Prune(ctx, target, s.remoteKeep())
+			if err != nil {
+				err = fmt.Errorf("uploaded, but pruning failed: %w", err)
+			}
+		}
+		cancel()
+		if err != nil {
+			s.addEvent(EventError, fmt.Sprintf("Backup upload to %s failed: %v", target, err))
+			continue
+		}
+		message := fmt.Sprintf("Backup uploaded to %s", target)
+		if len(removed) > 0 {
+			message += fmt.Sprintf(", removed %d old", len(removed))
+		}
+		s.addEvent(EventBackup, message)
+	}
+
+	for _, t := range extension.BackupTargets() {
+		ctx, cancel := context.WithTimeout(s.ctx, backupStoreTimeout)
+		err := t.StoreBackup(ctx, path)
+		cancel()
+		if err != nil {
+			s.addEvent(EventError, fmt.Sprintf("Backup target %s failed: %v", t.Name(), err))
+			continue
+		}
+		s.addEvent(EventBackup, fmt.Sprintf("Backup stored with %s", t.Name()))
+	}
+}
+
+// remoteKeep is how many backups each backup target keeps
+func (s *Server) remoteKeep() int {
+	if s.config.BackupRemoteKeep > 0 {
+		return s.config.BackupRemoteKeep
+	}
+	return s.config.MaxBackups
+}
+
+// downloadExtensionModpack fetches the modpack from an extension when the
+// modpack ID is "<source>:<id>" for a registered mod source. It returns an
+// empty path when the ID is a plain CurseForge one.
+func (s *Server) downloadExtensionModpack(modpackID, version string) (string, error) {
+	name, id, found := strings.Cut(modpackID, ":")
+	if !found {
+		return "", nil
+	}
+	src, ok := extension.ModSourceFor(name)
+	if !ok {
+		return "", fmt.Errorf("no mod source extension named %q", name)
+	}
+	return src.DownloadModpack(s.ctx, id, version, s.config.ServerDir)
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"mcserver-manager/internal/audit"
+	"mcserver-manager/internal/gitops"
+)
+
+// syncGitOps pulls the config repository and applies it. On a running
+// server the whitelist is reloaded in place; other files the server only
+// reads at startup raise the restart prompt.
+func (s *Server) syncGitOps() (*gitops.Result, error) {
+	result, err := s.gitops.Sync()
+	if err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("GitOps sync failed: %v", err))
+		return nil, err
+	}
+
+	if !result.Empty() {
+		s.addEvent(EventInfo, "GitOps applied "+result.String())
+		s.RecordAction(audit.SourceGitOps, s.gitops.Branch, "gitops sync", result.String(), nil)
+		s.applyGitOpsLive(result)
+	}
+	for _, file := range result.Invalid {
+		s.addEvent(EventWarning, "GitOps skipped invalid "+file)
+	}
+
+	drift := strings.Join(result.Drifted, ", ")
+	if drift != s.gitopsDrift && drift != "" {
+		s.addEvent(EventWarning, "GitOps drift, local edits kept: "+drift)
+	}
+	s.gitopsDrift = drift
+	return result, nil
+}
+
+// applyGitOpsLive hands changed files to a running server. Changes under
+// mods/ and config/ are already picked up by the mod watcher.
+func (s *Server) applyGitOpsLive(result *gitops.Result) {
+	if s.stats.Status != StatusRunning {
+		return
+	}
+
+	var needRestart []string
+	for _, file := range append(result.Changed, result.Removed...) {
+		file, _, _ = strings.Cut(file, " (")
+		switch {
+		case file == "whitelist.json":
+			s.SendCommand("whitelist reload")
+		case strings.HasPrefix(file, "config/"), strings.HasPrefix(file, "defaultconfigs/"):
+		default:
+			needRestart = append(needRestart, file)
+		}
+	}
+	if len(needRestart) > 0 && s.GetStats().RestartRequired == "" {
+		s.setRestartRequired(plural(len(needRestart), "changed config"))
+		s.addEvent(EventWarning, "Restart required to apply "+strings.Join(needRestart, ", "))
+	}
+}
+
+// gitopsLoop syncs on the configured interval while one server process runs
+func (s *Server) gitopsLoop() {
+	proc := s.cmd
+	ticker := time.NewTicker(time.Duration(s.config.GitOpsInterval) * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if s.cmd != proc {
+				return
+			}
+			s.syncGitOps()
+		}
+	}
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "gitops",
+		Usage: "gitops sync|status|drift",
+		Help:  "Sync config from the GitOps repository, or show its state and local drift",
+		Admin: true,
+		Run: func(s *Server, args []string) (string, error) {
+			if s.gitops == nil {
+				return "", fmt.Errorf("no GitOps repository configured (--gitops-repo)")
+			}
+			sub := "status"
+			if len(args) > 0 {
+				sub = args[0]
+			}
+
+			switch sub {
+			case "sync":
+				result, err := s.syncGitOps()
+				if err != nil {
+					return "", err
+				}
+				return "GitOps: " + result.String(), nil
+
+			case "status":
+				commit := s.gitops.Commit()
+				if commit == "" {
+					commit = "none yet"
+				} else if len(commit) > 7 {
+					commit = commit[:7]
+				}
+				return fmt.Sprintf("GitOps: %s (%s), last applied commit %s", s.gitops.Source(), s.gitops.Branch, commit), nil
+
+			case "drift":
+				drifted, err := s.gitops.Drift()
+				if err != nil {
+					return "", err
+				}
+				if len(drifted) == 0 {
+					return "GitOps: no local drift", nil
+				}
+				return "GitOps drift: " + strings.Join(drifted, ", "), nil
+			}
+			return "", fmt.Errorf("usage: gitops sync|status|drift")
+		},
+	})
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	psnet "github.com/shirou/gopsutil/v3/net"
+)
+
+// suspendLoop freezes the JVM once the server has been empty for the
+// configured time and thaws it when a client connects to the game port.
+// A frozen process keeps its listening socket, so the kernel still accepts
+// the connection; the client just waits until the JVM resumes.
+func (s *Server) suspendLoop() {
+	proc := s.cmd
+	idleFor := time.Duration(s.config.SuspendWhenEmpty) * time.Minute
+	emptySince := time.Now()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if s.cmd != proc {
+			return
+		}
+
+		switch s.stats.Status {
+		case StatusRunning:
+			if s.GetStats().PlayerCount > 0 {
+				emptySince = time.Now()
+				continue
+			}
+			if time.Since(emptySince) >= idleFor {
+				if err := s.suspend(); err != nil {
+					s.addEvent(EventWarning, fmt.Sprintf("Could not suspend server: %v", err))
+					emptySince = time.Now()
+				}
+			}
+
+		case StatusSuspended:
+			if s.incomingConnection() {
+				s.wake()
+				// Give the player time to finish joining before the idle
+				// timer can fire again
+				emptySince = time.Now()
+			}
+		}
+	}
+}
+
+// suspend saves the world and stops the JVM from being scheduled
+func (s *Server) suspend() error {
+	if s.process == nil {
+		return fmt.Errorf("no server process")
+	}
+
+	s.SendCommand("save-all")
+	time.Sleep(3 * time.Second)
+
+	if err := s.process.Suspend(); err != nil {
+		return err
+	}
+	s.updateStatus(StatusSuspended)
+	s.addEvent(EventInfo, "Server empty, suspended until someone connects")
+	return nil
+}
+
+// wake resumes a suspended server; it does nothing otherwise, so callers
+// about to talk to the server can call it unconditionally
+func (s *Server) wake() {
+	if s.stats.Status != StatusSuspended || s.process == nil {
+		return
+	}
+	if err := s.process.Resume(); err != nil {
+		s.addEvent(EventError, fmt.Sprintf("Could not resume server: %v", err))
+		return
+	}
+	s.updateStatus(StatusRunning)
+	s.addEvent(EventInfo, "Connection detected, server resumed")
+}
+
+// incomingConnection reports whether any TCP connection to the game port
+// is established, which is how a join or server-list ping shows up while
+// the JVM is frozen
+func (s *Server) incomingConnection() bool {
+	conns, err := psnet.Connections("tcp")
+	if err != nil {
+		return false
+	}
+	for _, c := range conns {
+		if c.Laddr.Port == uint32(s.config.Port) && c.Status == "ESTABLISHED" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"mcserver-manager/internal/metrics"
+)
+
+// historyLoop records a metrics sample every MetricsHistory seconds while
+// one server process runs, and drops the days older than MetricsKeep
+func (s *Server) historyLoop() {
+	if s.config.MetricsHistory <= 0 {
+		return
+	}
+	proc := s.cmd
+	ticker := time.NewTicker(time.Duration(s.config.MetricsHistory) * time.Second)
+	defer ticker.Stop()
+
+	warned := false
+	pruned := ""
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if s.cmd != proc {
+			return
+		}
+
+		if today := time.Now().Format("2006-01-02"); today != pruned && s.config.MetricsKeep > 0 {
+			pruned = today
+			if err := metrics.Prune(s.config.ServerDir, s.config.MetricsKeep); err != nil {
+				s.addEvent(EventWarning, fmt.Sprintf("Failed to prune metrics history: %v", err))
+			}
+		}
+
+		st := s.GetStats()
+		if st.Status != StatusRunning {
+			continue
+		}
+		err := metrics.Append(s.config.ServerDir, metrics.Sample{
+			Time:       time.Now(),
+			TPS:        st.TPS,
+			MSPT:       st.MSPT,
+			MemoryUsed: st.MemoryUsed,
+			MemoryMax:  st.MemoryMax,
+			CPUPercent: st.CPUPercent,
+			Players:    st.PlayerCount,
+		})
+		// Report a failing disk once until it recovers
+		if err != nil && !warned {
+			s.addEvent(EventWarning, err.Error())
+		}
+		warned = err != nil
+	}
+}
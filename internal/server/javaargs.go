@@ -0,0 +1,201 @@
+package server
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/mem"
+
+	"mcserver-manager/internal/stats"
+)
+
+// gcSelectors are the flags that pick a garbage collector
+var gcSelectors = map[string]bool{
+	"UseG1GC":            true,
+	"UseZGC":             true,
+	"UseShenandoahGC":    true,
+	"UseParallelGC":      true,
+	"UseSerialGC":        true,
+	"UseConcMarkSweepGC": true,
+	"UseEpsilonGC":       true,
+	"UseParallelOldGC":   true,
+}
+
+// removedFlags are -XX options by the Java release that dropped them. The
+// JVM ignores a removed option for a few releases, then refuses to start.
+var removedFlags = map[string]int{
+	"CMSIncrementalMode":            9,
+	"UseCMSCompactAtFullCollection": 9,
+	"UseParNewGC":                   10,
+	"AggressiveOpts":                12,
+	"UseConcMarkSweepGC":            14,
+	"UseBiasedLocking":              18,
+}
+
+// jvmArgKey returns what an argument sets, so that two arguments with the
+// same key conflict: -Xmx, -XX:Name or -Dname; other arguments only
+// conflict with an exact duplicate
+func jvmArgKey(arg string) string {
+	for _, prefix := range []string{"-Xmx", "-Xms", "-Xss", "-Xmn"} {
+		if strings.HasPrefix(arg, prefix) {
+			return prefix
+		}
+	}
+	if name, ok := strings.CutPrefix(arg, "-XX:"); ok {
+		name = strings.TrimLeft(name, "+-")
+		name, _, _ = strings.Cut(name, "=")
+		return "-XX:" + name
+	}
+	if name, ok := strings.CutPrefix(arg, "-D"); ok {
+		name, _, _ = strings.Cut(name, "=")
+		return "-D" + name
+	}
+	return arg
+}
+
+// gcOf returns the collector an argument selects, or ""
+func gcOf(arg string) string {
+	if name, ok := strings.CutPrefix(arg, "-XX:+"); ok && gcSelectors[name] {
+		return name
+	}
+	return ""
+}
+
+// gcTuning reports whether a built-in argument only makes sense with the
+// built-in collector
+func gcTuning(key string) bool {
+	name, ok := strings.CutPrefix(key, "-XX:")
+	if !ok {
+		return key == "-Dusing.aikars.flags" || key == "-Daikars.new.flags"
+	}
+	switch name {
+	case "ParallelRefProcEnabled", "MaxGCPauseMillis", "InitiatingHeapOccupancyPercent",
+		"SurvivorRatio", "MaxTenuringThreshold", "UseParNewGC", "UseCMSInitiatingOccupancyOnly":
+		return true
+	}
+	return gcSelectors[name] || strings.HasPrefix(name, "G1") || strings.HasPrefix(name, "CMS")
+}
+
+// mergeJVMArgs puts the user's JVM arguments after the built-in ones.
+// Where they conflict the user's win: a built-in argument with the same
+// key is dropped, and choosing another collector drops the built-in
+// collector and its tuning. It returns what was replaced, for the log.
+func mergeJVMArgs(builtin, user []string) ([]string, []string) {
+	userKeys := map[string]string{}
+	userGC := ""
+	for _, arg := range user {
+		userKeys[jvmArgKey(arg)] = arg
+		if gc := gcOf(arg); gc != "" {
+			userGC = gc
+		}
+	}
+	builtinGC := ""
+	for _, arg := range builtin {
+		if gc := gcOf(arg); gc != "" {
+			builtinGC = gc
+		}
+	}
+	dropTuning := userGC != "" && userGC != builtinGC
+
+	var merged, replaced []string
+	for _, arg := range builtin {
+		key := jvmArgKey(arg)
+		if override, ok := userKeys[key]; ok {
+			if override != arg {
+				replaced = append(replaced, fmt.Sprintf("%s replaces %s", override, arg))
+			}
+			continue
+		}
+		if dropTuning && gcTuning(key) {
+			continue
+		}
+		merged = append(merged, arg)
+	}
+	if dropTuning && builtinGC != "" {
+		replaced = append(replaced, fmt.Sprintf("-XX:+%s replaces the built-in %s flags", userGC, builtinGC))
+	}
+
+	// Of the user's own duplicates, the last one wins, as with the JVM
+	seen := map[string]bool{}
+	var own []string
+	for i := len(user) - 1; i >= 0; i-- {
+		key := jvmArgKey(user[i])
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		own = append(own, user[i])
+	}
+	for i := len(own) - 1; i >= 0; i-- {
+		merged = append(merged, own[i])
+	}
+	return merged, replaced
+}
+
+// checkJVMArgs returns warnings about arguments that will not do what the
+// user meant, on the Java the server runs on (0 when unknown)
+func checkJVMArgs(user, merged []string, java int) []string {
+	var warnings []string
+	var gcs []string
+	for _, arg := range user {
+		if gc := gcOf(arg); gc != "" && !slices.Contains(gcs, gc) {
+			gcs = append(gcs, gc)
+		}
+		if name, ok := strings.CutPrefix(jvmArgKey(arg), "-XX:"); ok {
+			if removed := removedFlags[name]; removed > 0 && java >= removed {
+				warnings = append(warnings, fmt.Sprintf("%s was removed in Java %d, so Java %d ignores it or refuses to start", arg, removed, java))
+			}
+			if name == "PermSize" || name == "MaxPermSize" {
+				warnings = append(warnings, fmt.Sprintf("%s has no effect since Java 8; use -XX:MaxMetaspaceSize", arg))
+			}
+		}
+		for _, prefix := range []string{"-Xmx", "-Xms"} {
+			if value, ok := strings.CutPrefix(arg, prefix); ok && value != "" && strings.Trim(value, "0123456789") == "" {
+				warnings = append(warnings, fmt.Sprintf("%s is %s bytes; add a unit, e.g. %s%sM", arg, value, prefix, value))
+			} else if ok && strings.ContainsAny(value, ".%") {
+				warnings = append(warnings, fmt.Sprintf("%s is not a size the JVM reads; use whole megabytes, or --ram-min/--ram-max, which take decimals and percentages", arg))
+			}
+		}
+		if arg == "-jar" || arg == "nogui" {
+			warnings = append(warnings, fmt.Sprintf("%q belongs to the server's own arguments, not --java-args", arg))
+		}
+	}
+	if len(gcs) > 1 {
+		warnings = append(warnings, fmt.Sprintf("more than one garbage collector selected (%s); the JVM refuses to start", strings.Join(gcs, ", ")))
+	}
+
+	var xms, xmx uint64
+	for _, arg := range merged {
+		if value, ok := strings.CutPrefix(arg, "-Xms"); ok {
+			xms = parseMemoryString(value)
+		}
+		if value, ok := strings.CutPrefix(arg, "-Xmx"); ok {
+			xmx = parseMemoryString(value)
+		}
+	}
+	if xms > 0 && xmx > 0 && xms > xmx {
+		warnings = append(warnings, "the initial heap (-Xms) is larger than the maximum (-Xmx); the JVM refuses to start")
+	}
+	if vm, err := mem.VirtualMemory(); err == nil && xmx > vm.Total {
+		warnings = append(warnings, fmt.Sprintf("-Xmx is more than this machine's %s of memory", stats.FormatBytes(vm.Total)))
+	}
+	return warnings
+}
+
+// withUserArgs merges --java-args into the built-in JVM arguments and logs
+// the replacements and warnings
+func (s *Server) withUserArgs(builtin []string) []string {
+	user := strings.Fields(s.config.JavaArgs)
+	if len(user) == 0 {
+		return builtin
+	}
+	merged, replaced := mergeJVMArgs(builtin, user)
+	if len(replaced) > 0 {
+		s.addEvent(EventInfo, "Java args: "+strings.Join(replaced, ", "))
+	}
+	for _, warning := range checkJVMArgs(user, merged, s.GetStats().JavaVersion) {
+		s.addEvent(EventWarning, "Java args: "+warning)
+	}
+	return merged
+}
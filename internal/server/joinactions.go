@@ -0,0 +1,236 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"mcserver-manager/internal/playerdb"
+)
+
+// joinActionsFile holds what happens when players join and leave,
+// relative to the server dir
+const joinActionsFile = ".mcserver/join-actions.json"
+
+// Wait after a join before acting, so the welcome shows below the join
+// message once the client has loaded in
+const joinActionDelay = 2 * time.Second
+
+// joinActions are run as players come and go. Welcomes are tellraw text
+// components (a JSON string is plain text); they and the commands may use
+// {player}, {joins} and {first_seen} besides the announcement placeholders.
+type joinActions struct {
+	Welcome      json.RawMessage `json:"welcome"`
+	FirstWelcome json.RawMessage `json:"firstWelcome"` // instead of welcome for first-time players
+
+	// Console commands on every join, on a first join only, and on leave,
+	// e.g. "team join members {player}"
+	Commands      []string `json:"commands"`
+	FirstCommands []string `json:"firstCommands"`
+	LeaveCommands []string `json:"leaveCommands"`
+
+	// Extra join commands for particular players, by name
+	Players map[string][]string `json:"players"`
+
+	// Players whose comings and goings raise an event of their own
+	Watch []watchedPlayer `json:"watch"`
+}
+
+// watchedPlayer routes a player's joins and leaves to an event type, such
+// as critical for someone to keep an eye on
+type watchedPlayer struct {
+	Player string `json:"player"`
+	Event  string `json:"event"` // event type name, custom if empty
+	Note   string `json:"note"`
+
+	eventType EventType
+}
+
+// loadJoinActions reads joinActionsFile. A missing file means no join
+// actions.
+func (s *Server) loadJoinActions() {
+	s.joinActions = nil
+	data, err := os.ReadFile(filepath.Join(s.config.ServerDir, joinActionsFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			s.addEvent(EventWarning, fmt.Sprintf("Failed to read join actions: %v", err))
+		}
+		return
+	}
+
+	var cfg joinActions
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Failed to parse %s: %v", joinActionsFile, err))
+		return
+	}
+	// Console commands are one line
+	for _, component := range []*json.RawMessage{&cfg.Welcome, &cfg.FirstWelcome} {
+		if len(*component) > 0 {
+			var compact bytes.Buffer
+			json.Compact(&compact, *component)
+			*component = compact.Bytes()
+		}
+	}
+	for i := range cfg.Watch {
+		w := &cfg.Watch[i]
+		w.eventType = EventCustom
+		if w.Event != "" {
+			t, err := ParseEventType(w.Event)
+			if err != nil {
+				s.addEvent(EventWarning, fmt.Sprintf("%s watch %s: %v", joinActionsFile, w.Player, err))
+				return
+			}
+			w.eventType = t
+		}
+	}
+	s.joinActions = &cfg
+}
+
+// playerJoined records a join in the player database and runs the join
+// actions
+func (s *Server) playerJoined(name string) {
+	record := playerdb.Player{Name: name}
+	first := false
+	if s.players != nil {
+		var err error
+		if record, first, err = s.players.Join(name, time.Now()); err != nil {
+			s.addEvent(EventWarning, err.Error())
+		}
+		s.updatePlayerRecord(record)
+	}
+	if first {
+		s.addEvent(EventInfo, fmt.Sprintf("%s joined for the first time", name))
+	}
+
+	cfg := s.joinActions
+	// Joins replayed from an existing log happened long ago
+	if cfg == nil || s.catchingUp.Load() {
+		return
+	}
+	s.watchedPlayer(cfg, name, "joined")
+
+	go func() {
+		time.Sleep(joinActionDelay)
+		welcome := cfg.Welcome
+		commands := cfg.Commands
+		if first {
+			if len(cfg.FirstWelcome) > 0 {
+				welcome = cfg.FirstWelcome
+			}
+			commands = append(append([]string(nil), cfg.FirstCommands...), commands...)
+		}
+		for player, extra := range cfg.Players {
+			if strings.EqualFold(player, name) {
+				commands = append(commands, extra...)
+			}
+		}
+
+		if len(welcome) > 0 {
+			s.SendCommand("tellraw " + name + " " + s.expandJoinPlaceholders(string(welcome), record, true))
+		}
+		for _, command := range commands {
+			if err := s.SendCommand(s.expandJoinPlaceholders(command, record, false)); err != nil {
+				s.addEvent(EventWarning, fmt.Sprintf("Join action for %s failed: %v", name, err))
+				return
+			}
+		}
+	}()
+}
+
+// playerLeft records a leave in the player database and runs the leave
+// commands
+func (s *Server) playerLeft(name string) {
+	if s.players != nil {
+		if err := s.players.Leave(name, time.Now()); err != nil {
+			s.addEvent(EventWarning, err.Error())
+		}
+	}
+
+	cfg := s.joinActions
+	if cfg == nil || s.catchingUp.Load() {
+		return
+	}
+	s.watchedPlayer(cfg, name, "left")
+
+	record := playerdb.Player{Name: name}
+	if s.players != nil {
+		if known, ok := s.players.Get(name); ok {
+			record = known
+		}
+	}
+	for _, command := range cfg.LeaveCommands {
+		if err := s.SendCommand(s.expandJoinPlaceholders(command, record, false)); err != nil {
+			s.addEvent(EventWarning, fmt.Sprintf("Leave action for %s failed: %v", name, err))
+			return
+		}
+	}
+}
+
+// updatePlayerRecord fills in what the player database knows about an online
+// player
+func (s *Server) updatePlayerRecord(record playerdb.Player) {
+	s.statsMutex.Lock()
+	defer s.statsMutex.Unlock()
+
+	for i, p := range s.stats.Players {
+		if p.Name != record.Name {
+			continue
+		}
+		s.stats.Players[i].Playtime = record.Playtime
+		if n := len(record.Sessions); n > 0 && s.stats.Players[i].IPAddress == "" {
+			s.stats.Players[i].IPAddress = record.Sessions[n-1].IP
+		}
+		return
+	}
+}
+
+// closeSessions ends the database sessions of players still online when the
+// server process goes away
+func (s *Server) closeSessions() {
+	if s.players == nil {
+		return
+	}
+	if err := s.players.CloseSessions(time.Now()); err != nil {
+		s.addEvent(EventWarning, err.Error())
+	}
+}
+
+// watchedPlayer raises the watch event for name, if it is watched
+func (s *Server) watchedPlayer(cfg *joinActions, name, what string) {
+	for _, w := range cfg.Watch {
+		if !strings.EqualFold(w.Player, name) {
+			continue
+		}
+		message := fmt.Sprintf("Watched player %s %s", name, what)
+		if w.Note != "" {
+			message += " (" + w.Note + ")"
+		}
+		s.addEvent(w.eventType, message)
+	}
+}
+
+// expandJoinPlaceholders fills in the player's details and then the live
+// stats
+func (s *Server) expandJoinPlaceholders(text string, p playerdb.Player, inJSON bool) string {
+	firstSeen := "before records began"
+	if !p.FirstSeen.IsZero() {
+		firstSeen = p.FirstSeen.Format("2006-01-02")
+	}
+	values := []string{
+		"{player}", p.Name,
+		"{joins}", strconv.Itoa(p.Joins),
+		"{first_seen}", firstSeen,
+	}
+	if inJSON {
+		for i := 1; i < len(values); i += 2 {
+			quoted, _ := json.Marshal(values[i])
+			values[i] = string(quoted[1 : len(quoted)-1])
+		}
+	}
+	return s.expandPlaceholders(strings.NewReplacer(values...).Replace(text), inJSON)
+}
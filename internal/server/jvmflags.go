@@ -0,0 +1,59 @@
+package server
+
+import (
+	"fmt"
+
+	"mcserver-manager/internal/servertype"
+)
+
+// aikarFlags are Aikar's G1 flags (https://mcflags.emc.gs), the default
+var aikarFlags = []string{
+	"-XX:+UseG1GC",
+	"-XX:+ParallelRefProcEnabled",
+	"-XX:MaxGCPauseMillis=200",
+	"-XX:+UnlockExperimentalVMOptions",
+	"-XX:+DisableExplicitGC",
+	"-XX:+AlwaysPreTouch",
+	"-XX:G1NewSizePercent=30",
+	"-XX:G1MaxNewSizePercent=40",
+	"-XX:G1HeapRegionSize=8M",
+	"-XX:G1ReservePercent=20",
+	"-XX:G1HeapWastePercent=5",
+	"-XX:G1MixedGCCountTarget=4",
+	"-XX:InitiatingHeapOccupancyPercent=15",
+	"-XX:G1MixedGCLiveThresholdPercent=90",
+	"-XX:G1RSetUpdatingPauseTimePercent=5",
+	"-XX:SurvivorRatio=32",
+	"-XX:+PerfDisableSharedMem",
+	"-XX:MaxTenuringThreshold=1",
+	"-Dusing.aikars.flags=https://mcflags.emc.gs",
+	"-Daikars.new.flags=true",
+}
+
+// legacyFlags are the CMS flags 1.7 to 1.12 modpacks were tuned for, back
+// when G1 was immature. CMS was removed in Java 14, so they are only used
+// on Java 8 and older.
+var legacyFlags = []string{
+	"-XX:+UseConcMarkSweepGC",
+	"-XX:+UseParNewGC",
+	"-XX:+CMSParallelRemarkEnabled",
+	"-XX:+CMSClassUnloadingEnabled",
+	"-XX:+UseCMSInitiatingOccupancyOnly",
+	"-XX:CMSInitiatingOccupancyFraction=70",
+	"-XX:+DisableExplicitGC",
+	"-XX:+AlwaysPreTouch",
+}
+
+// gcFlags returns the garbage collector flags for the detected Minecraft
+// version and the Java picked to run it
+func (s *Server) gcFlags() []string {
+	stats := s.GetStats()
+	if stats.Software == nil || stats.Software.MCVersion == "" || stats.JavaVersion == 0 {
+		return aikarFlags
+	}
+	if stats.JavaVersion <= 8 && servertype.Compare(stats.Software.MCVersion, "1.13") < 0 {
+		s.addEvent(EventInfo, fmt.Sprintf("Using legacy CMS flags for Minecraft %s on Java %d", stats.Software.MCVersion, stats.JavaVersion))
+		return legacyFlags
+	}
+	return aikarFlags
+}
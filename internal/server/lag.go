@@ -0,0 +1,291 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mcserver-manager/internal/stats"
+)
+
+// lagFile is the log of lag spikes, relative to the server dir
+const lagFile = ".mcserver/lag-spikes.jsonl"
+
+// Console lines kept from before a spike, and at most how many from during
+// one
+const (
+	lagContextLines = 20
+	lagSpikeLines   = 50
+)
+
+// A gap this long between TPS samples ends an open spike; the server was
+// stopped or stopped answering
+const lagSampleGap = time.Minute
+
+// Wait after a TPS line before sampling, so a reply spread over several
+// lines (Forge per dimension, Paper's tps and mspt) counts once, as its
+// last value
+const tpsSettle = time.Second
+
+// TPSPercentiles summarizes the TPS samples of a time window: P95 is the
+// TPS that 95% of the samples were at or above, P99 likewise
+type TPSPercentiles struct {
+	P95     float64
+	P99     float64
+	Samples int
+}
+
+// LagSpike is a stretch of TPS samples below LagThreshold
+type LagSpike struct {
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	MinTPS   float64       `json:"min_tps"`
+	MaxMSPT  float64       `json:"max_mspt,omitempty"`
+	Players  int           `json:"players"`
+	// Console lines from shortly before and during the spike
+	Lines []string `json:"lines,omitempty"`
+
+	lastLow time.Time
+}
+
+type tpsSample struct {
+	at  time.Time
+	tps float64
+}
+
+// lagTracker keeps the last hour of TPS samples, the console lines leading
+// up to now and the spike in progress
+type lagTracker struct {
+	mu       sync.Mutex
+	samples  []tpsSample
+	recent   []string
+	spike    *LagSpike
+	settling bool
+}
+
+// observeLine keeps a console line as context for lag spikes
+func (s *Server) observeLine(line string) {
+	t := &s.lag
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.spike != nil {
+		if len(t.spike.Lines) < lagContextLines+lagSpikeLines {
+			t.spike.Lines = append(t.spike.Lines, line)
+		}
+		return
+	}
+	t.recent = append(t.recent, line)
+	if len(t.recent) > lagContextLines {
+		t.recent = t.recent[len(t.recent)-lagContextLines:]
+	}
+}
+
+// sampleTPS takes a TPS sample once the reply the server is printing is
+// complete
+func (s *Server) sampleTPS() {
+	// Readings replayed from an existing log are not from now
+	if s.catchingUp.Load() {
+		return
+	}
+	t := &s.lag
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.settling {
+		t.settling = true
+		time.AfterFunc(tpsSettle, s.recordTPS)
+	}
+}
+
+// recordTPS records the current TPS, updates the percentiles and opens or
+// closes lag spikes
+func (s *Server) recordTPS() {
+	s.statsMutex.Lock()
+	defer s.statsMutex.Unlock()
+	t := &s.lag
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.settling = false
+
+	now, tps := time.Now(), s.stats.TPS
+
+	t.samples = append(recentSamples(t.samples, now.Add(-time.Hour)), tpsSample{now, tps})
+	s.stats.TPS5m = tpsPercentiles(recentSamples(t.samples, now.Add(-5*time.Minute)))
+	s.stats.TPS1h = tpsPercentiles(t.samples)
+
+	threshold := s.config.LagThreshold
+	if spike := t.spike; spike != nil {
+		switch {
+		case now.Sub(spike.lastLow) > lagSampleGap:
+			spike.Duration = spike.lastLow.Sub(spike.Start)
+		case tps >= threshold:
+			spike.Duration = now.Sub(spike.Start)
+		default:
+			spike.lastLow = now
+			spike.MinTPS = min(spike.MinTPS, tps)
+			spike.MaxMSPT = max(spike.MaxMSPT, s.stats.MSPT)
+			spike.Players = max(spike.Players, s.stats.PlayerCount)
+			return
+		}
+		t.spike = nil
+		// addEvent takes statsMutex
+		go s.finishLagSpike(spike)
+	}
+	if threshold > 0 && tps < threshold {
+		t.spike = &LagSpike{
+			Start:   now,
+			MinTPS:  tps,
+			MaxMSPT: s.stats.MSPT,
+			Players: s.stats.PlayerCount,
+			Lines:   append([]string(nil), t.recent...),
+			lastLow: now,
+		}
+		t.recent = t.recent[:0]
+	}
+}
+
+// finishLagSpike logs a spike that has ended
+func (s *Server) finishLagSpike(spike *LagSpike) {
+	s.addEvent(EventWarning, fmt.Sprintf("Lag spike: TPS down to %.1f for %s", spike.MinTPS, spike.Duration.Round(time.Second)))
+
+	path := filepath.Join(s.config.ServerDir, lagFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Failed to log lag spike: %v", err))
+		return
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Failed to log lag spike: %v", err))
+		return
+	}
+	defer f.Close()
+	if err := json.NewEncoder(f).Encode(spike); err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Failed to log lag spike: %v", err))
+	}
+}
+
+// LagSpikes returns the logged lag spikes that started after since, oldest
+// first, at most limit of the newest (0 for all)
+func LagSpikes(serverDir string, since time.Time, limit int) ([]LagSpike, error) {
+	f, err := os.Open(filepath.Join(serverDir, lagFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var spikes []LagSpike
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1024*1024)
+	for sc.Scan() {
+		var spike LagSpike
+		if json.Unmarshal(sc.Bytes(), &spike) == nil && spike.Start.After(since) {
+			spikes = append(spikes, spike)
+		}
+	}
+	if limit > 0 && len(spikes) > limit {
+		spikes = spikes[len(spikes)-limit:]
+	}
+	return spikes, sc.Err()
+}
+
+// String summarizes a spike on one line
+func (l *LagSpike) String() string {
+	text := fmt.Sprintf("%s  %s, TPS down to %.1f", l.Start.Format("2006-01-02 15:04:05"), l.Duration.Round(time.Second), l.MinTPS)
+	if l.MaxMSPT > 0 {
+		text += fmt.Sprintf(", MSPT up to %.1f", l.MaxMSPT)
+	}
+	return text + ", " + plural(l.Players, "player") + " online"
+}
+
+// recentSamples drops the samples before since from the front of samples
+func recentSamples(samples []tpsSample, since time.Time) []tpsSample {
+	i := 0
+	for i < len(samples) && samples[i].at.Before(since) {
+		i++
+	}
+	return samples[i:]
+}
+
+// tpsPercentiles takes the low percentiles of the samples by nearest rank
+func tpsPercentiles(samples []tpsSample) TPSPercentiles {
+	if len(samples) == 0 {
+		return TPSPercentiles{}
+	}
+	values := make([]float64, len(samples))
+	for i, sample := range samples {
+		values[i] = sample.tps
+	}
+	sort.Float64s(values)
+	at := func(p float64) float64 {
+		// The value p percent of the samples are at or above
+		rank := int(float64(len(values))*(100-p)/100 + 0.999999)
+		return values[max(rank, 1)-1]
+	}
+	return TPSPercentiles{P95: at(95), P99: at(99), Samples: len(values)}
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "tps",
+		Usage: "tps [history]",
+		Help:  "Show the current TPS and MSPT, or with history the percentiles and lag spikes of the last day",
+		Run: func(s *Server, args []string) (string, error) {
+			if len(args) == 1 && args[0] == "history" {
+				return actions["lag"].Run(s, nil)
+			}
+			if len(args) > 0 {
+				return "", fmt.Errorf("usage: tps [history]")
+			}
+			st := s.GetStats()
+			line := "TPS " + stats.FormatTPS(st.TPS)
+			if st.MSPT > 0 {
+				line += fmt.Sprintf(", MSPT %.1f", st.MSPT)
+			}
+			if st.TPS5m.Samples > 0 {
+				line += fmt.Sprintf(" (p95/p99 over 5m: %.1f/%.1f)", st.TPS5m.P95, st.TPS5m.P99)
+			}
+			return line, nil
+		},
+	})
+	registerAction(&Action{
+		Name:  "lag",
+		Usage: "lag [since] [count]",
+		Help:  "Show TPS percentiles and the lag spikes of the last day, or since e.g. 7d",
+		Run: func(s *Server, args []string) (string, error) {
+			since, limit := 24*time.Hour, 10
+			for _, arg := range args {
+				if n, err := strconv.Atoi(arg); err == nil {
+					limit = n
+				} else if d, err := stats.ParseDuration(arg); err == nil {
+					since = d
+				} else {
+					return "", fmt.Errorf("usage: lag [since] [count]")
+				}
+			}
+
+			st := s.GetStats()
+			lines := []string{fmt.Sprintf("TPS p95/p99: 5m %.1f/%.1f, 1h %.1f/%.1f (%d samples)",
+				st.TPS5m.P95, st.TPS5m.P99, st.TPS1h.P95, st.TPS1h.P99, st.TPS1h.Samples)}
+			from := time.Now().Add(-since)
+			spikes, err := LagSpikes(s.config.ServerDir, from, limit)
+			if err != nil {
+				return "", err
+			}
+			if len(spikes) == 0 {
+				lines = append(lines, "No lag spikes since "+from.Format("2006-01-02 15:04"))
+			}
+			for i := range spikes {
+				lines = append(lines, spikes[i].String())
+			}
+			return strings.Join(lines, "\n"), nil
+		},
+	})
+}
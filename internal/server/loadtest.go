@@ -0,0 +1,243 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"mcserver-manager/internal/loadbot"
+	"mcserver-manager/internal/props"
+	"mcserver-manager/internal/proxy"
+	"mcserver-manager/internal/slp"
+)
+
+// Bots join this far apart, so the test measures playing, not a login storm
+const loadTestJoinGap = 200 * time.Millisecond
+
+// LoadTestOptions configures a load test
+type LoadTestOptions struct {
+	// Bots to end with, added Step at a time; each step, and the empty
+	// server before the first, is measured for StepDuration
+	Bots         int
+	Step         int
+	StepDuration time.Duration
+
+	// Bots are called NamePrefix followed by a number
+	NamePrefix string
+	Behavior   loadbot.Behavior
+
+	// Give up on a start that takes longer
+	Timeout time.Duration
+
+	// Progress is told what the test is doing; may be nil
+	Progress func(string)
+}
+
+// LoadStep is how the server kept up with a number of bots
+type LoadStep struct {
+	// Bots in the world at the end of the step
+	Bots       int
+	AvgTPS     float64
+	MinTPS     float64
+	AvgMSPT    float64
+	MaxMSPT    float64
+	PeakMemory uint64
+	// Bots that failed to join or were dropped during the step
+	Failed int
+}
+
+// LoadTestResult is a whole load test
+type LoadTestResult struct {
+	Version string
+	Steps   []LoadStep
+	// Why bots failed to join or were dropped, with how many each
+	Failures map[string]int
+}
+
+// loadTest tracks the bots of a running load test
+type loadTest struct {
+	mu       sync.Mutex
+	failures map[string]int
+	failed   int
+	// Failures already counted in a step
+	reported int
+	joined   int
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+func (t *loadTest) fail(err error) {
+	t.mu.Lock()
+	t.failures[err.Error()]++
+	t.failed++
+	t.mu.Unlock()
+}
+
+// LoadTest starts the server, has bots join it in steps and measures TPS,
+// MSPT and memory at each step, then stops it again. Bots log in without
+// authentication, so the server must be in offline mode and reachable
+// directly, not only through a proxy.
+func (s *Server) LoadTest(opts LoadTestOptions) (*LoadTestResult, error) {
+	if s.stats.Status != StatusStopped && s.stats.Status != StatusCrashed {
+		return nil, fmt.Errorf("server is already running")
+	}
+	if opts.Bots <= 0 {
+		return nil, fmt.Errorf("no bots to test with")
+	}
+	if opts.Step <= 0 || opts.Step > opts.Bots {
+		opts.Step = opts.Bots
+	}
+	progress := opts.Progress
+	if progress == nil {
+		progress = func(string) {}
+	}
+	if err := s.checkLoadTest(opts.Bots); err != nil {
+		return nil, err
+	}
+
+	progress("Starting server...")
+	if err := s.Start(); err != nil {
+		return nil, err
+	}
+	defer s.Stop()
+	if err := s.waitRunning(opts.Timeout); err != nil {
+		return nil, err
+	}
+
+	address := net.JoinHostPort("127.0.0.1", strconv.Itoa(s.config.Port))
+	status, err := slp.Ping(address, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach the server at %s: %w", address, err)
+	}
+	protocol := int32(status.Version.Protocol)
+	if !loadbot.Supported(protocol) {
+		return nil, fmt.Errorf("%s (protocol %d) is not supported, bots speak %s", status.Version.Name, protocol, loadbot.SupportedVersions)
+	}
+	result := &LoadTestResult{Version: status.Version.Name}
+
+	test := &loadTest{failures: map[string]int{}, stop: make(chan struct{})}
+	defer func() {
+		close(test.stop)
+		test.wg.Wait()
+	}()
+
+	progress(fmt.Sprintf("Measuring the empty server for %s...", opts.StepDuration))
+	step, err := s.measureLoadStep(test, opts.StepDuration)
+	if err != nil {
+		return nil, err
+	}
+	result.Steps = append(result.Steps, *step)
+
+	for started := 0; started < opts.Bots; {
+		count := min(opts.Step, opts.Bots-started)
+		progress(fmt.Sprintf("Adding %s...", plural(count, "bot")))
+		for i := 0; i < count; i++ {
+			started++
+			s.startBot(test, address, fmt.Sprintf("%s%03d", opts.NamePrefix, started), protocol, opts.Behavior)
+			time.Sleep(loadTestJoinGap)
+		}
+		if step, err = s.measureLoadStep(test, opts.StepDuration); err != nil {
+			return nil, err
+		}
+		result.Steps = append(result.Steps, *step)
+		progress(fmt.Sprintf("%d in the world: %.1f avg / %.1f min TPS, %.1f ms avg MSPT", step.Bots, step.AvgTPS, step.MinTPS, step.AvgMSPT))
+	}
+
+	test.mu.Lock()
+	result.Failures = make(map[string]int, len(test.failures))
+	for reason, count := range test.failures {
+		result.Failures[reason] = count
+	}
+	test.mu.Unlock()
+	return result, nil
+}
+
+// checkLoadTest refuses a setup bots cannot join
+func (s *Server) checkLoadTest(bots int) error {
+	properties, err := props.Load(filepath.Join(s.config.ServerDir, "server.properties"))
+	if err != nil {
+		return err
+	}
+	switch {
+	case properties.GetDefault("online-mode", "true") == "true" && s.config.VelocityDir == "":
+		return fmt.Errorf("bots have no Mojang account; set online-mode=false in server.properties for the test")
+	case s.config.VelocityDir != "" || proxy.DetectForwarding(s.config.ServerDir) != proxy.ForwardingNone:
+		return fmt.Errorf("the server only accepts players through its proxy; turn proxy forwarding off for the test")
+	case properties.GetDefault("white-list", "false") == "true" || s.whitelistSource != nil:
+		return fmt.Errorf("the whitelist would keep the bots out; turn it off for the test")
+	}
+	if maxPlayers, err := strconv.Atoi(properties.GetDefault("max-players", "20")); err == nil && bots > maxPlayers {
+		return fmt.Errorf("max-players is %d; raise it in server.properties to test with %d bots", maxPlayers, bots)
+	}
+	return nil
+}
+
+// waitRunning waits for a start to finish
+func (s *Server) waitRunning(timeout time.Duration) error {
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-deadline:
+			return fmt.Errorf("server did not finish starting within %s", timeout)
+		case <-ticker.C:
+		}
+		switch s.GetStats().Status {
+		case StatusRunning:
+			return nil
+		case StatusCrashed, StatusStopped:
+			return fmt.Errorf("server exited during startup")
+		}
+	}
+}
+
+// startBot joins a bot and keeps it playing in the background until the
+// test ends
+func (s *Server) startBot(test *loadTest, address, name string, protocol int32, behavior loadbot.Behavior) {
+	test.wg.Add(1)
+	go func() {
+		defer test.wg.Done()
+		bot, err := loadbot.Join(address, name, protocol, 30*time.Second)
+		if err != nil {
+			test.fail(err)
+			return
+		}
+		test.mu.Lock()
+		test.joined++
+		test.mu.Unlock()
+
+		err = bot.Run(behavior, test.stop)
+		test.mu.Lock()
+		test.joined--
+		test.mu.Unlock()
+		if err != nil {
+			test.fail(err)
+		}
+	}()
+}
+
+// measureLoadStep measures the server with the bots it has now
+func (s *Server) measureLoadStep(test *loadTest, duration time.Duration) (*LoadStep, error) {
+	ticks, err := s.measureTicks(duration)
+	if err != nil {
+		return nil, fmt.Errorf("%w during the load test", err)
+	}
+
+	test.mu.Lock()
+	defer test.mu.Unlock()
+	failed := test.failed - test.reported
+	test.reported = test.failed
+	return &LoadStep{
+		Bots:       test.joined,
+		AvgTPS:     ticks.AvgTPS,
+		MinTPS:     ticks.MinTPS,
+		AvgMSPT:    ticks.AvgMSPT,
+		MaxMSPT:    ticks.MaxMSPT,
+		PeakMemory: ticks.PeakMemory,
+		Failed:     failed,
+	}, nil
+}
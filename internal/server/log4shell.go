@@ -0,0 +1,143 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"mcserver-manager/internal/advisory"
+	"mcserver-manager/internal/servertype"
+)
+
+// log4jCoreDir is where installers put log4j-core, one subdir per version
+const log4jCoreDir = "libraries/org/apache/logging/log4j/log4j-core"
+
+// log4j 2.16.0 removed message lookups (CVE-2021-44228, CVE-2021-45046)
+const log4jFixed = "2.16.0"
+
+// Mojang's log4j configs for the releases before 1.17, whose log4j is too
+// old for formatMsgNoLookups: the vanilla layout with %msg{nolookups}
+const log4jConfig = `<?xml version="1.0" encoding="UTF-8"?>
+<Configuration status="WARN" packages="%s">
+    <Appenders>
+        <Console name="SysOut" target="SYSTEM_OUT">
+            <PatternLayout pattern="[%%d{HH:mm:ss}] [%%t/%%level]: %%msg{nolookups}%%n" />
+        </Console>
+        <Queue name="ServerGuiConsole">
+            <PatternLayout pattern="[%%d{HH:mm:ss} %%level]: %%msg{nolookups}%%n" />
+        </Queue>
+        <RollingRandomAccessFile name="File" fileName="logs/latest.log" filePattern="logs/%%d{yyyy-MM-dd}-%%i.log.gz">
+            <PatternLayout pattern="[%%d{HH:mm:ss}] [%%t/%%level]: %%msg{nolookups}%%n" />
+            <Policies>
+                <TimeBasedTriggeringPolicy />
+                <OnStartupTriggeringPolicy />
+            </Policies>
+        </RollingRandomAccessFile>
+    </Appenders>
+    <Loggers>
+        <Root level="info">
+            <filters>
+                <MarkerFilter marker="NETWORK_PACKETS" onMatch="DENY" onMismatch="NEUTRAL" />
+            </filters>
+            <AppenderRef ref="SysOut"/>
+            <AppenderRef ref="File"/>
+            <AppenderRef ref="ServerGuiConsole"/>
+        </Root>
+    </Loggers>
+</Configuration>
+`
+
+// log4jArgs returns the JVM flags that close Log4Shell on the detected
+// Minecraft version, following Mojang's advice: formatMsgNoLookups on 1.17
+// to 1.18.0, a patched log4j config on vanilla 1.7 to 1.16.5. Modded and
+// Paper-style servers before 1.17 ship their own log4j config and are
+// fixed by updating the loader or build, which the advisories check.
+func (s *Server) log4jArgs() []string {
+	info := s.GetStats().Software
+	if info == nil || info.MCVersion == "" || strings.Contains(s.config.JavaArgs, "log4j") {
+		return nil
+	}
+	if v := log4jVersion(s.config.ServerDir); v != "" && servertype.Compare(v, log4jFixed) >= 0 {
+		return nil
+	}
+
+	mc := info.MCVersion
+	switch {
+	case servertype.Compare(mc, "1.18.1") >= 0:
+		return nil
+	case servertype.Compare(mc, "1.17") >= 0:
+		s.addEvent(EventInfo, fmt.Sprintf("Log4Shell: adding -Dlog4j2.formatMsgNoLookups=true for Minecraft %s", mc))
+		return []string{"-Dlog4j2.formatMsgNoLookups=true"}
+	case servertype.Compare(mc, "1.7") >= 0 && info.Type == servertype.Vanilla:
+		name, packages := "log4j2_112-116.xml", "com.mojang.util"
+		if servertype.Compare(mc, "1.12") < 0 {
+			name, packages = "log4j2_17-111.xml", "net.minecraft,com.mojang"
+		}
+		path := filepath.Join(s.config.ServerDir, ".mcserver", name)
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err == nil {
+			err = os.WriteFile(path, []byte(fmt.Sprintf(log4jConfig, packages)), 0644)
+		}
+		if err != nil {
+			s.addEvent(EventError, fmt.Sprintf("Log4Shell: could not write %s, the server is vulnerable: %v", name, err))
+			return nil
+		}
+		s.addEvent(EventInfo, fmt.Sprintf("Log4Shell: using the patched log4j config %s for Minecraft %s", name, mc))
+		return []string{"-Dlog4j.configurationFile=" + path}
+	}
+	return nil
+}
+
+// log4jVersion returns the newest log4j-core in the libraries, or ""
+func log4jVersion(serverDir string) string {
+	entries, err := os.ReadDir(filepath.Join(serverDir, log4jCoreDir))
+	if err != nil {
+		return ""
+	}
+	newest := ""
+	for _, e := range entries {
+		if e.IsDir() && (newest == "" || servertype.Compare(e.Name(), newest) > 0) {
+			newest = e.Name()
+		}
+	}
+	return newest
+}
+
+// checkAdvisories warns about the known vulnerabilities of the server
+// software and mods
+func (s *Server) checkAdvisories() {
+	findings, err := advisory.Check(s.config.ServerDir, s.GetStats().Software)
+	if err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Advisories: %v", err))
+	}
+	for _, f := range findings {
+		s.addEvent(EventCritical, "Vulnerable: "+f.String())
+	}
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "advisories",
+		Usage: "advisories",
+		Help:  "Check the server software and mods against the known-vulnerability database",
+		Run: func(s *Server, args []string) (string, error) {
+			info := s.GetStats().Software
+			if info == nil {
+				info = servertype.Detect(s.config.ServerDir)
+			}
+			findings, err := advisory.Check(s.config.ServerDir, info)
+			if err != nil {
+				return "", err
+			}
+			if len(findings) == 0 {
+				return fmt.Sprintf("No known vulnerabilities in %s and its mods", info), nil
+			}
+			lines := make([]string, len(findings))
+			for i, f := range findings {
+				lines[i] = f.String()
+			}
+			return strings.Join(lines, "\n"), nil
+		},
+	})
+}
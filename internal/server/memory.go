@@ -0,0 +1,91 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/mem"
+
+	"mcserver-manager/internal/stats"
+)
+
+// memoryUnits are the suffixes a memory size may have, as the JVM reads
+// them: powers of 1024
+var memoryUnits = map[string]uint64{
+	"":  1,
+	"B": 1,
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+	"T": 1 << 40,
+}
+
+// ParseMemory reads a memory size in bytes: "4G", "512M", a decimal such
+// as "1.5G", or a percentage of this machine's RAM such as "75%". A
+// trailing B is allowed ("4GB").
+func ParseMemory(value string) (uint64, error) {
+	text := strings.ToUpper(strings.TrimSpace(value))
+	if text == "" {
+		return 0, fmt.Errorf("memory size is empty")
+	}
+
+	if percent, ok := strings.CutSuffix(text, "%"); ok {
+		p, err := strconv.ParseFloat(strings.TrimSpace(percent), 64)
+		if err != nil || p <= 0 || p > 100 {
+			return 0, fmt.Errorf("invalid memory size %q: a percentage must be above 0 and at most 100", value)
+		}
+		vm, err := mem.VirtualMemory()
+		if err != nil {
+			return 0, fmt.Errorf("cannot use %q, the system memory is unknown: %w", value, err)
+		}
+		return uint64(float64(vm.Total) * p / 100), nil
+	}
+
+	number := strings.TrimSuffix(text, "B")
+	unit := ""
+	if n := len(number); n > 0 && (number[n-1] < '0' || number[n-1] > '9') && number[n-1] != '.' {
+		number, unit = number[:n-1], number[n-1:]
+	}
+	multiplier, ok := memoryUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid memory size %q: use K, M, G or T, e.g. 4G", value)
+	}
+	number = strings.TrimSpace(number)
+	amount, err := strconv.ParseFloat(number, 64)
+	if err != nil || amount <= 0 || strings.Trim(number, "0123456789.") != "" {
+		return 0, fmt.Errorf("invalid memory size %q: expected a number such as 4G, 1.5G or 75%%", value)
+	}
+	return uint64(amount * float64(multiplier)), nil
+}
+
+// jvmMemory renders a size for -Xms/-Xmx, which take whole numbers only:
+// whole gigabytes as G, anything else in megabytes
+func jvmMemory(bytes uint64) string {
+	if bytes >= 1<<30 && bytes%(1<<30) == 0 {
+		return fmt.Sprintf("%dG", bytes>>30)
+	}
+	return fmt.Sprintf("%dM", max(bytes>>20, 1))
+}
+
+// ResolveMemory checks RamMin and RamMax and rewrites them in the form the
+// JVM takes, so "1.5G" becomes 1536M and "50%" the share of this
+// machine's RAM
+func (c *Config) ResolveMemory() error {
+	ramMin, err := ParseMemory(c.RamMin)
+	if err != nil {
+		return fmt.Errorf("--ram-min: %w", err)
+	}
+	ramMax, err := ParseMemory(c.RamMax)
+	if err != nil {
+		return fmt.Errorf("--ram-max: %w", err)
+	}
+	if ramMin < 1<<20 || ramMax < 1<<20 {
+		return fmt.Errorf("--ram-min and --ram-max must be at least 1M; add a unit, e.g. 4G")
+	}
+	if ramMin > ramMax {
+		return fmt.Errorf("--ram-min %s (%s) is more than --ram-max %s (%s)", c.RamMin, stats.FormatBytes(ramMin), c.RamMax, stats.FormatBytes(ramMax))
+	}
+	c.RamMin, c.RamMax = jvmMemory(ramMin), jvmMemory(ramMax)
+	return nil
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"mcserver-manager/internal/loader"
+)
+
+// PlanMigration works out what moving the server to another mod loader
+// involves, without changing anything
+func (s *Server) PlanMigration(target, version string) (*loader.Plan, error) {
+	return loader.NewPlan(s.config.ServerDir, target, version)
+}
+
+// MigrateLoader moves the server to another mod loader through a blue/green
+// upgrade: the new loader, the configs and each mod's counterpart are set
+// up and smoke booted beside the server before the swap, and the old
+// setup stays behind for :upgrade rollback. Mods without a counterpart
+// block the migration unless force drops them.
+func (s *Server) MigrateLoader(plan *loader.Plan, force bool) error {
+	if missing := plan.Missing(); len(missing) > 0 {
+		if !force {
+			return fmt.Errorf("no %s build of %s; pass force to migrate without them", plan.To.Name, strings.Join(missing, ", "))
+		}
+		s.addEvent(EventWarning, fmt.Sprintf("Migration drops %s, which have no %s build", strings.Join(missing, ", "), plan.To.Name))
+	}
+
+	build := func(dir string) error {
+		s.addEvent(EventInfo, fmt.Sprintf("Migration: installing %s", &plan.To))
+		return plan.Build(s.config.ServerDir, dir, s.config.JavaPath)
+	}
+	if err := s.blueGreen(plan.To.String(), "", "", build); err != nil {
+		return err
+	}
+	s.addEvent(EventInfo, "Remove --modpack before the next launch, or it reinstalls the old loader")
+	return nil
+}
+
+// describePlan lists a migration plan one mod per line
+func describePlan(plan *loader.Plan) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Migrate %s to %s:\n", &plan.From, &plan.To)
+	for _, mod := range plan.Mods {
+		if mod.Replacement == "" {
+			fmt.Fprintf(&b, "  %s -> none, %s has no build\n", mod.File, plan.To.Name)
+			continue
+		}
+		fmt.Fprintf(&b, "  %s -> %s (%s)\n", mod.File, mod.Replacement, mod.Source)
+	}
+	if missing := plan.Missing(); len(missing) > 0 {
+		fmt.Fprintf(&b, "%s missing; apply needs force to drop them", plural(len(missing), "mod"))
+	} else {
+		fmt.Fprintf(&b, "Every mod has a %s build", plan.To.Name)
+	}
+	return b.String()
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "migrate",
+		Usage: "migrate forge|neoforge [version] [apply] [force]",
+		Help:  "Plan moving the server to another mod loader, or apply the plan as a blue/green upgrade",
+		Admin: true,
+		Run: func(s *Server, args []string) (string, error) {
+			if len(args) < 1 {
+				return "", fmt.Errorf("usage: migrate forge|neoforge [version] [apply] [force]")
+			}
+			target, version := args[0], ""
+			apply, force := false, false
+			for _, arg := range args[1:] {
+				switch arg {
+				case "apply":
+					apply = true
+				case "force":
+					force = true
+				default:
+					version = arg
+				}
+			}
+
+			plan, err := s.PlanMigration(target, version)
+			if err != nil {
+				return "", err
+			}
+			if !apply {
+				return describePlan(plan), nil
+			}
+			if missing := plan.Missing(); len(missing) > 0 && !force {
+				return describePlan(plan), fmt.Errorf("%s without a %s build", plural(len(missing), "mod"), target)
+			}
+
+			if s.stats.Status == StatusRunning {
+				go s.MigrateLoader(plan, force)
+				return fmt.Sprintf("Migrating to %s in the background; progress is in the event log", &plan.To), nil
+			}
+			return "Migrated to " + plan.To.String(), s.MigrateLoader(plan, force)
+		},
+	})
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"mcserver-manager/internal/curseforge"
+)
+
+// PlanModpackUpdate downloads a modpack version and compares it with what
+// is installed, without changing the server
+func (s *Server) PlanModpackUpdate(modpackID, version string) (*curseforge.UpgradePlan, error) {
+	cf := curseforge.NewClient()
+	modpackPath, err := s.downloadExtensionModpack(modpackID, version)
+	if modpackPath == "" && err == nil {
+		modpackPath, err = cf.DownloadModpack(modpackID, version, s.config.ServerDir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to download modpack: %w", err)
+	}
+	return cf.PlanUpgrade(modpackPath, s.config.ServerDir)
+}
+
+// UpdateModpack installs a modpack version over the stopped server in
+// place: mods dropped from the pack are removed, and the worlds, the
+// server's own files and configs edited since the last install are kept.
+// "modpack rollback" undoes it.
+func (s *Server) UpdateModpack(modpackID, version string, plan *curseforge.UpgradePlan) error {
+	status := s.GetStats().Status
+	if status != StatusStopped && status != StatusCrashed {
+		return fmt.Errorf("stop the server first; the update replaces jars it has open (or use :upgrade for a blue/green upgrade)")
+	}
+	cf := curseforge.NewClient()
+	done := s.trackModDownloads(cf)
+	err := cf.ApplyUpgrade(plan)
+	done()
+	s.updateStatus(status)
+	if err != nil {
+		s.addEvent(EventError, fmt.Sprintf("Modpack update failed, nothing changed: %v", err))
+		return err
+	}
+	s.config.ModpackID, s.config.ModpackVersion = modpackID, version
+	s.recordModpack(plan.To)
+	s.addEvent(EventInfo, fmt.Sprintf("Updated the modpack to %s", plan.To))
+	return nil
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "modpack",
+		Usage: "modpack update <modpack> [version] [apply]",
+		Help:  "Show what installing a modpack version in place would change, or install it, keeping the worlds and edited configs",
+		Admin: true,
+		Run: func(s *Server, args []string) (string, error) {
+			if len(args) < 2 || args[0] != "update" {
+				return "", fmt.Errorf("usage: modpack update <modpack> [version] [apply]")
+			}
+			modpackID, version, apply := args[1], "latest", false
+			for _, arg := range args[2:] {
+				if arg == "apply" {
+					apply = true
+				} else {
+					version = arg
+				}
+			}
+
+			plan, err := s.PlanModpackUpdate(modpackID, version)
+			if err != nil {
+				return "", err
+			}
+			summary := plan.Summary()
+			if plan.Empty() {
+				return strings.Join(append(summary, "Nothing to change"), "\n"), nil
+			}
+			if !apply {
+				return strings.Join(append(summary, "Add apply to make these changes"), "\n"), nil
+			}
+			if err := s.UpdateModpack(modpackID, version, plan); err != nil {
+				return strings.Join(summary, "\n"), err
+			}
+			summary = append(summary, "Updated; \"modpack rollback\" undoes it. Update --modpack/--modpack-version to match, or the next launch installs the old version")
+			return strings.Join(summary, "\n"), nil
+		},
+	})
+}
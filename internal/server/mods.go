@@ -0,0 +1,137 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"mcserver-manager/internal/mods"
+)
+
+// CheckMods inventories the server's jars and looks them up on Modrinth
+// and CurseForge
+func (s *Server) CheckMods() (*mods.Inventory, error) {
+	inv := mods.List(s.config.ServerDir)
+	if err := inv.Resolve(); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// UpdateMods updates the named mods, or every outdated one when names is
+// empty, after zipping the mods folder into the backup directory. A
+// running server picks the new jars up on its next restart, which the mod
+// watcher asks for.
+func (s *Server) UpdateMods(names []string) (string, error) {
+	inv, err := s.CheckMods()
+	if err != nil {
+		return "", err
+	}
+	var selected []mods.Mod
+	if len(names) == 0 {
+		selected = inv.Outdated()
+	}
+	for _, name := range names {
+		mod, err := inv.Find(name)
+		if err != nil {
+			return "", err
+		}
+		if mod.Update == nil {
+			return "", fmt.Errorf("%s is up to date or not on Modrinth or CurseForge", mod.File)
+		}
+		selected = append(selected, *mod)
+	}
+	if len(selected) == 0 {
+		return "Every mod is up to date", nil
+	}
+
+	s.addEvent(EventInfo, fmt.Sprintf("Updating %s...", plural(len(selected), "mod")))
+	backup, err := inv.Apply(selected, s.config.BackupDir)
+	if err != nil {
+		if backup != "" {
+			err = fmt.Errorf("%w (the mods before the update are in %s)", err, backup)
+		}
+		s.addEvent(EventError, fmt.Sprintf("Mod update failed: %v", err))
+		return "", err
+	}
+
+	lines := []string{fmt.Sprintf("Mods before the update are in %s", backup)}
+	for _, mod := range selected {
+		lines = append(lines, fmt.Sprintf("  %s -> %s", mod.File, mod.Update.FileName))
+	}
+	s.addEvent(EventInfo, fmt.Sprintf("Updated %s", plural(len(selected), "mod")))
+	if s.stats.Status == StatusRunning {
+		lines = append(lines, "Restart the server to load them")
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// describeMods lists the installed jars with what they declare
+func describeMods(inv *mods.Inventory) string {
+	if len(inv.Mods) == 0 {
+		return "No mods or plugins installed"
+	}
+	var lines []string
+	for _, mod := range inv.Mods {
+		id, version := mod.ID, mod.Version
+		if id == "" {
+			id = "?"
+		}
+		if version == "" {
+			version = "?"
+		}
+		lines = append(lines, fmt.Sprintf("%-40s %-24s %s", mod.File, id, version))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// describeOutdated lists the mods with a newer build, and how many jars
+// neither site knows
+func describeOutdated(inv *mods.Inventory) string {
+	var lines []string
+	unknown := 0
+	for _, mod := range inv.Mods {
+		switch {
+		case mod.Update != nil:
+			lines = append(lines, fmt.Sprintf("%-40s -> %s (%s, %s)", mod.File, mod.Update.FileName, mod.Update.Version, mod.Source))
+		case mod.Source == "":
+			unknown++
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, fmt.Sprintf("Every mod is up to date for %s %s", inv.Loader, inv.MCVersion))
+	}
+	if unknown > 0 {
+		lines = append(lines, fmt.Sprintf("%s not on Modrinth or CurseForge could not be checked", plural(unknown, "jar")))
+	}
+	for _, warning := range inv.Warnings {
+		lines = append(lines, "Warning: "+warning)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "mods",
+		Usage: "mods list|outdated|update [mod...]",
+		Help:  "List installed mods and plugins, check Modrinth and CurseForge for newer builds, or update them after backing up the mods folder",
+		Admin: true,
+		Run: func(s *Server, args []string) (string, error) {
+			if len(args) == 0 {
+				args = []string{"list"}
+			}
+			switch args[0] {
+			case "list":
+				return describeMods(mods.List(s.config.ServerDir)), nil
+			case "outdated":
+				inv, err := s.CheckMods()
+				if err != nil {
+					return "", err
+				}
+				return describeOutdated(inv), nil
+			case "update":
+				return s.UpdateMods(args[1:])
+			}
+			return "", fmt.Errorf("usage: mods list|outdated|update [mod...]")
+		},
+	})
+}
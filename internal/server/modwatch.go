@@ -0,0 +1,203 @@
+package server
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Writes come in bursts (a mod jar is copied in chunks, editors save via
+// temp files), so changes are only evaluated once things are quiet
+const modWatchSettle = 2 * time.Second
+
+// watchedDirs are the server folders whose changes need a restart
+var watchedDirs = []string{"mods", "config"}
+
+// watchModsLoop watches mods/ and config/ while one server process runs and
+// flags a restart once their content differs from what the process loaded.
+// It compares file hashes rather than trusting write events, because mods
+// rewrite their configs on startup and on shutdown without changing them.
+func (s *Server) watchModsLoop() {
+	proc := s.cmd
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Not watching mods for changes: %v", err))
+		return
+	}
+	defer watcher.Close()
+
+	// Mods write their configs while loading, so take the baseline once
+	// the server is up
+	for s.stats.Status == StatusStarting {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+		if s.cmd != proc {
+			return
+		}
+	}
+
+	baseline := map[string]string{}
+	for _, dir := range watchedDirs {
+		root := filepath.Join(s.config.ServerDir, dir)
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				watcher.Add(path)
+				return nil
+			}
+			if s.watchedFile(path) {
+				baseline[path] = fileHash(path)
+			}
+			return nil
+		})
+	}
+
+	touched := map[string]bool{}
+	settle := time.NewTimer(modWatchSettle)
+	settle.Stop()
+	defer settle.Stop()
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+
+		case <-ticker.C:
+			if s.cmd != proc {
+				s.setRestartRequired("")
+				return
+			}
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					watcher.Add(event.Name)
+					continue
+				}
+			}
+			if s.watchedFile(event.Name) {
+				touched[event.Name] = true
+				settle.Reset(modWatchSettle)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			s.addEvent(EventWarning, fmt.Sprintf("Mod watcher: %v", err))
+
+		case <-settle.C:
+			if s.cmd != proc {
+				return
+			}
+			s.evaluateModChanges(baseline, touched)
+		}
+	}
+}
+
+// evaluateModChanges folds the touched paths into the set of files that
+// differ from the baseline and updates the restart prompt
+func (s *Server) evaluateModChanges(baseline map[string]string, touched map[string]bool) {
+	before := s.GetStats().RestartRequired
+
+	var mods, configs []string
+	for path := range touched {
+		current := fileHash(path)
+		if current == baseline[path] {
+			// Rewritten with the same content, or changed back
+			delete(touched, path)
+			continue
+		}
+		rel, _ := filepath.Rel(s.config.ServerDir, path)
+		if isModPath(rel) {
+			mods = append(mods, rel)
+		} else {
+			configs = append(configs, rel)
+		}
+	}
+	sort.Strings(mods)
+	sort.Strings(configs)
+
+	var parts []string
+	if len(mods) > 0 {
+		parts = append(parts, plural(len(mods), "changed mod"))
+	}
+	if len(configs) > 0 {
+		parts = append(parts, plural(len(configs), "changed config"))
+	}
+	reason := strings.Join(parts, " and ")
+	s.setRestartRequired(reason)
+
+	if reason != "" && reason != before {
+		s.addEvent(EventWarning, fmt.Sprintf("Restart required to apply %s: %s", reason, strings.Join(append(mods, configs...), ", ")))
+	}
+}
+
+func (s *Server) setRestartRequired(reason string) {
+	s.statsMutex.Lock()
+	s.stats.RestartRequired = reason
+	s.statsMutex.Unlock()
+}
+
+// watchedFile reports whether a change to path matters: jars in mods/ and
+// any config file, but not editor swap files and other noise
+func (s *Server) watchedFile(path string) bool {
+	base := filepath.Base(path)
+	if strings.HasPrefix(base, ".") || strings.HasSuffix(base, "~") || strings.HasSuffix(base, ".tmp") || strings.HasSuffix(base, ".swp") {
+		return false
+	}
+	rel, err := filepath.Rel(s.config.ServerDir, path)
+	if err != nil {
+		return false
+	}
+	if isModPath(rel) {
+		return strings.HasSuffix(base, ".jar")
+	}
+	return true
+}
+
+func isModPath(rel string) bool {
+	return strings.HasPrefix(rel, "mods"+string(filepath.Separator))
+}
+
+// fileHash returns the SHA-1 of a file, or "" if it does not exist
+func fileHash(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	hash := sha1.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,367 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	"mcserver-manager/internal/i18n"
+	"mcserver-manager/internal/props"
+	"mcserver-manager/internal/rcon"
+)
+
+// errMonitoring refuses lifecycle operations on a server the manager only
+// watches
+var errMonitoring = errors.New("the server is managed externally; mcserver monitor only watches it")
+
+// How often the followed log is checked for new lines, and how often the
+// external process and the RCON connection are looked after
+const (
+	logPoll     = 250 * time.Millisecond
+	processPoll = 2 * time.Second
+	rconRedial  = 15 * time.Second
+)
+
+// Lines marking the launch and shutdown of a server whose process the
+// manager does not see start or exit
+var (
+	launchRegex   = regexp.MustCompile(`Starting minecraft server version|Loading Minecraft \S+ with Fabric Loader|ModLauncher running`)
+	stoppingRegex = regexp.MustCompile(`Stopping (the )?server`)
+)
+
+// Monitor watches a server launched by something else, such as Pterodactyl
+// or a systemd unit: it follows MonitorLog for status, players and events,
+// sends commands over RCON when RCONAddress is set, and reads resource
+// stats from the server's java process. It never starts, stops or restarts
+// the server. Monitor returns once watching has begun.
+func (s *Server) Monitor() error {
+	if _, err := os.Stat(s.config.MonitorLog); err != nil {
+		return fmt.Errorf("cannot follow server log: %w", err)
+	}
+	s.monitoring = true
+	if s.config.RCONAddress == "" {
+		s.config.RCONAddress, s.config.RCONPassword = RCONFromProperties(s.config.ServerDir, s.config.RCONPassword)
+	}
+
+	s.refreshWorldInfo()
+	s.detectServerType()
+	s.selectProfile()
+	s.loadEventRules()
+
+	// Replay the current log quietly for the state it leaves behind; the
+	// server rewrote it on launch, so it covers the session so far
+	s.detectProxy()
+	s.catchingUp.Store(true)
+	log, err := os.Open(s.config.MonitorLog)
+	if err != nil {
+		return fmt.Errorf("cannot follow server log: %w", err)
+	}
+	offset := s.followLog(log, 0)
+	s.catchingUp.Store(false)
+
+	s.loadScripts()
+	s.loadAnnouncements()
+	s.loadWorldBorder()
+	s.loadJoinActions()
+	s.addEvent(EventInfo, fmt.Sprintf("Monitoring %s (%s so far)", s.config.MonitorLog, s.stats.Status))
+
+	go s.tailLog(log, offset)
+	go s.watchExternalProcess()
+	go s.updateStatsLoop()
+	if s.config.QueryEnabled {
+		go s.queryLoop()
+	}
+	go s.detectAddresses()
+	if s.config.HealthInterval > 0 {
+		go s.healthLoop()
+	}
+	go s.watchModsLoop()
+	go s.configDriftLoop()
+	go s.historyLoop()
+	if s.config.RCONAddress != "" {
+		go s.rconLoop()
+		go s.requestTPSLoop()
+		go s.playerListLoop()
+		go s.announceLoop()
+		go s.worldBorderLoop()
+		go s.tempBanLoop()
+		if s.backupMgr != nil {
+			// Backups need save-off and save-on, so only with RCON
+			go s.backupScheduler()
+		}
+		go s.autosaveLoop()
+	} else {
+		s.addEvent(EventWarning, "No RCON (--rcon or enable-rcon in server.properties): commands, TPS and announcements are unavailable")
+	}
+	return nil
+}
+
+// Monitoring reports whether the server is watched rather than run
+func (s *Server) Monitoring() bool {
+	return s.monitoring
+}
+
+// tailLog follows the log like tail -F, starting over at the top when the
+// server rotates it
+func (s *Server) tailLog(log *os.File, offset int64) {
+	ticker := time.NewTicker(logPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			log.Close()
+			return
+		case <-ticker.C:
+		}
+
+		if log == nil {
+			f, err := os.Open(s.config.MonitorLog)
+			if err != nil {
+				continue
+			}
+			log, offset = f, 0
+		}
+		offset = s.followLog(log, offset)
+
+		opened, err1 := log.Stat()
+		current, err2 := os.Stat(s.config.MonitorLog)
+		if err1 != nil || err2 != nil || !os.SameFile(opened, current) || current.Size() < offset {
+			// Rotated or truncated; what was left of the old file is read
+			log.Close()
+			log = nil
+		}
+	}
+}
+
+// followLog handles the complete lines from offset to the end of log and
+// returns the offset after the last of them
+func (s *Server) followLog(log *os.File, offset int64) int64 {
+	if _, err := log.Seek(offset, io.SeekStart); err != nil {
+		return offset
+	}
+	reader := bufio.NewReader(log)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			// A partial line is read again once the server finishes it
+			return offset
+		}
+		offset += int64(len(line))
+		s.handleExternalLine(strings.TrimRight(line, "\r\n"))
+	}
+}
+
+// handleExternalLine parses a line of the followed log, including the
+// launch and shutdown an owned process would have reported
+func (s *Server) handleExternalLine(line string) {
+	switch {
+	case launchRegex.MatchString(line):
+		s.closeSessions()
+		s.statsMutex.Lock()
+		s.stats.Players = s.stats.Players[:0]
+		s.stats.PlayerCount = 0
+		s.stats.StartTime = time.Now()
+		s.statsMutex.Unlock()
+		s.updateStatus(StatusStarting)
+		s.addEvent(EventInfo, i18n.T("Server starting..."))
+	case stoppingRegex.MatchString(line):
+		if s.process != nil {
+			s.updateStatus(StatusStopping)
+		} else {
+			s.updateStatus(StatusStopped)
+		}
+	}
+	s.handleLine(line, &s.logWarned)
+}
+
+// watchExternalProcess finds the server's java process for resource stats
+// and notices when it goes away
+func (s *Server) watchExternalProcess() {
+	ticker := time.NewTicker(processPoll)
+	defer ticker.Stop()
+	for {
+		if s.process == nil {
+			if proc := s.findExternalProcess(); proc != nil {
+				s.process = proc
+				s.addEvent(EventInfo, fmt.Sprintf("Found server process %d", proc.Pid))
+			}
+		} else if running, _ := s.process.IsRunning(); !running {
+			pid := s.process.Pid
+			s.process = nil
+			s.closeSessions()
+			s.statsMutex.Lock()
+			s.stats.Players = s.stats.Players[:0]
+			s.stats.PlayerCount = 0
+			s.statsMutex.Unlock()
+			switch s.stats.Status {
+			case StatusStarting, StatusRunning:
+				s.updateStatus(StatusCrashed)
+				s.addEvent(EventError, fmt.Sprintf("Server process %d exited unexpectedly", pid))
+				s.scripts.Fire("on_crash", "process exited")
+			default:
+				s.updateStatus(StatusStopped)
+				s.addEvent(EventInfo, i18n.T("Server stopped"))
+			}
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// findExternalProcess returns MonitorPID's process, or else a java process
+// running in the server directory
+func (s *Server) findExternalProcess() *process.Process {
+	if s.config.MonitorPID > 0 {
+		proc, err := process.NewProcess(int32(s.config.MonitorPID))
+		if err != nil {
+			return nil
+		}
+		return proc
+	}
+
+	dir, err := filepath.EvalSymlinks(s.config.ServerDir)
+	if err != nil {
+		return nil
+	}
+	procs, err := process.Processes()
+	if err != nil {
+		return nil
+	}
+	for _, proc := range procs {
+		name, err := proc.Name()
+		if err != nil || !strings.HasPrefix(strings.ToLower(name), "java") {
+			continue
+		}
+		cwd, err := proc.Cwd()
+		if err != nil {
+			continue
+		}
+		if resolved, err := filepath.EvalSymlinks(cwd); err == nil && resolved == dir {
+			return proc
+		}
+	}
+	return nil
+}
+
+// rconLoop keeps an RCON connection open, redialling after failures. A
+// server that accepts the connection is up, whatever the log said.
+func (s *Server) rconLoop() {
+	ticker := time.NewTicker(rconRedial)
+	defer ticker.Stop()
+	warned := ""
+	for {
+		if s.rconClient() == nil {
+			client, err := rcon.Dial(s.config.RCONAddress, s.config.RCONPassword)
+			switch {
+			case err == nil:
+				s.rconMutex.Lock()
+				s.rcon = client
+				s.rconMutex.Unlock()
+				warned = ""
+				s.addEvent(EventInfo, fmt.Sprintf("Connected to RCON at %s", s.config.RCONAddress))
+				if s.stats.Status == StatusStopped || s.stats.Status == StatusCrashed {
+					s.updateStatus(StatusRunning)
+				}
+			case err.Error() != warned:
+				warned = err.Error()
+				s.addEvent(EventWarning, fmt.Sprintf("RCON at %s unavailable: %v", s.config.RCONAddress, err))
+			}
+		}
+
+		select {
+		case <-s.ctx.Done():
+			if client := s.rconClient(); client != nil {
+				client.Close()
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dialRCON connects to RCON unless connected, at the configured address or
+// the one enabled in server.properties
+func (s *Server) dialRCON() error {
+	if s.rconClient() != nil {
+		return nil
+	}
+	address, password := s.config.RCONAddress, s.config.RCONPassword
+	if address == "" {
+		address, password = RCONFromProperties(s.config.ServerDir, password)
+	}
+	if address == "" {
+		return fmt.Errorf("RCON is not enabled in server.properties")
+	}
+	client, err := rcon.Dial(address, password)
+	if err != nil {
+		return err
+	}
+	s.rconMutex.Lock()
+	if s.rcon != nil {
+		// Another command connected first
+		s.rconMutex.Unlock()
+		client.Close()
+		return nil
+	}
+	s.rcon = client
+	s.rconMutex.Unlock()
+	s.addEvent(EventWarning, fmt.Sprintf("Server console unavailable, sending commands over RCON at %s", address))
+	return nil
+}
+
+func (s *Server) rconClient() *rcon.Client {
+	s.rconMutex.Lock()
+	defer s.rconMutex.Unlock()
+	return s.rcon
+}
+
+// sendRCON runs a command over RCON and handles the reply like console
+// output, so TPS reports and CommandOutput work as with an owned process
+func (s *Server) sendRCON(command string) error {
+	client := s.rconClient()
+	if client == nil {
+		return fmt.Errorf("no RCON connection to the server")
+	}
+	reply, err := client.Command(command)
+	if err != nil {
+		client.Close()
+		s.rconMutex.Lock()
+		if s.rcon == client {
+			s.rcon = nil
+		}
+		s.rconMutex.Unlock()
+		return fmt.Errorf("failed to send command over RCON: %w", err)
+	}
+	for _, line := range strings.Split(reply, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			s.handleLine(line, &s.rconWarned)
+		}
+	}
+	return nil
+}
+
+// RCONFromProperties returns the RCON address enabled in server.properties,
+// using its password unless one was given; the address is "" when RCON is
+// off
+func RCONFromProperties(serverDir, password string) (string, string) {
+	p, err := props.Load(filepath.Join(serverDir, "server.properties"))
+	if err != nil || p.GetDefault("enable-rcon", "false") != "true" {
+		return "", password
+	}
+	if password == "" {
+		password = p.GetDefault("rcon.password", "")
+	}
+	return "127.0.0.1:" + p.GetDefault("rcon.port", "25575"), password
+}
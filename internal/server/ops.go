@@ -0,0 +1,187 @@
+package server
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"mcserver-manager/internal/props"
+)
+
+const mojangProfileURL = "https://api.mojang.com/users/profiles/minecraft/"
+
+// opEntry is one entry of ops.json
+type opEntry struct {
+	UUID                string `json:"uuid"`
+	Name                string `json:"name"`
+	Level               int    `json:"level"`
+	BypassesPlayerLimit bool   `json:"bypassesPlayerLimit"`
+}
+
+// reconcileOps brings ops.json in line with the configured ops before the
+// server reads it: missing ops are added, levels corrected and, with
+// OpsPrune, undeclared ops removed. Players whose UUID cannot be found
+// are opped by command once the server is up.
+func (s *Server) reconcileOps() error {
+	s.pendingOps = nil
+	if len(s.config.Ops) == 0 {
+		return nil
+	}
+
+	path := filepath.Join(s.config.ServerDir, "ops.json")
+	var ops []opEntry
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &ops); err != nil {
+			return fmt.Errorf("failed to parse ops.json: %w", err)
+		}
+	}
+
+	var changes []string
+	kept := ops[:0]
+	for _, op := range ops {
+		level, declared := s.declaredOp(op.Name)
+		switch {
+		case !declared && s.config.OpsPrune:
+			changes = append(changes, "removed "+op.Name)
+			continue
+		case declared && op.Level != level:
+			changes = append(changes, fmt.Sprintf("%s level %d -> %d", op.Name, op.Level, level))
+			op.Level = level
+		}
+		kept = append(kept, op)
+	}
+	ops = kept
+
+	for _, name := range sortedOps(s.config.Ops) {
+		if findOp(ops, name) {
+			continue
+		}
+		uuid, err := s.playerUUID(name)
+		if err != nil {
+			s.addEvent(EventWarning, fmt.Sprintf("Ops: no UUID for %s (%v); opping by command once the server is up", name, err))
+			s.pendingOps = append(s.pendingOps, name)
+			continue
+		}
+		ops = append(ops, opEntry{UUID: uuid, Name: name, Level: s.config.Ops[name]})
+		changes = append(changes, fmt.Sprintf("added %s (level %d)", name, s.config.Ops[name]))
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+	data, err = json.MarshalIndent(ops, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	s.addEvent(EventInfo, "Ops: "+strings.Join(changes, ", "))
+	return nil
+}
+
+// opPending ops the players reconcileOps could not add to ops.json. The
+// server gives them its default op level; the configured one is written
+// to ops.json on the next start.
+func (s *Server) opPending() {
+	for _, name := range s.pendingOps {
+		s.SendCommand("op " + name)
+	}
+	s.pendingOps = nil
+}
+
+// declaredOp returns the configured level of a player, matching names
+// case-insensitively like the server does
+func (s *Server) declaredOp(name string) (int, bool) {
+	for declared, level := range s.config.Ops {
+		if strings.EqualFold(declared, name) {
+			return level, true
+		}
+	}
+	return 0, false
+}
+
+// playerUUID finds a player's UUID: from the server's user cache, derived
+// from the name on an offline-mode server, or else from Mojang
+func (s *Server) playerUUID(name string) (string, error) {
+	if data, err := os.ReadFile(filepath.Join(s.config.ServerDir, "usercache.json")); err == nil {
+		var cache []struct {
+			Name string `json:"name"`
+			UUID string `json:"uuid"`
+		}
+		if json.Unmarshal(data, &cache) == nil {
+			for _, entry := range cache {
+				if strings.EqualFold(entry.Name, name) {
+					return entry.UUID, nil
+				}
+			}
+		}
+	}
+
+	// Behind Velocity online-mode is off, but players keep their Mojang UUIDs
+	properties, _ := props.Load(filepath.Join(s.config.ServerDir, "server.properties"))
+	if properties != nil && properties.GetDefault("online-mode", "true") == "false" && s.config.VelocityDir == "" {
+		return offlineUUID(name), nil
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(mojangProfileURL + name)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Mojang returned status %d", resp.StatusCode)
+	}
+	var profile struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&profile); err != nil {
+		return "", err
+	}
+	if len(profile.ID) != 32 {
+		return "", fmt.Errorf("unexpected profile ID %q", profile.ID)
+	}
+	return dashUUID(profile.ID), nil
+}
+
+// offlineUUID is the version 3 UUID an offline-mode server gives a name
+func offlineUUID(name string) string {
+	sum := md5.Sum([]byte("OfflinePlayer:" + name))
+	sum[6] = sum[6]&0x0f | 0x30
+	sum[8] = sum[8]&0x3f | 0x80
+	return dashUUID(hex.EncodeToString(sum[:]))
+}
+
+func dashUUID(id string) string {
+	return id[:8] + "-" + id[8:12] + "-" + id[12:16] + "-" + id[16:20] + "-" + id[20:]
+}
+
+func findOp(ops []opEntry, name string) bool {
+	for _, op := range ops {
+		if strings.EqualFold(op.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedOps(ops map[string]int) []string {
+	names := make([]string, 0, len(ops))
+	for name := range ops {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// pidFile records the running Java process, relative to the server dir
+const pidFile = ".mcserver/server.pid"
+
+func (s *Server) writePIDFile() {
+	if s.cmd == nil || s.cmd.Process == nil {
+		return
+	}
+	path := filepath.Join(s.config.ServerDir, pidFile)
+	os.MkdirAll(filepath.Dir(path), 0755)
+	os.WriteFile(path, []byte(strconv.Itoa(s.cmd.Process.Pid)), 0644)
+}
+
+func (s *Server) removePIDFile() {
+	os.Remove(filepath.Join(s.config.ServerDir, pidFile))
+}
+
+// RunningPID returns the PID of a server started by a manager in
+// serverDir, if that process is still alive
+func RunningPID(serverDir string) (int, bool) {
+	data, err := os.ReadFile(filepath.Join(serverDir, pidFile))
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, false
+	}
+	if alive, _ := process.PidExists(int32(pid)); !alive {
+		return 0, false
+	}
+	return pid, true
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"mcserver-manager/internal/playerdb"
+	"mcserver-manager/internal/stats"
+)
+
+// recentSessions is how many sessions players info shows
+const recentSessions = 10
+
+// playerSorts orders players list by the given column
+var playerSorts = map[string]func(a, b *playerdb.Player, now time.Time) bool{
+	"seen": func(a, b *playerdb.Player, now time.Time) bool { return a.LastSeen.After(b.LastSeen) },
+	"playtime": func(a, b *playerdb.Player, now time.Time) bool {
+		return a.TotalPlaytime(now) > b.TotalPlaytime(now)
+	},
+	"joins": func(a, b *playerdb.Player, now time.Time) bool { return a.Joins > b.Joins },
+	"first": func(a, b *playerdb.Player, now time.Time) bool { return a.FirstSeen.Before(b.FirstSeen) },
+	"name": func(a, b *playerdb.Player, now time.Time) bool {
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	},
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "players",
+		Usage: "players list [seen|playtime|joins|first|name] | players info <player>",
+		Help:  "List everyone the player database knows, or show one player's sessions, addresses and playtime",
+		Admin: true,
+		Run:   runPlayersAction,
+	})
+}
+
+func runPlayersAction(s *Server, args []string) (string, error) {
+	if s.players == nil {
+		return "", fmt.Errorf("the player database is unavailable")
+	}
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	now := time.Now()
+
+	switch args[0] {
+	case "list":
+		by := "seen"
+		if len(args) > 1 {
+			by = args[1]
+		}
+		less, ok := playerSorts[by]
+		if !ok || len(args) > 2 {
+			return "", fmt.Errorf("usage: players list [seen|playtime|joins|first|name]")
+		}
+		players := s.players.All()
+		if len(players) == 0 {
+			return "No players recorded yet", nil
+		}
+		sort.SliceStable(players, func(i, j int) bool { return less(&players[i], &players[j], now) })
+
+		lines := []string{fmt.Sprintf("%-16s %-16s %9s %6s  %s", "PLAYER", "LAST SEEN", "PLAYTIME", "JOINS", "FIRST SEEN")}
+		for i := range players {
+			p := &players[i]
+			seen := formatSeen(p.LastSeen)
+			if p.Online() {
+				seen = "online"
+			}
+			lines = append(lines, fmt.Sprintf("%-16s %-16s %9s %6d  %s",
+				p.Name, seen, stats.FormatDurationShort(p.TotalPlaytime(now)), p.Joins, formatSeen(p.FirstSeen)))
+		}
+		return strings.Join(lines, "\n"), nil
+
+	case "info":
+		if len(args) != 2 {
+			return "", fmt.Errorf("usage: players info <player>")
+		}
+		p, ok := s.players.Get(args[1])
+		if !ok {
+			return "", fmt.Errorf("%s has not played here", args[1])
+		}
+		return formatPlayerInfo(&p, s.players.SharingIP(p.Name), now), nil
+	}
+	return "", fmt.Errorf("usage: players list [seen|playtime|joins|first|name] | players info <player>")
+}
+
+func formatPlayerInfo(p *playerdb.Player, sharing []playerdb.Player, now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", p.Name)
+	if p.UUID != "" {
+		fmt.Fprintf(&b, "  UUID        %s\n", p.UUID)
+	}
+	fmt.Fprintf(&b, "  First seen  %s\n", formatSeen(p.FirstSeen))
+	last := formatSeen(p.LastSeen)
+	if p.Online() {
+		last = "online now"
+	}
+	fmt.Fprintf(&b, "  Last seen   %s\n", last)
+	fmt.Fprintf(&b, "  Playtime    %s over %s\n", stats.FormatDurationShort(p.TotalPlaytime(now)), plural(p.Joins, "join"))
+	if len(p.IPs) > 0 {
+		fmt.Fprintf(&b, "  Addresses   %s\n", strings.Join(p.IPs, ", "))
+	}
+	if len(sharing) > 0 {
+		names := make([]string, len(sharing))
+		for i, other := range sharing {
+			names[i] = other.Name
+		}
+		fmt.Fprintf(&b, "  Shares an address with %s\n", strings.Join(names, ", "))
+	}
+	if p.TempBan != nil {
+		fmt.Fprintf(&b, "  Banned      until %s\n", p.TempBan.Until.Format("2006-01-02 15:04"))
+	}
+
+	if len(p.Sessions) > 0 {
+		b.WriteString("\n  Recent sessions:\n")
+		sessions := p.Sessions[max(len(p.Sessions)-recentSessions, 0):]
+		for i := len(sessions) - 1; i >= 0; i-- {
+			session := sessions[i]
+			left, length := "online", now.Sub(session.Joined)
+			if !session.Left.IsZero() {
+				left, length = session.Left.Local().Format("15:04"), session.Left.Sub(session.Joined)
+			}
+			line := fmt.Sprintf("  %s - %-6s %7s  %s", session.Joined.Local().Format("2006-01-02 15:04"), left,
+				stats.FormatDurationShort(length), session.IP)
+			b.WriteString(strings.TrimRight(line, " ") + "\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// formatSeen returns a date from the player database, which is zero for
+// players seeded from the usercache
+func formatSeen(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
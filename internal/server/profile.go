@@ -0,0 +1,179 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"mcserver-manager/internal/modinfo"
+	"mcserver-manager/internal/servertype"
+	"mcserver-manager/internal/spark"
+	"mcserver-manager/internal/stats"
+)
+
+// profileFile holds the per-mod reports of past profiling runs, relative
+// to the server dir
+const profileFile = ".mcserver/profiles.jsonl"
+
+// How long spark may take to write its profile after being stopped
+const profileSaveTimeout = 30 * time.Second
+
+// sparkAvailable reports whether the server has spark: as a mod or plugin,
+// or built into Paper since 1.21
+func (s *Server) sparkAvailable() bool {
+	for _, m := range modinfo.List(s.config.ServerDir) {
+		if strings.EqualFold(m.ID, "spark") {
+			return true
+		}
+	}
+	info := s.GetStats().Software
+	return info != nil && info.Family() == "paper" && info.MCVersion != "" && servertype.Compare(info.MCVersion, "1.21") >= 0
+}
+
+// Profile runs spark's sampler for d, then attributes the server thread's
+// time to mods, records the report and raises an event with the top of it
+func (s *Server) Profile(d time.Duration) error {
+	if s.GetStats().Status != StatusRunning {
+		return fmt.Errorf("the server is not running")
+	}
+	if !s.sparkAvailable() {
+		return fmt.Errorf("spark is not installed; add the spark mod or plugin (Paper 1.21 and later has it built in)")
+	}
+	if !s.profiling.CompareAndSwap(false, true) {
+		return fmt.Errorf("a profile is already running")
+	}
+	defer s.profiling.Store(false)
+
+	if err := s.SendCommand("spark profiler start"); err != nil {
+		return err
+	}
+	started := time.Now()
+	s.addEvent(EventInfo, fmt.Sprintf("Profiling with spark for %s", d))
+
+	select {
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	case <-time.After(d):
+	}
+	if err := s.SendCommand("spark profiler stop --save-to-file"); err != nil {
+		return err
+	}
+
+	var path string
+	for deadline := time.Now().Add(profileSaveTimeout); path == "" && time.Now().Before(deadline); {
+		time.Sleep(time.Second)
+		if newest, at := spark.Newest(s.config.ServerDir); at.After(started) {
+			path = newest
+		}
+	}
+	if path == "" {
+		return fmt.Errorf("spark did not save a profile within %s", profileSaveTimeout)
+	}
+	// spark may still be writing it
+	time.Sleep(time.Second)
+
+	report, err := spark.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := saveProfile(s.config.ServerDir, report); err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Failed to record profile: %v", err))
+	}
+
+	var top []string
+	for _, m := range report.Mods[:min(3, len(report.Mods))] {
+		top = append(top, fmt.Sprintf("%s %.0f%%", m.Mod, m.Percent))
+	}
+	s.addEvent(EventInfo, "Profile done, most server thread time in: "+strings.Join(top, ", ")+" (see :profile report)")
+	return nil
+}
+
+func saveProfile(serverDir string, report *spark.Report) error {
+	path := filepath.Join(serverDir, profileFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(report)
+}
+
+// ProfileHistory returns the recorded profile reports, oldest first, at
+// most limit of the newest (0 for all)
+func ProfileHistory(serverDir string, limit int) ([]spark.Report, error) {
+	f, err := os.Open(filepath.Join(serverDir, profileFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var reports []spark.Report
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1024*1024)
+	for sc.Scan() {
+		var r spark.Report
+		if json.Unmarshal(sc.Bytes(), &r) == nil {
+			reports = append(reports, r)
+		}
+	}
+	if limit > 0 && len(reports) > limit {
+		reports = reports[len(reports)-limit:]
+	}
+	return reports, sc.Err()
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "profile",
+		Usage: "profile [duration] | profile report",
+		Help:  "Profile the server with spark (default 60s) and rank mods by server thread time, or show the last report",
+		Run: func(s *Server, args []string) (string, error) {
+			if len(args) == 1 && args[0] == "report" {
+				reports, err := ProfileHistory(s.config.ServerDir, 1)
+				if err != nil {
+					return "", err
+				}
+				if len(reports) == 0 {
+					return "No profiles yet; run :profile while the server lags", nil
+				}
+				return reports[0].String(), nil
+			}
+
+			d := time.Minute
+			if len(args) == 1 {
+				parsed, err := stats.ParseDuration(args[0])
+				if err != nil || parsed < 10*time.Second {
+					return "", fmt.Errorf("usage: profile [duration of at least 10s] | profile report")
+				}
+				d = parsed
+			} else if len(args) > 1 {
+				return "", fmt.Errorf("usage: profile [duration] | profile report")
+			}
+			// Check up front so the usual mistakes are reported right away
+			if s.GetStats().Status != StatusRunning {
+				return "", fmt.Errorf("the server is not running")
+			}
+			if !s.sparkAvailable() {
+				return "", fmt.Errorf("spark is not installed; add the spark mod or plugin (Paper 1.21 and later has it built in)")
+			}
+			if s.profiling.Load() {
+				return "", fmt.Errorf("a profile is already running")
+			}
+			go func() {
+				if err := s.Profile(d); err != nil {
+					s.addEvent(EventWarning, fmt.Sprintf("Profile failed: %v", err))
+				}
+			}()
+			return fmt.Sprintf("Profiling for %s; the ranking appears in the events, then in :profile report", d), nil
+		},
+	})
+}
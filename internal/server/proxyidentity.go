@@ -0,0 +1,56 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"mcserver-manager/internal/proxy"
+)
+
+// detectProxy reads whether the server runs behind a proxy, and with what
+// forwarding, from its configs. Called before each start, since forwarding
+// can be set up between starts.
+func (s *Server) detectProxy() {
+	s.forwarding = proxy.DetectForwarding(s.config.ServerDir)
+	s.proxyWarned.Store(false)
+}
+
+// behindProxy reports whether players connect through a proxy
+func (s *Server) behindProxy() bool {
+	return s.forwarding != proxy.ForwardingNone || s.config.VelocityDir != "" || len(s.config.ProxyAddresses) > 0
+}
+
+// proxyAddress reports whether ip is the proxy's own address rather than a
+// player's: one of ProxyAddresses, or loopback for a proxy on this host
+func (s *Server) proxyAddress(ip string) bool {
+	for _, addr := range s.config.ProxyAddresses {
+		if addr == ip {
+			return true
+		}
+	}
+	if !s.behindProxy() {
+		return false
+	}
+	parsed := net.ParseIP(ip)
+	return parsed != nil && parsed.IsLoopback()
+}
+
+// logAddress turns an address from the log into a plain IP; IPv6 ones come
+// bracketed, like "[2001:db8::1]"
+func logAddress(ip string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(ip, "["), "]")
+}
+
+// unforwarded warns, once per start, that a player's address or UUID is
+// the proxy's own, so the proxy is not forwarding player info
+func (s *Server) unforwarded(player, what string) {
+	if s.catchingUp.Load() || !s.proxyWarned.CompareAndSwap(false, true) {
+		return
+	}
+	hint := "turn on Velocity modern forwarding (--velocity-dir sets it up) or bungeecord in spigot.yml"
+	if s.forwarding != proxy.ForwardingNone {
+		hint = fmt.Sprintf("%s forwarding is on here, so check the proxy forwards player info", s.forwarding)
+	}
+	s.addEvent(EventWarning, fmt.Sprintf("%s arrived with the proxy's %s, which is not recorded: %s", player, what, hint))
+}
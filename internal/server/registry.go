@@ -0,0 +1,144 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Instance is one named server managed by a Registry
+type Instance struct {
+	Name   string
+	Config *Config
+	Server *Server
+}
+
+// Registry holds the servers one manager process runs, by name, in the
+// order they were added
+type Registry struct {
+	mutex      sync.Mutex
+	instances  map[string]*Instance
+	order      []string
+	backupGate chan struct{}
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{instances: make(map[string]*Instance)}
+}
+
+// Add creates the server for config under name. Two servers cannot share a
+// name, a port or a server directory. Their first backups are spread over
+// the backup interval.
+func (r *Registry) Add(name string, config *Config) (*Server, error) {
+	if name == "" {
+		return nil, fmt.Errorf("server name is empty")
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.instances[name]; ok {
+		return nil, fmt.Errorf("server %q is defined twice", name)
+	}
+	dir := filepath.Clean(config.ServerDir)
+	for _, other := range r.instances {
+		if other.Config.Port == config.Port {
+			return nil, fmt.Errorf("servers %q and %q both use port %d", other.Name, name, config.Port)
+		}
+		if filepath.Clean(other.Config.ServerDir) == dir {
+			return nil, fmt.Errorf("servers %q and %q both use %s", other.Name, name, dir)
+		}
+	}
+
+	config.Name = name
+	srv := New(config)
+	srv.backupGate = r.backupGate
+	r.instances[name] = &Instance{Name: name, Config: config, Server: srv}
+	r.order = append(r.order, name)
+	r.stagger()
+	return srv, nil
+}
+
+// Get returns the server called name
+func (r *Registry) Get(name string) (*Instance, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	inst, ok := r.instances[name]
+	return inst, ok
+}
+
+// Instances returns every server, in the order they were added
+func (r *Registry) Instances() []*Instance {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	list := make([]*Instance, 0, len(r.order))
+	for _, name := range r.order {
+		list = append(list, r.instances[name])
+	}
+	return list
+}
+
+// Names returns the server names, in the order they were added
+func (r *Registry) Names() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]string(nil), r.order...)
+}
+
+// StopAll stops every server at once and waits for them
+func (r *Registry) StopAll() {
+	var wg sync.WaitGroup
+	for _, inst := range r.Instances() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			inst.Server.Stop()
+		}()
+	}
+	wg.Wait()
+}
+
+// SetBackupLimit lets at most n of the servers archive a backup at once,
+// so that their compressions do not compete for the disk; 0 means no limit
+func (r *Registry) SetBackupLimit(n int) {
+	var gate chan struct{}
+	if n > 0 {
+		gate = make(chan struct{}, n)
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.backupGate = gate
+	for _, inst := range r.instances {
+		inst.Server.backupGate = gate
+	}
+}
+
+// stagger spreads the servers' first scheduled backups over their
+// interval, so servers started together do not back up together
+func (r *Registry) stagger() {
+	n := len(r.order)
+	for i, name := range r.order {
+		inst := r.instances[name]
+		interval := time.Duration(inst.Config.BackupInterval) * time.Minute
+		inst.Server.backupOffset = interval * time.Duration(i) / time.Duration(n)
+	}
+}
+
+// acquireBackupSlot waits for one of the backup slots shared with the
+// other servers of a registry, and returns the function that frees it
+func (s *Server) acquireBackupSlot() (func(), error) {
+	if s.backupGate == nil {
+		return func() {}, nil
+	}
+	select {
+	case s.backupGate <- struct{}{}:
+	default:
+		s.addEvent(EventBackup, "Waiting for another server's backup to finish...")
+		select {
+		case s.backupGate <- struct{}{}:
+		case <-s.ctx.Done():
+			return nil, fmt.Errorf("server stopped while waiting to back up")
+		}
+	}
+	return func() { <-s.backupGate }, nil
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"syscall"
+
+	"mcserver-manager/internal/logparse"
+)
+
+// liveConfig lists the Config fields a reload applies to the running
+// server; a change to any other field only takes effect on restart
+var liveConfig = map[string]bool{
+	"AutoRestart":         true,
+	"RestartCron":         true,
+	"BackupEnabled":       true,
+	"BackupInterval":      true,
+	"MaxBackups":          true,
+	"BackupWorlds":        true,
+	"BackupExclude":       true,
+	"BackupTargets":       true,
+	"BackupRemoteKeep":    true,
+	"AutosaveInterval":    true,
+	"AutosaveOwn":         true,
+	"ViewDistanceMin":     true,
+	"ViewDistanceMax":     true,
+	"SimDistanceMin":      true,
+	"SimDistanceMax":      true,
+	"ViewDistanceCommand": true,
+	"LogProfile":          true,
+	"PlayerNamePattern":   true,
+	"HidePrivateMessages": true,
+	"Scripts":             true,
+	"ThrottleJoins":       true,
+	"ProxyAddresses":      true,
+	"ThrottleWindow":      true,
+	"ThrottleFirewall":    true,
+	"FloodJoins":          true,
+	"DiskAlert":           true,
+	"LagThreshold":        true,
+	"StatsInterval":       true,
+	"TPSInterval":         true,
+	"CostPerHour":         true,
+	"PlayerListInterval":  true,
+	"ChatCommands":        true,
+	"ChatPrefix":          true,
+}
+
+// ReloadReport says what a reload changed
+type ReloadReport struct {
+	Applied      []string
+	NeedsRestart []string // config fields that changed but apply on restart
+}
+
+func (r *ReloadReport) String() string {
+	var parts []string
+	if len(r.Applied) > 0 {
+		parts = append(parts, "applied "+strings.Join(r.Applied, ", "))
+	}
+	if len(r.NeedsRestart) > 0 {
+		parts = append(parts, "restart needed for "+strings.Join(r.NeedsRestart, ", "))
+	}
+	if len(parts) == 0 {
+		return "nothing changed"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// SetConfigSource sets where Reload reads the configuration from. Without
+// one, Reload only re-reads the manager's files in .mcserver.
+func (s *Server) SetConfigSource(load func() (*Config, error)) {
+	s.configSource = load
+}
+
+// Reload re-reads the configuration and the manager's pattern and script
+// files, applying whatever does not need the server restarted
+func (s *Server) Reload() (*ReloadReport, error) {
+	report := &ReloadReport{}
+	if s.configSource != nil {
+		next, err := s.configSource()
+		if err != nil {
+			return nil, fmt.Errorf("failed to reload config: %w", err)
+		}
+		s.applyConfig(next, report)
+	}
+
+	if s.backupMgr != nil {
+		s.backupMgr.SetMaxBackups(s.config.MaxBackups)
+	}
+	s.selectProfile()
+	s.loadEventRules()
+	s.loadScripts()
+	s.loadAnnouncements()
+	s.loadWorldBorder()
+	s.loadJoinActions()
+	report.Applied = append(report.Applied, "log profile", "event patterns", "announcements", "world border", "join actions")
+	if s.config.Scripts {
+		report.Applied = append(report.Applied, "scripts")
+	}
+	s.signalReload()
+
+	s.addEvent(EventInfo, "Configuration reloaded: "+report.String())
+	return report, nil
+}
+
+// applyConfig copies the live fields of next into the running config and
+// records the rest as needing a restart
+func (s *Server) applyConfig(next *Config, report *ReloadReport) {
+	cur := reflect.ValueOf(s.config).Elem()
+	nxt := reflect.ValueOf(next).Elem()
+	for i := 0; i < cur.NumField(); i++ {
+		name := cur.Type().Field(i).Name
+		if reflect.DeepEqual(cur.Field(i).Interface(), nxt.Field(i).Interface()) {
+			continue
+		}
+		if !liveConfig[name] {
+			report.NeedsRestart = append(report.NeedsRestart, name)
+			continue
+		}
+		cur.Field(i).Set(nxt.Field(i))
+		report.Applied = append(report.Applied, name)
+	}
+}
+
+// selectProfile picks the console patterns for this server type
+func (s *Server) selectProfile() {
+	profile, err := logparse.Select(s.config.LogProfile, s.config.ServerDir, s.config.PlayerNamePattern)
+	if err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("%v, using auto-detected log profile", err))
+		profile, _ = logparse.Select("auto", s.config.ServerDir, "")
+	}
+	s.profile = profile
+}
+
+// reloadSignal returns a channel that is closed at the next reload, for
+// loops that cache configuration
+func (s *Server) reloadSignal() <-chan struct{} {
+	s.reloadMutex.Lock()
+	defer s.reloadMutex.Unlock()
+	if s.reloaded == nil {
+		s.reloaded = make(chan struct{})
+	}
+	return s.reloaded
+}
+
+func (s *Server) signalReload() {
+	s.reloadMutex.Lock()
+	defer s.reloadMutex.Unlock()
+	if s.reloaded != nil {
+		close(s.reloaded)
+	}
+	s.reloaded = make(chan struct{})
+}
+
+// WatchReloadSignal reloads on SIGHUP, the usual way to ask a daemon to
+// re-read its config. Windows has no SIGHUP; use the reload action there.
+func (s *Server) WatchReloadSignal() {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	go func() {
+		for range sig {
+			if _, err := s.Reload(); err != nil {
+				s.addEvent(EventError, err.Error())
+			}
+		}
+	}()
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "reload",
+		Usage: "reload",
+		Help:  "Re-read the configuration and event/script files without restarting",
+		Admin: true,
+		Run: func(s *Server, args []string) (string, error) {
+			report, err := s.Reload()
+			if err != nil {
+				return "", err
+			}
+			return "Reloaded: " + report.String(), nil
+		},
+	})
+}
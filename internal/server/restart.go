@@ -0,0 +1,342 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"mcserver-manager/internal/i18n"
+	"mcserver-manager/internal/stats"
+)
+
+// RestartSource is what asked for a restart, which picks its RestartPolicy
+type RestartSource string
+
+const (
+	RestartManual    RestartSource = "manual"    // TUI, API or :restart
+	RestartWatchdog  RestartSource = "watchdog"  // the manager deciding the server is unhealthy
+	RestartScheduled RestartSource = "scheduled" // a restart schedule
+)
+
+// RestartPolicy is how to treat online players when restarting
+type RestartPolicy struct {
+	// Countdown broadcast before the restart
+	Warning time.Duration
+	// Wait up to this long for the server to empty before counting down,
+	// 0 restarts right away
+	Deadline time.Duration
+}
+
+func (p RestartPolicy) String() string {
+	s := "warn " + p.Warning.String()
+	if p.Deadline > 0 {
+		s += ", wait up to " + p.Deadline.String() + " for the server to empty"
+	}
+	return s
+}
+
+// DefaultRestartPolicies are used for sources without a configured policy
+func DefaultRestartPolicies() map[RestartSource]RestartPolicy {
+	return map[RestartSource]RestartPolicy{
+		RestartManual:    {Warning: 30 * time.Second},
+		RestartWatchdog:  {},
+		RestartScheduled: {Warning: 15 * time.Minute},
+	}
+}
+
+// Times before a restart at which the countdown is broadcast
+var restartMarks = []time.Duration{
+	15 * time.Minute, 10 * time.Minute, 5 * time.Minute, 2 * time.Minute, time.Minute,
+	30 * time.Second, 10 * time.Second, 5 * time.Second, 4 * time.Second, 3 * time.Second, 2 * time.Second, time.Second,
+}
+
+// PendingRestart is a restart waiting on its countdown or on players
+type PendingRestart struct {
+	Source RestartSource
+	// When the restart happens at the latest
+	At time.Time
+	// Waiting for the server to empty rather than counting down
+	Deferred bool
+}
+
+// pendingRestart tracks the one restart that may be waiting
+type pendingRestart struct {
+	mu     sync.Mutex
+	info   *PendingRestart
+	cancel chan struct{}
+	now    chan struct{}
+}
+
+// signal closes one of the pending restart's channels, once; it reports
+// false if no restart is pending
+func (p *pendingRestart) signal(ch *chan struct{}) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.info == nil || *ch == nil {
+		return false
+	}
+	close(*ch)
+	*ch = nil
+	return true
+}
+
+// Restart restarts the server on request of an operator, warning online
+// players first
+func (s *Server) Restart() error {
+	return s.RestartFrom(RestartManual)
+}
+
+// RestartFrom restarts the server following the policy of source: with
+// nobody online it restarts right away, otherwise it broadcasts a countdown
+// first, after waiting for the server to empty if the policy defers. The
+// wait happens in the background; a second request while one is pending
+// is refused.
+func (s *Server) RestartFrom(source RestartSource) error {
+	return s.restartWith(source, s.restartPolicy(source))
+}
+
+// RestartIn restarts the server on request of an operator after a
+// countdown of d, in place of the manual policy's
+func (s *Server) RestartIn(d time.Duration) error {
+	return s.restartWith(RestartManual, RestartPolicy{Warning: d})
+}
+
+func (s *Server) restartWith(source RestartSource, policy RestartPolicy) error {
+	if s.stats.Status != StatusRunning || s.GetStats().PlayerCount == 0 || (policy.Warning == 0 && policy.Deadline == 0) {
+		return s.restartNow()
+	}
+
+	p := &s.pending
+	p.mu.Lock()
+	if p.info != nil {
+		p.mu.Unlock()
+		return fmt.Errorf("a %s restart is already pending (:restart now or :restart cancel)", p.info.Source)
+	}
+	p.info = &PendingRestart{Source: source, At: time.Now().Add(policy.Deadline + policy.Warning), Deferred: policy.Deadline > 0}
+	p.cancel, p.now = make(chan struct{}), make(chan struct{})
+	cancel, now := p.cancel, p.now
+	p.mu.Unlock()
+
+	go s.awaitRestart(source, policy, cancel, now)
+	return nil
+}
+
+// awaitRestart runs a pending restart's deferral and countdown
+func (s *Server) awaitRestart(source RestartSource, policy RestartPolicy, cancel, now <-chan struct{}) {
+	done := func() {
+		s.pending.mu.Lock()
+		s.pending.info = nil
+		s.pending.mu.Unlock()
+	}
+	empty := func() bool { return s.GetStats().PlayerCount == 0 }
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	if policy.Deadline > 0 {
+		deadline := time.Now().Add(policy.Deadline)
+		s.addEvent(EventRestart, fmt.Sprintf("%s restart deferred until the server is empty, at most until %s", capitalize(string(source)), deadline.Format("15:04")))
+		s.SendCommand(fmt.Sprintf("say A server restart is pending; it happens once everyone has left, or at %s at the latest", deadline.Format("15:04")))
+	wait:
+		for time.Now().Before(deadline) {
+			select {
+			case <-s.ctx.Done():
+				done()
+				return
+			case <-cancel:
+				done()
+				s.addEvent(EventRestart, "Pending restart cancelled")
+				return
+			case <-now:
+				break wait
+			case <-ticker.C:
+				if empty() {
+					break wait
+				}
+			}
+		}
+	}
+
+	if !empty() && policy.Warning > 0 {
+		s.pending.mu.Lock()
+		s.pending.info.Deferred = false
+		s.pending.info.At = time.Now().Add(policy.Warning)
+		at := s.pending.info.At
+		s.pending.mu.Unlock()
+		s.addEvent(EventRestart, fmt.Sprintf("Restarting in %s, warning players", policy.Warning))
+		s.SendCommand("say Server restarting in " + describeCountdown(policy.Warning))
+
+	countdown:
+		for left := time.Until(at); left > 0; left = time.Until(at) {
+			for _, mark := range restartMarks {
+				if mark < policy.Warning && left <= mark && left > mark-time.Second {
+					s.SendCommand("say Server restarting in " + describeCountdown(mark))
+				}
+			}
+			select {
+			case <-s.ctx.Done():
+				done()
+				return
+			case <-cancel:
+				done()
+				s.addEvent(EventRestart, "Pending restart cancelled")
+				s.SendCommand("say Server restart cancelled")
+				return
+			case <-now:
+				break countdown
+			case <-ticker.C:
+				if empty() {
+					break countdown
+				}
+			}
+		}
+	}
+
+	done()
+	if err := s.restartNow(); err != nil {
+		s.addEvent(EventError, fmt.Sprintf("Restart failed: %v", err))
+	}
+}
+
+// restartNow stops and starts the server without warning anyone
+func (s *Server) restartNow() error {
+	s.addEvent(EventRestart, i18n.T("Restarting server..."))
+
+	s.updateState(func(l *Lifetime) { l.Restarts++ })
+
+	if err := s.Stop(); err != nil {
+		return fmt.Errorf("failed to stop server for restart: %w", err)
+	}
+	s.updateStatus(StatusRestarting)
+
+	time.Sleep(2 * time.Second)
+
+	return s.Start()
+}
+
+// PendingRestart returns the restart waiting on players, if any
+func (s *Server) PendingRestart() *PendingRestart {
+	s.pending.mu.Lock()
+	defer s.pending.mu.Unlock()
+	if s.pending.info == nil {
+		return nil
+	}
+	info := *s.pending.info
+	return &info
+}
+
+func (s *Server) restartPolicy(source RestartSource) RestartPolicy {
+	if policy, ok := s.config.RestartPolicies[source]; ok {
+		return policy
+	}
+	return DefaultRestartPolicies()[source]
+}
+
+func describeCountdown(d time.Duration) string {
+	if d >= time.Minute && d%time.Minute == 0 {
+		return plural(int(d/time.Minute), "minute")
+	}
+	return plural(int(d.Round(time.Second)/time.Second), "second")
+}
+
+// nextClockTime returns the first time after from that the local clock
+// shows HH:MM
+func nextClockTime(clock string, from time.Time) (time.Time, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, want HH:MM", clock)
+	}
+	at := time.Date(from.Year(), from.Month(), from.Day(), t.Hour(), t.Minute(), 0, 0, from.Location())
+	if !at.After(from) {
+		at = at.AddDate(0, 0, 1)
+	}
+	return at, nil
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "restart",
+		Usage: "restart [now|cancel|status|at <HH:MM>|<duration>]",
+		Help:  "Restart with the manual restart policy or a countdown of the given length, skip or cancel the pending restart, or restart once at a time of day with the scheduled policy",
+		Admin: true,
+		Run: func(s *Server, args []string) (string, error) {
+			sub := ""
+			if len(args) > 0 {
+				sub = args[0]
+			}
+			p := &s.pending
+			switch sub {
+			case "":
+				if err := s.RestartFrom(RestartManual); err != nil {
+					return "", err
+				}
+				if pending := s.PendingRestart(); pending != nil {
+					return fmt.Sprintf("Restart pending (%s)", s.restartPolicy(RestartManual)), nil
+				}
+				return "Restarted", nil
+
+			case "now":
+				if !p.signal(&p.now) {
+					return "", fmt.Errorf("no restart is pending")
+				}
+				return "Restarting now", nil
+
+			case "cancel":
+				if !p.signal(&p.cancel) {
+					if s.restartAt.After(time.Now()) {
+						s.restartAt = time.Time{}
+						s.signalReload()
+						return "Requested restart cancelled", nil
+					}
+					return "", fmt.Errorf("no restart is pending")
+				}
+				return "Restart cancelled", nil
+
+			case "at":
+				if len(args) != 2 {
+					return "", fmt.Errorf("usage: restart at <HH:MM>")
+				}
+				at, err := nextClockTime(args[1], time.Now())
+				if err != nil {
+					return "", err
+				}
+				s.restartAt = at
+				// Wakes the schedule loop to pick up the new time
+				s.signalReload()
+				return fmt.Sprintf("Restart at %s (%s)", at.Format("Mon 15:04"), s.restartPolicy(RestartScheduled)), nil
+
+			case "status":
+				pending := s.PendingRestart()
+				if pending == nil {
+					if at, schedule := s.nextScheduledRestart(time.Now()); !at.IsZero() {
+						return fmt.Sprintf("No restart pending; the next scheduled one is at %s (%s)", at.Format("Mon 15:04"), schedule), nil
+					}
+					return "No restart pending", nil
+				}
+				state := "counting down"
+				if pending.Deferred {
+					state = "waiting for the server to empty"
+				}
+				return fmt.Sprintf("%s restart %s, at %s at the latest", capitalize(string(pending.Source)), state, pending.At.Format("15:04:05")), nil
+			}
+			if d, err := stats.ParseDuration(sub); err == nil && len(args) == 1 {
+				if err := s.RestartIn(d); err != nil {
+					return "", err
+				}
+				if pending := s.PendingRestart(); pending != nil {
+					return fmt.Sprintf("Restarting in %s", describeCountdown(d)), nil
+				}
+				return "Restarted", nil
+			}
+			return "", fmt.Errorf("usage: restart [now|cancel|status|at <HH:MM>|<duration>]")
+		},
+	})
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"fmt"
+	"os/exec"
+	"time"
+
+	"mcserver-manager/internal/cron"
+)
+
+// nextScheduledRestart returns the first time after from that one of the
+// restart schedules fires or a restart was asked for with restart at, and
+// which; the zero time without either
+func (s *Server) nextScheduledRestart(from time.Time) (time.Time, string) {
+	var next time.Time
+	var which string
+	for _, expr := range s.config.RestartCron {
+		schedule, err := cron.Parse(expr)
+		if err != nil {
+			// Checked when the flags were read
+			continue
+		}
+		if at := schedule.Next(from); !at.IsZero() && (next.IsZero() || at.Before(next)) {
+			next, which = at, schedule.String()
+		}
+	}
+	if s.restartAt.After(from) && (next.IsZero() || s.restartAt.Before(next)) {
+		next, which = s.restartAt, "requested"
+	}
+	return next, which
+}
+
+// restartScheduleLoop restarts the server at the times of the restart
+// schedules and of restart at, while one server process runs. The
+// countdown of the scheduled restart policy starts early enough to end at
+// the scheduled time.
+func (s *Server) restartScheduleLoop() {
+	proc := s.cmd
+	for {
+		// A restart that ended its countdown early, because everyone left,
+		// must not fire again in the next process
+		from := time.Now()
+		if s.scheduledRestart.After(from) {
+			from = s.scheduledRestart
+		}
+		next, schedule := s.nextScheduledRestart(from)
+
+		var timer *time.Timer
+		var due <-chan time.Time
+		if !next.IsZero() {
+			start := next
+			if policy := s.restartPolicy(RestartScheduled); policy.Deadline == 0 {
+				start = next.Add(-policy.Warning)
+			}
+			timer = time.NewTimer(max(time.Until(start), 0))
+			due = timer.C
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.reloadSignal():
+		case <-due:
+			if s.cmd == proc && s.GetStats().Status == StatusRunning {
+				s.restartOnSchedule(proc, next, schedule)
+			}
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if s.cmd != proc {
+			// Restarted; the new process has its own loop
+			return
+		}
+	}
+}
+
+// restartOnSchedule starts the scheduled restart due at at. With nobody
+// online to warn it waits for the time itself.
+func (s *Server) restartOnSchedule(proc *exec.Cmd, at time.Time, schedule string) {
+	s.scheduledRestart = at
+	if s.GetStats().PlayerCount == 0 {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(time.Until(at)):
+		}
+		if s.cmd != proc || s.GetStats().Status != StatusRunning {
+			return
+		}
+	}
+
+	s.addEvent(EventRestart, fmt.Sprintf("Scheduled restart for %s (%s)", at.Format("15:04"), schedule))
+	if err := s.RestartFrom(RestartScheduled); err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Scheduled restart skipped: %v", err))
+	}
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"mcserver-manager/internal/backup"
+)
+
+// RestoreBackupTo extracts the named backup into dir instead of the server
+// directory, leaving the server as it is. A relative dir is taken from the
+// server directory. It returns the directory extracted to.
+func (s *Server) RestoreBackupTo(name, dir string) (string, error) {
+	info, err := s.backupMgr.Find(name)
+	if err != nil {
+		return "", err
+	}
+
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(s.config.ServerDir, dir)
+	}
+	dir = filepath.Clean(dir)
+	if dir == filepath.Clean(s.config.ServerDir) {
+		return "", fmt.Errorf("%s is the live server; restore without a directory to replace it", dir)
+	}
+	// Extracting over files would mix the backup with whatever is there
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return "", fmt.Errorf("%s is not empty", dir)
+	}
+
+	s.addEvent(EventBackup, fmt.Sprintf("Extracting %s to %s...", info.Name, dir))
+	if err := backup.RestoreTo(info.Path, dir); err != nil {
+		s.addEvent(EventError, fmt.Sprintf("Restore failed: %v", err))
+		return "", err
+	}
+	s.addEvent(EventBackup, fmt.Sprintf("Extracted %s to %s", info.Name, dir))
+	return dir, nil
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "restore",
+		Usage: "restore <name> [dir]",
+		Help:  "Restore a backup over the server, stopping it meanwhile, or extract it into an empty dir to look through",
+		Admin: true,
+		Run: func(s *Server, args []string) (string, error) {
+			switch len(args) {
+			case 1:
+				info, err := s.backupMgr.Find(args[0])
+				if err != nil {
+					return "", err
+				}
+				if err := s.RestoreBackup(info.Name); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("Restored %s", info.Name), nil
+			case 2:
+				dir, err := s.RestoreBackupTo(args[0], args[1])
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("Extracted %s to %s; the server was not touched", args[0], dir), nil
+			}
+			return "", fmt.Errorf("usage: restore <name> [dir]")
+		},
+	})
+}
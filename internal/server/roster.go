@@ -0,0 +1,123 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// How long to wait for the server's reply to "list"
+const playerListTimeout = 5 * time.Second
+
+// playerListLoop sends "list" every PlayerListInterval seconds and
+// corrects the log-tracked players from the reply, for joins and leaves
+// the log profile did not catch (custom formats, proxies)
+func (s *Server) playerListLoop() {
+	// Wait for server to fully start
+	select {
+	case <-s.ctx.Done():
+		return
+	case <-time.After(30 * time.Second):
+	}
+
+	for {
+		// Re-read each round so a reload changes the interval; 0 pauses
+		// checking until a reload turns it back on
+		var timer *time.Timer
+		var due <-chan time.Time
+		if s.config.PlayerListInterval > 0 {
+			timer = time.NewTimer(time.Duration(s.config.PlayerListInterval) * time.Second)
+			due = timer.C
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.reloadSignal():
+		case <-due:
+			if s.stats.Status == StatusRunning {
+				if online, ok := s.requestPlayerList(); ok {
+					s.reconcilePlayers(online, "list")
+				}
+			}
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// requestPlayerList asks the server who is online. ok is false when there
+// was no reply, or when the names did not come on the count line, as on
+// servers that list players by group on the lines after it.
+func (s *Server) requestPlayerList() (online []string, ok bool) {
+	line, err := s.CommandOutput("list", s.profile.PlayerList, playerListTimeout)
+	if err != nil {
+		return nil, false
+	}
+	matches := s.profile.PlayerList.FindStringSubmatchIndex(line)
+	if matches == nil {
+		return nil, false
+	}
+	count := 0
+	fmt.Sscan(line[matches[2]:matches[3]], &count)
+
+	// "There are 2 of a max of 20 players online: Steve, Alex"
+	rest := strings.TrimSpace(line[matches[1]:])
+	rest = strings.TrimSpace(strings.TrimPrefix(rest, ":"))
+	if rest != "" {
+		for _, name := range strings.Split(rest, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				online = append(online, name)
+			}
+		}
+	}
+	return online, len(online) == count
+}
+
+// reconcilePlayers corrects the tracked players from an authoritative list
+// of who is online. A difference is only acted on when two checks in a row
+// see it, so a join or leave the list crossed paths with is not undone.
+// Corrected players go through the usual join and leave handling, so
+// sessions and join actions stay right.
+func (s *Server) reconcilePlayers(online []string, source string) {
+	s.statsMutex.Lock()
+	missing, extra := diffPlayers(s.stats.Players, online)
+	drift := make(map[string]bool, len(missing)+len(extra))
+	var add, remove []string
+	for _, name := range missing {
+		drift["+"+name] = true
+		if s.rosterDrift["+"+name] {
+			add = append(add, name)
+		}
+	}
+	for _, name := range extra {
+		drift["-"+name] = true
+		if s.rosterDrift["-"+name] {
+			remove = append(remove, name)
+		}
+	}
+	s.rosterDrift = drift
+	s.statsMutex.Unlock()
+
+	if len(add) == 0 && len(remove) == 0 {
+		return
+	}
+	for _, name := range add {
+		s.addPlayer(name)
+		s.playerJoined(name)
+	}
+	for _, name := range remove {
+		s.removePlayer(name)
+		s.playerLeft(name)
+	}
+
+	var changes []string
+	if len(add) > 0 {
+		changes = append(changes, "added "+strings.Join(add, ", "))
+	}
+	if len(remove) > 0 {
+		changes = append(changes, "removed "+strings.Join(remove, ", "))
+	}
+	s.addEvent(EventWarning, fmt.Sprintf("Corrected the player list from %s: %s (the log missed their join or leave)", source, strings.Join(changes, "; ")))
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"fmt"
+	"os"
+
+	"mcserver-manager/internal/sandbox"
+)
+
+// sandboxCommand wraps the java command in the sandbox helper and returns
+// it with the restricted environment. Temp files go to the server's own
+// directory, since /tmp is outside the allowlist.
+func (s *Server) sandboxCommand(name string, args []string) (string, []string, []string, error) {
+	policy, err := sandbox.ForServer(s.config.ServerDir, name, s.config.SandboxPaths)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	policy.BestEffort = s.config.SandboxBestEffort
+
+	tmp := sandbox.TmpDir(s.config.ServerDir)
+	if err := os.MkdirAll(tmp, 0700); err != nil {
+		return "", nil, nil, fmt.Errorf("failed to create private temp dir: %w", err)
+	}
+	args = append([]string{"-Djava.io.tmpdir=" + tmp}, args...)
+
+	wrapped, wrappedArgs, err := sandbox.Wrap(policy, name, args)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	s.addEvent(EventInfo, fmt.Sprintf("Sandboxed server: %s", policy.Describe()))
+	return wrapped, wrappedArgs, sandbox.Env(s.config.ServerDir), nil
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"fmt"
+
+	"mcserver-manager/internal/scripting"
+)
+
+// scriptHost is the API user scripts get; it goes through the same paths
+// as the console so scripts cannot do more than an operator could
+type scriptHost struct {
+	s *Server
+}
+
+func (h scriptHost) SendCommand(command string) error {
+	return h.s.SendCommand(command)
+}
+
+func (h scriptHost) Players() []string {
+	stats := h.s.GetStats()
+	names := make([]string, len(stats.Players))
+	for i, p := range stats.Players {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// Notify and Print publish without firing on_event, so a script reacting
+// to events cannot feed itself
+func (h scriptHost) Notify(script, message string) {
+	h.s.publishEvent(EventCustom, fmt.Sprintf("[%s] %s", script, message))
+}
+
+func (h scriptHost) Print(script, message string) {
+	h.s.publishEvent(EventInfo, fmt.Sprintf("[%s] %s", script, message))
+}
+
+// loadScripts (re)loads the Starlark scripts in scripting.Dir when
+// scripting is enabled
+func (s *Server) loadScripts() {
+	s.scripts.Close()
+	s.scripts = nil
+	if !s.config.Scripts {
+		return
+	}
+
+	rt, err := scripting.Load(s.config.ServerDir, scriptHost{s})
+	if err != nil {
+		s.addEvent(EventWarning, err.Error())
+	}
+	s.scripts = rt
+	if names := rt.Names(); len(names) > 0 {
+		s.addEvent(EventInfo, fmt.Sprintf("Loaded %d scripts", len(names)))
+	}
+}
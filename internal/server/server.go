@@ -3,8 +3,10 @@ package server
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -12,12 +14,32 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/shirou/gopsutil/v3/process"
 
+	"mcserver-manager/internal/addons"
+	"mcserver-manager/internal/audit"
 	"mcserver-manager/internal/backup"
 	"mcserver-manager/internal/curseforge"
+	"mcserver-manager/internal/gitops"
+	"mcserver-manager/internal/i18n"
+	"mcserver-manager/internal/logparse"
+	"mcserver-manager/internal/netinfo"
+	"mcserver-manager/internal/playerdb"
+	"mcserver-manager/internal/props"
+	"mcserver-manager/internal/proxy"
+	"mcserver-manager/internal/query"
+	"mcserver-manager/internal/rcon"
+	"mcserver-manager/internal/recording"
+	"mcserver-manager/internal/respack"
+	"mcserver-manager/internal/scripting"
+	"mcserver-manager/internal/slp"
+	"mcserver-manager/internal/vanilla"
+	"mcserver-manager/internal/whitelist"
+	"mcserver-manager/pkg/eventbus"
+	"mcserver-manager/pkg/extension"
 )
 
 // Server manages the Minecraft server process
@@ -33,15 +55,31 @@ type Server struct {
 	stats      ServerStats
 	statsMutex sync.RWMutex
 
-	// Channels
+	// Channels; console lines reach outputChan through spool
 	outputChan chan string
-	eventChan  chan ServerEvent
+	spool      *consoleSpool
+	events     *eventbus.Bus[extension.Event]
 	stopChan   chan struct{}
 
-	// Network tracking
-	lastBytesIn  uint64
-	lastBytesOut uint64
-	lastNetCheck time.Time
+	// Disk tracking
+	lastDiskRead  uint64
+	lastDiskWrite uint64
+	lastDiskCheck time.Time
+	disk          diskTracker
+	backingUp     atomic.Bool
+
+	// Autosaves and backups take turns; lastSave is when the world was
+	// last flushed, in Unix nanoseconds
+	saveMutex sync.Mutex
+	lastSave  atomic.Int64
+
+	// Set by a Registry: slots shared with its other servers' backups, and
+	// how much later than the interval the first scheduled backup runs
+	backupGate   chan struct{}
+	backupOffset time.Duration
+
+	// A spark profiling run is in progress
+	profiling atomic.Bool
 
 	// Context for cancellation
 	ctx        context.Context
@@ -49,28 +87,149 @@ type Server struct {
 
 	// Backup manager
 	backupMgr *backup.Manager
+
+	// Audit log of user commands and administrative actions
+	audit *audit.Log
+
+	// Config repository kept in sync by GitOps, nil unless configured
+	gitops      *gitops.Repo
+	gitopsDrift string // last reported drift, to report changes only
+
+	// Public address used for external reachability checks
+	publicAddr string
+
+	// Resource pack download server and the URL players are given
+	packHost *respack.Host
+	packURL  string
+
+	// cgroup v2 group created for the JVM, and its OOM kill count at start
+	cgroupDir   string
+	oomBaseline int
+
+	// Console patterns for the running server type, plus user event rules
+	profile    *logparse.Profile
+	eventRules []eventRule
+
+	// User Starlark scripts, nil unless enabled and present
+	scripts *scripting.Runtime
+
+	// Remote member list and the hash of the version last applied
+	whitelistSource *whitelist.Source
+	whitelistHash   string
+
+	// Console recording of the current process, nil unless enabled
+	recorder *recording.Recorder
+
+	// Last console line, for reporting where a stalled start stopped
+	lastOutput    atomic.Pointer[consoleLine]
+	startTimedOut bool
+	// Crashes in a row, for the restart backoff
+	crashStreak int
+
+	// Where the lifetime counters are saved, empty for smoke-boot servers,
+	// and when the running process's uptime was last added to them
+	statePath   string
+	stateMutex  sync.Mutex
+	stateWarned bool
+	uptimeMark  time.Time
+
+	// Restart waiting on its countdown or on players to leave, the time of
+	// the last restart the schedule started, and a one-off restart time
+	// from restart at
+	pending          pendingRestart
+	scheduledRestart time.Time
+	restartAt        time.Time
+
+	// Watching a server launched by something else (Monitor): commands go
+	// over rcon, and events are held back while the existing log is read
+	monitoring bool
+	rcon       *rcon.Client
+	rconMutex  sync.Mutex
+	catchingUp atomic.Bool
+	logWarned  bool
+	rconWarned bool
+
+	// Declared ops that could not be written to ops.json, opped by
+	// command once the server is up
+	pendingOps []string
+
+	// Players seen on this server, nil if the database is unreadable, and
+	// what to do as they come and go (nil without a join actions file)
+	players     *playerdb.DB
+	joinActions *joinActions
+
+	// Recent connections, for ThrottleJoins and FloodJoins
+	throttle connThrottle
+
+	// Proxy forwarding found in the server's configs, and whether the
+	// proxy's address showing up as a player's was reported this start
+	forwarding  proxy.Forwarding
+	proxyWarned atomic.Bool
+
+	// TPS history and lag spikes
+	lag lagTracker
+
+	// Broadcast rotation, nil without an announcements file, and the
+	// index of the next rotated message
+	announcements *announcementConfig
+	announceNext  int
+
+	// Border setup, nil without a world border file
+	worldBorder *worldBorderConfig
+
+	// Where Reload reads the config, and the channel closed on reload
+	configSource func() (*Config, error)
+	reloaded     chan struct{}
+	reloadMutex  sync.Mutex
+
+	// Pending CommandOutput calls
+	waiters      []*outputWaiter
+	waitersMutex sync.Mutex
+
+	// Held while a blue/green upgrade or rollback runs
+	upgradeMutex sync.Mutex
+
+	// Held while .mcserver/deaths.json is updated
+	deathsMutex sync.Mutex
+
+	// Differences from the last player list check, "+name" for untracked
+	// online players and "-name" for tracked ones not online
+	rosterDrift map[string]bool
 }
 
-// Regex patterns for parsing server output
+// How many recent events a new subscriber can ask to replay
+const eventBacklog = 100
+
+// Regex patterns for add-on output; the server's own lines are matched by
+// the log profile
 var (
-	playerJoinRegex  = regexp.MustCompile(`\[Server thread/INFO\].*?: (\w+) joined the game`)
-	playerLeaveRegex = regexp.MustCompile(`\[Server thread/INFO\].*?: (\w+) left the game`)
-	playerListRegex  = regexp.MustCompile(`There are (\d+) of a max of (\d+) players online`)
-	tpsRegex         = regexp.MustCompile(`Mean TPS: ([\d.]+)`)
-	doneRegex        = regexp.MustCompile(`Done \([\d.]+s\)! For help, type "help"`)
-	chatRegex        = regexp.MustCompile(`<(\w+)> (.+)`)
-	uuidRegex        = regexp.MustCompile(`UUID of player (\w+) is ([a-f0-9-]+)`)
-	ipRegex          = regexp.MustCompile(`(\w+)\[/(\d+\.\d+\.\d+\.\d+):\d+\] logged in`)
+	forwardingRegex = regexp.MustCompile(`Unable to verify player details|This server requires you to connect with Velocity`)
 )
 
 // New creates a new Server instance
 func New(config *Config) *Server {
+	s := newServer(config)
+	s.loadState()
+	s.disk.device = findDiskDevice(config.ServerDir)
+	if db, err := playerdb.Open(config.ServerDir); err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Player database unavailable: %v", err))
+	} else {
+		s.players = db
+	}
+	s.startExtensions()
+	return s
+}
+
+// newServer creates a Server without hooking up extensions, for the
+// throwaway servers of a blue/green smoke boot
+func newServer(config *Config) *Server {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	s := &Server{
 		config:     config,
 		outputChan: make(chan string, 1000),
-		eventChan:  make(chan ServerEvent, 100),
+		spool:      newConsoleSpool(config.ServerDir),
+		events:     eventbus.New[extension.Event](eventBacklog),
 		stopChan:   make(chan struct{}),
 		ctx:        ctx,
 		cancelFunc: cancel,
@@ -83,13 +242,37 @@ func New(config *Config) *Server {
 		},
 	}
 
-	if config.BackupEnabled {
-		s.backupMgr = backup.NewManager(config.ServerDir, config.BackupDir, config.MaxBackups)
+	// Always available for on-demand backups; BackupEnabled only controls
+	// the scheduler
+	s.backupMgr = backup.NewManager(config.ServerDir, config.BackupDir, config.MaxBackups)
+	s.audit = audit.Open(config.ServerDir)
+	if config.GitOpsRepo != "" {
+		s.gitops = gitops.New(config.ServerDir, config.GitOpsRepo, config.GitOpsBranch, config.GitOpsPath)
+	}
+	if config.WhitelistURL != "" {
+		// buildConfig has checked the URL
+		s.whitelistSource, _ = whitelist.NewSource(config.WhitelistURL)
 	}
+	profile, err := logparse.Select("auto", config.ServerDir, config.PlayerNamePattern)
+	if err != nil {
+		// Start reports the bad pattern when it picks the profile
+		profile, _ = logparse.Select("auto", config.ServerDir, "")
+	}
+	s.profile = profile
+
+	go s.pumpOutput()
 
 	return s
 }
 
+// close ends a Server's background goroutines; New'd servers live for the
+// whole process, so only smoke-boot servers are closed
+func (s *Server) close() {
+	s.cancelFunc()
+	s.spool.close()
+	s.events.Close()
+}
+
 // GetStats returns a copy of current server stats
 func (s *Server) GetStats() ServerStats {
 	s.statsMutex.RLock()
@@ -98,12 +281,19 @@ func (s *Server) GetStats() ServerStats {
 	stats := s.stats
 	stats.Players = make([]Player, len(s.stats.Players))
 	copy(stats.Players, s.stats.Players)
+	stats.Plugins = append([]string(nil), s.stats.Plugins...)
+	stats.LANAddresses = append([]string(nil), s.stats.LANAddresses...)
 	stats.RecentEvents = make([]ServerEvent, len(s.stats.RecentEvents))
 	copy(stats.RecentEvents, s.stats.RecentEvents)
+	stats.DroppedLines = s.spool.droppedLines()
+	stats.PendingRestart = s.PendingRestart()
 
 	if s.stats.Status == StatusRunning {
 		stats.Uptime = time.Since(s.stats.StartTime)
 	}
+	if !s.uptimeMark.IsZero() {
+		stats.Lifetime.Uptime += time.Since(s.uptimeMark)
+	}
 
 	return stats
 }
@@ -113,13 +303,19 @@ func (s *Server) OutputChan() <-chan string {
 	return s.outputChan
 }
 
-// EventChan returns the channel for server events
-func (s *Server) EventChan() <-chan ServerEvent {
-	return s.eventChan
+// Events returns the bus server events are published on. Each consumer
+// subscribes with its own buffer, so any number of them can listen. The
+// events are the extension package's, so code outside this module can
+// subscribe too.
+func (s *Server) Events() *eventbus.Bus[extension.Event] {
+	return s.events
 }
 
 // Start starts the Minecraft server
 func (s *Server) Start() error {
+	if s.monitoring {
+		return errMonitoring
+	}
 	s.updateStatus(StatusStarting)
 
 	// Ensure server directory exists
@@ -127,6 +323,9 @@ func (s *Server) Start() error {
 		return fmt.Errorf("failed to create server directory: %w", err)
 	}
 
+	// Record config edits made while the server was down
+	s.checkConfigDrift(driftWhileStopped)
+
 	// Download and install modpack if specified
 	if s.config.ModpackID != "" {
 		if err := s.installModpack(); err != nil {
@@ -135,20 +334,74 @@ func (s *Server) Start() error {
 		}
 	}
 
+	// Download the server jar if a Minecraft version or server type is set
+	if s.config.MCVersion != "" || s.config.ServerType != "" {
+		if err := s.installVanilla(); err != nil {
+			s.addEvent(EventError, fmt.Sprintf("Minecraft server download failed: %v", err))
+			return fmt.Errorf("minecraft server download failed: %w", err)
+		}
+	}
+
 	// Copy local mods from ./Mods or ./mods directory
 	if err := s.copyLocalMods(); err != nil {
 		s.addEvent(EventWarning, fmt.Sprintf("Local mods copy warning: %v", err))
 	}
 
+	// Bring the config files up to date with the GitOps repository
+	if s.gitops != nil {
+		s.syncGitOps()
+	}
+
+	// Install Geyser + Floodgate for Bedrock players
+	if s.config.BedrockCrossplay {
+		s.installGeyser()
+	}
+
+	// Install/update ViaVersion + ViaBackwards
+	if s.config.ViaVersion {
+		s.installViaVersion()
+	}
+
+	// Configure Velocity modern forwarding
+	if s.config.VelocityDir != "" {
+		s.configureForwarding()
+	}
+	s.detectProxy()
+
+	// Serve the resource pack for clients to download
+	if s.config.ResourcePack != "" {
+		if err := s.startResourcePack(); err != nil {
+			s.addEvent(EventWarning, fmt.Sprintf("Resource pack hosting failed: %v", err))
+		}
+	}
+
 	// Find server JAR
 	serverJar, err := s.findServerJar()
 	if err != nil {
 		return fmt.Errorf("failed to find server JAR: %w", err)
 	}
 
-	// Accept EULA
+	// The server refuses to run without the EULA accepted
 	if err := s.acceptEULA(); err != nil {
-		s.addEvent(EventWarning, "Could not auto-accept EULA")
+		if errors.Is(err, ErrEULANotAccepted) {
+			s.statsMutex.Lock()
+			s.stats.EULARequired = true
+			s.statsMutex.Unlock()
+			s.updateStatus(StatusStopped)
+			s.addEvent(EventError, "Minecraft EULA not accepted: read "+eulaURL+", then run :eula accept or start with --accept-eula")
+			return err
+		}
+		s.addEvent(EventWarning, fmt.Sprintf("Could not write eula.txt: %v", err))
+	}
+
+	// Make the configured players operators
+	if err := s.reconcileOps(); err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Could not reconcile ops: %v", err))
+	}
+
+	// Pull the member list; a failure keeps the current whitelist
+	if s.whitelistSource != nil {
+		s.syncWhitelist()
 	}
 
 	// Configure server.properties
@@ -156,11 +409,50 @@ func (s *Server) Start() error {
 		s.addEvent(EventWarning, fmt.Sprintf("Could not configure server.properties: %v", err))
 	}
 
+	// The manager's own edits are part of the trail too
+	s.checkConfigDrift(driftByManager)
+
+	s.refreshWorldInfo()
+	s.detectServerType()
+	s.checkAdvisories()
+
+	// Pick the console patterns for this server type
+	s.selectProfile()
+	s.loadEventRules()
+	s.loadScripts()
+	s.loadAnnouncements()
+	s.loadWorldBorder()
+	s.loadJoinActions()
+
 	// Build Java command
-	args := s.buildJavaArgs(serverJar)
+	name, args := s.javaPath(), s.buildJavaArgs(serverJar)
+
+	// Lock down what a malicious mod could reach
+	var env []string
+	if s.config.Sandbox {
+		if n, a, e, err := s.sandboxCommand(name, args); err == nil {
+			name, args, env = n, a, e
+		} else if s.config.SandboxBestEffort {
+			s.addEvent(EventWarning, fmt.Sprintf("Running without sandbox: %v", err))
+		} else {
+			s.addEvent(EventError, fmt.Sprintf("Sandbox unavailable, not starting (--sandbox best-effort runs without it): %v", err))
+			return fmt.Errorf("sandbox unavailable: %w", err)
+		}
+	}
 
-	s.cmd = exec.CommandContext(s.ctx, s.config.JavaPath, args...)
+	// Confine the JVM so a runaway server cannot take the machine down
+	if s.config.CgroupLimits {
+		if n, a, err := s.prepareCgroup(name, args); err != nil {
+			s.addEvent(EventWarning, fmt.Sprintf("Running without cgroup limits: %v", err))
+		} else {
+			name, args = n, a
+			s.addEvent(EventInfo, fmt.Sprintf("cgroup limits: %s", s.describeCgroupLimits()))
+		}
+	}
+
+	s.cmd = exec.CommandContext(s.ctx, name, args...)
 	s.cmd.Dir = s.config.ServerDir
+	s.cmd.Env = env
 
 	// Set up pipes
 	stdout, err := s.cmd.StdoutPipe()
@@ -179,44 +471,106 @@ func (s *Server) Start() error {
 	}
 
 	// Start the process
-	if err := s.cmd.Start(); err != nil {
+	if err := s.startTuned(); err != nil {
 		return fmt.Errorf("failed to start server: %w", err)
 	}
 
 	// Get process for monitoring
 	s.process, _ = process.NewProcess(int32(s.cmd.Process.Pid))
+	if err := s.joinCgroup(s.cmd.Process.Pid); err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Could not move server into its cgroup: %v", err))
+	}
+	s.oomBaseline = s.cgroupOOMKills()
+	s.writePIDFile()
 
 	s.statsMutex.Lock()
 	s.stats.StartTime = time.Now()
+	s.stats.RestartRequired = ""
 	s.statsMutex.Unlock()
+	s.lastOutput.Store(nil)
+	s.startTimedOut = false
+
+	s.recorder = nil
+	if s.config.RecordConsole {
+		if s.recorder, err = recording.Create(s.config.ServerDir, s.config.RecordKeep); err != nil {
+			s.addEvent(EventWarning, fmt.Sprintf("Console is not being recorded: %v", err))
+		}
+	}
 
 	// Start output readers
-	go s.readOutput(stdout)
-	go s.readOutput(stderr)
+	go s.readOutput(stdout, s.recorder)
+	go s.readOutput(stderr, s.recorder)
 
 	// Start monitoring
+	s.recordLaunch()
 	go s.monitorProcess()
+	go s.stateLoop()
+	go s.historyLoop()
+	go s.usageLoop()
 	go s.updateStatsLoop()
 	go s.requestTPSLoop()
+	go s.playerListLoop()
+	if s.config.QueryEnabled {
+		go s.queryLoop()
+	}
+	go s.detectAddresses()
+	if s.config.HealthInterval > 0 {
+		go s.healthLoop()
+	}
+	if s.config.AdaptiveView {
+		go s.viewDistanceLoop()
+	}
+	if s.config.SuspendWhenEmpty > 0 {
+		go s.suspendLoop()
+	}
+	if s.config.StartTimeout > 0 {
+		go s.startWatchdog()
+	}
+	go s.watchModsLoop()
+	go s.configDriftLoop()
+	go s.announceLoop()
+	go s.worldBorderLoop()
+	go s.tempBanLoop()
+	go s.restartScheduleLoop()
+	if s.config.MCVersion == vanilla.LatestSnapshot {
+		go s.snapshotLoop()
+	}
+	if s.config.ViaVersion {
+		go s.viaVersionLoop()
+	}
+	if s.gitops != nil && s.config.GitOpsInterval > 0 {
+		go s.gitopsLoop()
+	}
+	if s.whitelistSource != nil && s.config.WhitelistInterval > 0 {
+		go s.whitelistLoop()
+	}
 
-	// Start backup scheduler if enabled
-	if s.config.BackupEnabled && s.backupMgr != nil {
+	// Start backup scheduler; it idles while backups are disabled so a
+	// reload can turn them on
+	if s.backupMgr != nil {
 		go s.backupScheduler()
 	}
+	go s.autosaveLoop()
 
-	s.addEvent(EventInfo, "Server starting...")
+	s.addEvent(EventInfo, i18n.T("Server starting..."))
 
 	return nil
 }
 
 // Stop gracefully stops the server
 func (s *Server) Stop() error {
+	if s.monitoring {
+		return errMonitoring
+	}
+	s.wake()
+	// Stopping supersedes a restart waiting on players
+	s.pending.signal(&s.pending.cancel)
 	if s.stats.Status != StatusRunning && s.stats.Status != StatusStarting {
 		return nil
 	}
 
 	s.updateStatus(StatusStopping)
-	s.addEvent(EventInfo, "Stopping server gracefully...")
+	s.addEvent(EventInfo, i18n.T("Stopping server gracefully..."))
 
 	// Send stop command
 	if err := s.SendCommand("save-all"); err != nil {
@@ -244,9 +598,9 @@ func (s *Server) Stop() error {
 
 	select {
 	case <-done:
-		s.addEvent(EventInfo, "Server stopped gracefully")
+		s.addEvent(EventInfo, i18n.T("Server stopped gracefully"))
 	case <-time.After(30 * time.Second):
-		s.addEvent(EventWarning, "Server did not stop in time, forcing kill")
+		s.addEvent(EventWarning, i18n.T("Server did not stop in time, forcing kill"))
 		if s.cmd != nil && s.cmd.Process != nil {
 			s.cmd.Process.Kill()
 		}
@@ -258,38 +612,105 @@ func (s *Server) Stop() error {
 
 // SendCommand sends a command to the server console
 func (s *Server) SendCommand(command string) error {
-	if s.stdin == nil {
-		return fmt.Errorf("server not running")
+	// A line break would end the command and send the rest as another one
+	if strings.ContainsAny(command, "\r\n") {
+		return fmt.Errorf("command must be a single line")
 	}
-
-	_, err := fmt.Fprintln(s.stdin, command)
-	if err != nil {
-		return fmt.Errorf("failed to send command: %w", err)
+	if s.monitoring {
+		if err := s.sendRCON(command); err != nil {
+			return err
+		}
+	} else if err := s.sendStdin(command); err != nil {
+		// The console can be gone while the server still runs (stdin
+		// closed by a wrapper); RCON reaches it if it is enabled
+		if s.dialRCON() != nil || s.sendRCON(command) != nil {
+			return err
+		}
 	}
 
 	// Don't log TPS commands to avoid spam
-	if command != "forge tps" {
+	if !s.isTPSCommand(command) {
 		s.addEvent(EventCommand, fmt.Sprintf("Executed: %s", command))
+		if s.recorder != nil {
+			s.recorder.Write("> " + command)
+		}
 	}
 	return nil
 }
 
-// Restart restarts the server
-func (s *Server) Restart() error {
-	s.addEvent(EventRestart, "Restarting server...")
-	s.updateStatus(StatusRestarting)
+// sendStdin writes a command to the console of the launched process
+func (s *Server) sendStdin(command string) error {
+	if s.stdin == nil {
+		return fmt.Errorf("server not running")
+	}
+	s.wake()
 
-	s.statsMutex.Lock()
-	s.stats.Restarts++
-	s.statsMutex.Unlock()
+	if _, err := fmt.Fprintln(s.stdin, command); err != nil {
+		return fmt.Errorf("failed to send command: %w", err)
+	}
+	return nil
+}
 
-	if err := s.Stop(); err != nil {
-		return fmt.Errorf("failed to stop server for restart: %w", err)
+func (s *Server) isTPSCommand(command string) bool {
+	for _, c := range s.profile.TPSCommands {
+		if command == c {
+			return true
+		}
 	}
+	return false
+}
 
-	time.Sleep(2 * time.Second)
+// SendCommandAs sends a console command on behalf of a user and records
+// it in the audit log
+func (s *Server) SendCommandAs(source audit.Source, actor, command string) error {
+	err := s.SendCommand(command)
+	s.audit.Record(audit.Entry{
+		Source: source,
+		Actor:  actor,
+		Kind:   audit.KindCommand,
+		Action: command,
+		Error:  errorString(err),
+	})
+	return err
+}
+
+// Notify adds an event from outside the manager, such as a front end
+// reporting its own state
+func (s *Server) Notify(eventType EventType, message string) {
+	s.addEvent(eventType, message)
+}
+
+// RecordDenied records a command or action that was rejected before it
+// reached the server
+func (s *Server) RecordDenied(source audit.Source, actor string, kind audit.Kind, action, reason string) {
+	s.audit.Record(audit.Entry{
+		Source: source,
+		Actor:  actor,
+		Kind:   kind,
+		Action: action,
+		Detail: reason,
+		Denied: true,
+	})
+}
 
-	return s.Start()
+// RecordAction records an administrative action (restart, restore, config
+// change, ...) in the audit log
+func (s *Server) RecordAction(source audit.Source, actor, action, detail string, err error) {
+	s.audit.Record(audit.Entry{
+		Source: source,
+		Actor:  actor,
+		Kind:   audit.KindAction,
+		Action: action,
+		Detail: detail,
+		Error:  errorString(err),
+	})
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
 }
 
 // RunConsole runs the server in simple console mode (no TUI)
@@ -312,24 +733,209 @@ func (s *Server) RunConsole() error {
 	return nil
 }
 
-// requestTPSLoop periodically requests TPS from the server
+// requestTPSLoop requests TPS from the server every TPSInterval seconds
 func (s *Server) requestTPSLoop() {
-	ticker := time.NewTicker(5 * time.Second)
+	// Wait for server to fully start
+	select {
+	case <-s.ctx.Done():
+		return
+	case <-time.After(15 * time.Second):
+	}
+
+	for {
+		// Re-read each round so a reload changes the interval; 0 pauses
+		// polling until a reload turns it back on
+		var timer *time.Timer
+		var due <-chan time.Time
+		if s.config.TPSInterval > 0 {
+			timer = time.NewTimer(time.Duration(s.config.TPSInterval) * time.Second)
+			due = timer.C
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.reloadSignal():
+		case <-due:
+			if s.stats.Status == StatusRunning {
+				for _, command := range s.profile.TPSCommands {
+					s.SendCommand(command)
+				}
+			}
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// detectAddresses fills in the share line: the public address players
+// should connect to and the LAN addresses for local play
+func (s *Server) detectAddresses() {
+	var lan []string
+	for _, ip := range netinfo.LANAddresses() {
+		lan = append(lan, fmt.Sprintf("%s:%d", ip, s.config.Port))
+	}
+
+	share := s.config.PublicAddress
+	if share == "" && s.config.DetectPublicIP {
+		ip, err := netinfo.PublicIP()
+		if err != nil {
+			s.addEvent(EventWarning, err.Error())
+		} else {
+			share = fmt.Sprintf("%s:%d", ip, s.config.Port)
+		}
+	}
+
+	s.statsMutex.Lock()
+	s.stats.LANAddresses = lan
+	s.stats.ShareAddress = share
+	s.publicAddr = share
+	s.statsMutex.Unlock()
+
+	if share != "" {
+		s.addEvent(EventInfo, fmt.Sprintf("Share with friends: connect to %s", share))
+	}
+}
+
+// healthLoop pings the server with the Server List Ping handshake, locally
+// and optionally via its public address, and alerts when a "Running" server
+// stops answering
+func (s *Server) healthLoop() {
+	ticker := time.NewTicker(time.Duration(s.config.HealthInterval) * time.Second)
 	defer ticker.Stop()
 
-	// Wait for server to fully start
-	time.Sleep(15 * time.Second)
+	localAddr := fmt.Sprintf("127.0.0.1:%d", s.config.Port)
+	timeout := 5 * time.Second
 
 	for {
 		select {
 		case <-s.ctx.Done():
 			return
 		case <-ticker.C:
-			if s.stats.Status == StatusRunning {
-				s.SendCommand("forge tps")
+			if s.stats.Status != StatusRunning {
+				continue
+			}
+
+			status, err := slp.Ping(localAddr, timeout)
+
+			s.statsMutex.Lock()
+			wasReachable := s.stats.Reachable || s.stats.LastPing.IsZero()
+			s.stats.LastPing = time.Now()
+			s.stats.Reachable = err == nil
+			if err == nil {
+				s.stats.Latency = status.Latency
+				s.stats.MOTD = status.MOTD
+				s.stats.Favicon = status.Favicon
+			}
+			s.statsMutex.Unlock()
+
+			if err != nil && wasReachable {
+				s.addEvent(EventError, fmt.Sprintf("Server is running but not answering pings: %v", err))
+			} else if err == nil && !wasReachable {
+				s.addEvent(EventInfo, "Server is answering pings again")
 			}
+
+			s.statsMutex.RLock()
+			publicAddr := s.publicAddr
+			s.statsMutex.RUnlock()
+			if publicAddr == "" {
+				continue
+			}
+
+			_, pubErr := slp.Ping(publicAddr, timeout)
+
+			s.statsMutex.Lock()
+			wasPublic := s.stats.PublicReachable
+			s.stats.PublicReachable = pubErr == nil
+			s.stats.ShareVerified = pubErr == nil && s.stats.ShareAddress == publicAddr
+			s.statsMutex.Unlock()
+
+			if pubErr != nil && wasPublic {
+				s.addEvent(EventError, fmt.Sprintf("Server is no longer reachable at %s: %v", publicAddr, pubErr))
+			} else if pubErr == nil && !wasPublic {
+				s.addEvent(EventInfo, fmt.Sprintf("Server is reachable at %s", publicAddr))
+			}
+		}
+	}
+}
+
+// queryPort returns the configured query port, defaulting to the game port
+func (s *Server) queryPort() int {
+	if s.config.QueryPort > 0 {
+		return s.config.QueryPort
+	}
+	return s.config.Port
+}
+
+// queryLoop periodically queries the server over UDP and cross-checks the
+// authoritative player list against the log-tracked one
+func (s *Server) queryLoop() {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	client := query.NewClient(fmt.Sprintf("127.0.0.1:%d", s.queryPort()))
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if s.stats.Status != StatusRunning {
+				continue
+			}
+
+			stat, err := client.FullStat()
+			s.statsMutex.Lock()
+			if err != nil {
+				s.stats.LastQueryError = err.Error()
+				s.statsMutex.Unlock()
+				continue
+			}
+			s.stats.LastQueryError = ""
+			s.stats.MapName = stat.Map
+			s.stats.Plugins = stat.Plugins
+			s.stats.MaxPlayers = stat.MaxPlayers
+
+			missing, extra := diffPlayers(s.stats.Players, stat.Players)
+			wasDesynced := s.stats.PlayerDesync
+			desynced := len(missing) > 0 || len(extra) > 0
+			s.stats.PlayerDesync = desynced
+			s.statsMutex.Unlock()
+
+			if desynced && !wasDesynced {
+				s.addEvent(EventWarning, fmt.Sprintf("Player list desync: untracked online %v, tracked but offline %v", missing, extra))
+			} else if !desynced && wasDesynced {
+				s.addEvent(EventInfo, "Player list back in sync with query")
+			}
+			s.reconcilePlayers(stat.Players, "query")
+		}
+	}
+}
+
+// diffPlayers compares tracked players with an authoritative name list,
+// returning names missing from tracking and tracked names not online
+func diffPlayers(tracked []Player, online []string) (missing, extra []string) {
+	onlineSet := make(map[string]bool, len(online))
+	for _, name := range online {
+		onlineSet[name] = true
+	}
+
+	trackedSet := make(map[string]bool, len(tracked))
+	for _, p := range tracked {
+		trackedSet[p.Name] = true
+		if !onlineSet[p.Name] {
+			extra = append(extra, p.Name)
 		}
 	}
+
+	for _, name := range online {
+		if !trackedSet[name] {
+			missing = append(missing, name)
+		}
+	}
+
+	return missing, extra
 }
 
 // copyLocalMods copies mods from the current directory's Mods folder to the server
@@ -421,33 +1027,134 @@ func (s *Server) installModpack() error {
 	s.addEvent(EventInfo, fmt.Sprintf("Downloading modpack: %s", s.config.ModpackID))
 
 	cf := curseforge.NewClient()
+	defer s.trackModDownloads(cf)()
 
-	// Download modpack
-	modpackPath, err := cf.DownloadModpack(s.config.ModpackID, s.config.ModpackVersion, s.config.ServerDir)
+	// Download modpack, from an extension's source for "<source>:<id>"
+	modpackPath, err := s.downloadExtensionModpack(s.config.ModpackID, s.config.ModpackVersion)
+	if modpackPath == "" && err == nil {
+		modpackPath, err = cf.DownloadModpack(s.config.ModpackID, s.config.ModpackVersion, s.config.ServerDir)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to download modpack: %w", err)
 	}
 
 	s.updateStatus(StatusInstalling)
-	s.addEvent(EventInfo, "Installing modpack...")
+	s.addEvent(EventInfo, i18n.T("Installing modpack..."))
 
-	// Extract and install
-	if err := cf.InstallModpack(modpackPath, s.config.ServerDir); err != nil {
+	// Extract and install; over an earlier install, only what the pack
+	// changed is touched
+	plan, err := cf.PlanUpgrade(modpackPath, s.config.ServerDir)
+	if err != nil {
+		return fmt.Errorf("failed to install modpack: %w", err)
+	}
+	if !plan.FirstInstall() {
+		for _, line := range plan.Summary() {
+			s.addEvent(EventInfo, line)
+		}
+	}
+	if err := cf.ApplyUpgrade(plan); err != nil {
 		return fmt.Errorf("failed to install modpack: %w", err)
 	}
 
-	s.addEvent(EventInfo, "Modpack installed successfully")
+	s.recordModpack(modpackPath)
+	s.addEvent(EventInfo, i18n.T("Modpack installed successfully"))
 	return nil
 }
 
+// installGeyser installs and configures Geyser and Floodgate
+func (s *Server) installGeyser() {
+	result, err := addons.InstallGeyser(s.config.ServerDir, s.config.BedrockPort, s.config.Port)
+	if err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Bedrock cross-play unavailable: %v", err))
+		return
+	}
+
+	for _, jar := range result.Installed {
+		s.addEvent(EventInfo, fmt.Sprintf("Installed %s for %s", jar, result.Platform))
+	}
+	for _, warning := range result.Warnings {
+		s.addEvent(EventWarning, warning)
+	}
+	s.addEvent(EventInfo, fmt.Sprintf("Bedrock cross-play enabled on UDP port %d", s.config.BedrockPort))
+}
+
+// How often a running server checks for ViaVersion and ViaBackwards
+// releases
+const viaCheckInterval = 6 * time.Hour
+
+// installViaVersion keeps ViaVersion and ViaBackwards current
+func (s *Server) installViaVersion() {
+	result, err := addons.InstallViaVersion(s.config.ServerDir)
+	if err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("ViaVersion unavailable: %v", err))
+		return
+	}
+
+	for project, version := range result.Updated {
+		s.addEvent(EventInfo, fmt.Sprintf("Installed %s %s for Minecraft %s", project, version, result.MCVersion))
+	}
+}
+
+// viaVersionLoop looks for new ViaVersion and ViaBackwards releases while
+// one server process runs, and stages them for the next start
+func (s *Server) viaVersionLoop() {
+	proc := s.cmd
+	ticker := time.NewTicker(viaCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if s.cmd != proc {
+			return
+		}
+
+		result, err := addons.StageViaVersion(s.config.ServerDir)
+		if err != nil {
+			continue
+		}
+		for project, version := range result.Updated {
+			s.addEvent(EventInfo, fmt.Sprintf("%s %s is out; restart to install it", project, version))
+		}
+	}
+}
+
+// configureForwarding shares the Velocity forwarding secret with the backend
+// and verifies both sides agree before the server starts
+func (s *Server) configureForwarding() {
+	result, err := proxy.ConfigureModernForwarding(s.config.VelocityDir, s.config.ServerDir)
+	if err != nil {
+		s.addEvent(EventError, fmt.Sprintf("Velocity forwarding setup failed: %v", err))
+		return
+	}
+
+	if result.SecretCreated {
+		s.addEvent(EventInfo, "Generated new Velocity forwarding secret")
+	}
+	if result.InstalledMod != "" {
+		s.addEvent(EventInfo, fmt.Sprintf("Installed forwarding mod %s", result.InstalledMod))
+	}
+	if !result.ProxyConfigSet {
+		s.addEvent(EventWarning, "velocity.toml not found; set player-info-forwarding-mode = \"modern\" on the proxy")
+	}
+
+	if err := proxy.VerifyForwarding(s.config.VelocityDir, s.config.ServerDir); err != nil {
+		s.addEvent(EventError, fmt.Sprintf("Velocity forwarding check failed: %v", err))
+		return
+	}
+	s.addEvent(EventInfo, fmt.Sprintf("Velocity modern forwarding configured (%s)", result.Backend))
+}
+
 // findServerJar finds the server JAR file or detects Forge server
 func (s *Server) findServerJar() (string, error) {
 	// Check if this is a Forge server with run.sh
 	runShPath := filepath.Join(s.config.ServerDir, "run.sh")
 	if _, err := os.Stat(runShPath); err == nil {
-		// Check for unix_args.txt which indicates Forge
-		forgeLibPath := filepath.Join(s.config.ServerDir, "libraries/net/minecraftforge/forge")
-		if _, err := os.Stat(forgeLibPath); err == nil {
+		// Check for the installer's libraries, which indicate Forge or NeoForge
+		if s.forgeLibDir() != "" {
 			return "forge", nil // Special marker for Forge servers
 		}
 	}
@@ -492,47 +1199,78 @@ func (s *Server) findServerJar() (string, error) {
 	return "", fmt.Errorf("no server JAR found in %s", s.config.ServerDir)
 }
 
-// acceptEULA creates/updates eula.txt
-func (s *Server) acceptEULA() error {
-	eulaPath := filepath.Join(s.config.ServerDir, "eula.txt")
-	return os.WriteFile(eulaPath, []byte("eula=true\n"), 0644)
-}
-
 // configureServerProperties sets up server.properties
 func (s *Server) configureServerProperties() error {
 	propsPath := filepath.Join(s.config.ServerDir, "server.properties")
 
-	props := make(map[string]string)
-
-	// Read existing properties if file exists
-	if data, err := os.ReadFile(propsPath); err == nil {
-		lines := strings.Split(string(data), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line == "" || strings.HasPrefix(line, "#") {
-				continue
-			}
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) == 2 {
-				props[parts[0]] = parts[1]
-			}
-		}
+	properties, err := props.Load(propsPath)
+	if err != nil {
+		return err
 	}
 
 	// Set our configuration
-	props["server-port"] = strconv.Itoa(s.config.Port)
+	properties.Set("server-port", strconv.Itoa(s.config.Port))
+	if s.config.VelocityDir != "" {
+		// The proxy authenticates players; the backend must not
+		properties.Set("online-mode", "false")
+	}
+	if s.whitelistSource != nil {
+		properties.Set("white-list", "true")
+	}
+	if s.config.QueryEnabled {
+		properties.Set("enable-query", "true")
+		properties.Set("query.port", strconv.Itoa(s.queryPort()))
+	}
+	if s.packURL != "" {
+		properties.Set("resource-pack", s.packURL)
+		properties.Set("resource-pack-sha1", s.packHost.SHA1())
+		properties.Set("require-resource-pack", strconv.FormatBool(s.config.RequireResourcePack))
+	}
 
-	// Write back
-	var lines []string
-	lines = append(lines, "# Minecraft Server Properties")
-	lines = append(lines, fmt.Sprintf("# Generated by MCServer Manager on %s", time.Now().Format(time.RFC3339)))
-	lines = append(lines, "")
+	return properties.Save(propsPath)
+}
 
-	for key, value := range props {
-		lines = append(lines, fmt.Sprintf("%s=%s", key, value))
+// startResourcePack hashes the configured pack (again, in case it changed
+// since the last start) and serves it
+func (s *Server) startResourcePack() error {
+	if s.packHost == nil {
+		host, err := respack.NewHost(s.config.ResourcePack, s.config.ResourcePackPort)
+		if err != nil {
+			return err
+		}
+		s.packHost = host
+	} else if err := s.packHost.Refresh(); err != nil {
+		return err
 	}
 
-	return os.WriteFile(propsPath, []byte(strings.Join(lines, "\n")), 0644)
+	if err := s.packHost.Start(); err != nil {
+		return err
+	}
+	s.packURL = s.packHost.URL(s.resourcePackHost())
+	s.addEvent(EventInfo, fmt.Sprintf("Serving resource pack at %s", s.packURL))
+	return nil
+}
+
+// resourcePackHost picks the address players can download the pack from
+func (s *Server) resourcePackHost() string {
+	if s.config.ResourcePackHost != "" {
+		return s.config.ResourcePackHost
+	}
+	if s.config.PublicAddress != "" {
+		if host, _, err := net.SplitHostPort(s.config.PublicAddress); err == nil {
+			return host
+		}
+		return s.config.PublicAddress
+	}
+	if s.config.DetectPublicIP {
+		if ip, err := netinfo.PublicIP(); err == nil {
+			return ip
+		}
+	}
+	if lan := netinfo.LANAddresses(); len(lan) > 0 {
+		return lan[0]
+	}
+	return "localhost"
 }
 
 // buildJavaArgs constructs the Java command arguments
@@ -548,33 +1286,11 @@ func (s *Server) buildJavaArgs(serverJar string) []string {
 	}
 
 	// Performance optimizations
-	args = append(args,
-		"-XX:+UseG1GC",
-		"-XX:+ParallelRefProcEnabled",
-		"-XX:MaxGCPauseMillis=200",
-		"-XX:+UnlockExperimentalVMOptions",
-		"-XX:+DisableExplicitGC",
-		"-XX:+AlwaysPreTouch",
-		"-XX:G1NewSizePercent=30",
-		"-XX:G1MaxNewSizePercent=40",
-		"-XX:G1HeapRegionSize=8M",
-		"-XX:G1ReservePercent=20",
-		"-XX:G1HeapWastePercent=5",
-		"-XX:G1MixedGCCountTarget=4",
-		"-XX:InitiatingHeapOccupancyPercent=15",
-		"-XX:G1MixedGCLiveThresholdPercent=90",
-		"-XX:G1RSetUpdatingPauseTimePercent=5",
-		"-XX:SurvivorRatio=32",
-		"-XX:+PerfDisableSharedMem",
-		"-XX:MaxTenuringThreshold=1",
-		"-Dusing.aikars.flags=https://mcflags.emc.gs",
-		"-Daikars.new.flags=true",
-	)
+	args = append(args, s.gcFlags()...)
+	args = append(args, s.log4jArgs()...)
 
-	// Additional custom args
-	if s.config.JavaArgs != "" {
-		args = append(args, strings.Fields(s.config.JavaArgs)...)
-	}
+	// Additional custom args, which win over ours
+	args = s.withUserArgs(args)
 
 	// Server JAR
 	args = append(args, "-jar", serverJar, "nogui")
@@ -582,6 +1298,36 @@ func (s *Server) buildJavaArgs(serverJar string) []string {
 	return args
 }
 
+// LaunchCommand returns the Java executable and arguments the server would
+// start with now, without starting it. Detection and argument checks add
+// their usual events.
+func (s *Server) LaunchCommand() (string, []string, error) {
+	serverJar, err := s.findServerJar()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to find server JAR: %w", err)
+	}
+	s.detectServerType()
+	return s.javaPath(), s.buildJavaArgs(serverJar), nil
+}
+
+// Library dirs the Forge and NeoForge installers create, which hold the
+// launch args files
+var forgeLibDirs = []string{
+	"libraries/net/minecraftforge/forge",
+	"libraries/net/neoforged/neoforge",
+}
+
+// forgeLibDir returns the installed Forge or NeoForge library dir, or ""
+func (s *Server) forgeLibDir() string {
+	for _, dir := range forgeLibDirs {
+		path := filepath.Join(s.config.ServerDir, dir)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
 // buildForgeArgs builds arguments for Forge servers using @args files
 func (s *Server) buildForgeArgs() []string {
 	// Create user_jvm_args.txt with our memory settings
@@ -614,7 +1360,8 @@ func (s *Server) buildForgeArgs() []string {
 	var argsFile string
 
 	// Check for Windows args first
-	filepath.Walk(filepath.Join(s.config.ServerDir, "libraries/net/minecraftforge/forge"), func(path string, info os.FileInfo, err error) error {
+	libDir := s.forgeLibDir()
+	filepath.Walk(libDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil
 		}
@@ -631,13 +1378,13 @@ func (s *Server) buildForgeArgs() []string {
 	if argsFile == "" {
 		// Fallback - just try to run the forge jar directly
 		// Find forge jar
-		matches, _ := filepath.Glob(filepath.Join(s.config.ServerDir, "libraries/net/minecraftforge/forge/*/forge-*.jar"))
+		matches, _ := filepath.Glob(filepath.Join(libDir, "*", filepath.Base(libDir)+"-*.jar"))
 		if len(matches) > 0 {
-			return []string{
+			args := s.withUserArgs([]string{
 				fmt.Sprintf("-Xms%s", s.config.RamMin),
 				fmt.Sprintf("-Xmx%s", s.config.RamMax),
-				"-jar", matches[0], "nogui",
-			}
+			})
+			return append(args, "-jar", matches[0], "nogui")
 		}
 		return []string{"-jar", "server.jar", "nogui"}
 	}
@@ -662,6 +1409,8 @@ func (s *Server) buildForgeArgs() []string {
 		"-XX:+DisableExplicitGC",
 		"-XX:+AlwaysPreTouch",
 	)
+	args = append(args, s.log4jArgs()...)
+	args = s.withUserArgs(args)
 
 	// Parse the forge args file
 	lines := strings.Split(string(argsContent), "\n")
@@ -714,49 +1463,112 @@ func parseArgsLine(line string) []string {
 	return args
 }
 
-// readOutput reads from a pipe and sends to output channel
-func (s *Server) readOutput(pipe io.ReadCloser) {
+// readOutput reads from a pipe and queues lines for the output channel
+func (s *Server) readOutput(pipe io.ReadCloser, rec *recording.Recorder) {
+	warned := false
 	scanner := bufio.NewScanner(pipe)
 	for scanner.Scan() {
 		line := scanner.Text()
-
-		select {
-		case s.outputChan <- line:
-		default:
-			// Channel full, skip
+		if rec != nil && !s.privateLine(line) {
+			rec.Write(line)
 		}
+		s.handleLine(line, &warned)
+	}
+}
 
-		s.parseOutput(line)
+// privateLine reports whether a console line is a private message that
+// HidePrivateMessages keeps out of recordings and support bundles
+func (s *Server) privateLine(line string) bool {
+	return s.config.HidePrivateMessages && s.profile != nil && s.profile.Whisper != nil && s.profile.Whisper.MatchString(line)
+}
+
+// handleLine queues a console line and parses it; warned keeps each reader
+// from reporting dropped output more than once
+func (s *Server) handleLine(line string, warned *bool) {
+	s.lastOutput.Store(&consoleLine{text: line, at: time.Now()})
+
+	if err := s.spool.push(line); err != nil && !*warned {
+		*warned = true
+		s.addEvent(EventWarning, fmt.Sprintf("Console output is being dropped: %v", err))
 	}
+
+	s.notifyWaiters(line)
+	s.observeLine(line)
+	s.parseOutput(line)
 }
 
 // parseOutput parses server output for events and stats
 func (s *Server) parseOutput(line string) {
+	p := s.profile
+
 	// Check for server done starting
-	if doneRegex.MatchString(line) {
+	if p.Done.MatchString(line) {
 		s.updateStatus(StatusRunning)
-		s.addEvent(EventInfo, "Server started successfully!")
+		s.recordStarted()
+		s.addEvent(EventInfo, i18n.T("Server started successfully!"))
+		s.scripts.Fire("on_start")
+		// A fresh world has just written its level.dat
+		go s.refreshWorldInfo()
+		if len(s.pendingOps) > 0 {
+			go s.opPending()
+		}
+		if s.worldBorder != nil && !s.catchingUp.Load() {
+			go func() {
+				if err := s.applyWorldBorder(); err != nil {
+					s.addEvent(EventWarning, fmt.Sprintf("Failed to set the world border: %v", err))
+				}
+			}()
+		}
+		return
+	}
+
+	// Check for proxy forwarding handshake failures
+	if forwardingRegex.MatchString(line) {
+		s.addEvent(EventError, "Proxy forwarding handshake failed: check the Velocity forwarding secret")
 		return
 	}
 
 	// Check for player join
-	if matches := playerJoinRegex.FindStringSubmatch(line); len(matches) > 1 {
+	if matches := p.Join.FindStringSubmatch(line); len(matches) > 1 {
 		playerName := matches[1]
-		s.addPlayer(playerName)
-		s.addEvent(EventPlayerJoin, fmt.Sprintf("%s joined the game", playerName))
+		if s.addPlayer(playerName) {
+			s.addEvent(EventPlayerJoin, i18n.T("%s joined from Bedrock", playerName))
+		} else {
+			s.addEvent(EventPlayerJoin, i18n.T("%s joined the game", playerName))
+		}
+		s.scripts.Fire("on_join", playerName)
+		s.playerJoined(playerName)
 		return
 	}
 
 	// Check for player leave
-	if matches := playerLeaveRegex.FindStringSubmatch(line); len(matches) > 1 {
+	if matches := p.Leave.FindStringSubmatch(line); len(matches) > 1 {
 		playerName := matches[1]
-		s.removePlayer(playerName)
-		s.addEvent(EventPlayerLeave, fmt.Sprintf("%s left the game", playerName))
+		if s.removePlayer(playerName) {
+			s.addEvent(EventPlayerLeave, i18n.T("%s left (Bedrock)", playerName))
+		} else {
+			s.addEvent(EventPlayerLeave, i18n.T("%s left the game", playerName))
+		}
+		s.scripts.Fire("on_leave", playerName)
+		s.playerLeft(playerName)
+		return
+	}
+
+	// Geyser only marks Bedrock players: the Floodgate player also joins
+	// and leaves on the Java side, under the Java-side name, and that line
+	// reports it, so a Bedrock player counts once
+	if matches := p.GeyserJoin.FindStringSubmatch(line); len(matches) > 2 {
+		s.addBedrockPlayer(matches[2])
+		return
+	}
+
+	if matches := p.GeyserLeave.FindStringSubmatch(line); len(matches) > 1 {
+		s.removeBedrockPlayer(matches[1])
 		return
 	}
 
 	// Check for player list response
-	if matches := playerListRegex.FindStringSubmatch(line); len(matches) > 2 {
+	if matches := p.PlayerList.FindStringSubmatch(line); len(matches) > 2 {
 		current, _ := strconv.Atoi(matches[1])
 		max, _ := strconv.Atoi(matches[2])
 		s.statsMutex.Lock()
@@ -766,58 +1578,179 @@ func (s *Server) parseOutput(line string) {
 		return
 	}
 
-	// Check for TPS (Forge format: "Mean TPS: 20.00")
-	if matches := tpsRegex.FindStringSubmatch(line); len(matches) > 1 {
-		tps, _ := strconv.ParseFloat(matches[1], 64)
-		s.statsMutex.Lock()
-		s.stats.TPS = tps
-		s.statsMutex.Unlock()
+	// Check for TPS/MSPT (Forge prints both on one line)
+	if s.parseTickStats(line) {
+		return
+	}
+
+	// Check for private messages and emotes, which are not chat
+	if matches := p.Whisper.FindStringSubmatch(line); len(matches) > 3 {
+		if !s.config.HidePrivateMessages {
+			s.addEvent(EventWhisper, fmt.Sprintf("%s -> %s: %s", matches[1], matches[2], matches[3]))
+		}
+		return
+	}
+	if matches := p.Emote.FindStringSubmatch(line); len(matches) > 2 {
+		s.addEvent(EventEmote, fmt.Sprintf("* %s %s", matches[1], matches[2]))
+		return
+	}
+
+	// Check for deaths of online players, and ask where they happened
+	if matches := p.Death.FindStringSubmatch(line); len(matches) > 2 && s.playerOnline(matches[1]) {
+		go s.playerDied(matches[1], matches[2])
 		return
 	}
 
 	// Check for chat
-	if matches := chatRegex.FindStringSubmatch(line); len(matches) > 2 {
+	if matches := p.Chat.FindStringSubmatch(line); len(matches) > 2 {
 		s.addEvent(EventChat, fmt.Sprintf("<%s> %s", matches[1], matches[2]))
+		s.scripts.Fire("on_chat", matches[1], matches[2])
+		s.chatCommand(matches[1], matches[2], line)
 		return
 	}
 
+	// Count connections for throttling; the IP line below is one too.
+	// Behind a proxy without forwarding every connection is the proxy's.
+	if matches := p.Connection.FindStringSubmatch(line); len(matches) > 1 {
+		if ip := logAddress(matches[1]); !s.proxyAddress(ip) {
+			s.connectionAttempt(ip)
+		}
+	}
+
 	// Check for player IP (on join)
-	if matches := ipRegex.FindStringSubmatch(line); len(matches) > 2 {
-		s.updatePlayerIP(matches[1], matches[2])
+	if matches := p.IP.FindStringSubmatch(line); len(matches) > 2 {
+		ip := logAddress(matches[2])
+		if s.proxyAddress(ip) {
+			s.unforwarded(matches[1], "address "+ip)
+			return
+		}
+		s.updatePlayerIP(matches[1], ip)
+		if s.players != nil {
+			s.players.SetIP(matches[1], ip)
+		}
 		return
 	}
 
-	// Check for UUID
-	if matches := uuidRegex.FindStringSubmatch(line); len(matches) > 2 {
+	// Check for UUID; an offline-mode one behind a proxy is made up from
+	// the name, not the player's account
+	if matches := p.UUID.FindStringSubmatch(line); len(matches) > 2 {
+		if s.behindProxy() && proxy.OfflineUUID(matches[2]) {
+			s.unforwarded(matches[1], "offline-mode UUID")
+			return
+		}
 		s.updatePlayerUUID(matches[1], matches[2])
+		if s.players != nil {
+			s.players.SetUUID(matches[1], matches[2])
+		}
 		return
 	}
 
-	// Check for errors/warnings (but not TPS spam)
-	if strings.Contains(line, "Mean TPS:") || strings.Contains(line, "Mean tick time:") {
-		return // Skip TPS output from being logged as events
+	// User-defined patterns win over the generic warning/error fallback
+	for _, rule := range s.eventRules {
+		if message, ok := rule.Match(line); ok {
+			s.addEvent(rule.eventType, message)
+			return
+		}
 	}
 
-	if strings.Contains(line, "[WARN]") || strings.Contains(line, "WARN]") {
+	// Check for errors/warnings
+	if p.Warn.MatchString(line) {
 		s.addEvent(EventWarning, line)
 		return
 	}
 
-	if strings.Contains(line, "[ERROR]") || strings.Contains(line, "ERROR]") {
+	if p.Error.MatchString(line) {
 		s.addEvent(EventError, line)
 		return
 	}
 }
 
+// eventRule is a user rule with its event type resolved
+type eventRule struct {
+	logparse.EventRule
+	eventType EventType
+}
+
+// loadEventRules (re)reads the user's event patterns, so edits apply on the
+// next start without rebuilding
+func (s *Server) loadEventRules() {
+	rules, err := logparse.LoadEventRules(s.config.ServerDir)
+	if err != nil {
+		s.addEvent(EventWarning, err.Error())
+	}
+
+	var loaded []eventRule
+	for _, r := range rules {
+		t, err := ParseEventType(r.Type)
+		if err != nil {
+			s.addEvent(EventWarning, fmt.Sprintf("%s: %v", logparse.EventRulesFile, err))
+			continue
+		}
+		loaded = append(loaded, eventRule{EventRule: r, eventType: t})
+	}
+	s.eventRules = loaded
+	if len(loaded) > 0 {
+		s.addEvent(EventInfo, fmt.Sprintf("Loaded %d custom event patterns", len(loaded)))
+	}
+}
+
+// parseTickStats picks TPS and MSPT out of a line, reporting whether the
+// line was a tick report
+func (s *Server) parseTickStats(line string) bool {
+	p := s.profile
+	var tps, mspt []string
+	if p.TPS != nil {
+		tps = p.TPS.FindStringSubmatch(line)
+	}
+	if p.MSPT != nil {
+		mspt = p.MSPT.FindStringSubmatch(line)
+	}
+	if len(tps) < 2 && len(mspt) < 2 {
+		return false
+	}
+
+	s.statsMutex.Lock()
+	defer s.statsMutex.Unlock()
+	sampled := false
+	if len(mspt) > 1 {
+		s.stats.MSPT, _ = strconv.ParseFloat(mspt[1], 64)
+		if p.TPSFromMSPT && s.stats.MSPT > 0 {
+			s.stats.TPS = min(20, 1000/s.stats.MSPT)
+			sampled = true
+		}
+	}
+	if len(tps) > 1 {
+		s.stats.TPS, _ = strconv.ParseFloat(tps[1], 64)
+		sampled = true
+	}
+	if sampled {
+		s.sampleTPS()
+	}
+	s.scripts.Fire("on_tick", s.stats.TPS, s.stats.MSPT)
+	return true
+}
+
 // monitorProcess monitors the server process
 func (s *Server) monitorProcess() {
 	if s.cmd == nil {
 		return
 	}
 
+	rec := s.recorder
 	err := s.cmd.Wait()
+	s.removePIDFile()
+	s.closeSessions()
+	if rec != nil {
+		status := "exited"
+		if err != nil {
+			status = err.Error()
+		}
+		rec.Write("[mcserver] Server process " + status)
+		rec.Close()
+	}
 
 	if s.stats.Status == StatusStopping {
+		s.recordExit("")
 		s.updateStatus(StatusStopped)
 		return
 	}
@@ -825,45 +1758,64 @@ func (s *Server) monitorProcess() {
 	// Unexpected exit
 	if err != nil {
 		s.updateStatus(StatusCrashed)
-		s.addEvent(EventError, fmt.Sprintf("Server crashed: %v", err))
+		if s.startTimedOut {
+			s.recordExit("startup timeout")
+			s.addEvent(EventError, "Start failed: killed after the startup timeout")
+		} else {
+			s.recordExit(err.Error())
+			s.addEvent(EventError, i18n.T("Server crashed: %v", err))
+		}
+		if unproven := s.unprovenModpack(); unproven != "" {
+			s.addEvent(EventWarning, "Modpack "+unproven)
+		}
+		s.scripts.Fire("on_crash", err.Error())
+		if s.cgroupOOMKills() > s.oomBaseline {
+			s.addEvent(EventError, fmt.Sprintf("Server hit its cgroup memory limit (%d MB) and was killed", s.cgroupMemoryLimit()/1024/1024))
+		}
 
 		if s.config.AutoRestart {
-			s.addEvent(EventRestart, "Auto-restarting in 5 seconds...")
-			time.Sleep(5 * time.Second)
+			delay, ok := s.crashBackoff()
+			if !ok {
+				s.addEvent(EventCritical, fmt.Sprintf("Crash loop: %d crashes in a row, auto-restart paused until the server is started again", s.config.CrashLimit+1))
+				return
+			}
+			s.addEvent(EventRestart, fmt.Sprintf("Auto-restarting in %s...", delay))
+			time.Sleep(delay)
 
 			if s.stats.Status == StatusCrashed {
-				go s.Restart()
+				go s.restartNow()
 			}
 		}
 	} else {
+		s.recordExit("")
 		s.updateStatus(StatusStopped)
 	}
 }
 
-// updateStatsLoop periodically updates server statistics
+// updateStatsLoop updates the resource stats every StatsInterval seconds
 func (s *Server) updateStatsLoop() {
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
 	for {
+		timer := time.NewTimer(time.Duration(max(s.config.StatsInterval, 1)) * time.Second)
 		select {
 		case <-s.ctx.Done():
 			return
-		case <-ticker.C:
+		case <-timer.C:
 			s.updateResourceStats()
+			s.checkDiskSaturation()
 		}
 	}
 }
 
-// updateResourceStats updates CPU, memory, and network stats
+// updateResourceStats updates CPU, memory, and disk stats
 func (s *Server) updateResourceStats() {
+	s.statsMutex.Lock()
+	defer s.statsMutex.Unlock()
+
+	s.updateDiskUtil(time.Now())
 	if s.process == nil {
 		return
 	}
 
-	s.statsMutex.Lock()
-	defer s.statsMutex.Unlock()
-
 	// CPU
 	if cpu, err := s.process.CPUPercent(); err == nil {
 		s.stats.CPUPercent = cpu
@@ -877,21 +1829,21 @@ func (s *Server) updateResourceStats() {
 	// Parse max memory from config
 	s.stats.MemoryMax = parseMemoryString(s.config.RamMax)
 
-	// Network I/O
+	// Disk I/O; a new process starts its counters from zero
 	if ioCounters, err := s.process.IOCounters(); err == nil {
 		now := time.Now()
-		if !s.lastNetCheck.IsZero() {
-			elapsed := now.Sub(s.lastNetCheck).Seconds()
+		if !s.lastDiskCheck.IsZero() && ioCounters.ReadBytes >= s.lastDiskRead && ioCounters.WriteBytes >= s.lastDiskWrite {
+			elapsed := now.Sub(s.lastDiskCheck).Seconds()
 			if elapsed > 0 {
-				s.stats.BandwidthIn = float64(ioCounters.ReadBytes-s.lastBytesIn) / elapsed
-				s.stats.BandwidthOut = float64(ioCounters.WriteBytes-s.lastBytesOut) / elapsed
+				s.stats.DiskReadRate = float64(ioCounters.ReadBytes-s.lastDiskRead) / elapsed
+				s.stats.DiskWriteRate = float64(ioCounters.WriteBytes-s.lastDiskWrite) / elapsed
 			}
 		}
-		s.stats.BytesIn = ioCounters.ReadBytes
-		s.stats.BytesOut = ioCounters.WriteBytes
-		s.lastBytesIn = ioCounters.ReadBytes
-		s.lastBytesOut = ioCounters.WriteBytes
-		s.lastNetCheck = now
+		s.stats.DiskRead = ioCounters.ReadBytes
+		s.stats.DiskWrite = ioCounters.WriteBytes
+		s.lastDiskRead = ioCounters.ReadBytes
+		s.lastDiskWrite = ioCounters.WriteBytes
+		s.lastDiskCheck = now
 	}
 
 	// Update player count
@@ -900,41 +1852,136 @@ func (s *Server) updateResourceStats() {
 
 // backupScheduler runs scheduled backups
 func (s *Server) backupScheduler() {
-	ticker := time.NewTicker(time.Duration(s.config.BackupInterval) * time.Minute)
-	defer ticker.Stop()
-
+	proc := s.cmd
+	offset := s.backupOffset
 	for {
+		// Re-read each round so a reload changes the schedule
+		var timer *time.Timer
+		var due <-chan time.Time
+		if s.config.BackupEnabled && s.config.BackupInterval > 0 {
+			timer = time.NewTimer(time.Duration(s.config.BackupInterval)*time.Minute + offset)
+			due = timer.C
+		}
+
 		select {
 		case <-s.ctx.Done():
 			return
-		case <-ticker.C:
-			if s.stats.Status == StatusRunning {
+		case <-s.reloadSignal():
+		case <-due:
+			// Staggered from the other servers once; later rounds keep the gap
+			offset = 0
+			if s.cmd == proc && s.stats.Status == StatusRunning {
 				s.performBackup()
 			}
 		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if s.cmd != proc {
+			// Restarted; the new process has its own scheduler
+			return
+		}
 	}
 }
 
-// performBackup creates a world backup
+// performBackup creates a scheduled world backup of the selected worlds
 func (s *Server) performBackup() {
-	s.addEvent(EventBackup, "Starting world backup...")
+	s.backup(s.backupSelection())
+}
 
-	// Disable autosave and save
-	s.SendCommand("save-off")
-	s.SendCommand("save-all flush")
-	time.Sleep(2 * time.Second)
+// Backup creates a backup of every world now, pausing autosave while it
+// runs
+func (s *Server) Backup() error {
+	return s.backup(nil)
+}
 
-	// Create backup
-	if s.backupMgr != nil {
-		if err := s.backupMgr.CreateBackup(); err != nil {
-			s.addEvent(EventError, fmt.Sprintf("Backup failed: %v", err))
-		} else {
-			s.addEvent(EventBackup, "Backup completed successfully")
+// backup archives the worlds include accepts, all of them if it is nil
+func (s *Server) backup(include func(name string) bool) (err error) {
+	s.addEvent(EventBackup, i18n.T("Starting world backup..."))
+	s.backingUp.Store(true)
+	defer s.backingUp.Store(false)
+
+	// A panic while archiving fails the backup; autosave is resumed first
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("backup panicked: %v", r)
+			s.addEvent(EventError, i18n.T("Backup failed: %v", err))
 		}
+	}()
+
+	release, err := s.acquireBackupSlot()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	resume := s.pauseSaving()
+	defer resume()
+
+	st := s.GetStats()
+	software := ""
+	if st.Software != nil {
+		software = st.Software.String()
+	}
+	s.backupMgr.SetOrigin(st.Lifetime.Modpack, software)
+	path, err := s.backupMgr.CreateBackupOf(include)
+	if err != nil {
+		s.addEvent(EventError, i18n.T("Backup failed: %v", err))
+		return err
 	}
 
-	// Re-enable autosave
-	s.SendCommand("save-on")
+	message := i18n.T("Backup completed successfully")
+	if manifest, err := backup.ReadManifest(path); err == nil && len(manifest.Skipped) > 0 {
+		message += i18n.T(" (skipped %s)", strings.Join(manifest.Skipped, ", "))
+	}
+	s.addEvent(EventBackup, message)
+	go s.storeBackup(path)
+	return nil
+}
+
+// ListBackups returns the existing world backups
+func (s *Server) ListBackups() ([]backup.BackupInfo, error) {
+	return s.backupMgr.ListBackups()
+}
+
+// RestoreBackup restores the named backup, stopping the server first and
+// starting it again afterwards if it was running
+func (s *Server) RestoreBackup(name string) error {
+	if name == "" || filepath.Base(name) != name {
+		return fmt.Errorf("invalid backup name %q", name)
+	}
+
+	backups, err := s.backupMgr.ListBackups()
+	if err != nil {
+		return err
+	}
+	var path string
+	for _, b := range backups {
+		if b.Name == name {
+			path = b.Path
+		}
+	}
+	if path == "" {
+		return fmt.Errorf("backup %s not found", name)
+	}
+
+	s.wake()
+	wasRunning := s.stats.Status == StatusRunning
+	if wasRunning {
+		s.Stop()
+	}
+
+	s.addEvent(EventBackup, fmt.Sprintf("Restoring %s...", name))
+	if err := s.backupMgr.RestoreBackup(path); err != nil {
+		s.addEvent(EventError, fmt.Sprintf("Restore failed: %v", err))
+		return err
+	}
+	s.addEvent(EventBackup, "Restore completed successfully")
+
+	if wasRunning {
+		return s.Start()
+	}
+	return nil
 }
 
 // Helper functions
@@ -946,6 +1993,15 @@ func (s *Server) updateStatus(status ServerStatus) {
 }
 
 func (s *Server) addEvent(eventType EventType, message string) {
+	if s.catchingUp.Load() {
+		return
+	}
+	s.publishEvent(eventType, message)
+	s.scripts.Fire("on_event", eventType.Name(), message)
+}
+
+// publishEvent records and publishes an event without running script hooks
+func (s *Server) publishEvent(eventType EventType, message string) {
 	event := ServerEvent{
 		Time:    time.Now(),
 		Type:    eventType,
@@ -959,18 +2015,39 @@ func (s *Server) addEvent(eventType EventType, message string) {
 	}
 	s.statsMutex.Unlock()
 
-	select {
-	case s.eventChan <- event:
-	default:
-	}
+	s.events.Publish(extension.Event{Time: event.Time, Type: eventType.Name(), Message: message})
 }
 
-func (s *Server) addPlayer(name string) {
+// addPlayer lists a player who joined, reporting whether Geyser already
+// marked them as a Bedrock player
+func (s *Server) addPlayer(name string) (bedrock bool) {
 	s.statsMutex.Lock()
 	defer s.statsMutex.Unlock()
 
 	for _, p := range s.stats.Players {
 		if p.Name == name {
+			return p.Bedrock
+		}
+	}
+
+	s.stats.Players = append(s.stats.Players, Player{
+		Name:     name,
+		JoinedAt: time.Now(),
+	})
+	s.stats.PlayerCount = len(s.stats.Players)
+	s.recordJoin()
+	return false
+}
+
+// addBedrockPlayer lists a player Geyser connected by their Java-side
+// name, or marks them as Bedrock when the Java join came first
+func (s *Server) addBedrockPlayer(name string) {
+	s.statsMutex.Lock()
+	defer s.statsMutex.Unlock()
+
+	for i, p := range s.stats.Players {
+		if p.Name == name {
+			s.stats.Players[i].Bedrock = true
 			return
 		}
 	}
@@ -978,21 +2055,44 @@ func (s *Server) addPlayer(name string) {
 	s.stats.Players = append(s.stats.Players, Player{
 		Name:     name,
 		JoinedAt: time.Now(),
+		Bedrock:  true,
 	})
 	s.stats.PlayerCount = len(s.stats.Players)
+	s.recordJoin()
+}
+
+// removeBedrockPlayer removes a Bedrock player by Bedrock gamertag, which
+// Floodgate may have prefixed (e.g. ".Steve") and had its spaces replaced
+// with underscores on the Java side
+func (s *Server) removeBedrockPlayer(name string) {
+	s.statsMutex.Lock()
+	defer s.statsMutex.Unlock()
+
+	javaName := strings.ReplaceAll(name, " ", "_")
+	for i, p := range s.stats.Players {
+		if p.Bedrock && (p.Name == name || strings.TrimLeft(p.Name, ".*") == javaName) {
+			s.stats.Players = append(s.stats.Players[:i], s.stats.Players[i+1:]...)
+			break
+		}
+	}
+	s.stats.PlayerCount = len(s.stats.Players)
 }
 
-func (s *Server) removePlayer(name string) {
+// removePlayer drops a player who left, reporting whether they played
+// from Bedrock
+func (s *Server) removePlayer(name string) (bedrock bool) {
 	s.statsMutex.Lock()
 	defer s.statsMutex.Unlock()
 
 	for i, p := range s.stats.Players {
 		if p.Name == name {
+			bedrock = p.Bedrock
 			s.stats.Players = append(s.stats.Players[:i], s.stats.Players[i+1:]...)
 			break
 		}
 	}
 	s.stats.PlayerCount = len(s.stats.Players)
+	return bedrock
 }
 
 func (s *Server) updatePlayerUUID(name, uuid string) {
@@ -1019,21 +2119,9 @@ func (s *Server) updatePlayerIP(name, ip string) {
 	}
 }
 
+// parseMemoryString returns a memory size in bytes, or 0 when it is not
+// one
 func parseMemoryString(mem string) uint64 {
-	mem = strings.ToUpper(strings.TrimSpace(mem))
-
-	multiplier := uint64(1)
-	if strings.HasSuffix(mem, "G") {
-		multiplier = 1024 * 1024 * 1024
-		mem = strings.TrimSuffix(mem, "G")
-	} else if strings.HasSuffix(mem, "M") {
-		multiplier = 1024 * 1024
-		mem = strings.TrimSuffix(mem, "M")
-	} else if strings.HasSuffix(mem, "K") {
-		multiplier = 1024
-		mem = strings.TrimSuffix(mem, "K")
-	}
-
-	value, _ := strconv.ParseUint(mem, 10, 64)
-	return value * multiplier
+	value, _ := ParseMemory(mem)
+	return value
 }
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"fmt"
+
+	"mcserver-manager/internal/jdk"
+	"mcserver-manager/internal/servertype"
+	"mcserver-manager/internal/vanilla"
+)
+
+// detectServerType records the server software and Minecraft version,
+// picks the Java to run them on and warns when it cannot
+func (s *Server) detectServerType() {
+	info := servertype.Detect(s.config.ServerDir)
+	if info.Type == servertype.Vanilla {
+		// A Paper or Purpur jar the manager installed, before its first run
+		// has written the config files that give it away
+		if installed, _ := vanilla.Current(s.config.ServerDir); installed != nil && installed.Flavor != vanilla.Vanilla {
+			info.Type, info.LoaderVersion = installed.Flavor, installed.Build
+			if info.MCVersion == "" {
+				info.MCVersion = installed.ID
+			}
+		}
+	}
+	path, java, err := s.selectJava(info)
+
+	s.statsMutex.Lock()
+	previous := s.stats.Software
+	s.stats.Software = info
+	s.stats.JavaVersion = java
+	s.stats.JavaPath = path
+	s.statsMutex.Unlock()
+
+	if previous == nil || *previous != *info {
+		s.addEvent(EventInfo, fmt.Sprintf("Server software: %s", info))
+	}
+	if s.monitoring {
+		// The Java of a server run by something else is not ours to check
+		return
+	}
+
+	if err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Could not determine the Java version of %s: %v", path, err))
+		return
+	}
+	min, max := servertype.RequiredJava(info)
+	switch {
+	case min > 0 && java < min:
+		s.addEvent(EventError, fmt.Sprintf("Minecraft %s needs Java %d or newer, but %s is Java %d", info.MCVersion, min, path, java))
+	case max > 0 && java > max:
+		s.addEvent(EventWarning, fmt.Sprintf("%s for Minecraft %s needs Java %d, but %s is Java %d", info.Type, info.MCVersion, max, path, java))
+	}
+}
+
+// selectJava returns JavaPath and its version, unless the server needs a
+// Java it is not. Then the lowest fitting version is picked, as modpacks
+// are tested on the oldest Java they allow: from JavaPaths, or else from
+// the Javas installed on this machine.
+func (s *Server) selectJava(info *servertype.Info) (string, int, error) {
+	path := s.config.JavaPath
+	java, err := javaMajor(path)
+	if s.monitoring {
+		return path, java, err
+	}
+
+	min, max := servertype.RequiredJava(info)
+	if min == 0 && max == 0 {
+		return path, java, err
+	}
+	fits := func(v int) bool { return (min == 0 || v >= min) && (max == 0 || v <= max) }
+	if err == nil && fits(java) {
+		return path, java, nil
+	}
+
+	best := 0
+	for v := range s.config.JavaPaths {
+		if fits(v) && (best == 0 || v < best) {
+			best = v
+		}
+	}
+	if best == 0 {
+		installs := jdk.Discover()
+		// Newest first, so the last fit is the lowest version
+		for i := len(installs) - 1; i >= 0; i-- {
+			if fits(installs[i].Major) {
+				install := installs[i]
+				s.addEvent(EventInfo, fmt.Sprintf("Using Java %s (%s) for %s %s", install.Version, install.Path, info.Type, info.MCVersion))
+				return install.Path, install.Major, nil
+			}
+		}
+		return path, java, err
+	}
+
+	other := s.config.JavaPaths[best]
+	v, otherErr := javaMajor(other)
+	if otherErr != nil || !fits(v) {
+		s.addEvent(EventWarning, fmt.Sprintf("Not using %s for Java %d: %s", other, best, describeJava(v, otherErr)))
+		return path, java, err
+	}
+	s.addEvent(EventInfo, fmt.Sprintf("Using Java %d (%s) for %s %s", v, other, info.Type, info.MCVersion))
+	return other, v, nil
+}
+
+// describeJava explains a Java path that turned out unusable
+func describeJava(version int, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("it is Java %d", version)
+}
+
+// javaPath returns the Java the server runs on, as picked on start
+func (s *Server) javaPath() string {
+	s.statsMutex.RLock()
+	defer s.statsMutex.RUnlock()
+	if s.stats.JavaPath != "" {
+		return s.stats.JavaPath
+	}
+	return s.config.JavaPath
+}
+
+// javaMajor returns the major version of a java executable
+func javaMajor(javaPath string) (int, error) {
+	install, err := jdk.Probe(javaPath)
+	return install.Major, err
+}
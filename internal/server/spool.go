@@ -0,0 +1,185 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// Lines kept in memory before the spool starts writing to disk
+	spoolMemLines = 4096
+	// Disk spill cap; lines past it are counted as dropped
+	spoolMaxBytes = 64 << 20
+
+	spoolFile = ".mcserver/console-spill.log"
+)
+
+// consoleSpool sits between readOutput and outputChan so a slow consumer
+// never makes the reader throw lines away. Lines go to a ring buffer; once
+// it is full they are appended to a spill file and read back in order, and
+// only when the spill file reaches its cap are lines dropped.
+type consoleSpool struct {
+	mu    sync.Mutex
+	ready *sync.Cond
+
+	ring  [spoolMemLines]string
+	head  int // index of the oldest line
+	count int
+
+	path      string
+	spillW    *os.File
+	spillR    *bufio.Reader
+	spillFile *os.File
+	spilled   int   // lines on disk not yet read back
+	spillSize int64 // bytes written since the file was last emptied
+
+	dropped uint64
+	closed  bool
+}
+
+func newConsoleSpool(serverDir string) *consoleSpool {
+	c := &consoleSpool{path: filepath.Join(serverDir, spoolFile)}
+	c.ready = sync.NewCond(&c.mu)
+	return c
+}
+
+// push queues a line; it never blocks on the consumer. The error reports
+// that the line was dropped.
+func (c *consoleSpool) push(line string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.ready.Signal()
+
+	// Once spilling, everything goes to disk until it is drained, so lines
+	// come out in the order they arrived
+	if c.spilled == 0 && c.count < len(c.ring) {
+		c.ring[(c.head+c.count)%len(c.ring)] = line
+		c.count++
+		return nil
+	}
+
+	if err := c.spill(line); err != nil {
+		c.dropped++
+		return err
+	}
+	return nil
+}
+
+func (c *consoleSpool) spill(line string) error {
+	if c.spillSize+int64(len(line))+1 > spoolMaxBytes {
+		return fmt.Errorf("console spill file full (%d MiB)", spoolMaxBytes>>20)
+	}
+	if c.spillW == nil {
+		if err := c.openSpill(); err != nil {
+			return err
+		}
+	}
+	n, err := c.spillW.WriteString(line + "\n")
+	c.spillSize += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write console spill file: %w", err)
+	}
+	c.spilled++
+	return nil
+}
+
+func (c *consoleSpool) openSpill() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create console spill file: %w", err)
+	}
+	w, err := os.OpenFile(c.path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create console spill file: %w", err)
+	}
+	r, err := os.Open(c.path)
+	if err != nil {
+		w.Close()
+		return fmt.Errorf("failed to open console spill file: %w", err)
+	}
+	c.spillW, c.spillFile, c.spillR = w, r, bufio.NewReader(r)
+	c.spillSize = 0
+	return nil
+}
+
+// pop returns the oldest queued line, waiting until there is one. It
+// returns false once the spool is closed.
+func (c *consoleSpool) pop() (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for c.count == 0 && c.spilled == 0 && !c.closed {
+		c.ready.Wait()
+	}
+	if c.closed {
+		return "", false
+	}
+
+	// The ring always holds older lines than the spill file
+	if c.count > 0 {
+		line := c.ring[c.head]
+		c.ring[c.head] = ""
+		c.head = (c.head + 1) % len(c.ring)
+		c.count--
+		return line, true
+	}
+
+	line, err := c.spillR.ReadString('\n')
+	c.spilled--
+	if err != nil {
+		// The file was cut short under us; whatever is left is lost
+		c.dropped += uint64(c.spilled + 1)
+		c.spilled = 0
+	}
+	if c.spilled == 0 {
+		c.closeSpill()
+	}
+	return strings.TrimSuffix(line, "\n"), true
+}
+
+// close wakes pop for good and removes any spill file
+func (c *consoleSpool) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.spillW != nil {
+		c.closeSpill()
+	}
+	c.ready.Broadcast()
+}
+
+// closeSpill removes the drained spill file so the next burst starts empty
+func (c *consoleSpool) closeSpill() {
+	c.spillW.Close()
+	c.spillFile.Close()
+	os.Remove(c.path)
+	c.spillW, c.spillFile, c.spillR = nil, nil, nil
+	c.spillSize = 0
+}
+
+// droppedLines reports how many lines were lost because the spill file was
+// full or unwritable
+func (c *consoleSpool) droppedLines() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.dropped
+}
+
+// pumpOutput feeds queued lines to outputChan, blocking on the consumer
+// instead of the process reading the server's pipes
+func (s *Server) pumpOutput() {
+	for {
+		line, ok := s.spool.pop()
+		if !ok {
+			return
+		}
+		select {
+		case s.outputChan <- line:
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// stateFile keeps the lifetime counters across manager restarts, relative
+// to the server dir
+const stateFile = ".mcserver/state.json"
+
+// How often a running server's uptime is written out, bounding what a
+// host crash loses
+const stateCheckpoint = time.Minute
+
+// Lifetime is what the manager remembers about a server across its own
+// restarts and host reboots
+type Lifetime struct {
+	// First start under the manager
+	Since    time.Time `json:"since,omitzero"`
+	Starts   int       `json:"starts"`
+	Restarts int       `json:"restarts"`
+	Crashes  int       `json:"crashes"`
+
+	LastCrash       time.Time `json:"lastCrash,omitzero"`
+	LastCrashReason string    `json:"lastCrashReason,omitempty"`
+
+	// Time spent with the server process running
+	Uptime      time.Duration `json:"uptime"`
+	PlayerJoins int           `json:"playerJoins"`
+	PeakPlayers int           `json:"peakPlayers"`
+
+	// Modpack file installed by the last start, and the last one the
+	// server finished starting with
+	Modpack         string `json:"modpack,omitempty"`
+	LastGoodModpack string `json:"lastGoodModpack,omitempty"`
+
+	// When a backup or export turned autosave off, until it turns it back
+	// on; set after a run died in between
+	SavesOff time.Time `json:"savesOff,omitzero"`
+}
+
+// loadState picks up the counters of earlier manager runs. Only New'd
+// servers persist them; smoke-boot servers start from zero.
+func (s *Server) loadState() {
+	s.statePath = filepath.Join(s.config.ServerDir, stateFile)
+
+	data, err := os.ReadFile(s.statePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			s.addEvent(EventWarning, fmt.Sprintf("Could not read %s: %v", stateFile, err))
+		}
+		return
+	}
+	var lifetime Lifetime
+	if err := json.Unmarshal(data, &lifetime); err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Could not parse %s, counting from zero: %v", stateFile, err))
+		return
+	}
+
+	s.statsMutex.Lock()
+	s.stats.Lifetime = lifetime
+	s.stats.Restarts = lifetime.Restarts
+	s.statsMutex.Unlock()
+}
+
+// updateState changes the lifetime counters and writes them out
+func (s *Server) updateState(change func(l *Lifetime)) {
+	s.statsMutex.Lock()
+	change(&s.stats.Lifetime)
+	s.stats.Restarts = s.stats.Lifetime.Restarts
+	s.statsMutex.Unlock()
+	s.saveState()
+}
+
+// saveState writes the lifetime counters, with the running process's
+// uptime so far added in
+func (s *Server) saveState() {
+	if s.statePath == "" {
+		return
+	}
+	s.stateMutex.Lock()
+	defer s.stateMutex.Unlock()
+
+	s.statsMutex.Lock()
+	s.accrueUptime()
+	data, err := json.MarshalIndent(s.stats.Lifetime, "", "  ")
+	s.statsMutex.Unlock()
+	if err != nil {
+		return
+	}
+
+	tmp := s.statePath + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(s.statePath), 0755); err == nil {
+		if err = os.WriteFile(tmp, data, 0644); err == nil {
+			err = os.Rename(tmp, s.statePath)
+		}
+	}
+	if err != nil && !s.stateWarned {
+		s.stateWarned = true
+		s.addEvent(EventWarning, fmt.Sprintf("Could not save %s: %v", stateFile, err))
+	}
+}
+
+// accrueUptime adds the time since the last checkpoint of a running
+// process to the lifetime uptime; statsMutex must be held
+func (s *Server) accrueUptime() {
+	if s.uptimeMark.IsZero() {
+		return
+	}
+	now := time.Now()
+	s.stats.Lifetime.Uptime += now.Sub(s.uptimeMark)
+	s.uptimeMark = now
+}
+
+// recordLaunch counts a start of the server process
+func (s *Server) recordLaunch() {
+	s.updateState(func(l *Lifetime) {
+		now := time.Now()
+		if l.Since.IsZero() {
+			l.Since = now
+		}
+		l.Starts++
+		s.uptimeMark = now
+	})
+}
+
+// recordExit closes the uptime of the process that just ended, counting it
+// as a crash when reason is set
+func (s *Server) recordExit(reason string) {
+	s.updateState(func(l *Lifetime) {
+		s.accrueUptime()
+		s.uptimeMark = time.Time{}
+		if reason != "" {
+			l.Crashes++
+			l.LastCrash = time.Now()
+			l.LastCrashReason = reason
+		}
+	})
+}
+
+// recordStarted marks the installed modpack as known good
+func (s *Server) recordStarted() {
+	s.updateState(func(l *Lifetime) {
+		if l.Modpack != "" {
+			l.LastGoodModpack = l.Modpack
+		}
+	})
+}
+
+// recordModpack notes the modpack file an install used
+func (s *Server) recordModpack(path string) {
+	s.updateState(func(l *Lifetime) {
+		l.Modpack = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	})
+}
+
+// recordJoin counts a join towards the lifetime player stats, saved with
+// the next checkpoint; statsMutex must be held
+func (s *Server) recordJoin() {
+	s.stats.Lifetime.PlayerJoins++
+	s.stats.Lifetime.PeakPlayers = max(s.stats.Lifetime.PeakPlayers, len(s.stats.Players))
+}
+
+// stateLoop checkpoints the lifetime counters while the process runs
+func (s *Server) stateLoop() {
+	proc := s.cmd
+	ticker := time.NewTicker(stateCheckpoint)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if s.cmd != proc {
+				return
+			}
+			s.saveState()
+		}
+	}
+}
+
+// unprovenModpack describes a modpack installed since the last good start,
+// or returns "" when the installed one has started fine before
+func (s *Server) unprovenModpack() string {
+	s.statsMutex.RLock()
+	defer s.statsMutex.RUnlock()
+	l := s.stats.Lifetime
+	if l.Modpack == "" || l.LastGoodModpack == "" || l.Modpack == l.LastGoodModpack {
+		return ""
+	}
+	return fmt.Sprintf("%s has not started successfully yet; the last known good modpack is %s", l.Modpack, l.LastGoodModpack)
+}
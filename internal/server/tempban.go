@@ -0,0 +1,267 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"mcserver-manager/internal/playerdb"
+	"mcserver-manager/internal/stats"
+)
+
+// banTimeFormat is how banned-players.json writes its dates
+const banTimeFormat = "2006-01-02 15:04:05 -0700"
+
+// How often expired temporary bans are looked for
+const tempBanSweep = time.Minute
+
+// banEntry is one entry of banned-players.json
+type banEntry struct {
+	UUID    string `json:"uuid"`
+	Name    string `json:"name"`
+	Created string `json:"created"`
+	Source  string `json:"source"`
+	Expires string `json:"expires"`
+	Reason  string `json:"reason"`
+}
+
+// TempBan bans a player for d. On a running server it issues ban and the
+// manager pardons the player when the time is up; offline the entry is
+// written to banned-players.json with its expiry, which the server honors
+// itself.
+func (s *Server) TempBan(name string, d time.Duration, reason string) (time.Time, error) {
+	if err := s.checkPlayerName(name); err != nil {
+		return time.Time{}, err
+	}
+	if s.players == nil {
+		return time.Time{}, fmt.Errorf("the player database is unavailable")
+	}
+	now := time.Now()
+	until := now.Add(d)
+	shown := reason
+	if shown == "" {
+		shown = "Banned"
+	}
+	shown += " (until " + until.Format("2006-01-02 15:04") + ")"
+
+	if s.stats.Status == StatusRunning {
+		if err := s.SendCommand("ban " + name + " " + shown); err != nil {
+			return time.Time{}, err
+		}
+	} else if err := s.writeBanEntry(name, now, until, shown); err != nil {
+		return time.Time{}, err
+	}
+
+	if err := s.players.SetTempBan(name, &playerdb.TempBan{Since: now, Until: until, Reason: reason}); err != nil {
+		return time.Time{}, err
+	}
+	s.addEvent(EventInfo, fmt.Sprintf("Banned %s until %s", name, until.Format("2006-01-02 15:04")))
+	return until, nil
+}
+
+// LiftTempBan pardons a temporarily banned player early
+func (s *Server) LiftTempBan(name string) error {
+	if err := s.checkPlayerName(name); err != nil {
+		return err
+	}
+	if s.players == nil {
+		return fmt.Errorf("the player database is unavailable")
+	}
+	if p, ok := s.players.Get(name); !ok || p.TempBan == nil {
+		return fmt.Errorf("%s is not temporarily banned", name)
+	}
+
+	if s.stats.Status == StatusRunning {
+		if err := s.SendCommand("pardon " + name); err != nil {
+			return err
+		}
+	} else if err := s.removeBanEntry(name); err != nil {
+		return err
+	}
+	return s.players.SetTempBan(name, nil)
+}
+
+// tempBanLoop pardons players whose temporary ban ran out while one server
+// process runs
+func (s *Server) tempBanLoop() {
+	proc := s.cmd
+	ticker := time.NewTicker(tempBanSweep)
+	defer ticker.Stop()
+
+	for {
+		if s.cmd != proc {
+			return
+		}
+		if s.players != nil && s.stats.Status == StatusRunning {
+			s.pardonExpired()
+		}
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pardonExpired lifts the temporary bans that have run out
+func (s *Server) pardonExpired() {
+	now := time.Now()
+	for _, p := range s.players.TempBans() {
+		if p.TempBan.Until.After(now) {
+			// Sorted by expiry, so the rest have not run out either
+			return
+		}
+		if err := s.SendCommand("pardon " + p.Name); err != nil {
+			s.addEvent(EventWarning, fmt.Sprintf("Could not lift the temporary ban of %s: %v", p.Name, err))
+			return
+		}
+		if err := s.players.SetTempBan(p.Name, nil); err != nil {
+			s.addEvent(EventWarning, err.Error())
+			return
+		}
+		s.addEvent(EventInfo, fmt.Sprintf("Temporary ban of %s ran out, pardoned", p.Name))
+	}
+}
+
+// writeBanEntry adds or replaces a player's entry in banned-players.json
+func (s *Server) writeBanEntry(name string, created, until time.Time, reason string) error {
+	uuid, err := s.playerUUID(name)
+	if err != nil {
+		return fmt.Errorf("cannot ban %s while the server is stopped: %w", name, err)
+	}
+	entries, err := s.readBanList()
+	if err != nil {
+		return err
+	}
+	entries = removeBan(entries, name)
+	entries = append(entries, banEntry{
+		UUID:    uuid,
+		Name:    name,
+		Created: created.Format(banTimeFormat),
+		Source:  "mcserver",
+		Expires: until.Format(banTimeFormat),
+		Reason:  reason,
+	})
+	return s.writeBanList(entries)
+}
+
+// removeBanEntry drops a player's entry from banned-players.json
+func (s *Server) removeBanEntry(name string) error {
+	entries, err := s.readBanList()
+	if err != nil {
+		return err
+	}
+	return s.writeBanList(removeBan(entries, name))
+}
+
+func (s *Server) readBanList() ([]banEntry, error) {
+	data, err := os.ReadFile(filepath.Join(s.config.ServerDir, "banned-players.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []banEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse banned-players.json: %w", err)
+	}
+	return entries, nil
+}
+
+func (s *Server) writeBanList(entries []banEntry) error {
+	if entries == nil {
+		entries = []banEntry{}
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.config.ServerDir, "banned-players.json"), data, 0644)
+}
+
+// checkPlayerName refuses anything but a single player name, so a name
+// pasted into ban or pardon can neither be a selector such as @a nor carry
+// more words
+func (s *Server) checkPlayerName(name string) error {
+	if s.profile == nil || !s.profile.PlayerName.MatchString(name) {
+		return fmt.Errorf("%q is not a player name", name)
+	}
+	return nil
+}
+
+func removeBan(entries []banEntry, name string) []banEntry {
+	kept := entries[:0]
+	for _, e := range entries {
+		if !strings.EqualFold(e.Name, name) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "tempban",
+		Usage: "tempban <player> <duration> [reason] | tempban list | tempban lift <player>",
+		Help:  "Ban a player for a while (30m, 12h, 7d, 2w), list temporary bans, or lift one early",
+		// It bans and pardons around the caller's command policy, so it is
+		// left to admins like the other actions that touch the server
+		Admin: true,
+		Run: func(s *Server, args []string) (string, error) {
+			if len(args) == 0 {
+				return "", fmt.Errorf("usage: tempban <player> <duration> [reason] | tempban list | tempban lift <player>")
+			}
+
+			switch args[0] {
+			case "list":
+				if s.players == nil {
+					return "", fmt.Errorf("the player database is unavailable")
+				}
+				banned := s.players.TempBans()
+				if len(banned) == 0 {
+					return "No temporary bans", nil
+				}
+				lines := make([]string, len(banned))
+				for i, p := range banned {
+					line := fmt.Sprintf("%s until %s", p.Name, p.TempBan.Until.Format("2006-01-02 15:04"))
+					if left := time.Until(p.TempBan.Until); left > 0 {
+						line += " (" + stats.FormatDurationShort(left) + " left)"
+					} else {
+						line += " (ran out, pardoned once the server runs)"
+					}
+					if p.TempBan.Reason != "" {
+						line += ": " + p.TempBan.Reason
+					}
+					lines[i] = line
+				}
+				return strings.Join(lines, "\n"), nil
+
+			case "lift":
+				if len(args) != 2 {
+					return "", fmt.Errorf("usage: tempban lift <player>")
+				}
+				if err := s.LiftTempBan(args[1]); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("Lifted the ban of %s", args[1]), nil
+			}
+
+			if len(args) < 2 {
+				return "", fmt.Errorf("usage: tempban <player> <duration> [reason]")
+			}
+			d, err := stats.ParseDuration(args[1])
+			if err != nil {
+				return "", err
+			}
+			until, err := s.TempBan(args[0], d, strings.Join(args[2:], " "))
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Banned %s until %s", args[0], until.Format("2006-01-02 15:04")), nil
+		},
+	})
+}
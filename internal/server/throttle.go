@@ -0,0 +1,101 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// connThrottle counts recent connections per IP for ThrottleJoins and
+// across all IPs for FloodJoins
+type connThrottle struct {
+	mu       sync.Mutex
+	attempts map[string][]time.Time
+	all      []time.Time
+	blocked  map[string]bool
+	flooding bool
+}
+
+// connectionAttempt counts a connection from ip and blocks the IP once it
+// connects more often than the configured rate
+func (s *Server) connectionAttempt(ip string) {
+	joins, flood := s.config.ThrottleJoins, s.config.FloodJoins
+	// Replayed connections happened long ago
+	if (joins <= 0 && flood <= 0) || s.catchingUp.Load() {
+		return
+	}
+	window := time.Duration(s.config.ThrottleWindow) * time.Second
+	if window <= 0 {
+		window = time.Minute
+	}
+
+	t := &s.throttle
+	now := time.Now()
+	t.mu.Lock()
+	if t.attempts == nil {
+		t.attempts = make(map[string][]time.Time)
+		t.blocked = make(map[string]bool)
+	}
+	t.all = append(recentTimes(t.all, now.Add(-window)), now)
+	for addr, times := range t.attempts {
+		if times = recentTimes(times, now.Add(-window)); len(times) == 0 {
+			// Quiet long enough to be blocked again if it comes back
+			delete(t.attempts, addr)
+			delete(t.blocked, addr)
+		} else {
+			t.attempts[addr] = times
+		}
+	}
+	t.attempts[ip] = append(t.attempts[ip], now)
+	count := len(t.attempts[ip])
+
+	startFlood := flood > 0 && len(t.all) > flood && !t.flooding
+	if startFlood {
+		t.flooding = true
+	} else if t.flooding && len(t.all) <= flood/2 {
+		t.flooding = false
+	}
+	// The proxy or a local bot connects from loopback; banning it would
+	// lock everyone out
+	block := joins > 0 && count > joins && !t.blocked[ip] && !net.ParseIP(ip).IsLoopback()
+	if block {
+		t.blocked[ip] = true
+	}
+	total := len(t.all)
+	t.mu.Unlock()
+
+	if startFlood {
+		s.addEvent(EventCritical, fmt.Sprintf("Join flood: %d connections in the last %s", total, window))
+	}
+	if block {
+		go s.blockIP(ip, count, window)
+	}
+}
+
+// blockIP bans ip on the server and, with ThrottleFirewall, in the firewall
+func (s *Server) blockIP(ip string, count int, window time.Duration) {
+	s.addEvent(EventWarning, fmt.Sprintf("Blocking %s after %d connections in %s", ip, count, window))
+	if err := s.SendCommand("ban-ip " + ip + " Too many connections"); err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Could not ban %s: %v", ip, err))
+	}
+
+	args := strings.Fields(strings.ReplaceAll(s.config.ThrottleFirewall, "{ip}", ip))
+	if len(args) == 0 {
+		return
+	}
+	if out, err := exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Firewall command for %s failed: %v: %s", ip, err, strings.TrimSpace(string(out))))
+	}
+}
+
+// recentTimes drops the times before since from the front of times
+func recentTimes(times []time.Time, since time.Time) []time.Time {
+	i := 0
+	for i < len(times) && times[i].Before(since) {
+		i++
+	}
+	return times[i:]
+}
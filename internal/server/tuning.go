@@ -0,0 +1,76 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// priorityClasses are the accepted PriorityClass values (Windows)
+var priorityClasses = []string{"idle", "below-normal", "normal", "above-normal", "high", "realtime"}
+
+// maxCPUs bounds CPU numbers to what an affinity mask can hold
+// (CPU_SETSIZE on Linux)
+const maxCPUs = 1024
+
+// ParseCPUList parses a taskset-style CPU list such as "0-3,6"
+func ParseCPUList(list string) ([]int, error) {
+	var cpus []int
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(lo)
+		if err != nil || first < 0 {
+			return nil, fmt.Errorf("invalid CPU %q in %q", lo, list)
+		}
+		last := first
+		if isRange {
+			last, err = strconv.Atoi(hi)
+			if err != nil || last < first {
+				return nil, fmt.Errorf("invalid CPU range %q in %q", part, list)
+			}
+		}
+		if last >= maxCPUs {
+			return nil, fmt.Errorf("CPU %d in %q is above the limit of %d", last, list, maxCPUs-1)
+		}
+		for cpu := first; cpu <= last; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	if len(cpus) == 0 {
+		return nil, fmt.Errorf("empty CPU list")
+	}
+	return cpus, nil
+}
+
+// ValidatePriorityClass checks a PriorityClass value
+func ValidatePriorityClass(class string) error {
+	for _, c := range priorityClasses {
+		if class == c {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid priority class %q (%s)", class, strings.Join(priorityClasses, ", "))
+}
+
+// startTuned starts the JVM pinned and prioritised. Where the platform
+// allows it the settings are in place before exec, so no thread of the JVM
+// ever runs outside them.
+func (s *Server) startTuned() error {
+	if s.config.CPUAffinity == "" && s.config.Nice == 0 && s.config.PriorityClass == "" {
+		return s.cmd.Start()
+	}
+	tuneErr, err := startWithTuning(s.cmd, s.config)
+	if err != nil {
+		return err
+	}
+	if tuneErr != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Could not apply CPU affinity/priority: %v", tuneErr))
+		return nil
+	}
+	s.addEvent(EventInfo, "Applied CPU affinity/priority settings")
+	return nil
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+// startWithTuning forks the JVM from a thread that already has the
+// affinity mask and nice level, so the child inherits them at exec instead
+// of getting them after its first threads are running. The thread is
+// locked and never unlocked, so the runtime retires it instead of reusing
+// it with the server's settings. PriorityClass is Windows-only.
+func startWithTuning(cmd *exec.Cmd, config *Config) (tuneErr, err error) {
+	type result struct{ tuneErr, err error }
+	done := make(chan result, 1)
+	go func() {
+		runtime.LockOSThread()
+		tuneErr := tuneThread(config)
+		done <- result{tuneErr, cmd.Start()}
+	}()
+	r := <-done
+	return r.tuneErr, r.err
+}
+
+// tuneThread sets the affinity mask and nice level of the calling thread;
+// both are per-thread on Linux
+func tuneThread(config *Config) error {
+	if config.CPUAffinity != "" {
+		cpus, err := ParseCPUList(config.CPUAffinity)
+		if err != nil {
+			return err
+		}
+		var set unix.CPUSet
+		for _, cpu := range cpus {
+			set.Set(cpu)
+		}
+		if err := unix.SchedSetaffinity(0, &set); err != nil {
+			return fmt.Errorf("failed to set CPU affinity: %w", err)
+		}
+	}
+	if config.Nice != 0 {
+		if err := unix.Setpriority(unix.PRIO_PROCESS, unix.Gettid(), config.Nice); err != nil {
+			return fmt.Errorf("failed to set nice level: %w", err)
+		}
+	}
+	return nil
+}
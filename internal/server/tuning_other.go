@@ -0,0 +1,14 @@
+//go:build !linux && !windows
+
+package server
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// startWithTuning is only implemented for Linux and Windows; the server
+// starts without the settings
+func startWithTuning(cmd *exec.Cmd, config *Config) (tuneErr, err error) {
+	return fmt.Errorf("CPU affinity and priority are not supported on this platform"), cmd.Start()
+}
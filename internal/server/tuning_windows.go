@@ -0,0 +1,66 @@
+package server
+
+import (
+	"fmt"
+	"os/exec"
+
+	"golang.org/x/sys/windows"
+)
+
+var procSetProcessAffinityMask = windows.NewLazySystemDLL("kernel32.dll").NewProc("SetProcessAffinityMask")
+
+var priorityClassValues = map[string]uint32{
+	"idle":         windows.IDLE_PRIORITY_CLASS,
+	"below-normal": windows.BELOW_NORMAL_PRIORITY_CLASS,
+	"normal":       windows.NORMAL_PRIORITY_CLASS,
+	"above-normal": windows.ABOVE_NORMAL_PRIORITY_CLASS,
+	"high":         windows.HIGH_PRIORITY_CLASS,
+	"realtime":     windows.REALTIME_PRIORITY_CLASS,
+}
+
+// startWithTuning starts the JVM and then tunes it. Affinity and priority
+// class are per-process on Windows, so threads already running follow them.
+func startWithTuning(cmd *exec.Cmd, config *Config) (tuneErr, err error) {
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return applyProcessTuning(cmd.Process.Pid, config), nil
+}
+
+// applyProcessTuning sets the process affinity mask and priority class.
+// Nice is Linux-only.
+func applyProcessTuning(pid int, config *Config) error {
+	handle, err := windows.OpenProcess(windows.PROCESS_SET_INFORMATION|windows.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return fmt.Errorf("failed to open server process: %w", err)
+	}
+	defer windows.CloseHandle(handle)
+
+	if config.CPUAffinity != "" {
+		cpus, err := ParseCPUList(config.CPUAffinity)
+		if err != nil {
+			return err
+		}
+		var mask uintptr
+		for _, cpu := range cpus {
+			if cpu >= 64 {
+				return fmt.Errorf("CPU %d is outside the 64-CPU affinity mask", cpu)
+			}
+			mask |= 1 << uint(cpu)
+		}
+		if ok, _, err := procSetProcessAffinityMask.Call(uintptr(handle), mask); ok == 0 {
+			return fmt.Errorf("failed to set CPU affinity: %w", err)
+		}
+	}
+
+	if config.PriorityClass != "" {
+		class, ok := priorityClassValues[config.PriorityClass]
+		if !ok {
+			return ValidatePriorityClass(config.PriorityClass)
+		}
+		if err := windows.SetPriorityClass(handle, class); err != nil {
+			return fmt.Errorf("failed to set priority class: %w", err)
+		}
+	}
+	return nil
+}
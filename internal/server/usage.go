@@ -0,0 +1,228 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// usageFile holds the resources the server used per day, relative to the
+// server dir
+const usageFile = ".mcserver/usage.json"
+
+// Days of usage kept
+const usageKeep = 366
+
+// How often usage is added up while the server runs
+const usageInterval = 30 * time.Second
+
+// UsageDay is what the server process used on one day
+type UsageDay struct {
+	Date string `json:"date"`
+	// Seconds the process was alive, and of those with nobody online and
+	// suspended
+	Running   float64 `json:"running"`
+	Empty     float64 `json:"empty"`
+	Suspended float64 `json:"suspended"`
+	// CPU time in seconds, user and system
+	CPU float64 `json:"cpu"`
+	// Resident memory over time, in GiB-hours
+	MemoryHours float64 `json:"memory_hours"`
+	// Hours of players online, summed over the players
+	PlayerHours float64 `json:"player_hours"`
+}
+
+// usageLoop adds up the server process's running time, CPU time and
+// memory-hours while one process runs, and writes the daily totals out
+func (s *Server) usageLoop() {
+	proc := s.cmd
+	ticker := time.NewTicker(usageInterval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	var lastCPU float64
+	if s.process == nil {
+		return
+	}
+	if times, err := s.process.Times(); err == nil {
+		lastCPU = times.User + times.System
+	}
+	warned := false
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if s.cmd != proc || s.process == nil {
+			return
+		}
+
+		now := time.Now()
+		// A stalled loop (a suspended laptop) is not running time
+		elapsed := min(now.Sub(last), 2*usageInterval).Seconds()
+		last = now
+
+		var cpu float64
+		if times, err := s.process.Times(); err == nil {
+			cpu = max(times.User+times.System-lastCPU, 0)
+			lastCPU = times.User + times.System
+		}
+		st := s.GetStats()
+		err := updateUsage(s.config.ServerDir, now, func(day *UsageDay) {
+			day.Running += elapsed
+			if st.PlayerCount == 0 {
+				day.Empty += elapsed
+			}
+			if st.Status == StatusSuspended {
+				day.Suspended += elapsed
+			}
+			day.CPU += cpu
+			day.MemoryHours += float64(st.MemoryUsed) / (1 << 30) * elapsed / 3600
+			day.PlayerHours += float64(st.PlayerCount) * elapsed / 3600
+		})
+		// Report a failing disk once until it recovers
+		if err != nil && !warned {
+			s.addEvent(EventWarning, fmt.Sprintf("Could not save %s: %v", usageFile, err))
+		}
+		warned = err != nil
+	}
+}
+
+// updateUsage changes the usage of the day now falls on and writes the
+// file, dropping days past usageKeep
+func updateUsage(serverDir string, now time.Time, change func(day *UsageDay)) error {
+	days, err := ReadUsage(serverDir)
+	if err != nil {
+		return err
+	}
+	date := now.Format("2006-01-02")
+	if len(days) == 0 || days[len(days)-1].Date != date {
+		days = append(days, UsageDay{Date: date})
+	}
+	change(&days[len(days)-1])
+	if len(days) > usageKeep {
+		days = days[len(days)-usageKeep:]
+	}
+
+	data, err := json.MarshalIndent(days, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(serverDir, usageFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ReadUsage returns the recorded days, oldest first
+func ReadUsage(serverDir string) ([]UsageDay, error) {
+	data, err := os.ReadFile(filepath.Join(serverDir, usageFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var days []UsageDay
+	if err := json.Unmarshal(data, &days); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", usageFile, err)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Date < days[j].Date })
+	return days, nil
+}
+
+// UsageReport describes the last days of usage. With costPerHour, the
+// hosting cost of the time the server ran is estimated per day and week,
+// and how much of it was spent with nobody online.
+func UsageReport(serverDir string, days int, costPerHour float64) (string, error) {
+	all, err := ReadUsage(serverDir)
+	if err != nil {
+		return "", err
+	}
+	since := time.Now().AddDate(0, 0, -days+1).Format("2006-01-02")
+	var recent []UsageDay
+	for _, day := range all {
+		if day.Date >= since {
+			recent = append(recent, day)
+		}
+	}
+	if len(recent) == 0 {
+		return fmt.Sprintf("No usage recorded in the last %s", plural(days, "day")), nil
+	}
+
+	hours := func(seconds float64) string { return fmt.Sprintf("%.1fh", seconds/3600) }
+	cost := func(seconds float64) string { return fmt.Sprintf("$%.2f", seconds/3600*costPerHour) }
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-10s %7s %7s %7s %7s %9s %9s", "DATE", "UP", "EMPTY", "ASLEEP", "CPU", "MEM GB-H", "PLAYER-H")
+	if costPerHour > 0 {
+		fmt.Fprintf(&b, " %8s", "COST")
+	}
+	b.WriteString("\n")
+	var total UsageDay
+	for _, day := range recent {
+		fmt.Fprintf(&b, "%-10s %7s %7s %7s %7s %9.1f %9.1f", day.Date, hours(day.Running), hours(day.Empty), hours(day.Suspended), hours(day.CPU), day.MemoryHours, day.PlayerHours)
+		if costPerHour > 0 {
+			fmt.Fprintf(&b, " %8s", cost(day.Running))
+		}
+		b.WriteString("\n")
+		total.Running += day.Running
+		total.Empty += day.Empty
+		total.Suspended += day.Suspended
+		total.CPU += day.CPU
+		total.MemoryHours += day.MemoryHours
+		total.PlayerHours += day.PlayerHours
+	}
+
+	n := float64(len(recent))
+	fmt.Fprintf(&b, "\nUp %s a day on average, %.0f%% of it empty", hours(total.Running/n), percentOf(total.Empty, total.Running))
+	if total.Running > 0 {
+		fmt.Fprintf(&b, "; used %.2f CPU cores and %.1f GB on average while up", total.CPU/total.Running, total.MemoryHours/(total.Running/3600))
+	}
+	if costPerHour > 0 {
+		perDay := total.Running / n
+		fmt.Fprintf(&b, "\nEstimated cost at $%s/hour: %s a day, %s a week (always on: %s a week)",
+			strconv.FormatFloat(costPerHour, 'f', -1, 64), cost(perDay), cost(perDay*7), cost(7*24*3600))
+		fmt.Fprintf(&b, "\nTime with nobody online cost %s of the %s over %s", cost(total.Empty), cost(total.Running), plural(len(recent), "day"))
+		if total.Empty > total.Running/2 {
+			b.WriteString("; stopping the server, or its host, while empty would save most of it")
+		}
+	}
+	return b.String(), nil
+}
+
+func percentOf(part, whole float64) float64 {
+	if whole <= 0 {
+		return 0
+	}
+	return part / whole * 100
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "usage",
+		Usage: "usage [days]",
+		Help:  "Show the running time, CPU time, memory-hours and estimated cost of the last days (default 7)",
+		Run: func(s *Server, args []string) (string, error) {
+			days := 7
+			if len(args) > 0 {
+				n, err := strconv.Atoi(args[0])
+				if err != nil || n <= 0 {
+					return "", fmt.Errorf("usage: usage [days]")
+				}
+				days = n
+			}
+			return UsageReport(s.config.ServerDir, days, s.config.CostPerHour)
+		},
+	})
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"mcserver-manager/internal/servertype"
+	"mcserver-manager/internal/vanilla"
+	"mcserver-manager/internal/world"
+)
+
+// How often a server following the snapshot channel checks for a new one
+const snapshotCheckInterval = 6 * time.Hour
+
+// installVanilla keeps server.jar at the configured Minecraft version and
+// server type. A version or type switch always backs the world up first,
+// and one that would open a world saved by a newer version is refused. A
+// newer Paper or Purpur build of the same version is installed as is.
+func (s *Server) installVanilla() error {
+	flavor := s.config.ServerType
+	if flavor == "" {
+		flavor = vanilla.Vanilla
+	}
+	client := vanilla.NewClient()
+	target, err := client.ResolveFlavor(flavor, s.config.MCVersion, s.config.Snapshots)
+	if err != nil {
+		return err
+	}
+
+	current, err := vanilla.Current(s.config.ServerDir)
+	if err != nil {
+		return err
+	}
+	sameVersion := current != nil && current.ID == target.ID && current.Flavor == target.Flavor
+	if sameVersion && current.Build == target.Build {
+		return nil
+	}
+
+	s.updateStatus(StatusDownloading)
+	s.addEvent(EventInfo, fmt.Sprintf("Downloading %s server", target))
+	pending, err := client.Download(target, s.config.ServerDir)
+	if err != nil {
+		return err
+	}
+	defer pending.Discard()
+
+	info, _ := world.ReadInfo(s.activeWorldPath())
+	if info != nil && !sameVersion {
+		// Paper and Purpur jars do not carry the DataVersion; compare names
+		newer := info.DataVersion > pending.WorldVersion && pending.WorldVersion > 0 ||
+			pending.WorldVersion == 0 && info.Version != "" && servertype.Compare(info.Version, target.ID) > 0
+		if newer {
+			return fmt.Errorf("world %s was last saved by Minecraft %s, which is newer than %s; restore a backup from %s or earlier instead",
+				info.LevelName, info.Version, target.ID, target.ID)
+		}
+		if err := s.Backup(); err != nil {
+			return fmt.Errorf("not switching to %s without a backup: %w", target, err)
+		}
+	}
+	if !target.Release() {
+		s.addEvent(EventWarning, fmt.Sprintf("Minecraft %s is a %s: worlds it saves cannot be opened by earlier releases, and snapshots can break them. Keep the backup until the next release", target.ID, target.Type))
+	}
+	if target.Experimental {
+		s.addEvent(EventWarning, fmt.Sprintf("%s is an experimental build; keep the backup until a stable one is out", target))
+	}
+
+	if err := pending.Apply(); err != nil {
+		return err
+	}
+	from := "none"
+	if current != nil {
+		from = current.String()
+	}
+	s.addEvent(EventInfo, fmt.Sprintf("Installed %s (was %s)", target, from))
+	return nil
+}
+
+// snapshotLoop tells the operator when a newer snapshot is out, while one
+// server process runs; it is installed on the next start
+func (s *Server) snapshotLoop() {
+	proc := s.cmd
+	ticker := time.NewTicker(snapshotCheckInterval)
+	defer ticker.Stop()
+
+	announced := ""
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if s.cmd != proc {
+			return
+		}
+
+		latest, err := vanilla.NewClient().Resolve(vanilla.LatestSnapshot, true)
+		if err != nil {
+			continue
+		}
+		current, _ := vanilla.Current(s.config.ServerDir)
+		if current != nil && current.ID != latest.ID && latest.ID != announced {
+			announced = latest.ID
+			s.addEvent(EventInfo, fmt.Sprintf("Snapshot %s is out; restart to update from %s", latest.ID, current.ID))
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"mcserver-manager/internal/props"
+)
+
+const (
+	viewCheckInterval = 30 * time.Second
+	viewWindow        = 6 // samples that must agree, so 3 minutes sustained
+
+	strainedTPS  = 18.0
+	strainedMSPT = 45.0
+	healthyTPS   = 19.5
+	healthyMSPT  = 30.0
+
+	// Each this many players online lowers the ceiling by one chunk
+	playersPerChunk = 10
+)
+
+type tickSample struct {
+	tps     float64
+	mspt    float64
+	players int
+}
+
+// viewDistanceLoop lowers view/simulation distance while the server is
+// struggling and raises it again when there is headroom, within the
+// configured bounds. It runs for the lifetime of one server process.
+func (s *Server) viewDistanceLoop() {
+	proc := s.cmd
+
+	view, sim := s.readDistances()
+	s.statsMutex.Lock()
+	s.stats.ViewDistance, s.stats.SimulationDistance = view, sim
+	s.stats.DistancePending = false
+	s.statsMutex.Unlock()
+
+	ticker := time.NewTicker(viewCheckInterval)
+	defer ticker.Stop()
+
+	var samples []tickSample
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if s.cmd != proc {
+			// Restarted; the new process has its own loop
+			return
+		}
+
+		st := s.GetStats()
+		if st.Status != StatusRunning || st.DistancePending {
+			samples = nil
+			continue
+		}
+		samples = append(samples, tickSample{tps: st.TPS, mspt: st.MSPT, players: st.PlayerCount})
+		if len(samples) < viewWindow {
+			continue
+		}
+		samples = samples[len(samples)-viewWindow:]
+
+		newView, newSim := s.nextDistances(st.ViewDistance, st.SimulationDistance, samples)
+		if newView == st.ViewDistance && newSim == st.SimulationDistance {
+			continue
+		}
+		if err := s.applyDistances(newView, newSim); err != nil {
+			s.addEvent(EventWarning, fmt.Sprintf("Could not change view distance: %v", err))
+		}
+		// Judge the new setting on fresh samples only
+		samples = nil
+	}
+}
+
+// nextDistances decides the next view and simulation distance from a
+// window of samples. Simulation distance goes down first since it costs
+// the most tick time; view distance goes up first since players notice it.
+func (s *Server) nextDistances(view, sim int, samples []tickSample) (int, int) {
+	strained, healthy := true, true
+	players := 0
+	for _, smp := range samples {
+		if !(smp.tps < strainedTPS || smp.mspt > strainedMSPT) {
+			strained = false
+		}
+		if smp.tps < healthyTPS || smp.mspt >= healthyMSPT {
+			healthy = false
+		}
+		if smp.players > players {
+			players = smp.players
+		}
+	}
+
+	viewCeil := max(s.config.ViewDistanceMax-players/playersPerChunk, s.config.ViewDistanceMin)
+	simCeil := max(s.config.SimDistanceMax-players/playersPerChunk, s.config.SimDistanceMin)
+
+	switch {
+	case strained || view > viewCeil || sim > simCeil:
+		if sim > s.config.SimDistanceMin {
+			return view, sim - 1
+		}
+		if view > s.config.ViewDistanceMin {
+			return view - 1, sim
+		}
+
+	case healthy && players > 0:
+		if view < viewCeil {
+			return view + 1, sim
+		}
+		if sim < simCeil {
+			return view, sim + 1
+		}
+	}
+	return view, sim
+}
+
+// applyDistances changes the live setting through the configured console
+// command, or writes server.properties for the next restart
+func (s *Server) applyDistances(view, sim int) error {
+	if s.config.ViewDistanceCommand != "" {
+		command := strings.NewReplacer("{view}", strconv.Itoa(view), "{sim}", strconv.Itoa(sim)).Replace(s.config.ViewDistanceCommand)
+		if err := s.SendCommand(command); err != nil {
+			return err
+		}
+		s.statsMutex.Lock()
+		s.stats.ViewDistance, s.stats.SimulationDistance = view, sim
+		s.statsMutex.Unlock()
+		s.addEvent(EventInfo, fmt.Sprintf("View distance %d, simulation distance %d", view, sim))
+		return nil
+	}
+
+	propsPath := filepath.Join(s.config.ServerDir, "server.properties")
+	properties, err := props.Load(propsPath)
+	if err != nil {
+		return err
+	}
+	properties.Set("view-distance", strconv.Itoa(view))
+	properties.Set("simulation-distance", strconv.Itoa(sim))
+	if err := properties.Save(propsPath); err != nil {
+		return err
+	}
+	s.checkConfigDrift(driftByAdaptiveView)
+
+	s.statsMutex.Lock()
+	s.stats.DistancePending = true
+	s.statsMutex.Unlock()
+	s.addEvent(EventWarning, fmt.Sprintf("Set view distance %d, simulation distance %d: restart to apply", view, sim))
+	return nil
+}
+
+// readDistances returns the current distances from server.properties,
+// clamped to the configured bounds
+func (s *Server) readDistances() (int, int) {
+	view, sim := 10, 10
+	if properties, err := props.Load(filepath.Join(s.config.ServerDir, "server.properties")); err == nil {
+		if v, err := strconv.Atoi(properties.GetDefault("view-distance", "10")); err == nil {
+			view = v
+		}
+		if v, err := strconv.Atoi(properties.GetDefault("simulation-distance", "10")); err == nil {
+			sim = v
+		}
+	}
+	view = min(max(view, s.config.ViewDistanceMin), s.config.ViewDistanceMax)
+	sim = min(max(sim, s.config.SimDistanceMin), s.config.SimDistanceMax)
+	return view, sim
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// threadDumpDir holds thread dumps of stalled starts, relative to the
+// server dir
+const threadDumpDir = ".mcserver/thread-dumps"
+
+// Wait between automatic restarts after crashes in a row, doubling from
+// the minimum
+const (
+	crashBackoffMin = 5 * time.Second
+	crashBackoffMax = 5 * time.Minute
+
+	// A process that ran this long ends the crash loop
+	crashLoopReset = 10 * time.Minute
+)
+
+// consoleLine is the last line the server printed
+type consoleLine struct {
+	text string
+	at   time.Time
+}
+
+// startWatchdog fails a start that has not printed Done within
+// StartTimeout minutes, as modded servers sometimes hang while loading:
+// it captures a thread dump, reports where the console stopped and kills
+// the JVM, which monitorProcess then handles like a crash
+func (s *Server) startWatchdog() {
+	proc := s.cmd
+	select {
+	case <-s.ctx.Done():
+		return
+	case <-time.After(time.Duration(s.config.StartTimeout) * time.Minute):
+	}
+	if s.cmd != proc || s.stats.Status != StatusStarting {
+		return
+	}
+
+	stalled := "no console output"
+	if last := s.lastOutput.Load(); last != nil {
+		stalled = fmt.Sprintf("last output %s ago: %s", time.Since(last.at).Round(time.Second), last.text)
+	}
+	dump := s.threadDump(proc)
+	s.addEvent(EventCritical, fmt.Sprintf("Server did not finish starting within %d minutes (%s); %s", s.config.StartTimeout, stalled, dump))
+
+	s.startTimedOut = true
+	proc.Process.Kill()
+}
+
+// threadDump records what the JVM's threads are doing: saved by jcmd from
+// the same JDK if there is one, or else printed to the console by the JVM
+// on SIGQUIT. It returns where the dump went.
+func (s *Server) threadDump(proc *exec.Cmd) string {
+	if jcmd := findJDKTool(s.javaPath(), "jcmd"); jcmd != "" {
+		ctx, cancel := context.WithTimeout(s.ctx, 30*time.Second)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, jcmd, fmt.Sprint(proc.Process.Pid), "Thread.print").CombinedOutput()
+		if err == nil {
+			dir := filepath.Join(s.config.ServerDir, threadDumpDir)
+			path := filepath.Join(dir, time.Now().Format("2006-01-02_15-04-05")+".txt")
+			if err := os.MkdirAll(dir, 0755); err == nil {
+				if err := os.WriteFile(path, out, 0644); err == nil {
+					return "thread dump saved to " + filepath.Join(threadDumpDir, filepath.Base(path))
+				}
+			}
+		}
+	}
+
+	if err := proc.Process.Signal(syscall.SIGQUIT); err != nil {
+		return "no thread dump (jcmd not found)"
+	}
+	// Give the JVM a moment to print it before it is killed
+	time.Sleep(2 * time.Second)
+	return "thread dump printed to the console"
+}
+
+// findJDKTool looks for a JDK tool such as jcmd next to the java binary
+func findJDKTool(javaPath, tool string) string {
+	java, err := exec.LookPath(javaPath)
+	if err != nil {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(java); err == nil {
+		java = resolved
+	}
+	name := tool
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	path := filepath.Join(filepath.Dir(java), name)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// crashBackoff counts a crash towards the crash loop and returns how long
+// to wait before restarting, or false once CrashLimit crashes in a row
+// have happened
+func (s *Server) crashBackoff() (time.Duration, bool) {
+	if time.Since(s.stats.StartTime) >= crashLoopReset {
+		s.crashStreak = 0
+	}
+	s.crashStreak++
+	if s.config.CrashLimit > 0 && s.crashStreak > s.config.CrashLimit {
+		// A manual start begins a new streak
+		s.crashStreak = 0
+		return 0, false
+	}
+
+	delay := crashBackoffMin
+	for i := 1; i < s.crashStreak && delay < crashBackoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, crashBackoffMax), true
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// whitelistEntry is one entry of whitelist.json
+type whitelistEntry struct {
+	UUID string `json:"uuid"`
+	Name string `json:"name"`
+}
+
+// syncWhitelist replaces whitelist.json with the remote member list when it
+// changed, and has a running server reload it. Players without a known UUID
+// are added by command, which makes the server look them up.
+func (s *Server) syncWhitelist() error {
+	names, data, err := s.whitelistSource.Fetch()
+	if err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Whitelist sync failed: %v", err))
+		return err
+	}
+	sum := sha1.Sum(data)
+	hash := hex.EncodeToString(sum[:])
+	if hash == s.whitelistHash {
+		return nil
+	}
+
+	path := filepath.Join(s.config.ServerDir, "whitelist.json")
+	var current []whitelistEntry
+	if existing, err := os.ReadFile(path); err == nil && len(existing) > 0 {
+		if err := json.Unmarshal(existing, &current); err != nil {
+			return fmt.Errorf("failed to parse whitelist.json: %w", err)
+		}
+	}
+
+	var next []whitelistEntry
+	var added, unresolved []string
+	for _, name := range names {
+		if entry, ok := findWhitelisted(current, name); ok {
+			next = append(next, entry)
+			continue
+		}
+		added = append(added, name)
+		uuid, err := s.playerUUID(name)
+		if err != nil {
+			unresolved = append(unresolved, name)
+			continue
+		}
+		next = append(next, whitelistEntry{UUID: uuid, Name: name})
+	}
+	var removed []string
+	for _, entry := range current {
+		if _, ok := findWhitelisted(next, entry.Name); !ok {
+			removed = append(removed, entry.Name)
+		}
+	}
+
+	if next == nil {
+		next = []whitelistEntry{}
+	}
+	out, err := json.MarshalIndent(next, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, out, 0644); err != nil {
+		return err
+	}
+	s.whitelistHash = hash
+
+	if s.stats.Status == StatusRunning {
+		s.SendCommand("whitelist reload")
+		for _, name := range unresolved {
+			s.SendCommand("whitelist add " + name)
+		}
+	} else if len(unresolved) > 0 {
+		// Retry the lookups on the next sync
+		s.whitelistHash = ""
+	}
+	s.checkConfigDrift(driftByWhitelistSync)
+
+	if len(added) > 0 || len(removed) > 0 {
+		var parts []string
+		if len(added) > 0 {
+			parts = append(parts, "+"+strings.Join(added, ", +"))
+		}
+		if len(removed) > 0 {
+			parts = append(parts, "-"+strings.Join(removed, ", -"))
+		}
+		s.addEvent(EventInfo, fmt.Sprintf("Whitelist synced (%s): %s", plural(len(names), "player"), strings.Join(parts, ", ")))
+	}
+	return nil
+}
+
+// whitelistLoop syncs on the configured interval while one server process
+// runs
+func (s *Server) whitelistLoop() {
+	proc := s.cmd
+	ticker := time.NewTicker(time.Duration(s.config.WhitelistInterval) * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if s.cmd != proc {
+				return
+			}
+			s.syncWhitelist()
+		}
+	}
+}
+
+func findWhitelisted(entries []whitelistEntry, name string) (whitelistEntry, bool) {
+	for _, entry := range entries {
+		if strings.EqualFold(entry.Name, name) {
+			return entry, true
+		}
+	}
+	return whitelistEntry{}, false
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "whitelist",
+		Usage: "whitelist sync|status",
+		Help:  "Sync the whitelist from its remote source now, or show the source",
+		Admin: true,
+		Run: func(s *Server, args []string) (string, error) {
+			if s.whitelistSource == nil {
+				return "", fmt.Errorf("no remote whitelist configured (--whitelist-url)")
+			}
+			if len(args) == 0 || args[0] == "status" {
+				return fmt.Sprintf("Whitelist synced from %s every %d minutes", s.whitelistSource.URL, s.config.WhitelistInterval), nil
+			}
+			if args[0] != "sync" {
+				return "", fmt.Errorf("usage: whitelist sync|status")
+			}
+			// Apply even if the list is unchanged, e.g. after a local edit
+			s.whitelistHash = ""
+			if err := s.syncWhitelist(); err != nil {
+				return "", err
+			}
+			return "Whitelist synced", nil
+		},
+	})
+}
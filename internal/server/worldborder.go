@@ -0,0 +1,257 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// worldBorderFile holds the world border setup, relative to the server dir
+const worldBorderFile = ".mcserver/worldborder.json"
+
+// Layout of the times in the expansion schedule, in local time
+const worldBorderTimeLayout = "2006-01-02 15:04"
+
+// borderStep grows (or shrinks) the border to Size blocks, starting At
+// and taking Seconds to get there
+type borderStep struct {
+	At      string  `json:"at"`
+	Size    float64 `json:"size"`
+	Seconds int     `json:"seconds"`
+
+	at time.Time
+}
+
+type worldBorderConfig struct {
+	// Dimension to set the border in, the overworld when empty
+	Dimension string       `json:"dimension"`
+	CenterX   float64      `json:"center_x"`
+	CenterZ   float64      `json:"center_z"`
+	Size      float64      `json:"size"`
+	Schedule  []borderStep `json:"schedule"`
+}
+
+// loadWorldBorder reads worldBorderFile. A missing file leaves the border
+// to the server.
+func (s *Server) loadWorldBorder() {
+	s.worldBorder = nil
+	data, err := os.ReadFile(filepath.Join(s.config.ServerDir, worldBorderFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			s.addEvent(EventWarning, fmt.Sprintf("Failed to read world border: %v", err))
+		}
+		return
+	}
+
+	var cfg worldBorderConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Failed to parse %s: %v", worldBorderFile, err))
+		return
+	}
+	if cfg.Size <= 0 {
+		s.addEvent(EventWarning, fmt.Sprintf("%s: size must be positive", worldBorderFile))
+		return
+	}
+	for i := range cfg.Schedule {
+		step := &cfg.Schedule[i]
+		at, err := time.ParseInLocation(worldBorderTimeLayout, step.At, time.Local)
+		if err != nil {
+			s.addEvent(EventWarning, fmt.Sprintf("%s step %d: invalid time %q, want YYYY-MM-DD HH:MM", worldBorderFile, i+1, step.At))
+			return
+		}
+		if step.Size <= 0 || step.Seconds < 0 {
+			s.addEvent(EventWarning, fmt.Sprintf("%s step %d: size must be positive and seconds not negative", worldBorderFile, i+1))
+			return
+		}
+		step.at = at
+	}
+	sort.SliceStable(cfg.Schedule, func(i, j int) bool { return cfg.Schedule[i].at.Before(cfg.Schedule[j].at) })
+	s.worldBorder = &cfg
+}
+
+// sizeAt returns the border size the schedule calls for at t and, when a
+// step is still growing it then, the size it ends at and the time left
+func (c *worldBorderConfig) sizeAt(t time.Time) (size, target float64, left time.Duration) {
+	size = c.Size
+	for _, step := range c.Schedule {
+		if step.at.After(t) {
+			break
+		}
+		end := step.at.Add(time.Duration(step.Seconds) * time.Second)
+		if t.Before(end) {
+			done := float64(t.Sub(step.at)) / float64(end.Sub(step.at))
+			return size + (step.Size-size)*done, step.Size, end.Sub(t)
+		}
+		size = step.Size
+	}
+	return size, size, 0
+}
+
+// next returns the first step starting after t, or nil
+func (c *worldBorderConfig) next(t time.Time) *borderStep {
+	for i := range c.Schedule {
+		if c.Schedule[i].at.After(t) {
+			return &c.Schedule[i]
+		}
+	}
+	return nil
+}
+
+// borderCommand runs a worldborder subcommand in the configured dimension
+func (c *worldBorderConfig) borderCommand(format string, args ...any) string {
+	command := "worldborder " + fmt.Sprintf(format, args...)
+	if c.Dimension != "" {
+		command = "execute in " + c.Dimension + " run " + command
+	}
+	return command
+}
+
+// applyWorldBorder sets the center and the size the schedule is at now,
+// resuming a step that was still growing when the server went down
+func (s *Server) applyWorldBorder() error {
+	cfg := s.worldBorder
+	if cfg == nil {
+		return fmt.Errorf("no world border configured in %s", worldBorderFile)
+	}
+	size, target, left := cfg.sizeAt(time.Now())
+
+	commands := []string{
+		cfg.borderCommand("center %s %s", formatCoord(cfg.CenterX), formatCoord(cfg.CenterZ)),
+		cfg.borderCommand("set %s", formatBorderSize(size)),
+	}
+	if left > 0 {
+		commands = append(commands, cfg.borderCommand("set %s %d", formatBorderSize(target), int(math.Ceil(left.Seconds()))))
+	}
+	for _, command := range commands {
+		if err := s.SendCommand(command); err != nil {
+			return err
+		}
+	}
+
+	msg := fmt.Sprintf("World border set to %s blocks around %s, %s", formatBorderSize(size), formatCoord(cfg.CenterX), formatCoord(cfg.CenterZ))
+	if left > 0 {
+		msg += fmt.Sprintf(", growing to %s over %s", formatBorderSize(target), left.Round(time.Second))
+	}
+	s.addEvent(EventInfo, msg)
+	return nil
+}
+
+// worldBorderLoop starts the scheduled steps at their times while one
+// server process runs
+func (s *Server) worldBorderLoop() {
+	proc := s.cmd
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case now := <-ticker.C:
+			if s.cmd != proc {
+				return
+			}
+			cfg := s.worldBorder
+			if cfg == nil || s.GetStats().Status != StatusRunning {
+				last = now
+				continue
+			}
+			for i := range cfg.Schedule {
+				if step := &cfg.Schedule[i]; step.at.After(last) && !step.at.After(now) {
+					s.startBorderStep(cfg, step)
+				}
+			}
+			last = now
+		}
+	}
+}
+
+// startBorderStep sends the border on its way to a step's size
+func (s *Server) startBorderStep(cfg *worldBorderConfig, step *borderStep) {
+	command := cfg.borderCommand("set %s", formatBorderSize(step.Size))
+	if step.Seconds > 0 {
+		command += " " + strconv.Itoa(step.Seconds)
+	}
+	if err := s.SendCommand(command); err != nil {
+		s.addEvent(EventWarning, fmt.Sprintf("Failed to move the world border: %v", err))
+		return
+	}
+	msg := fmt.Sprintf("World border moving to %s blocks", formatBorderSize(step.Size))
+	if step.Seconds > 0 {
+		msg += " over " + (time.Duration(step.Seconds) * time.Second).String()
+	}
+	s.addEvent(EventInfo, msg)
+}
+
+func formatBorderSize(size float64) string {
+	return strconv.FormatFloat(math.Round(size*10)/10, 'f', -1, 64)
+}
+
+// describe shows the configured border and its schedule
+func (c *worldBorderConfig) describe(now time.Time) string {
+	size, target, left := c.sizeAt(now)
+	where := "the overworld"
+	if c.Dimension != "" {
+		where = c.Dimension
+	}
+	lines := []string{fmt.Sprintf("Center %s %s in %s, now %s blocks", formatCoord(c.CenterX), formatCoord(c.CenterZ), where, formatBorderSize(size))}
+	if left > 0 {
+		lines[0] += fmt.Sprintf(", growing to %s for another %s", formatBorderSize(target), left.Round(time.Second))
+	}
+	for i, step := range c.Schedule {
+		state := "done"
+		switch {
+		case step.at.After(now):
+			state = "pending"
+		case step.at.Add(time.Duration(step.Seconds) * time.Second).After(now):
+			state = "in progress"
+		}
+		over := ""
+		if step.Seconds > 0 {
+			over = " over " + (time.Duration(step.Seconds) * time.Second).String()
+		}
+		lines = append(lines, fmt.Sprintf("  %d. %s: %s blocks%s (%s)", i+1, step.At, formatBorderSize(step.Size), over, state))
+	}
+	if step := c.next(now); step != nil {
+		lines = append(lines, fmt.Sprintf("Next step in %s", step.at.Sub(now).Round(time.Minute)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "worldborder",
+		Usage: "worldborder [status|apply]",
+		Help:  "Show the configured world border and its schedule, or set it on the server again",
+		Run: func(s *Server, args []string) (string, error) {
+			cfg := s.worldBorder
+			if cfg == nil {
+				return "", fmt.Errorf("no world border configured in %s", worldBorderFile)
+			}
+			sub := "status"
+			if len(args) > 0 {
+				sub = args[0]
+			}
+			switch sub {
+			case "status":
+				return cfg.describe(time.Now()), nil
+			case "apply":
+				if s.GetStats().Status != StatusRunning {
+					return "", fmt.Errorf("the server is not running")
+				}
+				if err := s.applyWorldBorder(); err != nil {
+					return "", err
+				}
+				return "World border applied", nil
+			}
+			return "", fmt.Errorf("usage: worldborder [status|apply]")
+		},
+	})
+}
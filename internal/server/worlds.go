@@ -0,0 +1,312 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"mcserver-manager/internal/stats"
+	"mcserver-manager/internal/world"
+)
+
+// ListWorlds returns the world folders in the server directory
+func (s *Server) ListWorlds() ([]world.World, error) {
+	return world.List(s.config.ServerDir)
+}
+
+// activeWorldPath returns the folder of the world named by level-name
+func (s *Server) activeWorldPath() string {
+	return filepath.Join(s.config.ServerDir, world.ActiveName(s.config.ServerDir))
+}
+
+// refreshWorldInfo reloads the active world's level.dat into the stats
+func (s *Server) refreshWorldInfo() {
+	info, err := world.ReadInfo(s.activeWorldPath())
+	if err != nil {
+		info = nil
+	}
+
+	s.statsMutex.Lock()
+	s.stats.World = info
+	s.statsMutex.Unlock()
+}
+
+// SwitchWorld makes an existing world active, restarting the server if it
+// is running
+func (s *Server) SwitchWorld(name string) error {
+	if _, err := world.Find(s.config.ServerDir, name); err != nil {
+		return err
+	}
+	return s.withRestart(func() error {
+		if err := world.SetActive(s.config.ServerDir, name); err != nil {
+			return err
+		}
+		s.addEvent(EventInfo, fmt.Sprintf("Active world is now %s", name))
+		return nil
+	})
+}
+
+// CreateWorld switches to a new world that the server generates with seed
+// and levelType on its next start
+func (s *Server) CreateWorld(name, seed, levelType string) error {
+	if err := world.ValidateName(name); err != nil {
+		return err
+	}
+	return s.withRestart(func() error {
+		if err := world.PrepareNew(s.config.ServerDir, name, seed, levelType); err != nil {
+			return err
+		}
+		s.addEvent(EventInfo, fmt.Sprintf("New world %s will be generated on start", name))
+		return nil
+	})
+}
+
+// ArchiveWorld zips a retired world into the backup directory and removes
+// it from the server directory
+func (s *Server) ArchiveWorld(name string) (string, error) {
+	path, err := world.Archive(s.config.ServerDir, name, filepath.Join(s.config.BackupDir, "archived-worlds"))
+	if err != nil {
+		return "", err
+	}
+	s.addEvent(EventBackup, fmt.Sprintf("Archived world %s to %s", name, filepath.Base(path)))
+	return path, nil
+}
+
+// ImportWorld installs a world from a zip file or URL as name (the active
+// world if empty) and makes it active. Existing worlds are backed up first
+// and a running server is stopped and started again.
+func (s *Server) ImportWorld(source, name string) error {
+	if name == "" {
+		name = world.ActiveName(s.config.ServerDir)
+	}
+	if err := world.ValidateName(name); err != nil {
+		return err
+	}
+
+	archive, err := world.Fetch(s.config.ServerDir, source)
+	if err != nil {
+		return err
+	}
+	if world.IsURL(source) {
+		defer os.Remove(archive)
+	}
+
+	plan, err := world.Inspect(archive)
+	if err != nil {
+		return err
+	}
+
+	// withRestart only backs up a running server
+	if s.stats.Status != StatusRunning {
+		if worlds, _ := s.ListWorlds(); len(worlds) > 0 {
+			if _, err := s.backupMgr.CreateBackup(); err != nil {
+				return fmt.Errorf("backup failed, nothing changed: %w", err)
+			}
+		}
+	}
+
+	return s.withRestart(func() error {
+		if err := world.Import(s.config.ServerDir, archive, name, plan); err != nil {
+			return err
+		}
+		if err := world.SetActive(s.config.ServerDir, name); err != nil {
+			return err
+		}
+		s.addEvent(EventInfo, fmt.Sprintf("Imported world %s from %s", name, filepath.Base(source)))
+		return nil
+	})
+}
+
+// ExportWorld writes a world to outPath as a shareable zip, flushing it to
+// disk first if the server is running
+func (s *Server) ExportWorld(name, outPath string, opts world.ExportOptions) error {
+	if name == "" {
+		name = world.ActiveName(s.config.ServerDir)
+	}
+
+	if s.stats.Status == StatusRunning {
+		resume := s.pauseSaving()
+		defer resume()
+	}
+
+	if err := world.Export(s.config.ServerDir, name, outPath, opts); err != nil {
+		return err
+	}
+	s.addEvent(EventInfo, fmt.Sprintf("Exported world %s to %s", name, filepath.Base(outPath)))
+	return nil
+}
+
+// TrimWorld deletes region files outside the trim area. Unless it is a dry
+// run, a running server is backed up, stopped and started again around it.
+func (s *Server) TrimWorld(name string, opts world.TrimOptions) (*world.TrimReport, error) {
+	if name == "" {
+		name = world.ActiveName(s.config.ServerDir)
+	}
+	if opts.DryRun {
+		return world.Trim(s.config.ServerDir, name, opts)
+	}
+
+	var report *world.TrimReport
+	err := s.withRestart(func() error {
+		var err error
+		report, err = world.Trim(s.config.ServerDir, name, opts)
+		return err
+	})
+	if err == nil {
+		s.addEvent(EventInfo, fmt.Sprintf("Trimmed %s: removed %d region files (%s)",
+			name, report.RemovedFiles, stats.FormatBytes(uint64(report.RemovedBytes))))
+	}
+	return report, err
+}
+
+func init() {
+	registerAction(&Action{
+		Name:  "world",
+		Usage: "world list|use|create|archive|import|export|trim ...",
+		Help:  "Manage worlds (create <name> [seed=..] [type=..])",
+		Admin: true,
+		Run:   runWorldAction,
+	})
+}
+
+func runWorldAction(s *Server, args []string) (string, error) {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+
+	switch args[0] {
+	case "list":
+		worlds, err := s.ListWorlds()
+		if err != nil {
+			return "", err
+		}
+		if len(worlds) == 0 {
+			return "No worlds yet", nil
+		}
+		var lines []string
+		for _, w := range worlds {
+			marker := " "
+			if w.Active {
+				marker = "*"
+			}
+			version := w.Version
+			if version == "" {
+				version = "unknown"
+			}
+			lines = append(lines, fmt.Sprintf("%s %-24s %10s  %s", marker, w.Name, stats.FormatBytes(uint64(w.Size)), version))
+		}
+		return strings.Join(lines, "\n"), nil
+
+	case "use":
+		if len(args) != 2 {
+			return "", fmt.Errorf("usage: world use <name>")
+		}
+		return "Switched to " + args[1], s.SwitchWorld(args[1])
+
+	case "create":
+		if len(args) < 2 {
+			return "", fmt.Errorf("usage: world create <name> [seed=<seed>] [type=<level-type>]")
+		}
+		var seed, levelType string
+		for _, opt := range args[2:] {
+			key, value, _ := strings.Cut(opt, "=")
+			switch key {
+			case "seed":
+				seed = value
+			case "type":
+				levelType = value
+			default:
+				return "", fmt.Errorf("unknown option %q (seed=, type=)", opt)
+			}
+		}
+		return "Created " + args[1], s.CreateWorld(args[1], seed, levelType)
+
+	case "import":
+		if len(args) < 2 || len(args) > 3 {
+			return "", fmt.Errorf("usage: world import <zip|url> [name]")
+		}
+		var name string
+		if len(args) == 3 {
+			name = args[2]
+		}
+		return "Imported " + args[1], s.ImportWorld(args[1], name)
+
+	case "export":
+		if len(args) < 2 {
+			return "", fmt.Errorf("usage: world export <out.zip> [world=<name>] [scrub-players]")
+		}
+		var name string
+		var opts world.ExportOptions
+		for _, opt := range args[2:] {
+			key, value, _ := strings.Cut(opt, "=")
+			switch key {
+			case "world":
+				name = value
+			case "scrub-players":
+				opts.ScrubPlayers = true
+			default:
+				return "", fmt.Errorf("unknown option %q (world=, scrub-players)", opt)
+			}
+		}
+		return "Exported to " + args[1], s.ExportWorld(name, args[1], opts)
+
+	case "trim":
+		opts := world.TrimOptions{DryRun: true}
+		var name string
+		for _, opt := range args[1:] {
+			key, value, _ := strings.Cut(opt, "=")
+			switch key {
+			case "world":
+				name = value
+			case "radius":
+				r, err := strconv.Atoi(value)
+				if err != nil {
+					return "", fmt.Errorf("invalid radius %q", value)
+				}
+				opts.Radius = r
+			case "center":
+				x, z, ok := strings.Cut(value, ",")
+				cx, errX := strconv.Atoi(x)
+				cz, errZ := strconv.Atoi(z)
+				if !ok || errX != nil || errZ != nil {
+					return "", fmt.Errorf("invalid center %q, expected X,Z", value)
+				}
+				opts.CenterX, opts.CenterZ = cx, cz
+			case "border":
+				opts.UseBorder = true
+			case "apply":
+				opts.DryRun = false
+			default:
+				return "", fmt.Errorf("unknown option %q (radius=, center=, border, world=, apply)", opt)
+			}
+		}
+
+		report, err := s.TrimWorld(name, opts)
+		if err != nil {
+			return "", err
+		}
+		verb := "Would remove"
+		if !opts.DryRun {
+			verb = "Removed"
+		}
+		summary := fmt.Sprintf("%s %d region files (%s), keeping %d (%s) within %d blocks of %d,%d",
+			verb, report.RemovedFiles, stats.FormatBytes(uint64(report.RemovedBytes)),
+			report.KeptFiles, stats.FormatBytes(uint64(report.KeptBytes)),
+			report.Radius, report.CenterX, report.CenterZ)
+		if opts.DryRun {
+			summary += "\nDry run, nothing deleted (add apply, or --apply on the CLI)"
+		}
+		return summary, nil
+
+	case "archive":
+		if len(args) != 2 {
+			return "", fmt.Errorf("usage: world archive <name>")
+		}
+		path, err := s.ArchiveWorld(args[1])
+		return "Archived to " + path, err
+	}
+
+	return "", fmt.Errorf("unknown world action %q", args[0])
+}
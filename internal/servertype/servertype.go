@@ -0,0 +1,266 @@
+// Package servertype works out which server software and Minecraft version
+// a server directory runs, from its jars, their version.json and the
+// libraries installers leave behind.
+package servertype
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"mcserver-manager/internal/loader"
+)
+
+// Server types
+const (
+	Vanilla  = "vanilla"
+	Forge    = loader.Forge
+	NeoForge = loader.NeoForge
+	Fabric   = "fabric"
+	Paper    = "paper"
+	Purpur   = "purpur"
+	Spigot   = "spigot"
+)
+
+// Info is the detected server software
+type Info struct {
+	Type          string
+	LoaderVersion string // Forge/NeoForge/Fabric/Paper build, if known
+	MCVersion     string // empty if it could not be found
+
+	// Java major version the server jar asks for (version.json), 0 if unknown
+	Java int
+}
+
+func (i *Info) String() string {
+	s := i.Type
+	if i.LoaderVersion != "" {
+		s += " " + i.LoaderVersion
+	}
+	if i.MCVersion != "" {
+		s += " (Minecraft " + i.MCVersion + ")"
+	}
+	return s
+}
+
+// Family is the console dialect of the server: vanilla, forge, fabric or
+// paper, the names of the log profiles
+func (i *Info) Family() string {
+	switch i.Type {
+	case Forge, NeoForge:
+		return "forge"
+	case Paper, Purpur, Spigot:
+		return "paper"
+	}
+	return i.Type
+}
+
+var (
+	paperJar   = regexp.MustCompile(`^(paper|purpur)-(1\.[\d.]+)-(\d+)\.jar$`)
+	spigotJar  = regexp.MustCompile(`^spigot-(1\.[\d.]+)\.jar$`)
+	forgeJar   = regexp.MustCompile(`^forge-(1\.[\d.]+)-([\d.]+)(?:-universal|-server)?\.jar$`)
+	fabricJar  = regexp.MustCompile(`^fabric-server-mc\.(1\.[\d.]+)-loader\.([\d.]+)-launcher`)
+	vanillaJar = regexp.MustCompile(`^minecraft_server\.(1\.[\d.]+)\.jar$`)
+	mojangJar  = regexp.MustCompile(`^mojang_(1\.[\d.]+)\.jar$`)
+)
+
+const fabricLoaderDir = "libraries/net/fabricmc/fabric-loader"
+
+// Detect inspects serverDir. It always returns an Info, vanilla without a
+// version if nothing is recognized.
+func Detect(serverDir string) *Info {
+	if info, err := loader.Detect(serverDir); err == nil {
+		return &Info{Type: info.Name, LoaderVersion: info.Version, MCVersion: info.MCVersion, Java: javaFromTable(info.MCVersion)}
+	}
+
+	info := &Info{Type: Vanilla}
+	jars, _ := filepath.Glob(filepath.Join(serverDir, "*.jar"))
+	for _, jar := range jars {
+		name := strings.ToLower(filepath.Base(jar))
+		if m := paperJar.FindStringSubmatch(name); m != nil {
+			info.Type, info.MCVersion, info.LoaderVersion = m[1], m[2], m[3]
+		} else if m := spigotJar.FindStringSubmatch(name); m != nil {
+			info.Type, info.MCVersion = Spigot, m[1]
+		} else if m := forgeJar.FindStringSubmatch(name); m != nil {
+			info.Type, info.MCVersion, info.LoaderVersion = Forge, m[1], m[2]
+		} else if m := fabricJar.FindStringSubmatch(name); m != nil {
+			info.Type, info.MCVersion, info.LoaderVersion = Fabric, m[1], m[2]
+		} else {
+			continue
+		}
+		break
+	}
+
+	if info.Type == Vanilla {
+		switch {
+		case exists(serverDir, "purpur.yml"):
+			info.Type = Purpur
+		case exists(serverDir, "config/paper-global.yml"), exists(serverDir, "paper.yml"):
+			info.Type = Paper
+		case exists(serverDir, "spigot.yml"):
+			info.Type = Spigot
+		case exists(serverDir, ".fabric"), exists(serverDir, fabricLoaderDir), exists(serverDir, "fabric-server-launch.jar"):
+			info.Type = Fabric
+		}
+	}
+	if info.Type == Fabric && info.LoaderVersion == "" {
+		info.LoaderVersion = newestDir(filepath.Join(serverDir, fabricLoaderDir))
+	}
+
+	// The vanilla jar knows its own version; Fabric launches it, and
+	// Paper-style jars either embed its version.json or cache the jar
+	for _, name := range []string{"server.jar", "minecraft_server.jar"} {
+		if id, java := readVersionJSON(filepath.Join(serverDir, name)); id != "" {
+			info.Java = java
+			if info.MCVersion == "" {
+				info.MCVersion = id
+			}
+			break
+		}
+	}
+	if info.MCVersion == "" {
+		for _, jar := range jars {
+			if id, java := readVersionJSON(jar); id != "" {
+				info.MCVersion, info.Java = id, java
+				break
+			}
+			if m := vanillaJar.FindStringSubmatch(strings.ToLower(filepath.Base(jar))); m != nil {
+				info.MCVersion = m[1]
+				break
+			}
+		}
+	}
+	if info.MCVersion == "" {
+		cached, _ := filepath.Glob(filepath.Join(serverDir, "cache", "mojang_*.jar"))
+		for _, jar := range cached {
+			if m := mojangJar.FindStringSubmatch(filepath.Base(jar)); m != nil {
+				info.MCVersion = m[1]
+			}
+		}
+	}
+	if info.Java == 0 {
+		info.Java = javaFromTable(info.MCVersion)
+	}
+	return info
+}
+
+// readVersionJSON returns the id and Java version from the version.json
+// inside a server jar (Minecraft 1.14 and later)
+func readVersionJSON(jar string) (string, int) {
+	r, err := zip.OpenReader(jar)
+	if err != nil {
+		return "", 0
+	}
+	defer r.Close()
+
+	f, err := r.Open("version.json")
+	if err != nil {
+		return "", 0
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, 1<<20))
+	if err != nil {
+		return "", 0
+	}
+	var version struct {
+		ID          string `json:"id"`
+		JavaVersion int    `json:"java_version"`
+	}
+	if json.Unmarshal(data, &version) != nil {
+		return "", 0
+	}
+	return version.ID, version.JavaVersion
+}
+
+// RequiredJava returns the lowest Java major version a Minecraft version
+// runs on, and the highest for the old releases that break on newer ones
+// (0 for no limit)
+func RequiredJava(info *Info) (int, int) {
+	min := info.Java
+	if min == 0 {
+		min = javaFromTable(info.MCVersion)
+	}
+	max := 0
+	// Forge before 1.13 relies on Java 8's class loader
+	if info.Type == Forge && Compare(info.MCVersion, "1.13") < 0 && info.MCVersion != "" {
+		max = 8
+	}
+	return min, max
+}
+
+func javaFromTable(mcVersion string) int {
+	switch {
+	case mcVersion == "":
+		return 0
+	case Compare(mcVersion, "1.20.5") >= 0:
+		return 21
+	case Compare(mcVersion, "1.18") >= 0:
+		return 17
+	case Compare(mcVersion, "1.17") >= 0:
+		return 16
+	}
+	return 8
+}
+
+// Compare orders two release versions such as 1.20.4 and 1.21, returning
+// -1, 0 or 1. Snapshot IDs compare as their numeric prefix.
+func Compare(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) []int {
+	var parts []int
+	for _, p := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			// "1.21-pre1" and the like
+			if i := strings.IndexFunc(p, func(r rune) bool { return r < '0' || r > '9' }); i >= 0 {
+				n, _ = strconv.Atoi(p[:i])
+			}
+			parts = append(parts, n)
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+func exists(dir, name string) bool {
+	_, err := os.Stat(filepath.Join(dir, name))
+	return err == nil
+}
+
+// newestDir returns the last entry of a versioned library dir
+func newestDir(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].IsDir() {
+			return entries[i].Name()
+		}
+	}
+	return ""
+}
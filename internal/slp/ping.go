@@ -0,0 +1,214 @@
+package slp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Status is a server's Server List Ping response
+type Status struct {
+	Version struct {
+		Name     string `json:"name"`
+		Protocol int    `json:"protocol"`
+	} `json:"version"`
+	Players struct {
+		Max    int `json:"max"`
+		Online int `json:"online"`
+		Sample []struct {
+			Name string `json:"name"`
+			ID   string `json:"id"`
+		} `json:"sample"`
+	} `json:"players"`
+	Description json.RawMessage `json:"description"`
+	Favicon     string          `json:"favicon"`
+
+	// Filled in by Ping
+	MOTD    string        `json:"-"`
+	Latency time.Duration `json:"-"`
+}
+
+// Ping performs the status handshake against host:port and measures the
+// round-trip latency of a ping/pong exchange
+func Ping(address string, timeout time.Duration) (*Status, error) {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q: %w", address, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q", portStr)
+	}
+
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	reader := bufio.NewReader(conn)
+
+	// Handshake (next state 1 = status), then status request
+	var handshake bytes.Buffer
+	writeVarInt(&handshake, 0x00)
+	writeVarInt(&handshake, -1) // protocol version: any
+	writeString(&handshake, host)
+	binary.Write(&handshake, binary.BigEndian, uint16(port))
+	writeVarInt(&handshake, 1)
+
+	if err := writePacket(conn, handshake.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := writePacket(conn, []byte{0x00}); err != nil {
+		return nil, err
+	}
+
+	payload, err := readPacket(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read status: %w", err)
+	}
+	body := bytes.NewReader(payload)
+	if id, err := readVarInt(body); err != nil || id != 0x00 {
+		return nil, fmt.Errorf("unexpected status packet")
+	}
+	jsonStr, err := readString(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read status JSON: %w", err)
+	}
+
+	status := &Status{}
+	if err := json.Unmarshal([]byte(jsonStr), status); err != nil {
+		return nil, fmt.Errorf("failed to parse status JSON: %w", err)
+	}
+	status.MOTD = FlattenChat(status.Description)
+
+	// Ping/pong for latency
+	var ping bytes.Buffer
+	writeVarInt(&ping, 0x01)
+	sent := time.Now()
+	binary.Write(&ping, binary.BigEndian, sent.UnixMilli())
+	if err := writePacket(conn, ping.Bytes()); err != nil {
+		return nil, err
+	}
+	if _, err := readPacket(reader); err != nil {
+		return nil, fmt.Errorf("failed to read pong: %w", err)
+	}
+	status.Latency = time.Since(sent)
+
+	return status, nil
+}
+
+// FlattenChat turns a chat component (string or object) into plain text
+func FlattenChat(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return stripFormatting(text)
+	}
+
+	var component struct {
+		Text  string            `json:"text"`
+		Extra []json.RawMessage `json:"extra"`
+	}
+	if err := json.Unmarshal(raw, &component); err != nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(component.Text)
+	for _, extra := range component.Extra {
+		b.WriteString(FlattenChat(extra))
+	}
+	return stripFormatting(b.String())
+}
+
+// stripFormatting removes legacy § color codes
+func stripFormatting(s string) string {
+	var b strings.Builder
+	skip := false
+	for _, r := range s {
+		if skip {
+			skip = false
+			continue
+		}
+		if r == '§' {
+			skip = true
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func writePacket(w io.Writer, data []byte) error {
+	var buf bytes.Buffer
+	writeVarInt(&buf, int32(len(data)))
+	buf.Write(data)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func readPacket(r *bufio.Reader) ([]byte, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if length < 0 || length > 1<<21 {
+		return nil, fmt.Errorf("invalid packet length %d", length)
+	}
+	data := make([]byte, length)
+	_, err = io.ReadFull(r, data)
+	return data, err
+}
+
+func writeVarInt(buf *bytes.Buffer, value int32) {
+	v := uint32(value)
+	for {
+		if v&^0x7F == 0 {
+			buf.WriteByte(byte(v))
+			return
+		}
+		buf.WriteByte(byte(v&0x7F | 0x80))
+		v >>= 7
+	}
+}
+
+func readVarInt(r io.ByteReader) (int32, error) {
+	var result uint32
+	for i := 0; i < 5; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		result |= uint32(b&0x7F) << (7 * i)
+		if b&0x80 == 0 {
+			return int32(result), nil
+		}
+	}
+	return 0, fmt.Errorf("varint too long")
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	writeVarInt(buf, int32(len(s)))
+	buf.WriteString(s)
+}
+
+func readString(r *bytes.Reader) (string, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return "", err
+	}
+	if length < 0 || int(length) > r.Len() {
+		return "", fmt.Errorf("invalid string length %d", length)
+	}
+	data := make([]byte, length)
+	_, err = io.ReadFull(r, data)
+	return string(data), err
+}
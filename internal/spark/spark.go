@@ -0,0 +1,378 @@
+// Package spark reads the sampler profiles spark saves with
+// --save-to-file and attributes their CPU time to mods. The .sparkprofile
+// format is spark's SamplerData protobuf message; see spark_sampler.proto
+// in https://github.com/lucko/spark for the fields read here.
+package spark
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Dirs are where spark saves profiles, relative to the server dir: its
+// config folder on Forge, NeoForge and Fabric, its plugin folder on Paper
+var Dirs = []string{"config/spark", "plugins/spark"}
+
+// Ext is the extension of the files spark saves
+const Ext = ".sparkprofile"
+
+// Unattributed collects the time spent outside mod code: Minecraft, the JDK
+// and the mod loader
+const Unattributed = "(minecraft, java, loader)"
+
+// ModTime is the CPU time attributed to one mod
+type ModTime struct {
+	Mod     string        `json:"mod"`
+	Time    time.Duration `json:"time"`
+	Percent float64       `json:"percent"`
+}
+
+// Report ranks the mods of a profile by the time spent in their code
+type Report struct {
+	Time    time.Time     `json:"time"`
+	File    string        `json:"file,omitempty"`
+	Threads []string      `json:"threads"`
+	Total   time.Duration `json:"total"`
+	Mods    []ModTime     `json:"mods"`
+}
+
+// Node of a sampled call tree
+type node struct {
+	class    string
+	time     float64 // milliseconds, including callees
+	children []int   // indices into the thread's nodes
+}
+
+type thread struct {
+	name  string
+	time  float64
+	nodes []node
+	roots []int
+}
+
+// ReadFile reads a saved profile and attributes its time
+func ReadFile(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	report, err := Attribute(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spark profile %s: %w", filepath.Base(path), err)
+	}
+	if info, err := os.Stat(path); err == nil {
+		report.Time = info.ModTime()
+	}
+	report.File = path
+	return report, nil
+}
+
+// Attribute decodes a SamplerData message and sums the time of each
+// sampled frame, less its callees, into the mod that owns the innermost
+// mod class on its stack. Only the server thread counts when it was
+// sampled, as that is where TPS is lost.
+func Attribute(data []byte) (*Report, error) {
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = io.ReadAll(zr); err != nil {
+			return nil, err
+		}
+	}
+
+	var threads []thread
+	sources := map[string]string{}
+	err := fields(data, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		switch {
+		case num == 2 && typ == protowire.BytesType:
+			t, err := parseThread(value)
+			if err != nil {
+				return err
+			}
+			threads = append(threads, t)
+		case num == 3 && typ == protowire.BytesType:
+			var class, source string
+			err := fields(value, func(num protowire.Number, typ protowire.Type, value []byte) error {
+				if typ == protowire.BytesType {
+					switch num {
+					case 1:
+						class = string(value)
+					case 2:
+						source = string(value)
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			sources[class] = source
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(threads) == 0 {
+		return nil, fmt.Errorf("no sampled threads")
+	}
+
+	var selected []thread
+	for _, t := range threads {
+		if t.name == "Server thread" {
+			selected = append(selected, t)
+		}
+	}
+	if len(selected) == 0 {
+		selected = threads
+	}
+
+	report := &Report{Time: time.Now()}
+	byMod := map[string]float64{}
+	var total float64
+	for i := range selected {
+		t := &selected[i]
+		report.Threads = append(report.Threads, t.name)
+		var rootTime float64
+		for _, root := range t.roots {
+			rootTime += t.nodes[root].time
+			attribute(t, root, Unattributed, sources, byMod, 0)
+		}
+		// The thread's own time includes samples with no frames
+		total += max(t.time, rootTime)
+		byMod[Unattributed] += max(t.time-rootTime, 0)
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("the profile has no samples")
+	}
+
+	for mod, ms := range byMod {
+		if ms <= 0 {
+			continue
+		}
+		report.Mods = append(report.Mods, ModTime{
+			Mod:     mod,
+			Time:    time.Duration(ms * float64(time.Millisecond)),
+			Percent: ms / total * 100,
+		})
+	}
+	sort.Slice(report.Mods, func(i, j int) bool { return report.Mods[i].Time > report.Mods[j].Time })
+	report.Total = time.Duration(total * float64(time.Millisecond))
+	return report, nil
+}
+
+// attribute adds the self time of a node and its subtree to their owners
+func attribute(t *thread, i int, owner string, sources map[string]string, byMod map[string]float64, depth int) {
+	n := &t.nodes[i]
+	if source, ok := sources[n.class]; ok && source != "" {
+		owner = source
+	}
+	self := n.time
+	// A corrupt file could link nodes in a cycle
+	if depth < 4096 {
+		for _, child := range n.children {
+			self -= t.nodes[child].time
+			attribute(t, child, owner, sources, byMod, depth+1)
+		}
+	}
+	byMod[owner] += max(self, 0)
+}
+
+// parseThread reads a ThreadNode. Spark 1.10 and later store its stack
+// frames as one flat list (field 4) linked by index (children_refs); older
+// profiles nest them (field 3).
+func parseThread(data []byte) (thread, error) {
+	var t thread
+	err := fields(data, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		switch num {
+		case 1:
+			t.name = string(value)
+		case 2:
+			if typ == protowire.Fixed64Type {
+				t.time = fixedDouble(value)
+			}
+		case 3:
+			root, err := parseNested(&t, value)
+			if err != nil {
+				return err
+			}
+			t.roots = append(t.roots, root)
+		case 4:
+			n, err := parseNode(&t, value, false)
+			if err != nil {
+				return err
+			}
+			t.nodes = append(t.nodes, n)
+		case 5:
+			t.time += sumDoubles(typ, value)
+		case 6:
+			t.roots = append(t.roots, varints(typ, value)...)
+		}
+		return nil
+	})
+	if err != nil {
+		return t, err
+	}
+	for _, i := range t.roots {
+		if i < 0 || i >= len(t.nodes) {
+			return t, fmt.Errorf("thread %q refers to missing frame %d", t.name, i)
+		}
+	}
+	for _, n := range t.nodes {
+		for _, i := range n.children {
+			if i < 0 || i >= len(t.nodes) {
+				return t, fmt.Errorf("thread %q refers to missing frame %d", t.name, i)
+			}
+		}
+	}
+	return t, nil
+}
+
+// parseNested adds an old-style StackTraceNode and its nested children to
+// the thread and returns its index
+func parseNested(t *thread, data []byte) (int, error) {
+	n, err := parseNode(t, data, true)
+	if err != nil {
+		return 0, err
+	}
+	t.nodes = append(t.nodes, n)
+	return len(t.nodes) - 1, nil
+}
+
+// parseNode reads a StackTraceNode; nested children are added to the
+// thread first, when the profile has them
+func parseNode(t *thread, data []byte, nested bool) (node, error) {
+	var n node
+	err := fields(data, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		switch num {
+		case 1:
+			if typ == protowire.Fixed64Type {
+				n.time = fixedDouble(value)
+			}
+		case 2:
+			if nested && typ == protowire.BytesType {
+				child, err := parseNested(t, value)
+				if err != nil {
+					return err
+				}
+				n.children = append(n.children, child)
+			}
+		case 3:
+			n.class = string(value)
+		case 8:
+			n.time += sumDoubles(typ, value)
+		case 9:
+			n.children = append(n.children, varints(typ, value)...)
+		}
+		return nil
+	})
+	return n, err
+}
+
+// fields calls fn with each field of a message; varints and fixed values
+// come as their encoded bytes
+func fields(data []byte, fn func(protowire.Number, protowire.Type, []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		var value []byte
+		if typ == protowire.BytesType {
+			v, m := protowire.ConsumeBytes(data)
+			if m < 0 {
+				return protowire.ParseError(m)
+			}
+			value, n = v, m
+		} else {
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			value = data[:n]
+		}
+		if err := fn(num, typ, value); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+func fixedDouble(value []byte) float64 {
+	v, _ := protowire.ConsumeFixed64(value)
+	return math.Float64frombits(v)
+}
+
+// sumDoubles adds up a repeated double, packed or not
+func sumDoubles(typ protowire.Type, value []byte) float64 {
+	if typ == protowire.Fixed64Type {
+		return fixedDouble(value)
+	}
+	var sum float64
+	for len(value) >= 8 {
+		sum += fixedDouble(value[:8])
+		value = value[8:]
+	}
+	return sum
+}
+
+// varints decodes a repeated int32, packed or not
+func varints(typ protowire.Type, value []byte) []int {
+	var out []int
+	for len(value) > 0 {
+		v, n := protowire.ConsumeVarint(value)
+		if n < 0 {
+			break
+		}
+		out = append(out, int(int32(v)))
+		if typ == protowire.VarintType {
+			break
+		}
+		value = value[n:]
+	}
+	return out
+}
+
+// String renders the ranking, the top mods first
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s of %s sampled on %s\n", r.Time.Format("2006-01-02 15:04"), r.Total.Round(time.Millisecond), strings.Join(r.Threads, ", "))
+	width := 0
+	for _, m := range r.Mods {
+		width = max(width, len(m.Mod))
+	}
+	for i, m := range r.Mods {
+		fmt.Fprintf(&b, "%3d. %-*s %5.1f%%  %s\n", i+1, width, m.Mod, m.Percent, m.Time.Round(time.Millisecond))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Newest returns the most recently saved profile in the server dir, if
+// any
+func Newest(serverDir string) (string, time.Time) {
+	var path string
+	var newest time.Time
+	for _, dir := range Dirs {
+		matches, _ := filepath.Glob(filepath.Join(serverDir, dir, "*"+Ext))
+		for _, m := range matches {
+			if info, err := os.Stat(m); err == nil && info.ModTime().After(newest) {
+				path, newest = m, info.ModTime()
+			}
+		}
+	}
+	return path, newest
+}
@@ -2,6 +2,8 @@ package stats
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -82,6 +84,24 @@ func FormatDurationShort(d time.Duration) string {
 	return fmt.Sprintf("%dm", minutes)
 }
 
+// ParseDuration reads a duration that may also be in whole days or weeks,
+// such as 30m, 12h, 7d or 2w
+func ParseDuration(text string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if number, ok := strings.CutSuffix(text, suffix); ok {
+			if n, err := strconv.Atoi(number); err == nil && n > 0 {
+				return time.Duration(n) * unit, nil
+			}
+		}
+	}
+	d, err := time.ParseDuration(text)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q, want e.g. 30m, 12h, 7d or 2w", text)
+	}
+	return d, nil
+}
+
 // FormatPercent formats a percentage with color coding thresholds
 func FormatPercent(value float64) string {
 	return fmt.Sprintf("%.1f%%", value)
@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"os/user"
+
+	"mcserver-manager/internal/audit"
+	"mcserver-manager/internal/backup"
+	"mcserver-manager/internal/server"
+)
+
+// Backend is what the TUI drives: a local server or a remote agent
+type Backend interface {
+	Start() error
+	Stop() error
+	Restart() error
+	SendCommand(command string) error
+	RunAction(line string) (string, error)
+	GetStats() server.ServerStats
+	OutputChan() <-chan string
+	ListBackups() ([]backup.BackupInfo, error)
+}
+
+// localBackend drives a local server, recording what the user does in the
+// audit log. Remote agents audit on their side.
+type localBackend struct {
+	*server.Server
+	actor  string
+	output <-chan string // the server's own channel when nil
+}
+
+func newLocalBackend(srv *server.Server) *localBackend {
+	actor := "local"
+	if u, err := user.Current(); err == nil {
+		actor = u.Username
+	}
+	return &localBackend{Server: srv, actor: actor}
+}
+
+func (l *localBackend) OutputChan() <-chan string {
+	if l.output != nil {
+		return l.output
+	}
+	return l.Server.OutputChan()
+}
+
+func (l *localBackend) SendCommand(command string) error {
+	return l.SendCommandAs(audit.SourceTUI, l.actor, command)
+}
+
+func (l *localBackend) RunAction(line string) (string, error) {
+	return l.Server.RunAction(audit.SourceTUI, l.actor, line)
+}
+
+func (l *localBackend) Start() error {
+	err := l.Server.Start()
+	l.RecordAction(audit.SourceTUI, l.actor, "start", "", err)
+	return err
+}
+
+func (l *localBackend) Stop() error {
+	err := l.Server.Stop()
+	l.RecordAction(audit.SourceTUI, l.actor, "stop", "", err)
+	return err
+}
+
+func (l *localBackend) Restart() error {
+	err := l.Server.Restart()
+	l.RecordAction(audit.SourceTUI, l.actor, "restart", "", err)
+	return err
+}
@@ -0,0 +1,173 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"mcserver-manager/internal/backup"
+	"mcserver-manager/internal/i18n"
+	"mcserver-manager/internal/stats"
+)
+
+// backupView lists the backups in place of the console, opened with [B].
+// Restoring one asks first, since it replaces the world.
+type backupView struct {
+	open       bool
+	loading    bool
+	backups    []backup.BackupInfo // newest first
+	err        error
+	selected   int
+	confirming bool
+	restoring  string // backup being restored
+}
+
+// backupsMsg carries the backups listed when the view opened
+type backupsMsg struct {
+	backups []backup.BackupInfo
+	err     error
+}
+
+// restoreResultMsg reports how restoring a backup went
+type restoreResultMsg struct {
+	name   string
+	output string
+	err    error
+}
+
+// openBackups shows the backup view and lists the backups in the
+// background
+func (m *Model) openBackups() tea.Cmd {
+	m.backups = backupView{open: true, loading: true}
+	srv := m.srv
+	return func() tea.Msg {
+		backups, err := srv.ListBackups()
+		// The manager lists them oldest first
+		for i, j := 0, len(backups)-1; i < j; i, j = i+1, j-1 {
+			backups[i], backups[j] = backups[j], backups[i]
+		}
+		return backupsMsg{backups: backups, err: err}
+	}
+}
+
+// updateBackups handles keys and results while the backup view is open
+func (m *Model) updateBackups(msg tea.Msg) tea.Cmd {
+	v := &m.backups
+	switch msg := msg.(type) {
+	case backupsMsg:
+		v.loading = false
+		v.backups, v.err = msg.backups, msg.err
+
+	case restoreResultMsg:
+		m.backups = backupView{}
+		m.appendManagerOutput(":restore "+msg.name, msg.output, msg.err)
+
+	case tea.KeyMsg:
+		if v.restoring != "" {
+			return nil
+		}
+		if v.confirming {
+			switch msg.String() {
+			case "y", "Y":
+				v.confirming = false
+				return m.restoreBackup(v.backups[v.selected].Name)
+			case "n", "N", "esc":
+				v.confirming = false
+			}
+			return nil
+		}
+		switch msg.String() {
+		case "esc", "b", "q":
+			m.backups = backupView{}
+		case "up", "k":
+			if v.selected > 0 {
+				v.selected--
+			}
+		case "down", "j":
+			if v.selected < len(v.backups)-1 {
+				v.selected++
+			}
+		case "enter":
+			if len(v.backups) > 0 {
+				v.confirming = true
+			}
+		}
+	}
+	return nil
+}
+
+// restoreBackup runs the restore action in the background: the server is
+// stopped, the world replaced and the server started again
+func (m *Model) restoreBackup(name string) tea.Cmd {
+	m.backups.restoring = name
+	srv := m.srv
+	return func() tea.Msg {
+		output, err := srv.RunAction("restore " + name)
+		return restoreResultMsg{name: name, output: output, err: err}
+	}
+}
+
+// appendManagerOutput adds a manager action and its result to the console
+func (m *Model) appendManagerOutput(line, output string, err error) {
+	style := lipgloss.NewStyle().Foreground(primaryColor)
+	m.consoleLines = append(m.consoleLines, style.Render("[manager] "+line))
+	if output != "" {
+		for _, line := range strings.Split(output, "\n") {
+			m.consoleLines = append(m.consoleLines, style.Render("  "+line))
+		}
+	}
+	if err != nil {
+		m.consoleLines = append(m.consoleLines, lipgloss.NewStyle().Foreground(errorColor).Render("  "+err.Error()))
+	}
+}
+
+// renderBackups draws the backup view at the size of the console panels
+func (m *Model) renderBackups(width, height int) string {
+	v := m.backups
+	var b strings.Builder
+	b.WriteString(headerStyle.Render("💾 "+i18n.T("BACKUPS")) + "\n")
+	b.WriteString(dimStyle.Render(strings.Repeat("─", width)) + "\n")
+
+	rows := height - 4
+	switch {
+	case v.loading:
+		b.WriteString(dimStyle.Render(i18n.T("Loading...")) + "\n")
+	case v.err != nil:
+		b.WriteString(lipgloss.NewStyle().Foreground(errorColor).Render(v.err.Error()) + "\n")
+	case len(v.backups) == 0:
+		b.WriteString(dimStyle.Render(i18n.T("No backups yet")) + "\n")
+	default:
+		// Keep the selection in sight when there are more than fit
+		start := 0
+		if rows > 0 && v.selected >= rows {
+			start = v.selected - rows + 1
+		}
+		for i := start; i < len(v.backups) && i-start < rows; i++ {
+			info := v.backups[i]
+			line := fmt.Sprintf("%-32s %10s  %s", info.Name, stats.FormatBytes(uint64(info.Size)), i18n.T("%s ago", stats.FormatDurationShort(time.Since(info.CreatedAt))))
+			if i == v.selected {
+				b.WriteString(lipgloss.NewStyle().Foreground(primaryColor).Bold(true).Render("▶ "+line) + "\n")
+			} else {
+				b.WriteString("  " + line + "\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// renderBackupsHelp is the help line while the backup view is open
+func (m *Model) renderBackupsHelp() string {
+	v := m.backups
+	switch {
+	case v.restoring != "":
+		return lipgloss.NewStyle().Foreground(warningColor).Bold(true).Render(i18n.T("Restoring %s: the server is stopped meanwhile and started again afterwards...", v.restoring))
+	case v.confirming:
+		info := v.backups[v.selected]
+		return lipgloss.NewStyle().Foreground(warningColor).Bold(true).Render(i18n.T(
+			"Restore %s from %s? The server is stopped and the world replaced. [Y]es [N]o", info.Name, info.CreatedAt.Format("2006-01-02 15:04")))
+	}
+	return dimStyle.Render(i18n.T("[↑↓]Select [Enter]Restore [Esc/B]Back"))
+}
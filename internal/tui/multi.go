@@ -0,0 +1,144 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"mcserver-manager/internal/server"
+)
+
+// multiModel shows one of several servers at a time under a selector bar.
+// Every server keeps its own Model, so switching keeps its console and
+// graphs; they all update on the shared tick.
+type multiModel struct {
+	names  []string
+	models []*Model
+	active int
+}
+
+// routedMsg is a message for the Model that asked for it, such as the
+// result of its ":" action
+type routedMsg struct {
+	index int
+	msg   tea.Msg
+}
+
+// routeTo tags what cmd returns for model i
+func routeTo(i int, cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		switch msg := cmd().(type) {
+		case nil:
+			return nil
+		case tea.QuitMsg:
+			return msg
+		case tea.BatchMsg:
+			routed := make(tea.BatchMsg, len(msg))
+			for j, c := range msg {
+				routed[j] = routeTo(i, c)
+			}
+			return routed
+		default:
+			return routedMsg{index: i, msg: msg}
+		}
+	}
+}
+
+// RunMulti starts every server in registry and runs the TUI for them,
+// showing selected first ("" for the first one). [ and ] switch servers.
+// The servers are stopped when the TUI exits.
+func RunMulti(registry *server.Registry, selected string) error {
+	m := &multiModel{}
+	for i, inst := range registry.Instances() {
+		child := NewModel(inst.Config)
+		child.srv = newLocalBackend(inst.Server)
+		child.embedded = true
+		m.names = append(m.names, inst.Name)
+		m.models = append(m.models, child)
+		if inst.Name == selected {
+			m.active = i
+		}
+		go inst.Server.Start()
+	}
+
+	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+
+	registry.StopAll()
+	return err
+}
+
+func (m *multiModel) Init() tea.Cmd {
+	cmds := []tea.Cmd{tickCmd()}
+	for i, child := range m.models {
+		cmds = append(cmds, routeTo(i, child.Init()))
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m *multiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case routedMsg:
+		return m, m.update(msg.index, msg.msg)
+
+	case tickMsg:
+		cmds := []tea.Cmd{tickCmd()}
+		for i := range m.models {
+			cmds = append(cmds, m.update(i, msg))
+		}
+		return m, tea.Batch(cmds...)
+
+	case tea.WindowSizeMsg:
+		// The selector bar takes the top line
+		msg.Height--
+		var cmds []tea.Cmd
+		for i := range m.models {
+			cmds = append(cmds, m.update(i, msg))
+		}
+		return m, tea.Batch(cmds...)
+
+	case tea.KeyMsg:
+		if !m.models[m.active].inputFocused {
+			switch msg.String() {
+			case "[":
+				m.active = (m.active + len(m.models) - 1) % len(m.models)
+				return m, nil
+			case "]":
+				m.active = (m.active + 1) % len(m.models)
+				return m, nil
+			}
+		}
+	}
+	return m, m.update(m.active, msg)
+}
+
+// update passes msg to model i
+func (m *multiModel) update(i int, msg tea.Msg) tea.Cmd {
+	_, cmd := m.models[i].Update(msg)
+	return routeTo(i, cmd)
+}
+
+func (m *multiModel) View() string {
+	active := m.models[m.active]
+	if !active.ready || active.quitting {
+		return active.View()
+	}
+	return m.renderSelector() + "\n" + active.View()
+}
+
+// renderSelector lists the servers, colored by status, with the shown one
+// highlighted
+func (m *multiModel) renderSelector() string {
+	var tabs []string
+	for i, name := range m.names {
+		style := lipgloss.NewStyle().Padding(0, 1).Foreground(lipgloss.Color(m.models[i].serverStats.Status.Color()))
+		if i == m.active {
+			style = style.Bold(true).Underline(true)
+		}
+		tabs = append(tabs, style.Render("● "+name))
+	}
+	return strings.Join(tabs, "") + dimStyle.Render("  [ ] Switch server")
+}
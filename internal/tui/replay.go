@@ -0,0 +1,170 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"mcserver-manager/internal/backup"
+	"mcserver-manager/internal/recording"
+	"mcserver-manager/internal/server"
+)
+
+// replayBackend plays a console recording into the TUI. The server
+// controls do nothing; ":pause", ":resume" and ":speed <n>" steer playback.
+type replayBackend struct {
+	player  *recording.Player
+	maxIdle time.Duration
+	output  chan string
+
+	mu       sync.Mutex
+	speed    float64
+	paused   bool
+	position time.Duration
+	done     bool
+}
+
+// RunReplay plays a console recording in the TUI at speed times the
+// original pace, cutting pauses to maxIdle when it is set
+func RunReplay(path string, speed float64, maxIdle time.Duration) error {
+	player, err := recording.Open(path)
+	if err != nil {
+		return err
+	}
+	defer player.Close()
+
+	r := &replayBackend{player: player, maxIdle: maxIdle, speed: speed, output: make(chan string, 1000)}
+	stop := make(chan struct{})
+	defer close(stop)
+	go r.play(stop)
+
+	m := NewModel(nil)
+	m.srv = r
+	m.replay = fmt.Sprintf("%s (%s)", filepath.Base(path), player.Header.Started.Format("2006-01-02 15:04"))
+
+	_, err = tea.NewProgram(m, tea.WithAltScreen()).Run()
+	return err
+}
+
+func (r *replayBackend) play(stop <-chan struct{}) {
+	var last time.Duration
+	for {
+		frame, ok := r.player.Next()
+		if !ok {
+			r.mu.Lock()
+			r.done = true
+			r.mu.Unlock()
+			return
+		}
+
+		for waited := time.Duration(0); ; {
+			r.mu.Lock()
+			paused, speed := r.paused, r.speed
+			r.mu.Unlock()
+
+			wait := time.Duration(float64(frame.Offset-last) / speed)
+			if r.maxIdle > 0 && wait > r.maxIdle {
+				wait = r.maxIdle
+			}
+			if !paused && waited >= wait {
+				break
+			}
+			// Step in small increments so pausing and speed changes apply
+			// right away
+			select {
+			case <-stop:
+				return
+			case <-time.After(50 * time.Millisecond):
+			}
+			if !paused {
+				waited += 50 * time.Millisecond
+			}
+		}
+		last = frame.Offset
+
+		r.mu.Lock()
+		r.position = frame.Offset
+		r.mu.Unlock()
+		select {
+		case r.output <- frame.Line:
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (r *replayBackend) Start() error   { return errReplay }
+func (r *replayBackend) Stop() error    { return errReplay }
+func (r *replayBackend) Restart() error { return errReplay }
+
+func (r *replayBackend) SendCommand(command string) error { return errReplay }
+
+func (r *replayBackend) ListBackups() ([]backup.BackupInfo, error) { return nil, errReplay }
+
+func (r *replayBackend) RunAction(line string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", errReplay
+	}
+	switch fields[0] {
+	case "pause":
+		r.paused = true
+		return "Paused", nil
+	case "resume":
+		r.paused = false
+		return "Resumed", nil
+	case "speed":
+		if len(fields) != 2 {
+			return "", fmt.Errorf("usage: speed <multiplier>")
+		}
+		speed, err := strconv.ParseFloat(strings.TrimSuffix(fields[1], "x"), 64)
+		if err != nil || speed <= 0 {
+			return "", fmt.Errorf("invalid speed %q", fields[1])
+		}
+		r.speed = speed
+		return fmt.Sprintf("Playing at %gx", speed), nil
+	case "help":
+		return ":pause, :resume, :speed <multiplier>", nil
+	}
+	return "", errReplay
+}
+
+// GetStats reports playback: running while lines remain, with the position
+// in the recording as the uptime
+func (r *replayBackend) GetStats() server.ServerStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	st := server.ServerStats{Status: server.StatusRunning, Uptime: r.position}
+	if r.done {
+		st.Status = server.StatusStopped
+	}
+	return st
+}
+
+func (r *replayBackend) OutputChan() <-chan string {
+	return r.output
+}
+
+// replayStatus describes playback for the help line
+func (r *replayBackend) replayStatus() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch {
+	case r.done:
+		return "end of recording"
+	case r.paused:
+		return "paused"
+	}
+	return fmt.Sprintf("%gx", r.speed)
+}
+
+var errReplay = fmt.Errorf("not available while replaying a recording (:pause, :resume, :speed <n>)")
@@ -10,6 +10,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"mcserver-manager/internal/i18n"
 	"mcserver-manager/internal/server"
 	"mcserver-manager/internal/stats"
 )
@@ -34,6 +35,8 @@ var serverCommands = []string{
 	"say <msg> - Broadcast",
 	"kick <player>",
 	"ban <player>",
+	":tempban <p> <7d>",
+	":profile 60s - Mod lag",
 	"op <player>",
 	"tp <p> <x> <y> <z>",
 	"give <p> <item>",
@@ -41,11 +44,12 @@ var serverCommands = []string{
 	"weather <type>",
 	"save-all",
 	"stop",
+	":help - Manager actions",
 }
 
 type Model struct {
 	config      *server.Config
-	srv         *server.Server
+	srv         Backend
 	serverStats server.ServerStats
 
 	consoleViewport viewport.Model
@@ -65,37 +69,73 @@ type Model struct {
 	tpsHistory    []float64
 	memoryHistory []float64
 	cpuHistory    []float64
+	diskHistory   []float64 // read plus write rate
 
-	playerEvents []PlayerEvent
-}
+	// Join, leave and death events read from the console in replays,
+	// which have no server events
+	playerEvents []server.ServerEvent
+
+	// replay names the recording being played back, if any
+	replay string
+
+	backups backupView
 
-type PlayerEvent struct {
-	Time    time.Time
-	Player  string
-	Type    string
-	Message string
+	// embedded models are driven by a multiModel, which owns the ticks
+	embedded bool
 }
 
 type tickMsg time.Time
 
+// actionResultMsg carries the output of a ":" manager action
+type actionResultMsg struct {
+	line   string
+	output string
+	err    error
+}
+
 func Run(config *server.Config) error {
+	srv := server.New(config)
+	srv.WatchReloadSignal()
+	return RunServer(config, srv, srv.OutputChan())
+}
+
+// RunServer starts srv and runs the TUI for it, reading the console from
+// output, e.g. when the control API shares it. The server is stopped when
+// the TUI exits.
+func RunServer(config *server.Config, srv *server.Server, output <-chan string) error {
 	m := NewModel(config)
 	p := tea.NewProgram(m, tea.WithAltScreen())
 
-	m.srv = server.New(config)
+	backend := newLocalBackend(srv)
+	backend.output = output
+	m.srv = backend
 	go func() {
-		m.srv.Start()
+		srv.Start()
 	}()
 
 	_, err := p.Run()
 
-	if m.srv != nil {
-		m.srv.Stop()
-	}
+	srv.Stop()
 
 	return err
 }
 
+// RunRemote runs the TUI against an already running backend, such as a
+// remote agent. The server is left running when the TUI exits.
+func RunRemote(backend Backend) error {
+	m := NewModel(nil)
+	m.srv = backend
+
+	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	return err
+}
+
+// RunMonitor runs the TUI for a server launched by something else, which
+// is left running when the TUI exits
+func RunMonitor(srv *server.Server) error {
+	return RunRemote(newLocalBackend(srv))
+}
+
 func NewModel(config *server.Config) *Model {
 	ti := textinput.New()
 	ti.Placeholder = "Enter command..."
@@ -114,12 +154,16 @@ func NewModel(config *server.Config) *Model {
 		tpsHistory:      make([]float64, 0, 60),
 		memoryHistory:   make([]float64, 0, 60),
 		cpuHistory:      make([]float64, 0, 60),
-		playerEvents:    make([]PlayerEvent, 0, 100),
+		diskHistory:     make([]float64, 0, 60),
+		playerEvents:    make([]server.ServerEvent, 0, 100),
 		autoScroll:      true,
 	}
 }
 
 func (m *Model) Init() tea.Cmd {
+	if m.embedded {
+		return textinput.Blink
+	}
 	return tea.Batch(textinput.Blink, tickCmd())
 }
 
@@ -128,6 +172,9 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.backups.open && msg.String() != "ctrl+c" {
+			return m, m.updateBackups(msg)
+		}
 		switch msg.String() {
 		case "ctrl+c":
 			m.quitting = true
@@ -149,7 +196,11 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				cmd := m.commandInput.Value()
 				m.commandInput.Reset()
 				if m.srv != nil {
-					m.srv.SendCommand(cmd)
+					if strings.HasPrefix(cmd, ":") {
+						cmds = append(cmds, m.runAction(cmd))
+					} else {
+						m.srv.SendCommand(cmd)
+					}
 				}
 			}
 		case "r":
@@ -164,6 +215,14 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					go m.srv.Start()
 				}
 			}
+		case "b":
+			if !m.inputFocused && m.srv != nil {
+				cmds = append(cmds, m.openBackups())
+			}
+		case "y":
+			if !m.inputFocused && m.srv != nil && m.serverStats.EULARequired {
+				cmds = append(cmds, m.acceptEULA())
+			}
 		case "left", "right":
 			if !m.inputFocused && m.showSidePanel() {
 				m.focusPanel = (m.focusPanel + 1) % 2
@@ -207,6 +266,12 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.ready = true
 		m.recalculateLayout()
 
+	case actionResultMsg:
+		m.appendManagerOutput(msg.line, msg.output, msg.err)
+
+	case backupsMsg, restoreResultMsg:
+		cmds = append(cmds, m.updateBackups(msg))
+
 	case tickMsg:
 		if m.srv != nil {
 			m.serverStats = m.srv.GetStats()
@@ -230,6 +295,11 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.cpuHistory = m.cpuHistory[1:]
 			}
 
+			m.diskHistory = append(m.diskHistory, m.serverStats.DiskReadRate+m.serverStats.DiskWriteRate)
+			if len(m.diskHistory) > 60 {
+				m.diskHistory = m.diskHistory[1:]
+			}
+
 			// Read ALL available lines (fast drain)
 			for {
 				select {
@@ -239,7 +309,9 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					if len(m.consoleLines) > 1000 {
 						m.consoleLines = m.consoleLines[1:]
 					}
-					m.parsePlayerEvent(line)
+					if m.replay != "" {
+						m.parsePlayerEvent(line)
+					}
 				default:
 					goto doneReading
 				}
@@ -252,7 +324,9 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.playerViewport.SetContent(m.renderPlayerPanel())
 		}
 
-		cmds = append(cmds, tickCmd())
+		if !m.embedded {
+			cmds = append(cmds, tickCmd())
+		}
 	}
 
 	if m.inputFocused {
@@ -268,6 +342,29 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// runAction runs a ":" manager action in the background, since actions
+// such as switching worlds restart the server
+func (m *Model) runAction(line string) tea.Cmd {
+	srv := m.srv
+	return func() tea.Msg {
+		output, err := srv.RunAction(strings.TrimPrefix(line, ":"))
+		return actionResultMsg{line: line, output: output, err: err}
+	}
+}
+
+// acceptEULA accepts the EULA for the operator, who was shown where to read
+// it, and starts the server
+func (m *Model) acceptEULA() tea.Cmd {
+	srv := m.srv
+	return func() tea.Msg {
+		output, err := srv.RunAction("eula accept")
+		if err == nil {
+			go srv.Start()
+		}
+		return actionResultMsg{line: ":eula accept", output: output, err: err}
+	}
+}
+
 func (m *Model) colorizeConsoleLine(line string) string {
 	lowerLine := strings.ToLower(line)
 
@@ -350,16 +447,13 @@ func (m *Model) parsePlayerEvent(line string) {
 	lowerLine := strings.ToLower(line)
 
 	if strings.Contains(line, "joined the game") {
-		name := extractPlayerName(line)
-		m.addPlayerEvent(name, "join", "Joined")
+		m.addPlayerEvent(server.EventPlayerJoin, extractPlayerName(line)+" joined the game")
 	} else if strings.Contains(line, "left the game") {
-		name := extractPlayerName(line)
-		m.addPlayerEvent(name, "leave", "Left")
+		m.addPlayerEvent(server.EventPlayerLeave, extractPlayerName(line)+" left the game")
 	} else if strings.Contains(lowerLine, "was slain") || strings.Contains(lowerLine, "died") ||
 		strings.Contains(lowerLine, "was killed") || strings.Contains(lowerLine, "drowned") ||
 		strings.Contains(lowerLine, "burned") || strings.Contains(lowerLine, "fell") {
-		name := extractPlayerName(line)
-		m.addPlayerEvent(name, "death", "Died")
+		m.addPlayerEvent(server.EventInfo, extractPlayerName(line)+" died")
 	}
 }
 
@@ -374,10 +468,9 @@ func extractPlayerName(text string) string {
 	return "Player"
 }
 
-func (m *Model) addPlayerEvent(player, eventType, message string) {
-	event := PlayerEvent{
+func (m *Model) addPlayerEvent(eventType server.EventType, message string) {
+	event := server.ServerEvent{
 		Time:    time.Now(),
-		Player:  player,
 		Type:    eventType,
 		Message: message,
 	}
@@ -391,22 +484,91 @@ func (m *Model) renderPlayerPanel() string {
 	var b strings.Builder
 	panelWidth := m.playerViewport.Width
 
-	header := fmt.Sprintf("👥 PLAYERS %d/%d", m.serverStats.PlayerCount, m.serverStats.MaxPlayers)
+	if m.serverStats.ShareAddress != "" || len(m.serverStats.LANAddresses) > 0 {
+		b.WriteString(headerStyle.Render("🌐 "+i18n.T("CONNECT")) + "\n")
+		if m.serverStats.ShareAddress != "" {
+			style := valueStyle
+			if m.serverStats.ShareVerified {
+				style = playerOnlineStyle.Bold(true)
+			}
+			b.WriteString(style.Render(m.serverStats.ShareAddress) + "\n")
+		}
+		for _, addr := range m.serverStats.LANAddresses {
+			b.WriteString(dimStyle.Render("LAN "+addr) + "\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if sw := m.serverStats.Software; sw != nil {
+		b.WriteString(headerStyle.Render("⚙ "+i18n.T("SERVER")) + "\n")
+		b.WriteString(valueStyle.Render(sw.String()) + "\n")
+		if m.serverStats.JavaVersion > 0 {
+			b.WriteString(dimStyle.Render(fmt.Sprintf("Java %d", m.serverStats.JavaVersion)) + "\n")
+		}
+		if p := m.serverStats.TPS1h; p.Samples > 0 {
+			b.WriteString(dimStyle.Render(fmt.Sprintf("TPS p95 %.1f 5m, %.1f 1h", m.serverStats.TPS5m.P95, p.P95)) + "\n")
+		}
+		if l := m.serverStats.Lifetime; l.Starts > 0 {
+			b.WriteString(dimStyle.Render(i18n.T("Up %s over %d starts", stats.FormatDurationShort(l.Uptime), l.Starts)) + "\n")
+			if l.Crashes > 0 {
+				b.WriteString(dimStyle.Render(i18n.T("Crashes: %d, last %s ago", l.Crashes, stats.FormatDurationShort(time.Since(l.LastCrash)))) + "\n")
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	if st := m.serverStats; st.DiskRead > 0 || st.DiskWrite > 0 {
+		b.WriteString(headerStyle.Render("💽 "+i18n.T("DISK")) + "\n")
+		b.WriteString(valueStyle.Render(fmt.Sprintf("R %s  W %s", stats.FormatBytesPerSec(st.DiskReadRate), stats.FormatBytesPerSec(st.DiskWriteRate))) + "\n")
+		b.WriteString(dimStyle.Render(stats.Sparkline(m.diskHistory, panelWidth)) + "\n")
+		if st.DiskUtil > 0 {
+			style := dimStyle
+			if st.DiskUtil >= 90 {
+				style = lipgloss.NewStyle().Foreground(warningColor).Bold(true)
+			}
+			b.WriteString(style.Render(i18n.T("Disk %.0f%% busy", st.DiskUtil)) + "\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if w := m.serverStats.World; w != nil {
+		b.WriteString(headerStyle.Render("🗺 "+i18n.T("WORLD")) + "\n")
+		name := w.LevelName
+		if w.Version != "" {
+			name += " (" + w.Version + ")"
+		}
+		b.WriteString(valueStyle.Render(name) + "\n")
+		if w.HasSeed {
+			b.WriteString(dimStyle.Render(i18n.T("Seed %d", w.Seed)) + "\n")
+		}
+		b.WriteString(dimStyle.Render(i18n.T("Spawn %d, %d, %d", w.SpawnX, w.SpawnY, w.SpawnZ)) + "\n")
+		mode := w.GameTypeName()
+		if w.Hardcore {
+			mode = "hardcore"
+		}
+		b.WriteString(dimStyle.Render(mode) + "\n")
+		b.WriteString("\n")
+	}
+
+	header := "👥 " + i18n.T("PLAYERS %d/%d", m.serverStats.PlayerCount, m.serverStats.MaxPlayers)
 	b.WriteString(headerStyle.Render(header) + "\n")
 	b.WriteString(dimStyle.Render(strings.Repeat("─", panelWidth)) + "\n")
 
 	if len(m.serverStats.Players) == 0 {
-		b.WriteString(dimStyle.Render("No players online\n"))
+		b.WriteString(dimStyle.Render(i18n.T("No players online")) + "\n")
 	} else {
 		for _, player := range m.serverStats.Players {
 			pt := time.Since(player.JoinedAt)
 			line := fmt.Sprintf("● %s (%s)", player.Name, stats.FormatDurationShort(pt))
+			if player.Bedrock {
+				line += " [BE]"
+			}
 			b.WriteString(playerOnlineStyle.Render(line) + "\n")
 		}
 	}
 
 	b.WriteString("\n")
-	b.WriteString(headerStyle.Render("📋 EVENTS") + "\n")
+	b.WriteString(headerStyle.Render("📋 "+i18n.T("EVENTS")) + "\n")
 	b.WriteString(dimStyle.Render(strings.Repeat("─", panelWidth)) + "\n")
 
 	maxEvents := (m.playerViewport.Height - 10) / 1
@@ -417,37 +579,43 @@ func (m *Model) renderPlayerPanel() string {
 		maxEvents = 10
 	}
 
-	startIdx := len(m.playerEvents) - maxEvents
+	// Event types left out of the panel (--event-panel) are only logged
+	events := m.serverStats.RecentEvents
+	if m.replay != "" {
+		events = m.playerEvents
+	}
+	var shown []server.ServerEvent
+	for _, ev := range events {
+		if ev.Type.InPanel() || m.replay != "" {
+			shown = append(shown, ev)
+		}
+	}
+	startIdx := len(shown) - maxEvents
 	if startIdx < 0 {
 		startIdx = 0
 	}
 
-	if len(m.playerEvents) == 0 {
-		b.WriteString(dimStyle.Render("No events yet\n"))
+	if len(shown) == 0 {
+		b.WriteString(dimStyle.Render(i18n.T("No events yet")) + "\n")
 	} else {
-		for _, ev := range m.playerEvents[startIdx:] {
-			icon := "•"
-			style := dimStyle
-			switch ev.Type {
-			case "join":
-				icon = "→"
-				style = lipgloss.NewStyle().Foreground(successColor)
-			case "leave":
-				icon = "←"
-				style = lipgloss.NewStyle().Foreground(errorColor)
-			case "death":
-				icon = "☠"
-				style = lipgloss.NewStyle().Foreground(warningColor)
-			}
+		for _, ev := range shown[startIdx:] {
+			style := lipgloss.NewStyle().Foreground(lipgloss.Color(ev.Type.Color()))
 			timeStr := ev.Time.Format("15:04")
-			b.WriteString(dimStyle.Render(timeStr+" ") + style.Render(icon+" "+ev.Player) + "\n")
+			text := ev.Message
+			if ev.Type.Icon() != "" {
+				text = ev.Type.Icon() + " " + text
+			}
+			if room := panelWidth - len(timeStr) - 1; room > 1 {
+				style = style.MaxWidth(room)
+			}
+			b.WriteString(dimStyle.Render(timeStr+" ") + style.Render(text) + "\n")
 		}
 	}
 
 	remainingHeight := m.playerViewport.Height - strings.Count(b.String(), "\n") - 3
 	if remainingHeight > 4 {
 		b.WriteString("\n")
-		b.WriteString(headerStyle.Render("⌨ COMMANDS") + "\n")
+		b.WriteString(headerStyle.Render("⌨ "+i18n.T("COMMANDS")) + "\n")
 		b.WriteString(dimStyle.Render(strings.Repeat("─", panelWidth)) + "\n")
 
 		cmdCount := remainingHeight - 1
@@ -464,10 +632,10 @@ func (m *Model) renderPlayerPanel() string {
 
 func (m *Model) View() string {
 	if !m.ready {
-		return "Loading..."
+		return i18n.T("Loading...")
 	}
 	if m.quitting {
-		return "Shutting down...\n"
+		return i18n.T("Shutting down...") + "\n"
 	}
 
 	m.recalculateLayout()
@@ -479,7 +647,16 @@ func (m *Model) View() string {
 
 	m.consoleViewport.SetContent(strings.Join(m.consoleLines, "\n"))
 
-	if m.showSidePanel() {
+	if m.backups.open {
+		width := m.width - 4
+		height := m.consoleViewport.Height
+		b.WriteString(lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(primaryColor).
+			Width(width + 2).
+			Height(height + 2).
+			Render(m.renderBackups(width, height)))
+	} else if m.showSidePanel() {
 		leftBorderColor := borderColor
 		if m.focusPanel == 0 {
 			leftBorderColor = primaryColor
@@ -528,28 +705,28 @@ func (m *Model) View() string {
 
 func (m *Model) renderStatusBar() string {
 	statusIcon := "⭕"
-	statusText := "STOP"
+	statusText := i18n.T("STOP")
 	statusColor := errorColor
 	switch m.serverStats.Status {
 	case server.StatusRunning:
 		statusIcon = "🟢"
-		statusText = "RUN"
+		statusText = i18n.T("RUN")
 		statusColor = successColor
 	case server.StatusStarting:
 		statusIcon = "🟡"
-		statusText = "STARTING"
+		statusText = i18n.T("STARTING")
 		statusColor = warningColor
 	case server.StatusRestarting:
 		statusIcon = "🟡"
-		statusText = "RESTART"
+		statusText = i18n.T("RESTART")
 		statusColor = warningColor
 	case server.StatusStopping:
 		statusIcon = "🟡"
-		statusText = "STOPPING"
+		statusText = i18n.T("STOPPING")
 		statusColor = warningColor
 	case server.StatusCrashed:
 		statusIcon = "🔴"
-		statusText = "CRASH"
+		statusText = i18n.T("CRASH")
 		statusColor = errorColor
 	}
 
@@ -566,13 +743,13 @@ func (m *Model) renderStatusBar() string {
 	if m.width < 60 {
 		return fmt.Sprintf("%s%s T:%.0f M:%.0f%% P:%d",
 			statusIcon,
-			statusStyle.Render(statusText[:1]),
+			statusStyle.Render(string([]rune(statusText)[:1])),
 			m.serverStats.TPS,
 			memPct,
 			m.serverStats.PlayerCount,
 		)
 	} else if m.width < 90 {
-		return fmt.Sprintf("%s %s │ TPS:%s │ Mem:%s │ P:%d/%d",
+		return fmt.Sprintf("%s %s │ TPS:%s │ "+i18n.T("Mem")+":%s │ P:%d/%d",
 			statusIcon,
 			statusStyle.Render(statusText),
 			tpsStyle.Render(fmt.Sprintf("%.1f", m.serverStats.TPS)),
@@ -581,7 +758,7 @@ func (m *Model) renderStatusBar() string {
 			m.serverStats.MaxPlayers,
 		)
 	} else {
-		return fmt.Sprintf("%s %s │ TPS: %s │ Mem: %s │ CPU: %s │ Players: %d/%d │ Uptime: %s",
+		line := fmt.Sprintf("%s %s │ TPS: %s │ "+i18n.T("Mem")+": %s │ CPU: %s │ "+i18n.T("Players")+": %d/%d │ "+i18n.T("Uptime")+": %s",
 			statusIcon,
 			statusStyle.Render(statusText),
 			tpsStyle.Render(fmt.Sprintf("%.1f", m.serverStats.TPS)),
@@ -591,16 +768,59 @@ func (m *Model) renderStatusBar() string {
 			m.serverStats.MaxPlayers,
 			valueStyle.Render(stats.FormatDurationShort(m.serverStats.Uptime)),
 		)
+
+		if m.width >= 130 {
+			line += " │ " + i18n.T("Disk: R %s W %s",
+				valueStyle.Render(stats.FormatBytesPerSec(m.serverStats.DiskReadRate)),
+				valueStyle.Render(stats.FormatBytesPerSec(m.serverStats.DiskWriteRate)),
+			)
+		}
+
+		if m.serverStats.Status == server.StatusRunning && !m.serverStats.LastPing.IsZero() {
+			if m.serverStats.Reachable {
+				line += " │ Ping: " + valueStyle.Render(fmt.Sprintf("%dms", m.serverStats.Latency.Milliseconds()))
+			} else {
+				line += " │ " + lipgloss.NewStyle().Foreground(errorColor).Bold(true).Render(i18n.T("UNREACHABLE"))
+			}
+		}
+		return line
 	}
 }
 
 func (m *Model) renderHelpLine() string {
+	if r, ok := m.srv.(*replayBackend); ok {
+		return lipgloss.NewStyle().Foreground(primaryColor).Bold(true).Render("▶ "+i18n.T("Replaying %s, %s", m.replay, r.replayStatus())) +
+			dimStyle.Render("  "+i18n.T("[Tab]:pause/:resume/:speed <n> [↑↓]Scroll [End]Bottom [Q]Quit"))
+	}
+	if m.backups.open {
+		return m.renderBackupsHelp()
+	}
+	if m.serverStats.EULARequired {
+		return lipgloss.NewStyle().Foreground(warningColor).Bold(true).Render(i18n.T("Minecraft EULA not accepted (https://aka.ms/MinecraftEULA): press [Y] to accept it and start"))
+	}
+	if d := m.serverStats.Download; d != nil {
+		line := i18n.T("Downloading mods %s %.0f%% %d/%d", stats.ProgressBar(d.Percent(), 30), d.Percent(), d.Done, d.Total)
+		if d.ETA > 0 {
+			line += i18n.T(", %s left", d.ETA.Round(time.Second))
+		}
+		return lipgloss.NewStyle().Foreground(primaryColor).Bold(true).Render(line)
+	}
+	if p := m.serverStats.PendingRestart; p != nil {
+		state := i18n.T("in %s", time.Until(p.At).Round(time.Second))
+		if p.Deferred {
+			state = i18n.T("when the server is empty, at the latest %s", p.At.Format("15:04"))
+		}
+		return lipgloss.NewStyle().Foreground(warningColor).Bold(true).Render("⟳ " + i18n.T("%s restart %s: :restart now or :restart cancel", p.Source, state))
+	}
+	if reason := m.serverStats.RestartRequired; reason != "" {
+		return lipgloss.NewStyle().Foreground(warningColor).Bold(true).Render("⟳ " + i18n.T("Restart required to apply %s: press [R]", reason))
+	}
 	if m.width < 50 {
-		return dimStyle.Render("[Tab]In [End]Bottom [Q]Quit")
+		return dimStyle.Render(i18n.T("[Tab]In [End]Bottom [Q]Quit"))
 	} else if m.width < 80 {
-		return dimStyle.Render("[Tab]Input [↑↓]Scroll [End]Bottom [R]Restart [Q]Quit")
+		return dimStyle.Render(i18n.T("[Tab]Input [↑↓]Scroll [End]Bottom [R]Restart [Q]Quit"))
 	} else {
-		return dimStyle.Render("[Tab]Input [←→]Panel [↑↓/PgUp/PgDn]Scroll [End]AutoScroll [R]Restart [S]Start/Stop [Q]Quit")
+		return dimStyle.Render(i18n.T("[Tab]Input [←→]Panel [↑↓/PgUp/PgDn]Scroll [End]AutoScroll [R]Restart [S]Start/Stop [B]Backups [Q]Quit"))
 	}
 }
 
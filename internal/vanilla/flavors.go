@@ -0,0 +1,189 @@
+package vanilla
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+)
+
+// Server jar flavors
+const (
+	Vanilla = "vanilla"
+	Paper   = "paper"
+	Purpur  = "purpur"
+	Spigot  = "spigot"
+)
+
+const (
+	paperAPI  = "https://fill.papermc.io/v3/projects/paper"
+	purpurAPI = "https://api.purpurmc.org/v2/purpur"
+)
+
+// CheckFlavor reports whether flavor is one the client can download
+func CheckFlavor(flavor string) error {
+	switch flavor {
+	case "", Vanilla, Paper, Purpur:
+		return nil
+	case Spigot:
+		return fmt.Errorf("Spigot publishes no server jars; build one with BuildTools (java -jar BuildTools.jar --rev 1.21.1) and put spigot-*.jar in the server dir, or use paper, which runs Spigot plugins")
+	}
+	return fmt.Errorf("unknown server type %q: use vanilla, paper or purpur", flavor)
+}
+
+// ResolveFlavor looks up a version ID or the latest release of a flavor.
+// Paper and Purpur follow releases only; Paper's beta and alpha builds need
+// allowSnapshots.
+func (c *Client) ResolveFlavor(flavor, name string, allowSnapshots bool) (*Version, error) {
+	if err := CheckFlavor(flavor); err != nil {
+		return nil, err
+	}
+	switch flavor {
+	case Paper:
+		return c.resolvePaper(name, allowSnapshots)
+	case Purpur:
+		return c.resolvePurpur(name)
+	}
+	return c.Resolve(name, allowSnapshots)
+}
+
+// candidates returns the releases to look for in a flavor's version list,
+// newest first: the one given, or every release for the latest one
+func (c *Client) candidates(flavor, name string) ([]Version, error) {
+	if name == LatestSnapshot {
+		return nil, fmt.Errorf("%s has no snapshot builds; give a version or latest", title(flavor))
+	}
+	m, err := c.manifest()
+	if err != nil {
+		return nil, err
+	}
+	var list []Version
+	for _, v := range m.Versions {
+		if v.ID == name || (name == Latest || name == "") && v.Release() {
+			list = append(list, v)
+		}
+	}
+	if len(list) == 0 {
+		// Not in Mojang's manifest; let the flavor's API decide
+		list = append(list, Version{ID: name, Type: "release"})
+	}
+	return list, nil
+}
+
+type paperBuild struct {
+	ID        int    `json:"id"`
+	Channel   string `json:"channel"` // ALPHA, BETA, STABLE or RECOMMENDED
+	Downloads map[string]struct {
+		Name      string `json:"name"`
+		URL       string `json:"url"`
+		Checksums struct {
+			SHA256 string `json:"sha256"`
+		} `json:"checksums"`
+	} `json:"downloads"`
+}
+
+func (c *Client) resolvePaper(name string, allowSnapshots bool) (*Version, error) {
+	var project struct {
+		Versions map[string][]string `json:"versions"` // by version family
+	}
+	if err := c.getJSON(paperAPI, &project); err != nil {
+		return nil, fmt.Errorf("failed to fetch Paper versions: %w", err)
+	}
+	listed := make(map[string]bool)
+	for _, family := range project.Versions {
+		for _, id := range family {
+			listed[id] = true
+		}
+	}
+	candidates, err := c.candidates(Paper, name)
+	if err != nil {
+		return nil, err
+	}
+
+	experimentalOnly := ""
+	for _, v := range candidates {
+		if !listed[v.ID] {
+			continue
+		}
+		var builds []paperBuild
+		if err := c.getJSON(paperAPI+"/versions/"+v.ID+"/builds", &builds); err != nil {
+			return nil, fmt.Errorf("failed to fetch Paper builds for %s: %w", v.ID, err)
+		}
+		var best *paperBuild
+		for i := range builds {
+			b := &builds[i]
+			stable := b.Channel == "STABLE" || b.Channel == "RECOMMENDED"
+			if _, ok := b.Downloads["server:default"]; !ok || !stable && !allowSnapshots {
+				continue
+			}
+			if best == nil || b.ID > best.ID {
+				best = b
+			}
+		}
+		if best == nil {
+			if len(builds) > 0 && experimentalOnly == "" {
+				experimentalOnly = v.ID
+			}
+			continue
+		}
+		file := best.Downloads["server:default"]
+		v.Flavor, v.Build = Paper, strconv.Itoa(best.ID)
+		v.Experimental = best.Channel != "STABLE" && best.Channel != "RECOMMENDED"
+		v.jar = &jarFile{url: file.URL, hash: sha256.New, sum: file.Checksums.SHA256}
+		return &v, nil
+	}
+
+	if experimentalOnly != "" && (name == experimentalOnly || name == Latest || name == "") {
+		return nil, fmt.Errorf("Paper for Minecraft %s only has experimental builds so far; pass --snapshots to use one", experimentalOnly)
+	}
+	return nil, fmt.Errorf("Paper has no builds for Minecraft %q", name)
+}
+
+func (c *Client) resolvePurpur(name string) (*Version, error) {
+	var project struct {
+		Versions []string `json:"versions"`
+	}
+	if err := c.getJSON(purpurAPI, &project); err != nil {
+		return nil, fmt.Errorf("failed to fetch Purpur versions: %w", err)
+	}
+	listed := make(map[string]bool)
+	for _, id := range project.Versions {
+		listed[id] = true
+	}
+	candidates, err := c.candidates(Purpur, name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range candidates {
+		if !listed[v.ID] {
+			continue
+		}
+		var version struct {
+			Builds struct {
+				Latest string `json:"latest"`
+			} `json:"builds"`
+		}
+		if err := c.getJSON(purpurAPI+"/"+v.ID, &version); err != nil {
+			return nil, fmt.Errorf("failed to fetch Purpur builds for %s: %w", v.ID, err)
+		}
+		if version.Builds.Latest == "" {
+			continue
+		}
+		var build struct {
+			MD5    string `json:"md5"`
+			Result string `json:"result"`
+		}
+		url := purpurAPI + "/" + v.ID + "/" + version.Builds.Latest
+		if err := c.getJSON(url, &build); err != nil {
+			return nil, fmt.Errorf("failed to fetch Purpur build %s: %w", version.Builds.Latest, err)
+		}
+		if build.Result != "" && build.Result != "SUCCESS" {
+			continue
+		}
+		v.Flavor, v.Build = Purpur, version.Builds.Latest
+		v.jar = &jarFile{url: url + "/download", hash: md5.New, sum: build.MD5}
+		return &v, nil
+	}
+	return nil, fmt.Errorf("Purpur has no builds for Minecraft %q", name)
+}
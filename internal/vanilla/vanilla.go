@@ -0,0 +1,321 @@
+// Package vanilla downloads a server jar for a Minecraft version or release
+// channel: Mojang's own, or a Paper or Purpur build of it.
+package vanilla
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	manifestURL = "https://piston-meta.mojang.com/mc/game/version_manifest_v2.json"
+
+	// JarName is where the server jar is installed in the server dir
+	JarName = "server.jar"
+
+	stateFile = ".mcserver/vanilla.json"
+)
+
+// Channels a version may be given as instead of a version ID
+const (
+	Latest         = "latest"   // newest release
+	LatestSnapshot = "snapshot" // newest snapshot or pre-release
+)
+
+// Version is one entry of Mojang's version manifest
+type Version struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"` // release, snapshot, old_beta, old_alpha
+	URL         string    `json:"url"`
+	ReleaseTime time.Time `json:"releaseTime"`
+
+	// Set by ResolveFlavor; Paper and Purpur builds also name their jar
+	Flavor       string `json:"-"`
+	Build        string `json:"-"`
+	Experimental bool   `json:"-"` // a Paper beta or alpha build
+	jar          *jarFile
+}
+
+// jarFile is where a server jar is downloaded from and its checksum
+type jarFile struct {
+	url  string
+	hash func() hash.Hash
+	sum  string
+}
+
+// Release reports whether v is a full release. Pre-releases and release
+// candidates are listed as snapshots.
+func (v *Version) Release() bool {
+	return v.Type == "release"
+}
+
+func (v *Version) String() string {
+	return describe(v.Flavor, v.ID, v.Build)
+}
+
+// Installed describes the jar in a server dir
+type Installed struct {
+	ID           string    `json:"id"`
+	Type         string    `json:"type"`
+	Flavor       string    `json:"flavor,omitempty"` // empty in records older than flavors
+	Build        string    `json:"build,omitempty"`
+	WorldVersion int       `json:"worldVersion"` // DataVersion of worlds it saves
+	InstalledAt  time.Time `json:"installedAt"`
+}
+
+func (i *Installed) String() string {
+	return describe(i.Flavor, i.ID, i.Build)
+}
+
+// describe names a server jar, e.g. "Minecraft 1.21.1" or "Paper 1.21.1
+// build 130"
+func describe(flavor, id, build string) string {
+	if flavor == "" || flavor == Vanilla {
+		return "Minecraft " + id
+	}
+	name := title(flavor) + " " + id
+	if build != "" {
+		name += " build " + build
+	}
+	return name
+}
+
+// title capitalizes a flavor for messages
+func title(flavor string) string {
+	return strings.ToUpper(flavor[:1]) + flavor[1:]
+}
+
+type manifest struct {
+	Latest struct {
+		Release  string `json:"release"`
+		Snapshot string `json:"snapshot"`
+	} `json:"latest"`
+	Versions []Version `json:"versions"`
+}
+
+// Client talks to Mojang's launcher metadata
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient creates a new client
+func NewClient() *Client {
+	return &Client{httpClient: &http.Client{Timeout: 10 * time.Minute}}
+}
+
+// Resolve looks up a version ID or channel. Anything but a release needs
+// allowSnapshots, so snapshots are never picked up by accident.
+func (c *Client) Resolve(name string, allowSnapshots bool) (*Version, error) {
+	m, err := c.manifest()
+	if err != nil {
+		return nil, err
+	}
+
+	id := name
+	switch name {
+	case Latest, "":
+		id = m.Latest.Release
+	case LatestSnapshot:
+		if !allowSnapshots {
+			return nil, fmt.Errorf("the snapshot channel needs --snapshots")
+		}
+		id = m.Latest.Snapshot
+	}
+
+	for i := range m.Versions {
+		v := &m.Versions[i]
+		if v.ID != id {
+			continue
+		}
+		if !v.Release() && !allowSnapshots {
+			return nil, fmt.Errorf("%s is a %s, not a release; pass --snapshots to use it", v.ID, v.Type)
+		}
+		v.Flavor = Vanilla
+		return v, nil
+	}
+	return nil, fmt.Errorf("unknown Minecraft version %q", name)
+}
+
+func (c *Client) manifest() (*manifest, error) {
+	var m manifest
+	if err := c.getJSON(manifestURL, &m); err != nil {
+		return nil, fmt.Errorf("failed to fetch version manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// Pending is a downloaded jar that has not replaced the installed one yet,
+// so the caller can check and back up before committing to it
+type Pending struct {
+	Installed
+	path      string
+	serverDir string
+}
+
+// Download fetches v's server jar next to the installed one
+func (c *Client) Download(v *Version, serverDir string) (*Pending, error) {
+	jar := v.jar
+	if jar == nil {
+		var err error
+		if jar, err = c.mojangJar(v); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.MkdirAll(serverDir, 0755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(serverDir, JarName+".download")
+	if err := c.download(jar, path); err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to download %s: %w", v, err)
+	}
+
+	p := &Pending{
+		Installed: Installed{ID: v.ID, Type: v.Type, Flavor: v.Flavor, Build: v.Build, InstalledAt: time.Now()},
+		path:      path,
+		serverDir: serverDir,
+	}
+	p.WorldVersion = worldVersion(path)
+	return p, nil
+}
+
+// mojangJar looks up the download of a vanilla version's server jar
+func (c *Client) mojangJar(v *Version) (*jarFile, error) {
+	var meta struct {
+		Downloads struct {
+			Server struct {
+				SHA1 string `json:"sha1"`
+				URL  string `json:"url"`
+			} `json:"server"`
+		} `json:"downloads"`
+	}
+	if err := c.getJSON(v.URL, &meta); err != nil {
+		return nil, fmt.Errorf("failed to fetch %s metadata: %w", v.ID, err)
+	}
+	server := meta.Downloads.Server
+	if server.URL == "" {
+		return nil, fmt.Errorf("Minecraft %s has no server download", v.ID)
+	}
+	return &jarFile{url: server.URL, hash: sha1.New, sum: server.SHA1}, nil
+}
+
+// Apply installs the downloaded jar
+func (p *Pending) Apply() error {
+	if err := os.Rename(p.path, filepath.Join(p.serverDir, JarName)); err != nil {
+		return fmt.Errorf("failed to install %s: %w", JarName, err)
+	}
+	data, err := json.MarshalIndent(&p.Installed, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(p.serverDir, filepath.Dir(stateFile)), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(p.serverDir, stateFile), data, 0644)
+}
+
+// Discard removes the download if it was not applied
+func (p *Pending) Discard() {
+	os.Remove(p.path)
+}
+
+// Current returns what the manager last installed, or nil if it never
+// installed a vanilla jar here
+func Current(serverDir string) (*Installed, error) {
+	if _, err := os.Stat(filepath.Join(serverDir, JarName)); err != nil {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(serverDir, stateFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var installed Installed
+	if err := json.Unmarshal(data, &installed); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", stateFile, err)
+	}
+	if installed.Flavor == "" {
+		installed.Flavor = Vanilla
+	}
+	return &installed, nil
+}
+
+// worldVersion reads the DataVersion a server jar saves worlds with from
+// its version.json, or 0 if the jar predates it
+func worldVersion(jarPath string) int {
+	r, err := zip.OpenReader(jarPath)
+	if err != nil {
+		return 0
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if f.Name != "version.json" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return 0
+		}
+		defer rc.Close()
+		var v struct {
+			WorldVersion int `json:"world_version"`
+		}
+		if json.NewDecoder(rc).Decode(&v) != nil {
+			return 0
+		}
+		return v.WorldVersion
+	}
+	return 0
+}
+
+func (c *Client) getJSON(url string, v any) error {
+	resp, err := c.httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request returned status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (c *Client) download(jar *jarFile, dest string) error {
+	resp, err := c.httpClient.Get(jar.url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download returned status %d", resp.StatusCode)
+	}
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	sum := jar.hash()
+	_, err = io.Copy(io.MultiWriter(out, sum), resp.Body)
+	out.Close()
+	if err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(sum.Sum(nil)); jar.sum != "" && !strings.EqualFold(got, jar.sum) {
+		return fmt.Errorf("checksum mismatch: got %s, want %s", got, jar.sum)
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+// Package web is the browser dashboard: the stats the TUI shows, the live
+// console over a WebSocket and a command line, served by the same binary.
+// It sits on top of the control API agent, which authenticates callers
+// with their API tokens, sent as the WebSocket's first message, and checks
+// the commands they send.
+package web
+
+import (
+	"embed"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"mcserver-manager/internal/api"
+	"mcserver-manager/internal/server"
+)
+
+//go:embed index.html
+var static embed.FS
+
+// How often the dashboard's stats refresh
+const statsInterval = time.Second
+
+// How many recent events the dashboard lists
+const eventCount = 20
+
+// Handler serves the dashboard at /, its WebSocket at /ws and the control
+// API under /v1/, so the page's own requests need no other listener
+func Handler(agent *api.Agent) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/v1/", agent.Handler())
+	// The page itself holds nothing; it asks for a token and sends it over
+	// the WebSocket, so the browser never has a login to attach to other
+	// sites' requests
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "GET required", http.StatusMethodNotAllowed)
+			return
+		}
+		http.ServeFileFS(w, r, static, "index.html")
+	})
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		ws := websocket.Server{
+			Handshake: checkOrigin,
+			Handler: func(conn *websocket.Conn) {
+				id, ok := login(agent, conn, r.TLS != nil)
+				if !ok {
+					conn.Close()
+					return
+				}
+				serveConn(agent, id, conn)
+			},
+		}
+		ws.ServeHTTP(w, r)
+	})
+	return mux
+}
+
+// How long a dashboard has to send its token after connecting
+const loginTimeout = 10 * time.Second
+
+// login reads the "auth" message a dashboard opens with and checks its
+// token, telling the page when it is refused
+func login(agent *api.Agent, conn *websocket.Conn, mtls bool) (api.Identity, bool) {
+	conn.SetReadDeadline(time.Now().Add(loginTimeout))
+	var msg message
+	if err := websocket.JSON.Receive(conn, &msg); err != nil {
+		return api.Identity{}, false
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	id, ok := agent.Authenticate(msg.Token, mtls)
+	if msg.Type != "auth" || !ok {
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		websocket.JSON.Send(conn, message{Type: "denied", Error: "missing or invalid API token"})
+		return api.Identity{}, false
+	}
+	return id, true
+}
+
+// Serve serves the dashboard on ln. Like the API over plain HTTP, it
+// needs API tokens.
+func Serve(ln net.Listener, agent *api.Agent) error {
+	httpServer := &http.Server{
+		Handler:           Handler(agent),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return httpServer.Serve(ln)
+}
+
+// checkOrigin refuses WebSockets opened by other sites' pages, which the
+// browser would otherwise hand the dashboard's login
+func checkOrigin(config *websocket.Config, r *http.Request) error {
+	origin, err := url.Parse(r.Header.Get("Origin"))
+	if err != nil || origin.Host != r.Host {
+		return fmt.Errorf("cross-origin WebSocket refused")
+	}
+	config.Origin = origin
+	return nil
+}
+
+// message is what the dashboard and the manager send each other
+type message struct {
+	Type    string   `json:"type"` // auth, denied, hello, console, stats, result or command
+	Token   string   `json:"token,omitempty"`
+	Line    string   `json:"line,omitempty"`
+	Stats   *stats   `json:"stats,omitempty"`
+	Name    string   `json:"name,omitempty"`
+	Role    api.Role `json:"role,omitempty"`
+	Command string   `json:"command,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// stats is the part of the server's stats the dashboard shows
+type stats struct {
+	Status      string   `json:"status"`
+	StatusColor string   `json:"status_color"`
+	Uptime      int64    `json:"uptime_seconds"`
+	TPS         float64  `json:"tps"`
+	MSPT        float64  `json:"mspt"`
+	TPS1hP95    float64  `json:"tps_1h_p95"`
+	MemoryUsed  uint64   `json:"memory_used"`
+	MemoryMax   uint64   `json:"memory_max"`
+	CPUPercent  float64  `json:"cpu_percent"`
+	DiskRead    float64  `json:"disk_read_rate"`
+	DiskWrite   float64  `json:"disk_write_rate"`
+	Players     []string `json:"players"`
+	MaxPlayers  int      `json:"max_players"`
+	Software    string   `json:"software,omitempty"`
+	Java        int      `json:"java,omitempty"`
+	Address     string   `json:"address,omitempty"`
+	MOTD        string   `json:"motd,omitempty"`
+	Restart     string   `json:"restart_required,omitempty"`
+	Events      []event  `json:"events"`
+}
+
+type event struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Color   string    `json:"color"`
+	Icon    string    `json:"icon"`
+	Message string    `json:"message"`
+}
+
+func newStats(s server.ServerStats) *stats {
+	out := &stats{
+		Status:      s.Status.String(),
+		StatusColor: s.Status.Color(),
+		Uptime:      int64(s.Uptime.Seconds()),
+		TPS:         s.TPS,
+		MSPT:        s.MSPT,
+		TPS1hP95:    s.TPS1h.P95,
+		MemoryUsed:  s.MemoryUsed,
+		MemoryMax:   s.MemoryMax,
+		CPUPercent:  s.CPUPercent,
+		DiskRead:    s.DiskReadRate,
+		DiskWrite:   s.DiskWriteRate,
+		Players:     []string{},
+		MaxPlayers:  s.MaxPlayers,
+		Java:        s.JavaVersion,
+		Address:     s.ShareAddress,
+		MOTD:        s.MOTD,
+		Restart:     s.RestartRequired,
+		Events:      []event{},
+	}
+	if s.Software != nil {
+		out.Software = s.Software.String()
+	}
+	for _, p := range s.Players {
+		out.Players = append(out.Players, p.Name)
+	}
+	events := s.RecentEvents
+	if len(events) > eventCount {
+		events = events[len(events)-eventCount:]
+	}
+	for _, e := range events {
+		out.Events = append(out.Events, event{Time: e.Time, Type: e.Type.String(), Color: e.Type.Color(), Icon: e.Type.Icon(), Message: e.Message})
+	}
+	return out
+}
+
+// serveConn streams the console and stats to one dashboard and runs the
+// commands it sends, until either side hangs up
+func serveConn(agent *api.Agent, id api.Identity, conn *websocket.Conn) {
+	defer conn.Close()
+	lines, cancel := agent.Subscribe()
+	defer cancel()
+
+	// Writes come from this goroutine only; the reader hands results back
+	results := make(chan message, 8)
+	done := make(chan struct{})
+	quit := make(chan struct{})
+	defer close(quit)
+	go func() {
+		defer close(done)
+		for {
+			var msg message
+			if err := websocket.JSON.Receive(conn, &msg); err != nil {
+				return
+			}
+			if msg.Type != "command" || msg.Command == "" {
+				continue
+			}
+			result := message{Type: "result", Command: msg.Command}
+			if !id.Role.Allows(api.RoleOperator) {
+				result.Error = fmt.Sprintf("%s role required", api.RoleOperator)
+			} else if err := agent.SendCommand(id, msg.Command); err != nil {
+				result.Error = err.Error()
+			}
+			select {
+			case results <- result:
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	send := func(msg message) bool {
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		return websocket.JSON.Send(conn, msg) == nil
+	}
+	if !send(message{Type: "hello", Name: id.Name, Role: id.Role}) || !send(message{Type: "stats", Stats: newStats(agent.Stats())}) {
+		return
+	}
+
+	ticker := time.NewTicker(statsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case line, ok := <-lines:
+			if !ok || !send(message{Type: "console", Line: line}) {
+				return
+			}
+		case result := <-results:
+			if !send(result) {
+				return
+			}
+		case <-ticker.C:
+			if !send(message{Type: "stats", Stats: newStats(agent.Stats())}) {
+				return
+			}
+		}
+	}
+}
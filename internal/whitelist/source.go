@@ -0,0 +1,158 @@
+// Package whitelist fetches a member list maintained outside the server: a
+// whitelist.json file, a plain list of names, or a CSV such as a Google
+// Sheet export.
+package whitelist
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Columns of a CSV that hold the player name, checked in order
+var nameColumns = []string{"name", "username", "player", "minecraft", "ign", "gamertag"}
+
+var playerName = regexp.MustCompile(`^[A-Za-z0-9_]{1,16}$`)
+
+var (
+	gistPage  = regexp.MustCompile(`^https://gist\.github\.com/([^/]+)/([0-9a-f]+)/?$`)
+	sheetPage = regexp.MustCompile(`^https://docs\.google\.com/spreadsheets/d/([^/]+)`)
+)
+
+// Source is a remote member list
+type Source struct {
+	URL        string
+	httpClient *http.Client
+}
+
+// NewSource creates a source for a raw URL, or a Gist or Google Sheet page,
+// which are turned into their raw and CSV export URLs
+func NewSource(rawURL string) (*Source, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid whitelist URL %q", rawURL)
+	}
+
+	if m := gistPage.FindStringSubmatch(rawURL); m != nil {
+		rawURL = fmt.Sprintf("https://gist.githubusercontent.com/%s/%s/raw", m[1], m[2])
+	} else if m := sheetPage.FindStringSubmatch(rawURL); m != nil && !strings.Contains(rawURL, "/export") {
+		export := fmt.Sprintf("https://docs.google.com/spreadsheets/d/%s/export?format=csv", m[1])
+		gid := u.Query().Get("gid")
+		if gid == "" && strings.HasPrefix(u.Fragment, "gid=") {
+			gid = strings.TrimPrefix(u.Fragment, "gid=")
+		}
+		if gid != "" {
+			export += "&gid=" + url.QueryEscape(gid)
+		}
+		rawURL = export
+	}
+	return &Source{URL: rawURL, httpClient: &http.Client{Timeout: 30 * time.Second}}, nil
+}
+
+// Fetch downloads the list and returns the player names on it, sorted and
+// without duplicates, along with the raw content to detect changes by
+func (s *Source) Fetch() ([]string, []byte, error) {
+	resp, err := s.httpClient.Get(s.URL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch whitelist: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("whitelist source returned status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read whitelist: %w", err)
+	}
+
+	names, err := Parse(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return names, data, nil
+}
+
+// Parse reads names from a whitelist.json-style array of entries, a JSON
+// array of names, or CSV/plain text with one player per row under an
+// optional header. Entries that are not valid player names are skipped.
+func Parse(data []byte) ([]string, error) {
+	var names []string
+	trimmed := bytes.TrimSpace(data)
+
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		var entries []json.RawMessage
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse whitelist JSON: %w", err)
+		}
+		for _, raw := range entries {
+			var name string
+			if json.Unmarshal(raw, &name) != nil {
+				var entry struct {
+					Name string `json:"name"`
+				}
+				json.Unmarshal(raw, &entry)
+				name = entry.Name
+			}
+			names = append(names, name)
+		}
+	} else {
+		r := csv.NewReader(bytes.NewReader(trimmed))
+		r.FieldsPerRecord = -1
+		r.TrimLeadingSpace = true
+		rows, err := r.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse whitelist CSV: %w", err)
+		}
+		column := 0
+		if len(rows) > 0 {
+			var header bool
+			if column, header = nameColumn(rows[0]); header {
+				rows = rows[1:]
+			}
+		}
+		for _, row := range rows {
+			if column < len(row) {
+				names = append(names, row[column])
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	var valid []string
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if !playerName.MatchString(name) || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		valid = append(valid, name)
+	}
+	sort.Slice(valid, func(i, j int) bool { return strings.ToLower(valid[i]) < strings.ToLower(valid[j]) })
+	return valid, nil
+}
+
+// nameColumn picks the column holding names from a CSV header, preferring
+// an exact header match. Without a recognizable header it is the first.
+func nameColumn(header []string) (int, bool) {
+	for _, match := range []func(col, want string) bool{
+		func(col, want string) bool { return col == want },
+		strings.Contains,
+	} {
+		for _, want := range nameColumns {
+			for i, col := range header {
+				if match(strings.ToLower(strings.TrimSpace(col)), want) {
+					return i, true
+				}
+			}
+		}
+	}
+	return 0, false
+}
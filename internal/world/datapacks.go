@@ -0,0 +1,186 @@
+package world
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"mcserver-manager/internal/nbt"
+)
+
+// Datapack is a pack in a world's datapacks folder
+type Datapack struct {
+	Name    string // file or folder name inside datapacks/
+	Path    string
+	Size    int64
+	Enabled bool // listed as enabled in level.dat
+}
+
+// ID is the name the /datapack command knows the pack by
+func (d *Datapack) ID() string {
+	return "file/" + d.Name
+}
+
+// Datapacks lists the packs installed in a world. Enabled reflects
+// level.dat, so it is only as fresh as the last save.
+func Datapacks(worldPath string) ([]Datapack, error) {
+	dir := filepath.Join(worldPath, "datapacks")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	enabled := map[string]bool{}
+	if root, err := nbt.ReadFile(filepath.Join(worldPath, "level.dat")); err == nil {
+		if list, ok := root.Get("Data", "DataPacks", "Enabled").([]interface{}); ok {
+			for _, v := range list {
+				if id, ok := v.(string); ok {
+					enabled[id] = true
+				}
+			}
+		}
+	}
+
+	var packs []Datapack
+	for _, entry := range entries {
+		name := entry.Name()
+		p := filepath.Join(dir, name)
+		if !entry.IsDir() && !strings.HasSuffix(strings.ToLower(name), ".zip") {
+			continue
+		}
+		if entry.IsDir() {
+			if _, err := os.Stat(filepath.Join(p, "pack.mcmeta")); err != nil {
+				continue
+			}
+		}
+		pack := Datapack{Name: name, Path: p, Size: dirSize(p)}
+		pack.Enabled = enabled[pack.ID()]
+		packs = append(packs, pack)
+	}
+	return packs, nil
+}
+
+// FindDatapack returns an installed pack by name, with or without the
+// "file/" prefix and ".zip" suffix
+func FindDatapack(worldPath, name string) (*Datapack, error) {
+	packs, err := Datapacks(worldPath)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimPrefix(name, "file/")
+	for i := range packs {
+		if packs[i].Name == name || strings.TrimSuffix(packs[i].Name, ".zip") == name {
+			return &packs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("datapack %q is not installed", name)
+}
+
+// InstallDatapack copies a datapack zip (a local path or URL) into a
+// world's datapacks folder after checking it has a pack.mcmeta
+func InstallDatapack(serverDir, worldPath, source string) (*Datapack, error) {
+	name := filepath.Base(source)
+	if IsURL(source) {
+		u, err := url.Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL: %w", err)
+		}
+		name = path.Base(u.Path)
+	}
+	if !strings.HasSuffix(strings.ToLower(name), ".zip") {
+		name += ".zip"
+	}
+	if err := ValidateName(strings.TrimSuffix(name, ".zip")); err != nil {
+		return nil, fmt.Errorf("invalid datapack file name %q", name)
+	}
+
+	archivePath, err := Fetch(serverDir, source)
+	if err != nil {
+		return nil, err
+	}
+	if archivePath != source {
+		defer os.Remove(archivePath)
+	}
+
+	if err := checkPackMeta(archivePath); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(worldPath, "datapacks")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	dest := filepath.Join(dir, name)
+	if err := copyFileTo(archivePath, dest); err != nil {
+		return nil, fmt.Errorf("failed to install datapack: %w", err)
+	}
+
+	info, _ := os.Stat(dest)
+	pack := &Datapack{Name: name, Path: dest}
+	if info != nil {
+		pack.Size = info.Size()
+	}
+	return pack, nil
+}
+
+// RemoveDatapack deletes an installed pack
+func RemoveDatapack(worldPath, name string) error {
+	pack, err := FindDatapack(worldPath, name)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(pack.Path)
+}
+
+// checkPackMeta makes sure an archive is a datapack rather than, say, a
+// whole world or a resource pack in the wrong place
+func checkPackMeta(archivePath string) error {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open datapack: %w", err)
+	}
+	defer r.Close()
+
+	var hasMeta, hasData bool
+	for _, f := range r.File {
+		switch {
+		case f.Name == "pack.mcmeta":
+			hasMeta = true
+		case strings.HasPrefix(f.Name, "data/"):
+			hasData = true
+		}
+	}
+	if !hasMeta {
+		return fmt.Errorf("not a datapack: pack.mcmeta missing from the top of the zip")
+	}
+	if !hasData {
+		return fmt.Errorf("not a datapack: no data/ folder (is this a resource pack?)")
+	}
+	return nil
+}
+
+func copyFileTo(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
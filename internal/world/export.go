@@ -0,0 +1,54 @@
+package world
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// playerFolders hold per-player data inside a world (or dimension) folder
+var playerFolders = []string{"playerdata", "stats", "advancements"}
+
+// ExportOptions control what Export leaves out
+type ExportOptions struct {
+	ScrubPlayers bool // drop inventories, stats and advancements
+}
+
+// Export writes the named world and its dimension folders to outPath as a
+// zip with the world folder at the top, ready to drop into a single-player
+// saves folder or another server
+func Export(serverDir, name, outPath string, opts ExportOptions) error {
+	w, err := Find(serverDir, name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	folders := append([]string{name}, w.Dimensions...)
+	skip := func(rel string, info os.FileInfo) bool {
+		if !opts.ScrubPlayers || !info.IsDir() {
+			return false
+		}
+		parts := strings.Split(rel, "/")
+		if len(parts) != 2 {
+			return false
+		}
+		for _, folder := range playerFolders {
+			if parts[1] == folder {
+				return true
+			}
+		}
+		return false
+	}
+
+	tmp := outPath + ".tmp"
+	if err := zipFolders(tmp, serverDir, folders, skip); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, outPath)
+}
@@ -0,0 +1,256 @@
+package world
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// importDir is where archives are downloaded and extracted before being
+// swapped in, relative to the server directory
+const importDir = ".mcserver/import"
+
+// How long a world download may wait for the response headers, and for
+// the next bytes of the body; worlds can be gigabytes, so the whole
+// download has no limit
+const (
+	downloadHeaderTimeout = 30 * time.Second
+	downloadStallTimeout  = time.Minute
+)
+
+var downloadClient = &http.Client{Transport: downloadTransport()}
+
+func downloadTransport() http.RoundTripper {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.ResponseHeaderTimeout = downloadHeaderTimeout
+	return t
+}
+
+// ImportPlan describes where an archive's world lives inside it
+type ImportPlan struct {
+	Root       string            // archive folder containing level.dat ("" for the top)
+	Dimensions map[string]string // archive folder -> dimension suffix, for Bukkit layouts
+}
+
+// IsURL reports whether source should be downloaded rather than opened
+func IsURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// Fetch downloads source into the server's import directory if it is a
+// URL, and returns a local archive path
+func Fetch(serverDir, source string) (string, error) {
+	if !IsURL(source) {
+		return source, nil
+	}
+
+	dir := filepath.Join(serverDir, importDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	dest := filepath.Join(dir, "download.zip")
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	stalled := fmt.Errorf("download stalled for %s", downloadStallTimeout)
+	timer := time.AfterFunc(downloadStallTimeout, func() { cancel(stalled) })
+	defer timer.Stop()
+	fail := func(err error) error {
+		if context.Cause(ctx) == stalled {
+			err = stalled
+		}
+		return fmt.Errorf("failed to download world: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return "", fail(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download returned status %d", resp.StatusCode)
+	}
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(out, &stallReader{r: resp.Body, timer: timer})
+	out.Close()
+	if err != nil {
+		os.Remove(dest)
+		return "", fail(err)
+	}
+	return dest, nil
+}
+
+// stallReader pushes the stall timer back whenever bytes arrive
+type stallReader struct {
+	r     io.Reader
+	timer *time.Timer
+}
+
+func (s *stallReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if n > 0 {
+		s.timer.Reset(downloadStallTimeout)
+	}
+	return n, err
+}
+
+// Inspect checks that an archive contains a world and works out how it is
+// nested. The shallowest level.dat wins, so "MyMap/world/level.dat" and
+// "level.dat" at the top both import correctly.
+func Inspect(archivePath string) (*ImportPlan, error) {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer r.Close()
+
+	roots := map[string]bool{}
+	for _, f := range r.File {
+		name := strings.TrimPrefix(path.Clean("/"+f.Name), "/")
+		if path.Base(name) != "level.dat" {
+			continue
+		}
+		dir := path.Dir(name)
+		if dir == "." {
+			dir = ""
+		}
+		roots[dir] = true
+	}
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("archive has no level.dat, it does not look like a world")
+	}
+
+	best := ""
+	found := false
+	for dir := range roots {
+		if isDimensionOf(dir, roots) {
+			continue
+		}
+		if !found || depth(dir) < depth(best) || depth(dir) == depth(best) && dir < best {
+			best, found = dir, true
+		}
+	}
+
+	plan := &ImportPlan{Root: best, Dimensions: map[string]string{}}
+	if best != "" {
+		for _, suffix := range dimensionSuffixes {
+			sibling := best + suffix
+			if roots[sibling] {
+				plan.Dimensions[sibling] = suffix
+			}
+		}
+	}
+	return plan, nil
+}
+
+// Import extracts the world in archivePath as serverDir/name (plus any
+// Bukkit dimension folders), replacing existing folders of that name. The
+// caller is responsible for stopping the server and backing up first.
+func Import(serverDir, archivePath, name string, plan *ImportPlan) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer r.Close()
+
+	staging := filepath.Join(serverDir, importDir, fmt.Sprintf("%s-%d", name, time.Now().UnixNano()))
+	defer os.RemoveAll(staging)
+
+	// archive folder -> folder name in the server directory
+	targets := map[string]string{plan.Root: name}
+	for folder, suffix := range plan.Dimensions {
+		targets[folder] = name + suffix
+	}
+
+	for _, f := range r.File {
+		entry := strings.TrimPrefix(path.Clean("/"+f.Name), "/")
+		if f.FileInfo().IsDir() || path.Base(entry) == "session.lock" {
+			continue
+		}
+
+		folder, rel, ok := matchTarget(entry, targets)
+		if !ok {
+			continue
+		}
+		dest := filepath.Join(staging, targets[folder], filepath.FromSlash(rel))
+		if err := extractFile(f, dest); err != nil {
+			return fmt.Errorf("failed to extract %s: %w", f.Name, err)
+		}
+	}
+
+	for _, target := range targets {
+		src := filepath.Join(staging, target)
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		dest := filepath.Join(serverDir, target)
+		if err := os.RemoveAll(dest); err != nil {
+			return fmt.Errorf("failed to remove old %s: %w", target, err)
+		}
+		if err := os.Rename(src, dest); err != nil {
+			return fmt.Errorf("failed to move %s into place: %w", target, err)
+		}
+	}
+	return nil
+}
+
+// matchTarget finds which target folder an archive entry belongs to. The
+// top-level root ("") only claims entries no dimension folder claims.
+func matchTarget(entry string, targets map[string]string) (folder, rel string, ok bool) {
+	for folder := range targets {
+		if folder != "" && strings.HasPrefix(entry, folder+"/") {
+			return folder, strings.TrimPrefix(entry, folder+"/"), true
+		}
+	}
+	if _, top := targets[""]; top {
+		return "", entry, true
+	}
+	return "", "", false
+}
+
+func extractFile(f *zip.File, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, rc)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func depth(dir string) int {
+	if dir == "" {
+		return 0
+	}
+	return strings.Count(dir, "/") + 1
+}
@@ -0,0 +1,85 @@
+package world
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"mcserver-manager/internal/nbt"
+)
+
+// Info is what level.dat says about a world
+type Info struct {
+	LevelName   string            `json:"levelName"`
+	Version     string            `json:"version,omitempty"` // Minecraft version that last saved it
+	DataVersion int               `json:"dataVersion,omitempty"`
+	Seed        int64             `json:"seed"`
+	HasSeed     bool              `json:"hasSeed"`
+	SpawnX      int               `json:"spawnX"`
+	SpawnY      int               `json:"spawnY"`
+	SpawnZ      int               `json:"spawnZ"`
+	Hardcore    bool              `json:"hardcore"`
+	GameType    int               `json:"gameType"` // 0 survival, 1 creative, 2 adventure, 3 spectator
+	Difficulty  int               `json:"difficulty"`
+	LastPlayed  time.Time         `json:"lastPlayed"`
+	GameRules   map[string]string `json:"gameRules,omitempty"`
+}
+
+var gameTypeNames = []string{"survival", "creative", "adventure", "spectator"}
+
+// GameTypeName returns the default game mode as a word
+func (i *Info) GameTypeName() string {
+	if i.GameType >= 0 && i.GameType < len(gameTypeNames) {
+		return gameTypeNames[i.GameType]
+	}
+	return "unknown"
+}
+
+// ReadInfo parses a world folder's level.dat
+func ReadInfo(worldPath string) (*Info, error) {
+	root, err := nbt.ReadFile(filepath.Join(worldPath, "level.dat"))
+	if err != nil {
+		return nil, err
+	}
+	data := root.Compound("Data")
+
+	info := &Info{
+		LevelName: data.String("LevelName"),
+		Version:   data.String("Version", "Name"),
+		GameRules: map[string]string{},
+	}
+	if v, ok := data.Int("DataVersion"); ok {
+		info.DataVersion = int(v)
+	}
+
+	// 1.16+ keeps the seed in WorldGenSettings; older worlds in RandomSeed
+	if seed, ok := data.Int("WorldGenSettings", "seed"); ok {
+		info.Seed, info.HasSeed = seed, true
+	} else if seed, ok := data.Int("RandomSeed"); ok {
+		info.Seed, info.HasSeed = seed, true
+	}
+
+	x, _ := data.Int("SpawnX")
+	y, _ := data.Int("SpawnY")
+	z, _ := data.Int("SpawnZ")
+	info.SpawnX, info.SpawnY, info.SpawnZ = int(x), int(y), int(z)
+
+	if v, ok := data.Int("hardcore"); ok {
+		info.Hardcore = v != 0
+	}
+	if v, ok := data.Int("GameType"); ok {
+		info.GameType = int(v)
+	}
+	if v, ok := data.Int("Difficulty"); ok {
+		info.Difficulty = int(v)
+	}
+	if ms, ok := data.Int("LastPlayed"); ok {
+		info.LastPlayed = time.UnixMilli(ms)
+	}
+
+	for key, value := range data.Compound("GameRules") {
+		info.GameRules[key] = fmt.Sprint(value)
+	}
+
+	return info, nil
+}
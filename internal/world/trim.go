@@ -0,0 +1,163 @@
+package world
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"mcserver-manager/internal/nbt"
+)
+
+// regionSize is the number of blocks along one side of a region file
+const regionSize = 512
+
+var regionFileRegex = regexp.MustCompile(`^r\.(-?\d+)\.(-?\d+)\.mca$`)
+
+// Folders holding region-format files, per dimension folder
+var regionFolders = []string{"region", "entities", "poi"}
+
+// TrimOptions choose which region files Trim keeps. Coordinates are
+// overworld blocks; the nether is scaled by 1/8 like the world border.
+type TrimOptions struct {
+	Radius    int // keep regions within this many blocks of the center
+	CenterX   int
+	CenterZ   int
+	UseBorder bool // take center and radius from the world border instead
+	DryRun    bool
+}
+
+// TrimReport summarizes what Trim removed (or would remove)
+type TrimReport struct {
+	Radius       int
+	CenterX      int
+	CenterZ      int
+	RemovedFiles int
+	RemovedBytes int64
+	KeptFiles    int
+	KeptBytes    int64
+}
+
+// Trim deletes region files that lie entirely outside the configured
+// square around the center. The server must be stopped unless DryRun.
+func Trim(serverDir, name string, opts TrimOptions) (*TrimReport, error) {
+	w, err := Find(serverDir, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.UseBorder {
+		if err := applyBorder(w.Path, &opts); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Radius <= 0 {
+		return nil, fmt.Errorf("a radius (or the world border) is required")
+	}
+
+	report := &TrimReport{Radius: opts.Radius, CenterX: opts.CenterX, CenterZ: opts.CenterZ}
+	for _, dim := range dimensionDirs(serverDir, w) {
+		scale := 1
+		if dim.nether {
+			scale = 8
+		}
+		for _, folder := range regionFolders {
+			if err := trimFolder(filepath.Join(dim.path, folder), opts, scale, report); err != nil {
+				return report, err
+			}
+		}
+	}
+	return report, nil
+}
+
+type dimensionDir struct {
+	path   string
+	nether bool
+}
+
+// dimensionDirs returns every folder holding a dimension's region files,
+// covering both vanilla (DIM-1 inside the world) and Bukkit layouts
+func dimensionDirs(serverDir string, w *World) []dimensionDir {
+	dirs := []dimensionDir{
+		{path: w.Path},
+		{path: filepath.Join(w.Path, "DIM-1"), nether: true},
+		{path: filepath.Join(w.Path, "DIM1")},
+	}
+	for _, dim := range w.Dimensions {
+		base := filepath.Join(serverDir, dim)
+		dirs = append(dirs,
+			dimensionDir{path: filepath.Join(base, "DIM-1"), nether: true},
+			dimensionDir{path: filepath.Join(base, "DIM1")},
+		)
+	}
+	return dirs
+}
+
+func trimFolder(dir string, opts TrimOptions, scale int, report *TrimReport) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	cx, cz, r := opts.CenterX/scale, opts.CenterZ/scale, opts.Radius/scale
+	for _, entry := range entries {
+		m := regionFileRegex.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+		rx, _ := strconv.Atoi(m[1])
+		rz, _ := strconv.Atoi(m[2])
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		if !regionOutside(rx, rz, cx, cz, r) {
+			report.KeptFiles++
+			report.KeptBytes += info.Size()
+			continue
+		}
+
+		if !opts.DryRun {
+			if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", entry.Name(), err)
+			}
+		}
+		report.RemovedFiles++
+		report.RemovedBytes += info.Size()
+	}
+	return nil
+}
+
+// regionOutside reports whether region (rx, rz) lies entirely outside the
+// square of half-width r around (cx, cz)
+func regionOutside(rx, rz, cx, cz, r int) bool {
+	x0, z0 := rx*regionSize, rz*regionSize
+	x1, z1 := x0+regionSize-1, z0+regionSize-1
+	return x1 < cx-r || x0 > cx+r || z1 < cz-r || z0 > cz+r
+}
+
+// applyBorder takes the trim square from the world border in level.dat
+func applyBorder(worldPath string, opts *TrimOptions) error {
+	root, err := nbt.ReadFile(filepath.Join(worldPath, "level.dat"))
+	if err != nil {
+		return err
+	}
+	data := root.Compound("Data")
+
+	size, ok := data.Float("BorderSize")
+	if !ok || size <= 0 || size >= 5.9e7 {
+		return fmt.Errorf("the world has no world border set")
+	}
+	x, _ := data.Float("BorderCenterX")
+	z, _ := data.Float("BorderCenterZ")
+
+	opts.CenterX, opts.CenterZ = int(x), int(z)
+	opts.Radius = int(size/2) + 1
+	return nil
+}
@@ -0,0 +1,276 @@
+package world
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"mcserver-manager/internal/props"
+)
+
+// Bukkit-style servers keep other dimensions in sibling folders
+var dimensionSuffixes = []string{"_nether", "_the_end"}
+
+var nameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// World is a world folder in the server directory
+type World struct {
+	Name        string
+	Path        string
+	Dimensions  []string // sibling dimension folders, e.g. world_nether
+	Size        int64    // including dimension folders
+	Version     string   // Minecraft version that last saved it
+	DataVersion int
+	LastPlayed  time.Time
+	Active      bool
+}
+
+// ValidateName rejects names that aren't safe as a folder and level-name
+func ValidateName(name string) error {
+	if !nameRegex.MatchString(name) {
+		return fmt.Errorf("invalid world name %q (letters, digits, '_', '.', '-')", name)
+	}
+	return nil
+}
+
+// ActiveName returns the level-name from server.properties
+func ActiveName(serverDir string) string {
+	properties, err := props.Load(filepath.Join(serverDir, "server.properties"))
+	if err != nil {
+		return "world"
+	}
+	return properties.GetDefault("level-name", "world")
+}
+
+// List returns the worlds in serverDir, active first
+func List(serverDir string) ([]World, error) {
+	entries, err := os.ReadDir(serverDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read server directory: %w", err)
+	}
+
+	dirs := map[string]bool{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(serverDir, entry.Name(), "level.dat")); err == nil {
+			dirs[entry.Name()] = true
+		}
+	}
+
+	active := ActiveName(serverDir)
+	var worlds []World
+	for name := range dirs {
+		if isDimensionOf(name, dirs) {
+			continue
+		}
+
+		w := World{
+			Name:   name,
+			Path:   filepath.Join(serverDir, name),
+			Active: name == active,
+		}
+		w.Size = dirSize(w.Path)
+		for _, suffix := range dimensionSuffixes {
+			if dirs[name+suffix] {
+				w.Dimensions = append(w.Dimensions, name+suffix)
+				w.Size += dirSize(filepath.Join(serverDir, name+suffix))
+			}
+		}
+		readLevelInfo(&w)
+		worlds = append(worlds, w)
+	}
+
+	sort.Slice(worlds, func(i, j int) bool {
+		if worlds[i].Active != worlds[j].Active {
+			return worlds[i].Active
+		}
+		return worlds[i].Name < worlds[j].Name
+	})
+	return worlds, nil
+}
+
+// Find returns the named world
+func Find(serverDir, name string) (*World, error) {
+	worlds, err := List(serverDir)
+	if err != nil {
+		return nil, err
+	}
+	for i := range worlds {
+		if worlds[i].Name == name {
+			return &worlds[i], nil
+		}
+	}
+	return nil, fmt.Errorf("world %q not found", name)
+}
+
+// SetActive points level-name at an existing world
+func SetActive(serverDir, name string) error {
+	if _, err := Find(serverDir, name); err != nil {
+		return err
+	}
+	return updateProperties(serverDir, map[string]string{"level-name": name})
+}
+
+// PrepareNew points level-name at a world that doesn't exist yet, so the
+// server generates it with the given seed and level type on next start
+func PrepareNew(serverDir, name, seed, levelType string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(serverDir, name)); err == nil {
+		return fmt.Errorf("%s already exists", name)
+	}
+
+	values := map[string]string{"level-name": name, "level-seed": seed}
+	if levelType != "" {
+		values["level-type"] = levelType
+	}
+	return updateProperties(serverDir, values)
+}
+
+// Archive zips a retired world (and its dimension folders) into archiveDir
+// and removes it from the server directory
+func Archive(serverDir, name, archiveDir string) (string, error) {
+	w, err := Find(serverDir, name)
+	if err != nil {
+		return "", err
+	}
+	if w.Active {
+		return "", fmt.Errorf("%s is the active world; switch to another world first", name)
+	}
+
+	if err := os.MkdirAll(archiveDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	archivePath := filepath.Join(archiveDir, fmt.Sprintf("%s_%s.zip", name, time.Now().Format("2006-01-02_15-04-05")))
+
+	folders := append([]string{name}, w.Dimensions...)
+	if err := zipFolders(archivePath, serverDir, folders, nil); err != nil {
+		os.Remove(archivePath)
+		return "", err
+	}
+
+	for _, folder := range folders {
+		if err := os.RemoveAll(filepath.Join(serverDir, folder)); err != nil {
+			return archivePath, fmt.Errorf("archived, but failed to remove %s: %w", folder, err)
+		}
+	}
+	return archivePath, nil
+}
+
+// isDimensionOf reports whether name is a sibling dimension folder of
+// another world in dirs
+func isDimensionOf(name string, dirs map[string]bool) bool {
+	for _, suffix := range dimensionSuffixes {
+		if base := strings.TrimSuffix(name, suffix); base != name && dirs[base] {
+			return true
+		}
+	}
+	return false
+}
+
+// readLevelInfo fills version details from level.dat, leaving them empty
+// if it can't be parsed
+func readLevelInfo(w *World) {
+	info, err := ReadInfo(w.Path)
+	if err != nil {
+		return
+	}
+	w.Version = info.Version
+	w.DataVersion = info.DataVersion
+	w.LastPlayed = info.LastPlayed
+}
+
+func updateProperties(serverDir string, values map[string]string) error {
+	path := filepath.Join(serverDir, "server.properties")
+	properties, err := props.Load(path)
+	if err != nil {
+		return err
+	}
+	for key, value := range values {
+		properties.Set(key, value)
+	}
+	return properties.Save(path)
+}
+
+func dirSize(path string) int64 {
+	var size int64
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// zipFolders writes folders (relative to baseDir) into a new zip, leaving
+// out session.lock and anything skip returns true for
+func zipFolders(archivePath, baseDir string, folders []string, skip func(rel string, info os.FileInfo) bool) error {
+	out, err := os.Create(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer out.Close()
+
+	zw := zip.NewWriter(out)
+	for _, folder := range folders {
+		root := filepath.Join(baseDir, folder)
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(baseDir, path)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if skip != nil && skip(rel, info) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.IsDir() || info.Name() == "session.lock" {
+				return nil
+			}
+			return addFile(zw, path, rel, info)
+		})
+		if err != nil {
+			zw.Close()
+			return fmt.Errorf("failed to archive %s: %w", folder, err)
+		}
+	}
+	return zw.Close()
+}
+
+func addFile(zw *zip.Writer, path, name string, info os.FileInfo) error {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,168 @@
+// Package eventbus is a small in-process publish/subscribe bus. Each
+// subscriber gets its own buffered channel, so a slow consumer only loses
+// its own values and never stalls the publisher or other subscribers.
+package eventbus
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBuffer is the channel size used when a subscriber does not pick one
+const DefaultBuffer = 256
+
+// Bus fans published values out to every current subscriber and keeps a
+// short backlog that new subscribers can ask to replay
+type Bus[T any] struct {
+	mu          sync.Mutex
+	subscribers map[*Subscription[T]]struct{}
+	backlog     []T
+	size        int
+	closed      bool
+}
+
+// New creates a bus that remembers the last backlog values
+func New[T any](backlog int) *Bus[T] {
+	return &Bus[T]{
+		subscribers: make(map[*Subscription[T]]struct{}),
+		backlog:     make([]T, 0, backlog),
+		size:        backlog,
+	}
+}
+
+// Subscription is one consumer's view of the bus
+type Subscription[T any] struct {
+	bus     *Bus[T]
+	ch      chan T
+	filter  func(T) bool
+	dropped atomic.Uint64
+}
+
+// Option configures a subscription
+type Option[T any] func(*subscribeOptions[T])
+
+type subscribeOptions[T any] struct {
+	buffer int
+	replay bool
+	filter func(T) bool
+}
+
+// WithBuffer sets the subscriber's channel size
+func WithBuffer[T any](n int) Option[T] {
+	return func(o *subscribeOptions[T]) { o.buffer = n }
+}
+
+// WithBacklog replays the bus backlog before live values
+func WithBacklog[T any]() Option[T] {
+	return func(o *subscribeOptions[T]) { o.replay = true }
+}
+
+// WithFilter delivers only values for which keep returns true
+func WithFilter[T any](keep func(T) bool) Option[T] {
+	return func(o *subscribeOptions[T]) { o.filter = keep }
+}
+
+// Subscribe registers a new consumer. Call Close when done with it.
+func (b *Bus[T]) Subscribe(opts ...Option[T]) *Subscription[T] {
+	o := subscribeOptions[T]{buffer: DefaultBuffer}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	buffer := o.buffer
+	if o.replay && buffer < len(b.backlog) {
+		buffer = len(b.backlog)
+	}
+	sub := &Subscription[T]{bus: b, ch: make(chan T, buffer), filter: o.filter}
+	if b.closed {
+		close(sub.ch)
+		return sub
+	}
+	if o.replay {
+		for _, v := range b.backlog {
+			sub.offer(v)
+		}
+	}
+	b.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Publish delivers v to every subscriber without blocking; subscribers
+// whose buffer is full miss it and count it as dropped
+func (b *Bus[T]) Publish(v T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	if b.size > 0 {
+		if len(b.backlog) >= b.size {
+			b.backlog = b.backlog[1:]
+		}
+		b.backlog = append(b.backlog, v)
+	}
+	for sub := range b.subscribers {
+		sub.offer(v)
+	}
+}
+
+// Close ends every subscription; later Publish calls are ignored
+func (b *Bus[T]) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for sub := range b.subscribers {
+		close(sub.ch)
+	}
+	b.subscribers = nil
+}
+
+// Backlog returns a copy of the remembered values, oldest first
+func (b *Bus[T]) Backlog() []T {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]T(nil), b.backlog...)
+}
+
+// offer is called with the bus lock held
+func (s *Subscription[T]) offer(v T) {
+	if s.filter != nil && !s.filter(v) {
+		return
+	}
+	select {
+	case s.ch <- v:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// C returns the channel values arrive on; it is closed by Close
+func (s *Subscription[T]) C() <-chan T {
+	return s.ch
+}
+
+// Dropped reports how many values this subscriber missed because its
+// buffer was full
+func (s *Subscription[T]) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close unsubscribes and closes the channel; it is safe to call twice
+func (s *Subscription[T]) Close() {
+	b := s.bus
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[s]; ok {
+		delete(b.subscribers, s)
+		close(s.ch)
+	}
+}
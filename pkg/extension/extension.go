@@ -0,0 +1,156 @@
+// Package extension is the stable interface for compiling custom
+// integrations into the manager, or loading them as Go plugins, without
+// touching its internal packages.
+//
+// An extension implements Extension plus any of EventHandler,
+// MetricsExporter, BackupTarget and ModSource, and registers itself from
+// an init function:
+//
+//	func init() {
+//		extension.Register(&myExporter{})
+//	}
+package extension
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Extension is implemented by everything that can be registered
+type Extension interface {
+	// Name identifies the extension in logs; it must be unique
+	Name() string
+}
+
+// Event is a server event as extensions see it
+type Event struct {
+	Time    time.Time
+	Type    string // info, warning, error, critical, join, leave, chat, whisper, emote, command, backup, restart or custom
+	Message string
+}
+
+// EventHandler receives every server event. Calls come from one goroutine
+// per handler, in order; a handler that falls behind misses events rather
+// than slowing the server.
+type EventHandler interface {
+	Extension
+	HandleEvent(event Event)
+}
+
+// Metrics is a snapshot of the server's performance counters
+type Metrics struct {
+	Time       time.Time
+	Status     string
+	Uptime     time.Duration
+	TPS        float64
+	MSPT       float64
+	MemoryUsed uint64
+	MemoryMax  uint64
+	CPUPercent float64
+	Players    int
+	MaxPlayers int
+
+	DiskReadRate  float64 // bytes per second
+	DiskWriteRate float64
+	DiskUtil      float64 // percent of time the server's disk was busy
+
+	// Deprecated: these were always the disk rates; use DiskReadRate and
+	// DiskWriteRate
+	BandwidthIn  float64
+	BandwidthOut float64
+}
+
+// MetricsExporter is handed a Metrics snapshot on a fixed interval
+type MetricsExporter interface {
+	Extension
+	ExportMetrics(metrics Metrics) error
+}
+
+// BackupTarget stores a copy of every completed backup elsewhere, e.g. in
+// object storage. path is the local backup zip.
+type BackupTarget interface {
+	Extension
+	StoreBackup(ctx context.Context, path string) error
+}
+
+// ModSource supplies modpacks from somewhere other than CurseForge. It is
+// used when --modpack is "<name>:<id>" and name matches the source.
+type ModSource interface {
+	Extension
+	// DownloadModpack saves the server pack zip for id at version
+	// ("latest" unless set) into destDir and returns its path; the
+	// manager installs it the same way as a CurseForge server pack
+	DownloadModpack(ctx context.Context, id, version, destDir string) (string, error)
+}
+
+var (
+	mu         sync.RWMutex
+	extensions = map[string]Extension{}
+)
+
+// Register makes an extension available. It panics if ext implements none
+// of the extension interfaces or its name is already taken, since both are
+// programming errors found at startup.
+func Register(ext Extension) {
+	switch ext.(type) {
+	case EventHandler, MetricsExporter, BackupTarget, ModSource:
+	default:
+		panic(fmt.Sprintf("extension: %s implements no extension interface", ext.Name()))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, dup := extensions[ext.Name()]; dup {
+		panic(fmt.Sprintf("extension: %s registered twice", ext.Name()))
+	}
+	extensions[ext.Name()] = ext
+}
+
+// All returns every registered extension, sorted by name
+func All() []Extension {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	all := make([]Extension, 0, len(extensions))
+	for _, ext := range extensions {
+		all = append(all, ext)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name() < all[j].Name() })
+	return all
+}
+
+// EventHandlers returns the registered extensions that handle events
+func EventHandlers() []EventHandler {
+	return filter[EventHandler]()
+}
+
+// MetricsExporters returns the registered extensions that export metrics
+func MetricsExporters() []MetricsExporter {
+	return filter[MetricsExporter]()
+}
+
+// BackupTargets returns the registered extensions that store backups
+func BackupTargets() []BackupTarget {
+	return filter[BackupTarget]()
+}
+
+// ModSourceFor returns the registered mod source called name
+func ModSourceFor(name string) (ModSource, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	src, ok := extensions[name].(ModSource)
+	return src, ok
+}
+
+func filter[T any]() []T {
+	var out []T
+	for _, ext := range All() {
+		if t, ok := ext.(T); ok {
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,30 @@
+//go:build (linux || darwin || freebsd) && cgo
+
+package extension
+
+import (
+	"fmt"
+	"path/filepath"
+	"plugin"
+	"sort"
+)
+
+// LoadPlugins opens every *.so in dir. Plugins register their extensions
+// from init, so opening them is all that is needed. A plugin must be
+// built with the same Go version and module versions as the manager.
+func LoadPlugins(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.so"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var loaded []string
+	for _, file := range files {
+		if _, err := plugin.Open(file); err != nil {
+			return loaded, fmt.Errorf("failed to load plugin %s: %w", filepath.Base(file), err)
+		}
+		loaded = append(loaded, filepath.Base(file))
+	}
+	return loaded, nil
+}
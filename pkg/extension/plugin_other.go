@@ -0,0 +1,18 @@
+//go:build !((linux || darwin || freebsd) && cgo)
+
+package extension
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// LoadPlugins needs Go's plugin support, which only exists in cgo builds
+// on Linux, macOS and FreeBSD; compile extensions in instead
+func LoadPlugins(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.so"))
+	if err != nil || len(files) == 0 {
+		return nil, err
+	}
+	return nil, fmt.Errorf("this build cannot load plugins (needs cgo on Linux, macOS or FreeBSD); compile the extensions in instead")
+}